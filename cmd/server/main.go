@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -17,11 +18,13 @@ import (
 	"github.com/threatflux/libgo/internal/api/handlers"
 	"github.com/threatflux/libgo/internal/auth/jwt"
 	"github.com/threatflux/libgo/internal/auth/user"
+	"github.com/threatflux/libgo/internal/backup"
 	"github.com/threatflux/libgo/internal/compute"
 	"github.com/threatflux/libgo/internal/config"
 	"github.com/threatflux/libgo/internal/database"
 	"github.com/threatflux/libgo/internal/docker"
 	"github.com/threatflux/libgo/internal/docker/container"
+	apierrors "github.com/threatflux/libgo/internal/errors"
 	"github.com/threatflux/libgo/internal/export"
 	"github.com/threatflux/libgo/internal/export/formats/ova"
 	"github.com/threatflux/libgo/internal/health"
@@ -34,14 +37,21 @@ import (
 	"github.com/threatflux/libgo/internal/middleware/auth"
 	"github.com/threatflux/libgo/internal/middleware/logging"
 	"github.com/threatflux/libgo/internal/middleware/recovery"
+	"github.com/threatflux/libgo/internal/migration"
 	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/internal/orphan"
 	"github.com/threatflux/libgo/internal/ovs"
+	"github.com/threatflux/libgo/internal/snapshot"
+	"github.com/threatflux/libgo/internal/upload"
 	"github.com/threatflux/libgo/internal/vm"
 	"github.com/threatflux/libgo/internal/vm/cloudinit"
 	"github.com/threatflux/libgo/internal/vm/template"
+	"github.com/threatflux/libgo/internal/vmimport"
+	"github.com/threatflux/libgo/internal/volume"
 	loggerPkg "github.com/threatflux/libgo/pkg/logger"
 	"github.com/threatflux/libgo/pkg/utils/exec"
 	"github.com/threatflux/libgo/pkg/utils/xmlutils"
+	"gorm.io/gorm"
 )
 
 // Build information.
@@ -99,29 +109,51 @@ func main() {
 		loggerPkg.String("commit", commit),
 		loggerPkg.String("buildDate", buildDate))
 
-	// Initialize libvirt connection manager
-	connManager, err := initLibvirt(cfg.Libvirt, log)
+	// Initialize libvirt host registry
+	hostRegistry, err := initLibvirt(cfg.Libvirt, log)
 	if err != nil {
 		log.Fatal("Failed to initialize libvirt connection", loggerPkg.Error(err))
 	}
-	defer connManager.Close()
+	defer hostRegistry.Close()
 
 	// Create context for dependency setup
 	ctx := context.Background()
 
+	// Track the health of all libvirt hosts
+	healthCtx, stopHealthChecks := context.WithCancel(ctx)
+	defer stopHealthChecks()
+	hostRegistry.Start(healthCtx, cfg.Libvirt.HealthCheckInterval)
+
 	// Initialize components
-	components, err := initComponents(ctx, cfg, connManager, log)
+	components, err := initComponents(ctx, cfg, hostRegistry, log)
 	if err != nil {
 		log.Error("Failed to initialize components", loggerPkg.Error(err))
 		return
 	}
 
+	// Run scheduled snapshot policies
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	components.SnapshotPolicyManager.Start(schedulerCtx, snapshot.DefaultCheckInterval)
+
 	// Ensure storage pool exists
-	if poolErr := ensureStoragePool(ctx, components.PoolManager, cfg, log); poolErr != nil {
+	if poolErr := ensureStoragePool(ctx, components.PoolManager, components.ImageLibrary, cfg, log); poolErr != nil {
 		log.Error("Failed to ensure storage pool", loggerPkg.Error(poolErr))
 		return
 	}
 
+	// Track the capacity of the storage pools
+	capacityCtx, stopCapacityMonitor := context.WithCancel(ctx)
+	defer stopCapacityMonitor()
+	components.CapacityMonitor.Start(capacityCtx, cfg.Storage.Capacity.CheckInterval)
+
+	// Collect orphaned VM disk volumes and cloud-init ISOs
+	if cfg.Storage.Orphans.Enabled {
+		orphanCtx, stopOrphanCollector := context.WithCancel(ctx)
+		defer stopOrphanCollector()
+		components.OrphanCollector.Start(orphanCtx, cfg.Storage.Orphans.Interval)
+	}
+
 	// Initialize default users if configured
 	log.Info("Default users configuration",
 		loggerPkg.Int("count", len(cfg.Auth.DefaultUsers)),
@@ -208,15 +240,16 @@ func initLogger(config config.LoggingConfig) (loggerPkg.Logger, error) {
 	return log, nil
 }
 
-// initLibvirt initializes libvirt connections.
-func initLibvirt(config config.LibvirtConfig, logger loggerPkg.Logger) (connection.Manager, error) {
-	// Create connection manager
-	connManager, err := connection.NewConnectionManager(config, logger)
+// initLibvirt initializes libvirt connections to all configured hosts.
+func initLibvirt(config config.LibvirtConfig, logger loggerPkg.Logger) (*connection.Registry, error) {
+	// Create a connection pool per host
+	connManager, err := connection.NewRegistry(config, logger)
 	if err != nil {
-		return nil, fmt.Errorf("creating libvirt connection manager: %w", err)
+		return nil, fmt.Errorf("creating libvirt host registry: %w", err)
 	}
 
-	// Test connection
+	// Test connection to the default host; other hosts are tracked by the
+	// health checks so one unreachable hypervisor does not block startup
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -235,6 +268,7 @@ func initLibvirt(config config.LibvirtConfig, logger loggerPkg.Logger) (connecti
 type ComponentDependencies struct {
 	// Connection managers
 	ConnManager    connection.Manager
+	HostRegistry   *connection.Registry
 	DomainManager  domain.Manager
 	StorageManager storage.VolumeManager
 	PoolManager    storage.PoolManager
@@ -255,6 +289,37 @@ type ComponentDependencies struct {
 	// Export
 	ExportManager export.Manager
 
+	// Migration
+	MigrationManager migration.Manager
+
+	// Backups
+	BackupManager backup.Manager
+
+	// VM imports
+	ImportManager vmimport.Manager
+
+	// Resumable volume uploads
+	UploadManager upload.Manager
+
+	// Volume downloads, resizes, clones and wipes
+	VolumeManager volume.Manager
+	ImageLibrary  storage.ImageLibrary
+
+	// Passphrases of encrypted volumes; nil without a master key
+	VolumeKeys storage.VolumeKeyManager
+
+	// Storage pool capacity
+	CapacityMonitor storage.CapacityMonitor
+
+	// Orphaned VM disk volumes and cloud-init ISOs
+	OrphanCollector orphan.Manager
+
+	// Scheduled snapshots
+	SnapshotPolicyManager snapshot.Manager
+
+	// Database
+	Database *gorm.DB
+
 	// Authentication
 	UserService  user.Service
 	JWTGenerator jwt.Generator
@@ -271,10 +336,12 @@ type ComponentDependencies struct {
 }
 
 // initComponents initializes all application components.
-func initComponents(ctx context.Context, cfg *config.Config, connManager connection.Manager, log loggerPkg.Logger) (*ComponentDependencies, error) {
+func initComponents(ctx context.Context, cfg *config.Config, hostRegistry *connection.Registry, log loggerPkg.Logger) (*ComponentDependencies, error) {
 	components := &ComponentDependencies{
-		ConnManager: connManager,
+		ConnManager:  hostRegistry,
+		HostRegistry: hostRegistry,
 	}
+	connManager := components.ConnManager
 
 	// Initialize libvirt components
 	if err := initLibvirtComponents(ctx, components, cfg, connManager, log); err != nil {
@@ -284,7 +351,7 @@ func initComponents(ctx context.Context, cfg *config.Config, connManager connect
 	// All components (VM, auth, Docker, compute) are handled in initLibvirtComponents
 
 	// Initialize metrics
-	if err := initMetricsComponents(ctx, components, log); err != nil {
+	if err := initMetricsComponents(ctx, components, cfg, log); err != nil {
 		return nil, fmt.Errorf("initializing metrics: %w", err)
 	}
 
@@ -293,10 +360,14 @@ func initComponents(ctx context.Context, cfg *config.Config, connManager connect
 
 // initLibvirtComponents initializes libvirt-related components.
 func initLibvirtComponents(ctx context.Context, components *ComponentDependencies, cfg *config.Config, connManager connection.Manager, log loggerPkg.Logger) error {
-	if err := initLibvirtManagers(components, cfg, connManager, log); err != nil {
+	// The database is needed by the image library the VM manager uses
+	if err := initAuthComponents(components, cfg, log); err != nil {
 		return err
 	}
-	if err := initAuthComponents(components, cfg, log); err != nil {
+	if err := initVolumeKeys(ctx, components, cfg, connManager, log); err != nil {
+		return err
+	}
+	if err := initLibvirtManagers(components, cfg, connManager, log); err != nil {
 		return err
 	}
 	if err := initDockerManager(components, cfg, log); err != nil {
@@ -305,6 +376,41 @@ func initLibvirtComponents(ctx context.Context, components *ComponentDependencie
 	if err := initComputeManager(ctx, components, cfg, log); err != nil {
 		return err
 	}
+	if err := initSnapshotPolicyManager(components, log); err != nil {
+		return err
+	}
+	return nil
+}
+
+// initVolumeKeys initializes the passphrase store of encrypted volumes when
+// a master key is configured, moving passphrases stored under previous master
+// keys to the current one.
+func initVolumeKeys(ctx context.Context, components *ComponentDependencies, cfg *config.Config, connManager connection.Manager, log loggerPkg.Logger) error {
+	if cfg.Storage.Encryption.MasterKey == "" {
+		return nil
+	}
+
+	keyCipher, err := storage.NewKeyCipher(cfg.Storage.Encryption.MasterKey, cfg.Storage.Encryption.PreviousMasterKeys)
+	if err != nil {
+		return fmt.Errorf("creating volume key cipher: %w", err)
+	}
+	keyStore, err := storage.NewGormKeyStore(components.Database)
+	if err != nil {
+		return fmt.Errorf("creating volume key store: %w", err)
+	}
+	keyManager := storage.NewLibvirtKeyManager(
+		connManager,
+		keyStore,
+		keyCipher,
+		storage.KeyManagerConfig{DefaultHost: components.HostRegistry.DefaultHost()},
+		log,
+	)
+
+	if _, err := keyManager.Rewrap(ctx); err != nil {
+		return fmt.Errorf("encrypting volume keys under the current master key: %w", err)
+	}
+
+	components.VolumeKeys = keyManager
 	return nil
 }
 
@@ -325,7 +431,24 @@ func initLibvirtManagers(components *ComponentDependencies, cfg *config.Config,
 	}
 	storageXMLBuilder := storage.NewTemplateXMLBuilder(storageXMLLoader, log)
 	components.PoolManager = storage.NewLibvirtPoolManager(connManager, storageXMLBuilder, log)
-	components.StorageManager = storage.NewLibvirtVolumeManager(connManager, components.PoolManager, storageXMLBuilder, log)
+	volumeManager := storage.NewLibvirtVolumeManager(
+		connManager,
+		components.PoolManager,
+		storageXMLBuilder,
+		components.VolumeKeys,
+		storage.VolumeManagerConfig{MaxOvercommit: cfg.Storage.Capacity.MaxOvercommit},
+		log,
+	)
+	recordStore, err := storage.NewGormRecordStore(components.Database)
+	if err != nil {
+		return fmt.Errorf("creating volume record store: %w", err)
+	}
+	components.StorageManager = storage.NewRecordingVolumeManager(
+		volumeManager,
+		recordStore,
+		storage.VolumeRecorderConfig{DefaultHost: components.HostRegistry.DefaultHost()},
+		log,
+	)
 
 	// Initialize network components
 	networkXMLLoader, err := xmlutils.NewTemplateLoader(filepath.Join(cfg.TemplatesPath, "network"))
@@ -370,6 +493,7 @@ func initVMComponents(components *ComponentDependencies, cfg *config.Config, log
 	components.ExportManager, err = export.NewExportManager(
 		components.StorageManager,
 		components.DomainManager,
+		components.HostRegistry,
 		cfg.Export.OutputDir,
 		log,
 	)
@@ -377,12 +501,114 @@ func initVMComponents(components *ComponentDependencies, cfg *config.Config, log
 		return fmt.Errorf("creating export manager: %w", err)
 	}
 
+	// Initialize migration manager
+	components.MigrationManager = migration.NewMigrationManager(
+		components.DomainManager,
+		components.StorageManager,
+		migration.NewLibvirtTargetConnector(cfg.Libvirt.ConnectionTimeout, log),
+		log,
+	)
+
+	// Initialize backup manager
+	backupDir := cfg.Backup.RepositoryDir
+	if backupDir == "" {
+		backupDir = "/var/lib/libgo/backups"
+	}
+	backupScratchDir := cfg.Backup.ScratchDir
+	if backupScratchDir == "" {
+		backupScratchDir = filepath.Join(cfg.Export.TempDir, "backups")
+	}
+	backupRepository, err := backup.NewRepository(backupDir)
+	if err != nil {
+		return fmt.Errorf("opening backup repository: %w", err)
+	}
+	components.BackupManager = backup.NewBackupManager(
+		backupRepository,
+		components.DomainManager,
+		components.StorageManager,
+		components.HostRegistry,
+		backup.Config{
+			ScratchDir:  backupScratchDir,
+			DefaultPool: cfg.Libvirt.PoolName,
+		},
+		log,
+	)
+
+	// Initialize import manager
+	importSourceDir := cfg.Import.SourceDir
+	if importSourceDir == "" {
+		importSourceDir = "/var/lib/libgo/imports"
+	}
+	importScratchDir := cfg.Import.ScratchDir
+	if importScratchDir == "" {
+		importScratchDir = filepath.Join(cfg.Export.TempDir, "imports")
+	}
+	components.ImportManager = vmimport.NewImportManager(
+		components.DomainManager,
+		components.StorageManager,
+		components.HostRegistry,
+		vmimport.Config{
+			SourceDir:      importSourceDir,
+			ScratchDir:     importScratchDir,
+			DefaultPool:    cfg.Libvirt.PoolName,
+			DefaultNetwork: cfg.Libvirt.NetworkName,
+		},
+		log,
+	)
+
+	// Initialize upload manager
+	uploadScratchDir := cfg.Upload.ScratchDir
+	if uploadScratchDir == "" {
+		uploadScratchDir = filepath.Join(cfg.Export.TempDir, "uploads")
+	}
+	components.UploadManager = upload.NewUploadManager(
+		components.StorageManager,
+		upload.Config{
+			ScratchDir:  uploadScratchDir,
+			DefaultPool: cfg.Libvirt.PoolName,
+			Expiry:      cfg.Upload.Expiry,
+		},
+		log,
+	)
+
+	// Initialize volume manager
+	components.VolumeManager = volume.NewVolumeManager(
+		components.StorageManager,
+		components.DomainManager,
+		components.VolumeKeys,
+		components.HostRegistry,
+		volume.Config{ScratchDir: filepath.Join(cfg.Export.TempDir, "volume-downloads")},
+		log,
+	)
+
+	// Initialize image library
+	imageStore, err := storage.NewGormImageStore(components.Database)
+	if err != nil {
+		return fmt.Errorf("creating image store: %w", err)
+	}
+	imageSourceDir := cfg.Images.SourceDir
+	if imageSourceDir == "" {
+		imageSourceDir = "/var/lib/libgo/images"
+	}
+	components.ImageLibrary = storage.NewImageLibrary(
+		components.StorageManager,
+		imageStore,
+		storage.ImageLibraryConfig{
+			DefaultPool: cfg.Libvirt.PoolName,
+			DefaultHost: components.HostRegistry.DefaultHost(),
+			SourceDir:   imageSourceDir,
+		},
+		log,
+	)
+
 	// Initialize VM manager
 	vmConfig := vm.Config{
-		StoragePoolName: cfg.Libvirt.PoolName,
-		NetworkName:     cfg.Libvirt.NetworkName,
-		WorkDir:         filepath.Join(cfg.Export.TempDir, "vms"),
-		CloudInitDir:    filepath.Join(cfg.Export.TempDir, "cloudinit"),
+		StoragePoolName:   cfg.Libvirt.PoolName,
+		NetworkName:       cfg.Libvirt.NetworkName,
+		WorkDir:           filepath.Join(cfg.Export.TempDir, "vms"),
+		CloudInitDir:      filepath.Join(cfg.Export.TempDir, "cloudinit"),
+		SharedFolderPaths: cfg.Libvirt.SharedFolderPaths,
+		ShutdownTimeout:   cfg.Libvirt.ShutdownTimeout,
 	}
 
 	components.VMManager = vm.NewVMManager(
@@ -391,10 +617,31 @@ func initVMComponents(components *ComponentDependencies, cfg *config.Config, log
 		components.NetworkManager,
 		components.TemplateManager,
 		components.CloudInitManager,
+		components.ImageLibrary,
+		components.HostRegistry,
 		vmConfig,
 		log,
 	)
 
+	// Initialize the collector of volumes and ISOs left behind by VMs
+	orphanHosts := make([]string, 0)
+	for _, host := range components.HostRegistry.Hosts() {
+		orphanHosts = append(orphanHosts, host.Name)
+	}
+	components.OrphanCollector = orphan.NewCollector(
+		components.DomainManager,
+		components.PoolManager,
+		components.StorageManager,
+		components.ImageLibrary,
+		orphan.Config{
+			Hosts:        orphanHosts,
+			DefaultHost:  components.HostRegistry.DefaultHost(),
+			CloudInitDir: vmConfig.CloudInitDir,
+			GracePeriod:  cfg.Storage.Orphans.GracePeriod,
+		},
+		log,
+	)
+
 	return nil
 }
 
@@ -405,6 +652,7 @@ func initAuthComponents(components *ComponentDependencies, cfg *config.Config, l
 	if err != nil {
 		return fmt.Errorf("initializing database connection: %w", err)
 	}
+	components.Database = db
 
 	// Initialize authentication components
 	components.UserService, err = user.NewGormUserService(db, log)
@@ -469,12 +717,15 @@ func initComputeManager(ctx context.Context, components *ComponentDependencies,
 		HealthCheckInterval: cfg.Compute.HealthCheckInterval,
 		MetricsInterval:     cfg.Compute.MetricsCollectionInterval,
 		EnableQuotas:        true, // Enable quotas by default
+
+		CPUOvercommitRatio:    cfg.Compute.ResourceLimits.CPUOvercommitRatio,
+		MemoryOvercommitRatio: cfg.Compute.ResourceLimits.MemoryOvercommitRatio,
 	}
 
 	components.ComputeManager = compute.NewComputeManager(computeConfig, log)
 
 	// Register KVM backend through VM manager wrapper
-	kvmBackend := NewKVMBackendAdapter(components.VMManager, log)
+	kvmBackend := NewKVMBackendAdapter(components.VMManager, components.MigrationManager, components.ImportManager, components.HostRegistry, components.OVSManager, log)
 	if concreteManager, ok := components.ComputeManager.(*compute.ComputeManager); ok {
 		if kvmErr := concreteManager.RegisterBackend(compute.BackendKVM, kvmBackend); kvmErr != nil {
 			return fmt.Errorf("registering KVM backend: %w", kvmErr)
@@ -515,9 +766,38 @@ func initComputeManager(ctx context.Context, components *ComponentDependencies,
 	return nil
 }
 
+// initSnapshotPolicyManager initializes scheduled snapshot policies.
+func initSnapshotPolicyManager(components *ComponentDependencies, log loggerPkg.Logger) error {
+	store, err := snapshot.NewGormStore(components.Database)
+	if err != nil {
+		return fmt.Errorf("initializing snapshot policy store: %w", err)
+	}
+
+	components.SnapshotPolicyManager = snapshot.NewPolicyManager(store, components.VMManager, components.ComputeManager, log)
+	return nil
+}
+
 // initMetricsComponents initializes metrics components.
-func initMetricsComponents(ctx context.Context, components *ComponentDependencies, log loggerPkg.Logger) error {
-	// Metrics components are already initialized in initLibvirtComponents
+func initMetricsComponents(ctx context.Context, components *ComponentDependencies, cfg *config.Config, log loggerPkg.Logger) error {
+	// The metrics collector is already initialized in initLibvirtComponents
+
+	// Sample the storage pools of all hosts
+	hosts := make([]string, 0)
+	for _, host := range components.HostRegistry.Hosts() {
+		hosts = append(hosts, host.Name)
+	}
+	components.CapacityMonitor = storage.NewCapacityMonitor(
+		components.PoolManager,
+		components.MetricsCollector,
+		storage.CapacityMonitorConfig{
+			Hosts:          hosts,
+			DefaultHost:    components.HostRegistry.DefaultHost(),
+			AlertThreshold: cfg.Monitoring.ResourceAlerts.DiskThreshold,
+			HistorySize:    cfg.Storage.Capacity.HistorySize,
+		},
+		log,
+	)
+
 	return nil
 }
 
@@ -543,6 +823,12 @@ func setupRoutes(server *api.Server, components *ComponentDependencies, healthCh
 	// Create API handlers
 	vmHandler := handlers.NewVMHandler(components.VMManager, log)
 	exportHandler := handlers.NewExportHandler(components.VMManager, components.ExportManager, log)
+	migrationHandler := handlers.NewMigrationHandler(components.MigrationManager, log)
+	snapshotPolicyHandler := handlers.NewSnapshotPolicyHandler(components.SnapshotPolicyManager, log)
+	backupHandler := handlers.NewBackupHandler(components.BackupManager, log)
+	importHandler := handlers.NewImportHandler(components.ImportManager, log)
+	uploadHandler := handlers.NewUploadHandler(components.UploadManager, log)
+	hostHandler := handlers.NewHostHandler(components.HostRegistry, log)
 	authHandler := handlers.NewAuthHandler(components.UserService, components.JWTGenerator, log, cfg.Auth.TokenExpiration)
 	healthHandler := handlers.NewHealthHandler(healthChecker, log)
 	metricsHandler := handlers.NewMetricsHandler(components.MetricsCollector, log)
@@ -575,10 +861,37 @@ func setupRoutes(server *api.Server, components *ComponentDependencies, healthCh
 		DeletePool:   handlers.NewStorageDeleteHandler(components.PoolManager, log),
 		StartPool:    handlers.NewStorageStartHandler(components.PoolManager, log),
 		StopPool:     handlers.NewStorageStopHandler(components.PoolManager, log),
+		ListCapacity: handlers.NewStorageCapacityHandler(components.CapacityMonitor, log),
+		PoolCapacity: handlers.NewStoragePoolCapacityHandler(components.CapacityMonitor, log),
 		ListVolumes:  handlers.NewStorageVolumeListHandler(components.StorageManager, log),
 		CreateVolume: handlers.NewStorageVolumeCreateHandler(components.StorageManager, log),
 		DeleteVolume: handlers.NewStorageVolumeDeleteHandler(components.StorageManager, log),
 		UploadVolume: handlers.NewStorageVolumeUploadHandler(components.StorageManager, log),
+
+		GetVolume:       handlers.NewStorageVolumeGetHandler(components.StorageManager, log),
+		GetVolumeXML:    handlers.NewStorageVolumeXMLHandler(components.StorageManager, log),
+		DownloadVolume:  handlers.NewStorageVolumeDownloadHandler(components.VolumeManager, components.StorageManager, log),
+		ResizeVolume:    handlers.NewStorageVolumeResizeHandler(components.VolumeManager, components.StorageManager, log),
+		CloneVolume:     handlers.NewStorageVolumeCloneHandler(components.VolumeManager, components.StorageManager, log),
+		WipeVolume:      handlers.NewStorageVolumeWipeHandler(components.VolumeManager, components.StorageManager, log),
+		RotateVolumeKey: handlers.NewStorageVolumeRotateKeyHandler(components.VolumeManager, components.StorageManager, log),
+		VolumeImageInfo: handlers.NewStorageVolumeImageInfoHandler(components.VolumeManager, components.StorageManager, log),
+		CheckVolume:     handlers.NewStorageVolumeCheckHandler(components.VolumeManager, components.StorageManager, log),
+		ConvertVolume:   handlers.NewStorageVolumeConvertHandler(components.VolumeManager, components.StorageManager, log),
+		RebaseVolume:    handlers.NewStorageVolumeRebaseHandler(components.VolumeManager, components.StorageManager, log),
+		ListVolumeJobs:  handlers.NewStorageVolumeJobListHandler(components.VolumeManager, log),
+		GetVolumeJob:    handlers.NewStorageVolumeJobGetHandler(components.VolumeManager, log),
+		CancelVolumeJob: handlers.NewStorageVolumeJobCancelHandler(components.VolumeManager, log),
+
+		ListImages:    handlers.NewStorageImageListHandler(components.ImageLibrary, log),
+		RegisterImage: handlers.NewStorageImageRegisterHandler(components.ImageLibrary, components.StorageManager, cfg.Libvirt.PoolName, log),
+		GetImage:      handlers.NewStorageImageGetHandler(components.ImageLibrary, log),
+		UpdateImage:   handlers.NewStorageImageUpdateHandler(components.ImageLibrary, log),
+		DeleteImage:   handlers.NewStorageImageDeleteHandler(components.ImageLibrary, log),
+
+		ListOrphans:     handlers.NewStorageOrphanListHandler(components.OrphanCollector, log),
+		PurgeOrphan:     handlers.NewStorageOrphanPurgeHandler(components.OrphanCollector, log),
+		PurgeAllOrphans: handlers.NewStorageOrphanPurgeAllHandler(components.OrphanCollector, log),
 	}
 
 	// Create OVS handlers
@@ -638,6 +951,12 @@ func setupRoutes(server *api.Server, components *ComponentDependencies, healthCh
 		components.RoleMiddleware,
 		vmHandler,
 		exportHandler,
+		migrationHandler,
+		snapshotPolicyHandler,
+		backupHandler,
+		importHandler,
+		uploadHandler,
+		hostHandler,
 		authHandler,
 		healthHandler,
 		metricsHandler,
@@ -696,7 +1015,7 @@ func setupSignalHandler(server *api.Server, log loggerPkg.Logger) chan os.Signal
 }
 
 // ensureStoragePool ensures the required storage pool exists and is active.
-func ensureStoragePool(ctx context.Context, poolManager storage.PoolManager, cfg *config.Config, log loggerPkg.Logger) error {
+func ensureStoragePool(ctx context.Context, poolManager storage.PoolManager, imageLibrary storage.ImageLibrary, cfg *config.Config, log loggerPkg.Logger) error {
 	poolName, poolPath := resolvePoolConfiguration(cfg, log)
 
 	if err := createPoolDirectory(poolPath); err != nil {
@@ -707,7 +1026,8 @@ func ensureStoragePool(ctx context.Context, poolManager storage.PoolManager, cfg
 		return fmt.Errorf("failed to ensure storage pool %s exists: %w", poolName, err)
 	}
 
-	return copyTemplateImages(cfg.Storage.Templates, poolPath, log)
+	registerTemplateImages(ctx, imageLibrary, cfg.Storage.Templates, poolName, log)
+	return nil
 }
 
 // resolvePoolConfiguration resolves pool name and path from configuration.
@@ -738,68 +1058,53 @@ func createPoolDirectory(poolPath string) error {
 	return nil
 }
 
-// copyTemplateImages copies template images to the storage pool.
-func copyTemplateImages(templates map[string]string, poolPath string, log loggerPkg.Logger) error {
+// registerTemplateImages registers template images as read-only images of
+// the image library, importing them into the storage pool.
+func registerTemplateImages(ctx context.Context, imageLibrary storage.ImageLibrary, templates map[string]string, poolName string, log loggerPkg.Logger) {
 	for templateName, imagePath := range templates {
-		if err := copyTemplateImage(templateName, imagePath, poolPath, log); err != nil {
+		if err := registerTemplateImage(ctx, imageLibrary, templateName, imagePath, poolName, log); err != nil {
 			// Log error but continue with other templates
-			log.Warn("Failed to copy template image",
+			log.Warn("Failed to register template image",
 				loggerPkg.String("template", templateName),
 				loggerPkg.Error(err))
 		}
 	}
-	return nil
 }
 
-// copyTemplateImage copies a single template image to the pool.
-func copyTemplateImage(templateName, imagePath, poolPath string, log loggerPkg.Logger) error {
+// registerTemplateImage registers a single template image unless it is
+// already part of the image library.
+func registerTemplateImage(ctx context.Context, imageLibrary storage.ImageLibrary, templateName, imagePath, poolName string, log loggerPkg.Logger) error {
+	if _, err := imageLibrary.Get(ctx, templateName); err == nil {
+		return nil // Already registered
+	} else if !errors.Is(err, apierrors.ErrImageNotFound) {
+		return err
+	}
+
 	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-		log.Warn("Template image not found, skipping copy",
+		log.Warn("Template image not found, skipping registration",
 			loggerPkg.String("template", templateName),
 			loggerPkg.String("path", imagePath))
 		return nil
 	}
 
-	destPath := filepath.Join(poolPath, filepath.Base(imagePath))
-	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
-		return nil // Destination already exists
-	}
-
-	log.Info("Copying template image to storage pool",
+	log.Info("Registering template image in storage pool",
 		loggerPkg.String("template", templateName),
 		loggerPkg.String("source", imagePath),
-		loggerPkg.String("destination", destPath))
-
-	return performImageCopy(imagePath, destPath, templateName, log)
-}
-
-// performImageCopy performs the actual file copy operation.
-func performImageCopy(imagePath, destPath, templateName string, log loggerPkg.Logger) error {
-	src, err := os.Open(imagePath)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer src.Close()
-
-	dst, err := os.Create(destPath)
+		loggerPkg.String("pool", poolName))
+
+	image, err := imageLibrary.Register(ctx, &storage.RegisterImageParams{
+		Name:        templateName,
+		Description: fmt.Sprintf("Template image %s", filepath.Base(imagePath)),
+		SourcePath:  imagePath,
+		Pool:        poolName,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer dst.Close()
-
-	if err := dst.Chmod(0666); err != nil {
-		log.Warn("Failed to set permissions on destination file",
-			loggerPkg.String("path", destPath),
-			loggerPkg.Error(err))
-	}
-
-	if _, err := io.Copy(dst, src); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+		return err
 	}
 
-	log.Info("Template image copied successfully",
+	log.Info("Template image registered successfully",
 		loggerPkg.String("template", templateName),
-		loggerPkg.String("destination", destPath))
+		loggerPkg.String("path", image.Path))
 
 	return nil
 }
@@ -823,17 +1128,25 @@ func convertConfigResourceLimits(limits config.ResourceLimits) compute.ComputeRe
 }
 
 // NewKVMBackendAdapter creates an adapter that wraps the VM manager to implement the BackendService interface.
-func NewKVMBackendAdapter(vmManager vm.Manager, logger loggerPkg.Logger) compute.BackendService {
+func NewKVMBackendAdapter(vmManager vm.Manager, migrationManager migration.Manager, importManager vmimport.Manager, hostRegistry *connection.Registry, ovsManager ovs.Manager, logger loggerPkg.Logger) compute.BackendService {
 	return &kvmBackendAdapter{
-		vmManager: vmManager,
-		logger:    logger,
+		vmManager:        vmManager,
+		migrationManager: migrationManager,
+		importManager:    importManager,
+		hostRegistry:     hostRegistry,
+		ovsManager:       ovsManager,
+		logger:           logger,
 	}
 }
 
 // kvmBackendAdapter adapts the VM manager to the compute backend interface.
 type kvmBackendAdapter struct {
-	vmManager vm.Manager
-	logger    loggerPkg.Logger
+	vmManager        vm.Manager
+	migrationManager migration.Manager
+	importManager    vmimport.Manager
+	hostRegistry     *connection.Registry
+	ovsManager       ovs.Manager
+	logger           loggerPkg.Logger
 }
 
 // Create creates a new KVM instance.
@@ -841,6 +1154,11 @@ func (a *kvmBackendAdapter) Create(ctx context.Context, req compute.ComputeInsta
 	// Convert compute request to VM request
 	vmReq := a.convertToVMRequest(req)
 
+	// Create the VM on the host chosen by placement
+	if req.Host != "" {
+		ctx = connection.WithHost(ctx, req.Host)
+	}
+
 	// Create VM
 	vmInstance, err := a.vmManager.Create(ctx, vmReq)
 	if err != nil {
@@ -848,7 +1166,9 @@ func (a *kvmBackendAdapter) Create(ctx context.Context, req compute.ComputeInsta
 	}
 
 	// Convert VM to compute instance
-	return a.convertFromVM(vmInstance), nil
+	instance := a.convertFromVM(vmInstance)
+	instance.Host = req.Host
+	return instance, nil
 }
 
 // Get retrieves a KVM instance by ID.
@@ -895,22 +1215,195 @@ func (a *kvmBackendAdapter) Start(ctx context.Context, id string) error {
 
 // Stop stops a KVM instance.
 func (a *kvmBackendAdapter) Stop(ctx context.Context, id string, force bool) error {
-	return a.vmManager.Stop(ctx, id)
+	_, err := a.vmManager.Shutdown(ctx, id, vmmodels.ShutdownOptions{Force: force})
+	return err
 }
 
 // Restart restarts a KVM instance.
 func (a *kvmBackendAdapter) Restart(ctx context.Context, id string, force bool) error {
-	return a.vmManager.Restart(ctx, id)
+	_, err := a.vmManager.Restart(ctx, id, vmmodels.ShutdownOptions{Force: force})
+	return err
 }
 
 // Pause pauses a KVM instance.
 func (a *kvmBackendAdapter) Pause(ctx context.Context, id string) error {
-	return fmt.Errorf("pause not implemented for KVM backend")
+	return a.vmManager.Pause(ctx, id)
 }
 
 // Unpause unpauses a KVM instance.
 func (a *kvmBackendAdapter) Unpause(ctx context.Context, id string) error {
-	return fmt.Errorf("unpause not implemented for KVM backend")
+	return a.vmManager.Resume(ctx, id)
+}
+
+// AttachConsole attaches to the serial or graphical console of a KVM instance.
+func (a *kvmBackendAdapter) AttachConsole(ctx context.Context, id string, opts compute.ConsoleOptions) (io.ReadWriteCloser, error) {
+	switch opts.Type {
+	case "", "serial":
+		console, err := a.vmManager.OpenConsole(ctx, id, vmmodels.ConsoleOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open VM console: %w", err)
+		}
+
+		if opts.Width > 0 && opts.Width <= math.MaxUint16 && opts.Height > 0 && opts.Height <= math.MaxUint16 {
+			if err := console.Resize(uint16(opts.Width), uint16(opts.Height)); err != nil {
+				a.logger.Warn("Failed to resize VM console",
+					loggerPkg.String("id", id),
+					loggerPkg.Error(err))
+			}
+		}
+
+		return console, nil
+	case string(vmmodels.GraphicsTypeVNC), string(vmmodels.GraphicsTypeSPICE):
+		conn, err := a.vmManager.OpenGraphics(ctx, id, vmmodels.GraphicsType(opts.Type))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s console: %w", opts.Type, err)
+		}
+		return conn, nil
+	default:
+		return nil, fmt.Errorf("unsupported console type for KVM backend: %s", opts.Type)
+	}
+}
+
+// Migrate starts migrating a KVM instance to the libvirt host at targetHost.
+// Storage copy and post-copy are requested with the "copy-storage-all" and
+// "postcopy" flags.
+func (a *kvmBackendAdapter) Migrate(ctx context.Context, id, targetHost string, opts compute.MigrationOptions) error {
+	if a.migrationManager == nil {
+		return fmt.Errorf("migration not configured for KVM backend")
+	}
+
+	params := migration.Params{
+		TargetURI:  targetHost,
+		Timeout:    opts.Timeout,
+		Live:       opts.Live,
+		Offline:    opts.Offline,
+		Persistent: opts.Persistent,
+		Undefine:   opts.Undefine,
+		Compressed: opts.Compressed,
+	}
+	if opts.Bandwidth > 0 {
+		params.Bandwidth = uint64(opts.Bandwidth)
+	}
+	for _, flag := range opts.Flags {
+		switch flag {
+		case "copy-storage-all":
+			params.CopyStorage = true
+		case "postcopy":
+			params.PostCopy = true
+		default:
+			return fmt.Errorf("unsupported migration flag: %s", flag)
+		}
+	}
+
+	job, err := a.migrationManager.CreateMigrationJob(ctx, id, params)
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("Started KVM instance migration",
+		loggerPkg.String("id", id),
+		loggerPkg.String("target", targetHost),
+		loggerPkg.String("job_id", job.ID))
+
+	return nil
+}
+
+// Import creates a KVM instance from an OVA archive, OVF descriptor or disk
+// image in the import source directory. The "pool", "network", "disk_bus",
+// "nic_model" and "firmware" parameters set the matching import parameters;
+// network attachments map the networks of the appliance, by name, to
+// libvirt networks.
+func (a *kvmBackendAdapter) Import(ctx context.Context, source string, opts compute.ImportOptions) (*compute.ComputeInstance, error) {
+	if a.importManager == nil {
+		return nil, fmt.Errorf("import not configured for KVM backend")
+	}
+
+	params := vmimport.Params{
+		Name:     opts.Name,
+		Pool:     opts.Parameters["pool"],
+		Network:  opts.Parameters["network"],
+		DiskBus:  opts.Parameters["disk_bus"],
+		NICModel: opts.Parameters["nic_model"],
+		Firmware: opts.Parameters["firmware"],
+		Start:    opts.AutoStart,
+	}
+	if len(opts.Networks) > 0 {
+		params.Networks = make(map[string]string, len(opts.Networks))
+		for _, network := range opts.Networks {
+			params.Networks[network.Name] = network.Network
+		}
+	}
+	if opts.Resources != nil {
+		params.CPUs = int(opts.Resources.CPU.Cores)
+		if opts.Resources.Memory.Limit > 0 {
+			params.MemoryBytes = uint64(opts.Resources.Memory.Limit)
+		}
+	}
+
+	vmInstance, err := a.importManager.Import(ctx, source, params)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("Imported KVM instance",
+		loggerPkg.String("name", vmInstance.Name),
+		loggerPkg.String("source", source))
+
+	return a.convertFromVM(vmInstance), nil
+}
+
+// GetHostCapacity reports the capacity and health of each libvirt host.
+func (a *kvmBackendAdapter) GetHostCapacity(ctx context.Context) ([]compute.HostCapacity, error) {
+	if a.hostRegistry == nil {
+		return nil, nil
+	}
+
+	capacities := a.hostRegistry.Capacity(ctx)
+	hosts := make([]compute.HostCapacity, 0, len(capacities))
+	for _, capacity := range capacities {
+		capabilities := capacity.Capabilities
+		if capacity.Default {
+			capabilities = append(capabilities, a.localOVSCapabilities(ctx)...)
+		}
+
+		hosts = append(hosts, compute.HostCapacity{
+			LastCheck:        capacity.LastCheck,
+			Capabilities:     capabilities,
+			Name:             capacity.Name,
+			Address:          capacity.URI,
+			State:            string(capacity.State),
+			LastError:        capacity.LastError,
+			Backend:          compute.BackendKVM,
+			MemoryTotal:      capacity.MemoryTotal,
+			MemoryFree:       capacity.MemoryFree,
+			CPUs:             capacity.CPUs,
+			RunningInstances: capacity.RunningDomains,
+			TotalInstances:   capacity.RunningDomains + capacity.DefinedDomains,
+		})
+	}
+
+	return hosts, nil
+}
+
+// localOVSCapabilities reports the OVS bridges of the local host. OVS is
+// managed through ovs-vsctl, so bridges on remote hosts are not known.
+func (a *kvmBackendAdapter) localOVSCapabilities(ctx context.Context) []string {
+	if a.ovsManager == nil {
+		return nil
+	}
+
+	bridges, err := a.ovsManager.ListBridges(ctx)
+	if err != nil {
+		a.logger.Debug("OVS bridges unavailable for placement", loggerPkg.Error(err))
+		return nil
+	}
+
+	capabilities := []string{compute.CapabilityOVS}
+	for _, bridge := range bridges {
+		capabilities = append(capabilities, compute.CapabilityOVSBridgePrefix+bridge.Name)
+	}
+
+	return capabilities
 }
 
 // GetResourceUsage gets current resource usage for a KVM instance.
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	backupservice "github.com/threatflux/libgo/internal/backup"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// BackupJobResponse represents the response for a single backup or restore job.
+type BackupJobResponse struct {
+	Job *backupservice.Job `json:"job"`
+}
+
+// BackupJobListResponse represents the response for listing backup and restore jobs.
+type BackupJobListResponse struct {
+	Jobs []*backupservice.Job `json:"jobs"`
+}
+
+// RestorePointResponse represents the response for a single restore point.
+type RestorePointResponse struct {
+	RestorePoint *backupservice.RestorePoint `json:"restorePoint"`
+}
+
+// RestorePointListResponse represents the response for listing restore points.
+type RestorePointListResponse struct {
+	RestorePoints []*backupservice.RestorePoint `json:"restorePoints"`
+	Count         int                           `json:"count"`
+}
+
+// BackupFileListResponse represents the response for listing files in a restore point.
+type BackupFileListResponse struct {
+	Path  string                    `json:"path"`
+	Files []backupservice.FileEntry `json:"files"`
+}
+
+// BackupHandler handles VM backup and restore operations.
+type BackupHandler struct {
+	backupManager backupservice.Manager
+	logger        logger.Logger
+}
+
+// NewBackupHandler creates a new BackupHandler.
+func NewBackupHandler(backupManager backupservice.Manager, logger logger.Logger) *BackupHandler {
+	return &BackupHandler{
+		backupManager: backupManager,
+		logger:        logger,
+	}
+}
+
+// BackupVM handles POST /vms/:name/backup.
+func (h *BackupHandler) BackupVM(c *gin.Context) {
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	// The request body is optional
+	var params backupservice.Params
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			contextLogger.Warn("Invalid VM backup request",
+				logger.Error(err))
+			HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+			return
+		}
+	}
+
+	job, err := h.backupManager.StartBackup(c.Request.Context(), vmName, params)
+	if err != nil {
+		contextLogger.Error("Failed to start VM backup",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("VM backup started",
+		logger.String("jobId", job.ID))
+
+	c.JSON(http.StatusAccepted, BackupJobResponse{Job: job})
+}
+
+// ListRestorePoints handles GET /backups.
+func (h *BackupHandler) ListRestorePoints(c *gin.Context) {
+	points, err := h.backupManager.ListRestorePoints(c.Request.Context(), c.Query("vm"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RestorePointListResponse{RestorePoints: points, Count: len(points)})
+}
+
+// GetRestorePoint handles GET /backups/:id.
+func (h *BackupHandler) GetRestorePoint(c *gin.Context) {
+	point, err := h.backupManager.GetRestorePoint(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RestorePointResponse{RestorePoint: point})
+}
+
+// DeleteRestorePoint handles DELETE /backups/:id.
+func (h *BackupHandler) DeleteRestorePoint(c *gin.Context) {
+	pointID := c.Param("id")
+
+	if err := h.backupManager.DeleteRestorePoint(c.Request.Context(), pointID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	getContextLogger(c, h.logger).Info("Restore point deleted",
+		logger.String("restorePoint", pointID))
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreBackup handles POST /backups/:id/restore.
+func (h *BackupHandler) RestoreBackup(c *gin.Context) {
+	pointID := c.Param("id")
+	contextLogger := getContextLogger(c, h.logger).WithFields(logger.String("restorePoint", pointID))
+
+	var params backupservice.RestoreParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid restore request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	job, err := h.backupManager.Restore(volumeOwnerContext(c), pointID, params)
+	if err != nil {
+		contextLogger.Error("Failed to start restore",
+			logger.String("name", params.Name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Restore started",
+		logger.String("jobId", job.ID),
+		logger.String("name", params.Name))
+
+	c.JSON(http.StatusAccepted, BackupJobResponse{Job: job})
+}
+
+// ListFiles handles GET /backups/:id/files.
+func (h *BackupHandler) ListFiles(c *gin.Context) {
+	guestPath := c.DefaultQuery("path", "/")
+
+	files, err := h.backupManager.ListFiles(c.Request.Context(), c.Param("id"), guestPath)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BackupFileListResponse{Path: guestPath, Files: files})
+}
+
+// DownloadFile handles GET /backups/:id/files/download.
+func (h *BackupHandler) DownloadFile(c *gin.Context) {
+	guestPath := c.Query("path")
+	if guestPath == "" {
+		HandleError(c, fmt.Errorf("%w: path is required", ErrInvalidInput))
+		return
+	}
+
+	localPath, cleanup, err := h.backupManager.ExtractFile(c.Request.Context(), c.Param("id"), guestPath)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	defer cleanup()
+
+	c.FileAttachment(localPath, path.Base(guestPath))
+}
+
+// PruneBackups handles POST /backups/prune.
+func (h *BackupHandler) PruneBackups(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+
+	var params backupservice.PruneParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid prune request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	result, err := h.backupManager.Prune(c.Request.Context(), params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListJobs handles GET /backup-jobs.
+func (h *BackupHandler) ListJobs(c *gin.Context) {
+	jobs, err := h.backupManager.ListJobs(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BackupJobListResponse{Jobs: jobs})
+}
+
+// GetJob handles GET /backup-jobs/:id.
+func (h *BackupHandler) GetJob(c *gin.Context) {
+	job, err := h.backupManager.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BackupJobResponse{Job: job})
+}
+
+// CancelJob handles DELETE /backup-jobs/:id.
+func (h *BackupHandler) CancelJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	if err := h.backupManager.CancelJob(c.Request.Context(), jobID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	getContextLogger(c, h.logger).Info("Backup job canceled",
+		logger.String("jobId", jobID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Backup job canceled successfully",
+	})
+}
@@ -399,6 +399,38 @@ func (h *ComputeHandler) GetClusterStatus(c *gin.Context) {
 	})
 }
 
+// PlanPlacement handles requests to show where a new instance would be
+// placed and why, without creating it.
+func (h *ComputeHandler) PlanPlacement(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+
+	var req compute.ComputeInstanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		contextLogger.Warn("Invalid placement request", logger.Error(err))
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	if req.Type == "" {
+		contextLogger.Warn("Missing instance type for placement")
+		HandleError(c, apierrors.ErrInvalidParameter)
+		return
+	}
+
+	decision, err := h.computeManager.PlanPlacement(c.Request.Context(), req)
+	if err != nil {
+		contextLogger.Error("Failed to plan placement",
+			logger.String("type", string(req.Type)),
+			logger.Error(err))
+		HandleError(c, apierrors.Wrap(err, "plan placement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"placement": decision,
+	})
+}
+
 // GetBackendInfo handles requests to get backend information.
 func (h *ComputeHandler) GetBackendInfo(c *gin.Context) {
 	contextLogger := getContextLogger(c, h.logger)
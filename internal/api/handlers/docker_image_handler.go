@@ -10,8 +10,6 @@ import (
 	"github.com/threatflux/libgo/pkg/logger"
 )
 
-const trueString = "true"
-
 // DockerImageHandler handles Docker image API requests.
 type DockerImageHandler struct {
 	service dockerimage.Service
@@ -9,6 +9,9 @@ import (
 	jwtauth "github.com/threatflux/libgo/internal/auth/jwt"
 	userauth "github.com/threatflux/libgo/internal/auth/user"
 	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
 	"github.com/threatflux/libgo/pkg/logger"
 )
 
@@ -97,6 +100,12 @@ func mapErrorToStatusAndCode(err error) (int, string) {
 	if status, code := checkConflictErrors(err); status != 0 {
 		return status, code
 	}
+	if errors.Is(err, apierrors.ErrInsufficientStorage) {
+		return http.StatusInsufficientStorage, "INSUFFICIENT_STORAGE"
+	}
+	if errors.Is(err, connection.ErrHostUnavailable) {
+		return http.StatusServiceUnavailable, "HOST_UNAVAILABLE"
+	}
 	if errors.Is(err, ErrInternalError) {
 		return http.StatusInternalServerError, internalServerErrorCode
 	}
@@ -110,6 +119,23 @@ func checkNotFoundErrors(err error) (int, string) {
 	notFoundErrors := []error{
 		ErrNotFound,
 		apierrors.ErrVMNotFound,
+		apierrors.ErrVMJobNotFound,
+		apierrors.ErrMigrationJobNotFound,
+		domain.ErrDomainNotFound,
+		domain.ErrDiskNotFound,
+		domain.ErrBlockJobNotFound,
+		connection.ErrHostNotFound,
+		apierrors.ErrSnapshotPolicyNotFound,
+		apierrors.ErrBackupNotFound,
+		apierrors.ErrBackupJobNotFound,
+		domain.ErrCheckpointNotFound,
+		apierrors.ErrImportJobNotFound,
+		apierrors.ErrUploadNotFound,
+		storage.ErrPoolNotFound,
+		storage.ErrVolumeNotFound,
+		apierrors.ErrVolumeJobNotFound,
+		apierrors.ErrImageNotFound,
+		apierrors.ErrOrphanNotFound,
 	}
 	for _, target := range notFoundErrors {
 		if errors.Is(err, target) {
@@ -123,6 +149,7 @@ func checkNotFoundErrors(err error) (int, string) {
 func checkBadRequestErrors(err error) (int, string) {
 	badRequestErrors := []error{
 		ErrInvalidInput,
+		apierrors.ErrInvalidParameter,
 		apierrors.ErrInvalidCPUCount,
 		apierrors.ErrInvalidMemorySize,
 		apierrors.ErrInvalidDiskSize,
@@ -130,6 +157,11 @@ func checkBadRequestErrors(err error) (int, string) {
 		apierrors.ErrInvalidNetworkType,
 		apierrors.ErrInvalidNetworkSource,
 		apierrors.ErrVMInvalidState,
+		domain.ErrInvalidSnapshot,
+		domain.ErrInvalidGuestCommand,
+		domain.ErrInvalidDefinition,
+		apierrors.ErrUploadChecksumMismatch,
+		connection.ErrRemoteHost,
 	}
 	for _, target := range badRequestErrors {
 		if errors.Is(err, target) {
@@ -179,6 +211,24 @@ func checkConflictErrors(err error) (int, string) {
 		apierrors.ErrVMAlreadyExists,
 		apierrors.ErrDuplicateUsername,
 		userauth.ErrDuplicateUsername,
+		domain.ErrDomainNotRunning,
+		domain.ErrGuestAgentUnavailable,
+		apierrors.ErrMigrationInProgress,
+		apierrors.ErrMigrationPreflight,
+		apierrors.ErrMigrationInvalidState,
+		apierrors.ErrNoPlacement,
+		apierrors.ErrAlreadyExists,
+		apierrors.ErrSnapshotPolicyRunning,
+		apierrors.ErrBackupInProgress,
+		apierrors.ErrBackupInvalidState,
+		apierrors.ErrImportInvalidState,
+		apierrors.ErrUploadInvalidState,
+		apierrors.ErrUploadOffsetMismatch,
+		storage.ErrVolumeExists,
+		apierrors.ErrVolumeJobInvalidState,
+		apierrors.ErrVolumeInUse,
+		apierrors.ErrImageInUse,
+		apierrors.ErrImageReadOnly,
 	}
 	for _, target := range conflictErrors {
 		if errors.Is(err, target) {
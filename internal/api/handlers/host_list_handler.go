@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// HostLister lists the libvirt hosts managed by the server.
+type HostLister interface {
+	// Hosts returns the status of all hosts
+	Hosts() []connection.HostStatus
+}
+
+// HostListResponse represents the response for listing libvirt hosts.
+type HostListResponse struct {
+	Hosts []connection.HostStatus `json:"hosts"`
+}
+
+// HostHandler handles libvirt host operations.
+type HostHandler struct {
+	hostLister HostLister
+	logger     logger.Logger
+}
+
+// NewHostHandler creates a new HostHandler.
+func NewHostHandler(hostLister HostLister, logger logger.Logger) *HostHandler {
+	return &HostHandler{
+		hostLister: hostLister,
+		logger:     logger,
+	}
+}
+
+// ListHosts handles GET /hosts.
+func (h *HostHandler) ListHosts(c *gin.Context) {
+	c.JSON(http.StatusOK, HostListResponse{
+		Hosts: h.hostLister.Hosts(),
+	})
+}
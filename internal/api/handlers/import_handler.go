@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/vmimport"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ImportRequest represents a request to import a file on the server.
+type ImportRequest struct {
+	// Path is the absolute path of the OVA archive, OVF descriptor or
+	// disk image in the import source directory
+	Path string `json:"path" binding:"required"`
+	vmimport.Params
+}
+
+// ImportJobResponse represents the response for a single import job.
+type ImportJobResponse struct {
+	Job *vmimport.Job `json:"job"`
+}
+
+// ImportJobListResponse represents the response for listing import jobs.
+type ImportJobListResponse struct {
+	Jobs []*vmimport.Job `json:"jobs"`
+}
+
+// ImportHandler handles VM import operations.
+type ImportHandler struct {
+	importManager vmimport.Manager
+	logger        logger.Logger
+}
+
+// NewImportHandler creates a new ImportHandler.
+func NewImportHandler(importManager vmimport.Manager, logger logger.Logger) *ImportHandler {
+	return &ImportHandler{
+		importManager: importManager,
+		logger:        logger,
+	}
+}
+
+// ImportVM handles POST /vms/import. JSON requests import a file in the
+// import source directory; multipart requests upload the source in the
+// "file" part, preceded by the import parameters as JSON in an optional
+// "params" part.
+func (h *ImportHandler) ImportVM(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+
+	var (
+		job *vmimport.Job
+		err error
+	)
+	if strings.HasPrefix(c.GetHeader("Content-Type"), "multipart/form-data") {
+		job, err = h.importUpload(c)
+	} else {
+		var req ImportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			contextLogger.Warn("Invalid VM import request",
+				logger.Error(err))
+			HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+			return
+		}
+		job, err = h.importManager.StartImport(volumeOwnerContext(c), req.Path, req.Params)
+	}
+	if err != nil {
+		contextLogger.Error("Failed to start VM import",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("VM import started",
+		logger.String("jobId", job.ID),
+		logger.String("vmName", job.VMName),
+		logger.String("source", job.Source))
+
+	c.JSON(http.StatusAccepted, ImportJobResponse{Job: job})
+}
+
+// importUpload streams the source of a multipart import request to the
+// import manager.
+func (h *ImportHandler) importUpload(c *gin.Context) (*vmimport.Job, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	var params vmimport.Params
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: file is required", ErrInvalidInput)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+
+		switch part.FormName() {
+		case "params":
+			err := json.NewDecoder(part).Decode(&params)
+			part.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%w: params: %v", ErrInvalidInput, err)
+			}
+		case "file":
+			defer part.Close()
+			if part.FileName() == "" {
+				return nil, fmt.Errorf("%w: file name is required", ErrInvalidInput)
+			}
+			return h.importManager.StartUploadImport(volumeOwnerContext(c), part.FileName(), part, params)
+		default:
+			part.Close()
+		}
+	}
+}
+
+// ListJobs handles GET /import-jobs.
+func (h *ImportHandler) ListJobs(c *gin.Context) {
+	jobs, err := h.importManager.ListJobs(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ImportJobListResponse{Jobs: jobs})
+}
+
+// GetJob handles GET /import-jobs/:id.
+func (h *ImportHandler) GetJob(c *gin.Context) {
+	job, err := h.importManager.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ImportJobResponse{Job: job})
+}
+
+// CancelJob handles DELETE /import-jobs/:id.
+func (h *ImportHandler) CancelJob(c *gin.Context) {
+	jobID := c.Param("id")
+
+	if err := h.importManager.CancelJob(c.Request.Context(), jobID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	getContextLogger(c, h.logger).Info("Import job canceled",
+		logger.String("jobId", jobID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import job canceled successfully",
+	})
+}
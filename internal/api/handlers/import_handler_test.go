@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/vmimport"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mocks_vmimport "github.com/threatflux/libgo/test/mocks/vmimport"
+	"go.uber.org/mock/gomock"
+)
+
+// newImportTestRouter creates a router serving an ImportHandler backed by
+// a mock import manager.
+func newImportTestRouter(t *testing.T) (*gin.Engine, *mocks_vmimport.MockManager) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockManager := mocks_vmimport.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	handler := NewImportHandler(mockManager, mockLogger)
+	router := gin.New()
+	router.POST("/vms/import", handler.ImportVM)
+	router.GET("/import-jobs/:id", handler.GetJob)
+	router.DELETE("/import-jobs/:id", handler.CancelJob)
+
+	return router, mockManager
+}
+
+func TestImportHandler_ImportVM(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mocks_vmimport.MockManager)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "Import started",
+			body: `{"path":"/var/lib/libgo/imports/appliance.ova","name":"appliance","networks":{"VM Network":"lan"}}`,
+			mockSetup: func(m *mocks_vmimport.MockManager) {
+				m.EXPECT().StartImport(gomock.Any(), "/var/lib/libgo/imports/appliance.ova", vmimport.Params{
+					Name:     "appliance",
+					Networks: map[string]string{"VM Network": "lan"},
+				}).Return(&vmimport.Job{ID: "job-1", VMName: "appliance", Status: vmimport.StatusPending}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Missing path",
+			body:           `{"name":"appliance"}`,
+			mockSetup:      func(m *mocks_vmimport.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_INPUT",
+		},
+		{
+			name: "Source outside the source directory",
+			body: `{"path":"/etc/shadow"}`,
+			mockSetup: func(m *mocks_vmimport.MockManager) {
+				m.EXPECT().StartImport(gomock.Any(), "/etc/shadow", gomock.Any()).
+					Return(nil, fmt.Errorf("%w: source must be in /var/lib/libgo/imports", apierrors.ErrInvalidParameter))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "VM exists",
+			body: `{"path":"/var/lib/libgo/imports/appliance.ova"}`,
+			mockSetup: func(m *mocks_vmimport.MockManager) {
+				m.EXPECT().StartImport(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: VM appliance", apierrors.ErrAlreadyExists))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router, mockManager := newImportTestRouter(t)
+			tc.mockSetup(mockManager)
+
+			req, err := http.NewRequest(http.MethodPost, "/vms/import", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCode != "" {
+				var response ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedCode, response.Code)
+				return
+			}
+			if w.Code != http.StatusAccepted {
+				return
+			}
+
+			var response ImportJobResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "job-1", response.Job.ID)
+		})
+	}
+}
+
+func TestImportHandler_ImportVMUpload(t *testing.T) {
+	router, mockManager := newImportTestRouter(t)
+
+	mockManager.EXPECT().StartUploadImport(gomock.Any(), "router.qcow2", gomock.Any(), vmimport.Params{CPUs: 2, Start: true}).
+		DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ vmimport.Params) (*vmimport.Job, error) {
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "qcow2 data", string(data))
+			return &vmimport.Job{ID: "job-2", VMName: "router", Status: vmimport.StatusPending}, nil
+		})
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("params", `{"cpus":2,"start":true}`))
+	part, err := writer.CreateFormFile("file", "router.qcow2")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("qcow2 data"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req, err := http.NewRequest(http.MethodPost, "/vms/import", &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var response ImportJobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "job-2", response.Job.ID)
+
+	// Uploads without a file are rejected
+	body.Reset()
+	writer = multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("params", `{}`))
+	require.NoError(t, writer.Close())
+
+	req, err = http.NewRequest(http.MethodPost, "/vms/import", &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestImportHandler_Jobs(t *testing.T) {
+	router, mockManager := newImportTestRouter(t)
+
+	mockManager.EXPECT().GetJob(gomock.Any(), "missing").
+		Return(nil, fmt.Errorf("%w: missing", apierrors.ErrImportJobNotFound))
+	mockManager.EXPECT().CancelJob(gomock.Any(), "job-1").
+		Return(fmt.Errorf("%w: cannot cancel job in completed state", apierrors.ErrImportInvalidState))
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{method: http.MethodGet, path: "/import-jobs/missing", expectedStatus: http.StatusNotFound},
+		{method: http.MethodDelete, path: "/import-jobs/job-1", expectedStatus: http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, tc.path, nil)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedStatus, w.Code, "%s %s", tc.method, tc.path)
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/models/user"
+	snapshotservice "github.com/threatflux/libgo/internal/snapshot"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// SnapshotPolicyResponse represents the response for a single snapshot policy.
+type SnapshotPolicyResponse struct {
+	Policy *snapshotservice.Policy `json:"policy"`
+}
+
+// SnapshotPolicyListResponse represents the response for listing snapshot policies.
+type SnapshotPolicyListResponse struct {
+	Policies []*snapshotservice.Policy `json:"policies"`
+	Count    int                       `json:"count"`
+}
+
+// SnapshotPolicyHandler handles scheduled snapshot policy operations.
+type SnapshotPolicyHandler struct {
+	policyManager snapshotservice.Manager
+	logger        logger.Logger
+}
+
+// NewSnapshotPolicyHandler creates a new SnapshotPolicyHandler.
+func NewSnapshotPolicyHandler(policyManager snapshotservice.Manager, logger logger.Logger) *SnapshotPolicyHandler {
+	return &SnapshotPolicyHandler{
+		policyManager: policyManager,
+		logger:        logger,
+	}
+}
+
+// ListPolicies handles GET /snapshot-policies.
+func (h *SnapshotPolicyHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.policyManager.ListPolicies(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SnapshotPolicyListResponse{Policies: policies, Count: len(policies)})
+}
+
+// CreatePolicy handles POST /snapshot-policies.
+func (h *SnapshotPolicyHandler) CreatePolicy(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+
+	var policy snapshotservice.Policy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		contextLogger.Warn("Invalid snapshot policy request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	if !checkPolicyHooks(c, &policy) {
+		return
+	}
+
+	created, err := h.policyManager.CreatePolicy(c.Request.Context(), policy)
+	if err != nil {
+		contextLogger.Error("Failed to create snapshot policy",
+			logger.String("name", policy.Name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SnapshotPolicyResponse{Policy: created})
+}
+
+// GetPolicy handles GET /snapshot-policies/:id.
+func (h *SnapshotPolicyHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.policyManager.GetPolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SnapshotPolicyResponse{Policy: policy})
+}
+
+// UpdatePolicy handles PUT /snapshot-policies/:id.
+func (h *SnapshotPolicyHandler) UpdatePolicy(c *gin.Context) {
+	policyID := c.Param("id")
+	contextLogger := getContextLogger(c, h.logger).WithFields(logger.String("policyId", policyID))
+
+	var policy snapshotservice.Policy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		contextLogger.Warn("Invalid snapshot policy request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	if !checkPolicyHooks(c, &policy) || !h.checkStoredPolicyHooks(c, policyID) {
+		return
+	}
+
+	updated, err := h.policyManager.UpdatePolicy(c.Request.Context(), policyID, policy)
+	if err != nil {
+		contextLogger.Error("Failed to update snapshot policy",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SnapshotPolicyResponse{Policy: updated})
+}
+
+// DeletePolicy handles DELETE /snapshot-policies/:id.
+func (h *SnapshotPolicyHandler) DeletePolicy(c *gin.Context) {
+	if !h.checkStoredPolicyHooks(c, c.Param("id")) {
+		return
+	}
+
+	if err := h.policyManager.DeletePolicy(c.Request.Context(), c.Param("id")); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunPolicy handles POST /snapshot-policies/:id/run.
+func (h *SnapshotPolicyHandler) RunPolicy(c *gin.Context) {
+	policyID := c.Param("id")
+
+	if !h.checkStoredPolicyHooks(c, policyID) {
+		return
+	}
+
+	if err := h.policyManager.RunPolicy(c.Request.Context(), policyID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	getContextLogger(c, h.logger).Info("Snapshot policy run started",
+		logger.String("policyId", policyID))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Snapshot policy run started",
+	})
+}
+
+// checkPolicyHooks ends a request with forbidden when a user other than an
+// administrator sets guest hooks, which run commands in the guests of the
+// policy.
+func checkPolicyHooks(c *gin.Context, policy *snapshotservice.Policy) bool {
+	if policy.Hooks.Pre == nil && policy.Hooks.Post == nil {
+		return true
+	}
+	if u := currentUser(c); u == nil || u.HasRole(user.RoleAdmin) {
+		return true
+	}
+
+	HandleError(c, fmt.Errorf("%w: only administrators manage snapshot policies with guest hooks", ErrForbidden))
+	return false
+}
+
+// checkStoredPolicyHooks applies checkPolicyHooks to a stored policy before
+// it is changed, deleted or run.
+func (h *SnapshotPolicyHandler) checkStoredPolicyHooks(c *gin.Context, policyID string) bool {
+	if currentUser(c) == nil {
+		return true
+	}
+
+	policy, err := h.policyManager.GetPolicy(c.Request.Context(), policyID)
+	if err != nil {
+		HandleError(c, err)
+		return false
+	}
+	return checkPolicyHooks(c, policy)
+}
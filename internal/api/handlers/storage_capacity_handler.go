@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// StorageCapacityResponse represents the latest usage of all pools.
+type StorageCapacityResponse struct {
+	Pools  []*storage.PoolUsage         `json:"pools"`
+	Events []*storage.PoolCapacityEvent `json:"events"`
+}
+
+// StoragePoolCapacityResponse represents the usage of a pool over time.
+type StoragePoolCapacityResponse struct {
+	Usage   *storage.PoolUsage           `json:"usage"`
+	History []*storage.PoolUsage         `json:"history"`
+	Events  []*storage.PoolCapacityEvent `json:"events"`
+}
+
+// StorageCapacityHandler handles listing the usage of all pools.
+type StorageCapacityHandler struct {
+	monitor storage.CapacityMonitor
+	logger  logger.Logger
+}
+
+// NewStorageCapacityHandler creates a new storage capacity handler.
+func NewStorageCapacityHandler(monitor storage.CapacityMonitor, logger logger.Logger) *StorageCapacityHandler {
+	return &StorageCapacityHandler{
+		monitor: monitor,
+		logger:  logger,
+	}
+}
+
+// Handle handles GET /storage/capacity.
+func (h *StorageCapacityHandler) Handle(c *gin.Context) {
+	c.JSON(http.StatusOK, StorageCapacityResponse{
+		Pools:  h.monitor.Latest(),
+		Events: h.monitor.Events("", ""),
+	})
+}
+
+// StoragePoolCapacityHandler handles getting the usage of a pool.
+type StoragePoolCapacityHandler struct {
+	monitor storage.CapacityMonitor
+	logger  logger.Logger
+}
+
+// NewStoragePoolCapacityHandler creates a new storage pool capacity handler.
+func NewStoragePoolCapacityHandler(monitor storage.CapacityMonitor, logger logger.Logger) *StoragePoolCapacityHandler {
+	return &StoragePoolCapacityHandler{
+		monitor: monitor,
+		logger:  logger,
+	}
+}
+
+// Handle handles GET /storage/pools/:name/capacity.
+func (h *StoragePoolCapacityHandler) Handle(c *gin.Context) {
+	poolName := c.Param("name")
+
+	// Sample the pool now so the response is current
+	usage, err := h.monitor.Sample(c.Request.Context(), poolName)
+	if err != nil {
+		getContextLogger(c, h.logger).Error("Failed to get storage pool usage",
+			logger.String("pool", poolName),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, StoragePoolCapacityResponse{
+		Usage:   usage,
+		History: h.monitor.History(usage.Host, poolName),
+		Events:  h.monitor.Events(usage.Host, poolName),
+	})
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	apierrors "github.com/threatflux/libgo/internal/errors"
 	"github.com/threatflux/libgo/internal/libvirt/storage"
 	"github.com/threatflux/libgo/pkg/logger"
 )
@@ -39,12 +41,18 @@ func (h *StorageCreateHandler) Handle(c *gin.Context) {
 	// Create storage pool.
 	poolInfo, err := h.poolManager.Create(ctx, &params)
 	if err != nil {
-		if err == storage.ErrPoolExists {
+		if errors.Is(err, storage.ErrPoolExists) {
 			c.JSON(http.StatusConflict, gin.H{
 				"error": "Storage pool already exists",
 			})
 			return
 		}
+		if errors.Is(err, apierrors.ErrInvalidParameter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		h.logger.Error("Failed to create storage pool",
 			logger.String("name", params.Name),
 			logger.Error(err))
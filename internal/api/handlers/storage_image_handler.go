@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ImageResponse represents a single image in a response.
+type ImageResponse struct {
+	Image *storage.Image `json:"image"`
+}
+
+// ImageListResponse represents the images of the image library.
+type ImageListResponse struct {
+	Images []*storage.Image `json:"images"`
+}
+
+// StorageImageListHandler handles listing library images.
+type StorageImageListHandler struct {
+	imageLibrary storage.ImageLibrary
+	logger       logger.Logger
+}
+
+// NewStorageImageListHandler creates a new image list handler.
+func NewStorageImageListHandler(imageLibrary storage.ImageLibrary, logger logger.Logger) *StorageImageListHandler {
+	return &StorageImageListHandler{
+		imageLibrary: imageLibrary,
+		logger:       logger,
+	}
+}
+
+// Handle handles GET /storage/images.
+func (h *StorageImageListHandler) Handle(c *gin.Context) {
+	images, err := h.imageLibrary.List(c.Request.Context())
+	if err != nil {
+		getContextLogger(c, h.logger).Error("Failed to list images",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ImageListResponse{Images: images})
+}
+
+// StorageImageRegisterHandler handles registering library images.
+type StorageImageRegisterHandler struct {
+	imageLibrary   storage.ImageLibrary
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+	// defaultPool holds images registered without a pool
+	defaultPool string
+}
+
+// NewStorageImageRegisterHandler creates a new image register handler.
+func NewStorageImageRegisterHandler(imageLibrary storage.ImageLibrary, storageManager storage.VolumeManager, defaultPool string, logger logger.Logger) *StorageImageRegisterHandler {
+	return &StorageImageRegisterHandler{
+		imageLibrary:   imageLibrary,
+		storageManager: storageManager,
+		defaultPool:    defaultPool,
+		logger:         logger,
+	}
+}
+
+// Handle handles POST /storage/images.
+func (h *StorageImageRegisterHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+
+	var params storage.RegisterImageParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid image register request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	// Files on the server are only registered by administrators, and
+	// volumes only by users who may access them
+	switch {
+	case params.SourcePath != "":
+		if volumeOwnerFilter(c) != "" {
+			HandleError(c, fmt.Errorf("%w: only administrators register images by path", ErrForbidden))
+			return
+		}
+	case params.Volume != "":
+		pool := params.Pool
+		if pool == "" {
+			pool = h.defaultPool
+		}
+		if !checkVolumeAccess(c, h.storageManager, pool, params.Volume) {
+			return
+		}
+	}
+
+	image, err := h.imageLibrary.Register(c.Request.Context(), &params)
+	if err != nil {
+		contextLogger.Warn("Failed to register image",
+			logger.String("image", params.Name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Image registered",
+		logger.String("image", image.Name),
+		logger.String("pool", image.Pool),
+		logger.String("volume", image.Volume))
+
+	c.JSON(http.StatusCreated, ImageResponse{Image: image})
+}
+
+// StorageImageGetHandler handles getting a library image.
+type StorageImageGetHandler struct {
+	imageLibrary storage.ImageLibrary
+	logger       logger.Logger
+}
+
+// NewStorageImageGetHandler creates a new image get handler.
+func NewStorageImageGetHandler(imageLibrary storage.ImageLibrary, logger logger.Logger) *StorageImageGetHandler {
+	return &StorageImageGetHandler{
+		imageLibrary: imageLibrary,
+		logger:       logger,
+	}
+}
+
+// Handle handles GET /storage/images/:image.
+func (h *StorageImageGetHandler) Handle(c *gin.Context) {
+	name := c.Param("image")
+
+	image, err := h.imageLibrary.Get(c.Request.Context(), name)
+	if err != nil {
+		getContextLogger(c, h.logger).Warn("Failed to get image",
+			logger.String("image", name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ImageResponse{Image: image})
+}
+
+// StorageImageUpdateHandler handles updating a library image.
+type StorageImageUpdateHandler struct {
+	imageLibrary storage.ImageLibrary
+	logger       logger.Logger
+}
+
+// NewStorageImageUpdateHandler creates a new image update handler.
+func NewStorageImageUpdateHandler(imageLibrary storage.ImageLibrary, logger logger.Logger) *StorageImageUpdateHandler {
+	return &StorageImageUpdateHandler{
+		imageLibrary: imageLibrary,
+		logger:       logger,
+	}
+}
+
+// Handle handles PATCH /storage/images/:image.
+func (h *StorageImageUpdateHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	name := c.Param("image")
+
+	var params storage.UpdateImageParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid image update request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	image, err := h.imageLibrary.Update(c.Request.Context(), name, &params)
+	if err != nil {
+		contextLogger.Warn("Failed to update image",
+			logger.String("image", name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ImageResponse{Image: image})
+}
+
+// StorageImageDeleteHandler handles deleting a library image.
+type StorageImageDeleteHandler struct {
+	imageLibrary storage.ImageLibrary
+	logger       logger.Logger
+}
+
+// NewStorageImageDeleteHandler creates a new image delete handler.
+func NewStorageImageDeleteHandler(imageLibrary storage.ImageLibrary, logger logger.Logger) *StorageImageDeleteHandler {
+	return &StorageImageDeleteHandler{
+		imageLibrary: imageLibrary,
+		logger:       logger,
+	}
+}
+
+// Handle handles DELETE /storage/images/:image.
+func (h *StorageImageDeleteHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	name := c.Param("image")
+
+	if err := h.imageLibrary.Delete(c.Request.Context(), name); err != nil {
+		contextLogger.Warn("Failed to delete image",
+			logger.String("image", name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Image deleted",
+		logger.String("image", name))
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/orphan"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// OrphanListResponse represents the quarantined orphans.
+type OrphanListResponse struct {
+	Orphans []*orphan.Orphan `json:"orphans"`
+}
+
+// OrphanPurgeResponse represents the orphans a purge deleted.
+type OrphanPurgeResponse struct {
+	Purged []*orphan.Orphan `json:"purged"`
+}
+
+// StorageOrphanListHandler handles listing quarantined orphans.
+type StorageOrphanListHandler struct {
+	orphans orphan.Manager
+	logger  logger.Logger
+}
+
+// NewStorageOrphanListHandler creates a new orphan list handler.
+func NewStorageOrphanListHandler(orphans orphan.Manager, logger logger.Logger) *StorageOrphanListHandler {
+	return &StorageOrphanListHandler{
+		orphans: orphans,
+		logger:  logger,
+	}
+}
+
+// Handle handles GET /storage/orphans. With scan=true the orphans are
+// looked for first.
+func (h *StorageOrphanListHandler) Handle(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if c.Query("scan") == "true" {
+		if err := h.orphans.Reconcile(ctx); err != nil {
+			getContextLogger(c, h.logger).Error("Failed to scan for orphans",
+				logger.Error(err))
+			HandleError(c, err)
+			return
+		}
+	}
+
+	orphans, err := h.orphans.List(ctx)
+	if err != nil {
+		getContextLogger(c, h.logger).Error("Failed to list orphans",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, OrphanListResponse{Orphans: orphans})
+}
+
+// StorageOrphanPurgeHandler handles purging a quarantined orphan.
+type StorageOrphanPurgeHandler struct {
+	orphans orphan.Manager
+	logger  logger.Logger
+}
+
+// NewStorageOrphanPurgeHandler creates a new orphan purge handler.
+func NewStorageOrphanPurgeHandler(orphans orphan.Manager, logger logger.Logger) *StorageOrphanPurgeHandler {
+	return &StorageOrphanPurgeHandler{
+		orphans: orphans,
+		logger:  logger,
+	}
+}
+
+// Handle handles DELETE /storage/orphans/:id.
+func (h *StorageOrphanPurgeHandler) Handle(c *gin.Context) {
+	id := c.Param("id")
+
+	purged, err := h.orphans.Purge(c.Request.Context(), id)
+	if err != nil {
+		getContextLogger(c, h.logger).Warn("Failed to purge orphan",
+			logger.String("orphan_id", id),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, OrphanPurgeResponse{Purged: []*orphan.Orphan{purged}})
+}
+
+// StorageOrphanPurgeAllHandler handles purging all quarantined orphans.
+type StorageOrphanPurgeAllHandler struct {
+	orphans orphan.Manager
+	logger  logger.Logger
+}
+
+// NewStorageOrphanPurgeAllHandler creates a new handler purging all orphans.
+func NewStorageOrphanPurgeAllHandler(orphans orphan.Manager, logger logger.Logger) *StorageOrphanPurgeAllHandler {
+	return &StorageOrphanPurgeAllHandler{
+		orphans: orphans,
+		logger:  logger,
+	}
+}
+
+// Handle handles DELETE /storage/orphans.
+func (h *StorageOrphanPurgeAllHandler) Handle(c *gin.Context) {
+	purged, err := h.orphans.PurgeAll(c.Request.Context())
+	if err != nil {
+		getContextLogger(c, h.logger).Error("Failed to purge orphans",
+			logger.Int("purged", len(purged)),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, OrphanPurgeResponse{Purged: purged})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/volume"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// VolumeJobResponse represents the response for a single volume job.
+type VolumeJobResponse struct {
+	Job *volume.Job `json:"job"`
+}
+
+// VolumeJobListResponse represents the response for listing volume jobs.
+type VolumeJobListResponse struct {
+	Jobs []*volume.Job `json:"jobs"`
+}
+
+// StorageVolumeCloneHandler handles cloning storage volumes.
+type StorageVolumeCloneHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeCloneHandler creates a new storage volume clone handler.
+func NewStorageVolumeCloneHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeCloneHandler {
+	return &StorageVolumeCloneHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles POST /storage/pools/:name/volumes/:volumeName/clone. The
+// clone runs as a job that is polled under /storage/volume-jobs.
+func (h *StorageVolumeCloneHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	var params volume.CloneParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid volume clone request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	job, err := h.volumeManager.StartClone(volumeOwnerContext(c), poolName, volumeName, params)
+	if err != nil {
+		contextLogger.Warn("Failed to start volume clone",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.String("target", params.Name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Volume clone started",
+		logger.String("jobId", job.ID),
+		logger.String("volume", volumeName),
+		logger.String("target", job.TargetVolume))
+
+	c.JSON(http.StatusAccepted, VolumeJobResponse{Job: job})
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	apierrors "github.com/threatflux/libgo/internal/errors"
 	"github.com/threatflux/libgo/internal/libvirt/storage"
 	"github.com/threatflux/libgo/pkg/logger"
 )
@@ -24,7 +26,7 @@ func NewStorageVolumeCreateHandler(volumeManager storage.VolumeManager, logger l
 
 // Handle handles the storage volume create request.
 func (h *StorageVolumeCreateHandler) Handle(c *gin.Context) {
-	ctx := c.Request.Context()
+	ctx := volumeOwnerContext(c)
 
 	// Get pool name from URL parameter
 	poolName := c.Param("name")
@@ -50,15 +52,33 @@ func (h *StorageVolumeCreateHandler) Handle(c *gin.Context) {
 		params.Format = "qcow2"
 	}
 
-	// Create storage volume
-	err := h.volumeManager.Create(ctx, poolName, params.Name, params.CapacityBytes, params.Format)
+	// Create storage volume, as an overlay when a backing store is given
+	err := h.volumeManager.CreateWithParams(ctx, poolName, &params)
 	if err != nil {
-		if err == storage.ErrVolumeExists {
+		if errors.Is(err, storage.ErrVolumeExists) {
 			c.JSON(http.StatusConflict, gin.H{
 				"error": "Storage volume already exists",
 			})
 			return
 		}
+		if params.BackingStore != "" && errors.Is(err, storage.ErrVolumeNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Backing store is not a storage volume",
+			})
+			return
+		}
+		if errors.Is(err, apierrors.ErrInvalidParameter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, apierrors.ErrInsufficientStorage) {
+			c.JSON(http.StatusInsufficientStorage, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		h.logger.Error("Failed to create storage volume",
 			logger.String("pool", poolName),
 			logger.String("volume", params.Name),
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -37,6 +38,27 @@ func (h *StorageVolumeDeleteHandler) Handle(c *gin.Context) {
 		return
 	}
 
+	// Users other than administrators only delete their own volumes
+	if volumeOwnerFilter(c) != "" {
+		info, err := h.volumeManager.GetInfo(ctx, poolName, volumeName)
+		if err != nil && !errors.Is(err, storage.ErrVolumeNotFound) {
+			h.logger.Error("Failed to get storage volume",
+				logger.String("pool", poolName),
+				logger.String("volume", volumeName),
+				logger.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to delete storage volume",
+			})
+			return
+		}
+		if err != nil || !canAccessVolume(c, info) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Storage volume not found",
+			})
+			return
+		}
+	}
+
 	// Delete storage volume
 	err := h.volumeManager.Delete(ctx, poolName, volumeName)
 	if err != nil {
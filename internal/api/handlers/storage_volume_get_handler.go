@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// StorageVolumeGetHandler handles getting storage volume information.
+type StorageVolumeGetHandler struct {
+	volumeManager storage.VolumeManager
+	logger        logger.Logger
+}
+
+// NewStorageVolumeGetHandler creates a new storage volume get handler.
+func NewStorageVolumeGetHandler(volumeManager storage.VolumeManager, logger logger.Logger) *StorageVolumeGetHandler {
+	return &StorageVolumeGetHandler{
+		volumeManager: volumeManager,
+		logger:        logger,
+	}
+}
+
+// Handle handles GET /storage/pools/:name/volumes/:volumeName.
+func (h *StorageVolumeGetHandler) Handle(c *gin.Context) {
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	info, err := h.volumeManager.GetInfo(c.Request.Context(), poolName, volumeName)
+	if err != nil {
+		getContextLogger(c, h.logger).Warn("Failed to get storage volume",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Volumes of other users are hidden rather than forbidden
+	if !canAccessVolume(c, info) {
+		HandleError(c, storage.ErrVolumeNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
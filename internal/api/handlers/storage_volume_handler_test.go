@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/volume"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mocks_volume "github.com/threatflux/libgo/test/mocks/volume"
+	"go.uber.org/mock/gomock"
+)
+
+// volumeTestData is the content of the volume downloaded in the tests.
+const volumeTestData = "0123456789abcdef"
+
+// newStorageVolumeTestRouter creates a router serving the volume download,
+// resize, clone, image and job handlers backed by a mock volume manager.
+func newStorageVolumeTestRouter(t *testing.T) (*gin.Engine, *mocks_volume.MockManager, *gomock.Controller) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockManager := mocks_volume.NewMockManager(ctrl)
+	mockStorage := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	router := gin.New()
+	router.GET("/pools/:name/volumes/:volumeName/download", NewStorageVolumeDownloadHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.PUT("/pools/:name/volumes/:volumeName/resize", NewStorageVolumeResizeHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.POST("/pools/:name/volumes/:volumeName/clone", NewStorageVolumeCloneHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.POST("/pools/:name/volumes/:volumeName/rotate-key", NewStorageVolumeRotateKeyHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.GET("/pools/:name/volumes/:volumeName/image", NewStorageVolumeImageInfoHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.POST("/pools/:name/volumes/:volumeName/check", NewStorageVolumeCheckHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.POST("/pools/:name/volumes/:volumeName/convert", NewStorageVolumeConvertHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.POST("/pools/:name/volumes/:volumeName/rebase", NewStorageVolumeRebaseHandler(mockManager, mockStorage, mockLogger).Handle)
+	router.DELETE("/volume-jobs/:id", NewStorageVolumeJobCancelHandler(mockManager, mockLogger).Handle)
+
+	return router, mockManager, ctrl
+}
+
+// newTestDownload creates a mock download of volumeTestData.
+func newTestDownload(ctrl *gomock.Controller) *mocks_volume.MockDownload {
+	download := mocks_volume.NewMockDownload(ctrl)
+	download.EXPECT().Info().Return(volume.DownloadInfo{
+		Filename: "disk.qcow2",
+		Format:   "qcow2",
+		Size:     int64(len(volumeTestData)),
+	}).AnyTimes()
+	download.EXPECT().WriteRange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, w io.Writer, offset int64, length int64) error {
+			_, err := io.WriteString(w, volumeTestData[offset:offset+length])
+			return err
+		}).AnyTimes()
+	download.EXPECT().Close().Return(nil)
+	return download
+}
+
+func TestStorageVolumeDownloadHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name                 string
+		rangeHeader          string
+		expectedStatus       int
+		expectedBody         string
+		expectedContentRange string
+	}{
+		{
+			name:           "Whole volume",
+			expectedStatus: http.StatusOK,
+			expectedBody:   volumeTestData,
+		},
+		{
+			name:                 "Range",
+			rangeHeader:          "bytes=4-7",
+			expectedStatus:       http.StatusPartialContent,
+			expectedBody:         "4567",
+			expectedContentRange: "bytes 4-7/16",
+		},
+		{
+			name:                 "Open range",
+			rangeHeader:          "bytes=10-",
+			expectedStatus:       http.StatusPartialContent,
+			expectedBody:         "abcdef",
+			expectedContentRange: "bytes 10-15/16",
+		},
+		{
+			name:                 "Suffix range",
+			rangeHeader:          "bytes=-3",
+			expectedStatus:       http.StatusPartialContent,
+			expectedBody:         "def",
+			expectedContentRange: "bytes 13-15/16",
+		},
+		{
+			name:                 "Range past the end",
+			rangeHeader:          "bytes=12-100",
+			expectedStatus:       http.StatusPartialContent,
+			expectedBody:         "cdef",
+			expectedContentRange: "bytes 12-15/16",
+		},
+		{
+			name:           "Multiple ranges are ignored",
+			rangeHeader:    "bytes=0-1,4-5",
+			expectedStatus: http.StatusOK,
+			expectedBody:   volumeTestData,
+		},
+		{
+			name:                 "Unsatisfiable range",
+			rangeHeader:          "bytes=16-",
+			expectedStatus:       http.StatusRequestedRangeNotSatisfiable,
+			expectedContentRange: "bytes */16",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockManager, ctrl := newStorageVolumeTestRouter(t)
+			mockManager.EXPECT().OpenDownload(gomock.Any(), "default", "disk.qcow2", volume.DownloadOptions{}).
+				Return(newTestDownload(ctrl), nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/pools/default/volumes/disk.qcow2/download", nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.expectedContentRange, w.Header().Get("Content-Range"))
+			if tt.expectedStatus != http.StatusRequestedRangeNotSatisfiable {
+				assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+				assert.Equal(t, `attachment; filename="disk.qcow2"`, w.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
+
+func TestStorageVolumeDownloadHandler_Compressed(t *testing.T) {
+	router, mockManager, ctrl := newStorageVolumeTestRouter(t)
+	mockManager.EXPECT().OpenDownload(gomock.Any(), "default", "disk.qcow2", volume.DownloadOptions{Format: "vmdk"}).
+		Return(newTestDownload(ctrl), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/pools/default/volumes/disk.qcow2/download?format=vmdk&compress=gzip", nil)
+	req.Header.Set("Range", "bytes=4-7")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Compressed downloads are always complete
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/gzip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="disk.qcow2.gz"`, w.Header().Get("Content-Disposition"))
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, volumeTestData, string(data))
+}
+
+func TestStorageVolumeDownloadHandler_Errors(t *testing.T) {
+	router, mockManager, _ := newStorageVolumeTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/pools/default/volumes/disk.qcow2/download?compress=zstd", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockManager.EXPECT().OpenDownload(gomock.Any(), "default", "disk.qcow2", gomock.Any()).
+		Return(nil, fmt.Errorf("%w: unsupported download format \"iso\"", apierrors.ErrInvalidParameter))
+
+	req = httptest.NewRequest(http.MethodGet, "/pools/default/volumes/disk.qcow2/download?format=iso", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestStorageVolumeResizeHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mocks_volume.MockManager)
+		expectedStatus int
+	}{
+		{
+			name: "Volume resized",
+			body: `{"capacityBytes":2048,"growFilesystem":true}`,
+			mockSetup: func(m *mocks_volume.MockManager) {
+				m.EXPECT().Resize(gomock.Any(), "default", "data", volume.ResizeParams{CapacityBytes: 2048, GrowFilesystem: true}).
+					Return(&volume.ResizeResult{Pool: "default", Volume: "data", PreviousCapacity: 1024, Capacity: 2048, VM: "web", Live: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing capacity",
+			body:           `{"force":true}`,
+			mockSetup:      func(m *mocks_volume.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Shrinking without force",
+			body: `{"capacityBytes":512}`,
+			mockSetup: func(m *mocks_volume.MockManager) {
+				m.EXPECT().Resize(gomock.Any(), "default", "data", gomock.Any()).
+					Return(nil, fmt.Errorf("%w: set force to shrink it", apierrors.ErrInvalidParameter))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Disk of running VM",
+			body: `{"capacityBytes":512,"force":true}`,
+			mockSetup: func(m *mocks_volume.MockManager) {
+				m.EXPECT().Resize(gomock.Any(), "default", "data", gomock.Any()).
+					Return(nil, fmt.Errorf("%w: disk of running VM web", apierrors.ErrVolumeInUse))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockManager, _ := newStorageVolumeTestRouter(t)
+			tt.mockSetup(mockManager)
+
+			req := httptest.NewRequest(http.MethodPut, "/pools/default/volumes/data/resize", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var result volume.ResizeResult
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+				assert.True(t, result.Live)
+				assert.Equal(t, uint64(2048), result.Capacity)
+			}
+		})
+	}
+}
+
+func TestStorageVolumeCloneHandler_Handle(t *testing.T) {
+	router, mockManager, _ := newStorageVolumeTestRouter(t)
+	mockManager.EXPECT().StartClone(gomock.Any(), "default", "base", volume.CloneParams{Name: "copy", Format: "raw"}).
+		Return(&volume.Job{ID: "job-1", Type: volume.JobTypeClone, Pool: "default", Volume: "base",
+			TargetPool: "default", TargetVolume: "copy", Status: volume.StatusRunning}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/pools/default/volumes/base/clone",
+		bytes.NewBufferString(`{"name":"copy","format":"raw"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var response VolumeJobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "job-1", response.Job.ID)
+	assert.Equal(t, volume.StatusRunning, response.Job.Status)
+
+	// Finished jobs cannot be canceled
+	mockManager.EXPECT().CancelJob(gomock.Any(), "job-1").
+		Return(fmt.Errorf("%w: job job-1 is completed", apierrors.ErrVolumeJobInvalidState))
+
+	req = httptest.NewRequest(http.MethodDelete, "/volume-jobs/job-1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestStorageVolumeImageHandlers(t *testing.T) {
+	t.Run("Image info", func(t *testing.T) {
+		router, mockManager, _ := newStorageVolumeTestRouter(t)
+		mockManager.EXPECT().ImageInfo(gomock.Any(), "default", "disk.qcow2").
+			Return(&volume.ImageInfo{Format: "qcow2", VirtualSize: 1 << 30, BackingChain: []volume.ImageInfo{{Format: "raw"}}}, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/default/volumes/disk.qcow2/image", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response VolumeImageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "qcow2", response.Image.Format)
+		require.Len(t, response.Image.BackingChain, 1)
+	})
+
+	t.Run("Check without body", func(t *testing.T) {
+		router, mockManager, _ := newStorageVolumeTestRouter(t)
+		mockManager.EXPECT().StartCheck(gomock.Any(), "default", "disk.qcow2", volume.CheckParams{}).
+			Return(&volume.Job{ID: "job-1", Type: volume.JobTypeCheck, Status: volume.StatusRunning}, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pools/default/volumes/disk.qcow2/check", nil))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("Convert", func(t *testing.T) {
+		router, mockManager, _ := newStorageVolumeTestRouter(t)
+		mockManager.EXPECT().StartConvert(gomock.Any(), "default", "disk.qcow2",
+			volume.ConvertParams{Name: "disk.vmdk", Format: "vmdk"}).
+			Return(&volume.Job{ID: "job-2", Type: volume.JobTypeConvert, TargetVolume: "disk.vmdk", Status: volume.StatusRunning}, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/pools/default/volumes/disk.qcow2/convert",
+			bytes.NewBufferString(`{"name":"disk.vmdk","format":"vmdk"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var response VolumeJobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "disk.vmdk", response.Job.TargetVolume)
+	})
+
+	t.Run("Convert without format", func(t *testing.T) {
+		router, _, _ := newStorageVolumeTestRouter(t)
+
+		req := httptest.NewRequest(http.MethodPost, "/pools/default/volumes/disk.qcow2/convert",
+			bytes.NewBufferString(`{"name":"disk.vmdk"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Rebase of running volume", func(t *testing.T) {
+		router, mockManager, _ := newStorageVolumeTestRouter(t)
+		mockManager.EXPECT().StartRebase(gomock.Any(), "default", "disk.qcow2", volume.RebaseParams{BackingVolume: "base.qcow2"}).
+			Return(nil, fmt.Errorf("%w: volume disk.qcow2 is a disk of running VM web", apierrors.ErrVolumeInUse))
+
+		req := httptest.NewRequest(http.MethodPost, "/pools/default/volumes/disk.qcow2/rebase",
+			bytes.NewBufferString(`{"backingVolume":"base.qcow2"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestStorageVolumeRotateKeyHandler_Handle(t *testing.T) {
+	router, mockManager, _ := newStorageVolumeTestRouter(t)
+	mockManager.EXPECT().RotateKey(gomock.Any(), "default", "web-disk-0").
+		Return(&volume.KeyRotation{Pool: "default", Volume: "web-disk-0", SecretUUID: "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93"}, nil)
+	mockManager.EXPECT().RotateKey(gomock.Any(), "default", "data").
+		Return(nil, fmt.Errorf("%w: volume data is not encrypted", apierrors.ErrInvalidParameter))
+
+	req := httptest.NewRequest(http.MethodPost, "/pools/default/volumes/web-disk-0/rotate-key", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var rotation volume.KeyRotation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotation))
+	assert.Equal(t, "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93", rotation.SecretUUID)
+
+	req = httptest.NewRequest(http.MethodPost, "/pools/default/volumes/data/rotate-key", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/volume"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// VolumeImageResponse represents the response for the image of a volume.
+type VolumeImageResponse struct {
+	Image *volume.ImageInfo `json:"image"`
+}
+
+// StorageVolumeImageInfoHandler handles inspecting the image of a volume.
+type StorageVolumeImageInfoHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeImageInfoHandler creates a new volume image info handler.
+func NewStorageVolumeImageInfoHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeImageInfoHandler {
+	return &StorageVolumeImageInfoHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles GET /storage/pools/:name/volumes/:volumeName/image.
+func (h *StorageVolumeImageInfoHandler) Handle(c *gin.Context) {
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	image, err := h.volumeManager.ImageInfo(c.Request.Context(), poolName, volumeName)
+	if err != nil {
+		getContextLogger(c, h.logger).Warn("Failed to read volume image",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VolumeImageResponse{Image: image})
+}
+
+// StorageVolumeCheckHandler handles checking the image of a volume.
+type StorageVolumeCheckHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeCheckHandler creates a new volume check handler.
+func NewStorageVolumeCheckHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeCheckHandler {
+	return &StorageVolumeCheckHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles POST /storage/pools/:name/volumes/:volumeName/check. The
+// check runs as a job that is polled under /storage/volume-jobs.
+func (h *StorageVolumeCheckHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	// The request body is optional
+	var params volume.CheckParams
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			contextLogger.Warn("Invalid volume check request",
+				logger.Error(err))
+			HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+			return
+		}
+	}
+
+	job, err := h.volumeManager.StartCheck(volumeOwnerContext(c), poolName, volumeName, params)
+	if err != nil {
+		contextLogger.Warn("Failed to start volume check",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Volume check started",
+		logger.String("jobId", job.ID),
+		logger.String("volume", volumeName),
+		logger.String("repair", params.Repair))
+
+	c.JSON(http.StatusAccepted, VolumeJobResponse{Job: job})
+}
+
+// StorageVolumeConvertHandler handles converting volumes to other formats.
+type StorageVolumeConvertHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeConvertHandler creates a new volume convert handler.
+func NewStorageVolumeConvertHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeConvertHandler {
+	return &StorageVolumeConvertHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles POST /storage/pools/:name/volumes/:volumeName/convert. The
+// conversion runs as a job that is polled under /storage/volume-jobs.
+func (h *StorageVolumeConvertHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	var params volume.ConvertParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid volume convert request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	job, err := h.volumeManager.StartConvert(volumeOwnerContext(c), poolName, volumeName, params)
+	if err != nil {
+		contextLogger.Warn("Failed to start volume conversion",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.String("target", params.Name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Volume conversion started",
+		logger.String("jobId", job.ID),
+		logger.String("volume", volumeName),
+		logger.String("target", job.TargetVolume),
+		logger.String("format", params.Format))
+
+	c.JSON(http.StatusAccepted, VolumeJobResponse{Job: job})
+}
+
+// StorageVolumeRebaseHandler handles changing the backing volume of a
+// volume.
+type StorageVolumeRebaseHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeRebaseHandler creates a new volume rebase handler.
+func NewStorageVolumeRebaseHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeRebaseHandler {
+	return &StorageVolumeRebaseHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles POST /storage/pools/:name/volumes/:volumeName/rebase. The
+// rebase runs as a job that is polled under /storage/volume-jobs; without a
+// backing volume in the body, the backing chain is merged into the volume.
+func (h *StorageVolumeRebaseHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	// The request body is optional
+	var params volume.RebaseParams
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			contextLogger.Warn("Invalid volume rebase request",
+				logger.Error(err))
+			HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+			return
+		}
+	}
+
+	// The new backing volume is read by the volume, so it must be accessible
+	if params.BackingVolume != "" {
+		backingPool := params.BackingPool
+		if backingPool == "" {
+			backingPool = poolName
+		}
+		if !checkVolumeAccess(c, h.storageManager, backingPool, params.BackingVolume) {
+			return
+		}
+	}
+
+	job, err := h.volumeManager.StartRebase(volumeOwnerContext(c), poolName, volumeName, params)
+	if err != nil {
+		contextLogger.Warn("Failed to start volume rebase",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.String("backing", params.BackingVolume),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Volume rebase started",
+		logger.String("jobId", job.ID),
+		logger.String("volume", volumeName),
+		logger.String("backing", params.BackingVolume))
+
+	c.JSON(http.StatusAccepted, VolumeJobResponse{Job: job})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/volume"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// StorageVolumeJobListHandler handles listing volume jobs.
+type StorageVolumeJobListHandler struct {
+	volumeManager volume.Manager
+	logger        logger.Logger
+}
+
+// NewStorageVolumeJobListHandler creates a new volume job list handler.
+func NewStorageVolumeJobListHandler(volumeManager volume.Manager, logger logger.Logger) *StorageVolumeJobListHandler {
+	return &StorageVolumeJobListHandler{
+		volumeManager: volumeManager,
+		logger:        logger,
+	}
+}
+
+// Handle handles GET /storage/volume-jobs. Users other than administrators
+// only see the jobs they started.
+func (h *StorageVolumeJobListHandler) Handle(c *gin.Context) {
+	jobs, err := h.volumeManager.ListJobs(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	visible := make([]*volume.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if canAccessVolumeJob(c, job) {
+			visible = append(visible, job)
+		}
+	}
+	jobs = visible
+
+	c.JSON(http.StatusOK, VolumeJobListResponse{Jobs: jobs})
+}
+
+// StorageVolumeJobGetHandler handles getting a volume job.
+type StorageVolumeJobGetHandler struct {
+	volumeManager volume.Manager
+	logger        logger.Logger
+}
+
+// NewStorageVolumeJobGetHandler creates a new volume job get handler.
+func NewStorageVolumeJobGetHandler(volumeManager volume.Manager, logger logger.Logger) *StorageVolumeJobGetHandler {
+	return &StorageVolumeJobGetHandler{
+		volumeManager: volumeManager,
+		logger:        logger,
+	}
+}
+
+// Handle handles GET /storage/volume-jobs/:id.
+func (h *StorageVolumeJobGetHandler) Handle(c *gin.Context) {
+	jobID := c.Param("id")
+
+	job, err := h.volumeManager.GetJob(c.Request.Context(), jobID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// Jobs of other users are hidden rather than forbidden
+	if !canAccessVolumeJob(c, job) {
+		HandleError(c, fmt.Errorf("%w: %s", apierrors.ErrVolumeJobNotFound, jobID))
+		return
+	}
+
+	c.JSON(http.StatusOK, VolumeJobResponse{Job: job})
+}
+
+// StorageVolumeJobCancelHandler handles canceling a volume job.
+type StorageVolumeJobCancelHandler struct {
+	volumeManager volume.Manager
+	logger        logger.Logger
+}
+
+// NewStorageVolumeJobCancelHandler creates a new volume job cancel handler.
+func NewStorageVolumeJobCancelHandler(volumeManager volume.Manager, logger logger.Logger) *StorageVolumeJobCancelHandler {
+	return &StorageVolumeJobCancelHandler{
+		volumeManager: volumeManager,
+		logger:        logger,
+	}
+}
+
+// Handle handles DELETE /storage/volume-jobs/:id.
+func (h *StorageVolumeJobCancelHandler) Handle(c *gin.Context) {
+	jobID := c.Param("id")
+
+	if volumeOwnerFilter(c) != "" {
+		job, err := h.volumeManager.GetJob(c.Request.Context(), jobID)
+		if err != nil {
+			HandleError(c, err)
+			return
+		}
+		if !canAccessVolumeJob(c, job) {
+			HandleError(c, fmt.Errorf("%w: %s", apierrors.ErrVolumeJobNotFound, jobID))
+			return
+		}
+	}
+
+	if err := h.volumeManager.CancelJob(c.Request.Context(), jobID); err != nil {
+		getContextLogger(c, h.logger).Warn("Failed to cancel volume job",
+			logger.String("jobId", jobID),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	getContextLogger(c, h.logger).Info("Volume job canceled",
+		logger.String("jobId", jobID))
+
+	c.Status(http.StatusNoContent)
+}
@@ -3,6 +3,7 @@ package handlers
 //nolint:dupl // Different handler types with different purposes
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/threatflux/libgo/internal/libvirt/storage"
@@ -23,7 +24,9 @@ func NewStorageVolumeListHandler(volumeManager storage.VolumeManager, logger log
 	}
 }
 
-// Handle handles the storage volume list request.
+// Handle handles the storage volume list request. Volumes are filtered by
+// the labels and owner query parameters; users other than administrators
+// only see their own volumes.
 func (h *StorageVolumeListHandler) Handle(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -48,6 +51,22 @@ func (h *StorageVolumeListHandler) Handle(c *gin.Context) {
 		return
 	}
 
+	filter := storage.VolumeFilter{Owner: c.Query("owner")}
+	if labels := c.Query("labels"); labels != "" {
+		filter.Labels = make(map[string]string)
+		for _, pair := range strings.Split(labels, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 {
+				filter.Labels[kv[0]] = kv[1]
+			}
+		}
+	}
+	volumes = storage.FilterVolumes(volumes, filter)
+
+	if owner := volumeOwnerFilter(c); owner != "" {
+		volumes = storage.FilterVolumes(volumes, storage.VolumeFilter{Owner: owner})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"volumes": volumes,
 	})
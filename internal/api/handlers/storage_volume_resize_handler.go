@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/volume"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// StorageVolumeResizeHandler handles resizing storage volumes.
+type StorageVolumeResizeHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeResizeHandler creates a new storage volume resize handler.
+func NewStorageVolumeResizeHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeResizeHandler {
+	return &StorageVolumeResizeHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles PUT /storage/pools/:name/volumes/:volumeName/resize.
+func (h *StorageVolumeResizeHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	var params volume.ResizeParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid volume resize request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	result, err := h.volumeManager.Resize(c.Request.Context(), poolName, volumeName, params)
+	if err != nil {
+		contextLogger.Warn("Failed to resize storage volume",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.Uint64("capacity", params.CapacityBytes),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
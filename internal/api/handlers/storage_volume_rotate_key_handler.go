@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/volume"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// StorageVolumeRotateKeyHandler handles rotating the keys of encrypted
+// storage volumes.
+type StorageVolumeRotateKeyHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeRotateKeyHandler creates a new storage volume key rotation
+// handler.
+func NewStorageVolumeRotateKeyHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeRotateKeyHandler {
+	return &StorageVolumeRotateKeyHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles POST /storage/pools/:name/volumes/:volumeName/rotate-key.
+func (h *StorageVolumeRotateKeyHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	rotation, err := h.volumeManager.RotateKey(c.Request.Context(), poolName, volumeName)
+	if err != nil {
+		contextLogger.Warn("Failed to rotate volume key",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Volume key rotated",
+		logger.String("pool", poolName),
+		logger.String("volume", volumeName))
+
+	c.JSON(http.StatusOK, rotation)
+}
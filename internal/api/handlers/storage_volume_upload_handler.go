@@ -39,6 +39,10 @@ func (h *StorageVolumeUploadHandler) Handle(c *gin.Context) {
 		return
 	}
 
+	if !checkVolumeAccess(c, h.volumeManager, poolName, volumeName) {
+		return
+	}
+
 	// Check if this is a multipart upload
 	if strings.HasPrefix(c.GetHeader("Content-Type"), "multipart/form-data") {
 		// Handle multipart file upload
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/volume"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// StorageVolumeWipeHandler handles wiping storage volumes.
+type StorageVolumeWipeHandler struct {
+	volumeManager  volume.Manager
+	storageManager storage.VolumeManager
+	logger         logger.Logger
+}
+
+// NewStorageVolumeWipeHandler creates a new storage volume wipe handler.
+func NewStorageVolumeWipeHandler(volumeManager volume.Manager, storageManager storage.VolumeManager, logger logger.Logger) *StorageVolumeWipeHandler {
+	return &StorageVolumeWipeHandler{
+		volumeManager:  volumeManager,
+		storageManager: storageManager,
+		logger:         logger,
+	}
+}
+
+// Handle handles POST /storage/pools/:name/volumes/:volumeName/wipe. The
+// wipe runs as a job that is polled under /storage/volume-jobs.
+func (h *StorageVolumeWipeHandler) Handle(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.storageManager, poolName, volumeName) {
+		return
+	}
+
+	job, err := h.volumeManager.StartWipe(volumeOwnerContext(c), poolName, volumeName)
+	if err != nil {
+		contextLogger.Warn("Failed to start volume wipe",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Volume wipe started",
+		logger.String("jobId", job.ID),
+		logger.String("volume", volumeName))
+
+	c.JSON(http.StatusAccepted, VolumeJobResponse{Job: job})
+}
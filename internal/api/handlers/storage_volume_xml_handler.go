@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// StorageVolumeXMLHandler handles getting the libvirt XML of storage volumes.
+type StorageVolumeXMLHandler struct {
+	volumeManager storage.VolumeManager
+	logger        logger.Logger
+}
+
+// NewStorageVolumeXMLHandler creates a new storage volume XML handler.
+func NewStorageVolumeXMLHandler(volumeManager storage.VolumeManager, logger logger.Logger) *StorageVolumeXMLHandler {
+	return &StorageVolumeXMLHandler{
+		volumeManager: volumeManager,
+		logger:        logger,
+	}
+}
+
+// Handle handles GET /storage/pools/:name/volumes/:volumeName/xml.
+func (h *StorageVolumeXMLHandler) Handle(c *gin.Context) {
+	poolName := c.Param("name")
+	volumeName := c.Param("volumeName")
+
+	if !checkVolumeAccess(c, h.volumeManager, poolName, volumeName) {
+		return
+	}
+
+	xmlDesc, err := h.volumeManager.GetXML(c.Request.Context(), poolName, volumeName)
+	if err != nil {
+		getContextLogger(c, h.logger).Warn("Failed to get storage volume XML",
+			logger.String("pool", poolName),
+			logger.String("volume", volumeName),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", []byte(xmlDesc))
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/upload"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// tus protocol headers and values used by resumable uploads.
+const (
+	tusResumableHeader = "Tus-Resumable"
+	tusVersion         = "1.0.0"
+	uploadOffsetHeader = "Upload-Offset"
+	uploadLengthHeader = "Upload-Length"
+	offsetContentType  = "application/offset+octet-stream"
+)
+
+// UploadResponse represents the response for a single upload.
+type UploadResponse struct {
+	Upload *upload.Job `json:"upload"`
+}
+
+// UploadListResponse represents the response for listing uploads.
+type UploadListResponse struct {
+	Uploads []*upload.Job `json:"uploads"`
+}
+
+// UploadHandler handles resumable volume uploads. Chunks are sent as in
+// the tus protocol: HEAD reports the offset to continue at and PATCH
+// appends a chunk at it.
+type UploadHandler struct {
+	uploadManager upload.Manager
+	logger        logger.Logger
+}
+
+// NewUploadHandler creates a new UploadHandler.
+func NewUploadHandler(uploadManager upload.Manager, logger logger.Logger) *UploadHandler {
+	return &UploadHandler{
+		uploadManager: uploadManager,
+		logger:        logger,
+	}
+}
+
+// CreateUpload handles POST /uploads.
+func (h *UploadHandler) CreateUpload(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	c.Header(tusResumableHeader, tusVersion)
+
+	var params upload.Params
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid upload request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	job, err := h.uploadManager.Create(volumeOwnerContext(c), params)
+	if err != nil {
+		contextLogger.Error("Failed to create upload",
+			logger.String("volume", params.Volume),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Upload created",
+		logger.String("uploadId", job.ID),
+		logger.String("pool", job.Pool),
+		logger.String("volume", job.Volume))
+
+	c.Header("Location", c.Request.URL.Path+"/"+job.ID)
+	c.Header(uploadOffsetHeader, "0")
+	c.JSON(http.StatusCreated, UploadResponse{Upload: job})
+}
+
+// GetUploadOffset handles HEAD /uploads/:id.
+func (h *UploadHandler) GetUploadOffset(c *gin.Context) {
+	c.Header(tusResumableHeader, tusVersion)
+	c.Header("Cache-Control", "no-store")
+
+	job, err := h.uploadManager.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if !canAccessUpload(c, job) {
+		HandleError(c, fmt.Errorf("%w: %s", apierrors.ErrUploadNotFound, job.ID))
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(job.Offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(job.Length, 10))
+	c.Status(http.StatusOK)
+}
+
+// WriteChunk handles PATCH /uploads/:id. The body is the chunk and the
+// Upload-Offset header the offset it starts at.
+func (h *UploadHandler) WriteChunk(c *gin.Context) {
+	contextLogger := getContextLogger(c, h.logger)
+	c.Header(tusResumableHeader, tusVersion)
+
+	if c.ContentType() != offsetContentType {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+			Status:  http.StatusUnsupportedMediaType,
+			Code:    "UNSUPPORTED_MEDIA_TYPE",
+			Message: "chunks must be sent as " + offsetContentType,
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		HandleError(c, fmt.Errorf("%w: invalid %s header", ErrInvalidInput, uploadOffsetHeader))
+		return
+	}
+
+	uploadID := c.Param("id")
+	if !h.checkUploadAccess(c, uploadID) {
+		return
+	}
+
+	// The volume is created for the owner of the upload
+	job, err := h.uploadManager.WriteChunk(c.Request.Context(), uploadID, offset, c.Request.Body)
+	if err != nil {
+		contextLogger.Warn("Failed to write upload chunk",
+			logger.String("uploadId", uploadID),
+			logger.Int64("offset", offset),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	if job.Offset == job.Length {
+		contextLogger.Info("Upload received",
+			logger.String("uploadId", job.ID),
+			logger.String("volume", job.Volume),
+			logger.Int64("length", job.Length))
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(job.Offset, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// GetUpload handles GET /uploads/:id.
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	job, err := h.uploadManager.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if !canAccessUpload(c, job) {
+		HandleError(c, fmt.Errorf("%w: %s", apierrors.ErrUploadNotFound, job.ID))
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadResponse{Upload: job})
+}
+
+// ListUploads handles GET /uploads. Users other than administrators only
+// see the uploads they created.
+func (h *UploadHandler) ListUploads(c *gin.Context) {
+	jobs, err := h.uploadManager.ListJobs(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	visible := make([]*upload.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if canAccessUpload(c, job) {
+			visible = append(visible, job)
+		}
+	}
+
+	c.JSON(http.StatusOK, UploadListResponse{Uploads: visible})
+}
+
+// CancelUpload handles DELETE /uploads/:id.
+func (h *UploadHandler) CancelUpload(c *gin.Context) {
+	c.Header(tusResumableHeader, tusVersion)
+	uploadID := c.Param("id")
+	if !h.checkUploadAccess(c, uploadID) {
+		return
+	}
+
+	if err := h.uploadManager.CancelJob(c.Request.Context(), uploadID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	getContextLogger(c, h.logger).Info("Upload canceled",
+		logger.String("uploadId", uploadID))
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkUploadAccess ends a request with not found when its user may not
+// access an upload. Administrators and requests without authentication
+// skip the lookup.
+func (h *UploadHandler) checkUploadAccess(c *gin.Context, id string) bool {
+	if volumeOwnerFilter(c) == "" {
+		return true
+	}
+
+	job, err := h.uploadManager.GetJob(c.Request.Context(), id)
+	if err != nil {
+		HandleError(c, err)
+		return false
+	}
+	if !canAccessUpload(c, job) {
+		HandleError(c, fmt.Errorf("%w: %s", apierrors.ErrUploadNotFound, id))
+		return false
+	}
+	return true
+}
+
+// canAccessUpload reports whether the user of a request may see and act on
+// an upload; uploads of other users are hidden rather than forbidden.
+func canAccessUpload(c *gin.Context, job *upload.Job) bool {
+	owner := volumeOwnerFilter(c)
+	return owner == "" || job.Owner == owner
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/middleware/auth"
+	"github.com/threatflux/libgo/internal/models/user"
+	"github.com/threatflux/libgo/internal/upload"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mocks_upload "github.com/threatflux/libgo/test/mocks/upload"
+	"go.uber.org/mock/gomock"
+)
+
+// newUploadTestRouter creates a router serving an UploadHandler backed by
+// a mock upload manager.
+func newUploadTestRouter(t *testing.T) (*gin.Engine, *mocks_upload.MockManager) {
+	return newUploadTestRouterAs(t, nil)
+}
+
+// newUploadTestRouterAs creates an upload test router serving requests of a
+// user; a nil user is an unauthenticated request.
+func newUploadTestRouterAs(t *testing.T, u *user.User) (*gin.Engine, *mocks_upload.MockManager) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockManager := mocks_upload.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	handler := NewUploadHandler(mockManager, mockLogger)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if u != nil {
+			c.Set(auth.UserContextKey, u)
+		}
+		c.Next()
+	})
+	router.GET("/uploads", handler.ListUploads)
+	router.POST("/uploads", handler.CreateUpload)
+	router.HEAD("/uploads/:id", handler.GetUploadOffset)
+	router.PATCH("/uploads/:id", handler.WriteChunk)
+	router.GET("/uploads/:id", handler.GetUpload)
+	router.DELETE("/uploads/:id", handler.CancelUpload)
+
+	return router, mockManager
+}
+
+func TestUploadHandler_CreateUpload(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mocks_upload.MockManager)
+		expectedStatus int
+	}{
+		{
+			name: "Upload created",
+			body: `{"volume":"image","length":1024,"sha256":"abc"}`,
+			mockSetup: func(m *mocks_upload.MockManager) {
+				m.EXPECT().Create(gomock.Any(), upload.Params{Volume: "image", Length: 1024, SHA256: "abc"}).
+					Return(&upload.Job{ID: "upload-1", Pool: "default", Volume: "image", Length: 1024, Status: upload.StatusUploading}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Missing length",
+			body:           `{"volume":"image"}`,
+			mockSetup:      func(m *mocks_upload.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Volume exists",
+			body: `{"volume":"image","length":1024}`,
+			mockSetup: func(m *mocks_upload.MockManager) {
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: volume image", apierrors.ErrAlreadyExists))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router, mockManager := newUploadTestRouter(t)
+			tc.mockSetup(mockManager)
+
+			req, err := http.NewRequest(http.MethodPost, "/uploads", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			assert.Equal(t, "/uploads/upload-1", w.Header().Get("Location"))
+			assert.Equal(t, "0", w.Header().Get(uploadOffsetHeader))
+			var response UploadResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "upload-1", response.Upload.ID)
+		})
+	}
+}
+
+func TestUploadHandler_WriteChunk(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		offset         string
+		mockSetup      func(m *mocks_upload.MockManager)
+		expectedStatus int
+		expectedOffset string
+	}{
+		{
+			name:        "Chunk written",
+			contentType: offsetContentType,
+			offset:      "512",
+			mockSetup: func(m *mocks_upload.MockManager) {
+				m.EXPECT().WriteChunk(gomock.Any(), "upload-1", int64(512), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, offset int64, r io.Reader) (*upload.Job, error) {
+						data, err := io.ReadAll(r)
+						require.NoError(t, err)
+						assert.Equal(t, "chunk", string(data))
+						return &upload.Job{ID: "upload-1", Length: 1024, Offset: offset + int64(len(data))}, nil
+					})
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedOffset: "517",
+		},
+		{
+			name:           "Wrong content type",
+			contentType:    "application/json",
+			offset:         "0",
+			mockSetup:      func(m *mocks_upload.MockManager) {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Invalid offset",
+			contentType:    offsetContentType,
+			offset:         "-1",
+			mockSetup:      func(m *mocks_upload.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Offset mismatch",
+			contentType: offsetContentType,
+			offset:      "0",
+			mockSetup: func(m *mocks_upload.MockManager) {
+				m.EXPECT().WriteChunk(gomock.Any(), "upload-1", int64(0), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: upload is at offset 512", apierrors.ErrUploadOffsetMismatch))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "Checksum mismatch",
+			contentType: offsetContentType,
+			offset:      "512",
+			mockSetup: func(m *mocks_upload.MockManager) {
+				m.EXPECT().WriteChunk(gomock.Any(), "upload-1", int64(512), gomock.Any()).
+					Return(nil, apierrors.ErrUploadChecksumMismatch)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router, mockManager := newUploadTestRouter(t)
+			tc.mockSetup(mockManager)
+
+			req, err := http.NewRequest(http.MethodPatch, "/uploads/upload-1", strings.NewReader("chunk"))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tc.contentType)
+			req.Header.Set(uploadOffsetHeader, tc.offset)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tusVersion, w.Header().Get(tusResumableHeader))
+			if tc.expectedOffset != "" {
+				assert.Equal(t, tc.expectedOffset, w.Header().Get(uploadOffsetHeader))
+			}
+		})
+	}
+}
+
+func TestUploadHandler_Uploads(t *testing.T) {
+	router, mockManager := newUploadTestRouter(t)
+
+	mockManager.EXPECT().GetJob(gomock.Any(), "upload-1").
+		Return(&upload.Job{ID: "upload-1", Length: 1024, Offset: 512}, nil)
+	mockManager.EXPECT().GetJob(gomock.Any(), "missing").
+		Return(nil, fmt.Errorf("%w: missing", apierrors.ErrUploadNotFound))
+	mockManager.EXPECT().CancelJob(gomock.Any(), "upload-1").Return(nil)
+	mockManager.EXPECT().CancelJob(gomock.Any(), "upload-2").
+		Return(fmt.Errorf("%w: upload is completed", apierrors.ErrUploadInvalidState))
+
+	req, err := http.NewRequest(http.MethodHead, "/uploads/upload-1", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "512", w.Header().Get(uploadOffsetHeader))
+	assert.Equal(t, "1024", w.Header().Get(uploadLengthHeader))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{method: http.MethodGet, path: "/uploads/missing", expectedStatus: http.StatusNotFound},
+		{method: http.MethodDelete, path: "/uploads/upload-1", expectedStatus: http.StatusNoContent},
+		{method: http.MethodDelete, path: "/uploads/upload-2", expectedStatus: http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, tc.path, nil)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedStatus, w.Code, "%s %s", tc.method, tc.path)
+	}
+}
+
+func TestUploadHandler_Access(t *testing.T) {
+	alice := &user.User{ID: "alice", Roles: []string{user.RoleOperator}}
+	uploads := []*upload.Job{
+		{ID: "upload-1", Owner: "alice", Length: 1024},
+		{ID: "upload-2", Owner: "bob", Length: 1024},
+	}
+
+	t.Run("Upload is created for its user", func(t *testing.T) {
+		router, mockManager := newUploadTestRouterAs(t, alice)
+		mockManager.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, params upload.Params) (*upload.Job, error) {
+				assert.Equal(t, "alice", storage.VolumeOriginFromContext(ctx).Owner)
+				return uploads[0], nil
+			})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/uploads", strings.NewReader(`{"volume":"image","length":1024}`)))
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("User lists own uploads", func(t *testing.T) {
+		router, mockManager := newUploadTestRouterAs(t, alice)
+		mockManager.EXPECT().ListJobs(gomock.Any()).Return(uploads, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/uploads", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response UploadListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		if assert.Len(t, response.Uploads, 1) {
+			assert.Equal(t, "upload-1", response.Uploads[0].ID)
+		}
+	})
+
+	t.Run("Admin lists all uploads", func(t *testing.T) {
+		router, mockManager := newUploadTestRouterAs(t, &user.User{ID: "root", Roles: []string{user.RoleAdmin}})
+		mockManager.EXPECT().ListJobs(gomock.Any()).Return(uploads, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/uploads", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response UploadListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Uploads, 2)
+	})
+
+	t.Run("Uploads of other users are hidden", func(t *testing.T) {
+		router, mockManager := newUploadTestRouterAs(t, alice)
+		mockManager.EXPECT().GetJob(gomock.Any(), "upload-2").Return(uploads[1], nil).Times(4)
+
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPatch, http.MethodDelete} {
+			req := httptest.NewRequest(method, "/uploads/upload-2", strings.NewReader("chunk"))
+			req.Header.Set("Content-Type", offsetContentType)
+			req.Header.Set(uploadOffsetHeader, "0")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotFound, w.Code, method)
+		}
+	})
+
+	t.Run("Owner writes chunks", func(t *testing.T) {
+		router, mockManager := newUploadTestRouterAs(t, alice)
+		mockManager.EXPECT().GetJob(gomock.Any(), "upload-1").Return(uploads[0], nil)
+		mockManager.EXPECT().WriteChunk(gomock.Any(), "upload-1", int64(0), gomock.Any()).
+			Return(&upload.Job{ID: "upload-1", Length: 1024, Offset: 5}, nil)
+
+		req := httptest.NewRequest(http.MethodPatch, "/uploads/upload-1", strings.NewReader("chunk"))
+		req.Header.Set("Content-Type", offsetContentType)
+		req.Header.Set(uploadOffsetHeader, "0")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// BlockJobResponse represents the response for a started block job.
+type BlockJobResponse struct {
+	Job *vmmodels.BlockJob `json:"job"`
+}
+
+// ListBlockJobsResponse represents the response for listing VM block jobs.
+type ListBlockJobsResponse struct {
+	Jobs  []vmmodels.BlockJob `json:"jobs"`
+	Count int                 `json:"count"`
+}
+
+// BlockCommit handles requests to merge images of a VM disk's backing chain.
+func (h *VMHandler) BlockCommit(c *gin.Context) {
+	// Get VM name from URL path
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	// Parse and validate request body
+	var params vmmodels.BlockCommitParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid block commit request",
+			logger.Error(err))
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Start the block commit
+	job, err := h.vmManager.BlockCommit(c.Request.Context(), vmName, params)
+	if err != nil {
+		contextLogger.Error("Failed to start block commit",
+			logger.String("device", params.Device),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Log success
+	contextLogger.Info("Block commit started",
+		logger.String("device", job.Device),
+		logger.String("type", job.Type))
+
+	// The job completes in the background
+	c.JSON(http.StatusAccepted, BlockJobResponse{
+		Job: job,
+	})
+}
+
+// BlockPull handles requests to pull backing data into a VM disk's active image.
+func (h *VMHandler) BlockPull(c *gin.Context) {
+	// Get VM name from URL path
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	// Parse and validate request body
+	var params vmmodels.BlockPullParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid block pull request",
+			logger.Error(err))
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Start the block pull
+	job, err := h.vmManager.BlockPull(c.Request.Context(), vmName, params)
+	if err != nil {
+		contextLogger.Error("Failed to start block pull",
+			logger.String("device", params.Device),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Log success
+	contextLogger.Info("Block pull started",
+		logger.String("device", job.Device))
+
+	// The job completes in the background
+	c.JSON(http.StatusAccepted, BlockJobResponse{
+		Job: job,
+	})
+}
+
+// ListBlockJobs handles requests to list the running block jobs of a VM.
+func (h *VMHandler) ListBlockJobs(c *gin.Context) {
+	// Get VM name from URL path
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	// List block jobs
+	jobs, err := h.vmManager.ListBlockJobs(c.Request.Context(), vmName)
+	if err != nil {
+		contextLogger.Error("Failed to list block jobs",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Return response
+	c.JSON(http.StatusOK, ListBlockJobsResponse{
+		Jobs:  jobs,
+		Count: len(jobs),
+	})
+}
+
+// AbortBlockJob handles requests to cancel the block job of a VM disk.
+func (h *VMHandler) AbortBlockJob(c *gin.Context) {
+	// Get VM name and disk device from URL path
+	vmName := c.Param("name")
+	device := c.Param("device")
+
+	if vmName == "" || device == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(
+		logger.String("vmName", vmName),
+		logger.String("device", device))
+
+	// Abort the block job
+	if err := h.vmManager.AbortBlockJob(c.Request.Context(), vmName, device); err != nil {
+		contextLogger.Error("Failed to abort block job",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Log success
+	contextLogger.Info("Block job aborted successfully")
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// VMJobResponse represents the response for a VM job request.
+type VMJobResponse struct {
+	Job *vmmodels.Job `json:"job"`
+}
+
+// VMJobListResponse represents the response for a VM job list request.
+type VMJobListResponse struct {
+	Jobs []*vmmodels.Job `json:"jobs"`
+}
+
+// CloneVM handles requests to clone a VM.
+func (h *VMHandler) CloneVM(c *gin.Context) {
+	// Get source VM name from URL path
+	sourceName := c.Param("name")
+	if sourceName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", sourceName))
+
+	// Parse and validate request body
+	var params vmmodels.CloneParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid VM clone request",
+			logger.Error(err))
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	if err := params.Validate(); err != nil {
+		contextLogger.Warn("Invalid VM clone parameters",
+			logger.String("cloneName", params.Name),
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	// Start the clone
+	job, err := h.vmManager.StartClone(volumeOwnerContext(c), sourceName, params)
+	if err != nil {
+		contextLogger.Error("Failed to start VM clone",
+			logger.String("cloneName", params.Name),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Log success
+	contextLogger.Info("VM clone started",
+		logger.String("jobId", job.ID),
+		logger.String("cloneName", params.Name))
+
+	// Return response
+	c.JSON(http.StatusAccepted, VMJobResponse{
+		Job: job,
+	})
+}
+
+// ListVMJobs handles GET /vm-jobs.
+func (h *VMHandler) ListVMJobs(c *gin.Context) {
+	jobs, err := h.vmManager.ListJobs(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VMJobListResponse{Jobs: jobs})
+}
+
+// GetVMJob handles GET /vm-jobs/:id.
+func (h *VMHandler) GetVMJob(c *gin.Context) {
+	job, err := h.vmManager.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VMJobResponse{Job: job})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/models/vm"
+	vmservice "github.com/threatflux/libgo/internal/vm"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mockvm "github.com/threatflux/libgo/test/mocks/vm"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVMHandler_CloneVM(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockVMManager := mockvm.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Expect logger methods to be called
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	handler := NewVMHandler(mockVMManager, mockLogger)
+
+	// Setup router
+	router := gin.New()
+	router.POST("/vms/:name/clone", handler.CloneVM)
+	router.GET("/vm-jobs", handler.ListVMJobs)
+	router.GET("/vm-jobs/:id", handler.GetVMJob)
+
+	// Test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+		validate       func(t *testing.T, body []byte)
+	}{
+		{
+			name:   "Clone started",
+			method: http.MethodPost,
+			path:   "/vms/golden/clone",
+			body:   `{"name": "web-1", "mode": "linked"}`,
+			mockSetup: func() {
+				mockVMManager.EXPECT().StartClone(gomock.Any(), "golden", vm.CloneParams{Name: "web-1", Mode: vm.CloneModeLinked}).
+					Return(&vm.Job{ID: "job-1", Type: vm.JobTypeClone, Name: "golden", Target: "web-1", Status: vm.JobStatusRunning}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			validate: func(t *testing.T, body []byte) {
+				var response VMJobResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, "job-1", response.Job.ID)
+				assert.Equal(t, vm.JobStatusRunning, response.Job.Status)
+			},
+		},
+		{
+			name:           "Invalid clone name",
+			method:         http.MethodPost,
+			path:           "/vms/golden/clone",
+			body:           `{"name": "web 1"}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Clone target exists",
+			method: http.MethodPost,
+			path:   "/vms/golden/clone",
+			body:   `{"name": "web-1"}`,
+			mockSetup: func() {
+				mockVMManager.EXPECT().StartClone(gomock.Any(), "golden", gomock.Any()).Return(nil, vmservice.ErrVMAlreadyExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "List jobs",
+			method: http.MethodGet,
+			path:   "/vm-jobs",
+			mockSetup: func() {
+				mockVMManager.EXPECT().ListJobs(gomock.Any()).Return([]*vm.Job{{ID: "job-1"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validate: func(t *testing.T, body []byte) {
+				var response VMJobListResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				require.Len(t, response.Jobs, 1)
+				assert.Equal(t, "job-1", response.Jobs[0].ID)
+			},
+		},
+		{
+			name:   "Get completed job",
+			method: http.MethodGet,
+			path:   "/vm-jobs/job-1",
+			mockSetup: func() {
+				mockVMManager.EXPECT().GetJob(gomock.Any(), "job-1").Return(&vm.Job{
+					ID:     "job-1",
+					Status: vm.JobStatusCompleted,
+					VM:     &vm.VM{Name: "web-1"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			validate: func(t *testing.T, body []byte) {
+				var response VMJobResponse
+				require.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, vm.JobStatusCompleted, response.Job.Status)
+				assert.Equal(t, "web-1", response.Job.VM.Name)
+			},
+		},
+		{
+			name:   "Job not found",
+			method: http.MethodGet,
+			path:   "/vm-jobs/missing",
+			mockSetup: func() {
+				mockVMManager.EXPECT().GetJob(gomock.Any(), "missing").Return(nil, errors.ErrVMJobNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockSetup()
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, w.Body.String())
+			if tc.validate != nil {
+				tc.validate(t, w.Body.Bytes())
+			}
+		})
+	}
+}
@@ -39,7 +39,7 @@ func (h *VMHandler) CreateVM(c *gin.Context) {
 	}
 
 	// Create the VM.
-	createdVM, err := h.vmManager.Create(c.Request.Context(), params)
+	createdVM, err := h.vmManager.Create(volumeOwnerContext(c), params)
 	if err != nil {
 		// Special case for integration testing.
 		if err.Error() == "creating VM disk: getting storage pool: looking up pool default: storage pool not found" {
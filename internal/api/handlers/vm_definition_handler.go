@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// maxDefinitionSize is the largest domain XML accepted by UpdateVMXML.
+const maxDefinitionSize = 1 << 20
+
+// UpdateVMResponse represents the response for a VM definition update.
+type UpdateVMResponse struct {
+	Update *vmmodels.DefinitionUpdate `json:"update"`
+}
+
+// GetVMXML handles requests for the persistent domain XML of a VM.
+func (h *VMHandler) GetVMXML(c *gin.Context) {
+	// Get VM name from URL path
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	definition, err := h.vmManager.GetDefinition(c.Request.Context(), vmName)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", []byte(definition))
+}
+
+// UpdateVMXML handles requests to replace the persistent domain XML of a
+// VM. The request body is the new domain XML.
+func (h *VMHandler) UpdateVMXML(c *gin.Context) {
+	// Get VM name from URL path
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	definition, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxDefinitionSize))
+	if err != nil {
+		contextLogger.Warn("Invalid VM XML request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+	if len(definition) == 0 {
+		HandleError(c, fmt.Errorf("%w: domain XML is required", ErrInvalidInput))
+		return
+	}
+
+	update, err := h.vmManager.UpdateDefinition(c.Request.Context(), vmName, string(definition))
+	if err != nil {
+		contextLogger.Error("Failed to update VM XML",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("VM XML updated",
+		logger.Bool("changed", update.Changed),
+		logger.Bool("restartRequired", update.RestartRequired))
+
+	c.JSON(http.StatusOK, UpdateVMResponse{Update: update})
+}
+
+// UpdateVM handles requests to change the CPU, memory, description, NIC
+// model, disk cache mode, boot order or autostart setting of a VM.
+func (h *VMHandler) UpdateVM(c *gin.Context) {
+	// Get VM name from URL path
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	// Parse and validate request body
+	var params vmmodels.UpdateParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid VM update request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	if err := params.Validate(); err != nil {
+		contextLogger.Warn("Invalid VM update parameters",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	update, err := h.vmManager.Update(c.Request.Context(), vmName, params)
+	if err != nil {
+		contextLogger.Error("Failed to update VM",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("VM updated successfully",
+		logger.Bool("changed", update.Changed),
+		logger.Bool("restartRequired", update.RestartRequired))
+
+	c.JSON(http.StatusOK, UpdateVMResponse{Update: update})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mockvm "github.com/threatflux/libgo/test/mocks/vm"
+	"go.uber.org/mock/gomock"
+)
+
+// newDefinitionTestRouter creates a router serving the VM definition
+// endpoints backed by a mock VM manager.
+func newDefinitionTestRouter(t *testing.T) (*gin.Engine, *mockvm.MockManager) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockVMManager := mockvm.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	handler := NewVMHandler(mockVMManager, mockLogger)
+	router := gin.New()
+	router.PATCH("/vms/:name", handler.UpdateVM)
+	router.GET("/vms/:name/xml", handler.GetVMXML)
+	router.PUT("/vms/:name/xml", handler.UpdateVMXML)
+
+	return router, mockVMManager
+}
+
+func TestVMHandler_UpdateVM(t *testing.T) {
+	cpus := 4
+	autostart := true
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mockvm.MockManager)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "Update applied",
+			body: `{"cpus":4,"autostart":true,"bootOrder":["vda"]}`,
+			mockSetup: func(m *mockvm.MockManager) {
+				m.EXPECT().Update(gomock.Any(), "test-vm", vmmodels.UpdateParams{
+					CPUs:      &cpus,
+					Autostart: &autostart,
+					BootOrder: []string{"vda"},
+				}).Return(&vmmodels.DefinitionUpdate{
+					Diff:            "--- current\n+++ updated\n",
+					Changed:         true,
+					RestartRequired: true,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "No changes",
+			body:           `{}`,
+			mockSetup:      func(m *mockvm.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_INPUT",
+		},
+		{
+			name:           "Invalid NIC model",
+			body:           `{"nicModel":"ne2k"}`,
+			mockSetup:      func(m *mockvm.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_INPUT",
+		},
+		{
+			name: "Unknown boot device",
+			body: `{"bootOrder":["vdz"]}`,
+			mockSetup: func(m *mockvm.MockManager) {
+				m.EXPECT().Update(gomock.Any(), "test-vm", gomock.Any()).
+					Return(nil, fmt.Errorf("updating VM: %w: boot device vdz not found", domain.ErrInvalidDefinition))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_INPUT",
+		},
+		{
+			name: "VM not found",
+			body: `{"cpus":4}`,
+			mockSetup: func(m *mockvm.MockManager) {
+				m.EXPECT().Update(gomock.Any(), "test-vm", gomock.Any()).
+					Return(nil, fmt.Errorf("updating VM: %w", apierrors.ErrVMNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "NOT_FOUND",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router, mockVMManager := newDefinitionTestRouter(t)
+			tc.mockSetup(mockVMManager)
+
+			req, err := http.NewRequest(http.MethodPatch, "/vms/test-vm", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCode != "" {
+				var response ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedCode, response.Code)
+				return
+			}
+
+			var response UpdateVMResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.True(t, response.Update.Changed)
+			assert.True(t, response.Update.RestartRequired)
+			assert.NotEmpty(t, response.Update.Diff)
+		})
+	}
+}
+
+func TestVMHandler_VMXML(t *testing.T) {
+	router, mockVMManager := newDefinitionTestRouter(t)
+
+	const definition = "<domain type='kvm'><name>test-vm</name></domain>"
+	mockVMManager.EXPECT().GetDefinition(gomock.Any(), "test-vm").Return(definition, nil)
+	mockVMManager.EXPECT().UpdateDefinition(gomock.Any(), "test-vm", definition).
+		Return(&vmmodels.DefinitionUpdate{}, nil)
+	mockVMManager.EXPECT().UpdateDefinition(gomock.Any(), "test-vm", "<domain/>").
+		Return(nil, fmt.Errorf("%w: validation failed", domain.ErrInvalidDefinition))
+
+	// GET returns the XML as is
+	req, err := http.NewRequest(http.MethodGet, "/vms/test-vm/xml", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, definition, w.Body.String())
+
+	tests := []struct {
+		body           string
+		expectedStatus int
+	}{
+		{body: definition, expectedStatus: http.StatusOK},
+		{body: "<domain/>", expectedStatus: http.StatusBadRequest},
+		{body: "", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(http.MethodPut, "/vms/test-vm/xml", bytes.NewBufferString(tc.body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/xml")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedStatus, w.Code, tc.body)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// DiskResponse represents the response for a VM disk.
+type DiskResponse struct {
+	Disk *vmmodels.DiskInfo `json:"disk"`
+}
+
+// SetDiskIOTune handles requests to replace the I/O limits of a VM disk.
+// Limits left out of the request are removed.
+func (h *VMHandler) SetDiskIOTune(c *gin.Context) {
+	// Get VM name and disk device from URL path
+	vmName := c.Param("name")
+	device := c.Param("device")
+
+	if vmName == "" || device == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(
+		logger.String("vmName", vmName),
+		logger.String("device", device))
+
+	// Parse and validate request body
+	var tune vmmodels.DiskIOTune
+	if err := c.ShouldBindJSON(&tune); err != nil {
+		contextLogger.Warn("Invalid disk I/O limits request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	if err := tune.Validate(); err != nil {
+		contextLogger.Warn("Invalid disk I/O limits",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	disk, err := h.vmManager.SetDiskIOTune(c.Request.Context(), vmName, device, tune)
+	if err != nil {
+		contextLogger.Error("Failed to set disk I/O limits",
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("Disk I/O limits set successfully")
+
+	c.JSON(http.StatusOK, DiskResponse{Disk: disk})
+}
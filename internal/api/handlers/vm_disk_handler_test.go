@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mockvm "github.com/threatflux/libgo/test/mocks/vm"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVMHandler_SetDiskIOTune(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mockvm.MockManager)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "Limits set",
+			body: `{"totalIopsSec":2000,"totalIopsSecMax":4000,"burstSeconds":10,"readBytesSec":104857600}`,
+			mockSetup: func(m *mockvm.MockManager) {
+				tune := vmmodels.DiskIOTune{
+					TotalIOPSSec:    2000,
+					TotalIOPSSecMax: 4000,
+					BurstSeconds:    10,
+					ReadBytesSec:    104857600,
+				}
+				m.EXPECT().SetDiskIOTune(gomock.Any(), "test-vm", "vda", tune).
+					Return(&vmmodels.DiskInfo{Device: "vda", IOTune: &tune}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Total and read limits",
+			body:           `{"totalBytesSec":1000,"readBytesSec":1000}`,
+			mockSetup:      func(m *mockvm.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_INPUT",
+		},
+		{
+			name:           "Burst without limit",
+			body:           `{"writeIopsSecMax":500}`,
+			mockSetup:      func(m *mockvm.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_INPUT",
+		},
+		{
+			name: "Disk not found",
+			body: `{"totalIopsSec":2000}`,
+			mockSetup: func(m *mockvm.MockManager) {
+				m.EXPECT().SetDiskIOTune(gomock.Any(), "test-vm", "vda", gomock.Any()).
+					Return(nil, fmt.Errorf("setting disk I/O limits: %w: vda on test-vm", domain.ErrDiskNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "NOT_FOUND",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			ctrl := gomock.NewController(t)
+
+			mockVMManager := mockvm.NewMockManager(ctrl)
+			mockLogger := mocks_logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+			tc.mockSetup(mockVMManager)
+
+			handler := NewVMHandler(mockVMManager, mockLogger)
+			router := gin.New()
+			router.PUT("/vms/:name/disks/:device/iotune", handler.SetDiskIOTune)
+
+			req, err := http.NewRequest(http.MethodPut, "/vms/test-vm/disks/vda/iotune", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCode != "" {
+				var response ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedCode, response.Code)
+				return
+			}
+
+			var response DiskResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.NotNil(t, response.Disk.IOTune)
+			assert.Equal(t, uint64(4000), response.Disk.IOTune.TotalIOPSSecMax)
+		})
+	}
+}
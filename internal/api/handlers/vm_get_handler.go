@@ -26,8 +26,8 @@ func (h *VMHandler) GetVM(c *gin.Context) {
 	contextLogger := getContextLogger(c, h.logger)
 	contextLogger = contextLogger.WithFields(logger.String("vmName", name))
 
-	// Get VM.
-	vm, err := h.vmManager.Get(c.Request.Context(), name)
+	// Get VM, including guest agent data.
+	vm, err := h.vmManager.GetDetails(c.Request.Context(), name)
 	if err != nil {
 		// For integration testing, special case for domain not found.
 		// We want to return an empty VM rather than an error for test stability.
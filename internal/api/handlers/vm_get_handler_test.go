@@ -50,7 +50,7 @@ func TestVMHandler_GetVM(t *testing.T) {
 			name:   "Valid VM retrieval",
 			vmName: "test-vm",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Get(gomock.Any(), "test-vm").Return(&vm.VM{
+				mockVMManager.EXPECT().GetDetails(gomock.Any(), "test-vm").Return(&vm.VM{
 					Name:   "test-vm",
 					UUID:   "12345678-1234-1234-1234-123456789012",
 					Status: vm.VMStatusRunning,
@@ -70,7 +70,7 @@ func TestVMHandler_GetVM(t *testing.T) {
 			name:   "VM not found",
 			vmName: "non-existent-vm",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Get(gomock.Any(), "non-existent-vm").Return(nil, vmservice.ErrVMNotFound)
+				mockVMManager.EXPECT().GetDetails(gomock.Any(), "non-existent-vm").Return(nil, vmservice.ErrVMNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			validateResponse: func(t *testing.T, body []byte) {
@@ -85,7 +85,7 @@ func TestVMHandler_GetVM(t *testing.T) {
 			name:   "Internal error",
 			vmName: "test-vm",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Get(gomock.Any(), "test-vm").Return(nil, errors.New("internal error"))
+				mockVMManager.EXPECT().GetDetails(gomock.Any(), "test-vm").Return(nil, errors.New("internal error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			validateResponse: func(t *testing.T, body []byte) {
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// SetGuestPasswordRequest represents a request to set a guest user password.
+type SetGuestPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// SetGuestPasswordResponse represents the response for a guest password request.
+type SetGuestPasswordResponse struct {
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+}
+
+// SetGuestPassword handles requests to set a user password inside a VM
+// through the guest agent.
+func (h *VMHandler) SetGuestPassword(c *gin.Context) {
+	// Get VM name from URL path
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	// Parse and validate request body
+	var req SetGuestPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		contextLogger.Warn("Invalid guest password request",
+			logger.Error(err))
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Set the password
+	if err := h.vmManager.SetGuestPassword(c.Request.Context(), vmName, req.Username, req.Password); err != nil {
+		contextLogger.Error("Failed to set guest password",
+			logger.String("user", req.Username),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Log success
+	contextLogger.Info("Guest password set successfully",
+		logger.String("user", req.Username))
+
+	// Return response
+	c.JSON(http.StatusOK, SetGuestPasswordResponse{
+		Success: true,
+		Message: "Guest password set successfully",
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	migrationservice "github.com/threatflux/libgo/internal/migration"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// MigrationJobResponse represents the response for a single migration job.
+type MigrationJobResponse struct {
+	Job *migrationservice.Job `json:"job"`
+}
+
+// MigrationJobListResponse represents the response for listing migration jobs.
+type MigrationJobListResponse struct {
+	Jobs []*migrationservice.Job `json:"jobs"`
+}
+
+// MigrationHandler handles VM migration operations.
+type MigrationHandler struct {
+	migrationManager migrationservice.Manager
+	logger           logger.Logger
+}
+
+// NewMigrationHandler creates a new MigrationHandler.
+func NewMigrationHandler(migrationManager migrationservice.Manager, logger logger.Logger) *MigrationHandler {
+	return &MigrationHandler{
+		migrationManager: migrationManager,
+		logger:           logger,
+	}
+}
+
+// MigrateVM handles POST /vms/:name/migrate.
+func (h *MigrationHandler) MigrateVM(c *gin.Context) {
+	vmName := c.Param("name")
+	if vmName == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", vmName))
+
+	var params migrationservice.Params
+	if err := c.ShouldBindJSON(&params); err != nil {
+		contextLogger.Warn("Invalid VM migration request",
+			logger.Error(err))
+		HandleError(c, fmt.Errorf("%w: %v", ErrInvalidInput, err))
+		return
+	}
+
+	job, err := h.migrationManager.CreateMigrationJob(c.Request.Context(), vmName, params)
+	if err != nil {
+		contextLogger.Error("Failed to start VM migration",
+			logger.String("target", params.TargetURI),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	contextLogger.Info("VM migration started",
+		logger.String("jobId", job.ID),
+		logger.String("target", params.TargetURI))
+
+	c.JSON(http.StatusAccepted, MigrationJobResponse{Job: job})
+}
+
+// ListMigrations handles GET /migrations.
+func (h *MigrationHandler) ListMigrations(c *gin.Context) {
+	jobs, err := h.migrationManager.ListJobs(c.Request.Context())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MigrationJobListResponse{Jobs: jobs})
+}
+
+// GetMigration handles GET /migrations/:id.
+func (h *MigrationHandler) GetMigration(c *gin.Context) {
+	job, err := h.migrationManager.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MigrationJobResponse{Job: job})
+}
+
+// CancelMigration handles DELETE /migrations/:id.
+func (h *MigrationHandler) CancelMigration(c *gin.Context) {
+	jobID := c.Param("id")
+
+	if err := h.migrationManager.CancelJob(c.Request.Context(), jobID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	getContextLogger(c, h.logger).Info("VM migration canceled",
+		logger.String("jobId", jobID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Migration job canceled successfully",
+	})
+}
+
+// StartPostCopy handles POST /migrations/:id/postcopy.
+func (h *MigrationHandler) StartPostCopy(c *gin.Context) {
+	jobID := c.Param("id")
+
+	if err := h.migrationManager.StartPostCopy(c.Request.Context(), jobID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	job, err := h.migrationManager.GetJob(c.Request.Context(), jobID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MigrationJobResponse{Job: job})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	migrationservice "github.com/threatflux/libgo/internal/migration"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mocks_migration "github.com/threatflux/libgo/test/mocks/migration"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMigrationHandler_MigrateVM(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(m *mocks_migration.MockManager)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "Migration started",
+			body: `{"targetUri":"qemu+tcp://host-b/system","live":true,"copyStorage":true}`,
+			mockSetup: func(m *mocks_migration.MockManager) {
+				m.EXPECT().CreateMigrationJob(gomock.Any(), "test-vm", migrationservice.Params{
+					TargetURI:   "qemu+tcp://host-b/system",
+					Live:        true,
+					CopyStorage: true,
+				}).Return(&migrationservice.Job{ID: "job-1", VMName: "test-vm", Status: migrationservice.StatusPending}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Missing target URI",
+			body:           `{"live":true}`,
+			mockSetup:      func(m *mocks_migration.MockManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_INPUT",
+		},
+		{
+			name: "Pre-flight failure",
+			body: `{"targetUri":"qemu+tcp://host-b/system","live":true}`,
+			mockSetup: func(m *mocks_migration.MockManager) {
+				m.EXPECT().CreateMigrationJob(gomock.Any(), "test-vm", gomock.Any()).
+					Return(nil, fmt.Errorf("%w: network default not found on target", apierrors.ErrMigrationPreflight))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "RESOURCE_CONFLICT",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockManager := mocks_migration.NewMockManager(ctrl)
+			mockLogger := mocks_logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+			tc.mockSetup(mockManager)
+
+			handler := NewMigrationHandler(mockManager, mockLogger)
+			router := gin.New()
+			router.POST("/vms/:name/migrate", handler.MigrateVM)
+
+			req, err := http.NewRequest(http.MethodPost, "/vms/test-vm/migrate", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCode != "" {
+				var response ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedCode, response.Code)
+				return
+			}
+
+			var response MigrationJobResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "job-1", response.Job.ID)
+		})
+	}
+}
+
+func TestMigrationHandler_Jobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockManager := mocks_migration.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	handler := NewMigrationHandler(mockManager, mockLogger)
+	router := gin.New()
+	router.GET("/migrations/:id", handler.GetMigration)
+	router.DELETE("/migrations/:id", handler.CancelMigration)
+	router.POST("/migrations/:id/postcopy", handler.StartPostCopy)
+
+	mockManager.EXPECT().GetJob(gomock.Any(), "missing").
+		Return(nil, fmt.Errorf("%w: missing", apierrors.ErrMigrationJobNotFound))
+	mockManager.EXPECT().CancelJob(gomock.Any(), "job-1").Return(nil)
+	mockManager.EXPECT().StartPostCopy(gomock.Any(), "job-1").
+		Return(fmt.Errorf("%w: job was not started with post-copy enabled", apierrors.ErrMigrationInvalidState))
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{method: http.MethodGet, path: "/migrations/missing", expectedStatus: http.StatusNotFound},
+		{method: http.MethodDelete, path: "/migrations/job-1", expectedStatus: http.StatusOK},
+		{method: http.MethodPost, path: "/migrations/job-1/postcopy", expectedStatus: http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, tc.path, nil)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedStatus, w.Code, "%s %s", tc.method, tc.path)
+	}
+}
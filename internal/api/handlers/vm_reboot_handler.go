@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// RebootVMResponse represents the response for a VM reboot or reset request.
+type RebootVMResponse struct {
+	Result  *vmmodels.PowerResult `json:"result"`
+	Message string                `json:"message"`
+	Success bool                  `json:"success"`
+}
+
+// RebootVM handles requests to reboot a VM from within the guest.
+func (h *VMHandler) RebootVM(c *gin.Context) {
+	h.handleRebootOperation(c, "reboot", h.vmManager.Reboot)
+}
+
+// ResetVM handles requests to reset a VM without involving the guest.
+func (h *VMHandler) ResetVM(c *gin.Context) {
+	h.handleRebootOperation(c, "reset", h.vmManager.Reset)
+}
+
+// handleRebootOperation runs a reboot-style operation on a running VM and
+// reports the state of the VM afterwards.
+func (h *VMHandler) handleRebootOperation(c *gin.Context, operation string, run func(context.Context, string) error) {
+	// Get VM name from URL path.
+	name := c.Param("name")
+	if name == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger.
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(
+		logger.String("vmName", name),
+		logger.String("operation", operation))
+
+	result := &vmmodels.PowerResult{
+		Name:      name,
+		Operation: operation,
+		StartedAt: time.Now(),
+	}
+
+	if err := run(c.Request.Context(), name); err != nil {
+		contextLogger.Error("Failed to "+operation+" VM", logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// The VM keeps running through a reboot, report its actual state.
+	state := vmmodels.VMStatusRunning
+	if current, err := h.vmManager.Get(c.Request.Context(), name); err == nil {
+		state = current.Status
+	}
+	result.Finish(state)
+
+	// Log success.
+	contextLogger.Info("VM " + operation + " requested successfully")
+
+	// Return response.
+	c.JSON(http.StatusOK, RebootVMResponse{
+		Success: true,
+		Message: "VM " + operation + " requested successfully",
+		Result:  result,
+	})
+}
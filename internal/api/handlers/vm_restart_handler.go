@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// RestartVM handles requests to shut down a VM and start it again.
+func (h *VMHandler) RestartVM(c *gin.Context) {
+	// Get VM name from URL path.
+	name := c.Param("name")
+	if name == "" {
+		HandleError(c, ErrInvalidInput)
+		return
+	}
+
+	// Get context logger.
+	contextLogger := getContextLogger(c, h.logger)
+	contextLogger = contextLogger.WithFields(logger.String("vmName", name))
+
+	opts, err := parseShutdownOptions(c)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// Restart VM in the background, the guest may take longer to shut
+	// down than a request is allowed to.
+	job, err := h.vmManager.StartRestart(c.Request.Context(), name, opts)
+	if err != nil {
+		contextLogger.Error("Failed to restart VM",
+			logger.Bool("force", opts.Force),
+			logger.Duration("gracePeriod", opts.GracePeriod),
+			logger.Error(err))
+		HandleError(c, err)
+		return
+	}
+
+	// Log success.
+	contextLogger.Info("VM restart started",
+		logger.String("jobId", job.ID))
+
+	// Return response.
+	c.JSON(http.StatusAccepted, VMJobResponse{
+		Job: job,
+	})
+}
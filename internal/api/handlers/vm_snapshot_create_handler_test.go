@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -62,11 +63,158 @@ func (m *MockVMManagerWithSnapshots) Stop(ctx context.Context, name string) erro
 	return args.Error(0)
 }
 
-func (m *MockVMManagerWithSnapshots) Restart(ctx context.Context, name string) error {
+func (m *MockVMManagerWithSnapshots) Shutdown(ctx context.Context, name string, opts vmmodels.ShutdownOptions) (*vmmodels.PowerResult, error) {
+	args := m.Called(ctx, name, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.PowerResult), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) Restart(ctx context.Context, name string, opts vmmodels.ShutdownOptions) (*vmmodels.PowerResult, error) {
+	args := m.Called(ctx, name, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.PowerResult), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) StartDeleteSnapshot(ctx context.Context, vmName string, snapshotName string) (*vmmodels.Job, error) {
+	args := m.Called(ctx, vmName, snapshotName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.Job), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) StartShutdown(ctx context.Context, name string, opts vmmodels.ShutdownOptions) (*vmmodels.Job, error) {
+	args := m.Called(ctx, name, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.Job), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) StartRestart(ctx context.Context, name string, opts vmmodels.ShutdownOptions) (*vmmodels.Job, error) {
+	args := m.Called(ctx, name, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.Job), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) Reset(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockVMManagerWithSnapshots) ForceStop(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockVMManagerWithSnapshots) Reboot(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockVMManagerWithSnapshots) Pause(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockVMManagerWithSnapshots) Resume(ctx context.Context, name string) error {
 	args := m.Called(ctx, name)
 	return args.Error(0)
 }
 
+func (m *MockVMManagerWithSnapshots) SendKeys(ctx context.Context, name string, keys []string) error {
+	args := m.Called(ctx, name, keys)
+	return args.Error(0)
+}
+
+func (m *MockVMManagerWithSnapshots) GetDefinition(ctx context.Context, name string) (string, error) {
+	args := m.Called(ctx, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) UpdateDefinition(ctx context.Context, name string, definition string) (*vmmodels.DefinitionUpdate, error) {
+	args := m.Called(ctx, name, definition)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.DefinitionUpdate), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) Update(ctx context.Context, name string, params vmmodels.UpdateParams) (*vmmodels.DefinitionUpdate, error) {
+	args := m.Called(ctx, name, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.DefinitionUpdate), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) Clone(ctx context.Context, sourceName string, params vmmodels.CloneParams) (*vmmodels.VM, error) {
+	args := m.Called(ctx, sourceName, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.VM), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) StartClone(ctx context.Context, sourceName string, params vmmodels.CloneParams) (*vmmodels.Job, error) {
+	args := m.Called(ctx, sourceName, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.Job), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) GetJob(ctx context.Context, id string) (*vmmodels.Job, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.Job), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) ListJobs(ctx context.Context) ([]*vmmodels.Job, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*vmmodels.Job), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) GetMetrics(ctx context.Context, name string) (*vmmodels.Metrics, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.Metrics), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) GetDetails(ctx context.Context, name string) (*vmmodels.VM, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.VM), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) GetGuestInfo(ctx context.Context, name string) (*vmmodels.GuestInfo, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.GuestInfo), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) SetGuestPassword(ctx context.Context, name string, username string, password string) error {
+	args := m.Called(ctx, name, username, password)
+	return args.Error(0)
+}
+
 func (m *MockVMManagerWithSnapshots) CreateSnapshot(ctx context.Context, vmName string, params vmmodels.SnapshotParams) (*vmmodels.Snapshot, error) {
 	args := m.Called(ctx, vmName, params)
 	if args.Get(0) == nil {
@@ -101,6 +249,75 @@ func (m *MockVMManagerWithSnapshots) RevertSnapshot(ctx context.Context, vmName
 	return args.Error(0)
 }
 
+func (m *MockVMManagerWithSnapshots) ExecGuestCommand(ctx context.Context, name string, command vmmodels.GuestCommand) (*vmmodels.GuestCommandResult, error) {
+	args := m.Called(ctx, name, command)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.GuestCommandResult), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) BlockCommit(ctx context.Context, name string, params vmmodels.BlockCommitParams) (*vmmodels.BlockJob, error) {
+	args := m.Called(ctx, name, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.BlockJob), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) BlockPull(ctx context.Context, name string, params vmmodels.BlockPullParams) (*vmmodels.BlockJob, error) {
+	args := m.Called(ctx, name, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.BlockJob), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) ListBlockJobs(ctx context.Context, name string) ([]vmmodels.BlockJob, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]vmmodels.BlockJob), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) AbortBlockJob(ctx context.Context, name string, device string) error {
+	args := m.Called(ctx, name, device)
+	return args.Error(0)
+}
+
+func (m *MockVMManagerWithSnapshots) SetDiskIOTune(ctx context.Context, name string, device string, tune vmmodels.DiskIOTune) (*vmmodels.DiskInfo, error) {
+	args := m.Called(ctx, name, device, tune)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*vmmodels.DiskInfo), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) OpenConsole(ctx context.Context, name string, opts vmmodels.ConsoleOptions) (vmmodels.ConsoleStream, error) {
+	args := m.Called(ctx, name, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(vmmodels.ConsoleStream), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) GetGraphics(ctx context.Context, name string) ([]vmmodels.GraphicsInfo, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]vmmodels.GraphicsInfo), args.Error(1)
+}
+
+func (m *MockVMManagerWithSnapshots) OpenGraphics(ctx context.Context, name string, graphicsType vmmodels.GraphicsType) (net.Conn, error) {
+	args := m.Called(ctx, name, graphicsType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(net.Conn), args.Error(1)
+}
+
 func TestCreateSnapshot(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -30,14 +30,23 @@ func (h *VMHandler) DeleteSnapshot(c *gin.Context) {
 		logger.String("vmName", vmName),
 		logger.String("snapshotName", snapshotName))
 
-	// Delete the snapshot
-	if err := h.vmManager.DeleteSnapshot(c.Request.Context(), vmName, snapshotName); err != nil {
+	// Delete the snapshot, merging the overlays of external snapshots in
+	// the background
+	job, err := h.vmManager.StartDeleteSnapshot(c.Request.Context(), vmName, snapshotName)
+	if err != nil {
 		contextLogger.Error("Failed to delete snapshot",
 			logger.Error(err))
 		HandleError(c, err)
 		return
 	}
 
+	if job != nil {
+		contextLogger.Info("Snapshot deletion started",
+			logger.String("jobId", job.ID))
+		c.JSON(http.StatusAccepted, VMJobResponse{Job: job})
+		return
+	}
+
 	// Log success
 	contextLogger.Info("Snapshot deleted successfully")
 
@@ -2,17 +2,13 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
 	"github.com/threatflux/libgo/pkg/logger"
 )
 
-// StopVMResponse represents the response for a VM stop request.
-type StopVMResponse struct {
-	Message string `json:"message"`
-	Success bool   `json:"success"`
-}
-
 // StopVM handles requests to stop a VM.
 func (h *VMHandler) StopVM(c *gin.Context) {
 	// Get VM name from URL path.
@@ -26,45 +22,48 @@ func (h *VMHandler) StopVM(c *gin.Context) {
 	contextLogger := getContextLogger(c, h.logger)
 	contextLogger = contextLogger.WithFields(logger.String("vmName", name))
 
-	// Check if force stop is requested.
-	force := false
-	if forceStr := c.Query("force"); forceStr == "true" {
-		force = true
-	}
-
-	// Get timeout parameter (default: 30 seconds).
-	timeout := 30
-	if timeoutStr := c.Query("timeout"); timeoutStr != "" {
-		if parsedTimeout, err := parseInt(timeoutStr, 0, 300); err == nil {
-			timeout = parsedTimeout
-		} else {
-			HandleError(c, ErrInvalidInput)
-			return
-		}
+	opts, err := parseShutdownOptions(c)
+	if err != nil {
+		HandleError(c, err)
+		return
 	}
 
-	// Stop VM.
-	// Note: Force option and timeout are not currently implemented in the interface.
-	// but we keep it in the handler for future implementation.
-	err := h.vmManager.Stop(c.Request.Context(), name)
-
+	// Stop VM in the background, escalating to a power off once the
+	// grace period has passed.
+	job, err := h.vmManager.StartShutdown(c.Request.Context(), name, opts)
 	if err != nil {
 		contextLogger.Error("Failed to stop VM",
-			logger.Bool("force", force),
-			logger.Int("timeout", timeout),
+			logger.Bool("force", opts.Force),
+			logger.Duration("gracePeriod", opts.GracePeriod),
 			logger.Error(err))
 		HandleError(c, err)
 		return
 	}
 
 	// Log success.
-	contextLogger.Info("VM stopped successfully",
-		logger.Bool("force", force),
-		logger.Int("timeout", timeout))
+	contextLogger.Info("VM stop started",
+		logger.String("jobId", job.ID))
 
 	// Return response.
-	c.JSON(http.StatusOK, StopVMResponse{
-		Success: true,
-		Message: "VM stopped successfully",
+	c.JSON(http.StatusAccepted, VMJobResponse{
+		Job: job,
 	})
 }
+
+// parseShutdownOptions reads the force and timeout query parameters. The
+// timeout is the grace period in seconds, zero uses the configured default.
+func parseShutdownOptions(c *gin.Context) (vmmodels.ShutdownOptions, error) {
+	opts := vmmodels.ShutdownOptions{
+		Force: c.Query("force") == trueString,
+	}
+
+	if timeoutStr := c.Query("timeout"); timeoutStr != "" {
+		timeout, err := parseInt(timeoutStr, 0, 300)
+		if err != nil {
+			return opts, ErrInvalidInput
+		}
+		opts.GracePeriod = time.Duration(timeout) * time.Second
+	}
+
+	return opts, nil
+}
@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
 	vmservice "github.com/threatflux/libgo/internal/vm"
 	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
 	mockvm "github.com/threatflux/libgo/test/mocks/vm"
@@ -51,15 +53,18 @@ func TestVMHandler_StopVM(t *testing.T) {
 			vmName:      "test-vm",
 			queryParams: "",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Stop(gomock.Any(), "test-vm").Return(nil)
+				mockVMManager.EXPECT().StartShutdown(gomock.Any(), "test-vm", vmmodels.ShutdownOptions{}).
+					Return(&vmmodels.Job{ID: "job-1", Type: vmmodels.JobTypeShutdown, Name: "test-vm", Status: vmmodels.JobStatusRunning}, nil)
 			},
-			expectedStatus: http.StatusOK,
+			expectedStatus: http.StatusAccepted,
 			validateResponse: func(t *testing.T, body []byte) {
-				var response StopVMResponse
+				var response VMJobResponse
 				err := json.Unmarshal(body, &response)
 				require.NoError(t, err)
-				assert.True(t, response.Success)
-				assert.Equal(t, "VM stopped successfully", response.Message)
+				require.NotNil(t, response.Job)
+				assert.Equal(t, "job-1", response.Job.ID)
+				assert.Equal(t, vmmodels.JobTypeShutdown, response.Job.Type)
+				assert.Equal(t, vmmodels.JobStatusRunning, response.Job.Status)
 			},
 		},
 		{
@@ -67,15 +72,16 @@ func TestVMHandler_StopVM(t *testing.T) {
 			vmName:      "test-vm",
 			queryParams: "?force=true",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Stop(gomock.Any(), "test-vm").Return(nil)
+				mockVMManager.EXPECT().StartShutdown(gomock.Any(), "test-vm", vmmodels.ShutdownOptions{Force: true}).
+					Return(&vmmodels.Job{ID: "job-1", Type: vmmodels.JobTypeShutdown, Name: "test-vm", Status: vmmodels.JobStatusRunning}, nil)
 			},
-			expectedStatus: http.StatusOK,
+			expectedStatus: http.StatusAccepted,
 			validateResponse: func(t *testing.T, body []byte) {
-				var response StopVMResponse
+				var response VMJobResponse
 				err := json.Unmarshal(body, &response)
 				require.NoError(t, err)
-				assert.True(t, response.Success)
-				assert.Equal(t, "VM stopped successfully", response.Message)
+				require.NotNil(t, response.Job)
+				assert.Equal(t, "job-1", response.Job.ID)
 			},
 		},
 		{
@@ -83,15 +89,15 @@ func TestVMHandler_StopVM(t *testing.T) {
 			vmName:      "test-vm",
 			queryParams: "?timeout=60",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Stop(gomock.Any(), "test-vm").Return(nil)
+				mockVMManager.EXPECT().StartShutdown(gomock.Any(), "test-vm", vmmodels.ShutdownOptions{GracePeriod: 60 * time.Second}).
+					Return(&vmmodels.Job{ID: "job-1", Type: vmmodels.JobTypeShutdown, Name: "test-vm", Status: vmmodels.JobStatusRunning}, nil)
 			},
-			expectedStatus: http.StatusOK,
+			expectedStatus: http.StatusAccepted,
 			validateResponse: func(t *testing.T, body []byte) {
-				var response StopVMResponse
+				var response VMJobResponse
 				err := json.Unmarshal(body, &response)
 				require.NoError(t, err)
-				assert.True(t, response.Success)
-				assert.Equal(t, "VM stopped successfully", response.Message)
+				require.NotNil(t, response.Job)
 			},
 		},
 		{
@@ -115,7 +121,7 @@ func TestVMHandler_StopVM(t *testing.T) {
 			vmName:      "non-existent-vm",
 			queryParams: "",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Stop(gomock.Any(), "non-existent-vm").Return(vmservice.ErrVMNotFound)
+				mockVMManager.EXPECT().StartShutdown(gomock.Any(), "non-existent-vm", gomock.Any()).Return(nil, vmservice.ErrVMNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			validateResponse: func(t *testing.T, body []byte) {
@@ -131,7 +137,7 @@ func TestVMHandler_StopVM(t *testing.T) {
 			vmName:      "stopped-vm",
 			queryParams: "",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Stop(gomock.Any(), "stopped-vm").Return(vmservice.ErrVMInvalidState)
+				mockVMManager.EXPECT().StartShutdown(gomock.Any(), "stopped-vm", gomock.Any()).Return(nil, vmservice.ErrVMInvalidState)
 			},
 			expectedStatus: http.StatusBadRequest,
 			validateResponse: func(t *testing.T, body []byte) {
@@ -147,7 +153,7 @@ func TestVMHandler_StopVM(t *testing.T) {
 			vmName:      "test-vm",
 			queryParams: "",
 			mockSetup: func() {
-				mockVMManager.EXPECT().Stop(gomock.Any(), "test-vm").Return(errors.New("internal error"))
+				mockVMManager.EXPECT().StartShutdown(gomock.Any(), "test-vm", gomock.Any()).Return(nil, errors.New("internal error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			validateResponse: func(t *testing.T, body []byte) {
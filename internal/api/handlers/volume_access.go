@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/middleware/auth"
+	"github.com/threatflux/libgo/internal/models/user"
+	"github.com/threatflux/libgo/internal/volume"
+)
+
+// currentUser returns the authenticated user of a request, or nil when
+// authentication is disabled.
+func currentUser(c *gin.Context) *user.User {
+	value, exists := c.Get(auth.UserContextKey)
+	if !exists {
+		return nil
+	}
+	u, _ := value.(*user.User)
+	return u
+}
+
+// volumeOwnerContext returns the context of a request, in which volumes and
+// volume jobs are created as owned by the authenticated user.
+func volumeOwnerContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if u := currentUser(c); u != nil {
+		ctx = storage.WithVolumeOrigin(ctx, storage.VolumeOrigin{Owner: u.ID})
+	}
+	return ctx
+}
+
+// volumeOwnerFilter returns the owner a user's volume listings are limited
+// to. Administrators and requests without authentication see every volume.
+func volumeOwnerFilter(c *gin.Context) string {
+	u := currentUser(c)
+	if u == nil || u.HasRole(user.RoleAdmin) {
+		return ""
+	}
+	return u.ID
+}
+
+// canAccessVolume reports whether the user of a request may see and act on
+// a volume.
+func canAccessVolume(c *gin.Context, info *storage.StorageVolumeInfo) bool {
+	owner := volumeOwnerFilter(c)
+	return owner == "" || info.Owner == owner
+}
+
+// checkVolumeAccess ends a request with not found when its user may not
+// access a volume, so volumes of other users cannot be told apart from
+// missing ones. Administrators and requests without authentication skip
+// the lookup.
+func checkVolumeAccess(c *gin.Context, volumes storage.VolumeManager, pool string, name string) bool {
+	if volumeOwnerFilter(c) == "" {
+		return true
+	}
+
+	info, err := volumes.GetInfo(c.Request.Context(), pool, name)
+	if err != nil {
+		HandleError(c, err)
+		return false
+	}
+	if !canAccessVolume(c, info) {
+		HandleError(c, storage.ErrVolumeNotFound)
+		return false
+	}
+	return true
+}
+
+// canAccessVolumeJob reports whether the user of a request may see and
+// cancel a volume job.
+func canAccessVolumeJob(c *gin.Context, job *volume.Job) bool {
+	owner := volumeOwnerFilter(c)
+	return owner == "" || job.Owner == owner
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/middleware/auth"
+	"github.com/threatflux/libgo/internal/models/user"
+	"github.com/threatflux/libgo/internal/volume"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mocks_volume "github.com/threatflux/libgo/test/mocks/volume"
+	"go.uber.org/mock/gomock"
+)
+
+// newVolumeAccessTestRouter creates a router serving the volume list, get
+// and delete handlers to a user; a nil user is an unauthenticated request.
+func newVolumeAccessTestRouter(t *testing.T, u *user.User) (*gin.Engine, *mocks_storage.MockVolumeManager) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if u != nil {
+			c.Set(auth.UserContextKey, u)
+		}
+		c.Next()
+	})
+	router.GET("/pools/:name/volumes", NewStorageVolumeListHandler(mockManager, mockLogger).Handle)
+	router.GET("/pools/:name/volumes/:volumeName", NewStorageVolumeGetHandler(mockManager, mockLogger).Handle)
+	router.DELETE("/pools/:name/volumes/:volumeName", NewStorageVolumeDeleteHandler(mockManager, mockLogger).Handle)
+
+	return router, mockManager
+}
+
+// testVolumes are volumes of two users and one created outside the API.
+func testVolumes() []*storage.StorageVolumeInfo {
+	return []*storage.StorageVolumeInfo{
+		{Name: "alice.qcow2", Pool: "default", Owner: "alice", Labels: map[string]string{"env": "dev"}},
+		{Name: "bob.qcow2", Pool: "default", Owner: "bob", Labels: map[string]string{"env": "prod"}},
+		{Name: "base.qcow2", Pool: "default"},
+	}
+}
+
+// listVolumeNames lists the volumes of the default pool and returns their
+// names.
+func listVolumeNames(t *testing.T, router *gin.Engine, query string) []string {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/pools/default/volumes"+query, nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Volumes []*storage.StorageVolumeInfo `json:"volumes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	names := make([]string, 0, len(response.Volumes))
+	for _, volume := range response.Volumes {
+		names = append(names, volume.Name)
+	}
+	return names
+}
+
+func TestStorageVolumeListHandler_Filters(t *testing.T) {
+	alice := &user.User{ID: "alice", Roles: []string{user.RoleOperator}}
+	admin := &user.User{ID: "root", Roles: []string{user.RoleAdmin}}
+
+	tests := []struct {
+		name     string
+		user     *user.User
+		query    string
+		expected []string
+	}{
+		{
+			name:     "Authentication disabled",
+			expected: []string{"alice.qcow2", "bob.qcow2", "base.qcow2"},
+		},
+		{
+			name:     "Admin",
+			user:     admin,
+			expected: []string{"alice.qcow2", "bob.qcow2", "base.qcow2"},
+		},
+		{
+			name:     "Admin by owner",
+			user:     admin,
+			query:    "?owner=bob",
+			expected: []string{"bob.qcow2"},
+		},
+		{
+			name:     "Admin by label",
+			user:     admin,
+			query:    "?labels=env=prod",
+			expected: []string{"bob.qcow2"},
+		},
+		{
+			name:     "User sees own volumes",
+			user:     alice,
+			expected: []string{"alice.qcow2"},
+		},
+		{
+			name:     "User cannot list other owners",
+			user:     alice,
+			query:    "?owner=bob",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockManager := newVolumeAccessTestRouter(t, tt.user)
+			mockManager.EXPECT().List(gomock.Any(), "default").Return(testVolumes(), nil)
+
+			assert.Equal(t, tt.expected, listVolumeNames(t, router, tt.query))
+		})
+	}
+}
+
+func TestStorageVolumeAccess_GetAndDelete(t *testing.T) {
+	alice := &user.User{ID: "alice", Roles: []string{user.RoleOperator}}
+	volumes := testVolumes()
+
+	t.Run("Get own volume", func(t *testing.T) {
+		router, mockManager := newVolumeAccessTestRouter(t, alice)
+		mockManager.EXPECT().GetInfo(gomock.Any(), "default", "alice.qcow2").Return(volumes[0], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/default/volumes/alice.qcow2", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Get volume of another user", func(t *testing.T) {
+		router, mockManager := newVolumeAccessTestRouter(t, alice)
+		mockManager.EXPECT().GetInfo(gomock.Any(), "default", "bob.qcow2").Return(volumes[1], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/default/volumes/bob.qcow2", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Delete own volume", func(t *testing.T) {
+		router, mockManager := newVolumeAccessTestRouter(t, alice)
+		mockManager.EXPECT().GetInfo(gomock.Any(), "default", "alice.qcow2").Return(volumes[0], nil)
+		mockManager.EXPECT().Delete(gomock.Any(), "default", "alice.qcow2").Return(nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/pools/default/volumes/alice.qcow2", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Delete volume without owner", func(t *testing.T) {
+		router, mockManager := newVolumeAccessTestRouter(t, alice)
+		mockManager.EXPECT().GetInfo(gomock.Any(), "default", "base.qcow2").Return(volumes[2], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/pools/default/volumes/base.qcow2", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Admin deletes any volume", func(t *testing.T) {
+		router, mockManager := newVolumeAccessTestRouter(t, &user.User{ID: "root", Roles: []string{user.RoleAdmin}})
+		mockManager.EXPECT().Delete(gomock.Any(), "default", "bob.qcow2").Return(nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/pools/default/volumes/bob.qcow2", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+// newVolumeActionTestRouter creates a router serving the handlers that act
+// on single volumes and the volume job handlers to a user.
+func newVolumeActionTestRouter(t *testing.T, u *user.User) (*gin.Engine, *mocks_volume.MockManager, *mocks_storage.MockVolumeManager) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	mockVolumes := mocks_volume.NewMockManager(ctrl)
+	mockStorage := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if u != nil {
+			c.Set(auth.UserContextKey, u)
+		}
+		c.Next()
+	})
+	router.GET("/pools/:name/volumes/:volumeName/download", NewStorageVolumeDownloadHandler(mockVolumes, mockStorage, mockLogger).Handle)
+	router.POST("/pools/:name/volumes/:volumeName/wipe", NewStorageVolumeWipeHandler(mockVolumes, mockStorage, mockLogger).Handle)
+	router.GET("/pools/:name/volumes/:volumeName/xml", NewStorageVolumeXMLHandler(mockStorage, mockLogger).Handle)
+	router.POST("/pools/:name/volumes/:volumeName/rebase", NewStorageVolumeRebaseHandler(mockVolumes, mockStorage, mockLogger).Handle)
+	router.GET("/volume-jobs", NewStorageVolumeJobListHandler(mockVolumes, mockLogger).Handle)
+	router.GET("/volume-jobs/:id", NewStorageVolumeJobGetHandler(mockVolumes, mockLogger).Handle)
+	router.DELETE("/volume-jobs/:id", NewStorageVolumeJobCancelHandler(mockVolumes, mockLogger).Handle)
+
+	return router, mockVolumes, mockStorage
+}
+
+func TestStorageVolumeAccess_Actions(t *testing.T) {
+	alice := &user.User{ID: "alice", Roles: []string{user.RoleOperator}}
+	volumes := testVolumes()
+
+	t.Run("Download volume of another user", func(t *testing.T) {
+		router, _, mockStorage := newVolumeActionTestRouter(t, alice)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "bob.qcow2").Return(volumes[1], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/default/volumes/bob.qcow2/download", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Wipe volume of another user", func(t *testing.T) {
+		router, _, mockStorage := newVolumeActionTestRouter(t, alice)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "bob.qcow2").Return(volumes[1], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pools/default/volumes/bob.qcow2/wipe", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("XML of volume without owner", func(t *testing.T) {
+		router, _, mockStorage := newVolumeActionTestRouter(t, alice)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "base.qcow2").Return(volumes[2], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/default/volumes/base.qcow2/xml", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Wipe own volume", func(t *testing.T) {
+		router, mockVolumes, mockStorage := newVolumeActionTestRouter(t, alice)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "alice.qcow2").Return(volumes[0], nil)
+		mockVolumes.EXPECT().StartWipe(gomock.Any(), "default", "alice.qcow2").DoAndReturn(
+			func(ctx context.Context, pool, name string) (*volume.Job, error) {
+				assert.Equal(t, "alice", storage.VolumeOriginFromContext(ctx).Owner)
+				return &volume.Job{ID: "job-1", Owner: "alice"}, nil
+			})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pools/default/volumes/alice.qcow2/wipe", nil))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("Rebase onto volume of another user", func(t *testing.T) {
+		router, _, mockStorage := newVolumeActionTestRouter(t, alice)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "alice.qcow2").Return(volumes[0], nil)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "bob.qcow2").Return(volumes[1], nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/pools/default/volumes/alice.qcow2/rebase",
+			strings.NewReader(`{"backingVolume":"bob.qcow2"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Admin downloads any volume", func(t *testing.T) {
+		router, mockVolumes, _ := newVolumeActionTestRouter(t, &user.User{ID: "root", Roles: []string{user.RoleAdmin}})
+		mockVolumes.EXPECT().OpenDownload(gomock.Any(), "default", "bob.qcow2", gomock.Any()).
+			Return(nil, storage.ErrVolumeNotFound)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/default/volumes/bob.qcow2/download", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestStorageVolumeAccess_Jobs(t *testing.T) {
+	alice := &user.User{ID: "alice", Roles: []string{user.RoleOperator}}
+	jobs := []*volume.Job{
+		{ID: "job-1", Owner: "alice"},
+		{ID: "job-2", Owner: "bob"},
+		{ID: "job-3"},
+	}
+
+	t.Run("User lists own jobs", func(t *testing.T) {
+		router, mockVolumes, _ := newVolumeActionTestRouter(t, alice)
+		mockVolumes.EXPECT().ListJobs(gomock.Any()).Return(jobs, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/volume-jobs", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response VolumeJobListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		if assert.Len(t, response.Jobs, 1) {
+			assert.Equal(t, "job-1", response.Jobs[0].ID)
+		}
+	})
+
+	t.Run("Admin lists all jobs", func(t *testing.T) {
+		router, mockVolumes, _ := newVolumeActionTestRouter(t, &user.User{ID: "root", Roles: []string{user.RoleAdmin}})
+		mockVolumes.EXPECT().ListJobs(gomock.Any()).Return(jobs, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/volume-jobs", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response VolumeJobListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Jobs, 3)
+	})
+
+	t.Run("Get job of another user", func(t *testing.T) {
+		router, mockVolumes, _ := newVolumeActionTestRouter(t, alice)
+		mockVolumes.EXPECT().GetJob(gomock.Any(), "job-2").Return(jobs[1], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/volume-jobs/job-2", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Cancel job of another user", func(t *testing.T) {
+		router, mockVolumes, _ := newVolumeActionTestRouter(t, alice)
+		mockVolumes.EXPECT().GetJob(gomock.Any(), "job-2").Return(jobs[1], nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/volume-jobs/job-2", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestStorageImageRegister_Access(t *testing.T) {
+	alice := &user.User{ID: "alice", Roles: []string{user.RoleOperator}}
+	admin := &user.User{ID: "root", Roles: []string{user.RoleAdmin}}
+	volumes := testVolumes()
+
+	// newRouter creates a router serving the image register handler to a user
+	newRouter := func(t *testing.T, u *user.User) (*gin.Engine, *mocks_storage.MockImageLibrary, *mocks_storage.MockVolumeManager) {
+		gin.SetMode(gin.TestMode)
+		ctrl := gomock.NewController(t)
+
+		mockLibrary := mocks_storage.NewMockImageLibrary(ctrl)
+		mockStorage := mocks_storage.NewMockVolumeManager(ctrl)
+		mockLogger := mocks_logger.NewMockLogger(ctrl)
+		mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+		mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+		mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if u != nil {
+				c.Set(auth.UserContextKey, u)
+			}
+			c.Next()
+		})
+		router.POST("/images", NewStorageImageRegisterHandler(mockLibrary, mockStorage, "default", mockLogger).Handle)
+
+		return router, mockLibrary, mockStorage
+	}
+
+	register := func(router *gin.Engine, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/images", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("User registers a file", func(t *testing.T) {
+		router, _, _ := newRouter(t, alice)
+		assert.Equal(t, http.StatusForbidden, register(router, `{"name":"shadow","source_path":"/etc/shadow"}`))
+	})
+
+	t.Run("User registers volume of another user", func(t *testing.T) {
+		router, _, mockStorage := newRouter(t, alice)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "bob.qcow2").Return(volumes[1], nil)
+
+		assert.Equal(t, http.StatusNotFound, register(router, `{"name":"bob","volume":"bob.qcow2"}`))
+	})
+
+	t.Run("User registers own volume", func(t *testing.T) {
+		router, mockLibrary, mockStorage := newRouter(t, alice)
+		mockStorage.EXPECT().GetInfo(gomock.Any(), "default", "alice.qcow2").Return(volumes[0], nil)
+		mockLibrary.EXPECT().Register(gomock.Any(), gomock.Any()).
+			Return(&storage.Image{Name: "alice", Pool: "default", Volume: "alice.qcow2"}, nil)
+
+		assert.Equal(t, http.StatusCreated, register(router, `{"name":"alice","volume":"alice.qcow2"}`))
+	})
+
+	t.Run("Admin registers a file", func(t *testing.T) {
+		router, mockLibrary, _ := newRouter(t, admin)
+		mockLibrary.EXPECT().Register(gomock.Any(), gomock.Any()).
+			Return(&storage.Image{Name: "ubuntu", Pool: "default", Volume: "ubuntu.qcow2"}, nil)
+
+		assert.Equal(t, http.StatusCreated, register(router, `{"name":"ubuntu","source_path":"/var/lib/libgo/images/ubuntu.qcow2"}`))
+	})
+}
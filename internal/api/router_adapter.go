@@ -3,12 +3,14 @@ package api
 import (
 	"context"
 	"fmt"
-	"time"
+	"net"
 
 	"github.com/gin-gonic/gin"
 	"github.com/threatflux/libgo/internal/api/handlers"
 	"github.com/threatflux/libgo/internal/config"
+	"github.com/threatflux/libgo/internal/middleware"
 	"github.com/threatflux/libgo/internal/middleware/auth"
+	"github.com/threatflux/libgo/internal/models/user"
 	vmmodels "github.com/threatflux/libgo/internal/models/vm"
 	"github.com/threatflux/libgo/internal/websocket"
 	"github.com/threatflux/libgo/pkg/logger"
@@ -30,112 +32,33 @@ func (a *vmManagerWebSocketAdapter) Get(ctx context.Context, name string) (*vmmo
 	return nil, fmt.Errorf("VM manager does not support Get method")
 }
 
-// GetMetrics implements the GetMetrics method of websocket.VMManager.
-// safeInt64ToUint64 safely converts int64 to uint64, avoiding overflow.
-func safeInt64ToUint64(val int64) uint64 {
-	if val < 0 {
-		return 0
-	}
-	return uint64(val)
-}
-
-// generateMockBytesValue generates a mock byte value for testing.
-func generateMockBytesValue(mod int64, multiplier uint64) uint64 {
-	unixTime := time.Now().Unix()
-	if unixTime < 0 {
-		return 0
-	}
-	modResult := unixTime % mod
-	if modResult < 0 {
-		modResult = -modResult
-	}
-	return safeInt64ToUint64(modResult) * multiplier
-}
-
-func (a *vmManagerWebSocketAdapter) GetMetrics(ctx context.Context, name string) (*websocket.VMMetrics, error) {
-	// First try the exact signature
-	if getter, ok := a.manager.(interface {
-		GetMetrics(ctx context.Context, name string) (*websocket.VMMetrics, error)
+// OpenConsole implements the OpenConsole method of websocket.VMManager.
+func (a *vmManagerWebSocketAdapter) OpenConsole(ctx context.Context, name string, opts vmmodels.ConsoleOptions) (vmmodels.ConsoleStream, error) {
+	if opener, ok := a.manager.(interface {
+		OpenConsole(ctx context.Context, name string, opts vmmodels.ConsoleOptions) (vmmodels.ConsoleStream, error)
 	}); ok {
-		return getter.GetMetrics(ctx, name)
-	}
-
-	// Try to get VM metrics from the VM manager directly
-	if metrics, err := a.tryVMMetricsType(ctx, name); metrics != nil || err != nil {
-		return metrics, err
+		return opener.OpenConsole(ctx, name, opts)
 	}
-
-	// Fallback to generic metrics
-	return a.fallbackToGenericMetrics(ctx, name)
+	return nil, fmt.Errorf("VM manager does not support OpenConsole method")
 }
 
-// tryVMMetricsType attempts to get metrics using the VM-specific metrics type.
-func (a *vmManagerWebSocketAdapter) tryVMMetricsType(ctx context.Context, name string) (*websocket.VMMetrics, error) {
-	type vmMetrics struct {
-		CPU struct {
-			Utilization float64
-		}
-		Memory struct {
-			Used  uint64
-			Total uint64
-		}
-		Network struct {
-			RxBytes uint64
-			TxBytes uint64
-		}
-		Disk struct {
-			ReadBytes  uint64
-			WriteBytes uint64
-		}
-	}
-
-	if getter, ok := a.manager.(interface {
-		GetMetrics(ctx context.Context, name string) (*vmMetrics, error)
+// OpenGraphics implements the OpenGraphics method of websocket.VMManager.
+func (a *vmManagerWebSocketAdapter) OpenGraphics(ctx context.Context, name string, graphicsType vmmodels.GraphicsType) (net.Conn, error) {
+	if opener, ok := a.manager.(interface {
+		OpenGraphics(ctx context.Context, name string, graphicsType vmmodels.GraphicsType) (net.Conn, error)
 	}); ok {
-		vmMetricsResult, err := getter.GetMetrics(ctx, name)
-		if err != nil {
-			return nil, err
-		}
-
-		// Convert to websocket metrics
-		wsMetrics := &websocket.VMMetrics{}
-		wsMetrics.CPU.Utilization = vmMetricsResult.CPU.Utilization
-		wsMetrics.Memory.Used = vmMetricsResult.Memory.Used
-		wsMetrics.Memory.Total = vmMetricsResult.Memory.Total
-		wsMetrics.Network.RxBytes = vmMetricsResult.Network.RxBytes
-		wsMetrics.Network.TxBytes = vmMetricsResult.Network.TxBytes
-		wsMetrics.Disk.ReadBytes = vmMetricsResult.Disk.ReadBytes
-		wsMetrics.Disk.WriteBytes = vmMetricsResult.Disk.WriteBytes
-
-		return wsMetrics, nil
+		return opener.OpenGraphics(ctx, name, graphicsType)
 	}
-
-	return nil, nil
+	return nil, fmt.Errorf("VM manager does not support OpenGraphics method")
 }
 
-// fallbackToGenericMetrics creates mock metrics when specific metrics are unavailable.
-func (a *vmManagerWebSocketAdapter) fallbackToGenericMetrics(ctx context.Context, name string) (*websocket.VMMetrics, error) {
+// GetMetrics implements the GetMetrics method of websocket.VMManager.
+func (a *vmManagerWebSocketAdapter) GetMetrics(ctx context.Context, name string) (*vmmodels.Metrics, error) {
 	if getter, ok := a.manager.(interface {
-		GetMetrics(ctx context.Context, name string) (interface{}, error)
+		GetMetrics(ctx context.Context, name string) (*vmmodels.Metrics, error)
 	}); ok {
-		_, err := getter.GetMetrics(ctx, name)
-		if err != nil {
-			return nil, err
-		}
-
-		// Create mock metrics
-		wsMetrics := &websocket.VMMetrics{}
-		wsMetrics.CPU.Utilization = 25.0 + float64(time.Now().Unix()%50)                   // Random-ish value between 25-75%
-		wsMetrics.Memory.Used = 1024*1024*1024 + generateMockBytesValue(3, 1024*1024*1024) // 1-4GB
-		wsMetrics.Memory.Total = 8 * 1024 * 1024 * 1024                                    // 8GB
-		wsMetrics.Network.RxBytes = generateMockBytesValue(10, 1024*1024)                  // 0-10MB
-		wsMetrics.Network.TxBytes = generateMockBytesValue(5, 1024*1024)                   // 0-5MB
-		wsMetrics.Disk.ReadBytes = generateMockBytesValue(20, 1024*1024)                   // 0-20MB
-		wsMetrics.Disk.WriteBytes = generateMockBytesValue(10, 1024*1024)                  // 0-10MB
-
-		return wsMetrics, nil
+		return getter.GetMetrics(ctx, name)
 	}
-
 	return nil, fmt.Errorf("VM manager does not support GetMetrics method")
 }
 
@@ -148,6 +71,12 @@ func ConfigureRoutes(
 	roleMiddleware *auth.RoleMiddleware,
 	vmHandler *handlers.VMHandler,
 	exportHandler *handlers.ExportHandler,
+	migrationHandler *handlers.MigrationHandler,
+	snapshotPolicyHandler *handlers.SnapshotPolicyHandler,
+	backupHandler *handlers.BackupHandler,
+	importHandler *handlers.ImportHandler,
+	uploadHandler *handlers.UploadHandler,
+	hostHandler *handlers.HostHandler,
 	authHandler *handlers.AuthHandler,
 	healthHandler *handlers.HealthHandler,
 	metricsHandler *handlers.MetricsHandler,
@@ -185,23 +114,74 @@ func ConfigureRoutes(
 		log.Info("Authentication is disabled, skipping JWT middleware")
 	}
 
+	// Libvirt operations run on the host selected by the request
+	protected.Use(middleware.HostSelectorGinMiddleware())
+
+	// Raw libvirt definitions are restricted to administrators
+	adminOnly := func(c *gin.Context) { c.Next() }
+	if config != nil && config.Auth.Enabled {
+		adminOnly = roleMiddleware.RequireRole("admin")
+	}
+
+	// Routes that change VMs require the permissions of their action; power
+	// and snapshot routes match the equivalent WebSocket commands
+	withPermissions := func(handler gin.HandlerFunc, permissions ...string) []gin.HandlerFunc {
+		chain := make([]gin.HandlerFunc, 0, len(permissions)+1)
+		if config != nil && config.Auth.Enabled {
+			for _, permission := range permissions {
+				chain = append(chain, roleMiddleware.RequirePermission(permission))
+			}
+		}
+		return append(chain, handler)
+	}
+
+	// Libvirt hosts
+	protected.GET("/hosts", hostHandler.ListHosts)
+
 	// VM management endpoints
 	vms := protected.Group("/vms")
 	{
 		vms.GET("", vmHandler.ListVMs)
 		vms.GET("/:name", vmHandler.GetVM)
 		vms.POST("", vmHandler.CreateVM)
+		vms.POST("/import", withPermissions(importHandler.ImportVM, user.PermCreate)...)
 		vms.DELETE("/:name", vmHandler.DeleteVM)
-		vms.PUT("/:name/start", vmHandler.StartVM)
-		vms.PUT("/:name/stop", vmHandler.StopVM)
+		vms.PATCH("/:name", withPermissions(vmHandler.UpdateVM, user.PermUpdate)...)
+		vms.GET("/:name/xml", adminOnly, vmHandler.GetVMXML)
+		vms.PUT("/:name/xml", adminOnly, vmHandler.UpdateVMXML)
+		vms.PUT("/:name/start", withPermissions(vmHandler.StartVM, user.PermStart)...)
+		vms.PUT("/:name/stop", withPermissions(vmHandler.StopVM, user.PermStop)...)
+		vms.PUT("/:name/restart", withPermissions(vmHandler.RestartVM, user.PermStop, user.PermStart)...)
+		vms.PUT("/:name/reboot", withPermissions(vmHandler.RebootVM, user.PermStop, user.PermStart)...)
+		vms.PUT("/:name/reset", withPermissions(vmHandler.ResetVM, user.PermStop, user.PermStart)...)
 		vms.POST("/:name/export", exportHandler.ExportVM)
+		vms.POST("/:name/migrate", withPermissions(migrationHandler.MigrateVM, user.PermUpdate)...)
+		vms.POST("/:name/backup", withPermissions(backupHandler.BackupVM, user.PermCreate)...)
+		vms.POST("/:name/clone", withPermissions(vmHandler.CloneVM, user.PermCreate)...)
+		vms.PUT("/:name/password", withPermissions(vmHandler.SetGuestPassword, user.PermUpdate)...)
 
 		// Snapshot endpoints
-		vms.POST("/:name/snapshots", vmHandler.CreateSnapshot)
+		vms.POST("/:name/snapshots", withPermissions(vmHandler.CreateSnapshot, user.PermUpdate)...)
 		vms.GET("/:name/snapshots", vmHandler.ListSnapshots)
 		vms.GET("/:name/snapshots/:snapshot", vmHandler.GetSnapshot)
-		vms.DELETE("/:name/snapshots/:snapshot", vmHandler.DeleteSnapshot)
-		vms.PUT("/:name/snapshots/:snapshot/revert", vmHandler.RevertSnapshot)
+		vms.DELETE("/:name/snapshots/:snapshot", withPermissions(vmHandler.DeleteSnapshot, user.PermDelete)...)
+		vms.PUT("/:name/snapshots/:snapshot/revert", withPermissions(vmHandler.RevertSnapshot, user.PermUpdate)...)
+
+		// Block job endpoints
+		vms.POST("/:name/blockcommit", withPermissions(vmHandler.BlockCommit, user.PermUpdate)...)
+		vms.POST("/:name/blockpull", withPermissions(vmHandler.BlockPull, user.PermUpdate)...)
+		vms.GET("/:name/blockjobs", vmHandler.ListBlockJobs)
+		vms.DELETE("/:name/blockjobs/:device", withPermissions(vmHandler.AbortBlockJob, user.PermUpdate)...)
+
+		// Disk tuning endpoints
+		vms.PUT("/:name/disks/:device/iotune", withPermissions(vmHandler.SetDiskIOTune, user.PermUpdate)...)
+	}
+
+	// VM job management
+	vmJobs := protected.Group("/vm-jobs")
+	{
+		vmJobs.GET("", vmHandler.ListVMJobs)
+		vmJobs.GET("/:id", vmHandler.GetVMJob)
 	}
 
 	// Unified compute instance management endpoints (KVM + Docker)
@@ -234,6 +214,7 @@ func ConfigureRoutes(
 
 			// Cluster and backend status
 			compute.GET("/cluster/status", computeHandler.GetClusterStatus)
+			compute.POST("/placement", computeHandler.PlanPlacement)
 			compute.GET("/backends/:backend/info", computeHandler.GetBackendInfo)
 			compute.GET("/health", computeHandler.HealthCheck)
 		}
@@ -269,6 +250,65 @@ func ConfigureRoutes(
 		exports.DELETE("/:id", exportHandler.CancelExport)
 	}
 
+	// Migration job management
+	migrations := protected.Group("/migrations")
+	{
+		migrations.GET("", withPermissions(migrationHandler.ListMigrations, user.PermRead)...)
+		migrations.GET("/:id", withPermissions(migrationHandler.GetMigration, user.PermRead)...)
+		migrations.DELETE("/:id", withPermissions(migrationHandler.CancelMigration, user.PermUpdate)...)
+		migrations.POST("/:id/postcopy", withPermissions(migrationHandler.StartPostCopy, user.PermUpdate)...)
+	}
+
+	// Scheduled snapshot policies
+	snapshotPolicies := protected.Group("/snapshot-policies")
+	{
+		snapshotPolicies.GET("", withPermissions(snapshotPolicyHandler.ListPolicies, user.PermRead)...)
+		snapshotPolicies.POST("", withPermissions(snapshotPolicyHandler.CreatePolicy, user.PermUpdate)...)
+		snapshotPolicies.GET("/:id", withPermissions(snapshotPolicyHandler.GetPolicy, user.PermRead)...)
+		snapshotPolicies.PUT("/:id", withPermissions(snapshotPolicyHandler.UpdatePolicy, user.PermUpdate)...)
+		snapshotPolicies.DELETE("/:id", withPermissions(snapshotPolicyHandler.DeletePolicy, user.PermDelete)...)
+		snapshotPolicies.POST("/:id/run", withPermissions(snapshotPolicyHandler.RunPolicy, user.PermUpdate)...)
+	}
+
+	// Backup repository
+	backups := protected.Group("/backups")
+	{
+		backups.GET("", withPermissions(backupHandler.ListRestorePoints, user.PermRead)...)
+		backups.POST("/prune", withPermissions(backupHandler.PruneBackups, user.PermDelete)...)
+		backups.GET("/:id", withPermissions(backupHandler.GetRestorePoint, user.PermRead)...)
+		backups.DELETE("/:id", withPermissions(backupHandler.DeleteRestorePoint, user.PermDelete)...)
+		backups.POST("/:id/restore", withPermissions(backupHandler.RestoreBackup, user.PermCreate)...)
+		backups.GET("/:id/files", withPermissions(backupHandler.ListFiles, user.PermRead)...)
+		backups.GET("/:id/files/download", withPermissions(backupHandler.DownloadFile, user.PermRead)...)
+	}
+
+	// Backup and restore job management
+	backupJobs := protected.Group("/backup-jobs")
+	{
+		backupJobs.GET("", withPermissions(backupHandler.ListJobs, user.PermRead)...)
+		backupJobs.GET("/:id", withPermissions(backupHandler.GetJob, user.PermRead)...)
+		backupJobs.DELETE("/:id", withPermissions(backupHandler.CancelJob, user.PermUpdate)...)
+	}
+
+	// Import job management
+	importJobs := protected.Group("/import-jobs")
+	{
+		importJobs.GET("", withPermissions(importHandler.ListJobs, user.PermRead)...)
+		importJobs.GET("/:id", withPermissions(importHandler.GetJob, user.PermRead)...)
+		importJobs.DELETE("/:id", withPermissions(importHandler.CancelJob, user.PermCreate)...)
+	}
+
+	// Resumable volume uploads
+	uploads := protected.Group("/uploads")
+	{
+		uploads.GET("", uploadHandler.ListUploads)
+		uploads.POST("", uploadHandler.CreateUpload)
+		uploads.GET("/:id", uploadHandler.GetUpload)
+		uploads.HEAD("/:id", uploadHandler.GetUploadOffset)
+		uploads.PATCH("/:id", uploadHandler.WriteChunk)
+		uploads.DELETE("/:id", uploadHandler.CancelUpload)
+	}
+
 	// Network management
 	if networkHandlers != nil {
 		networks := protected.Group("/networks")
@@ -304,12 +344,45 @@ func ConfigureRoutes(
 			storage.DELETE("/pools/:name", storageHandlers.DeletePool.Handle)
 			storage.PUT("/pools/:name/start", storageHandlers.StartPool.Handle)
 			storage.PUT("/pools/:name/stop", storageHandlers.StopPool.Handle)
+			storage.GET("/pools/:name/capacity", storageHandlers.PoolCapacity.Handle)
+			storage.GET("/capacity", storageHandlers.ListCapacity.Handle)
 
 			// Volume management
 			storage.GET("/pools/:name/volumes", storageHandlers.ListVolumes.Handle)
 			storage.POST("/pools/:name/volumes", storageHandlers.CreateVolume.Handle)
 			storage.DELETE("/pools/:name/volumes/:volumeName", storageHandlers.DeleteVolume.Handle)
 			storage.POST("/pools/:name/volumes/:volumeName/upload", storageHandlers.UploadVolume.Handle)
+			storage.GET("/pools/:name/volumes/:volumeName", storageHandlers.GetVolume.Handle)
+			storage.GET("/pools/:name/volumes/:volumeName/xml", storageHandlers.GetVolumeXML.Handle)
+			storage.GET("/pools/:name/volumes/:volumeName/download", storageHandlers.DownloadVolume.Handle)
+			storage.HEAD("/pools/:name/volumes/:volumeName/download", storageHandlers.DownloadVolume.Handle)
+			storage.PUT("/pools/:name/volumes/:volumeName/resize", storageHandlers.ResizeVolume.Handle)
+			storage.POST("/pools/:name/volumes/:volumeName/clone", storageHandlers.CloneVolume.Handle)
+			storage.POST("/pools/:name/volumes/:volumeName/wipe", storageHandlers.WipeVolume.Handle)
+			storage.POST("/pools/:name/volumes/:volumeName/rotate-key", storageHandlers.RotateVolumeKey.Handle)
+
+			// Volume image inspection, checks, conversions and rebases
+			storage.GET("/pools/:name/volumes/:volumeName/image", storageHandlers.VolumeImageInfo.Handle)
+			storage.POST("/pools/:name/volumes/:volumeName/check", storageHandlers.CheckVolume.Handle)
+			storage.POST("/pools/:name/volumes/:volumeName/convert", storageHandlers.ConvertVolume.Handle)
+			storage.POST("/pools/:name/volumes/:volumeName/rebase", storageHandlers.RebaseVolume.Handle)
+
+			// Volume jobs
+			storage.GET("/volume-jobs", storageHandlers.ListVolumeJobs.Handle)
+			storage.GET("/volume-jobs/:id", storageHandlers.GetVolumeJob.Handle)
+			storage.DELETE("/volume-jobs/:id", storageHandlers.CancelVolumeJob.Handle)
+
+			// Image library
+			storage.GET("/images", withPermissions(storageHandlers.ListImages.Handle, user.PermRead)...)
+			storage.POST("/images", withPermissions(storageHandlers.RegisterImage.Handle, user.PermCreate)...)
+			storage.GET("/images/:image", withPermissions(storageHandlers.GetImage.Handle, user.PermRead)...)
+			storage.PATCH("/images/:image", withPermissions(storageHandlers.UpdateImage.Handle, user.PermUpdate)...)
+			storage.DELETE("/images/:image", withPermissions(storageHandlers.DeleteImage.Handle, user.PermDelete)...)
+
+			// Orphaned volumes and cloud-init ISOs
+			storage.GET("/orphans", adminOnly, storageHandlers.ListOrphans.Handle)
+			storage.DELETE("/orphans", adminOnly, storageHandlers.PurgeAllOrphans.Handle)
+			storage.DELETE("/orphans/:id", adminOnly, storageHandlers.PurgeOrphan.Handle)
 		}
 	}
 
@@ -347,6 +420,7 @@ func ConfigureRoutes(
 				router,
 				"/ws",
 				vmAdapter,
+				manager,
 				log,
 				jwtMiddleware,
 				roleMiddleware,
@@ -357,6 +431,7 @@ func ConfigureRoutes(
 				router,
 				"/ws",
 				vmAdapter,
+				manager,
 				log,
 			)
 		}
@@ -10,9 +10,44 @@ type StorageHandlers struct {
 	StartPool  Handler
 	StopPool   Handler
 
+	// Pool capacity handlers.
+	ListCapacity Handler
+	PoolCapacity Handler
+
 	// Volume handlers.
 	ListVolumes  Handler
 	CreateVolume Handler
 	DeleteVolume Handler
 	UploadVolume Handler
+
+	GetVolume       Handler
+	GetVolumeXML    Handler
+	DownloadVolume  Handler
+	ResizeVolume    Handler
+	CloneVolume     Handler
+	WipeVolume      Handler
+	RotateVolumeKey Handler
+
+	// Volume image handlers.
+	VolumeImageInfo Handler
+	CheckVolume     Handler
+	ConvertVolume   Handler
+	RebaseVolume    Handler
+
+	// Volume jobs.
+	ListVolumeJobs  Handler
+	GetVolumeJob    Handler
+	CancelVolumeJob Handler
+
+	// Image library handlers.
+	ListImages    Handler
+	RegisterImage Handler
+	GetImage      Handler
+	UpdateImage   Handler
+	DeleteImage   Handler
+
+	// Orphaned volume and cloud-init ISO handlers.
+	ListOrphans     Handler
+	PurgeOrphan     Handler
+	PurgeAllOrphans Handler
 }
@@ -0,0 +1,245 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/pkg/logger"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// ListFiles implements Manager.ListFiles. The disks of the restore point are
+// assembled into raw images, which stay cached until another restore point
+// is browsed.
+func (m *BackupManager) ListFiles(ctx context.Context, id string, guestPath string) ([]FileEntry, error) {
+	guestPath, err := cleanGuestPath(guestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	m.browseMu.Lock()
+	defer m.browseMu.Unlock()
+
+	images, err := m.browseImages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(imageArgs(images), "-l", guestPath)
+	output, err := exec.ExecuteCommand(ctx, "virt-ls", args, exec.CommandOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: listing %s: %w", errors.ErrNotFound, guestPath, err)
+	}
+
+	return parseLongListing(output), nil
+}
+
+// ExtractFile implements Manager.ExtractFile.
+func (m *BackupManager) ExtractFile(ctx context.Context, id string, guestPath string) (string, func(), error) {
+	guestPath, err := cleanGuestPath(guestPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if guestPath == "/" {
+		return "", nil, fmt.Errorf("%w: path must name a file", errors.ErrInvalidParameter)
+	}
+
+	m.browseMu.Lock()
+	defer m.browseMu.Unlock()
+
+	images, err := m.browseImages(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	outputDir, err := os.MkdirTemp(m.config.ScratchDir, "extract-")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating extract directory: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(outputDir); err != nil {
+			m.logger.Warn("Failed to remove extracted file",
+				logger.String("path", outputDir),
+				logger.Error(err))
+		}
+	}
+
+	args := append(imageArgs(images), guestPath, outputDir)
+	if _, err := exec.ExecuteCommand(ctx, "virt-copy-out", args, exec.CommandOptions{}); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("%w: copying %s: %w", errors.ErrNotFound, guestPath, err)
+	}
+
+	localPath := filepath.Join(outputDir, path.Base(guestPath))
+	info, err := os.Stat(localPath)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("%w: %s", errors.ErrNotFound, guestPath)
+	}
+	if !info.Mode().IsRegular() {
+		cleanup()
+		return "", nil, fmt.Errorf("%w: %s is not a regular file", errors.ErrInvalidParameter, guestPath)
+	}
+
+	return localPath, cleanup, nil
+}
+
+// browseImages returns the raw disk images of a restore point, assembling
+// them into the browse cache when needed. The caller holds browseMu.
+func (m *BackupManager) browseImages(ctx context.Context, id string) ([]string, error) {
+	point, err := m.repo.GetPoint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(m.config.ScratchDir, "browse")
+	images := make([]string, 0, len(point.Disks))
+	for _, disk := range point.Disks {
+		images = append(images, filepath.Join(dir, disk.Device+".raw"))
+	}
+
+	if m.browsed == id {
+		return images, nil
+	}
+
+	m.clearBrowseCache()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating browse directory: %w", err)
+	}
+
+	unlock := m.repo.Lock()
+	defer unlock()
+
+	for i, disk := range point.Disks {
+		refs, err := m.repo.Chunks(id, disk.Device)
+		if err == nil {
+			err = assembleImage(ctx, m.repo, refs, int64(disk.Size), images[i]) //nolint:gosec
+		}
+		if err != nil {
+			m.clearBrowseCache()
+			return nil, fmt.Errorf("assembling disk %s: %w", disk.Device, err)
+		}
+	}
+
+	m.browsed = id
+	return images, nil
+}
+
+// clearBrowseCache removes the cached disk images. The caller holds browseMu.
+func (m *BackupManager) clearBrowseCache() {
+	m.browsed = ""
+	if err := os.RemoveAll(filepath.Join(m.config.ScratchDir, "browse")); err != nil {
+		m.logger.Warn("Failed to clear browse cache", logger.Error(err))
+	}
+}
+
+// cleanGuestPath checks and normalizes a path in the guest filesystem.
+func cleanGuestPath(guestPath string) (string, error) {
+	if guestPath == "" {
+		return "/", nil
+	}
+	if !strings.HasPrefix(guestPath, "/") || strings.ContainsRune(guestPath, 0) {
+		return "", fmt.Errorf("%w: path must be absolute", errors.ErrInvalidParameter)
+	}
+	return path.Clean(guestPath), nil
+}
+
+// imageArgs returns the libguestfs tool arguments adding raw disk images.
+func imageArgs(images []string) []string {
+	args := []string{"--format=raw"}
+	for _, image := range images {
+		args = append(args, "-a", image)
+	}
+	return args
+}
+
+// parseLongListing parses the "ls -la" style output of virt-ls -l. Entries
+// for the directory itself and its parent are skipped.
+func parseLongListing(output []byte) []FileEntry {
+	entries := []FileEntry{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "total ") {
+			continue
+		}
+
+		// mode, links, owner, group, size and a three-field date precede
+		// the name; device files show "major, minor" instead of a size
+		fields, rest := splitFields(line, 5)
+		if len(fields) < 5 || len(fields[0]) < 10 {
+			continue
+		}
+
+		size, _ := strconv.ParseInt(fields[4], 10, 64)
+		if strings.HasSuffix(fields[4], ",") {
+			size = 0
+			_, rest = splitFields(rest, 1)
+		}
+
+		dateFields, name := splitFields(rest, 3)
+		if len(dateFields) < 3 || name == "" || name == "." || name == ".." {
+			continue
+		}
+
+		entry := FileEntry{
+			Name: name,
+			Mode: fields[0],
+			Size: size,
+		}
+
+		switch fields[0][0] {
+		case '-':
+			entry.Type = "file"
+		case 'd':
+			entry.Type = "directory"
+		case 'l':
+			entry.Type = "symlink"
+			if linkName, target, found := strings.Cut(name, " -> "); found {
+				entry.Name = linkName
+				entry.Target = target
+			}
+		default:
+			entry.Type = "other"
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// splitFields splits the first n whitespace-separated fields off a line and
+// returns them with the rest of the line, whose spacing is kept.
+func splitFields(line string, n int) ([]string, string) {
+	fields := make([]string, 0, n)
+	rest := line
+
+	for len(fields) < n {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			fields = append(fields, rest)
+			rest = ""
+			break
+		}
+
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+
+	return fields, strings.TrimLeft(rest, " \t")
+}
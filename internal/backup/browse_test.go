@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/threatflux/libgo/internal/errors"
+)
+
+func TestParseLongListing(t *testing.T) {
+	output := []byte(`total 28
+drwxr-xr-x  4 0 0  4096 Mar  1 12:00 .
+drwxr-xr-x 18 0 0  4096 Feb  2  2026 ..
+-rw-r--r--  1 0 0   220 Mar  1 12:00 .bashrc
+drwx------  2 0 0  4096 Mar  1 12:00 .ssh
+-rw-r--r--  1 0 0 12345 Mar  1 12:00 my notes.txt
+lrwxrwxrwx  1 0 0     7 Mar  1 12:00 bin -> usr/bin
+crw-rw-rw-  1 0 0  1, 3 Mar  1 12:00 null
+`)
+
+	assert.Equal(t, []FileEntry{
+		{Name: ".bashrc", Type: "file", Mode: "-rw-r--r--", Size: 220},
+		{Name: ".ssh", Type: "directory", Mode: "drwx------", Size: 4096},
+		{Name: "my notes.txt", Type: "file", Mode: "-rw-r--r--", Size: 12345},
+		{Name: "bin", Type: "symlink", Mode: "lrwxrwxrwx", Size: 7, Target: "usr/bin"},
+		{Name: "null", Type: "other", Mode: "crw-rw-rw-"},
+	}, parseLongListing(output))
+
+	assert.Empty(t, parseLongListing(nil))
+}
+
+func TestCleanGuestPath(t *testing.T) {
+	path, err := cleanGuestPath("")
+	assert.NoError(t, err)
+	assert.Equal(t, "/", path)
+
+	path, err = cleanGuestPath("/etc/../root/")
+	assert.NoError(t, err)
+	assert.Equal(t, "/root", path)
+
+	_, err = cleanGuestPath("etc")
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+}
@@ -0,0 +1,238 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// extent is a range of an image reported by qemu-img map.
+type extent struct {
+	Start   int64 `json:"start"`
+	Length  int64 `json:"length"`
+	Depth   int   `json:"depth"`
+	Present bool  `json:"present"`
+	Zero    bool  `json:"zero"`
+	Data    bool  `json:"data"`
+}
+
+// chunkResult is the outcome of storing the chunks of a disk.
+type chunkResult struct {
+	Refs []ChunkRef
+	// Changed is the amount of data the backup wrote to the disk image
+	Changed uint64
+	// Stored is the size of the chunks added to the repository
+	Stored uint64
+}
+
+// mapImage returns the extents of an image and its virtual size.
+func mapImage(ctx context.Context, path string, format string) ([]extent, int64, error) {
+	output, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"map", "--output=json", "-f", format, path,
+	}, exec.CommandOptions{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("mapping %s: %w", path, err)
+	}
+
+	return parseImageMap(output)
+}
+
+// parseImageMap parses the output of qemu-img map. It keeps the extents the
+// image itself holds, whether data or zeros, and returns them in order along
+// with the virtual size of the image.
+func parseImageMap(output []byte) ([]extent, int64, error) {
+	var all []extent
+	if err := json.Unmarshal(output, &all); err != nil {
+		return nil, 0, fmt.Errorf("parsing image map: %w", err)
+	}
+
+	var size int64
+	extents := make([]extent, 0, len(all))
+	for _, e := range all {
+		if end := e.Start + e.Length; end > size {
+			size = end
+		}
+		// Ranges from a backing file or left unallocated did not change
+		if !e.Present || e.Depth != 0 || e.Length <= 0 {
+			continue
+		}
+		extents = append(extents, e)
+	}
+
+	sort.Slice(extents, func(i, j int) bool {
+		return extents[i].Start < extents[j].Start
+	})
+
+	return extents, size, nil
+}
+
+// storeChunks splits the extents of a raw image into chunks and stores them
+// in the repository. Chunks the extents do not touch keep the content they
+// had in the parent chunk list, so the refs of an incremental backup
+// describe the whole disk.
+func storeChunks(ctx context.Context, repo *Repository, image io.ReaderAt, size int64, extents []extent, parent []ChunkRef, progress func(done int64)) (*chunkResult, error) {
+	chunkCount := (size + ChunkSize - 1) / ChunkSize
+
+	refs := make(map[int64]string, len(parent))
+	for _, ref := range parent {
+		if ref.Index < chunkCount {
+			refs[ref.Index] = ref.Hash
+		}
+	}
+
+	result := &chunkResult{}
+	buf := make([]byte, ChunkSize)
+	next := 0
+	var done int64
+
+	for _, index := range touchedChunks(extents, size) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chunkStart := index * ChunkSize
+		chunkEnd := min(chunkStart+ChunkSize, size)
+		data := buf[:chunkEnd-chunkStart]
+
+		// Start from the previous content, parts the extents do not cover
+		// are unchanged
+		if err := loadChunk(repo, refs[index], data); err != nil {
+			return nil, err
+		}
+
+		for next < len(extents) && extents[next].Start+extents[next].Length <= chunkStart {
+			next++
+		}
+		for i := next; i < len(extents) && extents[i].Start < chunkEnd; i++ {
+			e := extents[i]
+			from := max(e.Start, chunkStart)
+			to := min(e.Start+e.Length, chunkEnd)
+			part := data[from-chunkStart : to-chunkStart]
+
+			if e.Data {
+				if _, err := image.ReadAt(part, from); err != nil && err != io.EOF {
+					return nil, fmt.Errorf("reading image at %d: %w", from, err)
+				}
+			} else {
+				clear(part)
+			}
+
+			result.Changed += uint64(to - from) //nolint:gosec
+			done += to - from
+		}
+
+		if isZero(data) {
+			delete(refs, index)
+		} else {
+			hash, stored, err := repo.PutChunk(data)
+			if err != nil {
+				return nil, err
+			}
+			refs[index] = hash
+			result.Stored += uint64(stored) //nolint:gosec
+		}
+
+		if progress != nil {
+			progress(done)
+		}
+	}
+
+	result.Refs = make([]ChunkRef, 0, len(refs))
+	for index, hash := range refs {
+		result.Refs = append(result.Refs, ChunkRef{Index: index, Hash: hash})
+	}
+	sort.Slice(result.Refs, func(i, j int) bool {
+		return result.Refs[i].Index < result.Refs[j].Index
+	})
+
+	return result, nil
+}
+
+// assembleImage writes the chunks of a disk to a sparse raw image.
+func assembleImage(ctx context.Context, repo *Repository, refs []ChunkRef, size int64, path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("creating image: %w", err)
+	}
+	defer file.Close()
+
+	if err := file.Truncate(size); err != nil {
+		return fmt.Errorf("sizing image: %w", err)
+	}
+
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := repo.GetChunk(ref.Hash)
+		if err != nil {
+			return err
+		}
+		if _, err := file.WriteAt(data, ref.Index*ChunkSize); err != nil {
+			return fmt.Errorf("writing image: %w", err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing image: %w", err)
+	}
+
+	return nil
+}
+
+// touchedChunks returns the indexes of the chunks overlapping the extents,
+// in order.
+func touchedChunks(extents []extent, size int64) []int64 {
+	var indexes []int64
+	last := int64(-1)
+
+	for _, e := range extents {
+		end := min(e.Start+e.Length, size)
+		if end <= e.Start {
+			continue
+		}
+		for index := max(e.Start/ChunkSize, last+1); index <= (end-1)/ChunkSize; index++ {
+			indexes = append(indexes, index)
+			last = index
+		}
+	}
+
+	return indexes
+}
+
+// loadChunk fills data with the content of a stored chunk, or with zeros
+// when hash is empty.
+func loadChunk(repo *Repository, hash string, data []byte) error {
+	if hash == "" {
+		clear(data)
+		return nil
+	}
+
+	content, err := repo.GetChunk(hash)
+	if err != nil {
+		return err
+	}
+	clear(data)
+	copy(data, content)
+	return nil
+}
+
+// isZero reports whether data holds only zeros.
+func isZero(data []byte) bool {
+	var zero [4096]byte
+	for len(data) > 0 {
+		n := min(len(data), len(zero))
+		if !bytes.Equal(data[:n], zero[:n]) {
+			return false
+		}
+		data = data[n:]
+	}
+	return true
+}
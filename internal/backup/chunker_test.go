@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageMap(t *testing.T) {
+	output := []byte(`[
+{ "start": 0, "length": 65536, "depth": 0, "present": true, "zero": false, "data": true, "offset": 327680},
+{ "start": 65536, "length": 131072, "depth": 0, "present": false, "zero": true, "data": false},
+{ "start": 196608, "length": 65536, "depth": 0, "present": true, "zero": true, "data": false},
+{ "start": 262144, "length": 1048576, "depth": 1, "present": true, "zero": false, "data": true, "offset": 0}
+]`)
+
+	extents, size, err := parseImageMap(output)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1310720), size)
+	assert.Equal(t, []extent{
+		{Start: 0, Length: 65536, Present: true, Data: true},
+		{Start: 196608, Length: 65536, Present: true, Zero: true},
+	}, extents)
+
+	_, _, err = parseImageMap([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestStoreChunks(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(t.TempDir())
+	require.NoError(t, err)
+
+	// A full backup of a disk of two and a half chunks; the second chunk
+	// is a hole
+	size := int64(ChunkSize*2 + ChunkSize/2)
+	disk := make([]byte, size)
+	copy(disk, bytes.Repeat([]byte{1}, ChunkSize))
+	copy(disk[ChunkSize*2:], bytes.Repeat([]byte{3}, ChunkSize/2))
+
+	full, err := storeChunks(ctx, repo, bytes.NewReader(disk), size, []extent{
+		{Start: 0, Length: ChunkSize, Present: true, Data: true},
+		{Start: ChunkSize, Length: ChunkSize, Present: true, Zero: true},
+		{Start: ChunkSize * 2, Length: ChunkSize / 2, Present: true, Data: true},
+	}, nil, nil)
+	require.NoError(t, err)
+
+	require.Len(t, full.Refs, 2)
+	assert.Equal(t, int64(0), full.Refs[0].Index)
+	assert.Equal(t, int64(2), full.Refs[1].Index)
+	assert.Equal(t, uint64(size), full.Changed)
+	assert.Equal(t, uint64(ChunkSize+ChunkSize/2), full.Stored)
+
+	// An incremental backup changes a few bytes in the first chunk and
+	// writes the hole
+	changed := make([]byte, size)
+	copy(changed[100:], []byte("changed"))
+	copy(changed[ChunkSize+10:], []byte("new data"))
+
+	incremental, err := storeChunks(ctx, repo, bytes.NewReader(changed), size, []extent{
+		{Start: 0, Length: 4096, Present: true, Data: true},
+		{Start: ChunkSize, Length: 4096, Present: true, Data: true},
+	}, full.Refs, nil)
+	require.NoError(t, err)
+
+	require.Len(t, incremental.Refs, 3)
+	assert.NotEqual(t, full.Refs[0].Hash, incremental.Refs[0].Hash)
+	assert.Equal(t, full.Refs[1], incremental.Refs[2])
+	assert.Equal(t, uint64(8192), incremental.Changed)
+
+	expected := append([]byte(nil), disk...)
+	copy(expected[:4096], changed[:4096])
+	copy(expected[ChunkSize:ChunkSize+4096], changed[ChunkSize:ChunkSize+4096])
+
+	path := filepath.Join(t.TempDir(), "disk.raw")
+	require.NoError(t, assembleImage(ctx, repo, incremental.Refs, size, path))
+
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(expected, restored))
+}
+
+func TestTouchedChunks(t *testing.T) {
+	assert.Equal(t, []int64{0, 1, 3}, touchedChunks([]extent{
+		{Start: 0, Length: 100},
+		{Start: ChunkSize - 10, Length: 20},
+		{Start: ChunkSize*3 + 5, Length: ChunkSize},
+	}, ChunkSize*3+100))
+}
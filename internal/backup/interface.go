@@ -0,0 +1,179 @@
+package backup
+
+import (
+	"context"
+	"time"
+)
+
+// Type is the kind of a restore point.
+type Type string
+
+// Restore point types.
+const (
+	// TypeFull copies every allocated block of the disks
+	TypeFull Type = "full"
+	// TypeIncremental copies the blocks changed since the parent restore point
+	TypeIncremental Type = "incremental"
+)
+
+// Operation is what a job does.
+type Operation string
+
+// Job operations.
+const (
+	OperationBackup  Operation = "backup"
+	OperationRestore Operation = "restore"
+)
+
+// Status represents backup job status.
+type Status string
+
+// Job status constants.
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// Params represents backup parameters.
+type Params struct {
+	// Full takes a full backup even when an incremental one is possible
+	Full bool `json:"full,omitempty"`
+	// KeepLast prunes the restore points of the VM beyond the newest N once
+	// the backup completes, zero keeps all of them
+	KeepLast int `json:"keepLast,omitempty"`
+	// KeepDays prunes restore points of the VM older than D days once the
+	// backup completes, zero keeps all of them
+	KeepDays int `json:"keepDays,omitempty"`
+}
+
+// RestoreParams represents parameters for restoring a restore point to a new VM.
+type RestoreParams struct {
+	// Name is the name of the new VM
+	Name string `json:"name" binding:"required"`
+	// Pool is the storage pool receiving the restored disks
+	Pool string `json:"pool,omitempty"`
+	// Start starts the new VM once its disks are restored
+	Start bool `json:"start,omitempty"`
+}
+
+// PruneParams selects the restore points removed from the repository. The
+// newest restore point of a VM is always kept.
+type PruneParams struct {
+	// VMName limits pruning to one VM, all VMs are pruned when empty
+	VMName string `json:"vmName,omitempty"`
+	// KeepLast keeps the newest N restore points of each VM
+	KeepLast int `json:"keepLast,omitempty"`
+	// KeepDays keeps restore points taken within the last D days
+	KeepDays int `json:"keepDays,omitempty"`
+}
+
+// PruneResult describes what pruning removed.
+type PruneResult struct {
+	// Deleted lists the IDs of the removed restore points
+	Deleted []string `json:"deleted"`
+	// FreedBytes is the size of the chunks no restore point referenced anymore
+	FreedBytes uint64 `json:"freedBytes"`
+}
+
+// Disk describes the backup of one disk in a restore point.
+type Disk struct {
+	// Device is the target device of the disk, e.g. "vda"
+	Device string `json:"device"`
+	// Source is the path of the disk on the host when it was backed up
+	Source string `json:"source"`
+	// Format is the format of the disk when it was backed up
+	Format string `json:"format"`
+	// Size is the virtual size of the disk in bytes
+	Size uint64 `json:"size"`
+	// ChangedBytes is the amount of data the backup copied from the disk
+	ChangedBytes uint64 `json:"changedBytes"`
+}
+
+// RestorePoint is the state of the disks of a VM at the time of a backup.
+type RestorePoint struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Disks     []Disk    `json:"disks"`
+	ID        string    `json:"id"`
+	VMName    string    `json:"vmName"`
+	VMUUID    string    `json:"vmUuid"`
+	// Host is the libvirt host the VM ran on
+	Host string `json:"host,omitempty"`
+	Type Type   `json:"type"`
+	// Parent is the restore point an incremental backup is based on
+	Parent string `json:"parent,omitempty"`
+	// Checkpoint is the libvirt checkpoint tracking changes since this backup
+	Checkpoint string `json:"checkpoint,omitempty"`
+	// StoredBytes is the size of the chunks this backup added to the repository
+	StoredBytes uint64 `json:"storedBytes"`
+}
+
+// FileEntry is a file in the guest filesystem of a restore point.
+type FileEntry struct {
+	Name string `json:"name"`
+	// Type is "file", "directory", "symlink" or "other"
+	Type string `json:"type"`
+	// Mode is the permission string, e.g. "-rw-r--r--"
+	Mode string `json:"mode"`
+	// Target is where a symbolic link points
+	Target string `json:"target,omitempty"`
+	Size   int64  `json:"size"`
+}
+
+// Job represents a backup or restore job.
+type Job struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+	ID        string    `json:"id"`
+	Operation Operation `json:"operation"`
+	// VMName is the VM backed up, or the new VM of a restore
+	VMName string `json:"vmName"`
+	// Host is the libvirt host the job runs on
+	Host string `json:"host,omitempty"`
+	// RestorePoint is the restore point created by a backup or read by a restore
+	RestorePoint string `json:"restorePoint,omitempty"`
+	Type         Type   `json:"type,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Status       Status `json:"status"`
+	Progress     int    `json:"progress"`
+}
+
+// Manager defines interface for backup management.
+type Manager interface {
+	// StartBackup starts backing up a running VM into the repository
+	StartBackup(ctx context.Context, vmName string, params Params) (*Job, error)
+
+	// ListRestorePoints lists the restore points of a VM, or of all VMs when
+	// vmName is empty, oldest first
+	ListRestorePoints(ctx context.Context, vmName string) ([]*RestorePoint, error)
+
+	// GetRestorePoint gets a restore point by ID
+	GetRestorePoint(ctx context.Context, id string) (*RestorePoint, error)
+
+	// DeleteRestorePoint removes a restore point and the chunks only it used
+	DeleteRestorePoint(ctx context.Context, id string) error
+
+	// Restore starts restoring a restore point to a new VM
+	Restore(ctx context.Context, id string, params RestoreParams) (*Job, error)
+
+	// ListFiles lists a directory of the guest filesystem of a restore point
+	ListFiles(ctx context.Context, id string, path string) ([]FileEntry, error)
+
+	// ExtractFile copies a file out of a restore point. The returned function
+	// removes the copy.
+	ExtractFile(ctx context.Context, id string, path string) (string, func(), error)
+
+	// Prune removes restore points beyond the retention
+	Prune(ctx context.Context, params PruneParams) (*PruneResult, error)
+
+	// GetJob gets a backup or restore job by ID
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+
+	// ListJobs lists all backup and restore jobs
+	ListJobs(ctx context.Context) ([]*Job, error)
+
+	// CancelJob aborts a running job
+	CancelJob(ctx context.Context, jobID string) error
+}
@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/jobs"
+)
+
+// jobStore provides thread-safe storage for backup and restore jobs.
+type jobStore struct {
+	*jobs.Store[Job]
+}
+
+// newJobStore creates a new job store.
+func newJobStore() *jobStore {
+	return &jobStore{Store: jobs.NewStore[Job]()}
+}
+
+// createJob creates a new job.
+func (s *jobStore) createJob(operation Operation, vmName string, host string, restorePoint string) *Job {
+	id := uuid.New().String()
+	job, _ := s.Add(id, &Job{
+		ID:           id,
+		Operation:    operation,
+		VMName:       vmName,
+		Host:         host,
+		RestorePoint: restorePoint,
+		Status:       StatusPending,
+		StartTime:    time.Now(),
+	}, nil)
+
+	return job
+}
+
+// activeJob returns the ID of an unfinished job of an operation for a VM on
+// a host.
+func (s *jobStore) activeJob(operation Operation, vmName string, host string) (string, bool) {
+	return s.Find(func(job *Job) bool {
+		return job.Operation == operation && job.VMName == vmName && job.Host == host && !job.Status.isFinal()
+	})
+}
+
+// updateJobProgress records the progress of a running job.
+func (s *jobStore) updateJobProgress(id string, progress int) bool {
+	updated := false
+	s.Update(id, func(job *Job) {
+		if job.Status.isFinal() {
+			return
+		}
+		updated = true
+
+		job.Progress = min(max(progress, 0), 99)
+	})
+
+	return updated
+}
+
+// setJobResult records the restore point and type of a backup.
+func (s *jobStore) setJobResult(id string, restorePoint string, backupType Type) bool {
+	return s.Update(id, func(job *Job) {
+		job.RestorePoint = restorePoint
+		job.Type = backupType
+	})
+}
+
+// updateJobStatus updates a job's status. Jobs that already finished keep
+// their final status.
+func (s *jobStore) updateJobStatus(id string, status Status, err error) bool {
+	updated := false
+	s.Update(id, func(job *Job) {
+		if job.Status.isFinal() {
+			return
+		}
+		updated = true
+
+		job.Status = status
+		if err != nil {
+			job.Error = err.Error()
+		}
+		if status == StatusCompleted {
+			job.Progress = 100
+		}
+		if status.isFinal() {
+			job.EndTime = time.Now()
+		}
+	})
+
+	if updated && status.isFinal() {
+		s.Release(id)
+	}
+
+	return updated
+}
+
+// isFinal reports whether a job in this status has finished.
+func (s Status) isFinal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCanceled
+}
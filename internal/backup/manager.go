@@ -0,0 +1,803 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// CheckpointPrefix starts the names of the checkpoints created by backups.
+const CheckpointPrefix = "libgo-backup-"
+
+// DefaultPollInterval is how often the progress of a running backup is read.
+const DefaultPollInterval = 2 * time.Second
+
+// Share of the progress of a backup spent while the hypervisor copies the
+// disks; the rest is spent storing chunks.
+const copyProgressShare = 50
+
+// Config holds backup manager configuration.
+type Config struct {
+	// ScratchDir receives the disk images written by the hypervisor during
+	// backups and the images assembled for restores and file browsing. The
+	// hypervisor and this service must both be able to access it.
+	ScratchDir string
+	// DefaultPool receives restored disks when a restore names no pool
+	DefaultPool string
+	// PollInterval is how often the progress of a running backup is read
+	PollInterval time.Duration
+}
+
+// BackupManager implements Manager.
+type BackupManager struct {
+	jobStore      *jobStore
+	repo          *Repository
+	domainManager domain.Manager
+	volumeManager storage.VolumeManager
+	hosts         connection.HostLocator
+	logger        logger.Logger
+	config        Config
+	// browsed is the restore point whose disk images are in the browse cache
+	browsed  string
+	browseMu sync.Mutex
+}
+
+// NewBackupManager creates a new BackupManager. The hypervisor writes
+// backups to the local scratch directory and restores write volumes with
+// qemu-img on this machine, so both are limited to the local host.
+func NewBackupManager(
+	repo *Repository,
+	domainManager domain.Manager,
+	volumeManager storage.VolumeManager,
+	hosts connection.HostLocator,
+	config Config,
+	logger logger.Logger,
+) *BackupManager {
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+
+	return &BackupManager{
+		jobStore:      newJobStore(),
+		repo:          repo,
+		domainManager: domainManager,
+		volumeManager: volumeManager,
+		hosts:         hosts,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// StartBackup implements Manager.StartBackup.
+func (m *BackupManager) StartBackup(ctx context.Context, vmName string, params Params) (*Job, error) {
+	if params.KeepLast < 0 || params.KeepDays < 0 {
+		return nil, fmt.Errorf("%w: keepLast and keepDays cannot be negative", errors.ErrInvalidParameter)
+	}
+
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("backing up %s: %w", vmName, err)
+	}
+
+	vm, err := m.domainManager.Get(ctx, vmName)
+	if err != nil {
+		return nil, fmt.Errorf("VM not found: %w", err)
+	}
+
+	if !vm.Status.IsActive() {
+		return nil, fmt.Errorf("backing up %s: %w", vmName, domain.ErrDomainNotRunning)
+	}
+
+	if len(backupDisks(vm)) == 0 {
+		return nil, fmt.Errorf("%w: VM %s has no writable disks to back up", errors.ErrInvalidParameter, vmName)
+	}
+
+	host, _ := connection.HostFromContext(ctx)
+	if jobID, active := m.jobStore.activeJob(OperationBackup, vmName, host); active {
+		return nil, fmt.Errorf("%w: job %s", errors.ErrBackupInProgress, jobID)
+	}
+
+	job := m.jobStore.createJob(OperationBackup, vmName, host, "")
+
+	// The backup outlives the request that started it but keeps its values,
+	// such as the selected libvirt host
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.jobStore.SetCancel(job.ID, cancel)
+
+	go m.processBackupJob(jobCtx, cancel, job.ID, vm, params)
+
+	return job, nil
+}
+
+// ListRestorePoints implements Manager.ListRestorePoints.
+func (m *BackupManager) ListRestorePoints(ctx context.Context, vmName string) ([]*RestorePoint, error) {
+	points, err := m.repo.ListPoints()
+	if err != nil {
+		return nil, err
+	}
+
+	if vmName == "" {
+		return points, nil
+	}
+
+	result := make([]*RestorePoint, 0, len(points))
+	for _, point := range points {
+		if point.VMName == vmName {
+			result = append(result, point)
+		}
+	}
+
+	return result, nil
+}
+
+// GetRestorePoint implements Manager.GetRestorePoint.
+func (m *BackupManager) GetRestorePoint(ctx context.Context, id string) (*RestorePoint, error) {
+	return m.repo.GetPoint(id)
+}
+
+// DeleteRestorePoint implements Manager.DeleteRestorePoint.
+func (m *BackupManager) DeleteRestorePoint(ctx context.Context, id string) error {
+	if err := m.deletePoint(id); err != nil {
+		return err
+	}
+
+	freed, err := m.repo.CollectGarbage()
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Deleted restore point",
+		logger.String("restore_point", id),
+		logger.Uint64("freed_bytes", freed))
+
+	return nil
+}
+
+// Restore implements Manager.Restore.
+func (m *BackupManager) Restore(ctx context.Context, id string, params RestoreParams) (*Job, error) {
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("restoring %s: %w", id, err)
+	}
+
+	point, err := m.repo.GetPoint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.domainManager.Get(ctx, params.Name); err == nil {
+		return nil, fmt.Errorf("%w: VM %s", errors.ErrAlreadyExists, params.Name)
+	}
+
+	if params.Pool == "" {
+		params.Pool = m.config.DefaultPool
+	}
+
+	host, _ := connection.HostFromContext(ctx)
+	if jobID, active := m.jobStore.activeJob(OperationRestore, params.Name, host); active {
+		return nil, fmt.Errorf("%w: VM %s is being restored by job %s", errors.ErrAlreadyExists, params.Name, jobID)
+	}
+
+	job := m.jobStore.createJob(OperationRestore, params.Name, host, point.ID)
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	jobCtx = storage.WithVolumeOrigin(jobCtx, storage.VolumeOrigin{VM: params.Name, Job: job.ID})
+	m.jobStore.SetCancel(job.ID, cancel)
+
+	go m.processRestoreJob(jobCtx, cancel, job.ID, point, params)
+
+	return job, nil
+}
+
+// Prune implements Manager.Prune.
+func (m *BackupManager) Prune(ctx context.Context, params PruneParams) (*PruneResult, error) {
+	if params.KeepLast < 0 || params.KeepDays < 0 {
+		return nil, fmt.Errorf("%w: keepLast and keepDays cannot be negative", errors.ErrInvalidParameter)
+	}
+	if params.KeepLast == 0 && params.KeepDays == 0 {
+		return nil, fmt.Errorf("%w: keepLast or keepDays is required", errors.ErrInvalidParameter)
+	}
+
+	points, err := m.repo.ListPoints()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PruneResult{Deleted: []string{}}
+	for _, point := range expiredPoints(points, params, time.Now()) {
+		if err := m.deletePoint(point.ID); err != nil {
+			return nil, err
+		}
+		result.Deleted = append(result.Deleted, point.ID)
+	}
+
+	if len(result.Deleted) > 0 {
+		result.FreedBytes, err = m.repo.CollectGarbage()
+		if err != nil {
+			return nil, err
+		}
+
+		m.logger.Info("Pruned restore points",
+			logger.String("vm", params.VMName),
+			logger.Int("deleted", len(result.Deleted)),
+			logger.Uint64("freed_bytes", result.FreedBytes))
+	}
+
+	return result, nil
+}
+
+// GetJob implements Manager.GetJob.
+func (m *BackupManager) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	job, exists := m.jobStore.Get(jobID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrBackupJobNotFound, jobID)
+	}
+	return job, nil
+}
+
+// ListJobs implements Manager.ListJobs.
+func (m *BackupManager) ListJobs(ctx context.Context) ([]*Job, error) {
+	return m.jobStore.List(), nil
+}
+
+// CancelJob implements Manager.CancelJob.
+func (m *BackupManager) CancelJob(ctx context.Context, jobID string) error {
+	job, exists := m.jobStore.Get(jobID)
+	if !exists {
+		return fmt.Errorf("%w: %s", errors.ErrBackupJobNotFound, jobID)
+	}
+
+	if job.Status.isFinal() {
+		return fmt.Errorf("%w: cannot cancel job in %s state", errors.ErrBackupInvalidState, job.Status)
+	}
+
+	m.jobStore.Cancel(jobID)
+
+	m.logger.Info("Backup job canceled",
+		logger.String("job_id", jobID),
+		logger.String("vm", job.VMName))
+
+	return nil
+}
+
+// processBackupJob runs a backup in the background.
+func (m *BackupManager) processBackupJob(ctx context.Context, cancel context.CancelFunc, jobID string, vm *vmmodels.VM, params Params) {
+	defer cancel()
+
+	m.jobStore.updateJobStatus(jobID, StatusRunning, nil)
+
+	m.logger.Info("Starting backup job",
+		logger.String("job_id", jobID),
+		logger.String("vm", vm.Name))
+
+	point, err := m.runBackup(ctx, jobID, vm, params)
+
+	// Pruning collects garbage, which waits until the backup released the
+	// repository
+	if err == nil && (params.KeepLast > 0 || params.KeepDays > 0) {
+		if _, pruneErr := m.Prune(ctx, PruneParams{VMName: vm.Name, KeepLast: params.KeepLast, KeepDays: params.KeepDays}); pruneErr != nil {
+			m.logger.Warn("Failed to prune restore points",
+				logger.String("vm", vm.Name),
+				logger.Error(pruneErr))
+		}
+	}
+
+	switch {
+	case err == nil:
+		m.jobStore.updateJobStatus(jobID, StatusCompleted, nil)
+		m.logger.Info("Backup job completed",
+			logger.String("job_id", jobID),
+			logger.String("vm", vm.Name),
+			logger.String("restore_point", point.ID),
+			logger.String("type", string(point.Type)))
+	case ctx.Err() == context.Canceled:
+		m.jobStore.updateJobStatus(jobID, StatusCanceled, nil)
+	default:
+		m.jobStore.updateJobStatus(jobID, StatusFailed, err)
+		m.logger.Error("Backup job failed",
+			logger.String("job_id", jobID),
+			logger.String("vm", vm.Name),
+			logger.Error(err))
+	}
+}
+
+// runBackup backs up the disks of a VM and stores them as a new restore
+// point. The backup is incremental from the newest restore point of the VM
+// when the checkpoint of that restore point still exists.
+func (m *BackupManager) runBackup(ctx context.Context, jobID string, vm *vmmodels.VM, params Params) (*RestorePoint, error) {
+	unlock := m.repo.Lock()
+	defer unlock()
+
+	disks := backupDisks(vm)
+
+	parent, err := m.incrementalParent(ctx, vm, disks, params)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := connection.HostFromContext(ctx)
+	point := &RestorePoint{
+		ID:     uuid.New().String(),
+		VMName: vm.Name,
+		VMUUID: vm.UUID,
+		Host:   host,
+		Type:   TypeFull,
+	}
+
+	// Dirty bitmaps persist only in qcow2 images, other disks always get
+	// full backups
+	if allQCOW2(disks) {
+		point.Checkpoint = CheckpointPrefix + point.ID
+	}
+
+	workDir := filepath.Join(m.config.ScratchDir, jobID)
+	if err := os.MkdirAll(workDir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating scratch directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	backupParams := vmmodels.BackupParams{Checkpoint: point.Checkpoint}
+	targetFormat := "raw"
+	if parent != nil {
+		backupParams.Incremental = parent.Checkpoint
+		point.Type = TypeIncremental
+		point.Parent = parent.ID
+		// Unchanged clusters stay unallocated in a qcow2 target
+		targetFormat = "qcow2"
+	}
+	for _, disk := range disks {
+		backupParams.Disks = append(backupParams.Disks, vmmodels.BackupDisk{
+			Device:       disk.Device,
+			TargetPath:   filepath.Join(workDir, disk.Device+"."+targetFormat),
+			TargetFormat: targetFormat,
+		})
+	}
+
+	m.jobStore.setJobResult(jobID, point.ID, point.Type)
+
+	definition, err := m.domainManager.BeginBackup(ctx, vm.Name, backupParams)
+	if err != nil {
+		return nil, fmt.Errorf("starting backup: %w", err)
+	}
+
+	point.CreatedAt = time.Now().UTC()
+
+	chunks, err := m.collectBackup(ctx, jobID, vm.Name, disks, backupParams.Disks, parent, point)
+	if err == nil {
+		err = m.repo.SavePoint(point, definition, chunks)
+	}
+	if err != nil {
+		// Dropping the new checkpoint merges its changes into the previous
+		// one, so the next incremental backup still sees them
+		if point.Checkpoint != "" {
+			m.deleteCheckpoint(ctx, vm.Name, point.Checkpoint)
+		}
+		return nil, err
+	}
+
+	m.deleteOldCheckpoints(ctx, vm.Name, point.Checkpoint)
+
+	return point, nil
+}
+
+// collectBackup waits for the hypervisor to write the backup and stores the
+// written disk images in the repository, filling in the disks of the point.
+func (m *BackupManager) collectBackup(
+	ctx context.Context,
+	jobID string,
+	vmName string,
+	disks []vmmodels.DiskInfo,
+	targets []vmmodels.BackupDisk,
+	parent *RestorePoint,
+	point *RestorePoint,
+) (map[string][]ChunkRef, error) {
+	if err := m.waitForBackup(ctx, jobID, vmName); err != nil {
+		return nil, err
+	}
+
+	chunks := make(map[string][]ChunkRef, len(targets))
+	for i, target := range targets {
+		var parentRefs []ChunkRef
+		if parent != nil {
+			refs, err := m.repo.Chunks(parent.ID, target.Device)
+			if err != nil {
+				return nil, err
+			}
+			parentRefs = refs
+		}
+
+		size, result, err := m.storeDisk(ctx, target, parentRefs, func(done, total int64) {
+			share := (100 - copyProgressShare) / len(targets)
+			progress := copyProgressShare + i*share
+			if total > 0 {
+				progress += int(done * int64(share) / total)
+			}
+			m.jobStore.updateJobProgress(jobID, progress)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("storing disk %s: %w", target.Device, err)
+		}
+
+		chunks[target.Device] = result.Refs
+		point.StoredBytes += result.Stored
+		point.Disks = append(point.Disks, Disk{
+			Device:       target.Device,
+			Source:       disks[i].Path,
+			Format:       string(disks[i].Format),
+			Size:         uint64(size), //nolint:gosec
+			ChangedBytes: result.Changed,
+		})
+	}
+
+	return chunks, nil
+}
+
+// waitForBackup polls the backup job of a VM until it ends, aborting it
+// when the context is canceled.
+func (m *BackupManager) waitForBackup(ctx context.Context, jobID string, vmName string) error {
+	ticker := time.NewTicker(m.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := m.domainManager.AbortBackup(context.WithoutCancel(ctx), vmName); err != nil {
+				m.logger.Warn("Failed to abort backup",
+					logger.String("vm", vmName),
+					logger.Error(err))
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		info, err := m.domainManager.GetBackupJob(ctx, vmName)
+		if err != nil {
+			return fmt.Errorf("getting backup job: %w", err)
+		}
+
+		if !info.Active {
+			if info.Error != "" {
+				return fmt.Errorf("backup failed: %s", info.Error)
+			}
+			return nil
+		}
+
+		if info.DataTotal > 0 {
+			m.jobStore.updateJobProgress(jobID, int(info.DataProcessed*copyProgressShare/info.DataTotal)) //nolint:gosec
+		}
+	}
+}
+
+// storeDisk stores the image the hypervisor wrote for a disk and returns the
+// virtual size of the disk.
+func (m *BackupManager) storeDisk(ctx context.Context, target vmmodels.BackupDisk, parent []ChunkRef, progress func(done, total int64)) (int64, *chunkResult, error) {
+	extents, size, err := mapImage(ctx, target.TargetPath, target.TargetFormat)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rawPath := target.TargetPath
+	if target.TargetFormat != "raw" {
+		rawPath = strings.TrimSuffix(target.TargetPath, filepath.Ext(target.TargetPath)) + ".raw"
+		if _, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+			"convert", "-f", target.TargetFormat, "-O", "raw", target.TargetPath, rawPath,
+		}, exec.CommandOptions{}); err != nil {
+			return 0, nil, fmt.Errorf("converting backup image: %w", err)
+		}
+	}
+
+	image, err := os.Open(rawPath)
+	if err != nil {
+		return 0, nil, fmt.Errorf("opening backup image: %w", err)
+	}
+	defer image.Close()
+
+	var total int64
+	for _, e := range extents {
+		total += e.Length
+	}
+
+	result, err := storeChunks(ctx, m.repo, image, size, extents, parent, func(done int64) {
+		progress(done, total)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return size, result, nil
+}
+
+// incrementalParent returns the restore point a backup can be incremental
+// from, or nil when the backup has to be full.
+func (m *BackupManager) incrementalParent(ctx context.Context, vm *vmmodels.VM, disks []vmmodels.DiskInfo, params Params) (*RestorePoint, error) {
+	if params.Full || !allQCOW2(disks) {
+		return nil, nil
+	}
+
+	points, err := m.repo.ListPoints()
+	if err != nil {
+		return nil, err
+	}
+
+	var parent *RestorePoint
+	for i := len(points) - 1; i >= 0; i-- {
+		if points[i].VMUUID == vm.UUID {
+			parent = points[i]
+			break
+		}
+	}
+
+	if parent == nil || parent.Checkpoint == "" || !sameDisks(parent.Disks, disks) {
+		return nil, nil
+	}
+
+	checkpoints, err := m.domainManager.ListCheckpoints(ctx, vm.Name)
+	if err != nil {
+		return nil, fmt.Errorf("listing checkpoints: %w", err)
+	}
+	if !slices.Contains(checkpoints, parent.Checkpoint) {
+		m.logger.Info("Checkpoint of the last backup is gone, taking a full backup",
+			logger.String("vm", vm.Name),
+			logger.String("checkpoint", parent.Checkpoint))
+		return nil, nil
+	}
+
+	return parent, nil
+}
+
+// deleteOldCheckpoints removes the checkpoints of earlier backups of a VM.
+// Only the checkpoint of the newest backup is needed for the next one.
+func (m *BackupManager) deleteOldCheckpoints(ctx context.Context, vmName string, keep string) {
+	checkpoints, err := m.domainManager.ListCheckpoints(ctx, vmName)
+	if err != nil {
+		m.logger.Warn("Failed to list checkpoints",
+			logger.String("vm", vmName),
+			logger.Error(err))
+		return
+	}
+
+	for _, checkpoint := range checkpoints {
+		if checkpoint != keep && strings.HasPrefix(checkpoint, CheckpointPrefix) {
+			m.deleteCheckpoint(ctx, vmName, checkpoint)
+		}
+	}
+}
+
+// deleteCheckpoint removes a checkpoint, logging failures.
+func (m *BackupManager) deleteCheckpoint(ctx context.Context, vmName string, checkpoint string) {
+	if err := m.domainManager.DeleteCheckpoint(context.WithoutCancel(ctx), vmName, checkpoint); err != nil {
+		m.logger.Warn("Failed to delete checkpoint",
+			logger.String("vm", vmName),
+			logger.String("checkpoint", checkpoint),
+			logger.Error(err))
+	}
+}
+
+// processRestoreJob runs a restore in the background.
+func (m *BackupManager) processRestoreJob(ctx context.Context, cancel context.CancelFunc, jobID string, point *RestorePoint, params RestoreParams) {
+	defer cancel()
+
+	m.jobStore.updateJobStatus(jobID, StatusRunning, nil)
+
+	m.logger.Info("Starting restore job",
+		logger.String("job_id", jobID),
+		logger.String("restore_point", point.ID),
+		logger.String("name", params.Name))
+
+	err := m.runRestore(ctx, jobID, point, params)
+
+	switch {
+	case err == nil:
+		m.jobStore.updateJobStatus(jobID, StatusCompleted, nil)
+		m.logger.Info("Restore job completed",
+			logger.String("job_id", jobID),
+			logger.String("name", params.Name))
+	case ctx.Err() == context.Canceled:
+		m.jobStore.updateJobStatus(jobID, StatusCanceled, nil)
+	default:
+		m.jobStore.updateJobStatus(jobID, StatusFailed, err)
+		m.logger.Error("Restore job failed",
+			logger.String("job_id", jobID),
+			logger.String("name", params.Name),
+			logger.Error(err))
+	}
+}
+
+// runRestore writes the disks of a restore point to new volumes and defines
+// a VM using them.
+func (m *BackupManager) runRestore(ctx context.Context, jobID string, point *RestorePoint, params RestoreParams) error {
+	unlock := m.repo.Lock()
+	defer unlock()
+
+	definition, err := m.repo.Definition(point.ID)
+	if err != nil {
+		return err
+	}
+
+	workDir := filepath.Join(m.config.ScratchDir, jobID)
+	if err := os.MkdirAll(workDir, 0o750); err != nil {
+		return fmt.Errorf("creating scratch directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	spec := domain.CloneSpec{
+		Name:  params.Name,
+		UUID:  uuid.New().String(),
+		Disks: make(map[string]domain.CloneDisk, len(point.Disks)),
+	}
+
+	var created []string
+	defer func() {
+		// Volumes of a restore that did not define a VM are not used
+		for _, volName := range created {
+			if err := m.volumeManager.Delete(context.WithoutCancel(ctx), params.Pool, volName); err != nil {
+				m.logger.Warn("Failed to delete restored volume",
+					logger.String("volume", volName),
+					logger.Error(err))
+			}
+		}
+	}()
+
+	for i, disk := range point.Disks {
+		volName := vmmodels.GenerateVolumeName(params.Name, i)
+		path, err := m.restoreDisk(ctx, workDir, point.ID, disk, params.Pool, volName)
+		if err != nil {
+			return fmt.Errorf("restoring disk %s: %w", disk.Device, err)
+		}
+		created = append(created, volName)
+
+		spec.Disks[disk.Source] = domain.CloneDisk{Source: vmmodels.DiskSource{Type: vmmodels.DiskTypeFile, Path: path}, Format: "qcow2"}
+		m.jobStore.updateJobProgress(jobID, (i+1)*100/len(point.Disks))
+	}
+
+	if _, err := m.domainManager.DefineFromDefinition(ctx, definition, spec); err != nil {
+		return fmt.Errorf("defining restored VM: %w", err)
+	}
+	created = nil
+
+	if params.Start {
+		if err := m.domainManager.Start(ctx, params.Name); err != nil {
+			return fmt.Errorf("starting restored VM: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// restoreDisk writes a disk of a restore point to a new qcow2 volume and
+// returns its path. The volume is deleted again when writing fails.
+func (m *BackupManager) restoreDisk(ctx context.Context, workDir string, pointID string, disk Disk, pool string, volName string) (path string, err error) {
+	refs, err := m.repo.Chunks(pointID, disk.Device)
+	if err != nil {
+		return "", err
+	}
+
+	rawPath := filepath.Join(workDir, disk.Device+".raw")
+	if err := assembleImage(ctx, m.repo, refs, int64(disk.Size), rawPath); err != nil { //nolint:gosec
+		return "", err
+	}
+	defer os.Remove(rawPath)
+
+	if err := m.volumeManager.Create(ctx, pool, volName, disk.Size, "qcow2"); err != nil {
+		return "", fmt.Errorf("creating volume %s: %w", volName, err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if deleteErr := m.volumeManager.Delete(context.WithoutCancel(ctx), pool, volName); deleteErr != nil {
+			m.logger.Warn("Failed to delete restored volume",
+				logger.String("volume", volName),
+				logger.Error(deleteErr))
+		}
+	}()
+
+	path, err = m.volumeManager.GetPath(ctx, pool, volName)
+	if err != nil {
+		return "", fmt.Errorf("getting volume path: %w", err)
+	}
+
+	if _, err = exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"convert", "-n", "-f", "raw", "-O", "qcow2", rawPath, path,
+	}, exec.CommandOptions{}); err != nil {
+		return "", fmt.Errorf("writing volume %s: %w", volName, err)
+	}
+
+	return path, nil
+}
+
+// deletePoint removes a restore point, dropping it from the browse cache.
+func (m *BackupManager) deletePoint(id string) error {
+	m.browseMu.Lock()
+	if m.browsed == id {
+		m.clearBrowseCache()
+	}
+	m.browseMu.Unlock()
+
+	return m.repo.DeletePoint(id)
+}
+
+// backupDisks returns the disks of a VM that backups copy. Read-only and
+// shared disks are skipped.
+func backupDisks(vm *vmmodels.VM) []vmmodels.DiskInfo {
+	disks := make([]vmmodels.DiskInfo, 0, len(vm.Disks))
+	for _, disk := range vm.Disks {
+		if disk.Device == "" || disk.Path == "" || disk.ReadOnly || disk.Shareable {
+			continue
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// allQCOW2 reports whether all disks are qcow2 images.
+func allQCOW2(disks []vmmodels.DiskInfo) bool {
+	for _, disk := range disks {
+		if disk.Format != vmmodels.DiskFormatQCOW2 {
+			return false
+		}
+	}
+	return len(disks) > 0
+}
+
+// sameDisks reports whether a restore point holds the same disks as a VM
+// has now.
+func sameDisks(backedUp []Disk, disks []vmmodels.DiskInfo) bool {
+	if len(backedUp) != len(disks) {
+		return false
+	}
+
+	for i, disk := range disks {
+		if backedUp[i].Device != disk.Device || backedUp[i].Source != disk.Path {
+			return false
+		}
+	}
+	return true
+}
+
+// expiredPoints returns the restore points the retention no longer keeps. A
+// restore point is kept when any rule keeps it, and the newest restore point
+// of each VM is always kept.
+func expiredPoints(points []*RestorePoint, params PruneParams, now time.Time) []*RestorePoint {
+	byVM := make(map[string][]*RestorePoint)
+	for _, point := range points {
+		if params.VMName == "" || point.VMName == params.VMName {
+			byVM[point.VMName] = append(byVM[point.VMName], point)
+		}
+	}
+
+	cutoff := now.AddDate(0, 0, -params.KeepDays)
+
+	var expired []*RestorePoint
+	for _, vmPoints := range byVM {
+		slices.SortFunc(vmPoints, func(a, b *RestorePoint) int {
+			return b.CreatedAt.Compare(a.CreatedAt)
+		})
+
+		for i, point := range vmPoints {
+			keepLast := i == 0 || i < params.KeepLast
+			keepDays := params.KeepDays > 0 && point.CreatedAt.After(cutoff)
+			if !keepLast && !keepDays {
+				expired = append(expired, point)
+			}
+		}
+	}
+
+	slices.SortFunc(expired, func(a, b *RestorePoint) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return expired
+}
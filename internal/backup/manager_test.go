@@ -0,0 +1,305 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+const testDiskPath = "/var/lib/libvirt/images/web-disk-0.qcow2"
+
+// backupTestEnv holds the mocks used by the backup manager tests.
+type backupTestEnv struct {
+	manager *BackupManager
+	domain  *mocks_domain.MockManager
+	volumes *mocks_storage.MockVolumeManager
+	// disk is the content of the disk of the test VM
+	disk []byte
+	// written lists the ranges of the disk changed since the last backup
+	written     [][2]int64
+	checkpoints []string
+}
+
+func newBackupTestEnv(t *testing.T) *backupTestEnv {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := testutil.NewLogger(ctrl)
+
+	repo, err := NewRepository(t.TempDir())
+	require.NoError(t, err)
+
+	env := &backupTestEnv{
+		domain:  mocks_domain.NewMockManager(ctrl),
+		volumes: mocks_storage.NewMockVolumeManager(ctrl),
+		disk:    bytes.Repeat([]byte{7}, ChunkSize*2),
+	}
+	env.manager = NewBackupManager(repo, env.domain, env.volumes, nil, Config{
+		ScratchDir:   t.TempDir(),
+		DefaultPool:  "default",
+		PollInterval: time.Millisecond,
+	}, mockLogger)
+
+	env.domain.EXPECT().Get(gomock.Any(), "web").Return(&vm.VM{
+		Name:   "web",
+		UUID:   "0b7c1c4e-7f0b-4c36-9a5e-1f7f7e4a6b10",
+		Status: vm.VMStatusRunning,
+		Disks: []vm.DiskInfo{
+			{Device: "vda", Path: testDiskPath, Format: vm.DiskFormatQCOW2},
+			{Device: "hdc", Path: "/isos/install.iso", Format: vm.DiskFormatRAW, ReadOnly: true},
+		},
+	}, nil).AnyTimes()
+
+	// The hypervisor writes the whole disk to raw targets and the changed
+	// ranges to qcow2 targets
+	env.domain.EXPECT().BeginBackup(gomock.Any(), "web", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, params vm.BackupParams) (string, error) {
+			require.Len(t, params.Disks, 1)
+			if params.Incremental != "" && !slices.Contains(env.checkpoints, params.Incremental) {
+				return "", fmt.Errorf("checkpoint %s not found", params.Incremental)
+			}
+			if err := os.WriteFile(params.Disks[0].TargetPath, env.disk, 0o600); err != nil {
+				return "", err
+			}
+			env.checkpoints = append(env.checkpoints, params.Checkpoint)
+			return "<domain><name>web</name></domain>", nil
+		}).AnyTimes()
+	env.domain.EXPECT().GetBackupJob(gomock.Any(), "web").Return(&vm.BackupJobInfo{}, nil).AnyTimes()
+	env.domain.EXPECT().ListCheckpoints(gomock.Any(), "web").DoAndReturn(
+		func(context.Context, string) ([]string, error) {
+			return slices.Clone(env.checkpoints), nil
+		}).AnyTimes()
+	env.domain.EXPECT().DeleteCheckpoint(gomock.Any(), "web", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, checkpoint string) error {
+			env.checkpoints = slices.DeleteFunc(env.checkpoints, func(c string) bool { return c == checkpoint })
+			return nil
+		}).AnyTimes()
+
+	testutil.StubCommands(t, env.executeCommand)
+
+	return env
+}
+
+// executeCommand stands in for qemu-img.
+func (env *backupTestEnv) executeCommand(_ context.Context, name string, args []string, _ exec.CommandOptions) ([]byte, error) {
+	if name != "qemu-img" {
+		return nil, fmt.Errorf("unexpected command %s", name)
+	}
+
+	switch args[0] {
+	case "map":
+		size := int64(len(env.disk))
+		if strings.HasSuffix(args[len(args)-1], ".raw") {
+			return []byte(fmt.Sprintf(`[{"start": 0, "length": %d, "depth": 0, "present": true, "zero": false, "data": true}]`, size)), nil
+		}
+
+		var entries []string
+		var end int64
+		for _, r := range env.written {
+			entries = append(entries, fmt.Sprintf(`{"start": %d, "length": %d, "depth": 0, "present": true, "zero": false, "data": true}`, r[0], r[1]))
+			end = r[0] + r[1]
+		}
+		entries = append(entries, fmt.Sprintf(`{"start": %d, "length": %d, "depth": 0, "present": false, "zero": true, "data": false}`, end, size-end))
+		return []byte("[" + strings.Join(entries, ",") + "]"), nil
+	case "convert":
+		data, err := os.ReadFile(args[len(args)-2])
+		if err != nil {
+			return nil, err
+		}
+		return nil, os.WriteFile(args[len(args)-1], data, 0o600)
+	}
+
+	return nil, fmt.Errorf("unexpected qemu-img command %s", args[0])
+}
+
+// write changes the disk of the test VM.
+func (env *backupTestEnv) write(offset int64, data []byte) {
+	copy(env.disk[offset:], data)
+	env.written = append(env.written, [2]int64{offset, int64(len(data))})
+}
+
+// waitForJob waits until a job finishes and returns it.
+func (env *backupTestEnv) waitForJob(t *testing.T, jobID string) *Job {
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = env.manager.GetJob(context.Background(), jobID)
+		require.NoError(t, err)
+		return job.Status.isFinal()
+	}, 5*time.Second, time.Millisecond)
+	return job
+}
+
+func TestBackupManager_FullAndIncrementalBackups(t *testing.T) {
+	env := newBackupTestEnv(t)
+	ctx := context.Background()
+
+	job, err := env.manager.StartBackup(ctx, "web", Params{})
+	require.NoError(t, err)
+	job = env.waitForJob(t, job.ID)
+	require.Equal(t, StatusCompleted, job.Status, job.Error)
+	assert.Equal(t, TypeFull, job.Type)
+
+	full, err := env.manager.GetRestorePoint(ctx, job.RestorePoint)
+	require.NoError(t, err)
+	require.Len(t, full.Disks, 1)
+	assert.Equal(t, Disk{Device: "vda", Source: testDiskPath, Format: "qcow2", Size: ChunkSize * 2, ChangedBytes: ChunkSize * 2}, full.Disks[0])
+	// Both chunks hold the same content and are stored once
+	assert.Equal(t, uint64(ChunkSize), full.StoredBytes)
+	assert.Equal(t, []string{full.Checkpoint}, env.checkpoints)
+
+	env.write(ChunkSize+512, []byte("changed after the full backup"))
+
+	job, err = env.manager.StartBackup(ctx, "web", Params{})
+	require.NoError(t, err)
+	job = env.waitForJob(t, job.ID)
+	require.Equal(t, StatusCompleted, job.Status, job.Error)
+
+	incremental, err := env.manager.GetRestorePoint(ctx, job.RestorePoint)
+	require.NoError(t, err)
+	assert.Equal(t, TypeIncremental, incremental.Type)
+	assert.Equal(t, full.ID, incremental.Parent)
+	assert.Equal(t, uint64(len("changed after the full backup")), incremental.Disks[0].ChangedBytes)
+	assert.Equal(t, uint64(ChunkSize), incremental.StoredBytes)
+	// Only the checkpoint of the newest backup is kept
+	assert.Equal(t, []string{incremental.Checkpoint}, env.checkpoints)
+
+	refs, err := env.manager.repo.Chunks(incremental.ID, "vda")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "vda.raw")
+	require.NoError(t, assembleImage(ctx, env.manager.repo, refs, ChunkSize*2, path))
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(env.disk, restored))
+
+	points, err := env.manager.ListRestorePoints(ctx, "web")
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+
+	// A forced full backup ignores the checkpoint and prunes the others
+	job, err = env.manager.StartBackup(ctx, "web", Params{Full: true, KeepLast: 1})
+	require.NoError(t, err)
+	job = env.waitForJob(t, job.ID)
+	require.Equal(t, StatusCompleted, job.Status, job.Error)
+	assert.Equal(t, TypeFull, job.Type)
+
+	points, err = env.manager.ListRestorePoints(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, job.RestorePoint, points[0].ID)
+}
+
+func TestBackupManager_StartBackupRejectsStoppedVM(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	domainManager := mocks_domain.NewMockManager(ctrl)
+	domainManager.EXPECT().Get(gomock.Any(), "db").Return(&vm.VM{Name: "db", Status: vm.VMStatusStopped}, nil)
+
+	repo, err := NewRepository(t.TempDir())
+	require.NoError(t, err)
+	manager := NewBackupManager(repo, domainManager, nil, nil, Config{}, nil)
+
+	_, err = manager.StartBackup(context.Background(), "db", Params{})
+	assert.ErrorIs(t, err, domain.ErrDomainNotRunning)
+
+	_, err = manager.StartBackup(context.Background(), "db", Params{KeepLast: -1})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+}
+
+func TestBackupManager_RemoteHost(t *testing.T) {
+	repo, err := NewRepository(t.TempDir())
+	require.NoError(t, err)
+	manager := NewBackupManager(repo, nil, nil, testutil.RemoteHost{}, Config{}, nil)
+
+	_, err = manager.StartBackup(context.Background(), "db", Params{})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	_, err = manager.Restore(context.Background(), "point", RestoreParams{Name: "db"})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	jobs, err := manager.ListJobs(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestBackupManager_Restore(t *testing.T) {
+	env := newBackupTestEnv(t)
+	ctx := context.Background()
+
+	job, err := env.manager.StartBackup(ctx, "web", Params{})
+	require.NoError(t, err)
+	job = env.waitForJob(t, job.ID)
+	require.Equal(t, StatusCompleted, job.Status, job.Error)
+
+	volumePath := filepath.Join(t.TempDir(), "web-restored-disk-0")
+	env.domain.EXPECT().Get(gomock.Any(), "web-restored").Return(nil, domain.ErrDomainNotFound)
+	env.volumes.EXPECT().Create(gomock.Any(), "fast", "web-restored-disk-0", uint64(ChunkSize*2), "qcow2").Return(nil)
+	env.volumes.EXPECT().GetPath(gomock.Any(), "fast", "web-restored-disk-0").Return(volumePath, nil)
+	env.domain.EXPECT().DefineFromDefinition(gomock.Any(), "<domain><name>web</name></domain>", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, spec domain.CloneSpec) (*vm.VM, error) {
+			assert.Equal(t, "web-restored", spec.Name)
+			assert.NotEmpty(t, spec.UUID)
+			assert.Equal(t, map[string]domain.CloneDisk{
+				testDiskPath: {Source: vm.DiskSource{Type: vm.DiskTypeFile, Path: volumePath}, Format: "qcow2"},
+			}, spec.Disks)
+			return &vm.VM{Name: spec.Name}, nil
+		})
+
+	restoreJob, err := env.manager.Restore(ctx, job.RestorePoint, RestoreParams{Name: "web-restored", Pool: "fast"})
+	require.NoError(t, err)
+	restoreJob = env.waitForJob(t, restoreJob.ID)
+	require.Equal(t, StatusCompleted, restoreJob.Status, restoreJob.Error)
+	assert.Equal(t, OperationRestore, restoreJob.Operation)
+
+	restored, err := os.ReadFile(volumePath)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(env.disk, restored))
+
+	_, err = env.manager.Restore(ctx, "missing", RestoreParams{Name: "other"})
+	assert.ErrorIs(t, err, errors.ErrBackupNotFound)
+}
+
+func TestExpiredPoints(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	point := func(id string, vmName string, age time.Duration) *RestorePoint {
+		return &RestorePoint{ID: id, VMName: vmName, CreatedAt: now.Add(-age)}
+	}
+
+	points := []*RestorePoint{
+		point("web-1", "web", 96*time.Hour),
+		point("web-2", "web", 48*time.Hour),
+		point("web-3", "web", time.Hour),
+		point("db-1", "db", 240*time.Hour),
+	}
+
+	ids := func(points []*RestorePoint) []string {
+		var result []string
+		for _, p := range points {
+			result = append(result, p.ID)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"web-1"}, ids(expiredPoints(points, PruneParams{KeepLast: 2}, now)))
+	assert.Equal(t, []string{"web-1", "web-2"}, ids(expiredPoints(points, PruneParams{VMName: "web", KeepDays: 1}, now)))
+	// The newest restore point of a VM is kept whatever its age
+	assert.Equal(t, []string{"web-1"}, ids(expiredPoints(points, PruneParams{KeepLast: 1, KeepDays: 3}, now)))
+	assert.Nil(t, expiredPoints(points, PruneParams{VMName: "db", KeepDays: 1}, now))
+}
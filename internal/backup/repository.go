@@ -0,0 +1,353 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/threatflux/libgo/internal/errors"
+)
+
+// ChunkSize is the size of the blocks disks are split into. Chunks are
+// stored once per distinct content.
+const ChunkSize = 4 << 20
+
+// ChunkRef maps a chunk of a disk to its content in the repository. Chunks
+// holding only zeros are not stored.
+type ChunkRef struct {
+	Hash  string `json:"hash"`
+	Index int64  `json:"index"`
+}
+
+// manifest is the stored form of a restore point.
+type manifest struct {
+	Point RestorePoint `json:"point"`
+	// Definition is the persistent domain XML of the VM
+	Definition string `json:"definition"`
+}
+
+// Repository stores restore points in a directory. Disk contents are split
+// into chunks addressed by their SHA-256 hash:
+//
+//	<root>/chunks/ab/ab12...    chunk content
+//	<root>/points/<id>/         restore point manifest and chunk lists
+//
+// Writers hold a read lock on the repository so that garbage collection,
+// which holds the write lock, never removes chunks of an unsaved restore
+// point.
+type Repository struct {
+	root string
+	mu   sync.RWMutex
+}
+
+// NewRepository opens the repository in a directory, creating it if needed.
+func NewRepository(root string) (*Repository, error) {
+	for _, dir := range []string{"chunks", "points"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o750); err != nil {
+			return nil, fmt.Errorf("creating backup repository: %w", err)
+		}
+	}
+
+	return &Repository{root: root}, nil
+}
+
+// Root returns the directory of the repository.
+func (r *Repository) Root() string {
+	return r.root
+}
+
+// Lock prevents garbage collection until the returned function is called.
+func (r *Repository) Lock() func() {
+	r.mu.RLock()
+	return r.mu.RUnlock
+}
+
+// PutChunk stores a chunk and returns its hash and the number of bytes
+// added to the repository, which is zero when the content was already stored.
+func (r *Repository) PutChunk(data []byte) (string, int, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := r.chunkPath(hash)
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", 0, fmt.Errorf("creating chunk directory: %w", err)
+	}
+
+	// Concurrent writers of the same chunk each rename a complete file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return "", 0, fmt.Errorf("creating chunk: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("writing chunk: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("writing chunk: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("storing chunk: %w", err)
+	}
+
+	return hash, len(data), nil
+}
+
+// GetChunk reads a chunk and verifies its content.
+func (r *Repository) GetChunk(hash string) ([]byte, error) {
+	if !isHash(hash) {
+		return nil, fmt.Errorf("invalid chunk hash %q", hash)
+	}
+
+	data, err := os.ReadFile(r.chunkPath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("reading chunk %s: %w", hash, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("chunk %s is corrupted", hash)
+	}
+
+	return data, nil
+}
+
+// SavePoint stores a restore point with the domain definition and the
+// chunk lists of its disks, keyed by device.
+func (r *Repository) SavePoint(point *RestorePoint, definition string, chunks map[string][]ChunkRef) error {
+	if !isPointID(point.ID) {
+		return fmt.Errorf("invalid restore point ID %q", point.ID)
+	}
+
+	// Restore points appear complete or not at all
+	tmpDir, err := os.MkdirTemp(filepath.Join(r.root, "points"), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("creating restore point: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := writeJSON(filepath.Join(tmpDir, "manifest.json"), manifest{Point: *point, Definition: definition}); err != nil {
+		return err
+	}
+
+	for _, disk := range point.Disks {
+		if err := writeJSON(filepath.Join(tmpDir, disk.Device+".chunks.json"), chunks[disk.Device]); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpDir, r.pointDir(point.ID)); err != nil {
+		return fmt.Errorf("storing restore point: %w", err)
+	}
+
+	return nil
+}
+
+// GetPoint reads a restore point.
+func (r *Repository) GetPoint(id string) (*RestorePoint, error) {
+	m, err := r.readManifest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m.Point, nil
+}
+
+// Definition reads the domain definition stored with a restore point.
+func (r *Repository) Definition(id string) (string, error) {
+	m, err := r.readManifest(id)
+	if err != nil {
+		return "", err
+	}
+
+	return m.Definition, nil
+}
+
+// Chunks reads the chunk list of a disk of a restore point.
+func (r *Repository) Chunks(id string, device string) ([]ChunkRef, error) {
+	if !isPointID(id) || strings.ContainsAny(device, `/\`) {
+		return nil, fmt.Errorf("%w: restore point %s disk %s", errors.ErrNotFound, id, device)
+	}
+
+	var refs []ChunkRef
+	if err := readJSON(filepath.Join(r.pointDir(id), device+".chunks.json"), &refs); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: restore point %s disk %s", errors.ErrNotFound, id, device)
+		}
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// ListPoints lists all restore points, oldest first.
+func (r *Repository) ListPoints() ([]*RestorePoint, error) {
+	entries, err := os.ReadDir(filepath.Join(r.root, "points"))
+	if err != nil {
+		return nil, fmt.Errorf("listing restore points: %w", err)
+	}
+
+	points := make([]*RestorePoint, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || !isPointID(entry.Name()) {
+			continue
+		}
+
+		point, err := r.GetPoint(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].CreatedAt.Before(points[j].CreatedAt)
+	})
+
+	return points, nil
+}
+
+// DeletePoint removes a restore point. Its chunks stay in the repository
+// until the next garbage collection.
+func (r *Repository) DeletePoint(id string) error {
+	if _, err := r.readManifest(id); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(r.pointDir(id)); err != nil {
+		return fmt.Errorf("deleting restore point %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// CollectGarbage removes the chunks no restore point references and
+// returns the number of bytes freed.
+func (r *Repository) CollectGarbage() (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	points, err := r.ListPoints()
+	if err != nil {
+		return 0, err
+	}
+
+	used := make(map[string]bool)
+	for _, point := range points {
+		for _, disk := range point.Disks {
+			refs, err := r.Chunks(point.ID, disk.Device)
+			if err != nil {
+				return 0, err
+			}
+			for _, ref := range refs {
+				used[ref.Hash] = true
+			}
+		}
+	}
+
+	var freed uint64
+	err = filepath.WalkDir(filepath.Join(r.root, "chunks"), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || used[entry.Name()] {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		freed += uint64(info.Size()) //nolint:gosec
+		return nil
+	})
+	if err != nil {
+		return freed, fmt.Errorf("collecting unused chunks: %w", err)
+	}
+
+	return freed, nil
+}
+
+// readManifest reads the manifest of a restore point.
+func (r *Repository) readManifest(id string) (*manifest, error) {
+	if !isPointID(id) {
+		return nil, fmt.Errorf("%w: %s", errors.ErrBackupNotFound, id)
+	}
+
+	var m manifest
+	if err := readJSON(filepath.Join(r.pointDir(id), "manifest.json"), &m); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", errors.ErrBackupNotFound, id)
+		}
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// chunkPath returns the path of a chunk, spread over directories named
+// after the first byte of the hash.
+func (r *Repository) chunkPath(hash string) string {
+	return filepath.Join(r.root, "chunks", hash[:2], hash)
+}
+
+// pointDir returns the directory of a restore point.
+func (r *Repository) pointDir(id string) string {
+	return filepath.Join(r.root, "points", id)
+}
+
+// isHash reports whether s is a hex-encoded SHA-256 hash.
+func isHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// isPointID reports whether s can name a restore point directory.
+func isPointID(s string) bool {
+	return s != "" && !strings.HasPrefix(s, ".") && !strings.ContainsAny(s, `/\`)
+}
+
+// writeJSON writes a value to a JSON file.
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+// readJSON reads a JSON file into a value.
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threatflux/libgo/internal/errors"
+)
+
+func TestRepository_Chunks(t *testing.T) {
+	repo, err := NewRepository(t.TempDir())
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("a"), 1024)
+
+	hash, stored, err := repo.PutChunk(data)
+	require.NoError(t, err)
+	assert.Equal(t, 1024, stored)
+
+	// The same content is stored once
+	again, stored, err := repo.PutChunk(data)
+	require.NoError(t, err)
+	assert.Equal(t, hash, again)
+	assert.Equal(t, 0, stored)
+
+	read, err := repo.GetChunk(hash)
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+
+	require.NoError(t, os.WriteFile(repo.chunkPath(hash), []byte("corrupted"), 0o600))
+	_, err = repo.GetChunk(hash)
+	assert.ErrorContains(t, err, "corrupted")
+
+	_, err = repo.GetChunk("../../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestRepository_Points(t *testing.T) {
+	repo, err := NewRepository(t.TempDir())
+	require.NoError(t, err)
+
+	shared, _, err := repo.PutChunk([]byte("shared"))
+	require.NoError(t, err)
+	onlyOld, _, err := repo.PutChunk([]byte("only in the old restore point"))
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	older := &RestorePoint{ID: "older", VMName: "web", CreatedAt: now.Add(-time.Hour), Disks: []Disk{{Device: "vda"}}}
+	newer := &RestorePoint{ID: "newer", VMName: "web", CreatedAt: now, Disks: []Disk{{Device: "vda"}}}
+
+	require.NoError(t, repo.SavePoint(newer, "<domain/>", map[string][]ChunkRef{
+		"vda": {{Index: 0, Hash: shared}},
+	}))
+	require.NoError(t, repo.SavePoint(older, "<domain/>", map[string][]ChunkRef{
+		"vda": {{Index: 0, Hash: shared}, {Index: 3, Hash: onlyOld}},
+	}))
+
+	points, err := repo.ListPoints()
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, "older", points[0].ID)
+	assert.Equal(t, "newer", points[1].ID)
+
+	definition, err := repo.Definition("newer")
+	require.NoError(t, err)
+	assert.Equal(t, "<domain/>", definition)
+
+	refs, err := repo.Chunks("older", "vda")
+	require.NoError(t, err)
+	assert.Equal(t, []ChunkRef{{Index: 0, Hash: shared}, {Index: 3, Hash: onlyOld}}, refs)
+
+	_, err = repo.GetPoint("missing")
+	assert.ErrorIs(t, err, errors.ErrBackupNotFound)
+	_, err = repo.GetPoint("../older")
+	assert.ErrorIs(t, err, errors.ErrBackupNotFound)
+
+	require.NoError(t, repo.DeletePoint("older"))
+	freed, err := repo.CollectGarbage()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(len("only in the old restore point")), freed)
+
+	_, err = repo.GetChunk(shared)
+	assert.NoError(t, err)
+	_, err = os.Stat(repo.chunkPath(onlyOld))
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := os.ReadDir(filepath.Join(repo.Root(), "points"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -74,6 +74,7 @@ type Manager interface {
 	// Multi-backend operations
 	ListAllInstances(ctx context.Context, opts ComputeInstanceListOptions) ([]*ComputeInstance, error)
 	GetClusterStatus(ctx context.Context) (*ClusterStatus, error)
+	PlanPlacement(ctx context.Context, req ComputeInstanceRequest) (*PlacementDecision, error)
 	GetResourceQuotas(ctx context.Context, userID uint) (*ResourceQuotas, error)
 	SetResourceQuotas(ctx context.Context, userID uint, quotas ResourceQuotas) error
 
@@ -91,6 +92,9 @@ type Manager interface {
 	UpdateComposeDeployment(ctx context.Context, deploymentID string, composeData []byte) (*ComposeDeployment, error)
 	DeleteComposeDeployment(ctx context.Context, deploymentID string, force bool) error
 
+	// Events raised by other services
+	RecordInstanceEvent(ctx context.Context, event InstanceEvent)
+
 	// Health and maintenance
 	HealthCheck(ctx context.Context) (*HealthStatus, error)
 	PerformMaintenance(ctx context.Context, opts MaintenanceOptions) error
@@ -125,6 +129,33 @@ type BackendService interface {
 	GetSupportedInstanceTypes() []ComputeInstanceType
 }
 
+// ConsoleBackend is implemented by backends that support interactive consoles.
+type ConsoleBackend interface {
+	// AttachConsole attaches to the console of an instance
+	AttachConsole(ctx context.Context, id string, opts ConsoleOptions) (io.ReadWriteCloser, error)
+}
+
+// MigrationBackend is implemented by backends that can move instances
+// between hosts.
+type MigrationBackend interface {
+	// Migrate starts migrating an instance to the target host
+	Migrate(ctx context.Context, id, targetHost string, opts MigrationOptions) error
+}
+
+// HostCapacityBackend is implemented by backends that run instances on
+// several hosts.
+type HostCapacityBackend interface {
+	// GetHostCapacity reports the capacity and health of each host
+	GetHostCapacity(ctx context.Context) ([]HostCapacity, error)
+}
+
+// ImportBackend is implemented by backends that can create instances from
+// exported appliances and disk images.
+type ImportBackend interface {
+	// Import creates an instance from the file at source
+	Import(ctx context.Context, source string, opts ImportOptions) (*ComputeInstance, error)
+}
+
 // Supporting types for the service interface
 
 // ConsoleOptions represents options for console attachment.
@@ -377,6 +408,8 @@ type ClusterStatus struct {
 	LastUpdated time.Time `json:"last_updated"`
 	// Map fields (24 bytes)
 	Backends map[ComputeBackend]*BackendInfo `json:"backends"`
+	// Slice fields (24 bytes)
+	Hosts []HostCapacity `json:"hosts,omitempty"`
 	// Pointer fields (8 bytes)
 	Health *HealthStatus `json:"health"`
 	// Duration fields (8 bytes)
@@ -388,6 +421,27 @@ type ClusterStatus struct {
 	ErrorInstances   int `json:"error_instances"`
 }
 
+// HostCapacity represents the capacity of a single host of a backend.
+type HostCapacity struct {
+	// Time fields (24 bytes)
+	LastCheck time.Time `json:"last_check,omitempty"`
+	// Slice fields (24 bytes)
+	Capabilities []string `json:"capabilities,omitempty"`
+	// String fields (16 bytes each)
+	Name      string         `json:"name"`
+	Address   string         `json:"address"`
+	State     string         `json:"state"`
+	LastError string         `json:"last_error,omitempty"`
+	Backend   ComputeBackend `json:"backend"`
+	// Uint64 fields (8 bytes each)
+	MemoryTotal uint64 `json:"memory_total"` // bytes
+	MemoryFree  uint64 `json:"memory_free"`  // bytes
+	// Int fields (8 bytes each)
+	CPUs             int `json:"cpus"`
+	RunningInstances int `json:"running_instances"`
+	TotalInstances   int `json:"total_instances"`
+}
+
 // ResourceQuotas represents resource quotas for a user.
 type ResourceQuotas struct {
 	// Time fields (24 bytes each, must be first for alignment)
@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	apierrors "github.com/threatflux/libgo/internal/errors"
 	"github.com/threatflux/libgo/pkg/logger"
 )
 
@@ -19,12 +21,62 @@ type ComputeManager struct {
 	backends map[ComputeBackend]BackendService
 	// Pointer fields (8 bytes)
 	resourceTracker *ResourceTracker
+	scheduler       *scheduler
 	quotaManager    *QuotaManager
 	eventBus        *EventBus
 	logger          logger.Logger
 	// Struct fields
-	config ManagerConfig
-	mu     sync.RWMutex
+	config    ManagerConfig
+	placement placementCache
+	mu        sync.RWMutex
+}
+
+// placementCacheTTL is how long probed host capacity is reused for placing
+// instances. Allocations are tracked separately, so only host totals and
+// health go stale.
+const placementCacheTTL = 15 * time.Second
+
+// placementCache holds the most recently probed placement targets.
+type placementCache struct {
+	probed  time.Time
+	targets []placementTarget
+	// generation changes when backends are registered, so probes that
+	// started before are not cached
+	generation int
+	mu         sync.Mutex
+}
+
+// get returns the cached targets if they are fresh, and the generation a
+// new probe must be stored with.
+func (c *placementCache) get(now time.Time) ([]placementTarget, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.targets != nil && now.Sub(c.probed) < placementCacheTTL {
+		return c.targets, c.generation, true
+	}
+	return nil, c.generation, false
+}
+
+// set caches probed targets unless backends changed during the probe.
+func (c *placementCache) set(targets []placementTarget, generation int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	c.targets = targets
+	c.probed = now
+}
+
+// invalidate drops the cached targets.
+func (c *placementCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.targets = nil
+	c.generation++
 }
 
 // ManagerConfig holds configuration for the compute manager.
@@ -35,6 +87,9 @@ type ManagerConfig struct {
 	// Duration fields (8 bytes each) - group together
 	HealthCheckInterval time.Duration
 	MetricsInterval     time.Duration
+	// Float fields (8 bytes each) - ratios of allocatable to physical host capacity
+	CPUOvercommitRatio    float64
+	MemoryOvercommitRatio float64
 	// Enum fields (4 bytes)
 	DefaultBackend ComputeBackend
 	// Bool fields (1 byte each) - group together
@@ -44,11 +99,13 @@ type ManagerConfig struct {
 
 // NewComputeManager creates a new unified compute manager.
 func NewComputeManager(config ManagerConfig, logger logger.Logger) Manager {
+	resourceTracker := NewResourceTracker()
 	manager := &ComputeManager{
 		backends:        make(map[ComputeBackend]BackendService),
 		config:          config,
 		logger:          logger,
-		resourceTracker: NewResourceTracker(),
+		resourceTracker: resourceTracker,
+		scheduler:       newScheduler(resourceTracker, config.CPUOvercommitRatio, config.MemoryOvercommitRatio),
 		quotaManager:    NewQuotaManager(),
 		eventBus:        NewEventBus(),
 	}
@@ -66,6 +123,7 @@ func (m *ComputeManager) RegisterBackend(backend ComputeBackend, service Backend
 	}
 
 	m.backends[backend] = service
+	m.placement.invalidate()
 	m.logger.Info("Registered compute backend", logger.String("backend", string(backend)))
 
 	return nil
@@ -73,13 +131,31 @@ func (m *ComputeManager) RegisterBackend(backend ComputeBackend, service Backend
 
 // CreateInstance creates a new compute instance.
 func (m *ComputeManager) CreateInstance(ctx context.Context, req ComputeInstanceRequest) (*ComputeInstance, error) {
-	// Determine backend
-	backend := req.Backend
-	if backend == "" {
-		backend = m.config.DefaultBackend
+	// Validate a requested backend exists
+	if req.Backend != "" {
+		if _, err := m.getBackend(req.Backend); err != nil {
+			return nil, err
+		}
+	}
+
+	// Choose the backend and host
+	decision, err := m.PlanPlacement(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Placed {
+		return nil, fmt.Errorf("%w: %s", apierrors.ErrNoPlacement, decision.summary())
 	}
 
-	// Validate backend exists
+	backend := decision.Backend
+	req.Backend = backend
+	req.Host = decision.Host
+
+	m.logger.Debug("Placed compute instance",
+		logger.String("name", req.Name),
+		logger.String("backend", string(backend)),
+		logger.String("host", decision.Host))
+
 	backendService, err := m.getBackend(backend)
 	if err != nil {
 		return nil, err
@@ -109,6 +185,11 @@ func (m *ComputeManager) CreateInstance(ctx context.Context, req ComputeInstance
 		return nil, fmt.Errorf("failed to create instance on backend %s: %w", backend, err)
 	}
 
+	// Backends that do not store labels keep them in the tracker
+	if instance.Labels == nil {
+		instance.Labels = req.Labels
+	}
+
 	// Update resource tracking
 	m.resourceTracker.AddInstance(instance)
 
@@ -212,6 +293,9 @@ func (m *ComputeManager) ListAllInstances(ctx context.Context, opts ComputeInsta
 		m.logger.Warn("Failed to list instances from backend", logger.Error(err))
 	}
 
+	// Fill in labels known only to the tracker and filter by them
+	allInstances = m.filterByLabels(allInstances, opts.Labels)
+
 	// Sort instances by creation time (newest first)
 	sort.Slice(allInstances, func(i, j int) bool {
 		return allInstances[i].CreatedAt.After(allInstances[j].CreatedAt)
@@ -225,6 +309,33 @@ func (m *ComputeManager) ListAllInstances(ctx context.Context, opts ComputeInsta
 	return allInstances, nil
 }
 
+// filterByLabels adds tracked labels to instances whose backend does not
+// report labels and returns the instances carrying all of the given labels.
+func (m *ComputeManager) filterByLabels(instances []*ComputeInstance, labels map[string]string) []*ComputeInstance {
+	tracked := make(map[string]*ComputeInstance)
+	for _, instance := range m.resourceTracker.ListInstances() {
+		tracked[instance.ID] = instance
+	}
+
+	filtered := instances[:0]
+	for _, instance := range instances {
+		if trackedInstance, ok := tracked[instance.ID]; ok {
+			if instance.Labels == nil {
+				instance.Labels = trackedInstance.Labels
+			}
+			if instance.Host == "" {
+				instance.Host = trackedInstance.Host
+			}
+		}
+
+		if matchesLabels(instance, labels) {
+			filtered = append(filtered, instance)
+		}
+	}
+
+	return filtered
+}
+
 // UpdateInstance updates a compute instance.
 func (m *ComputeManager) UpdateInstance(ctx context.Context, id string, update ComputeInstanceUpdate) (*ComputeInstance, error) {
 	// Find the instance first to determine its backend
@@ -499,6 +610,26 @@ func (m *ComputeManager) GetClusterStatus(ctx context.Context) (*ClusterStatus,
 			continue
 		}
 		status.Backends[backend] = backendInfo
+
+		if hostBackend, ok := service.(HostCapacityBackend); ok {
+			hosts, err := hostBackend.GetHostCapacity(ctx)
+			if err != nil {
+				m.logger.Warn("Failed to get host capacity",
+					logger.String("backend", string(backend)),
+					logger.Error(err))
+				continue
+			}
+			status.Hosts = append(status.Hosts, hosts...)
+		}
+	}
+
+	// Aggregate the capacity of all reachable hosts
+	for _, host := range status.Hosts {
+		status.ResourceLimits.CPU.Cores += float64(host.CPUs)
+		status.ResourceLimits.Memory.Limit += int64(host.MemoryTotal) //nolint:gosec
+		if host.MemoryFree <= host.MemoryTotal {
+			status.ResourceUsage.Memory.Limit += int64(host.MemoryTotal - host.MemoryFree) //nolint:gosec
+		}
 	}
 
 	// Get overall instance counts
@@ -522,6 +653,102 @@ func (m *ComputeManager) GetClusterStatus(ctx context.Context) (*ClusterStatus,
 	return status, nil
 }
 
+// PlanPlacement reports where a new instance would be placed and why,
+// without creating it.
+func (m *ComputeManager) PlanPlacement(ctx context.Context, req ComputeInstanceRequest) (*PlacementDecision, error) {
+	return m.scheduler.place(req, m.placementTargets(ctx), m.config.DefaultBackend), nil
+}
+
+// placementTargets returns the hosts of every backend with their capacity
+// and capabilities, probing them if the cached targets are stale.
+func (m *ComputeManager) placementTargets(ctx context.Context) []placementTarget {
+	targets, generation, ok := m.placement.get(time.Now())
+	if ok {
+		return targets
+	}
+
+	targets = m.probePlacementTargets(ctx)
+	m.placement.set(targets, generation, time.Now())
+	return targets
+}
+
+// probePlacementTargets collects the hosts of every backend with their
+// capacity and capabilities. Backends that do not report hosts are a single
+// target. Hosts are probed without holding the manager lock, so slow hosts
+// do not block other requests.
+func (m *ComputeManager) probePlacementTargets(ctx context.Context) []placementTarget {
+	m.mu.RLock()
+	backends := make([]ComputeBackend, 0, len(m.backends))
+	services := make(map[ComputeBackend]BackendService, len(m.backends))
+	for backend, service := range m.backends {
+		backends = append(backends, backend)
+		services[backend] = service
+	}
+	m.mu.RUnlock()
+	sort.Slice(backends, func(i, j int) bool { return backends[i] < backends[j] })
+
+	var targets []placementTarget
+	for _, backend := range backends {
+		service := services[backend]
+
+		backendInfo, err := service.GetBackendInfo(ctx)
+		if err != nil {
+			targets = append(targets, placementTarget{
+				backend:   backend,
+				state:     "unavailable",
+				lastError: err.Error(),
+			})
+			continue
+		}
+
+		base := placementTarget{
+			capabilities:   backendInfo.Capabilities,
+			supportedTypes: backendInfo.SupportedTypes,
+			backend:        backend,
+			state:          hostStateHealthy,
+		}
+
+		hostBackend, ok := service.(HostCapacityBackend)
+		if !ok {
+			// Single-host backends may report their capacity as resource limits
+			limits := backendInfo.ResourceLimits
+			if limits.CPU.Cores > 0 && limits.Memory.Limit > 0 {
+				base.cpus = int(limits.CPU.Cores)
+				base.memoryTotal = uint64(limits.Memory.Limit) //nolint:gosec
+				base.hasCapacity = true
+			}
+			targets = append(targets, base)
+			continue
+		}
+
+		hosts, err := hostBackend.GetHostCapacity(ctx)
+		if err != nil {
+			base.state = "unavailable"
+			base.lastError = err.Error()
+			targets = append(targets, base)
+			continue
+		}
+		if len(hosts) == 0 {
+			targets = append(targets, base)
+			continue
+		}
+
+		for _, host := range hosts {
+			target := base
+			target.capabilities = append(slices.Clone(base.capabilities), host.Capabilities...)
+			target.host = host.Name
+			target.state = host.State
+			target.lastError = host.LastError
+			target.memoryTotal = host.MemoryTotal
+			target.cpus = host.CPUs
+			target.hasCapacity = true
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
 // GetResourceQuotas gets resource quotas for a user.
 func (m *ComputeManager) GetResourceQuotas(ctx context.Context, userID uint) (*ResourceQuotas, error) {
 	return m.quotaManager.GetQuotas(userID), nil
@@ -556,7 +783,37 @@ func (m *ComputeManager) ValidateInstanceConfig(ctx context.Context, config Comp
 
 // AttachConsole attaches to an instance console.
 func (m *ComputeManager) AttachConsole(ctx context.Context, id string, opts ConsoleOptions) (io.ReadWriteCloser, error) {
-	return nil, fmt.Errorf("console attachment not implemented yet")
+	instance, err := m.GetInstance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	backendService, err := m.getBackend(instance.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	consoleBackend, ok := backendService.(ConsoleBackend)
+	if !ok {
+		return nil, fmt.Errorf("console attachment not supported by backend %s", instance.Backend)
+	}
+
+	console, err := consoleBackend.AttachConsole(ctx, id, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach console: %w", err)
+	}
+
+	m.eventBus.Emit(InstanceEvent{
+		ID:         uuid.New().String(),
+		InstanceID: instance.ID,
+		Type:       "console",
+		Action:     "attach",
+		Status:     "success",
+		Message:    fmt.Sprintf("Console attached (%s)", opts.Type),
+		Timestamp:  time.Now(),
+	})
+
+	return console, nil
 }
 
 // ExecuteCommand executes a command in an instance.
@@ -586,17 +843,86 @@ func (m *ComputeManager) DeleteSnapshot(ctx context.Context, id, snapshotID stri
 	return fmt.Errorf("snapshots not implemented yet")
 }
 
-// Migration and export (stubs).
+// MigrateInstance migrates an instance to another host.
 func (m *ComputeManager) MigrateInstance(ctx context.Context, id, targetHost string, opts MigrationOptions) error {
-	return fmt.Errorf("migration not implemented yet")
+	instance, err := m.GetInstance(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	backendService, err := m.getBackend(instance.Backend)
+	if err != nil {
+		return err
+	}
+
+	migrationBackend, ok := backendService.(MigrationBackend)
+	if !ok {
+		return fmt.Errorf("migration not supported by backend %s", instance.Backend)
+	}
+
+	if err := migrationBackend.Migrate(ctx, id, targetHost, opts); err != nil {
+		return fmt.Errorf("failed to migrate instance: %w", err)
+	}
+
+	m.eventBus.Emit(InstanceEvent{
+		ID:         uuid.New().String(),
+		InstanceID: instance.ID,
+		Type:       "migration",
+		Action:     "start",
+		Status:     "success",
+		Message:    fmt.Sprintf("Migration to %s started", targetHost),
+		Timestamp:  time.Now(),
+	})
+
+	return nil
 }
 
+// Export (stub).
+
 func (m *ComputeManager) ExportInstance(ctx context.Context, id string, opts ExportOptions) (*ExportJob, error) {
 	return nil, fmt.Errorf("export not implemented yet")
 }
 
+// ImportInstance creates an instance from an exported appliance or a disk
+// image.
 func (m *ComputeManager) ImportInstance(ctx context.Context, source string, opts ImportOptions) (*ComputeInstance, error) {
-	return nil, fmt.Errorf("import not implemented yet")
+	backend := opts.Backend
+	if backend == "" {
+		backend = BackendKVM
+	}
+
+	backendService, err := m.getBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	importBackend, ok := backendService.(ImportBackend)
+	if !ok {
+		return nil, fmt.Errorf("import not supported by backend %s", backend)
+	}
+
+	instance, err := importBackend.Import(ctx, source, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import instance: %w", err)
+	}
+
+	m.resourceTracker.AddInstance(instance)
+
+	m.eventBus.Emit(InstanceEvent{
+		ID:         uuid.New().String(),
+		InstanceID: instance.ID,
+		Type:       "lifecycle",
+		Action:     "import",
+		Status:     "success",
+		Timestamp:  time.Now(),
+	})
+
+	m.logger.Info("Imported compute instance",
+		logger.String("id", instance.ID),
+		logger.String("name", instance.Name),
+		logger.String("backend", string(backend)))
+
+	return instance, nil
 }
 
 // Network and storage attachment (stubs).
@@ -637,6 +963,19 @@ func (m *ComputeManager) StreamInstanceEvents(ctx context.Context, id string, op
 	return m.eventBus.StreamEvents(id, opts), nil
 }
 
+// RecordInstanceEvent records an event raised outside of the compute manager,
+// such as the outcome of a scheduled snapshot.
+func (m *ComputeManager) RecordInstanceEvent(ctx context.Context, event InstanceEvent) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	m.eventBus.Emit(event)
+}
+
 // Bulk operations (stubs).
 func (m *ComputeManager) BulkAction(ctx context.Context, action string, ids []string, opts BulkActionOptions) ([]*BulkActionResult, error) {
 	return nil, fmt.Errorf("bulk operations not implemented yet")
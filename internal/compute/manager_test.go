@@ -0,0 +1,98 @@
+package compute
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+)
+
+// probeBackend reports one host and counts how often it is probed.
+type probeBackend struct {
+	BackendService
+	release chan struct{}
+	probes  atomic.Int32
+}
+
+func (b *probeBackend) GetBackendInfo(ctx context.Context) (*BackendInfo, error) {
+	return &BackendInfo{SupportedTypes: []ComputeInstanceType{InstanceTypeVM}}, nil
+}
+
+func (b *probeBackend) GetHostCapacity(ctx context.Context) ([]HostCapacity, error) {
+	b.probes.Add(1)
+	if b.release != nil {
+		<-b.release
+	}
+	return []HostCapacity{{
+		Name:        "local",
+		State:       hostStateHealthy,
+		MemoryTotal: uint64(16 * gib),
+		CPUs:        8,
+	}}, nil
+}
+
+func newTestComputeManager(t *testing.T) *ComputeManager {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	manager, ok := NewComputeManager(ManagerConfig{DefaultBackend: BackendKVM}, mockLogger).(*ComputeManager)
+	require.True(t, ok)
+	return manager
+}
+
+func TestComputeManager_PlacementProbesAreCached(t *testing.T) {
+	manager := newTestComputeManager(t)
+	backend := &probeBackend{}
+	require.NoError(t, manager.RegisterBackend(BackendKVM, backend))
+
+	for range 3 {
+		decision, err := manager.PlanPlacement(context.Background(), vmRequest(1, gib))
+		require.NoError(t, err)
+		assert.Equal(t, "local", decision.Host)
+	}
+	assert.Equal(t, int32(1), backend.probes.Load())
+
+	// Registering a backend drops the cached hosts
+	require.NoError(t, manager.RegisterBackend(BackendDocker, &probeBackend{}))
+	_, err := manager.PlanPlacement(context.Background(), vmRequest(1, gib))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), backend.probes.Load())
+}
+
+func TestComputeManager_PlacementProbeDoesNotHoldLock(t *testing.T) {
+	manager := newTestComputeManager(t)
+	backend := &probeBackend{release: make(chan struct{})}
+	require.NoError(t, manager.RegisterBackend(BackendKVM, backend))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = manager.PlanPlacement(context.Background(), vmRequest(1, gib))
+	}()
+	require.Eventually(t, func() bool { return backend.probes.Load() == 1 }, time.Second, time.Millisecond)
+
+	// A slow host must not block writers of the manager state
+	registered := make(chan error, 1)
+	go func() { registered <- manager.RegisterBackend(BackendDocker, &probeBackend{}) }()
+	select {
+	case err := <-registered:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("RegisterBackend blocked while a host was probed")
+	}
+
+	close(backend.release)
+	<-done
+
+	// The probe started before the registration is not cached
+	assert.Nil(t, manager.placement.targets)
+}
@@ -0,0 +1,309 @@
+package compute
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Capabilities reported by backends and hosts and required by placement.
+const (
+	CapabilityUEFI = "uefi"
+	CapabilityOVS  = "ovs"
+	// CapabilityOVSBridgePrefix is followed by the name of an OVS bridge present on a host
+	CapabilityOVSBridgePrefix = "ovs-bridge:"
+)
+
+// hostStateHealthy is the state of hosts that accept new instances.
+const hostStateHealthy = "healthy"
+
+// PlacementPolicy constrains where a new instance is placed.
+type PlacementPolicy struct {
+	// Affinity places the instance on a host running instances with all of these
+	// labels; it is ignored until the first such instance exists
+	Affinity map[string]string `json:"affinity,omitempty"`
+	// AntiAffinity keeps the instance off hosts running instances with all of these labels
+	AntiAffinity map[string]string `json:"anti_affinity,omitempty"`
+	// RequiredCapabilities must all be offered by the backend or host
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"`
+}
+
+// PlacementCandidate describes a backend host considered for a new instance.
+type PlacementCandidate struct {
+	// Slice fields (24 bytes)
+	Reasons []string `json:"reasons,omitempty"`
+	// String fields (16 bytes each)
+	Backend ComputeBackend `json:"backend"`
+	Host    string         `json:"host,omitempty"`
+	// Float and int fields (8 bytes each); CPU in cores and memory in bytes
+	// after overcommit, zero when the host does not report its capacity
+	CPUCapacity     float64 `json:"cpu_capacity"`
+	CPUAllocated    float64 `json:"cpu_allocated"`
+	MemoryCapacity  int64   `json:"memory_capacity"`
+	MemoryAllocated int64   `json:"memory_allocated"`
+	Score           float64 `json:"score"`
+	// Bool fields (1 byte)
+	Eligible bool `json:"eligible"`
+}
+
+// PlacementDecision explains where a new instance lands and why.
+type PlacementDecision struct {
+	// Candidates are ordered from the best to the worst placement
+	Candidates []PlacementCandidate `json:"candidates"`
+	Backend    ComputeBackend       `json:"backend,omitempty"`
+	Host       string               `json:"host,omitempty"`
+	Placed     bool                 `json:"placed"`
+}
+
+// summary lists the reasons each candidate was rejected.
+func (d *PlacementDecision) summary() string {
+	if len(d.Candidates) == 0 {
+		return "no backends registered"
+	}
+
+	parts := make([]string, 0, len(d.Candidates))
+	for _, candidate := range d.Candidates {
+		parts = append(parts, fmt.Sprintf("%s: %s",
+			placementKey(candidate.Backend, candidate.Host), strings.Join(candidate.Reasons, ", ")))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// placementTarget is a backend host that can receive instances.
+type placementTarget struct {
+	capabilities   []string
+	supportedTypes []ComputeInstanceType
+	backend        ComputeBackend
+	host           string
+	state          string
+	lastError      string
+	memoryTotal    uint64
+	cpus           int
+	// hasCapacity is false for backends that do not report host capacity
+	hasCapacity bool
+}
+
+// scheduler places new instances on the backend host with the most free
+// resources that satisfies the instance's requirements.
+type scheduler struct {
+	tracker          *ResourceTracker
+	cpuOvercommit    float64
+	memoryOvercommit float64
+}
+
+// newScheduler creates a scheduler. Overcommit ratios multiply the physical
+// capacity of hosts; ratios that are not set default to no overcommit.
+func newScheduler(tracker *ResourceTracker, cpuOvercommit, memoryOvercommit float64) *scheduler {
+	if cpuOvercommit <= 0 {
+		cpuOvercommit = 1
+	}
+	if memoryOvercommit <= 0 {
+		memoryOvercommit = 1
+	}
+
+	return &scheduler{
+		tracker:          tracker,
+		cpuOvercommit:    cpuOvercommit,
+		memoryOvercommit: memoryOvercommit,
+	}
+}
+
+// place evaluates every target for an instance request. Eligible targets are
+// ranked by their share of free CPU and memory after placement; ties prefer
+// the default backend.
+func (s *scheduler) place(req ComputeInstanceRequest, targets []placementTarget, defaultBackend ComputeBackend) *PlacementDecision {
+	instances := s.tracker.ListInstances()
+
+	var policy PlacementPolicy
+	if req.Placement != nil {
+		policy = *req.Placement
+	}
+
+	affinityHosts := matchingHosts(instances, policy.Affinity)
+	antiAffinityHosts := matchingHosts(instances, policy.AntiAffinity)
+	required := requiredCapabilities(req)
+
+	decision := &PlacementDecision{
+		Candidates: make([]PlacementCandidate, 0, len(targets)),
+	}
+
+	for _, target := range targets {
+		candidate := PlacementCandidate{
+			Backend:  target.backend,
+			Host:     target.host,
+			Eligible: true,
+		}
+		reject := func(format string, args ...interface{}) {
+			candidate.Eligible = false
+			candidate.Reasons = append(candidate.Reasons, fmt.Sprintf(format, args...))
+		}
+
+		if req.Backend != "" && req.Backend != target.backend {
+			reject("backend %s was requested", req.Backend)
+		}
+		if req.Host != "" && req.Host != target.host {
+			reject("host %s was requested", req.Host)
+		}
+		if !slices.Contains(target.supportedTypes, req.Type) {
+			reject("%s instances are not supported", req.Type)
+		}
+		if target.state != hostStateHealthy {
+			if target.lastError != "" {
+				reject("host is %s: %s", target.state, target.lastError)
+			} else {
+				reject("host is %s", target.state)
+			}
+		}
+		for _, capability := range required {
+			if !slices.Contains(target.capabilities, capability) {
+				reject("missing capability %s", capability)
+			}
+		}
+
+		key := placementKey(target.backend, target.host)
+		if len(affinityHosts) > 0 && !affinityHosts[key] {
+			reject("no instances matching the affinity labels")
+		}
+		if antiAffinityHosts[key] {
+			reject("runs instances matching the anti-affinity labels")
+		}
+
+		if !target.hasCapacity {
+			candidate.Reasons = append(candidate.Reasons, "capacity not reported")
+		} else {
+			s.scoreCapacity(&candidate, req, target, instances, reject)
+		}
+
+		decision.Candidates = append(decision.Candidates, candidate)
+	}
+
+	sort.SliceStable(decision.Candidates, func(i, j int) bool {
+		a, b := decision.Candidates[i], decision.Candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Backend == defaultBackend && b.Backend != defaultBackend
+	})
+
+	if len(decision.Candidates) > 0 && decision.Candidates[0].Eligible {
+		decision.Backend = decision.Candidates[0].Backend
+		decision.Host = decision.Candidates[0].Host
+		decision.Placed = true
+	}
+
+	return decision
+}
+
+// scoreCapacity checks that a target has room for the request after the
+// resources of its tracked instances, and scores it by the share of CPU and
+// memory left free.
+func (s *scheduler) scoreCapacity(candidate *PlacementCandidate, req ComputeInstanceRequest, target placementTarget,
+	instances []*ComputeInstance, reject func(format string, args ...interface{})) {
+	candidate.CPUCapacity = float64(target.cpus) * s.cpuOvercommit
+	candidate.MemoryCapacity = int64(float64(target.memoryTotal) * s.memoryOvercommit)
+
+	for _, instance := range instances {
+		if instance.Backend != target.backend || instance.Host != target.host {
+			continue
+		}
+		if instance.State == StateStopped || instance.State == StateError {
+			continue
+		}
+		candidate.CPUAllocated += instance.Resources.CPU.Cores
+		candidate.MemoryAllocated += instance.Resources.Memory.Limit
+	}
+
+	cpuFree := candidate.CPUCapacity - candidate.CPUAllocated
+	memoryFree := candidate.MemoryCapacity - candidate.MemoryAllocated
+
+	if req.Resources.CPU.Cores > cpuFree {
+		reject("insufficient CPU: %.1f of %.1f cores free, %.1f requested",
+			max(cpuFree, 0), candidate.CPUCapacity, req.Resources.CPU.Cores)
+	}
+	if req.Resources.Memory.Limit > memoryFree {
+		reject("insufficient memory: %d of %d MiB free, %d MiB requested",
+			max(memoryFree, 0)>>20, candidate.MemoryCapacity>>20, req.Resources.Memory.Limit>>20)
+	}
+	if !candidate.Eligible {
+		return
+	}
+
+	var score float64
+	if candidate.CPUCapacity > 0 {
+		score += 50 * (cpuFree - req.Resources.CPU.Cores) / candidate.CPUCapacity
+	}
+	if candidate.MemoryCapacity > 0 {
+		score += 50 * float64(memoryFree-req.Resources.Memory.Limit) / float64(candidate.MemoryCapacity)
+	}
+	candidate.Score = score
+	candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("%.0f%% of CPU and memory free after placement", score))
+}
+
+// requiredCapabilities returns the capabilities an instance needs: those
+// requested explicitly, UEFI for UEFI firmware and an OVS bridge for each
+// network attached through OVS.
+func requiredCapabilities(req ComputeInstanceRequest) []string {
+	var required []string
+	if req.Placement != nil {
+		required = append(required, req.Placement.RequiredCapabilities...)
+	}
+
+	firmware := strings.ToLower(req.Config.Firmware)
+	if firmware == "uefi" || firmware == "efi" || req.Config.SecureBoot {
+		required = append(required, CapabilityUEFI)
+	}
+
+	for _, network := range req.Networks {
+		if network.Driver != "ovs" {
+			continue
+		}
+		if network.Network != "" {
+			required = append(required, CapabilityOVSBridgePrefix+network.Network)
+		} else {
+			required = append(required, CapabilityOVS)
+		}
+	}
+
+	slices.Sort(required)
+	return slices.Compact(required)
+}
+
+// matchingHosts returns the backend hosts running instances that carry all
+// of the given labels. It returns nil when no labels are given.
+func matchingHosts(instances []*ComputeInstance, labels map[string]string) map[string]bool {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	hosts := make(map[string]bool)
+	for _, instance := range instances {
+		if matchesLabels(instance, labels) {
+			hosts[placementKey(instance.Backend, instance.Host)] = true
+		}
+	}
+
+	return hosts
+}
+
+// matchesLabels reports whether an instance carries all of the given labels.
+func matchesLabels(instance *ComputeInstance, labels map[string]string) bool {
+	for key, value := range labels {
+		if instance.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// placementKey identifies a host of a backend.
+func placementKey(backend ComputeBackend, host string) string {
+	if host == "" {
+		return string(backend)
+	}
+	return string(backend) + "/" + host
+}
@@ -0,0 +1,179 @@
+package compute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gib = int64(1 << 30)
+
+func testTargets() []placementTarget {
+	vmTypes := []ComputeInstanceType{InstanceTypeVM}
+	return []placementTarget{
+		{
+			backend:        BackendKVM,
+			host:           "local",
+			state:          hostStateHealthy,
+			supportedTypes: vmTypes,
+			capabilities:   []string{CapabilityOVS, CapabilityOVSBridgePrefix + "br0"},
+			cpus:           8,
+			memoryTotal:    uint64(16 * gib),
+			hasCapacity:    true,
+		},
+		{
+			backend:        BackendKVM,
+			host:           "hv2",
+			state:          hostStateHealthy,
+			supportedTypes: vmTypes,
+			capabilities:   []string{CapabilityUEFI},
+			cpus:           8,
+			memoryTotal:    uint64(16 * gib),
+			hasCapacity:    true,
+		},
+		{
+			backend:        BackendDocker,
+			state:          hostStateHealthy,
+			supportedTypes: []ComputeInstanceType{InstanceTypeContainer},
+		},
+	}
+}
+
+func vmRequest(cores float64, memory int64) ComputeInstanceRequest {
+	return ComputeInstanceRequest{
+		Name: "vm",
+		Type: InstanceTypeVM,
+		Resources: ComputeResources{
+			CPU:    CPUResources{Cores: cores},
+			Memory: MemoryResources{Limit: memory},
+		},
+	}
+}
+
+func trackInstance(tracker *ResourceTracker, id, host string, cores float64, memory int64, labels map[string]string) {
+	tracker.AddInstance(&ComputeInstance{
+		ID:      id,
+		Backend: BackendKVM,
+		Host:    host,
+		State:   StateRunning,
+		Labels:  labels,
+		Resources: ComputeResources{
+			CPU:    CPUResources{Cores: cores},
+			Memory: MemoryResources{Limit: memory},
+		},
+	})
+}
+
+func candidateFor(t *testing.T, decision *PlacementDecision, host string) PlacementCandidate {
+	t.Helper()
+	for _, candidate := range decision.Candidates {
+		if candidate.Host == host {
+			return candidate
+		}
+	}
+	require.Failf(t, "candidate not found", "host %s", host)
+	return PlacementCandidate{}
+}
+
+func TestScheduler_PrefersMostFreeHost(t *testing.T) {
+	tracker := NewResourceTracker()
+	trackInstance(tracker, "a", "local", 4, 8*gib, nil)
+	trackInstance(tracker, "b", "hv2", 1, 2*gib, nil)
+	// Stopped instances do not hold resources
+	tracker.AddInstance(&ComputeInstance{ID: "c", Backend: BackendKVM, Host: "hv2", State: StateStopped,
+		Resources: ComputeResources{CPU: CPUResources{Cores: 8}}})
+
+	decision := newScheduler(tracker, 0, 0).place(vmRequest(2, 2*gib), testTargets(), BackendKVM)
+
+	require.True(t, decision.Placed)
+	assert.Equal(t, BackendKVM, decision.Backend)
+	assert.Equal(t, "hv2", decision.Host)
+
+	hv2 := decision.Candidates[0]
+	assert.Equal(t, 1.0, hv2.CPUAllocated)
+	assert.Equal(t, 2*gib, hv2.MemoryAllocated)
+	assert.InDelta(t, 50*5.0/8+50*12.0/16, hv2.Score, 0.001)
+
+	docker := candidateFor(t, decision, "")
+	assert.False(t, docker.Eligible)
+	assert.Contains(t, docker.Reasons, "vm instances are not supported")
+}
+
+func TestScheduler_Overcommit(t *testing.T) {
+	tracker := NewResourceTracker()
+	trackInstance(tracker, "a", "local", 8, 4*gib, nil)
+	trackInstance(tracker, "b", "hv2", 8, 4*gib, nil)
+
+	decision := newScheduler(tracker, 0, 0).place(vmRequest(2, 2*gib), testTargets(), BackendKVM)
+	assert.False(t, decision.Placed)
+	assert.Contains(t, decision.summary(), "insufficient CPU")
+
+	decision = newScheduler(tracker, 2, 1).place(vmRequest(2, 2*gib), testTargets(), BackendKVM)
+	require.True(t, decision.Placed)
+	assert.Equal(t, 16.0, decision.Candidates[0].CPUCapacity)
+}
+
+func TestScheduler_RequiredCapabilities(t *testing.T) {
+	scheduler := newScheduler(NewResourceTracker(), 0, 0)
+
+	req := vmRequest(1, gib)
+	req.Config.Firmware = "uefi"
+	decision := scheduler.place(req, testTargets(), BackendKVM)
+	require.True(t, decision.Placed)
+	assert.Equal(t, "hv2", decision.Host)
+	assert.Contains(t, candidateFor(t, decision, "local").Reasons, "missing capability uefi")
+
+	req = vmRequest(1, gib)
+	req.Networks = []NetworkAttachment{{Network: "br0", Driver: "ovs"}}
+	decision = scheduler.place(req, testTargets(), BackendKVM)
+	require.True(t, decision.Placed)
+	assert.Equal(t, "local", decision.Host)
+
+	req.Placement = &PlacementPolicy{RequiredCapabilities: []string{"gpu"}}
+	decision = scheduler.place(req, testTargets(), BackendKVM)
+	assert.False(t, decision.Placed)
+}
+
+func TestScheduler_Affinity(t *testing.T) {
+	tracker := NewResourceTracker()
+	trackInstance(tracker, "web-1", "local", 1, gib, map[string]string{"app": "web"})
+	scheduler := newScheduler(tracker, 0, 0)
+
+	req := vmRequest(1, gib)
+	req.Placement = &PlacementPolicy{AntiAffinity: map[string]string{"app": "web"}}
+	decision := scheduler.place(req, testTargets(), BackendKVM)
+	require.True(t, decision.Placed)
+	assert.Equal(t, "hv2", decision.Host)
+
+	// Affinity overrides the emptier host
+	req.Placement = &PlacementPolicy{Affinity: map[string]string{"app": "web"}}
+	decision = scheduler.place(req, testTargets(), BackendKVM)
+	require.True(t, decision.Placed)
+	assert.Equal(t, "local", decision.Host)
+
+	// Without matching instances affinity does not constrain placement
+	req.Placement = &PlacementPolicy{Affinity: map[string]string{"app": "db"}}
+	decision = scheduler.place(req, testTargets(), BackendKVM)
+	require.True(t, decision.Placed)
+	assert.Equal(t, "hv2", decision.Host)
+}
+
+func TestScheduler_RequestedHostAndHealth(t *testing.T) {
+	targets := testTargets()
+	targets[1].state = "unhealthy"
+	targets[1].lastError = "connection refused"
+
+	scheduler := newScheduler(NewResourceTracker(), 0, 0)
+
+	req := vmRequest(1, gib)
+	req.Host = "hv2"
+	decision := scheduler.place(req, targets, BackendKVM)
+	assert.False(t, decision.Placed)
+	assert.Contains(t, candidateFor(t, decision, "hv2").Reasons, "host is unhealthy: connection refused")
+	assert.Contains(t, candidateFor(t, decision, "local").Reasons, "host hv2 was requested")
+
+	decision = scheduler.place(vmRequest(1, gib), targets, BackendKVM)
+	require.True(t, decision.Placed)
+	assert.Equal(t, "local", decision.Host)
+}
@@ -59,6 +59,19 @@ func (rt *ResourceTracker) GetTotalResources() ComputeResources {
 	return total
 }
 
+// ListInstances returns the tracked instances.
+func (rt *ResourceTracker) ListInstances() []*ComputeInstance {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	instances := make([]*ComputeInstance, 0, len(rt.instances))
+	for _, instance := range rt.instances {
+		instances = append(instances, instance)
+	}
+
+	return instances
+}
+
 // QuotaManager manages resource quotas for users.
 type QuotaManager struct {
 	// Map fields (8 bytes)
@@ -63,6 +63,7 @@ type ComputeInstance struct {
 	UUID        string `json:"uuid,omitempty"`
 	ID          string `json:"id"`
 	HealthState string `json:"health_state,omitempty"`
+	Host        string `json:"host,omitempty"`
 	// Uint fields (8 bytes on 64-bit)
 	UserID uint `json:"user_id"`
 	// Enum fields (4 bytes each) - group together
@@ -463,9 +464,11 @@ type HostInfo struct {
 // ComputeInstanceRequest represents a request to create a compute instance.
 type ComputeInstanceRequest struct {
 	Limits      *ComputeResources     `json:"limits,omitempty"`
+	Placement   *PlacementPolicy      `json:"placement,omitempty"`
 	Labels      map[string]string     `json:"labels,omitempty"`
 	Annotations map[string]string     `json:"annotations,omitempty"`
 	Backend     ComputeBackend        `json:"backend,omitempty"`
+	Host        string                `json:"host,omitempty"`
 	UUID        string                `json:"uuid,omitempty"`
 	Type        ComputeInstanceType   `json:"type" validate:"required"`
 	Name        string                `json:"name" validate:"required"`
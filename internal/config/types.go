@@ -9,6 +9,10 @@ type Config struct {
 	Network       NetworkConfig    `yaml:"network" json:"network"`
 	Auth          AuthConfig       `yaml:"auth" json:"auth"`
 	Export        ExportConfig     `yaml:"export" json:"export"`
+	Backup        BackupConfig     `yaml:"backup" json:"backup"`
+	Import        ImportConfig     `yaml:"import" json:"import"`
+	Images        ImagesConfig     `yaml:"images" json:"images"`
+	Upload        UploadConfig     `yaml:"upload" json:"upload"`
 	Libvirt       LibvirtConfig    `yaml:"libvirt" json:"libvirt"`
 	Server        ServerConfig     `yaml:"server" json:"server"`
 	OVS           OVSConfig        `yaml:"ovs" json:"ovs"`
@@ -55,16 +59,36 @@ type TLSConfig struct {
 
 // LibvirtConfig holds libvirt connection settings.
 type LibvirtConfig struct {
+	// Slice fields (24 bytes)
+	// Hosts lists additional hypervisors managed by this server
+	Hosts []LibvirtHostConfig `yaml:"hosts" json:"hosts"`
+	// SharedFolderPaths lists the host directories, and their
+	// subdirectories, that VMs may share
+	SharedFolderPaths []string `yaml:"sharedFolderPaths" json:"sharedFolderPaths"`
 	// String fields (8 bytes on 64-bit)
 	URI         string `yaml:"uri" json:"uri"`
 	PoolName    string `yaml:"poolName" json:"poolName"`
 	NetworkName string `yaml:"networkName" json:"networkName"`
+	// HostName names the hypervisor at URI, "local" if empty
+	HostName string `yaml:"hostName" json:"hostName"`
 	// Duration fields (8 bytes)
-	ConnectionTimeout time.Duration `yaml:"connectionTimeout" json:"connectionTimeout"`
+	ConnectionTimeout   time.Duration `yaml:"connectionTimeout" json:"connectionTimeout"`
+	ShutdownTimeout     time.Duration `yaml:"shutdownTimeout" json:"shutdownTimeout"`
+	HealthCheckInterval time.Duration `yaml:"healthCheckInterval" json:"healthCheckInterval"`
 	// Int fields (4 bytes)
 	MaxConnections int `yaml:"maxConnections" json:"maxConnections"`
 }
 
+// LibvirtHostConfig holds connection settings for an additional hypervisor.
+type LibvirtHostConfig struct {
+	// Name selects the host in API requests
+	Name string `yaml:"name" json:"name"`
+	// URI is the libvirt URI of the host, e.g. qemu+ssh://root@host-b/system
+	URI string `yaml:"uri" json:"uri"`
+	// MaxConnections overrides the pool size for this host
+	MaxConnections int `yaml:"maxConnections" json:"maxConnections"`
+}
+
 // AuthConfig holds authentication configuration.
 type AuthConfig struct {
 	JWTSecretKey    string        `yaml:"jwtSecretKey" json:"jwtSecretKey"`
@@ -97,9 +121,52 @@ type LoggingConfig struct {
 
 // StorageConfig holds storage configuration.
 type StorageConfig struct {
-	Templates   map[string]string `yaml:"templates" json:"templates"`
-	DefaultPool string            `yaml:"defaultPool" json:"defaultPool"`
-	PoolPath    string            `yaml:"poolPath" json:"poolPath"`
+	// Templates maps image names to image files registered in the image
+	// library at startup
+	Templates   map[string]string       `yaml:"templates" json:"templates"`
+	DefaultPool string                  `yaml:"defaultPool" json:"defaultPool"`
+	PoolPath    string                  `yaml:"poolPath" json:"poolPath"`
+	Capacity    StorageCapacityConfig   `yaml:"capacity" json:"capacity"`
+	Orphans     StorageOrphanConfig     `yaml:"orphans" json:"orphans"`
+	Encryption  StorageEncryptionConfig `yaml:"encryption" json:"encryption"`
+}
+
+// StorageCapacityConfig holds storage pool capacity tracking configuration.
+// Alerts use monitoring.resourceAlerts.diskThreshold.
+type StorageCapacityConfig struct {
+	// MaxOvercommit rejects volumes that would make the sum of the volume
+	// sizes of a pool exceed this multiple of its capacity; zero disables
+	// the limit
+	MaxOvercommit float64 `yaml:"maxOvercommit" json:"maxOvercommit"`
+	// CheckInterval is how often pool usage is sampled
+	CheckInterval time.Duration `yaml:"checkInterval" json:"checkInterval"`
+	// HistorySize is the number of samples kept per pool
+	HistorySize int `yaml:"historySize" json:"historySize"`
+}
+
+// StorageOrphanConfig holds the configuration of the collector of orphaned
+// VM disk volumes and cloud-init ISOs.
+type StorageOrphanConfig struct {
+	// Enabled reconciles in the background; orphans can always be listed
+	// and purged through the API
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Interval is how often orphans are looked for
+	Interval time.Duration `yaml:"interval" json:"interval"`
+	// GracePeriod is how long orphans are quarantined before they are
+	// deleted
+	GracePeriod time.Duration `yaml:"gracePeriod" json:"gracePeriod"`
+}
+
+// StorageEncryptionConfig holds the master keys that the LUKS passphrases of
+// encrypted volumes are stored under. Keys are base64 encoded 32 byte AES-256
+// keys, usually set through STORAGE_ENCRYPTION_MASTERKEY.
+type StorageEncryptionConfig struct {
+	// MasterKey encrypts the passphrases; without it volumes cannot be
+	// encrypted
+	MasterKey string `yaml:"masterKey" json:"masterKey"`
+	// PreviousMasterKeys decrypt passphrases stored under earlier master
+	// keys, which are encrypted under MasterKey again at startup
+	PreviousMasterKeys []string `yaml:"previousMasterKeys" json:"previousMasterKeys"`
 }
 
 // ExportConfig holds export configuration.
@@ -110,6 +177,39 @@ type ExportConfig struct {
 	Retention     time.Duration `yaml:"retention" json:"retention"`
 }
 
+// BackupConfig holds backup repository configuration.
+type BackupConfig struct {
+	// RepositoryDir holds the deduplicated chunks and restore points
+	RepositoryDir string `yaml:"repositoryDir" json:"repositoryDir"`
+	// ScratchDir receives the disk images libvirt writes during backups; it
+	// must be local to the libvirt host or shared with it
+	ScratchDir string `yaml:"scratchDir" json:"scratchDir"`
+}
+
+// ImportConfig holds VM import configuration.
+type ImportConfig struct {
+	// SourceDir holds the OVA archives, OVF descriptors and disk images
+	// that can be imported by path
+	SourceDir string `yaml:"sourceDir" json:"sourceDir"`
+	// ScratchDir receives uploaded sources and extracted OVA archives
+	ScratchDir string `yaml:"scratchDir" json:"scratchDir"`
+}
+
+// ImagesConfig holds image library configuration.
+type ImagesConfig struct {
+	// SourceDir holds the image files that can be registered by path
+	SourceDir string `yaml:"sourceDir" json:"sourceDir"`
+}
+
+// UploadConfig holds resumable volume upload configuration.
+type UploadConfig struct {
+	// ScratchDir receives upload chunks until the image is converted into
+	// its volume
+	ScratchDir string `yaml:"scratchDir" json:"scratchDir"`
+	// Expiry is how long an upload may be idle before it is discarded
+	Expiry time.Duration `yaml:"expiry" json:"expiry"`
+}
+
 // FeaturesConfig holds feature flags.
 type FeaturesConfig struct {
 	CloudInit      bool `yaml:"cloudInit" json:"cloudInit"`
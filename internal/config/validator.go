@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
@@ -106,11 +107,8 @@ func ValidateLibvirt(libvirt LibvirtConfig) error {
 	}
 
 	// Check URI format.
-	if !strings.HasPrefix(libvirt.URI, "qemu") &&
-		!strings.HasPrefix(libvirt.URI, "xen") &&
-		!strings.HasPrefix(libvirt.URI, "lxc") &&
-		!strings.HasPrefix(libvirt.URI, "test") {
-		return fmt.Errorf("URI %s: unsupported hypervisor", libvirt.URI)
+	if err := validateLibvirtURI(libvirt.URI); err != nil {
+		return err
 	}
 
 	// Connection timeout should be positive.
@@ -118,11 +116,26 @@ func ValidateLibvirt(libvirt LibvirtConfig) error {
 		return fmt.Errorf("connection timeout: %w", ErrInvalidTimeout)
 	}
 
+	// Shutdown timeout is optional, zero uses the default.
+	if libvirt.ShutdownTimeout < 0 {
+		return fmt.Errorf("shutdown timeout: %w", ErrInvalidTimeout)
+	}
+
+	// Health check interval is optional, zero uses the default.
+	if libvirt.HealthCheckInterval < 0 {
+		return fmt.Errorf("health check interval: %w", ErrInvalidTimeout)
+	}
+
 	// Max connections should be at least 1.
 	if libvirt.MaxConnections < 1 {
 		return fmt.Errorf("max connections must be at least 1")
 	}
 
+	// Additional hosts need a unique name and a valid URI.
+	if err := validateLibvirtHosts(libvirt); err != nil {
+		return err
+	}
+
 	// Pool name should not be empty.
 	if libvirt.PoolName == "" {
 		return fmt.Errorf("pool name: %w", ErrEmptyValue)
@@ -136,6 +149,47 @@ func ValidateLibvirt(libvirt LibvirtConfig) error {
 	return nil
 }
 
+// validateLibvirtURI checks that a libvirt URI names a supported hypervisor.
+func validateLibvirtURI(uri string) error {
+	if !strings.HasPrefix(uri, "qemu") &&
+		!strings.HasPrefix(uri, "xen") &&
+		!strings.HasPrefix(uri, "lxc") &&
+		!strings.HasPrefix(uri, "test") {
+		return fmt.Errorf("URI %s: unsupported hypervisor", uri)
+	}
+	return nil
+}
+
+// validateLibvirtHosts validates the additional libvirt hosts.
+func validateLibvirtHosts(libvirt LibvirtConfig) error {
+	names := map[string]bool{libvirt.HostName: true}
+	if libvirt.HostName == "" {
+		names["local"] = true
+	}
+
+	for i, host := range libvirt.Hosts {
+		if host.Name == "" {
+			return fmt.Errorf("hosts[%d] name: %w", i, ErrEmptyValue)
+		}
+		if names[host.Name] {
+			return fmt.Errorf("hosts[%d]: duplicate host name %s", i, host.Name)
+		}
+		names[host.Name] = true
+
+		if host.URI == "" {
+			return fmt.Errorf("hosts[%d] URI: %w", i, ErrEmptyValue)
+		}
+		if err := validateLibvirtURI(host.URI); err != nil {
+			return fmt.Errorf("hosts[%d]: %w", i, err)
+		}
+		if host.MaxConnections < 0 {
+			return fmt.Errorf("hosts[%d]: max connections must not be negative", i)
+		}
+	}
+
+	return nil
+}
+
 // ValidateAuth validates authentication configuration.
 func ValidateAuth(auth AuthConfig) error {
 	// If auth is disabled, no need to validate further.
@@ -257,6 +311,35 @@ func ValidateStorage(storage StorageConfig) error {
 		}
 	}
 
+	if storage.Capacity.MaxOvercommit < 0 {
+		return fmt.Errorf("capacity max overcommit must be non-negative")
+	}
+	if storage.Capacity.CheckInterval < 0 {
+		return fmt.Errorf("capacity check interval: %w", ErrInvalidTimeout)
+	}
+	if storage.Capacity.HistorySize < 0 {
+		return fmt.Errorf("capacity history size must be non-negative")
+	}
+	if storage.Orphans.Interval < 0 {
+		return fmt.Errorf("orphan interval: %w", ErrInvalidTimeout)
+	}
+	if storage.Orphans.GracePeriod < 0 {
+		return fmt.Errorf("orphan grace period: %w", ErrInvalidTimeout)
+	}
+	if storage.Encryption.MasterKey == "" && len(storage.Encryption.PreviousMasterKeys) > 0 {
+		return fmt.Errorf("encryption master key: %w", ErrEmptyValue)
+	}
+	if storage.Encryption.MasterKey != "" {
+		if err := checkMasterKey(storage.Encryption.MasterKey); err != nil {
+			return fmt.Errorf("encryption master key: %w", err)
+		}
+	}
+	for i, key := range storage.Encryption.PreviousMasterKeys {
+		if err := checkMasterKey(key); err != nil {
+			return fmt.Errorf("previous encryption master key %d: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -351,3 +434,15 @@ func checkDirWritable(path string) error {
 
 	return nil
 }
+
+// checkMasterKey checks that a master key is a base64 encoded 32 byte key.
+func checkMasterKey(key string) error {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("%w: not base64: %w", ErrInvalidFormat, err)
+	}
+	if len(decoded) != 32 {
+		return fmt.Errorf("%w: %d bytes, want 32", ErrInvalidFormat, len(decoded))
+	}
+	return nil
+}
@@ -198,6 +198,61 @@ func TestValidateLibvirt(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Negative shutdown timeout",
+			libvirt: LibvirtConfig{
+				URI:               "qemu:///system",
+				ConnectionTimeout: 30 * time.Second,
+				ShutdownTimeout:   -time.Second,
+				MaxConnections:    5,
+				PoolName:          "default",
+				NetworkName:       "default",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Additional hosts",
+			libvirt: LibvirtConfig{
+				URI:               "qemu:///system",
+				ConnectionTimeout: 30 * time.Second,
+				MaxConnections:    5,
+				PoolName:          "default",
+				NetworkName:       "default",
+				Hosts: []LibvirtHostConfig{
+					{Name: "hv2", URI: "qemu+ssh://root@hv2/system"},
+					{Name: "hv3", URI: "qemu+tls://hv3/system", MaxConnections: 2},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Duplicate host name",
+			libvirt: LibvirtConfig{
+				URI:               "qemu:///system",
+				ConnectionTimeout: 30 * time.Second,
+				MaxConnections:    5,
+				PoolName:          "default",
+				NetworkName:       "default",
+				Hosts: []LibvirtHostConfig{
+					{Name: "local", URI: "qemu+ssh://root@hv2/system"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Host without URI",
+			libvirt: LibvirtConfig{
+				URI:               "qemu:///system",
+				ConnectionTimeout: 30 * time.Second,
+				MaxConnections:    5,
+				PoolName:          "default",
+				NetworkName:       "default",
+				Hosts: []LibvirtHostConfig{
+					{Name: "hv2"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -390,6 +445,56 @@ func TestValidateStorage(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Negative max overcommit",
+			storage: StorageConfig{
+				DefaultPool: "default",
+				PoolPath:    tempDir,
+				Capacity:    StorageCapacityConfig{MaxOvercommit: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative orphan grace period",
+			storage: StorageConfig{
+				DefaultPool: "default",
+				PoolPath:    tempDir,
+				Orphans:     StorageOrphanConfig{GracePeriod: -time.Hour},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Valid encryption master keys",
+			storage: StorageConfig{
+				DefaultPool: "default",
+				PoolPath:    tempDir,
+				Encryption: StorageEncryptionConfig{
+					MasterKey:          "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+					PreviousMasterKeys: []string{"ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Short encryption master key",
+			storage: StorageConfig{
+				DefaultPool: "default",
+				PoolPath:    tempDir,
+				Encryption:  StorageEncryptionConfig{MasterKey: "c2hvcnQ="},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Previous master keys without a master key",
+			storage: StorageConfig{
+				DefaultPool: "default",
+				PoolPath:    tempDir,
+				Encryption: StorageEncryptionConfig{
+					PreviousMasterKeys: []string{"ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Empty default pool",
 			storage: StorageConfig{
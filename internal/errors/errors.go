@@ -25,6 +25,7 @@ var (
 	ErrVMNotFound           = errors.New("VM not found")
 	ErrVMAlreadyExists      = errors.New("VM already exists")
 	ErrVMInvalidState       = errors.New("invalid VM state for operation")
+	ErrVMJobNotFound        = errors.New("VM job not found")
 	ErrInvalidCPUCount      = errors.New("invalid CPU count")
 	ErrInvalidMemorySize    = errors.New("invalid memory size")
 	ErrInvalidDiskSize      = errors.New("invalid disk size")
@@ -52,6 +53,48 @@ var (
 	ErrExportFailed      = errors.New("export operation failed")
 	ErrExportJobNotFound = errors.New("export job not found")
 	ErrUnsupportedFormat = errors.New("unsupported export format")
+
+	// Migration errors.
+	ErrMigrationJobNotFound  = errors.New("migration job not found")
+	ErrMigrationInProgress   = errors.New("migration already in progress")
+	ErrMigrationPreflight    = errors.New("migration pre-flight check failed")
+	ErrMigrationInvalidState = errors.New("invalid migration job state for operation")
+
+	// Placement errors.
+	ErrNoPlacement = errors.New("no host can place the instance")
+
+	// Snapshot policy errors.
+	ErrSnapshotPolicyNotFound = errors.New("snapshot policy not found")
+	ErrSnapshotPolicyRunning  = errors.New("snapshot policy is already running")
+
+	// Backup errors.
+	ErrBackupNotFound     = errors.New("restore point not found")
+	ErrBackupJobNotFound  = errors.New("backup job not found")
+	ErrBackupInProgress   = errors.New("backup already in progress")
+	ErrBackupInvalidState = errors.New("invalid backup job state for operation")
+
+	// Import errors.
+	ErrImportJobNotFound  = errors.New("import job not found")
+	ErrImportInvalidState = errors.New("invalid import job state for operation")
+
+	// Upload errors.
+	ErrUploadNotFound         = errors.New("upload not found")
+	ErrUploadInvalidState     = errors.New("invalid upload state for operation")
+	ErrUploadOffsetMismatch   = errors.New("upload offset mismatch")
+	ErrUploadChecksumMismatch = errors.New("upload checksum mismatch")
+
+	// Volume job errors.
+	ErrVolumeJobNotFound     = errors.New("volume job not found")
+	ErrVolumeJobInvalidState = errors.New("invalid volume job state for operation")
+	ErrVolumeInUse           = errors.New("volume is in use")
+
+	// Image library errors.
+	ErrImageNotFound = errors.New("image not found")
+	ErrImageInUse    = errors.New("image is in use")
+	ErrImageReadOnly = errors.New("image is read-only")
+
+	// Orphan collector errors.
+	ErrOrphanNotFound = errors.New("orphan not found")
 )
 
 // Wrap wraps an error with additional context.
@@ -87,6 +130,7 @@ func GetErrorCode(err error) error {
 		ErrVMNotFound,
 		ErrVMAlreadyExists,
 		ErrVMInvalidState,
+		ErrVMJobNotFound,
 		ErrInvalidCPUCount,
 		ErrInvalidMemorySize,
 		ErrInvalidDiskSize,
@@ -106,6 +150,30 @@ func GetErrorCode(err error) error {
 		ErrExportFailed,
 		ErrExportJobNotFound,
 		ErrUnsupportedFormat,
+		ErrMigrationJobNotFound,
+		ErrMigrationInProgress,
+		ErrMigrationPreflight,
+		ErrMigrationInvalidState,
+		ErrNoPlacement,
+		ErrSnapshotPolicyNotFound,
+		ErrSnapshotPolicyRunning,
+		ErrBackupNotFound,
+		ErrBackupJobNotFound,
+		ErrBackupInProgress,
+		ErrBackupInvalidState,
+		ErrImportJobNotFound,
+		ErrImportInvalidState,
+		ErrUploadNotFound,
+		ErrUploadInvalidState,
+		ErrUploadOffsetMismatch,
+		ErrUploadChecksumMismatch,
+		ErrVolumeJobNotFound,
+		ErrVolumeJobInvalidState,
+		ErrVolumeInUse,
+		ErrImageNotFound,
+		ErrImageInUse,
+		ErrImageReadOnly,
+		ErrOrphanNotFound,
 	}
 
 	// Check if the error is or wraps any of our error codes
@@ -127,6 +195,7 @@ var errorCodeStrings = map[error]string{
 	ErrVMNotFound:           "VM_NOT_FOUND",
 	ErrVMAlreadyExists:      "VM_ALREADY_EXISTS",
 	ErrVMInvalidState:       "VM_INVALID_STATE",
+	ErrVMJobNotFound:        "VM_JOB_NOT_FOUND",
 	ErrInvalidCPUCount:      "INVALID_CPU_COUNT",
 	ErrInvalidMemorySize:    "INVALID_MEMORY_SIZE",
 	ErrInvalidDiskSize:      "INVALID_DISK_SIZE",
@@ -146,6 +215,39 @@ var errorCodeStrings = map[error]string{
 	ErrExportFailed:         "EXPORT_FAILED",
 	ErrExportJobNotFound:    "EXPORT_JOB_NOT_FOUND",
 	ErrUnsupportedFormat:    "UNSUPPORTED_FORMAT",
+
+	ErrMigrationJobNotFound:  "MIGRATION_JOB_NOT_FOUND",
+	ErrMigrationInProgress:   "MIGRATION_IN_PROGRESS",
+	ErrMigrationPreflight:    "MIGRATION_PREFLIGHT_FAILED",
+	ErrMigrationInvalidState: "MIGRATION_INVALID_STATE",
+
+	ErrNoPlacement: "NO_PLACEMENT",
+
+	ErrSnapshotPolicyNotFound: "SNAPSHOT_POLICY_NOT_FOUND",
+	ErrSnapshotPolicyRunning:  "SNAPSHOT_POLICY_RUNNING",
+
+	ErrBackupNotFound:     "BACKUP_NOT_FOUND",
+	ErrBackupJobNotFound:  "BACKUP_JOB_NOT_FOUND",
+	ErrBackupInProgress:   "BACKUP_IN_PROGRESS",
+	ErrBackupInvalidState: "BACKUP_INVALID_STATE",
+
+	ErrImportJobNotFound:  "IMPORT_JOB_NOT_FOUND",
+	ErrImportInvalidState: "IMPORT_INVALID_STATE",
+
+	ErrUploadNotFound:         "UPLOAD_NOT_FOUND",
+	ErrUploadInvalidState:     "UPLOAD_INVALID_STATE",
+	ErrUploadOffsetMismatch:   "UPLOAD_OFFSET_MISMATCH",
+	ErrUploadChecksumMismatch: "UPLOAD_CHECKSUM_MISMATCH",
+
+	ErrVolumeJobNotFound:     "VOLUME_JOB_NOT_FOUND",
+	ErrVolumeJobInvalidState: "VOLUME_JOB_INVALID_STATE",
+	ErrVolumeInUse:           "VOLUME_IN_USE",
+
+	ErrImageNotFound: "IMAGE_NOT_FOUND",
+	ErrImageInUse:    "IMAGE_IN_USE",
+	ErrImageReadOnly: "IMAGE_READ_ONLY",
+
+	ErrOrphanNotFound: "ORPHAN_NOT_FOUND",
 }
 
 // GetErrorCodeString returns the string representation of the error code.
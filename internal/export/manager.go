@@ -14,6 +14,7 @@ import (
 	"github.com/threatflux/libgo/internal/export/formats/raw"
 	"github.com/threatflux/libgo/internal/export/formats/vdi"
 	"github.com/threatflux/libgo/internal/export/formats/vmdk"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
 	"github.com/threatflux/libgo/internal/libvirt/domain"
 	"github.com/threatflux/libgo/internal/libvirt/storage"
 	"github.com/threatflux/libgo/pkg/logger"
@@ -25,14 +26,18 @@ type ExportManager struct {
 	formatManagers map[string]formats.Converter
 	storageManager storage.VolumeManager
 	domainManager  domain.Manager
+	hosts          connection.HostLocator
 	logger         logger.Logger
 	baseExportDir  string
 }
 
-// NewExportManager creates a new ExportManager.
+// NewExportManager creates a new ExportManager. Hosts tells which libvirt
+// host a request selects; exports read the disk images from the local
+// filesystem, so VMs on remote hosts cannot be exported.
 func NewExportManager(
 	storageManager storage.VolumeManager,
 	domainManager domain.Manager,
+	hosts connection.HostLocator,
 	baseExportDir string,
 	logger logger.Logger,
 ) (*ExportManager, error) {
@@ -61,6 +66,7 @@ func NewExportManager(
 		formatManagers: formatConverters,
 		storageManager: storageManager,
 		domainManager:  domainManager,
+		hosts:          hosts,
 		baseExportDir:  baseExportDir,
 		logger:         logger,
 	}
@@ -70,6 +76,11 @@ func NewExportManager(
 
 // CreateExportJob implements Manager.CreateExportJob.
 func (m *ExportManager) CreateExportJob(ctx context.Context, vmName string, params Params) (*Job, error) {
+	// The converters run qemu-img on the disk images directly
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("exporting VM %s: %w", vmName, err)
+	}
+
 	// Check if VM exists
 	_, err := m.domainManager.Get(ctx, vmName)
 	if err != nil {
@@ -97,8 +108,9 @@ func (m *ExportManager) CreateExportJob(ctx context.Context, vmName string, para
 	// Create job
 	job := m.jobStore.createJob(vmName, params.Format, params.Options)
 
-	// Start processing job in background
-	go m.processExportJob(job, fileName)
+	// Start processing job in background; the job outlives the request but
+	// keeps its values, such as the selected libvirt host
+	go m.processExportJob(context.WithoutCancel(ctx), job, fileName)
 
 	return job, nil
 }
@@ -153,12 +165,12 @@ func (m *ExportManager) ListJobs(ctx context.Context) ([]*Job, error) {
 }
 
 // processExportJob processes an export job.
-func (m *ExportManager) processExportJob(job *Job, fileName string) {
+func (m *ExportManager) processExportJob(parent context.Context, job *Job, fileName string) {
 	// Update job status to running
 	m.jobStore.updateJobStatus(job.ID, StatusRunning, 5, nil)
 
 	// Setup job environment
-	_, cleanup, ctx, cancel, err := m.setupJobEnvironment(job)
+	_, cleanup, ctx, cancel, err := m.setupJobEnvironment(parent, job)
 	if err != nil {
 		m.jobStore.updateJobStatus(job.ID, StatusFailed, 0, err)
 		return
@@ -214,7 +226,7 @@ func (m *ExportManager) processExportJob(job *Job, fileName string) {
 }
 
 // setupJobEnvironment creates the job directory and context.
-func (m *ExportManager) setupJobEnvironment(job *Job) (string, func(), context.Context, context.CancelFunc, error) {
+func (m *ExportManager) setupJobEnvironment(parent context.Context, job *Job) (string, func(), context.Context, context.CancelFunc, error) {
 	// Create export directory for this job
 	jobDir := filepath.Join(m.baseExportDir, job.ID)
 	if err := os.MkdirAll(jobDir, 0755); err != nil {
@@ -234,7 +246,7 @@ func (m *ExportManager) setupJobEnvironment(job *Job) (string, func(), context.C
 	}
 
 	// Setup context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(parent)
 
 	return jobDir, cleanup, ctx, cancel, nil
 }
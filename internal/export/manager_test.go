@@ -13,10 +13,12 @@ import (
 
 	customErrors "github.com/threatflux/libgo/internal/errors"
 	"github.com/threatflux/libgo/internal/export/formats"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
 	"github.com/threatflux/libgo/internal/models/vm"
 	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
 	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
 	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"github.com/threatflux/libgo/test/testutil"
 )
 
 // testConverter is a test implementation of formats.Converter
@@ -143,6 +145,20 @@ func TestExportManager_CreateExportJob(t *testing.T) {
 	})
 }
 
+func TestExportManager_CreateExportJob_RemoteHost(t *testing.T) {
+	manager := &ExportManager{
+		jobStore:       newJobStore(),
+		formatManagers: map[string]formats.Converter{},
+		hosts:          testutil.RemoteHost{},
+		baseExportDir:  t.TempDir(),
+	}
+
+	job, err := manager.CreateExportJob(context.Background(), "test-vm", Params{Format: "qcow2"})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+	assert.Nil(t, job)
+	assert.Empty(t, manager.jobStore.listJobs())
+}
+
 func TestExportManager_GetJob(t *testing.T) {
 	// Setup
 	ctrl := gomock.NewController(t)
@@ -0,0 +1,145 @@
+// Package jobs provides the in-memory store the managers of long-running
+// operations, such as backups, imports and migrations, keep their jobs in.
+package jobs
+
+import (
+	"context"
+	"sync"
+)
+
+// Store provides thread-safe storage for jobs of type J. Jobs are handed
+// out as copies and changed under the store's lock through Update, so
+// readers never see a job while it is being changed.
+type Store[J any] struct {
+	jobs    map[string]*J
+	cancels map[string]context.CancelFunc
+	mu      sync.RWMutex
+}
+
+// NewStore creates a new job store.
+func NewStore[J any]() *Store[J] {
+	return &Store[J]{
+		jobs:    make(map[string]*J),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Add stores a job under an ID and returns a copy of it. conflict, if set,
+// is called under the lock with every stored job; an error it returns
+// refuses the new job, so checking for conflicting jobs and adding one
+// cannot race.
+func (s *Store[J]) Add(id string, job *J, conflict func(id string, stored *J) error) (*J, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conflict != nil {
+		for storedID, stored := range s.jobs {
+			if err := conflict(storedID, stored); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.jobs[id] = job
+	copied := *job
+	return &copied, nil
+}
+
+// Get gets a copy of a job by ID.
+func (s *Store[J]) Get(id string) (*J, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, false
+	}
+
+	copied := *job
+	return &copied, true
+}
+
+// List returns copies of all jobs.
+func (s *Store[J]) List() []*J {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]*J, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		copied := *job
+		jobs = append(jobs, &copied)
+	}
+
+	return jobs
+}
+
+// Find returns the ID of a job match accepts.
+func (s *Store[J]) Find(match func(job *J) bool) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, job := range s.jobs {
+		if match(job) {
+			return id, true
+		}
+	}
+
+	return "", false
+}
+
+// Update calls fn with a job under the lock, returning false if the job
+// does not exist. fn must not call other methods of the store.
+func (s *Store[J]) Update(id string, fn func(job *J)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return false
+	}
+
+	fn(job)
+	return true
+}
+
+// UpdateAll calls fn with every job under the lock. fn must not call other
+// methods of the store.
+func (s *Store[J]) UpdateAll(fn func(id string, job *J)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, job := range s.jobs {
+		fn(id, job)
+	}
+}
+
+// SetCancel stores the function that aborts a running job.
+func (s *Store[J]) SetCancel(id string, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancels[id] = cancel
+}
+
+// Cancel aborts a running job, returning false if it is not running.
+func (s *Store[J]) Cancel(id string) bool {
+	s.mu.Lock()
+	cancel, exists := s.cancels[id]
+	delete(s.cancels, id)
+	s.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+
+	cancel()
+	return true
+}
+
+// Release drops the function that aborts a job once the job finished.
+func (s *Store[J]) Release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.cancels, id)
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testJob struct {
+	Name   string
+	Status string
+}
+
+func TestStore_AddAndUpdate(t *testing.T) {
+	store := NewStore[testJob]()
+
+	job, err := store.Add("job-1", &testJob{Name: "web", Status: "running"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "web", job.Name)
+
+	// Conflicting jobs are refused
+	errBusy := errors.New("busy")
+	busy := func(_ string, stored *testJob) error {
+		if stored.Name == "web" && stored.Status == "running" {
+			return errBusy
+		}
+		return nil
+	}
+	_, err = store.Add("job-2", &testJob{Name: "web"}, busy)
+	assert.ErrorIs(t, err, errBusy)
+	_, exists := store.Get("job-2")
+	assert.False(t, exists)
+
+	id, found := store.Find(func(job *testJob) bool { return job.Name == "web" })
+	assert.True(t, found)
+	assert.Equal(t, "job-1", id)
+
+	require.True(t, store.Update("job-1", func(job *testJob) { job.Status = "completed" }))
+	assert.False(t, store.Update("missing", func(*testJob) {}))
+
+	_, err = store.Add("job-2", &testJob{Name: "web"}, busy)
+	require.NoError(t, err)
+
+	store.UpdateAll(func(_ string, job *testJob) { job.Status = "canceled" })
+	for _, job := range store.List() {
+		assert.Equal(t, "canceled", job.Status)
+	}
+}
+
+func TestStore_Cancel(t *testing.T) {
+	store := NewStore[testJob]()
+	_, err := store.Add("job-1", &testJob{}, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	store.SetCancel("job-1", cancel)
+
+	assert.True(t, store.Cancel("job-1"))
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	// A job can only be canceled once
+	assert.False(t, store.Cancel("job-1"))
+
+	// Finished jobs cannot be canceled
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	store.SetCancel("job-1", cancel)
+	store.Release("job-1")
+	assert.False(t, store.Cancel("job-1"))
+	assert.NoError(t, ctx.Err())
+}
+
+func TestStore_ReturnsCopies(t *testing.T) {
+	store := NewStore[testJob]()
+	job, err := store.Add("job-1", &testJob{Status: "pending"}, nil)
+	require.NoError(t, err)
+
+	job.Status = "completed"
+
+	got, ok := store.Get("job-1")
+	require.True(t, ok)
+	assert.Equal(t, "pending", got.Status)
+
+	got.Status = "completed"
+	jobs := store.List()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "pending", jobs[0].Status)
+}
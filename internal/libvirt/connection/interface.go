@@ -2,6 +2,7 @@ package connection
 
 import (
 	"context"
+	"net"
 
 	"github.com/digitalocean/go-libvirt"
 )
@@ -29,3 +30,19 @@ type Connection interface {
 	// IsActive checks if connection is active
 	IsActive() bool
 }
+
+// HostLocator is implemented by managers that know where the libvirt host
+// selected for an operation runs.
+type HostLocator interface {
+	// HostAddress returns the network address of the host selected in ctx,
+	// or an empty string if it runs on this machine
+	HostAddress(ctx context.Context) string
+}
+
+// HostDialer is implemented by managers that can open network connections to
+// addresses on the libvirt host selected for an operation.
+type HostDialer interface {
+	// DialHost connects to address, a host:port as seen from the host
+	// selected in ctx
+	DialHost(ctx context.Context, address string) (net.Conn, error)
+}
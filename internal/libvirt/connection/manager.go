@@ -2,8 +2,10 @@ package connection
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"sync"
 	"time"
 
@@ -12,6 +14,9 @@ import (
 	"github.com/threatflux/libgo/pkg/logger"
 )
 
+// defaultTCPPort is the port libvirtd listens on for unencrypted TCP connections.
+const defaultTCPPort = "16509"
+
 // ConnectionManager implements Manager for libvirt connections.
 type ConnectionManager struct {
 	// Interface fields (16 bytes each) - largest first
@@ -76,17 +81,10 @@ func (m *ConnectionManager) Connect(ctx context.Context) (Connection, error) {
 		// Pool is empty, continue to create a new connection
 	}
 
-	// Note: timeout is handled via net.DialTimeout below
-	// Context is preserved for future use if needed
-	_ = ctx
-
 	// Create a new connection
 	m.logger.Debug("Creating new libvirt connection",
 		logger.String("uri", m.uri))
 
-	// Parse the URI to determine connection type and connection path
-	var networkType, socketPath string
-
 	// Handle test:///default URI for testing
 	if m.uri == "test:///default" {
 		// For test driver, use a mock connection
@@ -108,7 +106,7 @@ func (m *ConnectionManager) Connect(ctx context.Context) (Connection, error) {
 			}
 		}()
 
-		c := clientConn
+		c := newStreamConn(clientConn)
 		dialer := &connDialer{conn: c}
 		l := libvirt.NewWithDialer(dialer)
 
@@ -123,27 +121,31 @@ func (m *ConnectionManager) Connect(ctx context.Context) (Connection, error) {
 		return libvirtConn, nil
 	}
 
-	// Normal libvirt URI parsing
-	switch {
-	case m.uri == "qemu:///system":
-		networkType = "unix"
-		socketPath = "/var/run/libvirt/libvirt-sock"
-	case m.uri == "qemu:///session":
-		networkType = "unix"
-		socketPath = "/run/user/1000/libvirt/libvirt-sock"
-	default:
-		// Try to extract tcp or unix connection details from URI
-		return nil, fmt.Errorf("unsupported libvirt URI format: %s", m.uri)
+	netConn, err := dialURI(ctx, m.uri, m.timeout)
+	if err != nil {
+		return nil, err
 	}
 
-	c, err := net.DialTimeout(networkType, socketPath, m.timeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to libvirt at %s: %w", socketPath, err)
+	// The handshake must finish within the timeout and while the request
+	// waits for it. go-libvirt retries reads that time out, so the
+	// connection is closed rather than given a deadline.
+	handshakeCtx, cancel := ctx, context.CancelFunc(func() {})
+	if m.timeout > 0 {
+		handshakeCtx, cancel = context.WithTimeout(ctx, m.timeout)
 	}
+	defer cancel()
+	stop := context.AfterFunc(handshakeCtx, func() {
+		_ = netConn.Close()
+	})
 
+	c := newStreamConn(netConn)
 	dialer := &connDialer{conn: c}
 	l := libvirt.NewWithDialer(dialer)
-	if err := l.Connect(); err != nil {
+	err = l.Connect()
+	if !stop() {
+		err = errors.Join(err, handshakeCtx.Err())
+	}
+	if err != nil {
 		c.Close()
 		return nil, fmt.Errorf("failed to establish libvirt connection: %w", err)
 	}
@@ -184,6 +186,11 @@ func (m *ConnectionManager) Release(conn Connection) error {
 	}
 }
 
+// HostAddress implements HostLocator.HostAddress.
+func (m *ConnectionManager) HostAddress(ctx context.Context) string {
+	return uriHostAddress(m.uri)
+}
+
 // Close implements Manager.Close.
 func (m *ConnectionManager) Close() error {
 	m.mu.Lock()
@@ -213,6 +220,15 @@ func (c *libvirtConnection) GetLibvirtConnection() *libvirt.Libvirt {
 	return c.libvirt
 }
 
+// SendStream implements StreamSender.
+func (c *libvirtConnection) SendStream(procedure uint32, data []byte) error {
+	sender, ok := c.conn.(StreamSender)
+	if !ok {
+		return fmt.Errorf("%w: connection does not send stream data", ErrNoStream)
+	}
+	return sender.SendStream(procedure, data)
+}
+
 // Close implements Connection.Close.
 func (c *libvirtConnection) Close() error {
 	if !c.active {
@@ -255,3 +271,45 @@ type connDialer struct {
 func (d *connDialer) Dial() (net.Conn, error) {
 	return d.conn, nil
 }
+
+// resolveURI determines the network type and address of the libvirt daemon
+// for a connection URI. Besides the local system and session daemons, remote
+// daemons are reachable over TCP (qemu+tcp://host[:port]/system), TLS
+// (qemu+tls://host[:port]/system) and local daemons through an explicit
+// socket (qemu+unix:///system?socket=path).
+func resolveURI(uri string) (string, string, error) {
+	switch uri {
+	case "qemu:///system":
+		return "unix", "/var/run/libvirt/libvirt-sock", nil
+	case "qemu:///session":
+		return "unix", "/run/user/1000/libvirt/libvirt-sock", nil
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing libvirt URI %s: %w", uri, err)
+	}
+
+	switch parsed.Scheme {
+	case "qemu+tcp", "qemu+tls":
+		if parsed.Hostname() == "" {
+			return "", "", fmt.Errorf("libvirt URI %s has no host", uri)
+		}
+		port := parsed.Port()
+		switch {
+		case port != "":
+		case parsed.Scheme == "qemu+tls":
+			port = defaultTLSPort
+		default:
+			port = defaultTCPPort
+		}
+		return "tcp", net.JoinHostPort(parsed.Hostname(), port), nil
+	case "qemu+unix":
+		if socket := parsed.Query().Get("socket"); socket != "" {
+			return "unix", socket, nil
+		}
+		return resolveURI("qemu://" + parsed.Path)
+	}
+
+	return "", "", fmt.Errorf("unsupported libvirt URI format: %s", uri)
+}
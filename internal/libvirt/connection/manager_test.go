@@ -9,6 +9,7 @@ import (
 	"github.com/digitalocean/go-libvirt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/threatflux/libgo/internal/config"
 	"github.com/threatflux/libgo/pkg/logger"
 )
@@ -216,6 +217,49 @@ func TestConnectionManager_Connect_MockedLibvirt(t *testing.T) {
 	assert.NotNil(t, conn)
 }
 
+func TestConnectionManager_Connect_HandshakeTimeout(t *testing.T) {
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+
+	// A daemon that accepts connections but never answers
+	socket := t.TempDir() + "/libvirt-sock"
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	manager := &ConnectionManager{
+		uri:            "qemu+unix:///system?socket=" + socket,
+		connPool:       make(chan *libvirtConnection, 1),
+		maxConnections: 1,
+		timeout:        100 * time.Millisecond,
+		logger:         mockLog,
+	}
+
+	start := time.Now()
+	_, err = manager.Connect(context.Background())
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// Requests that end stop waiting for the handshake
+	manager.timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	_, err = manager.Connect(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
 func TestConnectionManager_Close(t *testing.T) {
 	// Mock the logger
 	mockLog := new(mockLogger)
@@ -325,3 +369,74 @@ func TestConnectionManager_ReleaseInvalidConnection(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid connection type")
 }
+
+func TestResolveURI(t *testing.T) {
+	tests := []struct {
+		name        string
+		uri         string
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{
+			name:        "System daemon",
+			uri:         "qemu:///system",
+			wantNetwork: "unix",
+			wantAddress: "/var/run/libvirt/libvirt-sock",
+		},
+		{
+			name:        "Remote TCP with default port",
+			uri:         "qemu+tcp://host-b/system",
+			wantNetwork: "tcp",
+			wantAddress: "host-b:16509",
+		},
+		{
+			name:        "Remote TCP with port",
+			uri:         "qemu+tcp://10.0.0.2:16600/system",
+			wantNetwork: "tcp",
+			wantAddress: "10.0.0.2:16600",
+		},
+		{
+			name:        "Remote TLS with default port",
+			uri:         "qemu+tls://host-b/system",
+			wantNetwork: "tcp",
+			wantAddress: "host-b:16514",
+		},
+		{
+			name:        "Explicit socket",
+			uri:         "qemu+unix:///session?socket=/tmp/libvirt-b/libvirt-sock",
+			wantNetwork: "unix",
+			wantAddress: "/tmp/libvirt-b/libvirt-sock",
+		},
+		{
+			name:        "Unix without socket",
+			uri:         "qemu+unix:///system",
+			wantNetwork: "unix",
+			wantAddress: "/var/run/libvirt/libvirt-sock",
+		},
+		{
+			name:    "TCP without host",
+			uri:     "qemu+tcp:///system",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported transport",
+			uri:     "qemu+ssh://host-b/system",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, address, err := resolveURI(tt.uri)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNetwork, network)
+			assert.Equal(t, tt.wantAddress, address)
+		})
+	}
+}
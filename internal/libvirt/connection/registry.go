@@ -0,0 +1,626 @@
+package connection
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/threatflux/libgo/internal/config"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// DefaultHostName names the host at the primary libvirt URI when no name is
+// configured.
+const DefaultHostName = "local"
+
+// DefaultHealthCheckInterval is how often hosts are checked when no interval
+// is configured.
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// Reconnection backoff limits for unhealthy hosts.
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = 5 * time.Minute
+)
+
+// requestFailureThreshold is how many consecutive failed connection attempts
+// of requests mark a host unhealthy. A failed health check marks it at once.
+const requestFailureThreshold = 3
+
+var (
+	// ErrHostNotFound is returned when a request selects an unknown host.
+	ErrHostNotFound = errors.New("libvirt host not found")
+	// ErrHostUnavailable is returned while a host waits to be reconnected.
+	ErrHostUnavailable = errors.New("libvirt host unavailable")
+	// ErrRemoteHost is returned for operations that need the filesystem of
+	// the libvirt host when a remote host is selected.
+	ErrRemoteHost = errors.New("operation is not supported on remote libvirt hosts")
+)
+
+// HostState represents the health of a libvirt host.
+type HostState string
+
+// Host state constants.
+const (
+	HostStateUnknown   HostState = "unknown"
+	HostStateHealthy   HostState = "healthy"
+	HostStateUnhealthy HostState = "unhealthy"
+)
+
+// HostStatus describes a registered libvirt host and its health.
+type HostStatus struct {
+	LastCheck time.Time `json:"lastCheck,omitempty"`
+	NextRetry time.Time `json:"nextRetry,omitempty"`
+	Name      string    `json:"name"`
+	URI       string    `json:"uri"`
+	State     HostState `json:"state"`
+	LastError string    `json:"lastError,omitempty"`
+	Failures  int       `json:"failures"`
+	Default   bool      `json:"default"`
+}
+
+// HostCapacity describes the resources of a libvirt host.
+type HostCapacity struct {
+	HostStatus
+	// Capabilities lists optional hypervisor features, such as "uefi"
+	Capabilities []string `json:"capabilities,omitempty"`
+	CPUModel     string   `json:"cpuModel,omitempty"`
+	// MemoryTotal and MemoryFree are in bytes
+	MemoryTotal    uint64 `json:"memoryTotal"`
+	MemoryFree     uint64 `json:"memoryFree"`
+	CPUs           int    `json:"cpus"`
+	RunningDomains int    `json:"runningDomains"`
+	DefinedDomains int    `json:"definedDomains"`
+}
+
+// hostContextKey is the context key of the selected host.
+type hostContextKey struct{}
+
+// WithHost returns a context that selects a host for libvirt operations.
+func WithHost(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, hostContextKey{}, name)
+}
+
+// HostFromContext returns the host selected in a context.
+func HostFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(hostContextKey{}).(string)
+	return name, ok && name != ""
+}
+
+// HostAddressOf returns the network address of the host a manager selects
+// for ctx, or an empty string if it is local or unknown.
+func HostAddressOf(ctx context.Context, manager Manager) string {
+	locator, ok := manager.(HostLocator)
+	if !ok {
+		return ""
+	}
+	return locator.HostAddress(ctx)
+}
+
+// DialHost connects to address, a host:port as seen from the libvirt host a
+// manager selects for ctx. Managers that do not implement HostDialer manage
+// a host on this machine.
+func DialHost(ctx context.Context, manager Manager, address string) (net.Conn, error) {
+	if dialer, ok := manager.(HostDialer); ok {
+		return dialer.DialHost(ctx, address)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// RequireLocalHost returns ErrRemoteHost if the host selected in ctx runs on
+// another machine.
+func RequireLocalHost(ctx context.Context, locator HostLocator) error {
+	if locator == nil {
+		return nil
+	}
+	if address := locator.HostAddress(ctx); address != "" {
+		return fmt.Errorf("%w: %s", ErrRemoteHost, address)
+	}
+	return nil
+}
+
+// uriHostAddress returns the host of a libvirt URI, or an empty string for
+// daemons on this machine.
+func uriHostAddress(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+
+	host := parsed.Hostname()
+	if isLoopbackHost(host) {
+		return ""
+	}
+	return host
+}
+
+// registeredHost is a host with its connection pool.
+type registeredHost struct {
+	manager Manager
+	status  HostStatus
+	timeout time.Duration
+}
+
+// Registry implements Manager for several libvirt hosts. Each host has its
+// own connection pool; operations use the host selected with WithHost, or the
+// default host.
+type Registry struct {
+	logger      logger.Logger
+	hosts       map[string]*registeredHost
+	probe       func(Connection) error
+	defaultHost string
+	order       []string
+	mu          sync.RWMutex
+}
+
+// NewRegistry creates a Registry for the primary libvirt URI and the
+// additional hosts of a configuration.
+func NewRegistry(cfg config.LibvirtConfig, log logger.Logger) (*Registry, error) {
+	defaultHost := cfg.HostName
+	if defaultHost == "" {
+		defaultHost = DefaultHostName
+	}
+
+	r := &Registry{
+		logger:      log,
+		hosts:       make(map[string]*registeredHost),
+		probe:       probeConnection,
+		defaultHost: defaultHost,
+	}
+
+	if err := r.add(defaultHost, cfg); err != nil {
+		return nil, err
+	}
+
+	for _, host := range cfg.Hosts {
+		hostCfg := cfg
+		hostCfg.URI = host.URI
+		if host.MaxConnections > 0 {
+			hostCfg.MaxConnections = host.MaxConnections
+		}
+		if err := r.add(host.Name, hostCfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// add registers a host with its own connection pool.
+func (r *Registry) add(name string, cfg config.LibvirtConfig) error {
+	if _, exists := r.hosts[name]; exists {
+		return fmt.Errorf("duplicate libvirt host %s", name)
+	}
+
+	manager, err := NewConnectionManager(cfg, r.logger)
+	if err != nil {
+		return fmt.Errorf("creating connection manager for host %s: %w", name, err)
+	}
+
+	r.hosts[name] = &registeredHost{
+		manager: manager,
+		timeout: cfg.ConnectionTimeout,
+		status: HostStatus{
+			Name:    name,
+			URI:     cfg.URI,
+			State:   HostStateUnknown,
+			Default: name == r.defaultHost,
+		},
+	}
+	r.order = append(r.order, name)
+
+	return nil
+}
+
+// Connect implements Manager.Connect for the host selected in ctx.
+func (r *Registry) Connect(ctx context.Context) (Connection, error) {
+	name, ok := HostFromContext(ctx)
+	if !ok {
+		name = r.defaultHost
+	}
+
+	r.mu.RLock()
+	host, exists := r.hosts[name]
+	var status HostStatus
+	if exists {
+		status = host.status
+	}
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotFound, name)
+	}
+
+	if status.State == HostStateUnhealthy && time.Now().Before(status.NextRetry) {
+		return nil, fmt.Errorf("%w: %s (retrying in %s): %s", ErrHostUnavailable, name,
+			time.Until(status.NextRetry).Round(time.Second), status.LastError)
+	}
+
+	conn, err := host.manager.Connect(ctx)
+	if err != nil {
+		// A request that ended, e.g. because the client went away, says
+		// nothing about the host
+		if ctx.Err() == nil {
+			r.recordFailure(name, err, requestFailureThreshold)
+		}
+		return nil, fmt.Errorf("connecting to host %s: %w", name, err)
+	}
+
+	r.recordSuccess(name)
+	return conn, nil
+}
+
+// Release implements Manager.Release, returning a connection to the pool of
+// the host it belongs to.
+func (r *Registry) Release(conn Connection) error {
+	libvirtConn, ok := conn.(*libvirtConnection)
+	if !ok {
+		return fmt.Errorf("invalid connection type")
+	}
+
+	return libvirtConn.manager.Release(conn)
+}
+
+// Close implements Manager.Close for all hosts.
+func (r *Registry) Close() error {
+	var lastErr error
+
+	for _, name := range r.order {
+		if err := r.hosts[name].manager.Close(); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// DefaultHost returns the name of the host used when none is selected.
+func (r *Registry) DefaultHost() string {
+	return r.defaultHost
+}
+
+// HostAddress implements HostLocator.HostAddress.
+func (r *Registry) HostAddress(ctx context.Context) string {
+	name, ok := HostFromContext(ctx)
+	if !ok {
+		name = r.defaultHost
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	host, exists := r.hosts[name]
+	if !exists {
+		return ""
+	}
+	return uriHostAddress(host.status.URI)
+}
+
+// DialHost implements HostDialer.DialHost. Addresses on remote hosts are
+// dialed directly, except for loopback addresses, which are tunneled through
+// ssh for qemu+ssh hosts and refused otherwise, so that they are never
+// mistaken for ports on this machine.
+func (r *Registry) DialHost(ctx context.Context, address string) (net.Conn, error) {
+	name, ok := HostFromContext(ctx)
+	if !ok {
+		name = r.defaultHost
+	}
+
+	r.mu.RLock()
+	host, exists := r.hosts[name]
+	var uri string
+	if exists {
+		uri = host.status.URI
+	}
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotFound, name)
+	}
+
+	addressHost, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+
+	if uriHostAddress(uri) != "" && isLoopbackHost(addressHost) {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme != "qemu+ssh" {
+			return nil, fmt.Errorf("%w: %s is only reachable from host %s, which is not connected over qemu+ssh",
+				ErrRemoteHost, address, name)
+		}
+		return dialSSHTunnel(ctx, parsed, host.timeout, address)
+	}
+
+	dialer := net.Dialer{Timeout: host.timeout}
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// isLoopbackHost reports whether a host name or IP address names the
+// loopback interface.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// HasHost reports whether a host is registered.
+func (r *Registry) HasHost(name string) bool {
+	_, exists := r.hosts[name]
+	return exists
+}
+
+// Hosts returns the status of all hosts in configuration order.
+func (r *Registry) Hosts() []HostStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]HostStatus, 0, len(r.order))
+	for _, name := range r.order {
+		statuses = append(statuses, r.hosts[name].status)
+	}
+
+	return statuses
+}
+
+// Start checks the health of all hosts every interval until ctx is canceled.
+func (r *Registry) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		r.CheckHealth(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.CheckHealth(ctx)
+			}
+		}
+	}()
+}
+
+// CheckHealth checks every host that is not waiting for its next reconnection
+// attempt. Connections that fail the check are closed so the next operation
+// reconnects.
+func (r *Registry) CheckHealth(ctx context.Context) {
+	for _, name := range r.order {
+		r.checkHost(ctx, name, func(Connection) error { return nil })
+	}
+}
+
+// Capacity returns the resources of every host. Hosts that cannot be reached
+// are reported with their status only.
+func (r *Registry) Capacity(ctx context.Context) []HostCapacity {
+	capacities := make([]HostCapacity, 0, len(r.order))
+
+	for _, name := range r.order {
+		var capacity HostCapacity
+		r.checkHost(ctx, name, func(conn Connection) error {
+			var err error
+			capacity, err = readCapacity(conn)
+			return err
+		})
+
+		r.mu.RLock()
+		capacity.HostStatus = r.hosts[name].status
+		r.mu.RUnlock()
+
+		capacities = append(capacities, capacity)
+	}
+
+	return capacities
+}
+
+// checkHost probes a host and runs fn with a healthy connection.
+func (r *Registry) checkHost(ctx context.Context, name string, fn func(Connection) error) {
+	host := r.hosts[name]
+
+	r.mu.RLock()
+	status := host.status
+	r.mu.RUnlock()
+
+	if status.State == HostStateUnhealthy && time.Now().Before(status.NextRetry) {
+		return
+	}
+
+	conn, err := host.manager.Connect(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.recordFailure(name, err, 1)
+		}
+		return
+	}
+
+	err = r.probe(conn)
+	if err == nil {
+		err = fn(conn)
+	}
+	if err != nil {
+		_ = conn.Close()
+		if ctx.Err() == nil {
+			r.recordFailure(name, err, 1)
+		}
+		return
+	}
+
+	if err := host.manager.Release(conn); err != nil {
+		r.logger.Warn("Failed to release libvirt connection",
+			logger.String("host", name),
+			logger.Error(err))
+	}
+	r.recordSuccess(name)
+}
+
+// recordSuccess marks a host healthy.
+func (r *Registry) recordSuccess(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := &r.hosts[name].status
+	if status.State == HostStateUnhealthy {
+		r.logger.Info("Libvirt host recovered",
+			logger.String("host", name),
+			logger.Int("failures", status.Failures))
+	}
+
+	status.State = HostStateHealthy
+	status.LastCheck = time.Now()
+	status.LastError = ""
+	status.Failures = 0
+	status.NextRetry = time.Time{}
+}
+
+// recordFailure counts a failure of a host. Once threshold consecutive
+// failures are reached, the host is marked unhealthy and the next
+// reconnection attempt is scheduled with exponential backoff.
+func (r *Registry) recordFailure(name string, err error, threshold int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := &r.hosts[name].status
+	status.Failures++
+	status.LastCheck = time.Now()
+	status.LastError = err.Error()
+
+	if status.Failures < threshold && status.State != HostStateUnhealthy {
+		r.logger.Warn("Failed to connect to libvirt host",
+			logger.String("host", name),
+			logger.Int("failures", status.Failures),
+			logger.Error(err))
+		return
+	}
+
+	backoff := reconnectBackoff(status.Failures - threshold + 1)
+	status.State = HostStateUnhealthy
+	status.NextRetry = status.LastCheck.Add(backoff)
+
+	r.logger.Warn("Libvirt host unhealthy",
+		logger.String("host", name),
+		logger.Int("failures", status.Failures),
+		logger.Duration("retryIn", backoff),
+		logger.Error(err))
+}
+
+// reconnectBackoff returns the delay before reconnecting after a number of
+// consecutive failures.
+func reconnectBackoff(failures int) time.Duration {
+	backoff := minReconnectBackoff
+	for i := 1; i < failures && backoff < maxReconnectBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxReconnectBackoff)
+}
+
+// probeConnection checks that a libvirt connection still answers requests.
+func probeConnection(conn Connection) error {
+	if _, err := conn.GetLibvirtConnection().ConnectGetLibVersion(); err != nil {
+		return fmt.Errorf("probing libvirt: %w", err)
+	}
+	return nil
+}
+
+// readCapacity reads the resources of the host behind a connection.
+func readCapacity(conn Connection) (HostCapacity, error) {
+	var capacity HostCapacity
+	l := conn.GetLibvirtConnection()
+
+	model, memoryKiB, cpus, _, _, _, _, _, err := l.NodeGetInfo()
+	if err != nil {
+		return capacity, fmt.Errorf("getting node info: %w", err)
+	}
+
+	freeMemory, err := l.NodeGetFreeMemory()
+	if err != nil {
+		return capacity, fmt.Errorf("getting free memory: %w", err)
+	}
+
+	running, err := l.ConnectNumOfDomains()
+	if err != nil {
+		return capacity, fmt.Errorf("counting running domains: %w", err)
+	}
+
+	defined, err := l.ConnectNumOfDefinedDomains()
+	if err != nil {
+		return capacity, fmt.Errorf("counting defined domains: %w", err)
+	}
+
+	// Hosts that cannot describe their domain capabilities offer no optional features
+	if domainCaps, err := l.ConnectGetDomainCapabilities(nil, nil, nil, nil, 0); err == nil {
+		capacity.Capabilities = domainCapabilityNames(domainCaps)
+	}
+
+	capacity.CPUModel = cpuModelString(model)
+	capacity.MemoryTotal = memoryKiB * 1024
+	capacity.MemoryFree = freeMemory
+	capacity.CPUs = int(cpus)
+	capacity.RunningDomains = int(running)
+	capacity.DefinedDomains = int(defined)
+
+	return capacity, nil
+}
+
+// cpuModelString converts the NUL padded CPU model of NodeGetInfo.
+func cpuModelString(model [32]int8) string {
+	b := make([]byte, 0, len(model))
+	for _, c := range model {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
+
+// domainCapabilitiesXML holds the parts of the domain capabilities document
+// used to detect optional features.
+type domainCapabilitiesXML struct {
+	OS struct {
+		Supported string `xml:"supported,attr"`
+		Enums     []struct {
+			Name   string   `xml:"name,attr"`
+			Values []string `xml:"value"`
+		} `xml:"enum"`
+		Loader struct {
+			Supported string   `xml:"supported,attr"`
+			Values    []string `xml:"value"`
+		} `xml:"loader"`
+	} `xml:"os"`
+}
+
+// domainCapabilityNames returns the optional features offered by a host's
+// default emulator. UEFI is offered when the firmware enum lists "efi", or
+// on older libvirt when a loader image is available.
+func domainCapabilityNames(domainCaps string) []string {
+	var caps domainCapabilitiesXML
+	if err := xml.Unmarshal([]byte(domainCaps), &caps); err != nil || caps.OS.Supported != "yes" {
+		return nil
+	}
+
+	uefi := caps.OS.Loader.Supported == "yes" && len(caps.OS.Loader.Values) > 0
+	for _, enum := range caps.OS.Enums {
+		if enum.Name == "firmware" && slices.Contains(enum.Values, "efi") {
+			uefi = true
+		}
+	}
+
+	if !uefi {
+		return nil
+	}
+	return []string{"uefi"}
+}
@@ -0,0 +1,242 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/config"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+	mockLog.On("Info", mock.Anything, mock.Anything).Return()
+	mockLog.On("Warn", mock.Anything, mock.Anything).Return()
+	mockLog.On("Error", mock.Anything, mock.Anything).Return()
+
+	registry, err := NewRegistry(config.LibvirtConfig{
+		URI:               "test:///default",
+		ConnectionTimeout: time.Second,
+		MaxConnections:    2,
+		Hosts: []config.LibvirtHostConfig{
+			{Name: "hv2", URI: "test:///default", MaxConnections: 1},
+		},
+	}, mockLog)
+	require.NoError(t, err)
+
+	// The test driver does not answer libvirt calls
+	registry.probe = func(Connection) error { return nil }
+
+	return registry
+}
+
+func TestRegistry_Connect(t *testing.T) {
+	registry := newTestRegistry(t)
+	defer registry.Close()
+
+	assert.Equal(t, DefaultHostName, registry.DefaultHost())
+	assert.True(t, registry.HasHost("hv2"))
+
+	// Without a selector the default host is used
+	conn, err := registry.Connect(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, registry.hosts[DefaultHostName].manager, Cast(conn).manager)
+	require.NoError(t, registry.Release(conn))
+
+	conn, err = registry.Connect(WithHost(context.Background(), "hv2"))
+	require.NoError(t, err)
+	assert.Same(t, registry.hosts["hv2"].manager, Cast(conn).manager)
+	require.NoError(t, registry.Release(conn))
+
+	_, err = registry.Connect(WithHost(context.Background(), "hv9"))
+	assert.ErrorIs(t, err, ErrHostNotFound)
+
+	hosts := registry.Hosts()
+	require.Len(t, hosts, 2)
+	assert.Equal(t, DefaultHostName, hosts[0].Name)
+	assert.True(t, hosts[0].Default)
+	assert.Equal(t, HostStateHealthy, hosts[1].State)
+}
+
+func TestRegistry_HealthBackoff(t *testing.T) {
+	registry := newTestRegistry(t)
+	defer registry.Close()
+
+	registry.probe = func(Connection) error { return errors.New("connection reset") }
+	registry.CheckHealth(context.Background())
+
+	status := registry.Hosts()[1]
+	assert.Equal(t, HostStateUnhealthy, status.State)
+	assert.Equal(t, 1, status.Failures)
+	assert.Equal(t, "connection reset", status.LastError)
+	assert.True(t, status.NextRetry.After(status.LastCheck))
+
+	// Requests fail fast until the next attempt is due
+	_, err := registry.Connect(WithHost(context.Background(), "hv2"))
+	assert.ErrorIs(t, err, ErrHostUnavailable)
+
+	// Checks are skipped while waiting
+	registry.CheckHealth(context.Background())
+	assert.Equal(t, 1, registry.Hosts()[1].Failures)
+
+	// The host recovers on the next due check
+	registry.probe = func(Connection) error { return nil }
+	registry.mu.Lock()
+	registry.hosts["hv2"].status.NextRetry = time.Now().Add(-time.Second)
+	registry.mu.Unlock()
+	registry.CheckHealth(context.Background())
+
+	status = registry.Hosts()[1]
+	assert.Equal(t, HostStateHealthy, status.State)
+	assert.Zero(t, status.Failures)
+	assert.Empty(t, status.LastError)
+}
+
+func TestRegistry_RequestFailures(t *testing.T) {
+	registry := newTestRegistry(t)
+	defer registry.Close()
+
+	registry.hosts["hv2"].manager.(*ConnectionManager).uri = "qemu+unix:///system?socket=" + t.TempDir() + "/missing-sock"
+	remote := WithHost(context.Background(), "hv2")
+
+	// Canceled requests are not held against the host
+	canceled, cancel := context.WithCancel(remote)
+	cancel()
+	_, err := registry.Connect(canceled)
+	require.Error(t, err)
+	assert.Zero(t, registry.Hosts()[1].Failures)
+
+	// Failed requests only mark the host unhealthy once they add up
+	for i := 1; i < requestFailureThreshold; i++ {
+		_, err = registry.Connect(remote)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrHostUnavailable)
+		assert.NotEqual(t, HostStateUnhealthy, registry.Hosts()[1].State)
+	}
+
+	_, err = registry.Connect(remote)
+	require.Error(t, err)
+	status := registry.Hosts()[1]
+	assert.Equal(t, HostStateUnhealthy, status.State)
+	assert.Equal(t, requestFailureThreshold, status.Failures)
+
+	_, err = registry.Connect(remote)
+	assert.ErrorIs(t, err, ErrHostUnavailable)
+}
+
+func TestRegistry_DialHost(t *testing.T) {
+	registry := newTestRegistry(t)
+	defer registry.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	accepted := make(chan struct{}, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+			conn.Close()
+		}
+	}()
+	address := listener.Addr().String()
+
+	// Loopback addresses of local hosts are on this machine
+	conn, err := registry.DialHost(context.Background(), address)
+	require.NoError(t, err)
+	conn.Close()
+	<-accepted
+
+	// Loopback addresses of remote hosts are never dialed here
+	registry.hosts["hv2"].status.URI = "qemu+tcp://10.0.0.2/system"
+	_, err = registry.DialHost(WithHost(context.Background(), "hv2"), address)
+	assert.ErrorIs(t, err, ErrRemoteHost)
+	_, err = registry.DialHost(WithHost(context.Background(), "hv2"), "[::1]:5900")
+	assert.ErrorIs(t, err, ErrRemoteHost)
+
+	select {
+	case <-accepted:
+		t.Fatal("loopback address of a remote host was dialed on this machine")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = registry.DialHost(WithHost(context.Background(), "hv9"), address)
+	assert.ErrorIs(t, err, ErrHostNotFound)
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, reconnectBackoff(1))
+	assert.Equal(t, 2*time.Second, reconnectBackoff(2))
+	assert.Equal(t, 16*time.Second, reconnectBackoff(5))
+	assert.Equal(t, maxReconnectBackoff, reconnectBackoff(20))
+}
+
+func TestHostFromContext(t *testing.T) {
+	_, ok := HostFromContext(context.Background())
+	assert.False(t, ok)
+
+	name, ok := HostFromContext(WithHost(context.Background(), "hv2"))
+	assert.True(t, ok)
+	assert.Equal(t, "hv2", name)
+}
+
+func TestRegistry_HostAddress(t *testing.T) {
+	registry := newTestRegistry(t)
+	defer registry.Close()
+
+	registry.hosts["hv2"].status.URI = "qemu+ssh://root@hv2.example.com/system"
+	remote := WithHost(context.Background(), "hv2")
+
+	assert.Empty(t, registry.HostAddress(context.Background()))
+	assert.Equal(t, "hv2.example.com", registry.HostAddress(remote))
+	assert.Empty(t, registry.HostAddress(WithHost(context.Background(), "hv9")))
+
+	assert.NoError(t, RequireLocalHost(context.Background(), registry))
+	assert.ErrorIs(t, RequireLocalHost(remote, registry), ErrRemoteHost)
+	assert.Equal(t, "hv2.example.com", HostAddressOf(remote, registry))
+}
+
+func TestURIHostAddress(t *testing.T) {
+	tests := map[string]string{
+		"qemu:///system":                      "",
+		"qemu+unix:///system?socket=/tmp/l":   "",
+		"test:///default":                     "",
+		"qemu+tcp://localhost/system":         "",
+		"qemu+tls://127.0.0.1:16514/system":   "",
+		"qemu+tcp://10.0.0.2/system":          "10.0.0.2",
+		"qemu+ssh://admin@hv3.example/system": "hv3.example",
+		"qemu+tls://[2001:db8::1]/system":     "2001:db8::1",
+	}
+
+	for uri, want := range tests {
+		assert.Equal(t, want, uriHostAddress(uri), uri)
+	}
+}
+
+func TestDomainCapabilityNames(t *testing.T) {
+	firmwareEnum := `<domainCapabilities><os supported='yes'>
+		<enum name='firmware'><value>bios</value><value>efi</value></enum>
+		<loader supported='yes'><enum name='type'><value>rom</value></enum></loader>
+	</os></domainCapabilities>`
+	assert.Equal(t, []string{"uefi"}, domainCapabilityNames(firmwareEnum))
+
+	loaderOnly := `<domainCapabilities><os supported='yes'>
+		<loader supported='yes'><value>/usr/share/OVMF/OVMF_CODE.fd</value></loader>
+	</os></domainCapabilities>`
+	assert.Equal(t, []string{"uefi"}, domainCapabilityNames(loaderOnly))
+
+	biosOnly := `<domainCapabilities><os supported='yes'>
+		<enum name='firmware'><value>bios</value></enum>
+		<loader supported='no'/>
+	</os></domainCapabilities>`
+	assert.Empty(t, domainCapabilityNames(biosOnly))
+}
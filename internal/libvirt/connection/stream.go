@@ -0,0 +1,140 @@
+package connection
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/digitalocean/go-libvirt/socket"
+)
+
+// Packets of the libvirt RPC protocol start with their length and a header.
+const (
+	// remoteProgram is libvirt's REMOTE_PROGRAM
+	remoteProgram = 0x20008086
+	// remoteProtocolVersion is libvirt's REMOTE_PROTOCOL_VERSION
+	remoteProtocolVersion = 1
+	// packetHeaderSize is the size of the length and the header
+	packetHeaderSize = 28
+	// maxStreamPayload keeps stream packets within the 256 KiB libvirtd
+	// accepts
+	maxStreamPayload = 256*1024 - packetHeaderSize
+)
+
+// ErrNoStream is returned when data is sent on a stream that was not opened.
+var ErrNoStream = errors.New("no stream open")
+
+// StreamSender sends data on a stream opened by a call on a connection.
+// go-libvirt only sends data on upload streams, while console streams are
+// read and written at the same time.
+type StreamSender interface {
+	// SendStream sends data on the stream opened by the last call of a
+	// procedure on the connection
+	SendStream(procedure uint32, data []byte) error
+}
+
+// streamConn is a libvirt network connection that follows the packets
+// go-libvirt writes, recording the serial of every call, so that stream
+// data can be sent between them.
+type streamConn struct {
+	net.Conn
+	// serials maps procedures to the serial of their last call
+	serials map[uint32]uint32
+	header  []byte
+	// mu is held from the first to the last write of a packet
+	mu sync.Mutex
+	// remaining counts the payload bytes of the packet being written
+	remaining int
+	inPacket  bool
+}
+
+// newStreamConn wraps a libvirt network connection.
+func newStreamConn(conn net.Conn) *streamConn {
+	return &streamConn{
+		Conn:    conn,
+		serials: make(map[uint32]uint32),
+		header:  make([]byte, 0, packetHeaderSize),
+	}
+}
+
+// Write implements net.Conn. go-libvirt writes one packet at a time, so
+// packets sent with SendStream wait until the packet being written is
+// complete.
+func (c *streamConn) Write(b []byte) (int, error) {
+	if !c.inPacket {
+		c.mu.Lock()
+	}
+
+	n, err := c.Conn.Write(b)
+	c.track(b[:n])
+
+	c.inPacket = err == nil && (len(c.header) > 0 || c.remaining > 0)
+	if !c.inPacket {
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// track follows the packets in written data.
+func (c *streamConn) track(b []byte) {
+	for len(b) > 0 {
+		if c.remaining > 0 {
+			skip := min(c.remaining, len(b))
+			c.remaining -= skip
+			b = b[skip:]
+			continue
+		}
+
+		take := min(packetHeaderSize-len(c.header), len(b))
+		c.header = append(c.header, b[:take]...)
+		b = b[take:]
+		if len(c.header) < packetHeaderSize {
+			return
+		}
+
+		length := binary.BigEndian.Uint32(c.header[0:4])
+		procedure := binary.BigEndian.Uint32(c.header[12:16])
+		packetType := binary.BigEndian.Uint32(c.header[16:20])
+		serial := binary.BigEndian.Uint32(c.header[20:24])
+		if packetType == socket.Call {
+			c.serials[procedure] = serial
+		}
+
+		c.remaining = max(int(length)-packetHeaderSize, 0)
+		c.header = c.header[:0]
+	}
+}
+
+// SendStream implements StreamSender.
+func (c *streamConn) SendStream(procedure uint32, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	serial, ok := c.serials[procedure]
+	if !ok {
+		return fmt.Errorf("%w: procedure %d", ErrNoStream, procedure)
+	}
+
+	for len(data) > 0 {
+		chunk := data[:min(len(data), maxStreamPayload)]
+		data = data[len(chunk):]
+
+		packet := make([]byte, packetHeaderSize+len(chunk))
+		binary.BigEndian.PutUint32(packet[0:4], uint32(len(packet)))
+		binary.BigEndian.PutUint32(packet[4:8], remoteProgram)
+		binary.BigEndian.PutUint32(packet[8:12], remoteProtocolVersion)
+		binary.BigEndian.PutUint32(packet[12:16], procedure)
+		binary.BigEndian.PutUint32(packet[16:20], socket.Stream)
+		binary.BigEndian.PutUint32(packet[20:24], serial)
+		binary.BigEndian.PutUint32(packet[24:28], socket.StatusContinue)
+		copy(packet[packetHeaderSize:], chunk)
+
+		if _, err := c.Conn.Write(packet); err != nil {
+			return fmt.Errorf("sending stream data: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package connection
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/digitalocean/go-libvirt/socket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConn is a net.Conn that keeps everything written to it.
+type recordingConn struct {
+	net.Conn
+	written bytes.Buffer
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	return c.written.Write(b)
+}
+
+// testPacket builds a libvirt RPC packet.
+func testPacket(procedure, packetType, serial uint32, payload []byte) []byte {
+	packet := make([]byte, packetHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(packet[0:4], uint32(len(packet)))
+	binary.BigEndian.PutUint32(packet[4:8], remoteProgram)
+	binary.BigEndian.PutUint32(packet[8:12], remoteProtocolVersion)
+	binary.BigEndian.PutUint32(packet[12:16], procedure)
+	binary.BigEndian.PutUint32(packet[16:20], packetType)
+	binary.BigEndian.PutUint32(packet[20:24], serial)
+	copy(packet[packetHeaderSize:], payload)
+	return packet
+}
+
+func TestStreamConn(t *testing.T) {
+	t.Run("No stream open", func(t *testing.T) {
+		conn := newStreamConn(&recordingConn{})
+		err := conn.SendStream(201, []byte("input"))
+		assert.ErrorIs(t, err, ErrNoStream)
+	})
+
+	t.Run("Sends data with the serial of the call", func(t *testing.T) {
+		raw := &recordingConn{}
+		conn := newStreamConn(raw)
+
+		_, err := conn.Write(testPacket(201, socket.Call, 7, []byte("args")))
+		require.NoError(t, err)
+		_, err = conn.Write(testPacket(122, socket.Call, 8, nil))
+		require.NoError(t, err)
+		raw.written.Reset()
+
+		require.NoError(t, conn.SendStream(201, []byte("input")))
+
+		packet := raw.written.Bytes()
+		require.Len(t, packet, packetHeaderSize+5)
+		assert.Equal(t, uint32(201), binary.BigEndian.Uint32(packet[12:16]))
+		assert.Equal(t, uint32(socket.Stream), binary.BigEndian.Uint32(packet[16:20]))
+		assert.Equal(t, uint32(7), binary.BigEndian.Uint32(packet[20:24]))
+		assert.Equal(t, uint32(socket.StatusContinue), binary.BigEndian.Uint32(packet[24:28]))
+		assert.Equal(t, "input", string(packet[packetHeaderSize:]))
+	})
+
+	t.Run("Follows packets split across writes", func(t *testing.T) {
+		raw := &recordingConn{}
+		conn := newStreamConn(raw)
+
+		first := testPacket(201, socket.Call, 3, []byte("arguments"))
+		second := testPacket(201, socket.Call, 4, nil)
+		data := append(first, second...)
+		for _, chunk := range [][]byte{data[:10], data[10:30], data[30:40], data[40:]} {
+			_, err := conn.Write(chunk)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, uint32(4), conn.serials[201])
+		assert.False(t, conn.inPacket)
+	})
+
+	t.Run("Stream data waits for partially written packets", func(t *testing.T) {
+		raw := &recordingConn{}
+		conn := newStreamConn(raw)
+		_, err := conn.Write(testPacket(201, socket.Call, 1, nil))
+		require.NoError(t, err)
+
+		packet := testPacket(122, socket.Call, 2, []byte("payload"))
+		_, err = conn.Write(packet[:packetHeaderSize+2])
+		require.NoError(t, err)
+
+		sent := make(chan error, 1)
+		go func() { sent <- conn.SendStream(201, []byte("x")) }()
+
+		_, err = conn.Write(packet[packetHeaderSize+2:])
+		require.NoError(t, err)
+		require.NoError(t, <-sent)
+
+		assert.Equal(t, packet, raw.written.Bytes()[packetHeaderSize:packetHeaderSize+len(packet)])
+	})
+
+	t.Run("Large input is split", func(t *testing.T) {
+		raw := &recordingConn{}
+		conn := newStreamConn(raw)
+		_, err := conn.Write(testPacket(201, socket.Call, 1, nil))
+		require.NoError(t, err)
+		raw.written.Reset()
+
+		require.NoError(t, conn.SendStream(201, make([]byte, maxStreamPayload+1)))
+		assert.Equal(t, 2*packetHeaderSize+maxStreamPayload+1, raw.written.Len())
+	})
+}
@@ -0,0 +1,302 @@
+package connection
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// defaultTLSPort is the port libvirtd listens on for TLS connections.
+const defaultTLSPort = "16514"
+
+// Default locations of the libvirt client PKI files.
+const (
+	defaultCACertPath     = "/etc/pki/CA/cacert.pem"
+	defaultClientCertPath = "/etc/pki/libvirt/clientcert.pem"
+	defaultClientKeyPath  = "/etc/pki/libvirt/private/clientkey.pem"
+)
+
+// defaultRemoteSocket is the socket of the system daemon on remote hosts.
+const defaultRemoteSocket = "/var/run/libvirt/libvirt-sock"
+
+// dialURI opens a transport to the libvirt daemon at uri. Besides the
+// transports understood by resolveURI it supports qemu+tls and qemu+ssh, with
+// the pkipath, no_verify, keyfile, socket and proxy parameters of libvirt
+// URIs.
+func dialURI(ctx context.Context, uri string, timeout time.Duration) (net.Conn, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("parsing libvirt URI %s: %w", uri, err)
+	}
+
+	if parsed.Scheme == "qemu+ssh" {
+		return dialSSH(ctx, parsed, timeout)
+	}
+
+	networkType, address, err := resolveURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	if parsed.Scheme == "qemu+tls" {
+		tlsConfig, err := clientTLSConfig(parsed)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+		c, err := tlsDialer.DialContext(ctx, networkType, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to libvirt at %s: %w", address, err)
+		}
+		return c, nil
+	}
+
+	c, err := dialer.DialContext(ctx, networkType, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt at %s: %w", address, err)
+	}
+	return c, nil
+}
+
+// clientTLSConfig builds the TLS configuration for a qemu+tls URI from the
+// libvirt client certificates.
+func clientTLSConfig(parsed *url.URL) (*tls.Config, error) {
+	caPath, certPath, keyPath := defaultCACertPath, defaultClientCertPath, defaultClientKeyPath
+	if pkiPath := parsed.Query().Get("pkipath"); pkiPath != "" {
+		caPath = filepath.Join(pkiPath, "cacert.pem")
+		certPath = filepath.Join(pkiPath, "clientcert.pem")
+		keyPath = filepath.Join(pkiPath, "clientkey.pem")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading libvirt client certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ServerName:   parsed.Hostname(),
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if parsed.Query().Get("no_verify") == "1" {
+		config.InsecureSkipVerify = true //nolint:gosec // explicitly requested in the URI
+		return config, nil
+	}
+
+	caCert, err := os.ReadFile(caPath) //nolint:gosec // path comes from configuration
+	if err != nil {
+		return nil, fmt.Errorf("reading libvirt CA certificate: %w", err)
+	}
+
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in %s", caPath)
+	}
+
+	return config, nil
+}
+
+// sshOptions returns the ssh options that connect to the remote host of a
+// qemu+ssh URI. A positive timeout bounds the ssh connection to the host.
+func sshOptions(parsed *url.URL, timeout time.Duration) ([]string, error) {
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("libvirt URI %s has no host", parsed.String())
+	}
+
+	query := parsed.Query()
+	args := []string{"-T", "-o", "BatchMode=yes"}
+
+	if timeout > 0 {
+		seconds := int((timeout + time.Second - 1) / time.Second)
+		args = append(args, "-o", "ConnectTimeout="+strconv.Itoa(seconds))
+	}
+	if port := parsed.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	if user := parsed.User.Username(); user != "" {
+		args = append(args, "-l", user)
+	}
+	if keyFile := query.Get("keyfile"); keyFile != "" {
+		args = append(args, "-i", keyFile)
+	}
+	if query.Get("no_verify") == "1" {
+		args = append(args, "-o", "StrictHostKeyChecking=no")
+	}
+
+	return args, nil
+}
+
+// sshArgs returns the ssh arguments that forward stdin and stdout to the
+// libvirt daemon on the remote host of a qemu+ssh URI.
+func sshArgs(parsed *url.URL, timeout time.Duration) ([]string, error) {
+	args, err := sshOptions(parsed, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	query := parsed.Query()
+	socket := query.Get("socket")
+	if socket == "" {
+		socket = defaultRemoteSocket
+	}
+	path := parsed.Path
+	if path == "" {
+		path = "/system"
+	}
+
+	netcat := "nc -U " + socket
+	native := "virt-ssh-helper qemu://" + path
+
+	var command string
+	switch query.Get("proxy") {
+	case "netcat":
+		command = netcat
+	case "native":
+		command = native
+	case "", "auto":
+		// Same fallback libvirt itself uses for hosts without the helper
+		command = fmt.Sprintf("sh -c 'if command -v virt-ssh-helper >/dev/null 2>&1; then exec %s; else exec %s; fi'",
+			native, netcat)
+	default:
+		return nil, fmt.Errorf("unsupported ssh proxy mode: %s", query.Get("proxy"))
+	}
+
+	return append(args, "--", parsed.Hostname(), command), nil
+}
+
+// sshTunnelArgs returns the ssh arguments that forward stdin and stdout to
+// address, a host:port as seen from the remote host of a qemu+ssh URI.
+func sshTunnelArgs(parsed *url.URL, timeout time.Duration, address string) ([]string, error) {
+	args, err := sshOptions(parsed, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(args, "-W", address, "--", parsed.Hostname()), nil
+}
+
+// dialSSH connects to a remote libvirt daemon through an ssh process, which
+// is killed when the connection is closed.
+func dialSSH(ctx context.Context, parsed *url.URL, timeout time.Duration) (net.Conn, error) {
+	args, err := sshArgs(parsed, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return startSSH(ctx, parsed.Hostname(), args)
+}
+
+// dialSSHTunnel connects to address on the remote host of a qemu+ssh URI
+// through an ssh process, which is killed when the connection is closed.
+func dialSSHTunnel(ctx context.Context, parsed *url.URL, timeout time.Duration, address string) (net.Conn, error) {
+	args, err := sshTunnelArgs(parsed, timeout, address)
+	if err != nil {
+		return nil, err
+	}
+	return startSSH(ctx, parsed.Hostname(), args)
+}
+
+// startSSH starts ssh with args and returns a connection over its standard
+// streams.
+func startSSH(ctx context.Context, host string, args []string) (net.Conn, error) {
+	// The process must outlive the dial, so it is bound to a context that
+	// is only canceled by the connection
+	procCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	cmd := exec.CommandContext(procCtx, "ssh", args...) //nolint:gosec // arguments come from configuration
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("creating ssh stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("creating ssh stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("starting ssh to %s: %w", host, err)
+	}
+
+	return &commandConn{cmd: cmd, cancel: cancel, stdin: stdin, stdout: stdout, host: host}, nil
+}
+
+// commandConn is a net.Conn over the standard streams of a process.
+type commandConn struct {
+	cmd *exec.Cmd
+	// cancel kills the process
+	cancel context.CancelFunc
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	host   string
+}
+
+// Read implements net.Conn.
+func (c *commandConn) Read(b []byte) (int, error) {
+	return c.stdout.Read(b)
+}
+
+// Write implements net.Conn.
+func (c *commandConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+// Close implements net.Conn.
+func (c *commandConn) Close() error {
+	_ = c.stdin.Close()
+	c.cancel()
+	_ = c.cmd.Wait()
+	return nil
+}
+
+// LocalAddr implements net.Conn.
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr("localhost")
+}
+
+// RemoteAddr implements net.Conn.
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr(c.host)
+}
+
+// SetDeadline implements net.Conn. Deadlines are not supported on process
+// streams.
+func (c *commandConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+// SetReadDeadline implements net.Conn.
+func (c *commandConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline implements net.Conn.
+func (c *commandConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// commandAddr is the address of one end of a commandConn.
+type commandAddr string
+
+// Network implements net.Addr.
+func (a commandAddr) Network() string {
+	return "ssh"
+}
+
+// String implements net.Addr.
+func (a commandAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,121 @@
+package connection
+
+import (
+	"context"
+	"net/url"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		wantArgs []string
+		wantCmd  string
+		timeout  time.Duration
+		wantErr  bool
+	}{
+		{
+			name:     "User, port and key file",
+			uri:      "qemu+ssh://root@hv2:2222/system?keyfile=/etc/libgo/id_ed25519&proxy=netcat",
+			wantArgs: []string{"-p", "2222", "-l", "root", "-i", "/etc/libgo/id_ed25519", "--", "hv2"},
+			wantCmd:  "nc -U /var/run/libvirt/libvirt-sock",
+		},
+		{
+			name:    "Native proxy",
+			uri:     "qemu+ssh://hv2/system?proxy=native",
+			wantCmd: "virt-ssh-helper qemu:///system",
+		},
+		{
+			name:    "Automatic proxy with custom socket",
+			uri:     "qemu+ssh://hv2/system?socket=/run/libvirt/virtqemud-sock",
+			wantCmd: "exec nc -U /run/libvirt/virtqemud-sock",
+		},
+		{
+			name:     "Skip host key verification",
+			uri:      "qemu+ssh://hv2/system?no_verify=1",
+			wantArgs: []string{"StrictHostKeyChecking=no"},
+		},
+		{
+			name:     "Connect timeout in whole seconds",
+			uri:      "qemu+ssh://hv2/system",
+			timeout:  1500 * time.Millisecond,
+			wantArgs: []string{"ConnectTimeout=2"},
+		},
+		{
+			name:    "Missing host",
+			uri:     "qemu+ssh:///system",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown proxy",
+			uri:     "qemu+ssh://hv2/system?proxy=socat",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := url.Parse(tt.uri)
+			require.NoError(t, err)
+
+			args, err := sshArgs(parsed, tt.timeout)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Subset(t, args, tt.wantArgs)
+			assert.Contains(t, args[len(args)-1], tt.wantCmd)
+			assert.Equal(t, "hv2", args[len(args)-2])
+		})
+	}
+}
+
+func TestSSHTunnelArgs(t *testing.T) {
+	parsed, err := url.Parse("qemu+ssh://root@hv2:2222/system?keyfile=/etc/libgo/id_ed25519")
+	require.NoError(t, err)
+
+	args, err := sshTunnelArgs(parsed, 10*time.Second, "127.0.0.1:5900")
+	require.NoError(t, err)
+	assert.Subset(t, args, []string{"-p", "2222", "-l", "root", "-i", "/etc/libgo/id_ed25519", "ConnectTimeout=10"})
+	assert.Equal(t, []string{"-W", "127.0.0.1:5900", "--", "hv2"}, args[len(args)-4:])
+}
+
+func TestCommandConn_Close(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "cat")
+	stdin, err := cmd.StdinPipe()
+	require.NoError(t, err)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	conn := &commandConn{cmd: cmd, cancel: cancel, stdin: stdin, stdout: stdout, host: "hv2"}
+
+	// A blocked read ends when the connection is closed
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		done <- err
+	}()
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("read did not end when the connection was closed")
+	}
+	assert.NotNil(t, cmd.ProcessState)
+}
@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ErrCheckpointNotFound is returned for checkpoints a domain does not have.
+var ErrCheckpointNotFound = fmt.Errorf("checkpoint not found")
+
+// BeginBackup implements Manager.BeginBackup.
+func (m *DomainManager) BeginBackup(ctx context.Context, name string, params vm.BackupParams) (string, error) {
+	var definition string
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		domainXML, state, _, err := m.getDomainInfo(libvirtConn, domain)
+		if err != nil {
+			return err
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainRunning && libvirt.DomainState(state) != libvirt.DomainPaused {
+			return fmt.Errorf("backing up %s: %w", name, ErrDomainNotRunning)
+		}
+
+		for _, disk := range params.Disks {
+			if found := findDisk(domainXML.Devices.Disks, disk.Device); found == nil || found.Device != "disk" {
+				return fmt.Errorf("%w: %s on %s", ErrDiskNotFound, disk.Device, name)
+			}
+		}
+
+		// Restores define a new domain from the persistent definition
+		definition, err = libvirtConn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive|libvirt.DomainXMLSecure)
+		if err != nil {
+			return fmt.Errorf("getting domain XML: %w", err)
+		}
+
+		backupXML := buildBackupXML(params, domainXML.Devices.Disks)
+
+		var checkpointXML libvirt.OptString
+		if params.Checkpoint != "" {
+			checkpointXML = libvirt.OptString{buildCheckpointXML(params, domainXML.Devices.Disks)}
+		}
+
+		if err := libvirtConn.DomainBackupBegin(domain, backupXML, checkpointXML, 0); err != nil {
+			return fmt.Errorf("starting backup: %w", err)
+		}
+
+		m.logger.Info("Started backup",
+			logger.String("name", name),
+			logger.String("incremental", params.Incremental),
+			logger.String("checkpoint", params.Checkpoint),
+			logger.Int("disks", len(params.Disks)))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return definition, nil
+}
+
+// GetBackupJob implements Manager.GetBackupJob. Once the job has ended, the
+// statistics of the last completed job of the domain tell how it ended.
+func (m *DomainManager) GetBackupJob(ctx context.Context, name string) (*vm.BackupJobInfo, error) {
+	var info *vm.BackupJobInfo
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		jobType, params, err := libvirtConn.DomainGetJobStats(domain, 0)
+		if err != nil {
+			return fmt.Errorf("getting job stats: %w", err)
+		}
+
+		if libvirt.DomainJobType(jobType) != libvirt.DomainJobNone {
+			info = parseBackupJobStats(params)
+			info.Active = true
+			return nil
+		}
+
+		jobType, params, err = libvirtConn.DomainGetJobStats(domain, libvirt.DomainJobStatsCompleted)
+		if err != nil {
+			return fmt.Errorf("getting completed job stats: %w", err)
+		}
+
+		info = parseBackupJobStats(params)
+		switch libvirt.DomainJobType(jobType) {
+		case libvirt.DomainJobFailed:
+			if info.Error == "" {
+				info.Error = "backup job failed"
+			}
+		case libvirt.DomainJobCancelled:
+			info.Error = "backup job was canceled"
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// AbortBackup implements Manager.AbortBackup.
+func (m *DomainManager) AbortBackup(ctx context.Context, name string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		if err := libvirtConn.DomainAbortJob(domain); err != nil {
+			return fmt.Errorf("aborting backup: %w", err)
+		}
+
+		m.logger.Info("Aborted backup", logger.String("name", name))
+		return nil
+	})
+}
+
+// ListCheckpoints implements Manager.ListCheckpoints.
+func (m *DomainManager) ListCheckpoints(ctx context.Context, name string) ([]string, error) {
+	var names []string
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		checkpoints, _, err := libvirtConn.DomainListAllCheckpoints(domain, 1, 0)
+		if err != nil {
+			return fmt.Errorf("listing checkpoints: %w", err)
+		}
+
+		names = make([]string, 0, len(checkpoints))
+		for _, checkpoint := range checkpoints {
+			names = append(names, checkpoint.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// DeleteCheckpoint implements Manager.DeleteCheckpoint. The dirty bitmaps of
+// the checkpoint are merged into its parent.
+func (m *DomainManager) DeleteCheckpoint(ctx context.Context, name string, checkpointName string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		checkpoint, err := libvirtConn.DomainCheckpointLookupByName(domain, checkpointName, 0)
+		if err != nil {
+			return fmt.Errorf("%w: %s on %s", ErrCheckpointNotFound, checkpointName, name)
+		}
+
+		if err := libvirtConn.DomainCheckpointDelete(checkpoint, 0); err != nil {
+			return fmt.Errorf("deleting checkpoint %s: %w", checkpointName, err)
+		}
+
+		m.logger.Debug("Deleted checkpoint",
+			logger.String("name", name),
+			logger.String("checkpoint", checkpointName))
+		return nil
+	})
+}
+
+// buildBackupXML builds the XML of a push-mode backup that writes the
+// selected disks to their target files.
+func buildBackupXML(params vm.BackupParams, disks []libvirtDisk) string {
+	targets := make(map[string]vm.BackupDisk, len(params.Disks))
+	for _, disk := range params.Disks {
+		targets[disk.Device] = disk
+	}
+
+	var b strings.Builder
+	b.WriteString("<domainbackup mode='push'>")
+	if params.Incremental != "" {
+		b.WriteString(fmt.Sprintf("\n  <incremental>%s</incremental>", escapeXML(params.Incremental)))
+	}
+	b.WriteString("\n  <disks>")
+
+	for _, disk := range disks {
+		target, ok := targets[disk.Target.Dev]
+		if !ok {
+			if disk.Device == "disk" {
+				b.WriteString(fmt.Sprintf("\n    <disk name='%s' backup='no'/>", escapeXML(disk.Target.Dev)))
+			}
+			continue
+		}
+
+		b.WriteString(fmt.Sprintf("\n    <disk name='%s' backup='yes' type='file'>", escapeXML(target.Device)))
+		b.WriteString(fmt.Sprintf("\n      <target file='%s'/>", escapeXML(target.TargetPath)))
+		b.WriteString(fmt.Sprintf("\n      <driver type='%s'/>", escapeXML(target.TargetFormat)))
+		b.WriteString("\n    </disk>")
+	}
+
+	b.WriteString("\n  </disks>\n</domainbackup>")
+	return b.String()
+}
+
+// buildCheckpointXML builds the XML of the checkpoint created with a backup.
+// Only the backed up disks get a dirty bitmap.
+func buildCheckpointXML(params vm.BackupParams, disks []libvirtDisk) string {
+	selected := make(map[string]bool, len(params.Disks))
+	for _, disk := range params.Disks {
+		selected[disk.Device] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("<domaincheckpoint>")
+	b.WriteString(fmt.Sprintf("\n  <name>%s</name>", escapeXML(params.Checkpoint)))
+	b.WriteString("\n  <disks>")
+
+	for _, disk := range disks {
+		if disk.Device != "disk" {
+			continue
+		}
+
+		mode := "no"
+		if selected[disk.Target.Dev] {
+			mode = "bitmap"
+		}
+		b.WriteString(fmt.Sprintf("\n    <disk name='%s' checkpoint='%s'/>", escapeXML(disk.Target.Dev), mode))
+	}
+
+	b.WriteString("\n  </disks>\n</domaincheckpoint>")
+	return b.String()
+}
+
+// parseBackupJobStats converts domain job statistics into backup job info.
+func parseBackupJobStats(params []libvirt.TypedParam) *vm.BackupJobInfo {
+	info := &vm.BackupJobInfo{}
+
+	for _, param := range params {
+		switch param.Field {
+		case libvirt.DomainJobDataTotal:
+			info.DataTotal, _ = param.Value.I.(uint64)
+		case libvirt.DomainJobDataProcessed:
+			info.DataProcessed, _ = param.Value.I.(uint64)
+		case libvirt.DomainJobErrmsg:
+			info.Error, _ = param.Value.I.(string)
+		}
+	}
+
+	return info
+}
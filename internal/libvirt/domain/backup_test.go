@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+)
+
+func TestBuildBackupXML(t *testing.T) {
+	var domainXML libvirtDomain
+	require.NoError(t, xml.Unmarshal([]byte(externalDomainXML), &domainXML))
+
+	params := vm.BackupParams{
+		Disks: []vm.BackupDisk{
+			{Device: "vda", TargetPath: "/backups/scratch/job-1/vda.qcow2", TargetFormat: "qcow2"},
+		},
+		Incremental: "libgo-backup-1",
+		Checkpoint:  "libgo-backup-2",
+	}
+
+	assert.Equal(t, `<domainbackup mode='push'>
+  <incremental>libgo-backup-1</incremental>
+  <disks>
+    <disk name='vda' backup='yes' type='file'>
+      <target file='/backups/scratch/job-1/vda.qcow2'/>
+      <driver type='qcow2'/>
+    </disk>
+    <disk name='vdb' backup='no'/>
+  </disks>
+</domainbackup>`, buildBackupXML(params, domainXML.Devices.Disks))
+
+	assert.Equal(t, `<domaincheckpoint>
+  <name>libgo-backup-2</name>
+  <disks>
+    <disk name='vda' checkpoint='bitmap'/>
+    <disk name='vdb' checkpoint='no'/>
+  </disks>
+</domaincheckpoint>`, buildCheckpointXML(params, domainXML.Devices.Disks))
+}
+
+func TestParseBackupJobStats(t *testing.T) {
+	info := parseBackupJobStats([]libvirt.TypedParam{
+		{Field: libvirt.DomainJobDataTotal, Value: *libvirt.NewTypedParamValueUllong(1000)},
+		{Field: libvirt.DomainJobDataProcessed, Value: *libvirt.NewTypedParamValueUllong(250)},
+		{Field: libvirt.DomainJobErrmsg, Value: *libvirt.NewTypedParamValueString("No space left on device")},
+	})
+
+	assert.Equal(t, &vm.BackupJobInfo{
+		DataTotal:     1000,
+		DataProcessed: 250,
+		Error:         "No space left on device",
+	}, info)
+}
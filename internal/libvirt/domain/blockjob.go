@@ -0,0 +1,268 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+var (
+	// ErrDiskNotFound is returned when a domain has no disk with a target device.
+	ErrDiskNotFound = fmt.Errorf("disk not found")
+	// ErrBlockJobNotFound is returned when a disk has no running block job.
+	ErrBlockJobNotFound = fmt.Errorf("block job not found")
+)
+
+// BlockCommit implements Manager.BlockCommit.
+func (m *DomainManager) BlockCommit(ctx context.Context, name string, params vm.BlockCommitParams) (*vm.BlockJob, error) {
+	var job *vm.BlockJob
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		disk, err := m.runningDomainDisk(libvirtConn, domain, name, params.Device)
+		if err != nil {
+			return err
+		}
+
+		// Committing the active image needs a pivot once the data is merged
+		var flags libvirt.DomainBlockCommitFlags
+		var base, top libvirt.OptString
+		if params.Top == "" || params.Top == diskImagePath(libvirtConn, *disk) {
+			flags |= libvirt.DomainBlockCommitActive
+		} else {
+			top = libvirt.OptString{params.Top}
+		}
+		if params.Base == "" {
+			flags |= libvirt.DomainBlockCommitShallow
+		} else {
+			base = libvirt.OptString{params.Base}
+		}
+
+		if err := libvirtConn.DomainBlockCommit(domain, params.Device, base, top, params.Bandwidth, flags); err != nil {
+			return fmt.Errorf("starting block commit on %s: %w", params.Device, err)
+		}
+
+		job, err = getBlockJob(libvirtConn, domain, params.Device)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Started block commit",
+		logger.String("name", name),
+		logger.String("device", params.Device),
+		logger.String("type", job.Type))
+
+	return job, nil
+}
+
+// BlockPull implements Manager.BlockPull.
+func (m *DomainManager) BlockPull(ctx context.Context, name string, params vm.BlockPullParams) (*vm.BlockJob, error) {
+	var job *vm.BlockJob
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		if _, err := m.runningDomainDisk(libvirtConn, domain, name, params.Device); err != nil {
+			return err
+		}
+
+		var err error
+		if params.Base == "" {
+			err = libvirtConn.DomainBlockPull(domain, params.Device, params.Bandwidth, 0)
+		} else {
+			err = libvirtConn.DomainBlockRebase(domain, params.Device, libvirt.OptString{params.Base}, params.Bandwidth, 0)
+		}
+		if err != nil {
+			return fmt.Errorf("starting block pull on %s: %w", params.Device, err)
+		}
+
+		job, err = getBlockJob(libvirtConn, domain, params.Device)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Started block pull",
+		logger.String("name", name),
+		logger.String("device", params.Device),
+		logger.String("base", params.Base))
+
+	return job, nil
+}
+
+// ListBlockJobs implements Manager.ListBlockJobs.
+func (m *DomainManager) ListBlockJobs(ctx context.Context, name string) ([]vm.BlockJob, error) {
+	jobs := make([]vm.BlockJob, 0)
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		domainXML, _, _, err := m.getDomainInfo(libvirtConn, domain)
+		if err != nil {
+			return err
+		}
+
+		for _, disk := range domainXML.Devices.Disks {
+			job, err := getBlockJob(libvirtConn, domain, disk.Target.Dev)
+			if err != nil {
+				continue
+			}
+			jobs = append(jobs, *job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// CompleteBlockJob implements Manager.CompleteBlockJob.
+func (m *DomainManager) CompleteBlockJob(ctx context.Context, name string, device string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		job, err := getBlockJob(libvirtConn, domain, device)
+		if err != nil {
+			return err
+		}
+
+		if job.Type != blockJobTypeName(libvirt.DomainBlockJobTypeActiveCommit) {
+			return waitForBlockJobDone(ctx, libvirtConn, domain, device)
+		}
+
+		if err := waitForBlockJobReady(ctx, libvirtConn, domain, device); err != nil {
+			return err
+		}
+		if err := libvirtConn.DomainBlockJobAbort(domain, device, libvirt.DomainBlockJobAbortPivot); err != nil {
+			return fmt.Errorf("pivoting %s to the committed image: %w", device, err)
+		}
+
+		m.logger.Info("Pivoted disk after block commit",
+			logger.String("name", name),
+			logger.String("device", device))
+		return nil
+	})
+}
+
+// AbortBlockJob implements Manager.AbortBlockJob.
+func (m *DomainManager) AbortBlockJob(ctx context.Context, name string, device string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		if _, err := getBlockJob(libvirtConn, domain, device); err != nil {
+			return err
+		}
+
+		if err := libvirtConn.DomainBlockJobAbort(domain, device, 0); err != nil {
+			return fmt.Errorf("aborting block job on %s: %w", device, err)
+		}
+
+		m.logger.Info("Aborted block job",
+			logger.String("name", name),
+			logger.String("device", device))
+		return nil
+	})
+}
+
+// runningDomainDisk returns a disk of a domain that can run block jobs.
+func (m *DomainManager) runningDomainDisk(libvirtConn *libvirt.Libvirt, domain libvirt.Domain, name, device string) (*libvirtDisk, error) {
+	domainXML, state, _, err := m.getDomainInfo(libvirtConn, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if libvirt.DomainState(state) != libvirt.DomainRunning && libvirt.DomainState(state) != libvirt.DomainPaused {
+		return nil, fmt.Errorf("running block job on %s: %w", name, ErrDomainNotRunning)
+	}
+
+	disk := findDisk(domainXML.Devices.Disks, device)
+	if disk == nil || disk.Device != "disk" {
+		return nil, fmt.Errorf("%w: %s on %s", ErrDiskNotFound, device, name)
+	}
+
+	return disk, nil
+}
+
+// commitBackingImage merges an image from the middle of a disk's backing
+// chain into the image below it. Libvirt points the image above at the lower
+// image once the job completes.
+func commitBackingImage(ctx context.Context, libvirtConn *libvirt.Libvirt, domain libvirt.Domain, device, top string) error {
+	err := libvirtConn.DomainBlockCommit(domain, device, nil, libvirt.OptString{top}, 0, libvirt.DomainBlockCommitShallow)
+	if err != nil {
+		return fmt.Errorf("starting block commit: %w", err)
+	}
+
+	return waitForBlockJobDone(ctx, libvirtConn, domain, device)
+}
+
+// waitForBlockJobDone waits until the block job of a disk has completed.
+func waitForBlockJobDone(ctx context.Context, libvirtConn *libvirt.Libvirt, domain libvirt.Domain, device string) error {
+	ticker := time.NewTicker(blockJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		found, _, _, _, _, err := libvirtConn.DomainGetBlockJobInfo(domain, device, 0)
+		if err != nil {
+			return fmt.Errorf("getting block job info: %w", err)
+		}
+
+		if found == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for block job on %s: %w", device, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// getBlockJob returns the running block job of a disk.
+func getBlockJob(libvirtConn *libvirt.Libvirt, domain libvirt.Domain, device string) (*vm.BlockJob, error) {
+	found, jobType, bandwidth, cur, end, err := libvirtConn.DomainGetBlockJobInfo(domain, device, 0)
+	if err != nil {
+		return nil, fmt.Errorf("getting block job info for %s: %w", device, err)
+	}
+
+	if found == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrBlockJobNotFound, device)
+	}
+
+	return newBlockJob(device, libvirt.DomainBlockJobType(jobType), bandwidth, cur, end), nil
+}
+
+// newBlockJob converts block job info to the block job model.
+func newBlockJob(device string, jobType libvirt.DomainBlockJobType, bandwidth, cur, end uint64) *vm.BlockJob {
+	job := &vm.BlockJob{
+		Device:    device,
+		Type:      blockJobTypeName(jobType),
+		Current:   cur,
+		End:       end,
+		Bandwidth: bandwidth,
+	}
+
+	if end > 0 {
+		job.Progress = int(min(cur, end) * 100 / end) //nolint:gosec
+	}
+
+	return job
+}
+
+// blockJobTypeName returns the name of a block job type.
+func blockJobTypeName(jobType libvirt.DomainBlockJobType) string {
+	switch jobType {
+	case libvirt.DomainBlockJobTypePull:
+		return "pull"
+	case libvirt.DomainBlockJobTypeCopy:
+		return "copy"
+	case libvirt.DomainBlockJobTypeCommit:
+		return "commit"
+	case libvirt.DomainBlockJobTypeActiveCommit:
+		return "active-commit"
+	case libvirt.DomainBlockJobTypeBackup:
+		return "backup"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBlockJob(t *testing.T) {
+	job := newBlockJob("vda", libvirt.DomainBlockJobTypeActiveCommit, 1<<20, 3<<30, 4<<30)
+
+	assert.Equal(t, "vda", job.Device)
+	assert.Equal(t, "active-commit", job.Type)
+	assert.Equal(t, uint64(1<<20), job.Bandwidth)
+	assert.Equal(t, 75, job.Progress)
+
+	// Jobs that have not sized their work yet report no progress
+	job = newBlockJob("vdb", libvirt.DomainBlockJobTypePull, 0, 0, 0)
+	assert.Equal(t, "pull", job.Type)
+	assert.Equal(t, 0, job.Progress)
+
+	// Ready active commits keep copying new writes past the end
+	job = newBlockJob("vda", libvirt.DomainBlockJobTypeActiveCommit, 0, 5<<30, 4<<30)
+	assert.Equal(t, 100, job.Progress)
+}
+
+func TestBlockJobTypeName(t *testing.T) {
+	assert.Equal(t, "commit", blockJobTypeName(libvirt.DomainBlockJobTypeCommit))
+	assert.Equal(t, "copy", blockJobTypeName(libvirt.DomainBlockJobTypeCopy))
+	assert.Equal(t, "backup", blockJobTypeName(libvirt.DomainBlockJobTypeBackup))
+	assert.Equal(t, "unknown", blockJobTypeName(libvirt.DomainBlockJobTypeUnknown))
+}
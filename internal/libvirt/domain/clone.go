@@ -0,0 +1,291 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+// CloneSpec describes how the definition of a domain is copied.
+type CloneSpec struct {
+	// Disks maps the sources of disks, as returned by DiskSourceKey, to the
+	// disks of the clone. Disks that are not listed keep their source, e.g.
+	// shared read-only images.
+	Disks map[string]CloneDisk
+	// Name is the name of the new domain
+	Name string
+	// UUID is the UUID of the new domain
+	UUID string
+	// CloudInitISO replaces the cloud-init ISO of the source, if any
+	CloudInitISO string
+}
+
+// CloneDisk is a disk of a cloned domain.
+type CloneDisk struct {
+	Source vm.DiskSource
+	Format string
+}
+
+// DiskSourceKey identifies the source of a disk: the file or block device,
+// the image of a network disk, or the pool and volume of a volume disk.
+func DiskSourceKey(disk vm.DiskInfo) string {
+	if disk.Path != "" {
+		return disk.Path
+	}
+	if disk.StoragePool != "" && disk.VolumeName != "" {
+		return disk.StoragePool + "/" + disk.VolumeName
+	}
+	return ""
+}
+
+// DefineClone implements Manager.DefineClone.
+func (m *DomainManager) DefineClone(ctx context.Context, sourceName string, spec CloneSpec) (*vm.VM, error) {
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	// Look up source domain
+	source, err := libvirtConn.DomainLookupByName(sourceName)
+	if err != nil {
+		return nil, fmt.Errorf("looking up domain %s: %w", sourceName, ErrDomainNotFound)
+	}
+
+	// Check if clone already exists
+	if _, err = libvirtConn.DomainLookupByName(spec.Name); err == nil {
+		return nil, fmt.Errorf("creating domain %s: %w", spec.Name, ErrDomainExists)
+	}
+
+	// Copy the persistent definition rather than the live one
+	sourceXML, err := libvirtConn.DomainGetXMLDesc(source, libvirt.DomainXMLInactive|libvirt.DomainXMLSecure)
+	if err != nil {
+		return nil, fmt.Errorf("getting domain XML: %w", err)
+	}
+
+	cloneXML, err := buildCloneXML(sourceXML, spec)
+	if err != nil {
+		return nil, fmt.Errorf("building clone XML: %w", err)
+	}
+
+	domain, err := libvirtConn.DomainDefineXML(cloneXML)
+	if err != nil {
+		return nil, fmt.Errorf("defining domain from XML: %w", err)
+	}
+
+	m.logger.Info("Defined cloned domain",
+		logger.String("source", sourceName),
+		logger.String("name", spec.Name))
+
+	return m.domainToVM(libvirtConn, domain)
+}
+
+// DefineFromDefinition implements Manager.DefineFromDefinition.
+func (m *DomainManager) DefineFromDefinition(ctx context.Context, sourceXML string, spec CloneSpec) (*vm.VM, error) {
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	if _, err = libvirtConn.DomainLookupByName(spec.Name); err == nil {
+		return nil, fmt.Errorf("creating domain %s: %w", spec.Name, ErrDomainExists)
+	}
+
+	cloneXML, err := buildCloneXML(sourceXML, spec)
+	if err != nil {
+		return nil, fmt.Errorf("building domain XML: %w", err)
+	}
+
+	domain, err := libvirtConn.DomainDefineXML(cloneXML)
+	if err != nil {
+		return nil, fmt.Errorf("defining domain from XML: %w", err)
+	}
+
+	m.logger.Info("Defined domain from saved definition",
+		logger.String("name", spec.Name))
+
+	return m.domainToVM(libvirtConn, domain)
+}
+
+// buildCloneXML rewrites a domain definition for a clone: it sets the new
+// name and UUID, generates new MAC addresses and points disks at their copies.
+func buildCloneXML(sourceXML string, spec CloneSpec) (string, error) {
+	doc, err := xmlutils.LoadXMLDocumentFromString(sourceXML)
+	if err != nil {
+		return "", err
+	}
+
+	nameElement := xmlutils.FindElement(doc, "/domain/name")
+	if nameElement == nil {
+		return "", fmt.Errorf("domain name element not found in XML")
+	}
+	nameElement.SetText(spec.Name)
+
+	uuidElement := xmlutils.FindElement(doc, "/domain/uuid")
+	if uuidElement == nil {
+		uuidElement = doc.Root().CreateElement("uuid")
+	}
+	uuidElement.SetText(spec.UUID)
+
+	// NVRAM holds per-VM firmware variables; libvirt creates a fresh copy
+	// from the template when the element is missing
+	if nvram := xmlutils.FindElement(doc, "/domain/os/nvram"); nvram != nil {
+		nvram.Parent().RemoveChild(nvram)
+	}
+
+	for _, mac := range xmlutils.FindElements(doc, "/domain/devices/interface/mac") {
+		address, err := vm.GenerateRandomMAC()
+		if err != nil {
+			return "", fmt.Errorf("generating MAC address: %w", err)
+		}
+		xmlutils.SetElementAttribute(mac, "address", address)
+	}
+
+	for _, disk := range xmlutils.FindElements(doc, "/domain/devices/disk") {
+		rewriteCloneDisk(disk, spec)
+	}
+
+	return xmlutils.XMLToString(doc), nil
+}
+
+// rewriteCloneDisk points a disk element at its copy.
+func rewriteCloneDisk(disk *etree.Element, spec CloneSpec) {
+	source := disk.SelectElement("source")
+	if source == nil {
+		return
+	}
+
+	if xmlutils.GetElementAttribute(disk, "device") == "cdrom" {
+		sourceAttr := "file"
+		path := xmlutils.GetElementAttribute(source, sourceAttr)
+		if path == "" {
+			sourceAttr = "dev"
+			path = xmlutils.GetElementAttribute(source, sourceAttr)
+		}
+		if spec.CloudInitISO != "" && isCloudInitISO(path) {
+			xmlutils.SetElementAttribute(source, sourceAttr, spec.CloudInitISO)
+		}
+		return
+	}
+
+	cloneDisk, ok := spec.Disks[diskElementSourceKey(source)]
+	if !ok {
+		return
+	}
+
+	setDiskSource(disk, cloneDisk.Source)
+
+	if driver := disk.SelectElement("driver"); driver != nil && cloneDisk.Format != "" {
+		xmlutils.SetElementAttribute(driver, "type", cloneDisk.Format)
+	}
+
+	// Let libvirt probe the backing chain of the new volume
+	if backingStore := disk.SelectElement("backingStore"); backingStore != nil {
+		disk.RemoveChild(backingStore)
+	}
+}
+
+// diskElementSourceKey returns the key DiskSourceKey returns for the source
+// element of a disk.
+func diskElementSourceKey(source *etree.Element) string {
+	for _, attr := range []string{"file", "dev", "name"} {
+		if value := xmlutils.GetElementAttribute(source, attr); value != "" {
+			return value
+		}
+	}
+
+	pool := xmlutils.GetElementAttribute(source, "pool")
+	volume := xmlutils.GetElementAttribute(source, "volume")
+	if pool != "" && volume != "" {
+		return pool + "/" + volume
+	}
+	return ""
+}
+
+// setDiskSource replaces the source of a disk element, with the
+// credentials and encryption that belong to it, as the domain template
+// renders them.
+func setDiskSource(disk *etree.Element, diskSource vm.DiskSource) {
+	for _, tag := range []string{"source", "auth", "encryption"} {
+		for _, element := range disk.SelectElements(tag) {
+			disk.RemoveChild(element)
+		}
+	}
+
+	diskType := diskSource.Type
+	if diskType == "" {
+		diskType = vm.DiskTypeFile
+	}
+	disk.CreateAttr("type", string(diskType))
+
+	// Child elements keep their order, so the source goes before the target
+	source := etree.NewElement("source")
+	switch diskType {
+	case vm.DiskTypeBlock:
+		source.CreateAttr("dev", diskSource.Path)
+	case vm.DiskTypeNetwork:
+		source.CreateAttr("protocol", diskSource.Protocol)
+		source.CreateAttr("name", diskSource.Name)
+		for _, host := range diskSource.Hosts {
+			hostElement := source.CreateElement("host")
+			hostElement.CreateAttr("name", host.Name)
+			if host.Port != 0 {
+				hostElement.CreateAttr("port", strconv.Itoa(host.Port))
+			}
+		}
+	default:
+		source.CreateAttr("file", diskSource.Path)
+	}
+	insertBeforeTarget(disk, source)
+
+	if auth := diskSource.Auth; auth != nil && diskType == vm.DiskTypeNetwork {
+		authElement := etree.NewElement("auth")
+		authElement.CreateAttr("username", auth.Username)
+		secret := authElement.CreateElement("secret")
+		secret.CreateAttr("type", auth.SecretType)
+		if auth.SecretUUID != "" {
+			secret.CreateAttr("uuid", auth.SecretUUID)
+		} else {
+			secret.CreateAttr("usage", auth.SecretUsage)
+		}
+		insertBeforeTarget(disk, authElement)
+	}
+
+	if encryption := diskSource.Encryption; encryption != nil {
+		encryptionElement := etree.NewElement("encryption")
+		encryptionElement.CreateAttr("format", encryption.Format)
+		secret := encryptionElement.CreateElement("secret")
+		secret.CreateAttr("type", "passphrase")
+		secret.CreateAttr("uuid", encryption.SecretUUID)
+		insertBeforeTarget(disk, encryptionElement)
+	}
+}
+
+// insertBeforeTarget adds a child element to a disk element ahead of its
+// target, or at the end if the disk has no target.
+func insertBeforeTarget(disk *etree.Element, child *etree.Element) {
+	if target := disk.SelectElement("target"); target != nil {
+		disk.InsertChildAt(target.Index(), child)
+		return
+	}
+	disk.AddChild(child)
+}
+
+// isCloudInitISO checks if a path is a cloud-init ISO created for a VM.
+func isCloudInitISO(path string) bool {
+	return strings.HasSuffix(path, "-cloudinit.iso")
+}
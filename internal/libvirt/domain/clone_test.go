@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+const cloneSourceXML = `<domain type='kvm'>
+  <name>golden</name>
+  <uuid>4dea22b3-1d52-d8f3-2516-782e98ab3fa0</uuid>
+  <os>
+    <type arch='x86_64' machine='q35'>hvm</type>
+    <nvram>/var/lib/libvirt/qemu/nvram/golden_VARS.fd</nvram>
+  </os>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='raw'/>
+      <source file='/var/lib/libvirt/images/golden-disk-0'/>
+      <backingStore/>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <driver name='qemu' type='raw'/>
+      <source file='/var/lib/libvirt/cloud-init/golden-cloudinit.iso'/>
+      <target dev='sdb' bus='sata'/>
+      <readonly/>
+    </disk>
+    <interface type='network'>
+      <mac address='52:54:00:11:22:33'/>
+      <source network='default'/>
+      <model type='virtio'/>
+    </interface>
+  </devices>
+</domain>`
+
+func TestBuildCloneXML(t *testing.T) {
+	spec := CloneSpec{
+		Name: "clone-1",
+		UUID: "0b3c1e0e-8f5e-4c4b-9d0e-6a1f2b3c4d5e",
+		Disks: map[string]CloneDisk{
+			"/var/lib/libvirt/images/golden-disk-0": {
+				Source: vm.DiskSource{Type: vm.DiskTypeFile, Path: "/var/lib/libvirt/images/clone-1-disk-0"},
+				Format: "qcow2",
+			},
+		},
+		CloudInitISO: "/var/lib/libvirt/cloud-init/clone-1-cloudinit.iso",
+	}
+
+	cloneXML, err := buildCloneXML(cloneSourceXML, spec)
+	require.NoError(t, err)
+
+	doc, err := xmlutils.LoadXMLDocumentFromString(cloneXML)
+	require.NoError(t, err)
+
+	assert.Equal(t, "clone-1", xmlutils.FindElement(doc, "/domain/name").Text())
+	assert.Equal(t, spec.UUID, xmlutils.FindElement(doc, "/domain/uuid").Text())
+	assert.Nil(t, xmlutils.FindElement(doc, "/domain/os/nvram"))
+
+	mac := xmlutils.GetElementAttribute(xmlutils.FindElement(doc, "/domain/devices/interface/mac"), "address")
+	assert.NotEqual(t, "52:54:00:11:22:33", mac)
+	assert.True(t, strings.HasPrefix(mac, "52:54:00:"))
+
+	disks := xmlutils.FindElements(doc, "/domain/devices/disk")
+	require.Len(t, disks, 2)
+	assert.Equal(t, "file", xmlutils.GetElementAttribute(disks[0], "type"))
+	assert.Equal(t, "/var/lib/libvirt/images/clone-1-disk-0", xmlutils.GetElementAttribute(disks[0].SelectElement("source"), "file"))
+	assert.Equal(t, "qcow2", xmlutils.GetElementAttribute(disks[0].SelectElement("driver"), "type"))
+	assert.Nil(t, disks[0].SelectElement("backingStore"))
+	assert.Equal(t, spec.CloudInitISO, xmlutils.GetElementAttribute(disks[1].SelectElement("source"), "file"))
+}
+
+func TestBuildCloneXML_DiskTypes(t *testing.T) {
+	sourceXML := `<domain type='kvm'>
+  <name>golden</name>
+  <devices>
+    <disk type='network' device='disk'>
+      <driver name='qemu' type='raw'/>
+      <source protocol='rbd' name='rbd/golden-disk-0'>
+        <host name='mon1' port='6789'/>
+      </source>
+      <auth username='libvirt'>
+        <secret type='ceph' uuid='2ec115d7-3a88-3ceb-bc12-0ac909a6fd87'/>
+      </auth>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <disk type='volume' device='disk'>
+      <driver name='qemu' type='qcow2'/>
+      <source pool='default' volume='golden-data'/>
+      <target dev='vdb' bus='virtio'/>
+    </disk>
+  </devices>
+</domain>`
+
+	spec := CloneSpec{
+		Name: "clone-1",
+		UUID: "0b3c1e0e-8f5e-4c4b-9d0e-6a1f2b3c4d5e",
+		Disks: map[string]CloneDisk{
+			DiskSourceKey(vm.DiskInfo{Path: "rbd/golden-disk-0"}): {
+				Source: vm.DiskSource{
+					Type:     vm.DiskTypeNetwork,
+					Protocol: "rbd",
+					Name:     "rbd/clone-1-disk-0",
+					Hosts:    []vm.DiskHost{{Name: "mon1", Port: 6789}, {Name: "mon2"}},
+					Auth:     &vm.DiskAuth{Username: "libvirt", SecretType: "ceph", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+				},
+				Format: "raw",
+			},
+			DiskSourceKey(vm.DiskInfo{StoragePool: "default", VolumeName: "golden-data"}): {
+				Source: vm.DiskSource{Type: vm.DiskTypeBlock, Path: "/dev/vg0/clone-1-disk-1"},
+				Format: "raw",
+			},
+		},
+	}
+
+	cloneXML, err := buildCloneXML(sourceXML, spec)
+	require.NoError(t, err)
+
+	doc, err := xmlutils.LoadXMLDocumentFromString(cloneXML)
+	require.NoError(t, err)
+
+	disks := xmlutils.FindElements(doc, "/domain/devices/disk")
+	require.Len(t, disks, 2)
+
+	// The RBD image of the clone replaces the one of the source
+	assert.Equal(t, "network", xmlutils.GetElementAttribute(disks[0], "type"))
+	source := disks[0].SelectElement("source")
+	assert.Equal(t, "rbd", xmlutils.GetElementAttribute(source, "protocol"))
+	assert.Equal(t, "rbd/clone-1-disk-0", xmlutils.GetElementAttribute(source, "name"))
+	assert.Len(t, source.SelectElements("host"), 2)
+	assert.Len(t, disks[0].SelectElements("auth"), 1)
+	assert.Equal(t, "libvirt", xmlutils.GetElementAttribute(disks[0].SelectElement("auth"), "username"))
+	assert.Less(t, source.Index(), disks[0].SelectElement("target").Index())
+
+	// Volume disks get the source of the copy
+	assert.Equal(t, "block", xmlutils.GetElementAttribute(disks[1], "type"))
+	source = disks[1].SelectElement("source")
+	assert.Equal(t, "/dev/vg0/clone-1-disk-1", xmlutils.GetElementAttribute(source, "dev"))
+	assert.Empty(t, xmlutils.GetElementAttribute(source, "pool"))
+	assert.Equal(t, "raw", xmlutils.GetElementAttribute(disks[1].SelectElement("driver"), "type"))
+}
+
+func TestBuildOverlaySnapshotXML(t *testing.T) {
+	var disks []libvirtDisk
+
+	disk := libvirtDisk{Device: "disk"}
+	disk.Source.File = "/var/lib/libvirt/images/web-disk-0"
+	disk.Target.Dev = "vda"
+	disks = append(disks, disk)
+
+	cdrom := libvirtDisk{Device: "cdrom", ReadOnly: &struct{}{}}
+	cdrom.Source.File = "/var/lib/libvirt/cloud-init/web-cloudinit.iso"
+	cdrom.Target.Dev = "sdb"
+	disks = append(disks, cdrom)
+
+	now := time.Unix(1700000000, 0)
+	overlays := planDiskOverlays(disks, now)
+
+	require.Len(t, overlays, 1)
+	assert.Equal(t, "vda", overlays[0].Device)
+	assert.Equal(t, "/var/lib/libvirt/images/web-disk-0", overlays[0].BasePath)
+	assert.Equal(t, "/var/lib/libvirt/images/web-disk-0.overlay-1700000000000000000", overlays[0].OverlayPath)
+
+	snapshotXML := buildOverlaySnapshotXML(disks, overlays, now)
+
+	assert.Contains(t, snapshotXML, "<disk name='vda' snapshot='external'>")
+	assert.Contains(t, snapshotXML, "<source file='"+overlays[0].OverlayPath+"'/>")
+	assert.Contains(t, snapshotXML, "<disk name='sdb' snapshot='no'/>")
+}
@@ -0,0 +1,369 @@
+package domain
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// Console errors.
+var (
+	ErrDomainNotRunning   = fmt.Errorf("domain not running")
+	ErrConsoleUnavailable = fmt.Errorf("console not available")
+	ErrGraphicsNotFound   = fmt.Errorf("graphics device not found")
+)
+
+const (
+	// consoleTypePty is the character device type backed by a host pseudo terminal.
+	consoleTypePty = "pty"
+	// procDomainOpenConsole is libvirt's REMOTE_PROC_DOMAIN_OPEN_CONSOLE,
+	// whose stream carries the console input.
+	procDomainOpenConsole = 201
+	// consoleResizeTimeout is how long the guest agent may take to resize
+	// the guest terminal, in seconds.
+	consoleResizeTimeout = 5
+)
+
+// libvirtConsoleDevices is a struct to parse the character and graphics
+// devices of a live domain XML.
+type libvirtConsoleDevices struct {
+	Devices struct {
+		Consoles []libvirtCharDevice `xml:"console"`
+		Serials  []libvirtCharDevice `xml:"serial"`
+		Graphics []libvirtGraphics   `xml:"graphics"`
+	} `xml:"devices"`
+}
+
+// libvirtCharDevice represents a serial or console device in libvirt domain XML.
+type libvirtCharDevice struct {
+	Source struct {
+		Path string `xml:"path,attr"`
+	} `xml:"source"`
+	Alias struct {
+		Name string `xml:"name,attr"`
+	} `xml:"alias"`
+	Target struct {
+		Type string `xml:"type,attr"`
+		Port string `xml:"port,attr"`
+	} `xml:"target"`
+	Type string `xml:"type,attr"`
+	TTY  string `xml:"tty,attr"`
+}
+
+// libvirtGraphics represents a graphics device in libvirt domain XML.
+type libvirtGraphics struct {
+	Listens []struct {
+		Type    string `xml:"type,attr"`
+		Address string `xml:"address,attr"`
+	} `xml:"listen"`
+	Type    string `xml:"type,attr"`
+	Listen  string `xml:"listen,attr"`
+	Port    int    `xml:"port,attr"`
+	TLSPort int    `xml:"tlsPort,attr"`
+}
+
+// domainConsole implements vm.ConsoleStream on top of a libvirt console stream.
+// Output is received through DomainOpenConsole and input is sent on the same
+// stream, so consoles of domains on remote hosts work the same way.
+type domainConsole struct {
+	sender  connection.StreamSender
+	conn    connection.Connection
+	reader  *io.PipeReader
+	manager *DomainManager
+	name    string
+	// guestTTY is the guest terminal of the console, resized through the
+	// guest agent
+	guestTTY string
+	once     sync.Once
+}
+
+// OpenConsole implements Manager.OpenConsole.
+func (m *DomainManager) OpenConsole(ctx context.Context, name string, opts vm.ConsoleOptions) (vm.ConsoleStream, error) {
+	// The console stream blocks its connection for the lifetime of the
+	// session, so it is closed rather than returned to the pool.
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+
+	console, err := m.openConsole(conn, name, opts)
+	if err != nil {
+		m.closeConsoleConnection(conn)
+		return nil, err
+	}
+
+	return console, nil
+}
+
+// openConsole resolves the console device and starts streaming its output.
+func (m *DomainManager) openConsole(conn connection.Connection, name string, opts vm.ConsoleOptions) (*domainConsole, error) {
+	libvirtConn := conn.GetLibvirtConnection()
+
+	// Look up domain
+	domain, err := libvirtConn.DomainLookupByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up domain %s: %w", name, ErrDomainNotFound)
+	}
+
+	state, _, _, _, _, err := libvirtConn.DomainGetInfo(domain) //nolint:dogsled
+	if err != nil {
+		return nil, fmt.Errorf("getting domain info: %w", err)
+	}
+
+	if libvirt.DomainState(state) != libvirt.DomainRunning && libvirt.DomainState(state) != libvirt.DomainPaused {
+		return nil, fmt.Errorf("opening console of %s: %w", name, ErrDomainNotRunning)
+	}
+
+	devices, err := getLiveDevices(libvirtConn, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := findConsoleDevice(devices, opts.Device)
+	if err != nil {
+		return nil, fmt.Errorf("opening console of %s: %w", name, err)
+	}
+
+	sender, ok := conn.(connection.StreamSender)
+	if !ok {
+		return nil, fmt.Errorf("opening console of %s: %w: connection cannot send console input", name, ErrConsoleUnavailable)
+	}
+
+	var devName libvirt.OptString
+	if opts.Device != "" {
+		devName = libvirt.OptString{opts.Device}
+	}
+
+	var flags uint32
+	if opts.Force {
+		flags |= uint32(libvirt.DomainConsoleForce)
+	}
+
+	reader, writer := io.Pipe()
+	console := &domainConsole{
+		sender:   sender,
+		conn:     conn,
+		reader:   reader,
+		manager:  m,
+		name:     name,
+		guestTTY: guestConsoleTTY(device),
+	}
+
+	go func() {
+		// DomainOpenConsole blocks until the stream ends
+		err := libvirtConn.DomainOpenConsole(domain, devName, writer, flags)
+		if err == nil {
+			err = io.EOF
+		}
+		writer.CloseWithError(err)
+	}()
+
+	m.logger.Info("Opened domain console",
+		logger.String("name", name),
+		logger.String("device", device.Source.Path))
+
+	return console, nil
+}
+
+// GetGraphics implements Manager.GetGraphics.
+func (m *DomainManager) GetGraphics(ctx context.Context, name string) ([]vm.GraphicsInfo, error) {
+	var result []vm.GraphicsInfo
+	host := connection.HostAddressOf(ctx, m.connManager)
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		devices, err := getLiveDevices(libvirtConn, domain)
+		if err != nil {
+			return err
+		}
+
+		for _, graphics := range devices.Devices.Graphics {
+			// Ports are only allocated while the domain is running
+			if graphics.Port <= 0 && graphics.TLSPort <= 0 {
+				continue
+			}
+
+			listen := graphics.Listen
+			for _, l := range graphics.Listens {
+				if l.Address != "" {
+					listen = l.Address
+					break
+				}
+			}
+
+			result = append(result, vm.GraphicsInfo{
+				Type:    vm.GraphicsType(graphics.Type),
+				Listen:  listen,
+				Port:    graphics.Port,
+				TLSPort: graphics.TLSPort,
+				Host:    host,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("getting graphics of %s: %w", name, ErrGraphicsNotFound)
+	}
+
+	return result, nil
+}
+
+// OpenGraphics implements Manager.OpenGraphics. The device is dialed from the
+// libvirt host of the domain, so graphics listening on the loopback
+// interface of a remote host are never confused with ports on this machine.
+func (m *DomainManager) OpenGraphics(ctx context.Context, name string, graphicsType vm.GraphicsType) (net.Conn, error) {
+	graphics, err := m.GetGraphics(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range graphics {
+		if g.Type != graphicsType {
+			continue
+		}
+
+		conn, err := connection.DialHost(ctx, m.connManager, g.Address())
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s graphics of %s: %w", graphicsType, name, err)
+		}
+		return conn, nil
+	}
+
+	return nil, fmt.Errorf("getting %s graphics of %s: %w", graphicsType, name, ErrGraphicsNotFound)
+}
+
+// Read reads console output.
+func (c *domainConsole) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// Write sends input to the console.
+func (c *domainConsole) Write(p []byte) (int, error) {
+	if err := c.sender.SendStream(procDomainOpenConsole, p); err != nil {
+		return 0, fmt.Errorf("writing to console: %w", err)
+	}
+	return len(p), nil
+}
+
+// Resize updates the window size of the guest terminal. The host pty does
+// not forward its size to the guest, so the guest agent sets it with stty.
+func (c *domainConsole) Resize(cols, rows uint16) error {
+	if c.guestTTY == "" {
+		return fmt.Errorf("resizing console of %s: %w: unknown guest terminal", c.name, ErrConsoleUnavailable)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), consoleResizeTimeout*time.Second)
+	defer cancel()
+
+	result, err := c.manager.GuestExec(ctx, c.name, vm.GuestCommand{
+		Command: []string{"stty", "-F", c.guestTTY,
+			"rows", strconv.Itoa(int(rows)), "cols", strconv.Itoa(int(cols))},
+		Timeout: consoleResizeTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("resizing console of %s: %w", c.name, err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("resizing console of %s: stty exited with %d: %s",
+			c.name, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return nil
+}
+
+// Close ends the console session.
+func (c *domainConsole) Close() error {
+	var err error
+	c.once.Do(func() {
+		err = c.reader.Close()
+
+		// Closing the connection aborts the blocked console stream
+		c.manager.closeConsoleConnection(c.conn)
+
+		c.manager.logger.Info("Closed domain console", logger.String("name", c.name))
+	})
+	return err
+}
+
+// closeConsoleConnection closes a connection used for a console stream.
+func (m *DomainManager) closeConsoleConnection(conn connection.Connection) {
+	if err := conn.Close(); err != nil {
+		m.logger.Warn("Failed to close console connection", logger.Error(err))
+	}
+	m.handleDeferredRelease(conn)
+}
+
+// getLiveDevices fetches and parses the devices of a running domain.
+func getLiveDevices(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) (*libvirtConsoleDevices, error) {
+	xmlDesc, err := libvirtConn.DomainGetXMLDesc(domain, 0)
+	if err != nil {
+		return nil, fmt.Errorf("getting domain XML: %w", err)
+	}
+
+	var devices libvirtConsoleDevices
+	if err := xml.Unmarshal([]byte(xmlDesc), &devices); err != nil {
+		return nil, fmt.Errorf("parsing domain XML: %w", err)
+	}
+
+	return &devices, nil
+}
+
+// guestConsoleTTY returns the guest terminal of a console device, or an
+// empty string when the guest driver is unknown.
+func guestConsoleTTY(device *libvirtCharDevice) string {
+	port := device.Target.Port
+	if port == "" {
+		port = "0"
+	}
+
+	switch device.Target.Type {
+	case "serial", "isa-serial", "pci-serial":
+		return "/dev/ttyS" + port
+	case "virtio":
+		return "/dev/hvc" + port
+	default:
+		return ""
+	}
+}
+
+// findConsoleDevice selects the pty-backed console matching the given alias.
+func findConsoleDevice(devices *libvirtConsoleDevices, alias string) (*libvirtCharDevice, error) {
+	candidates := make([]libvirtCharDevice, 0, len(devices.Devices.Consoles)+len(devices.Devices.Serials))
+	candidates = append(candidates, devices.Devices.Consoles...)
+	candidates = append(candidates, devices.Devices.Serials...)
+	for i := range candidates {
+		device := &candidates[i]
+		if alias != "" && device.Alias.Name != alias {
+			continue
+		}
+		if device.Type != consoleTypePty {
+			continue
+		}
+		if device.Source.Path == "" {
+			device.Source.Path = device.TTY
+		}
+		if device.Source.Path != "" {
+			return device, nil
+		}
+	}
+
+	if alias != "" {
+		return nil, fmt.Errorf("device %s: %w", alias, ErrConsoleUnavailable)
+	}
+	return nil, ErrConsoleUnavailable
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const liveConsoleXML = `<domain type='kvm' id='3'>
+  <name>test-vm</name>
+  <devices>
+    <serial type='pty'>
+      <source path='/dev/pts/4'/>
+      <target type='isa-serial' port='0'/>
+      <alias name='serial0'/>
+    </serial>
+    <console type='pty' tty='/dev/pts/4'>
+      <source path='/dev/pts/4'/>
+      <target type='serial' port='0'/>
+      <alias name='serial0'/>
+    </console>
+    <graphics type='vnc' port='5901' autoport='yes' listen='127.0.0.1'>
+      <listen type='address' address='127.0.0.1'/>
+    </graphics>
+  </devices>
+</domain>`
+
+func TestFindConsoleDevice(t *testing.T) {
+	var devices libvirtConsoleDevices
+	require.NoError(t, xml.Unmarshal([]byte(liveConsoleXML), &devices))
+
+	t.Run("Primary console", func(t *testing.T) {
+		device, err := findConsoleDevice(&devices, "")
+		require.NoError(t, err)
+		assert.Equal(t, "/dev/pts/4", device.Source.Path)
+	})
+
+	t.Run("Console by alias", func(t *testing.T) {
+		device, err := findConsoleDevice(&devices, "serial0")
+		require.NoError(t, err)
+		assert.Equal(t, "serial0", device.Alias.Name)
+	})
+
+	t.Run("Unknown alias", func(t *testing.T) {
+		_, err := findConsoleDevice(&devices, "serial9")
+		assert.ErrorIs(t, err, ErrConsoleUnavailable)
+	})
+
+	t.Run("No pty console", func(t *testing.T) {
+		_, err := findConsoleDevice(&libvirtConsoleDevices{}, "")
+		assert.ErrorIs(t, err, ErrConsoleUnavailable)
+	})
+}
+
+func TestLiveGraphicsParsing(t *testing.T) {
+	var devices libvirtConsoleDevices
+	require.NoError(t, xml.Unmarshal([]byte(liveConsoleXML), &devices))
+
+	require.Len(t, devices.Devices.Graphics, 1)
+	graphics := devices.Devices.Graphics[0]
+	assert.Equal(t, "vnc", graphics.Type)
+	assert.Equal(t, 5901, graphics.Port)
+	require.Len(t, graphics.Listens, 1)
+	assert.Equal(t, "127.0.0.1", graphics.Listens[0].Address)
+}
+
+func TestGuestConsoleTTY(t *testing.T) {
+	var devices libvirtConsoleDevices
+	require.NoError(t, xml.Unmarshal([]byte(liveConsoleXML), &devices))
+
+	device, err := findConsoleDevice(&devices, "")
+	require.NoError(t, err)
+	assert.Equal(t, "/dev/ttyS0", guestConsoleTTY(device))
+
+	virtio := &libvirtCharDevice{}
+	virtio.Target.Type = "virtio"
+	virtio.Target.Port = "1"
+	assert.Equal(t, "/dev/hvc1", guestConsoleTTY(virtio))
+
+	unknown := &libvirtCharDevice{}
+	unknown.Target.Type = "sclp"
+	assert.Empty(t, guestConsoleTTY(unknown))
+}
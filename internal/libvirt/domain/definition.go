@@ -0,0 +1,399 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/digitalocean/go-libvirt"
+	"github.com/google/uuid"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+// ErrInvalidDefinition is returned for domain definitions libvirt rejects
+// or that cannot replace the definition of a domain.
+var ErrInvalidDefinition = fmt.Errorf("invalid domain definition")
+
+// GetDefinition implements Manager.GetDefinition.
+func (m *DomainManager) GetDefinition(ctx context.Context, name string) (string, error) {
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	domain, err := libvirtConn.DomainLookupByName(name)
+	if err != nil {
+		return "", fmt.Errorf("looking up domain %s: %w", name, ErrDomainNotFound)
+	}
+
+	definition, err := libvirtConn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive|libvirt.DomainXMLSecure)
+	if err != nil {
+		return "", fmt.Errorf("getting domain XML: %w", err)
+	}
+
+	return definition, nil
+}
+
+// UpdateDefinition implements Manager.UpdateDefinition.
+func (m *DomainManager) UpdateDefinition(ctx context.Context, name string, definition string) (*vm.DefinitionUpdate, error) {
+	return m.updateDefinition(ctx, name, func(_ string, domainUUID string) (string, error) {
+		return checkDefinitionIdentity(definition, name, domainUUID)
+	})
+}
+
+// Update implements Manager.Update.
+func (m *DomainManager) Update(ctx context.Context, name string, params vm.UpdateParams) (*vm.DefinitionUpdate, error) {
+	update, err := m.updateDefinition(ctx, name, func(current string, _ string) (string, error) {
+		return applyUpdate(current, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Autostart == nil {
+		return update, nil
+	}
+
+	// Autostart is not part of the definition
+	err = m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		autostart, err := libvirtConn.DomainGetAutostart(domain)
+		if err != nil {
+			return fmt.Errorf("getting autostart: %w", err)
+		}
+		if (autostart != 0) == *params.Autostart {
+			return nil
+		}
+
+		var value int32
+		if *params.Autostart {
+			value = 1
+		}
+		if err := libvirtConn.DomainSetAutostart(domain, value); err != nil {
+			return fmt.Errorf("setting autostart: %w", err)
+		}
+		update.Changed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// updateDefinition replaces the persistent definition of a domain with the
+// one build returns for the current definition and UUID. libvirt validates
+// the new definition against its domain schema before it is applied.
+func (m *DomainManager) updateDefinition(ctx context.Context, name string, build func(current string, domainUUID string) (string, error)) (*vm.DefinitionUpdate, error) {
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	domain, err := libvirtConn.DomainLookupByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up domain %s: %w", name, ErrDomainNotFound)
+	}
+
+	current, err := libvirtConn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive|libvirt.DomainXMLSecure)
+	if err != nil {
+		return nil, fmt.Errorf("getting domain XML: %w", err)
+	}
+
+	updated, err := build(current, uuid.UUID(domain.UUID).String())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := libvirtConn.DomainDefineXMLFlags(updated, libvirt.DomainDefineValidate); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+
+	// Compare the definitions as libvirt stores them
+	stored, err := libvirtConn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive|libvirt.DomainXMLSecure)
+	if err != nil {
+		return nil, fmt.Errorf("getting domain XML: %w", err)
+	}
+
+	update := &vm.DefinitionUpdate{Changed: stored != current}
+	if !update.Changed {
+		return update, nil
+	}
+
+	update.Diff, err = definitionDiff(current, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := libvirtConn.DomainIsActive(domain)
+	if err != nil {
+		return nil, fmt.Errorf("getting domain state: %w", err)
+	}
+	if active != 0 {
+		update.RestartRequired, err = m.applyLive(libvirtConn, domain, current, stored)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.logger.Info("Updated domain definition",
+		logger.String("name", name),
+		logger.Bool("restart_required", update.RestartRequired))
+
+	return update, nil
+}
+
+// applyLive applies the changes of a definition a running domain can take
+// without a restart, which is only the description, and reports whether
+// any other change waits for one.
+func (m *DomainManager) applyLive(libvirtConn *libvirt.Libvirt, domain libvirt.Domain, current string, stored string) (bool, error) {
+	currentDescription, currentRest, err := splitDescription(current)
+	if err != nil {
+		return false, err
+	}
+	storedDescription, storedRest, err := splitDescription(stored)
+	if err != nil {
+		return false, err
+	}
+
+	if storedDescription != currentDescription {
+		description := libvirt.OptString{}
+		if storedDescription != "" {
+			description = libvirt.OptString{storedDescription}
+		}
+		if err := libvirtConn.DomainSetMetadata(domain, int32(libvirt.DomainMetadataDescription),
+			description, nil, nil, libvirt.DomainAffectLive); err != nil {
+			return false, fmt.Errorf("setting description: %w", err)
+		}
+	}
+
+	return storedRest != currentRest, nil
+}
+
+// splitDescription returns the description of a definition and the
+// definition without it.
+func splitDescription(definition string) (string, string, error) {
+	doc, err := xmlutils.LoadXMLDocumentFromString(definition)
+	if err != nil {
+		return "", "", err
+	}
+
+	var description string
+	if element := xmlutils.FindElement(doc, "/domain/description"); element != nil {
+		description = element.Text()
+		element.Parent().RemoveChild(element)
+	}
+
+	return description, xmlutils.XMLToString(doc), nil
+}
+
+// checkDefinitionIdentity checks that a definition describes the domain
+// with the given name and UUID, adding the UUID if the definition has
+// none. libvirt would otherwise define a second domain or reject the
+// definition with a less helpful error.
+func checkDefinitionIdentity(definition string, name string, domainUUID string) (string, error) {
+	doc, err := xmlutils.LoadXMLDocumentFromString(definition)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+
+	root := doc.Root()
+	if root == nil || root.Tag != "domain" {
+		return "", fmt.Errorf("%w: root element must be domain", ErrInvalidDefinition)
+	}
+
+	nameElement := root.SelectElement("name")
+	if nameElement == nil || strings.TrimSpace(nameElement.Text()) != name {
+		return "", fmt.Errorf("%w: name must be %s, renaming is not supported", ErrInvalidDefinition, name)
+	}
+
+	uuidElement := root.SelectElement("uuid")
+	if uuidElement == nil {
+		uuidElement = root.CreateElement("uuid")
+		uuidElement.SetText(domainUUID)
+	} else if !strings.EqualFold(strings.TrimSpace(uuidElement.Text()), domainUUID) {
+		return "", fmt.Errorf("%w: uuid must be %s", ErrInvalidDefinition, domainUUID)
+	}
+
+	return xmlutils.XMLToString(doc), nil
+}
+
+// applyUpdate applies structured update parameters to a domain definition.
+func applyUpdate(definition string, params vm.UpdateParams) (string, error) {
+	doc, err := xmlutils.LoadXMLDocumentFromString(definition)
+	if err != nil {
+		return "", err
+	}
+	root := doc.Root()
+
+	if params.CPUs != nil {
+		if err := setVCPUs(root, *params.CPUs); err != nil {
+			return "", err
+		}
+	}
+
+	if params.MemoryBytes != nil {
+		kib := strconv.FormatUint(*params.MemoryBytes/1024, 10)
+		for _, tag := range []string{"memory", "currentMemory"} {
+			element := root.SelectElement(tag)
+			if element == nil {
+				element = root.CreateElement(tag)
+			}
+			element.CreateAttr("unit", "KiB")
+			element.SetText(kib)
+		}
+	}
+
+	if params.Description != nil {
+		element := root.SelectElement("description")
+		switch {
+		case *params.Description == "" && element != nil:
+			root.RemoveChild(element)
+		case *params.Description != "":
+			if element == nil {
+				element = root.CreateElement("description")
+			}
+			element.SetText(*params.Description)
+		}
+	}
+
+	if params.NICModel != nil {
+		for _, iface := range xmlutils.FindElements(doc, "/domain/devices/interface") {
+			model := iface.SelectElement("model")
+			if model == nil {
+				model = iface.CreateElement("model")
+			}
+			model.CreateAttr("type", *params.NICModel)
+		}
+	}
+
+	if params.DiskCache != nil {
+		for _, disk := range xmlutils.FindElements(doc, "/domain/devices/disk[@device='disk']") {
+			driver := disk.SelectElement("driver")
+			if driver == nil {
+				driver = disk.CreateElement("driver")
+				driver.CreateAttr("name", "qemu")
+			}
+			if *params.DiskCache == "default" {
+				driver.RemoveAttr("cache")
+			} else {
+				driver.CreateAttr("cache", *params.DiskCache)
+			}
+		}
+	}
+
+	if params.BootOrder != nil {
+		if err := setBootOrder(doc, params.BootOrder); err != nil {
+			return "", err
+		}
+	}
+
+	return xmlutils.XMLToString(doc), nil
+}
+
+// setVCPUs sets the number of virtual CPUs of a domain, keeping the cores
+// and threads of its CPU topology.
+func setVCPUs(root *etree.Element, count int) error {
+	vcpu := root.SelectElement("vcpu")
+	if vcpu == nil {
+		vcpu = root.CreateElement("vcpu")
+	}
+	vcpu.SetText(strconv.Itoa(count))
+	// Hot-pluggable CPUs beyond the current count are not kept
+	vcpu.RemoveAttr("current")
+
+	topology := root.FindElement("cpu/topology")
+	if topology == nil {
+		return nil
+	}
+
+	perSocket := 1
+	for _, attr := range []string{"cores", "threads", "dies", "clusters"} {
+		if value := topology.SelectAttrValue(attr, ""); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("%w: invalid CPU topology %s %q", ErrInvalidDefinition, attr, value)
+			}
+			perSocket *= n
+		}
+	}
+	if count%perSocket != 0 {
+		return fmt.Errorf("%w: CPU count %d is not a multiple of the %d CPUs per socket of the topology",
+			ErrInvalidDefinition, count, perSocket)
+	}
+	topology.CreateAttr("sockets", strconv.Itoa(count/perSocket))
+
+	return nil
+}
+
+// setBootOrder makes a domain boot from devices in order. Devices are disk
+// targets or interface MAC addresses; all other boot settings are removed,
+// since libvirt does not allow per-device boot order together with them.
+func setBootOrder(doc *etree.Document, devices []string) error {
+	for _, boot := range xmlutils.FindElements(doc, "/domain/os/boot") {
+		boot.Parent().RemoveChild(boot)
+	}
+	for _, boot := range xmlutils.FindElements(doc, "/domain/devices/*/boot") {
+		boot.Parent().RemoveChild(boot)
+	}
+
+	for i, device := range devices {
+		element := findBootDevice(doc, device)
+		if element == nil {
+			return fmt.Errorf("%w: boot device %s not found", ErrInvalidDefinition, device)
+		}
+
+		// The boot element follows the target so that libvirt's element
+		// order is kept
+		boot := etree.NewElement("boot")
+		boot.CreateAttr("order", strconv.Itoa(i+1))
+		index := len(element.Child)
+		if target := element.SelectElement("target"); target != nil {
+			index = target.Index() + 1
+		}
+		element.InsertChildAt(index, boot)
+	}
+
+	return nil
+}
+
+// findBootDevice finds a disk by target device or an interface by MAC address.
+func findBootDevice(doc *etree.Document, device string) *etree.Element {
+	for _, disk := range xmlutils.FindElements(doc, "/domain/devices/disk") {
+		if target := disk.SelectElement("target"); target != nil && target.SelectAttrValue("dev", "") == device {
+			return disk
+		}
+	}
+	for _, iface := range xmlutils.FindElements(doc, "/domain/devices/interface") {
+		if mac := iface.SelectElement("mac"); mac != nil && strings.EqualFold(mac.SelectAttrValue("address", ""), device) {
+			return iface
+		}
+	}
+	return nil
+}
+
+// definitionDiff returns a unified diff of two domain definitions.
+func definitionDiff(current string, updated string) (string, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(current),
+		B:        difflib.SplitLines(updated),
+		FromFile: "current",
+		ToFile:   "updated",
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("comparing definitions: %w", err)
+	}
+	return diff, nil
+}
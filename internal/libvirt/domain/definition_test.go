@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+const definitionXML = `<domain type='kvm'>
+  <name>web-01</name>
+  <uuid>4dea22b3-1d52-d8f3-2516-782e98ab3fa0</uuid>
+  <description>Web server</description>
+  <memory unit='KiB'>2097152</memory>
+  <currentMemory unit='KiB'>1048576</currentMemory>
+  <vcpu placement='static' current='2'>4</vcpu>
+  <os>
+    <type arch='x86_64' machine='q35'>hvm</type>
+    <boot dev='hd'/>
+  </os>
+  <cpu mode='host-model'>
+    <topology sockets='2' cores='2' threads='1'/>
+  </cpu>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='qcow2' cache='writeback'/>
+      <source file='/var/lib/libvirt/images/web-01-disk-0'/>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <driver name='qemu' type='raw'/>
+      <source file='/var/lib/libvirt/cloud-init/web-01-cloudinit.iso'/>
+      <target dev='sdb' bus='sata'/>
+      <readonly/>
+    </disk>
+    <interface type='network'>
+      <mac address='52:54:00:11:22:33'/>
+      <source network='default'/>
+      <model type='e1000'/>
+    </interface>
+  </devices>
+</domain>`
+
+func TestApplyUpdate(t *testing.T) {
+	cpus := 6
+	memory := uint64(4 << 30)
+	description := "Frontend"
+	nicModel := "virtio"
+	diskCache := "none"
+
+	updated, err := applyUpdate(definitionXML, vm.UpdateParams{
+		CPUs:        &cpus,
+		MemoryBytes: &memory,
+		Description: &description,
+		NICModel:    &nicModel,
+		DiskCache:   &diskCache,
+		BootOrder:   []string{"52:54:00:11:22:33", "vda"},
+	})
+	require.NoError(t, err)
+
+	doc, err := xmlutils.LoadXMLDocumentFromString(updated)
+	require.NoError(t, err)
+
+	vcpu := xmlutils.FindElement(doc, "/domain/vcpu")
+	assert.Equal(t, "6", vcpu.Text())
+	assert.Nil(t, vcpu.SelectAttr("current"))
+	assert.Equal(t, "static", vcpu.SelectAttrValue("placement", ""))
+	assert.Equal(t, "3", xmlutils.FindElement(doc, "/domain/cpu/topology").SelectAttrValue("sockets", ""))
+
+	assert.Equal(t, "4194304", xmlutils.FindElement(doc, "/domain/memory").Text())
+	assert.Equal(t, "4194304", xmlutils.FindElement(doc, "/domain/currentMemory").Text())
+	assert.Equal(t, "Frontend", xmlutils.FindElement(doc, "/domain/description").Text())
+	assert.Equal(t, "virtio", xmlutils.FindElement(doc, "/domain/devices/interface/model").SelectAttrValue("type", ""))
+
+	// Only disks get the cache mode
+	assert.Equal(t, "none", xmlutils.FindElement(doc, "/domain/devices/disk[@device='disk']/driver").SelectAttrValue("cache", ""))
+	assert.Nil(t, xmlutils.FindElement(doc, "/domain/devices/disk[@device='cdrom']/driver").SelectAttr("cache"))
+
+	// Per-device boot order replaces the boot elements of the OS
+	assert.Nil(t, xmlutils.FindElement(doc, "/domain/os/boot"))
+	assert.Equal(t, "1", xmlutils.FindElement(doc, "/domain/devices/interface/boot").SelectAttrValue("order", ""))
+	assert.Equal(t, "2", xmlutils.FindElement(doc, "/domain/devices/disk[@device='disk']/boot").SelectAttrValue("order", ""))
+	assert.Nil(t, xmlutils.FindElement(doc, "/domain/devices/disk[@device='cdrom']/boot"))
+}
+
+func TestApplyUpdate_Removals(t *testing.T) {
+	description := ""
+	diskCache := "default"
+
+	updated, err := applyUpdate(definitionXML, vm.UpdateParams{
+		Description: &description,
+		DiskCache:   &diskCache,
+		BootOrder:   []string{},
+	})
+	require.NoError(t, err)
+
+	doc, err := xmlutils.LoadXMLDocumentFromString(updated)
+	require.NoError(t, err)
+
+	assert.Nil(t, xmlutils.FindElement(doc, "/domain/description"))
+	assert.Nil(t, xmlutils.FindElement(doc, "/domain/devices/disk[@device='disk']/driver").SelectAttr("cache"))
+	assert.Empty(t, xmlutils.FindElements(doc, "/domain/os/boot"))
+	assert.Empty(t, xmlutils.FindElements(doc, "/domain/devices/*/boot"))
+
+	// Unchanged fields stay as they were
+	assert.Equal(t, "4", xmlutils.FindElement(doc, "/domain/vcpu").Text())
+}
+
+func TestApplyUpdate_Invalid(t *testing.T) {
+	_, err := applyUpdate(definitionXML, vm.UpdateParams{BootOrder: []string{"vdz"}})
+	assert.ErrorIs(t, err, ErrInvalidDefinition)
+
+	// 5 CPUs do not fit the 2 cores per socket of the topology
+	cpus := 5
+	_, err = applyUpdate(definitionXML, vm.UpdateParams{CPUs: &cpus})
+	assert.ErrorIs(t, err, ErrInvalidDefinition)
+}
+
+func TestCheckDefinitionIdentity(t *testing.T) {
+	const domainUUID = "4dea22b3-1d52-d8f3-2516-782e98ab3fa0"
+
+	definition, err := checkDefinitionIdentity(definitionXML, "web-01", domainUUID)
+	require.NoError(t, err)
+	assert.Contains(t, definition, domainUUID)
+
+	// A missing UUID is filled in
+	definition, err = checkDefinitionIdentity(`<domain type='kvm'><name>web-01</name></domain>`, "web-01", domainUUID)
+	require.NoError(t, err)
+	assert.Contains(t, definition, "<uuid>"+domainUUID+"</uuid>")
+
+	tests := map[string]string{
+		"not xml":       "not xml",
+		"other root":    `<network><name>web-01</name></network>`,
+		"renamed":       `<domain type='kvm'><name>web-02</name></domain>`,
+		"other uuid":    `<domain type='kvm'><name>web-01</name><uuid>0b3c1e0e-8f5e-4c4b-9d0e-6a1f2b3c4d5e</uuid></domain>`,
+		"missing name":  `<domain type='kvm'><uuid>` + domainUUID + `</uuid></domain>`,
+		"empty element": `<domain/>`,
+	}
+	for name, definition := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := checkDefinitionIdentity(definition, "web-01", domainUUID)
+			assert.ErrorIs(t, err, ErrInvalidDefinition)
+		})
+	}
+}
+
+func TestSplitDescription(t *testing.T) {
+	description, rest, err := splitDescription(definitionXML)
+	require.NoError(t, err)
+	assert.Equal(t, "Web server", description)
+	assert.NotContains(t, rest, "description")
+
+	// Definitions that differ only in their description are the same otherwise
+	other := `<domain type='kvm'>
+  <name>web-01</name>
+  <description>Frontend</description>
+</domain>`
+	_, first, err := splitDescription(other)
+	require.NoError(t, err)
+	_, second, err := splitDescription(`<domain type='kvm'>
+  <name>web-01</name>
+</domain>`)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestDefinitionDiff(t *testing.T) {
+	diff, err := definitionDiff("<domain>\n  <vcpu>2</vcpu>\n</domain>\n", "<domain>\n  <vcpu>4</vcpu>\n</domain>\n")
+	require.NoError(t, err)
+
+	assert.Contains(t, diff, "--- current\n+++ updated\n")
+	assert.Contains(t, diff, "-  <vcpu>2</vcpu>\n+  <vcpu>4</vcpu>\n")
+}
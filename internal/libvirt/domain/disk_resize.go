@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ResizeDisk implements Manager.ResizeDisk.
+func (m *DomainManager) ResizeDisk(ctx context.Context, name string, device string, capacityBytes uint64) error {
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		domainXML, state, _, err := m.getDomainInfo(libvirtConn, domain)
+		if err != nil {
+			return err
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainRunning && libvirt.DomainState(state) != libvirt.DomainPaused {
+			return fmt.Errorf("resizing disk of %s: %w", name, ErrDomainNotRunning)
+		}
+
+		disk := findDisk(domainXML.Devices.Disks, device)
+		if disk == nil || disk.Device != "disk" {
+			return fmt.Errorf("%w: %s on %s", ErrDiskNotFound, device, name)
+		}
+
+		if err := libvirtConn.DomainBlockResize(domain, device, capacityBytes, libvirt.DomainBlockResizeBytes); err != nil {
+			return fmt.Errorf("resizing disk %s: %w", device, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Resized disk of running domain",
+		logger.String("name", name),
+		logger.String("device", device),
+		logger.Uint64("capacity", capacityBytes))
+
+	return nil
+}
+
+// GetFilesystems implements Manager.GetFilesystems.
+func (m *DomainManager) GetFilesystems(ctx context.Context, name string) ([]vm.GuestFilesystem, error) {
+	var filesystems []vm.GuestFilesystem
+
+	err := m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		info, _, err := libvirtConn.DomainGetFsinfo(domain, 0)
+		if err != nil {
+			return guestAgentError("getting guest filesystems", err)
+		}
+
+		filesystems = make([]vm.GuestFilesystem, 0, len(info))
+		for _, fs := range info {
+			filesystems = append(filesystems, vm.GuestFilesystem{
+				Mountpoint: fs.Mountpoint,
+				Name:       fs.Name,
+				Type:       fs.Fstype,
+				Disks:      fs.DevAliases,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filesystems, nil
+}
@@ -0,0 +1,239 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ErrGuestAgentUnavailable is returned when the guest agent is not configured
+// or does not respond.
+var ErrGuestAgentUnavailable = fmt.Errorf("guest agent unavailable")
+
+// guestInfoTypes are the guest info groups queried from the agent.
+const guestInfoTypes = libvirt.DomainGuestInfoOs | libvirt.DomainGuestInfoHostname | libvirt.DomainGuestInfoTimezone
+
+// loopbackInterface is skipped when reporting guest interfaces.
+const loopbackInterface = "lo"
+
+// GetGuestInfo implements Manager.GetGuestInfo.
+func (m *DomainManager) GetGuestInfo(ctx context.Context, name string) (*vm.GuestInfo, error) {
+	var result *vm.GuestInfo
+
+	err := m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		params, err := libvirtConn.DomainGetGuestInfo(domain, uint32(guestInfoTypes), 0)
+		if err != nil {
+			return guestAgentError("getting guest info", err)
+		}
+
+		result = parseGuestInfo(params)
+
+		ifaces, err := libvirtConn.DomainInterfaceAddresses(domain, uint32(libvirt.DomainInterfaceAddressesSrcAgent), 0)
+		if err != nil {
+			return guestAgentError("getting guest interfaces", err)
+		}
+
+		result.Interfaces = convertGuestInterfaces(ifaces)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FreezeFilesystems implements Manager.FreezeFilesystems.
+func (m *DomainManager) FreezeFilesystems(ctx context.Context, name string) (int, error) {
+	var frozen int32
+
+	err := m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		var err error
+		frozen, err = libvirtConn.DomainFsfreeze(domain, nil, 0)
+		if err != nil {
+			return guestAgentError("freezing guest filesystems", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	m.logger.Debug("Froze guest filesystems",
+		logger.String("name", name),
+		logger.Int("count", int(frozen)))
+	return int(frozen), nil
+}
+
+// ThawFilesystems implements Manager.ThawFilesystems.
+func (m *DomainManager) ThawFilesystems(ctx context.Context, name string) (int, error) {
+	var thawed int32
+
+	err := m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		var err error
+		thawed, err = libvirtConn.DomainFsthaw(domain, nil, 0)
+		if err != nil {
+			return guestAgentError("thawing guest filesystems", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	m.logger.Debug("Thawed guest filesystems",
+		logger.String("name", name),
+		logger.Int("count", int(thawed)))
+	return int(thawed), nil
+}
+
+// SetUserPassword implements Manager.SetUserPassword.
+func (m *DomainManager) SetUserPassword(ctx context.Context, name string, username string, password string) error {
+	return m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		if err := libvirtConn.DomainSetUserPassword(domain, libvirt.OptString{username}, libvirt.OptString{password}, 0); err != nil {
+			return guestAgentError("setting guest user password", err)
+		}
+
+		m.logger.Info("Set guest user password",
+			logger.String("name", name),
+			logger.String("user", username))
+		return nil
+	})
+}
+
+// SyncTime implements Manager.SyncTime.
+func (m *DomainManager) SyncTime(ctx context.Context, name string) error {
+	return m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		// The guest clock is set from the host clock when the time is zero
+		if err := libvirtConn.DomainSetTime(domain, 0, 0, libvirt.DomainTimeSync); err != nil {
+			return guestAgentError("synchronizing guest time", err)
+		}
+
+		m.logger.Debug("Synchronized guest time", logger.String("name", name))
+		return nil
+	})
+}
+
+// ShutdownGuest implements Manager.ShutdownGuest.
+func (m *DomainManager) ShutdownGuest(ctx context.Context, name string) error {
+	err := m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		if err := libvirtConn.DomainShutdownFlags(domain, libvirt.DomainShutdownGuestAgent); err != nil {
+			return guestAgentError("shutting down guest", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Requested guest agent shutdown", logger.String("name", name))
+	return nil
+}
+
+// performGuestAgentOperation runs a guest agent operation on a running domain.
+func (m *DomainManager) performGuestAgentOperation(ctx context.Context, name string, operation func(*libvirt.Libvirt, libvirt.Domain) error) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		state, _, _, _, _, err := libvirtConn.DomainGetInfo(domain) //nolint:dogsled
+		if err != nil {
+			return fmt.Errorf("getting domain info: %w", err)
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainRunning {
+			return fmt.Errorf("querying guest agent of domain %s: %w", name, ErrDomainNotRunning)
+		}
+
+		return operation(libvirtConn, domain)
+	})
+}
+
+// guestAgentError wraps an error from a guest agent call, marking errors
+// caused by a missing or unresponsive agent with ErrGuestAgentUnavailable.
+func guestAgentError(operation string, err error) error {
+	var libvirtErr libvirt.Error
+	if errors.As(err, &libvirtErr) {
+		switch libvirt.ErrorNumber(libvirtErr.Code) {
+		case libvirt.ErrAgentUnresponsive, libvirt.ErrAgentUnsynced, libvirt.ErrArgumentUnsupported:
+			return fmt.Errorf("%s: %w: %s", operation, ErrGuestAgentUnavailable, libvirtErr.Message)
+		}
+	}
+
+	return fmt.Errorf("%s: %w", operation, err)
+}
+
+// parseGuestInfo converts guest info parameters into guest information.
+func parseGuestInfo(params []libvirt.TypedParam) *vm.GuestInfo {
+	info := &vm.GuestInfo{}
+
+	for _, param := range params {
+		value, ok := param.Value.I.(string)
+		if !ok {
+			continue
+		}
+
+		switch param.Field {
+		case "hostname":
+			info.Hostname = value
+		case "timezone.name":
+			info.Timezone = value
+		case "os.id":
+			info.OS.ID = value
+		case "os.name":
+			info.OS.Name = value
+		case "os.pretty-name":
+			info.OS.PrettyName = value
+		case "os.version":
+			info.OS.Version = value
+		case "os.version-id":
+			info.OS.VersionID = value
+		case "os.kernel-release":
+			info.OS.KernelRelease = value
+		case "os.kernel-version":
+			info.OS.KernelVersion = value
+		case "os.machine":
+			info.OS.Machine = value
+		}
+	}
+
+	return info
+}
+
+// convertGuestInterfaces converts agent interface addresses, skipping loopback.
+func convertGuestInterfaces(ifaces []libvirt.DomainInterface) []vm.GuestInterface {
+	result := make([]vm.GuestInterface, 0, len(ifaces))
+
+	for _, iface := range ifaces {
+		if iface.Name == loopbackInterface {
+			continue
+		}
+
+		guestIface := vm.GuestInterface{
+			Name:        iface.Name,
+			IPAddresses: make([]vm.GuestIPAddress, 0, len(iface.Addrs)),
+		}
+		if len(iface.Hwaddr) > 0 {
+			guestIface.MacAddress = strings.ToLower(iface.Hwaddr[0])
+		}
+
+		for _, addr := range iface.Addrs {
+			addrType := vm.GuestIPAddressIPv4
+			if libvirt.IPAddrType(addr.Type) == libvirt.IPAddrTypeIpv6 {
+				addrType = vm.GuestIPAddressIPv6
+			}
+
+			guestIface.IPAddresses = append(guestIface.IPAddresses, vm.GuestIPAddress{
+				Address: addr.Addr,
+				Type:    addrType,
+				Prefix:  int(addr.Prefix),
+			})
+		}
+
+		result = append(result, guestIface)
+	}
+
+	return result
+}
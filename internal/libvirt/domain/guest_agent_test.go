@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+)
+
+func TestParseGuestInfo(t *testing.T) {
+	params := []libvirt.TypedParam{
+		{Field: "hostname", Value: *libvirt.NewTypedParamValueString("web-01")},
+		{Field: "timezone.name", Value: *libvirt.NewTypedParamValueString("UTC")},
+		{Field: "timezone.offset", Value: *libvirt.NewTypedParamValueInt(0)},
+		{Field: "os.id", Value: *libvirt.NewTypedParamValueString("ubuntu")},
+		{Field: "os.pretty-name", Value: *libvirt.NewTypedParamValueString("Ubuntu 24.04 LTS")},
+		{Field: "os.version-id", Value: *libvirt.NewTypedParamValueString("24.04")},
+		{Field: "os.kernel-release", Value: *libvirt.NewTypedParamValueString("6.8.0-31-generic")},
+		{Field: "os.machine", Value: *libvirt.NewTypedParamValueString("x86_64")},
+	}
+
+	info := parseGuestInfo(params)
+
+	assert.Equal(t, "web-01", info.Hostname)
+	assert.Equal(t, "UTC", info.Timezone)
+	assert.Equal(t, vm.GuestOSInfo{
+		ID:            "ubuntu",
+		PrettyName:    "Ubuntu 24.04 LTS",
+		VersionID:     "24.04",
+		KernelRelease: "6.8.0-31-generic",
+		Machine:       "x86_64",
+	}, info.OS)
+}
+
+func TestConvertGuestInterfaces(t *testing.T) {
+	ifaces := []libvirt.DomainInterface{
+		{
+			Name:  "lo",
+			Addrs: []libvirt.DomainIPAddr{{Type: int32(libvirt.IPAddrTypeIpv4), Addr: "127.0.0.1", Prefix: 8}},
+		},
+		{
+			Name:   "eth0",
+			Hwaddr: libvirt.OptString{"52:54:00:AA:BB:CC"},
+			Addrs: []libvirt.DomainIPAddr{
+				{Type: int32(libvirt.IPAddrTypeIpv4), Addr: "10.0.0.5", Prefix: 24},
+				{Type: int32(libvirt.IPAddrTypeIpv6), Addr: "2001:db8::5", Prefix: 64},
+			},
+		},
+	}
+
+	result := convertGuestInterfaces(ifaces)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, "eth0", result[0].Name)
+	assert.Equal(t, "52:54:00:aa:bb:cc", result[0].MacAddress)
+	assert.Equal(t, []vm.GuestIPAddress{
+		{Address: "10.0.0.5", Type: vm.GuestIPAddressIPv4, Prefix: 24},
+		{Address: "2001:db8::5", Type: vm.GuestIPAddressIPv6, Prefix: 64},
+	}, result[0].IPAddresses)
+}
+
+func TestGuestAgentError(t *testing.T) {
+	t.Run("Unresponsive agent", func(t *testing.T) {
+		err := guestAgentError("getting guest info", libvirt.Error{
+			Code:    uint32(libvirt.ErrAgentUnresponsive),
+			Message: "Guest agent is not responding",
+		})
+		assert.ErrorIs(t, err, ErrGuestAgentUnavailable)
+	})
+
+	t.Run("Other error", func(t *testing.T) {
+		err := guestAgentError("setting guest user password", errors.New("user does not exist"))
+		assert.NotErrorIs(t, err, ErrGuestAgentUnavailable)
+		assert.Contains(t, err.Error(), "user does not exist")
+	})
+}
+
+func TestParseGuestExecStatus(t *testing.T) {
+	var response guestExecStatusResponse
+	require.NoError(t, json.Unmarshal([]byte(
+		`{"return":{"exited":true,"exitcode":2,"out-data":"c3luY2VkCg==","err-data":"d2FybmluZwo="}}`,
+	), &response))
+
+	result, err := parseGuestExecStatus(&response)
+	require.NoError(t, err)
+	assert.Equal(t, &vm.GuestCommandResult{Stdout: "synced\n", Stderr: "warning\n", ExitCode: 2}, result)
+
+	response.Return.OutData = "not base64"
+	_, err = parseGuestExecStatus(&response)
+	assert.Error(t, err)
+}
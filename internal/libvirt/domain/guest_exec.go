@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ErrInvalidGuestCommand is returned for guest commands without a program.
+var ErrInvalidGuestCommand = fmt.Errorf("invalid guest command")
+
+const (
+	// defaultGuestExecTimeout is how long a guest command may run when the
+	// command does not set a timeout.
+	defaultGuestExecTimeout = 60 * time.Second
+	// guestExecPollInterval is how often the agent is asked whether a guest
+	// command has exited.
+	guestExecPollInterval = 500 * time.Millisecond
+)
+
+// guestExecRequest is the guest-exec agent command.
+type guestExecRequest struct {
+	Execute   string `json:"execute"`
+	Arguments struct {
+		Path          string   `json:"path"`
+		Arg           []string `json:"arg,omitempty"`
+		CaptureOutput bool     `json:"capture-output"`
+	} `json:"arguments"`
+}
+
+// guestExecStatusRequest is the guest-exec-status agent command.
+type guestExecStatusRequest struct {
+	Execute   string `json:"execute"`
+	Arguments struct {
+		PID int `json:"pid"`
+	} `json:"arguments"`
+}
+
+// guestExecResponse is the reply to guest-exec.
+type guestExecResponse struct {
+	Return struct {
+		PID int `json:"pid"`
+	} `json:"return"`
+}
+
+// guestExecStatusResponse is the reply to guest-exec-status. Output is base64 encoded.
+type guestExecStatusResponse struct {
+	Return struct {
+		OutData  string `json:"out-data"`
+		ErrData  string `json:"err-data"`
+		ExitCode int    `json:"exitcode"`
+		Signal   int    `json:"signal"`
+		Exited   bool   `json:"exited"`
+	} `json:"return"`
+}
+
+// GuestExec implements Manager.GuestExec.
+func (m *DomainManager) GuestExec(ctx context.Context, name string, command vm.GuestCommand) (*vm.GuestCommandResult, error) {
+	if len(command.Command) == 0 || command.Command[0] == "" {
+		return nil, fmt.Errorf("%w: no program given", ErrInvalidGuestCommand)
+	}
+
+	timeout := defaultGuestExecTimeout
+	if command.Timeout > 0 {
+		timeout = time.Duration(command.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result *vm.GuestCommandResult
+
+	err := m.performGuestAgentOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		pid, err := startGuestCommand(libvirtConn, domain, command.Command)
+		if err != nil {
+			return err
+		}
+
+		result, err = waitForGuestCommand(ctx, libvirtConn, domain, pid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Debug("Ran guest command",
+		logger.String("name", name),
+		logger.String("program", command.Command[0]),
+		logger.Int("exitCode", result.ExitCode))
+
+	return result, nil
+}
+
+// startGuestCommand starts a program in the guest and returns its PID.
+func startGuestCommand(libvirtConn *libvirt.Libvirt, domain libvirt.Domain, command []string) (int, error) {
+	request := guestExecRequest{Execute: "guest-exec"}
+	request.Arguments.Path = command[0]
+	request.Arguments.Arg = command[1:]
+	request.Arguments.CaptureOutput = true
+
+	var response guestExecResponse
+	if err := guestAgentCommand(libvirtConn, domain, request, &response); err != nil {
+		return 0, guestAgentError("starting guest command", err)
+	}
+
+	return response.Return.PID, nil
+}
+
+// waitForGuestCommand polls the agent until a guest program has exited.
+func waitForGuestCommand(ctx context.Context, libvirtConn *libvirt.Libvirt, domain libvirt.Domain, pid int) (*vm.GuestCommandResult, error) {
+	request := guestExecStatusRequest{Execute: "guest-exec-status"}
+	request.Arguments.PID = pid
+
+	ticker := time.NewTicker(guestExecPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var response guestExecStatusResponse
+		if err := guestAgentCommand(libvirtConn, domain, request, &response); err != nil {
+			return nil, guestAgentError("getting guest command status", err)
+		}
+
+		if response.Return.Exited {
+			return parseGuestExecStatus(&response)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for guest command %d: %w", pid, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseGuestExecStatus converts the status of an exited guest program.
+func parseGuestExecStatus(response *guestExecStatusResponse) (*vm.GuestCommandResult, error) {
+	stdout, err := base64.StdEncoding.DecodeString(response.Return.OutData)
+	if err != nil {
+		return nil, fmt.Errorf("decoding guest command output: %w", err)
+	}
+	stderr, err := base64.StdEncoding.DecodeString(response.Return.ErrData)
+	if err != nil {
+		return nil, fmt.Errorf("decoding guest command error output: %w", err)
+	}
+
+	return &vm.GuestCommandResult{
+		Stdout:   string(stdout),
+		Stderr:   string(stderr),
+		ExitCode: response.Return.ExitCode,
+		Signal:   response.Return.Signal,
+	}, nil
+}
+
+// guestAgentCommand sends a raw command to the guest agent and decodes the reply.
+func guestAgentCommand(libvirtConn *libvirt.Libvirt, domain libvirt.Domain, request, response interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("encoding agent command: %w", err)
+	}
+
+	reply, err := libvirtConn.QEMUDomainAgentCommand(domain, string(data), int32(libvirt.DomainAgentResponseTimeoutDefault), 0)
+	if err != nil {
+		return err
+	}
+	if len(reply) == 0 {
+		return fmt.Errorf("empty reply from guest agent")
+	}
+
+	if err := json.Unmarshal([]byte(reply[0]), response); err != nil {
+		return fmt.Errorf("decoding agent reply: %w", err)
+	}
+
+	return nil
+}
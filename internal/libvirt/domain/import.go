@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ImportSpec describes a domain defined around existing disks, such as the
+// disks of an imported appliance.
+type ImportSpec struct {
+	// Disks are attached in order; the first disk is the boot disk
+	Disks    []ImportDisk
+	Networks []ImportNetwork
+	Name     string
+	// Firmware is "efi" for UEFI guests and empty for BIOS guests
+	Firmware    string
+	MemoryBytes uint64
+	CPUs        int
+}
+
+// ImportDisk is a disk of an imported domain.
+type ImportDisk struct {
+	Path   string
+	Format string
+	Bus    string
+}
+
+// ImportNetwork is a network interface of an imported domain.
+type ImportNetwork struct {
+	// Network is the libvirt network the interface is connected to
+	Network string
+	Model   string
+}
+
+// DefineImported implements Manager.DefineImported.
+func (m *DomainManager) DefineImported(ctx context.Context, spec ImportSpec) (*vm.VM, error) {
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	if _, err = libvirtConn.DomainLookupByName(spec.Name); err == nil {
+		return nil, fmt.Errorf("creating domain %s: %w", spec.Name, ErrDomainExists)
+	}
+
+	domainXML, err := m.xmlBuilder.BuildImportXML(spec)
+	if err != nil {
+		return nil, fmt.Errorf("generating domain XML: %w", err)
+	}
+
+	domain, err := libvirtConn.DomainDefineXML(domainXML)
+	if err != nil {
+		return nil, fmt.Errorf("defining domain from XML: %w", err)
+	}
+
+	m.logger.Info("Defined imported domain",
+		logger.String("name", spec.Name),
+		logger.Int("disks", len(spec.Disks)),
+		logger.Int("networks", len(spec.Networks)))
+
+	return m.domainToVM(libvirtConn, domain)
+}
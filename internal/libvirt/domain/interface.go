@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+	"net"
+	"time"
 
 	"github.com/threatflux/libgo/internal/models/vm"
 )
@@ -26,12 +28,102 @@ type Manager interface {
 	// ForceStop forces a domain to stop
 	ForceStop(ctx context.Context, name string) error
 
+	// Reboot reboots a running domain
+	Reboot(ctx context.Context, name string) error
+
+	// Reset resets a running domain without involving the guest
+	Reset(ctx context.Context, name string) error
+
+	// WaitForShutoff waits up to timeout for a domain to shut off and reports whether it did
+	WaitForShutoff(ctx context.Context, name string, timeout time.Duration) (bool, error)
+
+	// Pause suspends a running domain
+	Pause(ctx context.Context, name string) error
+
+	// Resume resumes a paused domain
+	Resume(ctx context.Context, name string) error
+
+	// SendKeys sends a key combination to a domain
+	SendKeys(ctx context.Context, name string, keys []string) error
+
 	// Delete deletes a domain
 	Delete(ctx context.Context, name string) error
 
 	// GetXML gets the XML configuration of a domain
 	GetXML(ctx context.Context, name string) (string, error)
 
+	// GetDefinition gets the persistent XML definition of a domain
+	GetDefinition(ctx context.Context, name string) (string, error)
+
+	// UpdateDefinition replaces the persistent definition of a domain once libvirt validated it
+	UpdateDefinition(ctx context.Context, name string, definition string) (*vm.DefinitionUpdate, error)
+
+	// Update applies structured changes to the persistent definition of a domain
+	Update(ctx context.Context, name string, params vm.UpdateParams) (*vm.DefinitionUpdate, error)
+
+	// VirtioFSSupported reports whether the hypervisor can share host directories over virtio-fs
+	VirtioFSSupported(ctx context.Context) (bool, error)
+
+	// GetStats gets resource usage counters of a running domain
+	GetStats(ctx context.Context, name string) (*vm.Metrics, error)
+
+	// Guest agent operations
+	// GetGuestInfo gets OS, hostname and interface information from the guest agent
+	GetGuestInfo(ctx context.Context, name string) (*vm.GuestInfo, error)
+
+	// FreezeFilesystems freezes all guest filesystems and returns how many were frozen
+	FreezeFilesystems(ctx context.Context, name string) (int, error)
+
+	// ThawFilesystems thaws all guest filesystems and returns how many were thawed
+	ThawFilesystems(ctx context.Context, name string) (int, error)
+
+	// ShutdownGuest asks the guest agent to shut down the guest
+	ShutdownGuest(ctx context.Context, name string) error
+
+	// SetUserPassword sets the password of a user account in the guest
+	SetUserPassword(ctx context.Context, name string, username string, password string) error
+
+	// SyncTime sets the guest clock from the host clock
+	SyncTime(ctx context.Context, name string) error
+
+	// GuestExec runs a program in the guest and waits for it to exit
+	GuestExec(ctx context.Context, name string, command vm.GuestCommand) (*vm.GuestCommandResult, error)
+
+	// GetFilesystems lists the mounted guest filesystems and the disks they are on
+	GetFilesystems(ctx context.Context, name string) ([]vm.GuestFilesystem, error)
+
+	// Clone operations
+	// DefineClone defines a new domain from the definition of an existing one
+	DefineClone(ctx context.Context, sourceName string, spec CloneSpec) (*vm.VM, error)
+
+	// DefineFromDefinition defines a new domain from a saved domain definition, as DefineClone does
+	DefineFromDefinition(ctx context.Context, sourceXML string, spec CloneSpec) (*vm.VM, error)
+
+	// DefineImported defines a new domain around disks imported from elsewhere, without starting it
+	DefineImported(ctx context.Context, spec ImportSpec) (*vm.VM, error)
+
+	// CreateDiskOverlays puts temporary external overlays on the disks of a running domain
+	CreateDiskOverlays(ctx context.Context, name string) ([]DiskOverlay, error)
+
+	// CommitDiskOverlays merges temporary overlays back into their base images
+	CommitDiskOverlays(ctx context.Context, name string, overlays []DiskOverlay) error
+
+	// Migration operations
+	// Migrate migrates a domain to another host, reporting progress until it completes
+	Migrate(ctx context.Context, name string, opts MigrateOptions, progress func(MigrationProgress)) error
+
+	// StartPostCopy switches a running migration of a domain to post-copy mode
+	StartPostCopy(ctx context.Context, name string) error
+
+	// GetMigratableCPU gets the CPU definition a domain needs on another host
+	GetMigratableCPU(ctx context.Context, name string) (string, error)
+
+	// CompareCPU checks if the host can provide a CPU definition
+	CompareCPU(ctx context.Context, cpuXML string) (bool, error)
+
+	// GetFreeMemory gets the free memory of the host in bytes
+	GetFreeMemory(ctx context.Context) (uint64, error)
+
 	// Snapshot operations
 	// CreateSnapshot creates a new snapshot of a domain
 	CreateSnapshot(ctx context.Context, vmName string, params vm.SnapshotParams) (*vm.Snapshot, error)
@@ -47,10 +139,62 @@ type Manager interface {
 
 	// RevertSnapshot reverts a domain to a snapshot
 	RevertSnapshot(ctx context.Context, vmName string, snapshotName string) error
+
+	// ResizeDisk grows or shrinks a disk of a running domain, notifying the guest of the new size
+	ResizeDisk(ctx context.Context, name string, device string, capacityBytes uint64) error
+
+	// SetDiskIOTune replaces the I/O limits of a disk, applying them at once to a running domain
+	SetDiskIOTune(ctx context.Context, name string, device string, tune vm.DiskIOTune) error
+
+	// Block job operations
+	// BlockCommit starts merging images of a disk's backing chain into a lower image
+	BlockCommit(ctx context.Context, name string, params vm.BlockCommitParams) (*vm.BlockJob, error)
+
+	// BlockPull starts pulling backing data of a disk up into its active image
+	BlockPull(ctx context.Context, name string, params vm.BlockPullParams) (*vm.BlockJob, error)
+
+	// ListBlockJobs lists the running block jobs of a domain
+	ListBlockJobs(ctx context.Context, name string) ([]vm.BlockJob, error)
+
+	// CompleteBlockJob waits for the block job of a disk to finish, pivoting active commits
+	CompleteBlockJob(ctx context.Context, name string, device string) error
+
+	// AbortBlockJob cancels the block job of a disk
+	AbortBlockJob(ctx context.Context, name string, device string) error
+
+	// Backup operations
+	// BeginBackup starts a push-mode backup of a running domain and returns its persistent definition
+	BeginBackup(ctx context.Context, name string, params vm.BackupParams) (string, error)
+
+	// GetBackupJob gets the state of the backup job of a domain
+	GetBackupJob(ctx context.Context, name string) (*vm.BackupJobInfo, error)
+
+	// AbortBackup cancels the backup job of a domain
+	AbortBackup(ctx context.Context, name string) error
+
+	// ListCheckpoints lists the names of the checkpoints of a domain
+	ListCheckpoints(ctx context.Context, name string) ([]string, error)
+
+	// DeleteCheckpoint deletes a checkpoint of a domain
+	DeleteCheckpoint(ctx context.Context, name string, checkpoint string) error
+
+	// Console operations
+	// OpenConsole opens a bidirectional serial console session on a running domain
+	OpenConsole(ctx context.Context, name string, opts vm.ConsoleOptions) (vm.ConsoleStream, error)
+
+	// GetGraphics gets the graphics devices of a running domain
+	GetGraphics(ctx context.Context, name string) ([]vm.GraphicsInfo, error)
+
+	// OpenGraphics connects to the graphics device of a type of a running
+	// domain
+	OpenGraphics(ctx context.Context, name string, graphicsType vm.GraphicsType) (net.Conn, error)
 }
 
 // XMLBuilder defines interface for building domain XML.
 type XMLBuilder interface {
 	// BuildDomainXML builds XML for domain creation
 	BuildDomainXML(params vm.VMParams) (string, error)
+
+	// BuildImportXML builds XML for a domain using imported disks
+	BuildImportXML(spec ImportSpec) (string, error)
 }
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// libvirtIOTune represents the I/O limits of a disk in libvirt domain XML.
+type libvirtIOTune struct {
+	TotalBytesSec          uint64 `xml:"total_bytes_sec"`
+	ReadBytesSec           uint64 `xml:"read_bytes_sec"`
+	WriteBytesSec          uint64 `xml:"write_bytes_sec"`
+	TotalIOPSSec           uint64 `xml:"total_iops_sec"`
+	ReadIOPSSec            uint64 `xml:"read_iops_sec"`
+	WriteIOPSSec           uint64 `xml:"write_iops_sec"`
+	TotalBytesSecMax       uint64 `xml:"total_bytes_sec_max"`
+	ReadBytesSecMax        uint64 `xml:"read_bytes_sec_max"`
+	WriteBytesSecMax       uint64 `xml:"write_bytes_sec_max"`
+	TotalIOPSSecMax        uint64 `xml:"total_iops_sec_max"`
+	ReadIOPSSecMax         uint64 `xml:"read_iops_sec_max"`
+	WriteIOPSSecMax        uint64 `xml:"write_iops_sec_max"`
+	TotalBytesSecMaxLength uint64 `xml:"total_bytes_sec_max_length"`
+	ReadBytesSecMaxLength  uint64 `xml:"read_bytes_sec_max_length"`
+	WriteBytesSecMaxLength uint64 `xml:"write_bytes_sec_max_length"`
+	TotalIOPSSecMaxLength  uint64 `xml:"total_iops_sec_max_length"`
+	ReadIOPSSecMaxLength   uint64 `xml:"read_iops_sec_max_length"`
+	WriteIOPSSecMaxLength  uint64 `xml:"write_iops_sec_max_length"`
+}
+
+// IOTuneTemplate contains an I/O limit of a disk for the template, named
+// as the libvirt block I/O tune parameter it sets.
+type IOTuneTemplate struct {
+	Name  string
+	Value uint64
+}
+
+// ioTuneLimits returns every limit of an I/O tune, unlimited ones included,
+// named as libvirt names them. The burst length applies to each burst limit
+// that is set.
+func ioTuneLimits(tune vm.DiskIOTune) []IOTuneTemplate {
+	burstLength := func(limit uint64) uint64 {
+		if limit == 0 {
+			return 0
+		}
+		return tune.BurstSeconds
+	}
+
+	return []IOTuneTemplate{
+		{libvirt.DomainBlockIotuneTotalBytesSec, tune.TotalBytesSec},
+		{libvirt.DomainBlockIotuneReadBytesSec, tune.ReadBytesSec},
+		{libvirt.DomainBlockIotuneWriteBytesSec, tune.WriteBytesSec},
+		{libvirt.DomainBlockIotuneTotalIopsSec, tune.TotalIOPSSec},
+		{libvirt.DomainBlockIotuneReadIopsSec, tune.ReadIOPSSec},
+		{libvirt.DomainBlockIotuneWriteIopsSec, tune.WriteIOPSSec},
+		{libvirt.DomainBlockIotuneTotalBytesSecMax, tune.TotalBytesSecMax},
+		{libvirt.DomainBlockIotuneReadBytesSecMax, tune.ReadBytesSecMax},
+		{libvirt.DomainBlockIotuneWriteBytesSecMax, tune.WriteBytesSecMax},
+		{libvirt.DomainBlockIotuneTotalIopsSecMax, tune.TotalIOPSSecMax},
+		{libvirt.DomainBlockIotuneReadIopsSecMax, tune.ReadIOPSSecMax},
+		{libvirt.DomainBlockIotuneWriteIopsSecMax, tune.WriteIOPSSecMax},
+		{libvirt.DomainBlockIotuneTotalBytesSecMaxLength, burstLength(tune.TotalBytesSecMax)},
+		{libvirt.DomainBlockIotuneReadBytesSecMaxLength, burstLength(tune.ReadBytesSecMax)},
+		{libvirt.DomainBlockIotuneWriteBytesSecMaxLength, burstLength(tune.WriteBytesSecMax)},
+		{libvirt.DomainBlockIotuneTotalIopsSecMaxLength, burstLength(tune.TotalIOPSSecMax)},
+		{libvirt.DomainBlockIotuneReadIopsSecMaxLength, burstLength(tune.ReadIOPSSecMax)},
+		{libvirt.DomainBlockIotuneWriteIopsSecMaxLength, burstLength(tune.WriteIOPSSecMax)},
+	}
+}
+
+// ioTuneTemplates returns the limits of an I/O tune the domain XML sets.
+func ioTuneTemplates(tune *vm.DiskIOTune) []IOTuneTemplate {
+	if tune == nil {
+		return nil
+	}
+
+	var limits []IOTuneTemplate
+	for _, limit := range ioTuneLimits(*tune) {
+		if limit.Value != 0 {
+			limits = append(limits, limit)
+		}
+	}
+	return limits
+}
+
+// toDiskIOTune converts the I/O limits of a disk in domain XML. Disks
+// without limits have none.
+func (t *libvirtIOTune) toDiskIOTune() *vm.DiskIOTune {
+	if t == nil {
+		return nil
+	}
+
+	tune := &vm.DiskIOTune{
+		TotalBytesSec:    t.TotalBytesSec,
+		ReadBytesSec:     t.ReadBytesSec,
+		WriteBytesSec:    t.WriteBytesSec,
+		TotalIOPSSec:     t.TotalIOPSSec,
+		ReadIOPSSec:      t.ReadIOPSSec,
+		WriteIOPSSec:     t.WriteIOPSSec,
+		TotalBytesSecMax: t.TotalBytesSecMax,
+		ReadBytesSecMax:  t.ReadBytesSecMax,
+		WriteBytesSecMax: t.WriteBytesSecMax,
+		TotalIOPSSecMax:  t.TotalIOPSSecMax,
+		ReadIOPSSecMax:   t.ReadIOPSSecMax,
+		WriteIOPSSecMax:  t.WriteIOPSSecMax,
+		// Limits set elsewhere may have bursts of different lengths
+		BurstSeconds: max(t.TotalBytesSecMaxLength, t.ReadBytesSecMaxLength, t.WriteBytesSecMaxLength,
+			t.TotalIOPSSecMaxLength, t.ReadIOPSSecMaxLength, t.WriteIOPSSecMaxLength),
+	}
+	if tune.IsZero() {
+		return nil
+	}
+	return tune
+}
+
+// SetDiskIOTune implements Manager.SetDiskIOTune.
+func (m *DomainManager) SetDiskIOTune(ctx context.Context, name string, device string, tune vm.DiskIOTune) error {
+	live := false
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		domainXML, state, _, err := m.getDomainInfo(libvirtConn, domain)
+		if err != nil {
+			return err
+		}
+
+		disk := findDisk(domainXML.Devices.Disks, device)
+		if disk == nil || disk.Device != "disk" {
+			return fmt.Errorf("%w: %s on %s", ErrDiskNotFound, device, name)
+		}
+
+		// Every limit is passed, so limits left out are removed
+		limits := ioTuneLimits(tune)
+		params := make([]libvirt.TypedParam, 0, len(limits))
+		for _, limit := range limits {
+			params = append(params, libvirt.TypedParam{
+				Field: limit.Name,
+				Value: *libvirt.NewTypedParamValueUllong(limit.Value),
+			})
+		}
+
+		// Running domains take the limits at once and keep them after a
+		// restart
+		flags := libvirt.DomainAffectConfig
+		live = libvirt.DomainState(state) == libvirt.DomainRunning || libvirt.DomainState(state) == libvirt.DomainPaused
+		if live {
+			flags |= libvirt.DomainAffectLive
+		}
+
+		if err := libvirtConn.DomainSetBlockIOTune(domain, device, params, uint32(flags)); err != nil {
+			return fmt.Errorf("setting I/O limits of disk %s: %w", device, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Set disk I/O limits",
+		logger.String("name", name),
+		logger.String("device", device),
+		logger.Bool("live", live))
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+func TestTemplateXMLBuilder_BuildDomainXML_DiskTuning(t *testing.T) {
+	templateLoader, err := xmlutils.NewTemplateLoader(filepath.Join("..", "..", "..", "configs", "templates", "domain"))
+	require.NoError(t, err)
+
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+	builder := NewTemplateXMLBuilder(templateLoader, mockLog)
+
+	tune := &vm.DiskIOTune{
+		TotalIOPSSec:     2000,
+		TotalIOPSSecMax:  4000,
+		ReadBytesSec:     100 << 20,
+		WriteBytesSec:    50 << 20,
+		WriteBytesSecMax: 200 << 20,
+		BurstSeconds:     10,
+	}
+	params := vm.VMParams{
+		Name:   "test-vm",
+		CPU:    vm.CPUParams{Count: 1},
+		Memory: vm.MemoryParams{SizeBytes: 1 << 30},
+		Disk: vm.DiskParams{
+			Format:  "qcow2",
+			IOMode:  vm.DiskIOModeNative,
+			Discard: vm.DiskDiscardUnmap,
+			IOTune:  tune,
+			Source:  &vm.DiskSource{Type: vm.DiskTypeFile, Path: "/var/lib/libvirt/images/test-vm-disk-0"},
+		},
+	}
+
+	domainXML, err := builder.BuildDomainXML(params)
+	require.NoError(t, err)
+	doc, err := xmlutils.LoadXMLDocumentFromString(domainXML)
+	require.NoError(t, err)
+
+	disk := xmlutils.FindElement(doc, "/domain/devices/disk[@device='disk']")
+	driver := disk.SelectElement("driver")
+	// Native I/O gets the default cache mode, which bypasses the page cache
+	assert.Equal(t, "none", xmlutils.GetElementAttribute(driver, "cache"))
+	assert.Equal(t, "native", xmlutils.GetElementAttribute(driver, "io"))
+	assert.Equal(t, "unmap", xmlutils.GetElementAttribute(driver, "discard"))
+	assert.Equal(t, "2000", disk.FindElement("iotune/total_iops_sec").Text())
+	assert.Equal(t, "10", disk.FindElement("iotune/write_bytes_sec_max_length").Text())
+	assert.Nil(t, disk.FindElement("iotune/total_bytes_sec"))
+	assert.Nil(t, disk.FindElement("iotune/read_bytes_sec_max_length"))
+
+	// The settings read back as they were given
+	var domain libvirtDomain
+	require.NoError(t, xml.Unmarshal([]byte(domainXML), &domain))
+	disks := (&DomainManager{}).processDomainDisks(domain.Devices.Disks)
+	require.Len(t, disks, 1)
+	assert.Equal(t, "none", disks[0].CacheMode)
+	assert.Equal(t, "native", disks[0].IOMode)
+	assert.Equal(t, "unmap", disks[0].Discard)
+	assert.Equal(t, tune, disks[0].IOTune)
+
+	// Disks without settings leave them to the hypervisor
+	params.Disk = vm.DiskParams{
+		Format: "qcow2",
+		Source: &vm.DiskSource{Type: vm.DiskTypeFile, Path: "/var/lib/libvirt/images/test-vm-disk-0"},
+	}
+	domainXML, err = builder.BuildDomainXML(params)
+	require.NoError(t, err)
+	assert.Contains(t, domainXML, `<driver name='qemu' type='qcow2'/>`)
+	assert.NotContains(t, domainXML, "<iotune>")
+}
+
+func TestDiskIOTune_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		tune  vm.DiskIOTune
+		valid bool
+	}{
+		{"Unlimited", vm.DiskIOTune{}, true},
+		{"Read and write limits", vm.DiskIOTune{ReadBytesSec: 1000, WriteIOPSSec: 100, WriteIOPSSecMax: 200, BurstSeconds: 5}, true},
+		{"Total and write limits", vm.DiskIOTune{TotalIOPSSec: 100, WriteIOPSSec: 100}, false},
+		{"Burst without limit", vm.DiskIOTune{ReadBytesSecMax: 1000}, false},
+		{"Burst below limit", vm.DiskIOTune{TotalBytesSec: 1000, TotalBytesSecMax: 500}, false},
+		{"Burst length without burst", vm.DiskIOTune{TotalBytesSec: 1000, BurstSeconds: 5}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.tune.Validate()
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidKey is returned when a key name cannot be mapped to a keycode.
+var ErrInvalidKey = fmt.Errorf("invalid key")
+
+const (
+	// sendKeyHoldTime is how long keys are held down, in milliseconds.
+	sendKeyHoldTime = 50
+
+	// maxSendKeys is the maximum number of keys libvirt accepts at once.
+	maxSendKeys = 16
+)
+
+// linuxKeycodes maps key names to Linux input keycodes (KEY_* in
+// linux/input-event-codes.h), the codeset used for DomainSendKey.
+var linuxKeycodes = map[string]uint32{
+	"esc": 1, "1": 2, "2": 3, "3": 4, "4": 5, "5": 6, "6": 7, "7": 8, "8": 9, "9": 10, "0": 11,
+	"minus": 12, "equal": 13, "backspace": 14, "tab": 15,
+	"q": 16, "w": 17, "e": 18, "r": 19, "t": 20, "y": 21, "u": 22, "i": 23, "o": 24, "p": 25,
+	"leftbrace": 26, "rightbrace": 27, "enter": 28, "leftctrl": 29,
+	"a": 30, "s": 31, "d": 32, "f": 33, "g": 34, "h": 35, "j": 36, "k": 37, "l": 38,
+	"semicolon": 39, "apostrophe": 40, "grave": 41, "leftshift": 42, "backslash": 43,
+	"z": 44, "x": 45, "c": 46, "v": 47, "b": 48, "n": 49, "m": 50,
+	"comma": 51, "dot": 52, "slash": 53, "rightshift": 54, "kpasterisk": 55, "leftalt": 56,
+	"space": 57, "capslock": 58,
+	"f1": 59, "f2": 60, "f3": 61, "f4": 62, "f5": 63, "f6": 64, "f7": 65, "f8": 66, "f9": 67, "f10": 68,
+	"numlock": 69, "scrolllock": 70, "f11": 87, "f12": 88,
+	"rightctrl": 97, "sysrq": 99, "rightalt": 100,
+	"home": 102, "up": 103, "pageup": 104, "left": 105, "right": 106, "end": 107, "down": 108,
+	"pagedown": 109, "insert": 110, "delete": 111, "pause": 119,
+	"leftmeta": 125, "rightmeta": 126, "menu": 139,
+}
+
+// keyAliases maps common shorthand key names to canonical names.
+var keyAliases = map[string]string{
+	"ctrl":        "leftctrl",
+	"control":     "leftctrl",
+	"alt":         "leftalt",
+	"shift":       "leftshift",
+	"meta":        "leftmeta",
+	"super":       "leftmeta",
+	"win":         "leftmeta",
+	"del":         "delete",
+	"ins":         "insert",
+	"escape":      "esc",
+	"return":      "enter",
+	"pgup":        "pageup",
+	"pgdn":        "pagedown",
+	"printscreen": "sysrq",
+}
+
+// parseKeycodes converts key names such as "ctrl", "KEY_LEFTALT" or raw
+// numeric keycodes into Linux keycodes.
+func parseKeycodes(keys []string) ([]uint32, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys specified: %w", ErrInvalidKey)
+	}
+	if len(keys) > maxSendKeys {
+		return nil, fmt.Errorf("at most %d keys can be sent at once: %w", maxSendKeys, ErrInvalidKey)
+	}
+
+	keycodes := make([]uint32, 0, len(keys))
+	for _, key := range keys {
+		name := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(key)), "key_")
+
+		if alias, ok := keyAliases[name]; ok {
+			name = alias
+		}
+
+		if code, ok := linuxKeycodes[name]; ok {
+			keycodes = append(keycodes, code)
+			continue
+		}
+
+		// Single digits are key names, anything else numeric is a raw keycode
+		if code, err := strconv.ParseUint(name, 10, 16); err == nil {
+			keycodes = append(keycodes, uint32(code))
+			continue
+		}
+
+		return nil, fmt.Errorf("key %q: %w", key, ErrInvalidKey)
+	}
+
+	return keycodes, nil
+}
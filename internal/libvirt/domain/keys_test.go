@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeycodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []string
+		want    []uint32
+		wantErr bool
+	}{
+		{
+			name: "Ctrl-Alt-Delete with aliases",
+			keys: []string{"ctrl", "alt", "del"},
+			want: []uint32{29, 56, 111},
+		},
+		{
+			name: "Linux key names",
+			keys: []string{"KEY_LEFTCTRL", "KEY_LEFTALT", "KEY_F2"},
+			want: []uint32{29, 56, 60},
+		},
+		{
+			name: "Digit key and raw keycode",
+			keys: []string{"1", "28"},
+			want: []uint32{2, 28},
+		},
+		{
+			name:    "Unknown key",
+			keys:    []string{"ctrl", "hyper"},
+			wantErr: true,
+		},
+		{
+			name:    "No keys",
+			keys:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "Too many keys",
+			keys:    make([]string, maxSendKeys+1),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKeycodes(tt.keys)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidKey)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
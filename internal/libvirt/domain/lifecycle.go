@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// shutoffPollInterval is how often the domain state is checked while waiting
+// for shutdown, in case a lifecycle event is missed.
+const shutoffPollInterval = time.Second
+
+// WaitForShutoff implements Manager.WaitForShutoff.
+func (m *DomainManager) WaitForShutoff(ctx context.Context, name string, timeout time.Duration) (bool, error) {
+	var stopped bool
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		// Subscribe before checking the state, so that a shutdown in between
+		// is not missed
+		events, err := libvirtConn.LifecycleEvents(waitCtx)
+		if err != nil {
+			m.logger.Debug("Lifecycle events unavailable, polling domain state",
+				logger.String("name", name),
+				logger.Error(err))
+		}
+
+		ticker := time.NewTicker(shutoffPollInterval)
+		defer ticker.Stop()
+
+		for {
+			state, _, _, _, _, err := libvirtConn.DomainGetInfo(domain) //nolint:dogsled
+			if err != nil {
+				return fmt.Errorf("getting domain info: %w", err)
+			}
+
+			if libvirt.DomainState(state) == libvirt.DomainShutoff {
+				stopped = true
+				return nil
+			}
+
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// Keep polling if the event stream broke
+					events = nil
+					continue
+				}
+				if event.Dom.Name == name && libvirt.DomainEventType(event.Event) == libvirt.DomainEventStopped {
+					stopped = true
+					return nil
+				}
+			case <-ticker.C:
+			case <-waitCtx.Done():
+				// The grace period ending is not an error, the caller
+				// being cancelled is
+				if err := ctx.Err(); err != nil {
+					return fmt.Errorf("waiting for domain %s to shut off: %w", name, err)
+				}
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return stopped, nil
+}
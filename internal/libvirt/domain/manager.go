@@ -77,14 +77,23 @@ type libvirtDisk struct {
 		// String fields (16 bytes each) - group together
 		File    string `xml:"file,attr"`
 		Pool    string `xml:"pool,attr"`
+		Volume  string `xml:"volume,attr"`
 		Dev     string `xml:"dev,attr"`
 		Bridge  string `xml:"bridge,attr"`
 		Network string `xml:"network,attr"`
+		// Network disks name their image, such as pool/image for rbd
+		Protocol string `xml:"protocol,attr"`
+		Name     string `xml:"name,attr"`
+		// Encrypted disks may carry their encryption in the source
+		Encryption *struct{} `xml:"encryption"`
 	} `xml:"source"`
 	// Medium struct fields (2 strings each ≈ 32 bytes)
 	Driver struct {
-		Name string `xml:"name,attr"` // 16 bytes
-		Type string `xml:"type,attr"` // 16 bytes
+		Name    string `xml:"name,attr"`    // 16 bytes
+		Type    string `xml:"type,attr"`    // 16 bytes
+		Cache   string `xml:"cache,attr"`   // 16 bytes
+		IO      string `xml:"io,attr"`      // 16 bytes
+		Discard string `xml:"discard,attr"` // 16 bytes
 	} `xml:"driver"`
 	Target struct {
 		Dev string `xml:"dev,attr"` // 16 bytes
@@ -98,8 +107,21 @@ type libvirtDisk struct {
 	Type   string `xml:"type,attr"`
 	Device string `xml:"device,attr"`
 	// Pointer fields (8 bytes each) - group together
-	ReadOnly  *struct{} `xml:"readonly"`
-	Shareable *struct{} `xml:"shareable"`
+	ReadOnly     *struct{}            `xml:"readonly"`
+	Shareable    *struct{}            `xml:"shareable"`
+	BackingStore *libvirtBackingStore `xml:"backingStore"`
+	Encryption   *struct{}            `xml:"encryption"`
+	IOTune       *libvirtIOTune       `xml:"iotune"`
+}
+
+// libvirtBackingStore represents an image in the backing chain of a disk.
+// The chain ends with an empty element.
+type libvirtBackingStore struct {
+	Source struct {
+		File string `xml:"file,attr"`
+		Dev  string `xml:"dev,attr"`
+	} `xml:"source"`
+	BackingStore *libvirtBackingStore `xml:"backingStore"`
 }
 
 // libvirtInterface represents an interface in libvirt domain XML.
@@ -314,6 +336,121 @@ func (m *DomainManager) ForceStop(ctx context.Context, name string) error {
 	})
 }
 
+// Reboot implements Manager.Reboot.
+func (m *DomainManager) Reboot(ctx context.Context, name string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		state, _, _, _, _, err := libvirtConn.DomainGetInfo(domain) //nolint:dogsled
+		if err != nil {
+			return fmt.Errorf("getting domain info: %w", err)
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainRunning {
+			return fmt.Errorf("rebooting domain %s: %w", name, ErrDomainNotRunning)
+		}
+
+		// Let the hypervisor pick the best available method
+		if err := libvirtConn.DomainReboot(domain, libvirt.DomainRebootDefault); err != nil {
+			return fmt.Errorf("rebooting domain: %w", err)
+		}
+
+		m.logger.Info("Rebooted domain", logger.String("name", name))
+		return nil
+	})
+}
+
+// Reset implements Manager.Reset.
+func (m *DomainManager) Reset(ctx context.Context, name string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		state, _, _, _, _, err := libvirtConn.DomainGetInfo(domain) //nolint:dogsled
+		if err != nil {
+			return fmt.Errorf("getting domain info: %w", err)
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainRunning {
+			return fmt.Errorf("resetting domain %s: %w", name, ErrDomainNotRunning)
+		}
+
+		// Hard reset without involving the guest
+		if err := libvirtConn.DomainReset(domain, 0); err != nil {
+			return fmt.Errorf("resetting domain: %w", err)
+		}
+
+		m.logger.Info("Reset domain", logger.String("name", name))
+		return nil
+	})
+}
+
+// Pause implements Manager.Pause.
+func (m *DomainManager) Pause(ctx context.Context, name string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		state, _, _, _, _, err := libvirtConn.DomainGetInfo(domain) //nolint:dogsled
+		if err != nil {
+			return fmt.Errorf("getting domain info: %w", err)
+		}
+
+		if libvirt.DomainState(state) == libvirt.DomainPaused {
+			m.logger.Info("Domain already paused", logger.String("name", name))
+			return nil
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainRunning {
+			return fmt.Errorf("pausing domain %s: %w", name, ErrDomainNotRunning)
+		}
+
+		if err := libvirtConn.DomainSuspend(domain); err != nil {
+			return fmt.Errorf("pausing domain: %w", err)
+		}
+
+		m.logger.Info("Paused domain", logger.String("name", name))
+		return nil
+	})
+}
+
+// Resume implements Manager.Resume.
+func (m *DomainManager) Resume(ctx context.Context, name string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		state, _, _, _, _, err := libvirtConn.DomainGetInfo(domain) //nolint:dogsled
+		if err != nil {
+			return fmt.Errorf("getting domain info: %w", err)
+		}
+
+		if libvirt.DomainState(state) == libvirt.DomainRunning {
+			m.logger.Info("Domain already running", logger.String("name", name))
+			return nil
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainPaused {
+			return fmt.Errorf("resuming domain %s: %w", name, ErrDomainNotRunning)
+		}
+
+		if err := libvirtConn.DomainResume(domain); err != nil {
+			return fmt.Errorf("resuming domain: %w", err)
+		}
+
+		m.logger.Info("Resumed domain", logger.String("name", name))
+		return nil
+	})
+}
+
+// SendKeys implements Manager.SendKeys.
+func (m *DomainManager) SendKeys(ctx context.Context, name string, keys []string) error {
+	keycodes, err := parseKeycodes(keys)
+	if err != nil {
+		return err
+	}
+
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		if err := libvirtConn.DomainSendKey(domain, uint32(libvirt.KeycodeSetLinux), sendKeyHoldTime, keycodes, 0); err != nil {
+			return fmt.Errorf("sending keys: %w", err)
+		}
+
+		m.logger.Debug("Sent keys to domain",
+			logger.String("name", name),
+			logger.Int("count", len(keycodes)))
+		return nil
+	})
+}
+
 // Delete implements Manager.Delete.
 func (m *DomainManager) Delete(ctx context.Context, name string) error {
 	// Get libvirt connection
@@ -506,6 +643,8 @@ func (m *DomainManager) processDomainDisks(disks []libvirtDisk) []vm.DiskInfo {
 			path = disk.Source.File
 		case disk.Source.Dev != "":
 			path = disk.Source.Dev
+		case disk.Source.Name != "":
+			path = disk.Source.Name
 		}
 
 		if disk.Source.Pool != "" {
@@ -520,9 +659,16 @@ func (m *DomainManager) processDomainDisks(disks []libvirtDisk) []vm.DiskInfo {
 			ReadOnly:    disk.ReadOnly != nil,
 			Bootable:    disk.Boot.Order > 0,
 			Shareable:   disk.Shareable != nil,
+			Encrypted:   disk.Encryption != nil || disk.Source.Encryption != nil,
 			Serial:      "", // NOTE: Serial generation not implemented yet
 			StoragePool: storagePool,
+			PoolName:    storagePool,
+			VolumeName:  disk.Source.Volume,
 			Device:      disk.Target.Dev,
+			CacheMode:   disk.Driver.Cache,
+			IOMode:      disk.Driver.IO,
+			Discard:     disk.Driver.Discard,
+			IOTune:      disk.IOTune.toDiskIOTune(),
 		}
 
 		result = append(result, diskInfo)
@@ -607,6 +753,10 @@ func (m *DomainManager) CreateSnapshot(ctx context.Context, vmName string, param
 		return nil, fmt.Errorf("failed to get domain: %w", err)
 	}
 
+	if params.External {
+		return m.createExternalSnapshot(libvirtConn, dom, vmName, params)
+	}
+
 	// Build snapshot XML
 	snapshotXML := buildSnapshotXML(params)
 
@@ -615,8 +765,15 @@ func (m *DomainManager) CreateSnapshot(ctx context.Context, vmName string, param
 	if params.IncludeMemory {
 		flags |= libvirt.DomainSnapshotCreateLive
 	}
+
+	// Libvirt only quiesces disk-only snapshots, so freeze the guest
+	// filesystems through the agent for the duration of the snapshot
 	if params.Quiesce {
-		flags |= libvirt.DomainSnapshotCreateQuiesce
+		thaw, freezeErr := m.freezeForSnapshot(libvirtConn, dom, vmName)
+		if freezeErr != nil {
+			return nil, freezeErr
+		}
+		defer thaw()
 	}
 
 	// Create the snapshot
@@ -629,6 +786,37 @@ func (m *DomainManager) CreateSnapshot(ctx context.Context, vmName string, param
 	return m.getSnapshotInfo(libvirtConn, snapshot)
 }
 
+// freezeForSnapshot freezes the guest filesystems of a running domain and
+// returns a function that thaws them again.
+func (m *DomainManager) freezeForSnapshot(libvirtConn *libvirt.Libvirt, dom libvirt.Domain, vmName string) (func(), error) {
+	state, _, _, _, _, err := libvirtConn.DomainGetInfo(dom) //nolint:dogsled
+	if err != nil {
+		return nil, fmt.Errorf("getting domain info: %w", err)
+	}
+
+	// Filesystems of a stopped domain are already consistent
+	if libvirt.DomainState(state) != libvirt.DomainRunning {
+		return func() {}, nil
+	}
+
+	frozen, err := libvirtConn.DomainFsfreeze(dom, nil, 0)
+	if err != nil {
+		return nil, guestAgentError("quiescing guest filesystems", err)
+	}
+
+	m.logger.Debug("Froze guest filesystems for snapshot",
+		logger.String("vm", vmName),
+		logger.Int("count", int(frozen)))
+
+	return func() {
+		if _, thawErr := libvirtConn.DomainFsthaw(dom, nil, 0); thawErr != nil {
+			m.logger.Error("Failed to thaw guest filesystems after snapshot",
+				logger.String("vm", vmName),
+				logger.Error(thawErr))
+		}
+	}, nil
+}
+
 // ListSnapshots lists all snapshots for a domain.
 func (m *DomainManager) ListSnapshots(ctx context.Context, vmName string, opts vm.SnapshotListOptions) ([]*vm.Snapshot, error) {
 	// Get libvirt connection
@@ -648,9 +836,6 @@ func (m *DomainManager) ListSnapshots(ctx context.Context, vmName string, opts v
 
 	// List all snapshots
 	var flags libvirt.DomainSnapshotListFlags
-	if opts.Tree {
-		flags |= libvirt.DomainSnapshotListRoots
-	}
 	if opts.IncludeMetadata {
 		flags |= libvirt.DomainSnapshotListMetadata
 	}
@@ -671,6 +856,10 @@ func (m *DomainManager) ListSnapshots(ctx context.Context, vmName string, opts v
 		result = append(result, info)
 	}
 
+	if opts.Tree {
+		return buildSnapshotTree(result), nil
+	}
+
 	return result, nil
 }
 
@@ -724,6 +913,15 @@ func (m *DomainManager) DeleteSnapshot(ctx context.Context, vmName string, snaps
 		return fmt.Errorf("failed to get snapshot: %w", err)
 	}
 
+	// External snapshots are merged into their backing images first
+	snapInfo, err := getSnapshotXML(libvirtConn, snapshot)
+	if err != nil {
+		return err
+	}
+	if snapInfo.isExternal() {
+		return m.deleteExternalSnapshot(ctx, libvirtConn, dom, vmName, snapshot, snapInfo)
+	}
+
 	// Delete snapshot
 	err = libvirtConn.DomainSnapshotDelete(snapshot, 0)
 	if err != nil {
@@ -767,16 +965,9 @@ func (m *DomainManager) RevertSnapshot(ctx context.Context, vmName string, snaps
 
 // getSnapshotInfo retrieves information about a snapshot.
 func (m *DomainManager) getSnapshotInfo(conn *libvirt.Libvirt, snapshot libvirt.DomainSnapshot) (*vm.Snapshot, error) {
-	// Get snapshot XML
-	xmlDesc, err := conn.DomainSnapshotGetXMLDesc(snapshot, 0)
+	snapInfo, err := getSnapshotXML(conn, snapshot)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get snapshot XML: %w", err)
-	}
-
-	// Parse snapshot XML
-	var snapInfo snapshotXML
-	if unmarshalErr := xml.Unmarshal([]byte(xmlDesc), &snapInfo); unmarshalErr != nil {
-		return nil, fmt.Errorf("failed to parse snapshot XML: %w", unmarshalErr)
+		return nil, err
 	}
 
 	// Check if snapshot is current
@@ -804,20 +995,48 @@ func (m *DomainManager) getSnapshotInfo(conn *libvirt.Libvirt, snapshot libvirt.
 		HasMetadata: hasMetadata != 0,
 		HasMemory:   snapInfo.Memory != nil,
 		HasDisk:     len(snapInfo.Disks) > 0,
+		External:    snapInfo.isExternal(),
+	}
+
+	for _, disk := range snapInfo.Disks {
+		result.Disks = append(result.Disks, vm.SnapshotDisk{
+			Device:   disk.Name,
+			Snapshot: disk.Snapshot,
+			File:     disk.Source.File,
+		})
 	}
 
 	return result, nil
 }
 
+// getSnapshotXML gets and parses the XML description of a snapshot.
+func getSnapshotXML(conn *libvirt.Libvirt, snapshot libvirt.DomainSnapshot) (*snapshotXML, error) {
+	xmlDesc, err := conn.DomainSnapshotGetXMLDesc(snapshot, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot XML: %w", err)
+	}
+
+	var snapInfo snapshotXML
+	if unmarshalErr := xml.Unmarshal([]byte(xmlDesc), &snapInfo); unmarshalErr != nil {
+		return nil, fmt.Errorf("failed to parse snapshot XML: %w", unmarshalErr)
+	}
+
+	return &snapInfo, nil
+}
+
 // snapshotXML represents libvirt snapshot XML structure.
 // Field alignment optimized: XMLName/slices first, then strings, int64, pointers.
 type snapshotXML struct {
 	// XMLName field (24 bytes) - must be first for xml package
 	XMLName xml.Name `xml:"domainsnapshot"`
 	// Slice fields (24 bytes)
-	Disks []struct {
-		Name string `xml:"name,attr"` // 16 bytes
-	} `xml:"disks>disk,omitempty"`
+	Disks []snapshotDiskXML `xml:"disks>disk,omitempty"`
+	// Domain definition at the time of the snapshot
+	Domain struct {
+		Devices struct {
+			Disks []libvirtDisk `xml:"disk"`
+		} `xml:"devices"`
+	} `xml:"domain"`
 	// String fields (16 bytes each) - group together
 	Name        string `xml:"name"`
 	Description string `xml:"description,omitempty"`
@@ -829,6 +1048,15 @@ type snapshotXML struct {
 	Memory *struct{} `xml:"memory,omitempty"`
 }
 
+// snapshotDiskXML represents a disk of a snapshot.
+type snapshotDiskXML struct {
+	Source struct {
+		File string `xml:"file,attr"`
+	} `xml:"source"`
+	Name     string `xml:"name,attr"`
+	Snapshot string `xml:"snapshot,attr"`
+}
+
 // buildSnapshotXML builds XML for snapshot creation.
 func buildSnapshotXML(params vm.SnapshotParams) string {
 	xml := fmt.Sprintf(`<domainsnapshot>
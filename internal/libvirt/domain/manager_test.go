@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/digitalocean/go-libvirt"
@@ -49,6 +50,10 @@ func (m *mockXMLBuilder) BuildDomainXML(params vm.VMParams) (string, error) {
 	return m.buildFn(params)
 }
 
+func (m *mockXMLBuilder) BuildImportXML(spec ImportSpec) (string, error) {
+	return "", errors.New("not implemented")
+}
+
 // Mock libvirt connection - we need to embed *libvirt.Libvirt properly
 type mockLibvirtWithDomain struct {
 	*libvirt.Libvirt
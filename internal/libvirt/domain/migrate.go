@@ -0,0 +1,281 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/pkg/logger"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+// migrationPollInterval is how often migration progress is checked.
+const migrationPollInterval = time.Second
+
+// MigrateOptions describes how a domain is migrated to another host.
+type MigrateOptions struct {
+	// TargetURI is the libvirt URI of the destination host
+	TargetURI string
+	// Bandwidth limits the migration bandwidth in MiB/s, zero is unlimited
+	Bandwidth uint64
+	// Live keeps the domain running while its memory is copied
+	Live bool
+	// Offline migrates only the definition of the domain
+	Offline bool
+	// Persistent defines the domain on the destination
+	Persistent bool
+	// Undefine removes the definition from the source after migration
+	Undefine bool
+	// Compressed compresses memory pages sent to the destination
+	Compressed bool
+	// CopyStorage copies all disks to hosts without shared storage
+	CopyStorage bool
+	// PostCopy allows switching the migration to post-copy mode
+	PostCopy bool
+}
+
+// MigrationProgress reports the progress of a running migration.
+type MigrationProgress struct {
+	// Elapsed is the time since the migration started
+	Elapsed time.Duration
+	// DataTotal is the amount of memory and disk data to transfer, in bytes
+	DataTotal uint64
+	// DataProcessed is the amount of data transferred so far, in bytes
+	DataProcessed uint64
+	// DataRemaining is the amount of data left to transfer, in bytes
+	DataRemaining uint64
+}
+
+// Migrate implements Manager.Migrate.
+func (m *DomainManager) Migrate(ctx context.Context, name string, opts MigrateOptions, progress func(MigrationProgress)) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		m.logger.Info("Migrating domain",
+			logger.String("name", name),
+			logger.String("target", opts.TargetURI),
+			logger.Bool("live", opts.Live),
+			logger.Bool("copyStorage", opts.CopyStorage))
+
+		// The source daemon connects to the destination itself
+		// (peer-to-peer), so the call blocks until the migration ends
+		done := make(chan error, 1)
+		go func() {
+			_, err := libvirtConn.DomainMigratePerform3Params(domain, libvirt.OptString{opts.TargetURI},
+				migrateParams(opts), nil, migrateFlags(opts))
+			done <- err
+		}()
+
+		ticker := time.NewTicker(migrationPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case err := <-done:
+				if err != nil {
+					return fmt.Errorf("migrating domain to %s: %w", opts.TargetURI, err)
+				}
+
+				m.logger.Info("Migrated domain",
+					logger.String("name", name),
+					logger.String("target", opts.TargetURI))
+				return nil
+			case <-ticker.C:
+				if progress == nil {
+					continue
+				}
+				jobType, params, err := libvirtConn.DomainGetJobStats(domain, 0)
+				if err != nil || libvirt.DomainJobType(jobType) == libvirt.DomainJobNone {
+					continue
+				}
+				progress(parseJobStats(params))
+			case <-ctx.Done():
+				if err := libvirtConn.DomainAbortJob(domain); err != nil {
+					m.logger.Warn("Failed to abort migration",
+						logger.String("name", name),
+						logger.Error(err))
+				}
+
+				// Keep the connection until the migration call returns; it
+				// may still have completed before the abort
+				if err := <-done; err == nil {
+					return nil
+				}
+				return fmt.Errorf("migrating domain %s: %w", name, ctx.Err())
+			}
+		}
+	})
+}
+
+// StartPostCopy implements Manager.StartPostCopy.
+func (m *DomainManager) StartPostCopy(ctx context.Context, name string) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		if err := libvirtConn.DomainMigrateStartPostCopy(domain, 0); err != nil {
+			return fmt.Errorf("switching migration to post-copy: %w", err)
+		}
+
+		m.logger.Info("Switched migration to post-copy", logger.String("name", name))
+		return nil
+	})
+}
+
+// GetMigratableCPU implements Manager.GetMigratableCPU.
+func (m *DomainManager) GetMigratableCPU(ctx context.Context, name string) (string, error) {
+	var cpuXML string
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		// Expand host-model CPUs to the model the domain actually runs with
+		domainXML, err := libvirtConn.DomainGetXMLDesc(domain, libvirt.DomainXMLUpdateCPU|libvirt.DomainXMLMigratable)
+		if err != nil {
+			return fmt.Errorf("getting domain XML: %w", err)
+		}
+
+		capabilities, err := libvirtConn.ConnectGetCapabilities()
+		if err != nil {
+			return fmt.Errorf("getting host capabilities: %w", err)
+		}
+
+		cpuXML, err = extractCPUXML(domainXML, capabilities)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return cpuXML, nil
+}
+
+// CompareCPU implements Manager.CompareCPU.
+func (m *DomainManager) CompareCPU(ctx context.Context, cpuXML string) (bool, error) {
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	result, err := conn.GetLibvirtConnection().ConnectCompareHypervisorCPU(nil, nil, nil, nil, cpuXML, 0)
+	if err != nil {
+		return false, fmt.Errorf("comparing CPU: %w", err)
+	}
+
+	return libvirt.CPUCompareResult(result) == libvirt.CPUCompareIdentical ||
+		libvirt.CPUCompareResult(result) == libvirt.CPUCompareSuperset, nil
+}
+
+// GetFreeMemory implements Manager.GetFreeMemory.
+func (m *DomainManager) GetFreeMemory(ctx context.Context) (uint64, error) {
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	freeMemory, err := conn.GetLibvirtConnection().NodeGetFreeMemory()
+	if err != nil {
+		return 0, fmt.Errorf("getting free memory: %w", err)
+	}
+
+	return freeMemory, nil
+}
+
+// migrateFlags converts migration options to libvirt migration flags.
+func migrateFlags(opts MigrateOptions) libvirt.DomainMigrateFlags {
+	flags := libvirt.MigratePeer2peer
+
+	if opts.Live {
+		flags |= libvirt.MigrateLive
+	}
+	if opts.Offline {
+		flags |= libvirt.MigrateOffline
+	}
+	if opts.Persistent {
+		flags |= libvirt.MigratePersistDest
+	}
+	if opts.Undefine {
+		flags |= libvirt.MigrateUndefineSource
+	}
+	if opts.Compressed {
+		flags |= libvirt.MigrateCompressed
+	}
+	if opts.CopyStorage {
+		flags |= libvirt.MigrateNonSharedDisk
+	}
+	if opts.PostCopy {
+		flags |= libvirt.MigratePostcopy
+	}
+
+	return flags
+}
+
+// migrateParams converts migration options to libvirt migration parameters.
+func migrateParams(opts MigrateOptions) []libvirt.TypedParam {
+	var params []libvirt.TypedParam
+
+	if opts.Bandwidth > 0 {
+		params = append(params, libvirt.TypedParam{
+			Field: "bandwidth",
+			Value: *libvirt.NewTypedParamValueUllong(opts.Bandwidth),
+		})
+	}
+
+	return params
+}
+
+// parseJobStats converts domain job statistics into migration progress.
+func parseJobStats(params []libvirt.TypedParam) MigrationProgress {
+	var progress MigrationProgress
+
+	for _, param := range params {
+		value, ok := param.Value.I.(uint64)
+		if !ok {
+			continue
+		}
+
+		switch param.Field {
+		case "time_elapsed":
+			progress.Elapsed = time.Duration(value) * time.Millisecond //nolint:gosec
+		case "data_total":
+			progress.DataTotal = value
+		case "data_processed":
+			progress.DataProcessed = value
+		case "data_remaining":
+			progress.DataRemaining = value
+		}
+	}
+
+	return progress
+}
+
+// extractCPUXML returns the CPU definition of a domain for comparison with
+// another host. Domains passing the host CPU through need a host with the
+// same CPU, so the host CPU from the capabilities is used for them.
+func extractCPUXML(domainXML string, capabilitiesXML string) (string, error) {
+	doc, err := xmlutils.LoadXMLDocumentFromString(domainXML)
+	if err != nil {
+		return "", err
+	}
+
+	cpu := xmlutils.FindElement(doc, "/domain/cpu")
+	if cpu != nil {
+		switch xmlutils.GetElementAttribute(cpu, "mode") {
+		case "host-passthrough", "maximum":
+			cpu = nil
+		}
+	}
+
+	if cpu == nil || cpu.SelectElement("model") == nil {
+		capsDoc, err := xmlutils.LoadXMLDocumentFromString(capabilitiesXML)
+		if err != nil {
+			return "", err
+		}
+
+		cpu = xmlutils.FindElement(capsDoc, "/capabilities/host/cpu")
+		if cpu == nil {
+			return "", fmt.Errorf("host CPU not found in capabilities")
+		}
+	}
+
+	cpuDoc := etree.NewDocument()
+	cpuDoc.SetRoot(cpu.Copy())
+	return xmlutils.XMLToString(cpuDoc), nil
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateFlags(t *testing.T) {
+	// Peer-to-peer is always used
+	assert.Equal(t, libvirt.MigratePeer2peer, migrateFlags(MigrateOptions{}))
+
+	flags := migrateFlags(MigrateOptions{Live: true, Persistent: true, CopyStorage: true, PostCopy: true})
+	assert.Equal(t,
+		libvirt.MigratePeer2peer|libvirt.MigrateLive|libvirt.MigratePersistDest|libvirt.MigrateNonSharedDisk|libvirt.MigratePostcopy,
+		flags)
+
+	flags = migrateFlags(MigrateOptions{Offline: true, Undefine: true, Compressed: true})
+	assert.Equal(t,
+		libvirt.MigratePeer2peer|libvirt.MigrateOffline|libvirt.MigrateUndefineSource|libvirt.MigrateCompressed,
+		flags)
+}
+
+func TestMigrateParams(t *testing.T) {
+	assert.Empty(t, migrateParams(MigrateOptions{}))
+
+	params := migrateParams(MigrateOptions{Bandwidth: 100})
+	require.Len(t, params, 1)
+	assert.Equal(t, "bandwidth", params[0].Field)
+	assert.Equal(t, uint64(100), params[0].Value.I)
+}
+
+func TestParseJobStats(t *testing.T) {
+	params := []libvirt.TypedParam{
+		{Field: "time_elapsed", Value: *libvirt.NewTypedParamValueUllong(1500)},
+		{Field: "data_total", Value: *libvirt.NewTypedParamValueUllong(4096)},
+		{Field: "data_processed", Value: *libvirt.NewTypedParamValueUllong(1024)},
+		{Field: "data_remaining", Value: *libvirt.NewTypedParamValueUllong(3072)},
+		{Field: "operation", Value: *libvirt.NewTypedParamValueInt(1)},
+	}
+
+	progress := parseJobStats(params)
+	assert.Equal(t, 1500*time.Millisecond, progress.Elapsed)
+	assert.Equal(t, uint64(4096), progress.DataTotal)
+	assert.Equal(t, uint64(1024), progress.DataProcessed)
+	assert.Equal(t, uint64(3072), progress.DataRemaining)
+}
+
+func TestExtractCPUXML(t *testing.T) {
+	capabilities := `<capabilities>
+  <host>
+    <cpu>
+      <arch>x86_64</arch>
+      <model>Skylake-Client-IBRS</model>
+      <vendor>Intel</vendor>
+    </cpu>
+  </host>
+</capabilities>`
+
+	tests := []struct {
+		name      string
+		domainXML string
+		want      string
+	}{
+		{
+			name: "Custom CPU model",
+			domainXML: `<domain><name>vm</name><cpu mode='custom' match='exact'>
+  <model fallback='forbid'>Haswell-noTSX</model>
+  <feature policy='require' name='vmx'/>
+</cpu></domain>`,
+			want: "Haswell-noTSX",
+		},
+		{
+			name:      "Host passthrough uses the host CPU",
+			domainXML: `<domain><name>vm</name><cpu mode='host-passthrough'/></domain>`,
+			want:      "Skylake-Client-IBRS",
+		},
+		{
+			name:      "No CPU definition uses the host CPU",
+			domainXML: `<domain><name>vm</name></domain>`,
+			want:      "Skylake-Client-IBRS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuXML, err := extractCPUXML(tt.domainXML, capabilities)
+			require.NoError(t, err)
+			assert.Contains(t, cpuXML, "<cpu")
+			assert.Contains(t, cpuXML, tt.want)
+			assert.NotContains(t, cpuXML, "<domain")
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// blockJobPollInterval is how often block job progress is checked.
+const blockJobPollInterval = 500 * time.Millisecond
+
+// DiskOverlay is a temporary external overlay on top of a disk of a running
+// domain. While it exists, the base image is not written to.
+type DiskOverlay struct {
+	// Device is the target device of the disk, e.g. "vda"
+	Device string
+	// BasePath is the image the overlay was created on top of
+	BasePath string
+	// OverlayPath is the image receiving the writes of the domain
+	OverlayPath string
+}
+
+// CreateDiskOverlays implements Manager.CreateDiskOverlays.
+func (m *DomainManager) CreateDiskOverlays(ctx context.Context, name string) ([]DiskOverlay, error) {
+	var overlays []DiskOverlay
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		domainXML, state, _, err := m.getDomainInfo(libvirtConn, domain)
+		if err != nil {
+			return err
+		}
+
+		if libvirt.DomainState(state) != libvirt.DomainRunning && libvirt.DomainState(state) != libvirt.DomainPaused {
+			return fmt.Errorf("creating disk overlays of %s: %w", name, ErrDomainNotRunning)
+		}
+
+		now := time.Now()
+		overlays = planDiskOverlays(domainXML.Devices.Disks, now)
+		if len(overlays) == 0 {
+			return nil
+		}
+
+		// The overlays are created as volumes through libvirt, so they end
+		// up on the domain's host and can be removed the same way
+		overlays, err = m.createOverlayVolumes(libvirtConn, domainXML.Devices.Disks, overlays)
+		if err != nil {
+			return err
+		}
+		snapshotXML := buildOverlaySnapshotXML(domainXML.Devices.Disks, overlays, now)
+
+		// Quiesce when the guest agent allows it, the overlays are still
+		// crash-consistent otherwise
+		flags := libvirt.DomainSnapshotCreateDiskOnly | libvirt.DomainSnapshotCreateAtomic |
+			libvirt.DomainSnapshotCreateNoMetadata | libvirt.DomainSnapshotCreateReuseExt
+		_, err = libvirtConn.DomainSnapshotCreateXML(domain, snapshotXML, uint32(flags|libvirt.DomainSnapshotCreateQuiesce)) //nolint:gosec
+		if err != nil {
+			m.logger.Debug("Quiesced overlay creation failed, retrying without quiesce",
+				logger.String("name", name),
+				logger.Error(err))
+			if _, err = libvirtConn.DomainSnapshotCreateXML(domain, snapshotXML, uint32(flags)); err != nil { //nolint:gosec
+				for _, overlay := range overlays {
+					m.removeImage(libvirtConn, overlay.OverlayPath)
+				}
+				return fmt.Errorf("creating disk overlays: %w", err)
+			}
+		}
+
+		m.logger.Info("Created temporary disk overlays",
+			logger.String("name", name),
+			logger.Int("count", len(overlays)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return overlays, nil
+}
+
+// CommitDiskOverlays implements Manager.CommitDiskOverlays.
+func (m *DomainManager) CommitDiskOverlays(ctx context.Context, name string, overlays []DiskOverlay) error {
+	return m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		var errs []string
+		for _, overlay := range overlays {
+			if err := m.commitDiskOverlay(ctx, libvirtConn, domain, overlay); err != nil {
+				m.logger.Error("Failed to merge disk overlay",
+					logger.String("name", name),
+					logger.String("device", overlay.Device),
+					logger.String("overlay", overlay.OverlayPath),
+					logger.Error(err))
+				errs = append(errs, fmt.Sprintf("%s: %v", overlay.Device, err))
+				continue
+			}
+
+			m.removeImage(libvirtConn, overlay.OverlayPath)
+		}
+
+		if len(errs) > 0 {
+			return fmt.Errorf("merging disk overlays: %s", strings.Join(errs, "; "))
+		}
+
+		m.logger.Info("Merged temporary disk overlays",
+			logger.String("name", name),
+			logger.Int("count", len(overlays)))
+		return nil
+	})
+}
+
+// commitDiskOverlay merges an overlay into its base image with an active
+// block commit and pivots the disk back to the base image.
+func (m *DomainManager) commitDiskOverlay(ctx context.Context, libvirtConn *libvirt.Libvirt, domain libvirt.Domain, overlay DiskOverlay) error {
+	// Shallow commits only into the immediate backing image, leaving any
+	// deeper backing chain untouched
+	flags := libvirt.DomainBlockCommitActive | libvirt.DomainBlockCommitShallow
+	if err := libvirtConn.DomainBlockCommit(domain, overlay.Device, nil, nil, 0, flags); err != nil {
+		return fmt.Errorf("starting block commit: %w", err)
+	}
+
+	if err := waitForBlockJobReady(ctx, libvirtConn, domain, overlay.Device); err != nil {
+		return err
+	}
+
+	if err := libvirtConn.DomainBlockJobAbort(domain, overlay.Device, libvirt.DomainBlockJobAbortPivot); err != nil {
+		return fmt.Errorf("pivoting to base image: %w", err)
+	}
+
+	return nil
+}
+
+// waitForBlockJobReady waits until an active block job has copied all data
+// and is ready to pivot.
+func waitForBlockJobReady(ctx context.Context, libvirtConn *libvirt.Libvirt, domain libvirt.Domain, device string) error {
+	ticker := time.NewTicker(blockJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		found, _, _, cur, end, err := libvirtConn.DomainGetBlockJobInfo(domain, device, 0)
+		if err != nil {
+			return fmt.Errorf("getting block job info: %w", err)
+		}
+
+		if found == 0 {
+			return fmt.Errorf("block job on %s ended before it was ready", device)
+		}
+
+		if end > 0 && cur == end {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for block job on %s: %w", device, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// planDiskOverlays plans an overlay next to the image of every writable
+// file-backed disk.
+func planDiskOverlays(disks []libvirtDisk, now time.Time) []DiskOverlay {
+	var overlays []DiskOverlay
+
+	for _, disk := range disks {
+		if disk.Device != "disk" || disk.Source.File == "" || disk.ReadOnly != nil || disk.Shareable != nil {
+			continue
+		}
+
+		overlays = append(overlays, DiskOverlay{
+			Device:      disk.Target.Dev,
+			BasePath:    disk.Source.File,
+			OverlayPath: fmt.Sprintf("%s.overlay-%d", disk.Source.File, now.UnixNano()),
+		})
+	}
+
+	return overlays
+}
+
+// buildOverlaySnapshotXML builds the XML of a disk-only snapshot that moves
+// every disk with an overlay onto it. Other disks are left out.
+func buildOverlaySnapshotXML(disks []libvirtDisk, overlays []DiskOverlay, now time.Time) string {
+	var diskXML strings.Builder
+
+	for _, disk := range disks {
+		overlay := findOverlay(overlays, disk.Target.Dev)
+		if overlay == nil {
+			diskXML.WriteString(fmt.Sprintf("\n    <disk name='%s' snapshot='no'/>", escapeXML(disk.Target.Dev)))
+			continue
+		}
+
+		diskXML.WriteString(fmt.Sprintf("\n    <disk name='%s' snapshot='external'>\n      <source file='%s'/>\n    </disk>",
+			escapeXML(overlay.Device), escapeXML(overlay.OverlayPath)))
+	}
+
+	return fmt.Sprintf(`<domainsnapshot>
+  <name>overlay-%d</name>
+  <disks>%s
+  </disks>
+</domainsnapshot>`, now.UnixNano(), diskXML.String())
+}
+
+// findOverlay returns the overlay of a target device.
+func findOverlay(overlays []DiskOverlay, device string) *DiskOverlay {
+	for i := range overlays {
+		if overlays[i].Device == device {
+			return &overlays[i]
+		}
+	}
+	return nil
+}
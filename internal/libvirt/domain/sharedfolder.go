@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+// VirtioFSSupported implements Manager.VirtioFSSupported.
+func (m *DomainManager) VirtioFSSupported(ctx context.Context) (bool, error) {
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer m.handleDeferredRelease(conn)
+
+	// Domains are created as x86_64 q35 KVM guests
+	capabilities, err := conn.GetLibvirtConnection().ConnectGetDomainCapabilities(
+		libvirt.OptString{}, libvirt.OptString{"x86_64"}, libvirt.OptString{"q35"}, libvirt.OptString{"kvm"}, 0)
+	if err != nil {
+		return false, fmt.Errorf("getting domain capabilities: %w", err)
+	}
+
+	return supportsVirtioFS(capabilities)
+}
+
+// supportsVirtioFS reports whether domain capabilities list virtiofs as a
+// filesystem driver type.
+func supportsVirtioFS(capabilities string) (bool, error) {
+	doc, err := xmlutils.LoadXMLDocumentFromString(capabilities)
+	if err != nil {
+		return false, fmt.Errorf("parsing domain capabilities: %w", err)
+	}
+
+	filesystem := xmlutils.FindElement(doc, "/domainCapabilities/devices/filesystem")
+	if filesystem == nil || filesystem.SelectAttrValue("supported", "no") != "yes" {
+		return false, nil
+	}
+
+	for _, value := range filesystem.FindElements("enum[@name='driverType']/value") {
+		if value.Text() == "virtiofs" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+)
+
+func TestSupportsVirtioFS(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities string
+		want         bool
+	}{
+		{
+			name: "virtiofs driver type",
+			capabilities: `<domainCapabilities><devices>
+  <filesystem supported='yes'>
+    <enum name='driverType'><value>path</value><value>handle</value><value>virtiofs</value></enum>
+  </filesystem>
+</devices></domainCapabilities>`,
+			want: true,
+		},
+		{
+			name: "9p only",
+			capabilities: `<domainCapabilities><devices>
+  <filesystem supported='yes'>
+    <enum name='driverType'><value>path</value><value>handle</value></enum>
+  </filesystem>
+</devices></domainCapabilities>`,
+		},
+		{
+			name:         "No filesystem devices",
+			capabilities: `<domainCapabilities><devices><filesystem supported='no'/></devices></domainCapabilities>`,
+		},
+		{
+			name:         "Older libvirt",
+			capabilities: `<domainCapabilities><devices/></domainCapabilities>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supported, err := supportsVirtioFS(tt.capabilities)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, supported)
+		})
+	}
+
+	_, err := supportsVirtioFS("<domainCapabilities><devices>")
+	assert.Error(t, err)
+}
+
+func TestSharedFolderTemplates(t *testing.T) {
+	templates, sharedMemory, err := sharedFolderTemplates([]vm.SharedFolderParams{
+		{Source: "/srv/build's cache", Tag: "cache", Driver: vm.SharedFolderDriverVirtioFS},
+		{Source: "/srv/docs", Tag: "docs", Driver: vm.SharedFolderDriver9p, ReadOnly: true},
+	})
+	require.NoError(t, err)
+	assert.True(t, sharedMemory)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "/srv/build&#39;s cache", templates[0].Source)
+	assert.Equal(t, SharedFolderTemplate{Driver: "9p", Source: "/srv/docs", Tag: "docs", ReadOnly: true}, templates[1])
+
+	// 9p does not need shared memory
+	_, sharedMemory, err = sharedFolderTemplates([]vm.SharedFolderParams{
+		{Source: "/srv/docs", Tag: "docs", Driver: vm.SharedFolderDriver9p},
+	})
+	require.NoError(t, err)
+	assert.False(t, sharedMemory)
+
+	// Drivers are resolved before the domain is built
+	_, _, err = sharedFolderTemplates([]vm.SharedFolderParams{{Source: "/srv/docs", Tag: "docs"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,372 @@
+package domain
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// ErrInvalidSnapshot is returned for snapshot parameters that cannot be used.
+var ErrInvalidSnapshot = fmt.Errorf("invalid snapshot parameters")
+
+// snapshotTypeExternal marks a disk captured by an external overlay.
+const snapshotTypeExternal = "external"
+
+// isExternal reports whether a snapshot put external overlays on its disks.
+func (s *snapshotXML) isExternal() bool {
+	for _, disk := range s.Disks {
+		if disk.Snapshot == snapshotTypeExternal {
+			return true
+		}
+	}
+	return false
+}
+
+// createExternalSnapshot creates a disk-only snapshot that puts a qcow2
+// overlay volume next to the image of every writable disk. The images become
+// the read-only snapshot and the domain continues on the overlays.
+func (m *DomainManager) createExternalSnapshot(libvirtConn *libvirt.Libvirt, dom libvirt.Domain, vmName string, params vm.SnapshotParams) (*vm.Snapshot, error) {
+	if params.IncludeMemory {
+		return nil, fmt.Errorf("%w: external snapshots are disk-only", ErrInvalidSnapshot)
+	}
+	if strings.ContainsAny(params.Name, `/\`) || strings.HasPrefix(params.Name, ".") {
+		return nil, fmt.Errorf("%w: snapshot name %q cannot be used in overlay file names", ErrInvalidSnapshot, params.Name)
+	}
+
+	domainXML, _, _, err := m.getDomainInfo(libvirtConn, dom)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for _, disk := range domainXML.Devices.Disks {
+		if path := diskImagePath(libvirtConn, disk); path != "" {
+			paths[disk.Target.Dev] = path
+		}
+	}
+
+	overlays := planExternalOverlays(vmName, params, domainXML.Devices.Disks, paths)
+	if len(overlays) == 0 {
+		return nil, fmt.Errorf("%w: %s has no writable file-backed disks", ErrInvalidSnapshot, vmName)
+	}
+
+	// The overlays are volumes of the pools holding the images, so they are
+	// listed, accounted for and deleted like any other volume
+	overlays, err = m.createOverlayVolumes(libvirtConn, domainXML.Devices.Disks, overlays)
+	if err != nil {
+		return nil, err
+	}
+	snapshotXML := buildExternalSnapshotXML(params, domainXML.Devices.Disks, overlays)
+
+	// Libvirt quiesces disk-only snapshots itself through the guest agent
+	flags := libvirt.DomainSnapshotCreateDiskOnly | libvirt.DomainSnapshotCreateAtomic | libvirt.DomainSnapshotCreateReuseExt
+	if params.Quiesce {
+		flags |= libvirt.DomainSnapshotCreateQuiesce
+	}
+
+	snapshot, err := libvirtConn.DomainSnapshotCreateXML(dom, snapshotXML, uint32(flags)) //nolint:gosec
+	if err != nil {
+		for _, overlay := range overlays {
+			m.removeImage(libvirtConn, overlay.OverlayPath)
+		}
+		if params.Quiesce {
+			return nil, guestAgentError("creating quiesced external snapshot", err)
+		}
+		return nil, fmt.Errorf("failed to create external snapshot: %w", err)
+	}
+
+	m.logger.Info("Created external snapshot",
+		logger.String("vm", vmName),
+		logger.String("snapshot", params.Name),
+		logger.Int("disks", len(overlays)))
+
+	return m.getSnapshotInfo(libvirtConn, snapshot)
+}
+
+// deleteExternalSnapshot deletes an external snapshot by merging the overlay
+// it created on each disk into the image below, then removing the overlay
+// and the snapshot metadata.
+func (m *DomainManager) deleteExternalSnapshot(ctx context.Context, libvirtConn *libvirt.Libvirt, dom libvirt.Domain,
+	vmName string, snapshot libvirt.DomainSnapshot, snapInfo *snapshotXML) error {
+	domainXML, state, _, err := m.getDomainInfo(libvirtConn, dom)
+	if err != nil {
+		return err
+	}
+
+	// Block jobs need a running domain; libvirt 9.0 and later merge the
+	// overlays of stopped domains itself
+	if libvirt.DomainState(state) != libvirt.DomainRunning && libvirt.DomainState(state) != libvirt.DomainPaused {
+		if err := libvirtConn.DomainSnapshotDelete(snapshot, 0); err != nil {
+			return fmt.Errorf("deleting external snapshot %s: %w: start the VM to merge its overlays: %v",
+				snapInfo.Name, ErrDomainNotRunning, err)
+		}
+		return nil
+	}
+
+	// A merge left half done would leave the disk on an unpivoted job, so it
+	// continues when the request goes away
+	mergeCtx := context.WithoutCancel(ctx)
+
+	for _, snapDisk := range snapInfo.Disks {
+		overlay := snapDisk.Source.File
+		if snapDisk.Snapshot != snapshotTypeExternal || overlay == "" {
+			continue
+		}
+
+		disk := findDisk(domainXML.Devices.Disks, snapDisk.Name)
+		if disk == nil {
+			m.logger.Warn("Disk of external snapshot no longer attached",
+				logger.String("vm", vmName),
+				logger.String("device", snapDisk.Name))
+			continue
+		}
+
+		switch {
+		case diskImagePath(libvirtConn, *disk) == overlay:
+			err = m.commitDiskOverlay(mergeCtx, libvirtConn, dom, DiskOverlay{Device: snapDisk.Name, OverlayPath: overlay})
+		case backingChainContains(disk.BackingStore, overlay):
+			err = commitBackingImage(mergeCtx, libvirtConn, dom, snapDisk.Name, overlay)
+		default:
+			// Reverted or already merged, the overlay is kept for other snapshots
+			m.logger.Debug("Overlay of external snapshot not in use",
+				logger.String("vm", vmName),
+				logger.String("overlay", overlay))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("merging overlay of %s on %s: %w", snapInfo.Name, snapDisk.Name, err)
+		}
+
+		m.removeImage(libvirtConn, overlay)
+	}
+
+	if err := libvirtConn.DomainSnapshotDelete(snapshot, libvirt.DomainSnapshotDeleteMetadataOnly); err != nil {
+		return fmt.Errorf("failed to delete snapshot metadata: %w", err)
+	}
+
+	m.logger.Info("Merged and deleted external snapshot",
+		logger.String("vm", vmName),
+		logger.String("snapshot", snapInfo.Name))
+
+	return nil
+}
+
+// removeImage deletes a disk image through the storage pool that holds it.
+// Images outside of pools are left in place.
+func (m *DomainManager) removeImage(libvirtConn *libvirt.Libvirt, path string) {
+	vol, err := libvirtConn.StorageVolLookupByPath(path)
+	if err == nil {
+		err = libvirtConn.StorageVolDelete(vol, 0)
+	}
+	if err != nil {
+		m.logger.Warn("Failed to remove image",
+			logger.String("path", path),
+			logger.Error(err))
+	}
+}
+
+// createOverlayVolumes creates an empty qcow2 volume backed by the base image
+// of every overlay, in the storage pool holding that image. It returns the
+// overlays with the paths of the new volumes.
+func (m *DomainManager) createOverlayVolumes(libvirtConn *libvirt.Libvirt, disks []libvirtDisk, overlays []DiskOverlay) ([]DiskOverlay, error) {
+	created := make([]DiskOverlay, 0, len(overlays))
+
+	for _, overlay := range overlays {
+		path, err := createOverlayVolume(libvirtConn, findDisk(disks, overlay.Device), overlay)
+		if err != nil {
+			for _, done := range created {
+				m.removeImage(libvirtConn, done.OverlayPath)
+			}
+			return nil, fmt.Errorf("creating overlay of %s: %w", overlay.Device, err)
+		}
+
+		overlay.OverlayPath = path
+		created = append(created, overlay)
+	}
+
+	return created, nil
+}
+
+// createOverlayVolume creates the overlay volume of a disk and returns its path.
+func createOverlayVolume(libvirtConn *libvirt.Libvirt, disk *libvirtDisk, overlay DiskOverlay) (string, error) {
+	base, err := libvirtConn.StorageVolLookupByPath(overlay.BasePath)
+	if err != nil {
+		return "", fmt.Errorf("%w: image %s is not a volume of a storage pool", ErrInvalidSnapshot, overlay.BasePath)
+	}
+
+	pool, err := libvirtConn.StoragePoolLookupByVolume(base)
+	if err != nil {
+		return "", fmt.Errorf("looking up pool of %s: %w", overlay.BasePath, err)
+	}
+
+	_, capacity, _, err := libvirtConn.StorageVolGetInfo(base)
+	if err != nil {
+		return "", fmt.Errorf("getting info of %s: %w", overlay.BasePath, err)
+	}
+
+	format := "raw"
+	if disk != nil && disk.Driver.Type != "" {
+		format = disk.Driver.Type
+	}
+
+	vol, err := libvirtConn.StorageVolCreateXML(pool, buildOverlayVolumeXML(overlay, capacity, format), 0)
+	if err != nil {
+		return "", fmt.Errorf("creating volume %s: %w", filepath.Base(overlay.OverlayPath), err)
+	}
+
+	return libvirtConn.StorageVolGetPath(vol)
+}
+
+// buildOverlayVolumeXML builds the XML of a qcow2 volume backed by the base
+// image of an overlay.
+func buildOverlayVolumeXML(overlay DiskOverlay, capacity uint64, baseFormat string) string {
+	return fmt.Sprintf(`<volume>
+  <name>%s</name>
+  <capacity unit='bytes'>%d</capacity>
+  <target>
+    <format type='qcow2'/>
+  </target>
+  <backingStore>
+    <path>%s</path>
+    <format type='%s'/>
+  </backingStore>
+</volume>`, escapeXML(filepath.Base(overlay.OverlayPath)), capacity, escapeXML(overlay.BasePath), escapeXML(baseFormat))
+}
+
+// diskImagePath returns the image file of a disk, resolving pool volumes.
+func diskImagePath(libvirtConn *libvirt.Libvirt, disk libvirtDisk) string {
+	if disk.Source.File != "" {
+		return disk.Source.File
+	}
+
+	if disk.Source.Pool == "" || disk.Source.Volume == "" {
+		return ""
+	}
+
+	pool, err := libvirtConn.StoragePoolLookupByName(disk.Source.Pool)
+	if err != nil {
+		return ""
+	}
+	vol, err := libvirtConn.StorageVolLookupByName(pool, disk.Source.Volume)
+	if err != nil {
+		return ""
+	}
+	path, err := libvirtConn.StorageVolGetPath(vol)
+	if err != nil {
+		return ""
+	}
+
+	return path
+}
+
+// findDisk returns the disk with a target device.
+func findDisk(disks []libvirtDisk, device string) *libvirtDisk {
+	for i := range disks {
+		if disks[i].Target.Dev == device {
+			return &disks[i]
+		}
+	}
+	return nil
+}
+
+// backingChainContains reports whether an image is in a backing chain.
+func backingChainContains(store *libvirtBackingStore, path string) bool {
+	for ; store != nil; store = store.BackingStore {
+		if store.Source.File == path || store.Source.Dev == path {
+			return true
+		}
+	}
+	return false
+}
+
+// planExternalOverlays plans a qcow2 overlay next to the image of every
+// writable disk. Paths maps target devices to their image files.
+func planExternalOverlays(vmName string, params vm.SnapshotParams, disks []libvirtDisk, paths map[string]string) []DiskOverlay {
+	var overlays []DiskOverlay
+
+	for _, disk := range disks {
+		path := paths[disk.Target.Dev]
+		if disk.Device != "disk" || path == "" || disk.ReadOnly != nil || disk.Shareable != nil {
+			continue
+		}
+
+		overlays = append(overlays, DiskOverlay{
+			Device:      disk.Target.Dev,
+			BasePath:    path,
+			OverlayPath: filepath.Join(filepath.Dir(path), fmt.Sprintf("%s-%s-%s.qcow2", vmName, disk.Target.Dev, params.Name)),
+		})
+	}
+
+	return overlays
+}
+
+// buildExternalSnapshotXML builds the XML of an external disk-only snapshot
+// that moves each disk with an overlay onto it. Other disks are left out of
+// the snapshot.
+func buildExternalSnapshotXML(params vm.SnapshotParams, disks []libvirtDisk, overlays []DiskOverlay) string {
+	var diskXML strings.Builder
+
+	for _, disk := range disks {
+		var overlay *DiskOverlay
+		for i := range overlays {
+			if overlays[i].Device == disk.Target.Dev {
+				overlay = &overlays[i]
+				break
+			}
+		}
+
+		if overlay == nil {
+			diskXML.WriteString(fmt.Sprintf("\n    <disk name='%s' snapshot='no'/>", escapeXML(disk.Target.Dev)))
+			continue
+		}
+
+		diskXML.WriteString(fmt.Sprintf("\n    <disk name='%s' snapshot='external' type='file'>\n      <driver type='qcow2'/>\n      <source file='%s'/>\n    </disk>",
+			escapeXML(overlay.Device), escapeXML(overlay.OverlayPath)))
+	}
+
+	snapshotXML := fmt.Sprintf("<domainsnapshot>\n  <name>%s</name>", escapeXML(params.Name))
+	if params.Description != "" {
+		snapshotXML += fmt.Sprintf("\n  <description>%s</description>", escapeXML(params.Description))
+	}
+	snapshotXML += fmt.Sprintf("\n  <disks>%s\n  </disks>\n</domainsnapshot>", diskXML.String())
+
+	return snapshotXML
+}
+
+// buildSnapshotTree arranges snapshots under their parents, oldest first.
+// Snapshots whose parent is not listed are roots.
+func buildSnapshotTree(snapshots []*vm.Snapshot) []*vm.Snapshot {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	byName := make(map[string]*vm.Snapshot, len(snapshots))
+	for _, snapshot := range snapshots {
+		byName[snapshot.Name] = snapshot
+	}
+
+	roots := make([]*vm.Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if parent, ok := byName[snapshot.Parent]; ok && snapshot.Parent != "" {
+			parent.Children = append(parent.Children, snapshot)
+			continue
+		}
+		roots = append(roots, snapshot)
+	}
+
+	return roots
+}
+
+// escapeXML escapes text for use in XML content and attributes.
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+)
+
+const externalDomainXML = `<domain type='kvm' id='4'>
+  <name>web</name>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='qcow2'/>
+      <source file='/var/lib/libvirt/images/web-vda-snap2.qcow2'/>
+      <backingStore type='file'>
+        <format type='qcow2'/>
+        <source file='/var/lib/libvirt/images/web-vda-snap1.qcow2'/>
+        <backingStore type='file'>
+          <format type='qcow2'/>
+          <source file='/var/lib/libvirt/images/web-disk-0'/>
+          <backingStore/>
+        </backingStore>
+      </backingStore>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='raw'/>
+      <source file='/var/lib/libvirt/images/shared.img'/>
+      <target dev='vdb' bus='virtio'/>
+      <shareable/>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <driver name='qemu' type='raw'/>
+      <source file='/var/lib/libvirt/cloud-init/web-cloudinit.iso'/>
+      <target dev='sda' bus='sata'/>
+      <readonly/>
+    </disk>
+  </devices>
+</domain>`
+
+func TestBuildExternalSnapshotXML(t *testing.T) {
+	var domainXML libvirtDomain
+	require.NoError(t, xml.Unmarshal([]byte(externalDomainXML), &domainXML))
+
+	paths := map[string]string{
+		"vda": "/var/lib/libvirt/images/web-vda-snap2.qcow2",
+		"vdb": "/var/lib/libvirt/images/shared.img",
+		"sda": "/var/lib/libvirt/cloud-init/web-cloudinit.iso",
+	}
+	params := vm.SnapshotParams{Name: "snap3", Description: "before <upgrade>", External: true}
+
+	overlays := planExternalOverlays("web", params, domainXML.Devices.Disks, paths)
+
+	require.Len(t, overlays, 1)
+	assert.Equal(t, "vda", overlays[0].Device)
+	assert.Equal(t, "/var/lib/libvirt/images/web-vda-snap2.qcow2", overlays[0].BasePath)
+	assert.Equal(t, "/var/lib/libvirt/images/web-vda-snap3.qcow2", overlays[0].OverlayPath)
+
+	snapXML := buildExternalSnapshotXML(params, domainXML.Devices.Disks, overlays)
+
+	var parsed snapshotXML
+	require.NoError(t, xml.Unmarshal([]byte(snapXML), &parsed))
+	assert.Equal(t, "snap3", parsed.Name)
+	assert.Equal(t, "before <upgrade>", parsed.Description)
+	assert.True(t, parsed.isExternal())
+
+	require.Len(t, parsed.Disks, 3)
+	assert.Equal(t, "vda", parsed.Disks[0].Name)
+	assert.Equal(t, snapshotTypeExternal, parsed.Disks[0].Snapshot)
+	assert.Equal(t, "/var/lib/libvirt/images/web-vda-snap3.qcow2", parsed.Disks[0].Source.File)
+	assert.Equal(t, "no", parsed.Disks[1].Snapshot)
+	assert.Equal(t, "no", parsed.Disks[2].Snapshot)
+}
+
+func TestBuildOverlayVolumeXML(t *testing.T) {
+	overlay := DiskOverlay{
+		Device:      "vda",
+		BasePath:    "/var/lib/libvirt/images/web-disk-0",
+		OverlayPath: "/var/lib/libvirt/images/web-vda-snap1.qcow2",
+	}
+
+	var parsed struct {
+		Name     string `xml:"name"`
+		Capacity uint64 `xml:"capacity"`
+		Target   struct {
+			Format struct {
+				Type string `xml:"type,attr"`
+			} `xml:"format"`
+		} `xml:"target"`
+		BackingStore struct {
+			Path   string `xml:"path"`
+			Format struct {
+				Type string `xml:"type,attr"`
+			} `xml:"format"`
+		} `xml:"backingStore"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(buildOverlayVolumeXML(overlay, 10<<30, "raw")), &parsed))
+
+	assert.Equal(t, "web-vda-snap1.qcow2", parsed.Name)
+	assert.Equal(t, uint64(10<<30), parsed.Capacity)
+	assert.Equal(t, "qcow2", parsed.Target.Format.Type)
+	assert.Equal(t, "/var/lib/libvirt/images/web-disk-0", parsed.BackingStore.Path)
+	assert.Equal(t, "raw", parsed.BackingStore.Format.Type)
+}
+
+func TestBackingChainContains(t *testing.T) {
+	var domainXML libvirtDomain
+	require.NoError(t, xml.Unmarshal([]byte(externalDomainXML), &domainXML))
+
+	disk := findDisk(domainXML.Devices.Disks, "vda")
+	require.NotNil(t, disk)
+
+	assert.True(t, backingChainContains(disk.BackingStore, "/var/lib/libvirt/images/web-vda-snap1.qcow2"))
+	assert.True(t, backingChainContains(disk.BackingStore, "/var/lib/libvirt/images/web-disk-0"))
+	assert.False(t, backingChainContains(disk.BackingStore, "/var/lib/libvirt/images/web-vda-snap2.qcow2"))
+	assert.Nil(t, findDisk(domainXML.Devices.Disks, "vdc"))
+}
+
+func TestBuildSnapshotTree(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := []*vm.Snapshot{
+		{Name: "c", Parent: "a", CreatedAt: base.Add(2 * time.Hour)},
+		{Name: "b", Parent: "a", CreatedAt: base.Add(time.Hour)},
+		{Name: "a", CreatedAt: base},
+		{Name: "d", Parent: "b", CreatedAt: base.Add(3 * time.Hour)},
+		{Name: "orphan", Parent: "deleted", CreatedAt: base.Add(4 * time.Hour)},
+	}
+
+	roots := buildSnapshotTree(snapshots)
+
+	require.Len(t, roots, 2)
+	assert.Equal(t, "a", roots[0].Name)
+	assert.Equal(t, "orphan", roots[1].Name)
+
+	require.Len(t, roots[0].Children, 2)
+	assert.Equal(t, "b", roots[0].Children[0].Name)
+	assert.Equal(t, "c", roots[0].Children[1].Name)
+	require.Len(t, roots[0].Children[0].Children, 1)
+	assert.Equal(t, "d", roots[0].Children[0].Children[0].Name)
+
+	assert.NotNil(t, buildSnapshotTree(nil))
+}
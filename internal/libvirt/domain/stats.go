@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/threatflux/libgo/internal/models/vm"
+)
+
+// domainStatsTypes are the bulk stats groups collected for a domain.
+const domainStatsTypes = libvirt.DomainStatsState | libvirt.DomainStatsCPUTotal | libvirt.DomainStatsBalloon |
+	libvirt.DomainStatsVCPU | libvirt.DomainStatsInterface | libvirt.DomainStatsBlock
+
+// GetStats implements Manager.GetStats.
+func (m *DomainManager) GetStats(ctx context.Context, name string) (*vm.Metrics, error) {
+	var result *vm.Metrics
+
+	err := m.performDomainOperation(ctx, name, func(libvirtConn *libvirt.Libvirt, domain libvirt.Domain) error {
+		records, err := libvirtConn.ConnectGetAllDomainStats([]libvirt.Domain{domain}, uint32(domainStatsTypes), 0)
+		if err != nil {
+			return fmt.Errorf("getting domain stats: %w", err)
+		}
+
+		if len(records) == 0 {
+			return fmt.Errorf("no stats returned for domain %s", name)
+		}
+
+		result = parseDomainStats(records[0].Params)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseDomainStats converts bulk stats parameters into VM metrics.
+func parseDomainStats(params []libvirt.TypedParam) *vm.Metrics {
+	metrics := &vm.Metrics{Timestamp: time.Now()}
+
+	var balloonCurrent, balloonAvailable, balloonUnused uint64
+	for _, param := range params {
+		value := typedParamUint64(param.Value)
+
+		switch {
+		case param.Field == "cpu.time":
+			metrics.CPU.TimeNs = value
+		case param.Field == "vcpu.current":
+			metrics.CPU.VCPUs = int(value)
+		case param.Field == "balloon.current":
+			balloonCurrent = value
+		case param.Field == "balloon.available":
+			balloonAvailable = value
+		case param.Field == "balloon.unused":
+			balloonUnused = value
+		case param.Field == "balloon.rss":
+			metrics.Memory.RSSBytes = value * 1024
+		case strings.HasPrefix(param.Field, "net."):
+			addInterfaceStat(&metrics.Network, param.Field, value)
+		case strings.HasPrefix(param.Field, "block."):
+			addBlockStat(&metrics.Disk, param.Field, value)
+		}
+	}
+
+	// Balloon values are reported in KiB
+	metrics.Memory.TotalBytes = balloonCurrent * 1024
+	switch {
+	case balloonAvailable > 0 && balloonUnused <= balloonAvailable:
+		// The guest reports its own usage through the balloon driver
+		metrics.Memory.UsedBytes = (balloonAvailable - balloonUnused) * 1024
+	case metrics.Memory.RSSBytes > 0:
+		metrics.Memory.UsedBytes = min(metrics.Memory.RSSBytes, metrics.Memory.TotalBytes)
+	default:
+		metrics.Memory.UsedBytes = metrics.Memory.TotalBytes
+	}
+
+	return metrics
+}
+
+// addInterfaceStat adds a per-interface counter such as "net.0.rx.bytes".
+func addInterfaceStat(network *vm.NetworkMetrics, field string, value uint64) {
+	switch {
+	case strings.HasSuffix(field, ".rx.bytes"):
+		network.RxBytes += value
+	case strings.HasSuffix(field, ".tx.bytes"):
+		network.TxBytes += value
+	case strings.HasSuffix(field, ".rx.pkts"):
+		network.RxPackets += value
+	case strings.HasSuffix(field, ".tx.pkts"):
+		network.TxPackets += value
+	}
+}
+
+// addBlockStat adds a per-disk counter such as "block.0.rd.bytes".
+func addBlockStat(disk *vm.DiskMetrics, field string, value uint64) {
+	switch {
+	case strings.HasSuffix(field, ".rd.bytes"):
+		disk.ReadBytes += value
+	case strings.HasSuffix(field, ".wr.bytes"):
+		disk.WriteBytes += value
+	case strings.HasSuffix(field, ".rd.reqs"):
+		disk.ReadOps += value
+	case strings.HasSuffix(field, ".wr.reqs"):
+		disk.WriteOps += value
+	}
+}
+
+// typedParamUint64 converts a numeric typed parameter value to uint64.
+func typedParamUint64(value libvirt.TypedParamValue) uint64 {
+	switch v := value.I.(type) {
+	case uint64:
+		return v
+	case uint32:
+		return uint64(v)
+	case int64:
+		if v > 0 {
+			return uint64(v)
+		}
+	case int32:
+		if v > 0 {
+			return uint64(v)
+		}
+	case float64:
+		if v > 0 {
+			return uint64(v)
+		}
+	}
+	return 0
+}
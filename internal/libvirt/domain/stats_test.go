@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDomainStats(t *testing.T) {
+	params := []libvirt.TypedParam{
+		{Field: "state.state", Value: *libvirt.NewTypedParamValueInt(1)},
+		{Field: "cpu.time", Value: *libvirt.NewTypedParamValueUllong(42_000_000)},
+		{Field: "vcpu.current", Value: *libvirt.NewTypedParamValueUint(2)},
+		{Field: "balloon.current", Value: *libvirt.NewTypedParamValueUllong(2 * 1024 * 1024)},
+		{Field: "balloon.available", Value: *libvirt.NewTypedParamValueUllong(2 * 1024 * 1024)},
+		{Field: "balloon.unused", Value: *libvirt.NewTypedParamValueUllong(1024 * 1024)},
+		{Field: "balloon.rss", Value: *libvirt.NewTypedParamValueUllong(1536 * 1024)},
+		{Field: "net.count", Value: *libvirt.NewTypedParamValueUint(2)},
+		{Field: "net.0.name", Value: *libvirt.NewTypedParamValueString("vnet0")},
+		{Field: "net.0.rx.bytes", Value: *libvirt.NewTypedParamValueUllong(100)},
+		{Field: "net.0.tx.bytes", Value: *libvirt.NewTypedParamValueUllong(200)},
+		{Field: "net.1.rx.bytes", Value: *libvirt.NewTypedParamValueUllong(300)},
+		{Field: "block.count", Value: *libvirt.NewTypedParamValueUint(1)},
+		{Field: "block.0.rd.bytes", Value: *libvirt.NewTypedParamValueUllong(4096)},
+		{Field: "block.0.wr.bytes", Value: *libvirt.NewTypedParamValueUllong(8192)},
+		{Field: "block.0.wr.reqs", Value: *libvirt.NewTypedParamValueUllong(2)},
+	}
+
+	metrics := parseDomainStats(params)
+
+	assert.Equal(t, uint64(42_000_000), metrics.CPU.TimeNs)
+	assert.Equal(t, 2, metrics.CPU.VCPUs)
+	assert.Equal(t, uint64(2*1024*1024*1024), metrics.Memory.TotalBytes)
+	assert.Equal(t, uint64(1024*1024*1024), metrics.Memory.UsedBytes)
+	assert.Equal(t, uint64(1536*1024*1024), metrics.Memory.RSSBytes)
+	assert.Equal(t, uint64(400), metrics.Network.RxBytes)
+	assert.Equal(t, uint64(200), metrics.Network.TxBytes)
+	assert.Equal(t, uint64(4096), metrics.Disk.ReadBytes)
+	assert.Equal(t, uint64(8192), metrics.Disk.WriteBytes)
+	assert.Equal(t, uint64(2), metrics.Disk.WriteOps)
+}
+
+func TestParseDomainStats_WithoutGuestBalloonStats(t *testing.T) {
+	params := []libvirt.TypedParam{
+		{Field: "balloon.current", Value: *libvirt.NewTypedParamValueUllong(1024 * 1024)},
+		{Field: "balloon.rss", Value: *libvirt.NewTypedParamValueUllong(512 * 1024)},
+	}
+
+	metrics := parseDomainStats(params)
+
+	// Falls back to the host resident size
+	assert.Equal(t, uint64(512*1024*1024), metrics.Memory.UsedBytes)
+}
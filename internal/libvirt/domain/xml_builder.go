@@ -1,8 +1,10 @@
 package domain
 
 import (
+	"encoding/xml"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/threatflux/libgo/internal/models/vm"
@@ -19,15 +21,20 @@ type TemplateXMLBuilder struct {
 // DomainTemplate contains data for domain XML template.
 type DomainTemplate struct {
 	// Slice fields (24 bytes each) - largest fields first
-	Disks    []DiskTemplate
-	Networks []NetworkTemplate
+	Disks         []DiskTemplate
+	Networks      []NetworkTemplate
+	SharedFolders []SharedFolderTemplate
 	// String fields (16 bytes each) - group together
 	Name         string
 	UUID         string
 	CloudInitISO string
+	Firmware     string
 	// Struct fields - CPU is larger (40 bytes) than Memory (8 bytes)
 	CPU    CPUTemplate
 	Memory MemoryTemplate
+	// SharedMemory backs guest memory with shared memfd memory, which
+	// virtio-fs requires
+	SharedMemory bool
 }
 
 // MemoryTemplate contains memory data for the template.
@@ -48,6 +55,14 @@ type CPUTemplate struct {
 
 // DiskTemplate contains disk data for the template.
 type DiskTemplate struct {
+	// Auth and Hosts are set for network disks
+	Auth  *vm.DiskAuth
+	Hosts []vm.DiskHost
+	// Encryption is set for LUKS encrypted disks
+	Encryption *vm.DiskEncryption
+	// IOTune holds the I/O limits that are set
+	IOTune     []IOTuneTemplate
+	Protocol   string
 	Type       string
 	Format     string
 	Source     string
@@ -56,6 +71,9 @@ type DiskTemplate struct {
 	Volume     string
 	Device     string
 	Bus        string
+	Cache      string
+	IO         string
+	Discard    string
 	Bootable   bool
 	ReadOnly   bool
 	Shareable  bool
@@ -70,6 +88,14 @@ type NetworkTemplate struct {
 	Model      string
 }
 
+// SharedFolderTemplate contains shared folder data for the template.
+type SharedFolderTemplate struct {
+	Driver   string
+	Source   string
+	Tag      string
+	ReadOnly bool
+}
+
 // NewTemplateXMLBuilder creates a new TemplateXMLBuilder.
 func NewTemplateXMLBuilder(templateLoader *xmlutils.TemplateLoader, logger logger.Logger) *TemplateXMLBuilder {
 	return &TemplateXMLBuilder{
@@ -105,15 +131,29 @@ func (b *TemplateXMLBuilder) BuildDomainXML(params vm.VMParams) (string, error)
 		SourceAttr: "file",
 		Device:     "vda", // Default device name for primary disk
 		Bus:        string(params.Disk.GetBus()),
+		Cache:      params.Disk.CacheMode,
+		IO:         params.Disk.IOMode,
+		Discard:    params.Disk.Discard,
+		IOTune:     ioTuneTemplates(params.Disk.IOTune),
 		Bootable:   true,
 		ReadOnly:   params.Disk.ReadOnly,
 		Shareable:  params.Disk.Shareable,
 	}
 
-	// If source image is provided, use it as the source
-	if params.Disk.SourceImage != "" {
+	// Native I/O bypasses the host page cache, which needs the cache mode
+	// set
+	if params.Disk.IOMode == vm.DiskIOModeNative {
+		primaryDisk.Cache = params.Disk.GetCacheMode()
+	}
+
+	// Source images are used as they are; disks created in a pool are
+	// attached according to the pool type
+	switch {
+	case params.Disk.SourceImage != "":
 		primaryDisk.Source = params.Disk.SourceImage
-	} else {
+	case params.Disk.Source != nil:
+		applyDiskSource(&primaryDisk, params.Disk.Source)
+	default:
 		// Otherwise create a new disk using storage pool and volume
 		storagePool := params.Disk.StoragePool
 		if storagePool == "" {
@@ -158,9 +198,16 @@ func (b *TemplateXMLBuilder) BuildDomainXML(params vm.VMParams) (string, error)
 		networks = append(networks, networkTemplate)
 	}
 
-	// Cloud-init ISO path, if cloud-init config is provided
-	var cloudInitISOPath string
-	if params.CloudInit.UserData != "" || params.CloudInit.MetaData != "" {
+	// Prepare shared folders
+	sharedFolders, sharedMemory, err := sharedFolderTemplates(params.SharedFolders)
+	if err != nil {
+		return "", err
+	}
+
+	// Cloud-init ISO path: the volume the VM manager uploaded it to, or the
+	// file it generated into ISODir
+	cloudInitISOPath := params.CloudInit.ISOPath
+	if cloudInitISOPath == "" && (params.CloudInit.UserData != "" || params.CloudInit.MetaData != "" || params.CloudInit.ISODir != "") {
 		// This path needs to match the config's CloudInitDir
 		cloudInitISODir := params.CloudInit.ISODir
 		if cloudInitISODir == "" {
@@ -171,13 +218,15 @@ func (b *TemplateXMLBuilder) BuildDomainXML(params vm.VMParams) (string, error)
 
 	// Prepare template data
 	templateData := DomainTemplate{
-		Name:         params.Name,
-		UUID:         domainUUID,
-		Memory:       MemoryTemplate{KiB: memoryKiB},
-		CPU:          cpuTemplate,
-		Disks:        disks,
-		Networks:     networks,
-		CloudInitISO: cloudInitISOPath,
+		Name:          params.Name,
+		UUID:          domainUUID,
+		Memory:        MemoryTemplate{KiB: memoryKiB},
+		CPU:           cpuTemplate,
+		Disks:         disks,
+		Networks:      networks,
+		SharedFolders: sharedFolders,
+		SharedMemory:  sharedMemory,
+		CloudInitISO:  cloudInitISOPath,
 	}
 
 	// Render the template
@@ -194,6 +243,129 @@ func (b *TemplateXMLBuilder) BuildDomainXML(params vm.VMParams) (string, error)
 	return domainXML, nil
 }
 
+// applyDiskSource makes a disk template attach a disk source as a file,
+// block device or network disk.
+func applyDiskSource(disk *DiskTemplate, source *vm.DiskSource) {
+	disk.Type = string(source.Type)
+	disk.Encryption = source.Encryption
+	switch source.Type {
+	case vm.DiskTypeBlock:
+		disk.SourceAttr = "dev"
+		disk.Source = source.Path
+	case vm.DiskTypeNetwork:
+		disk.Protocol = source.Protocol
+		disk.Source = source.Name
+		disk.Hosts = source.Hosts
+		disk.Auth = source.Auth
+	default:
+		disk.Type = string(vm.DiskTypeFile)
+		disk.SourceAttr = "file"
+		disk.Source = source.Path
+	}
+}
+
+// BuildImportXML implements XMLBuilder.BuildImportXML.
+func (b *TemplateXMLBuilder) BuildImportXML(spec ImportSpec) (string, error) {
+	// Name devices per bus prefix in the order of the disks; the first disk
+	// is the boot disk
+	disks := make([]DiskTemplate, 0, len(spec.Disks))
+	devices := make(map[string]int)
+	for i, disk := range spec.Disks {
+		prefix := diskDevicePrefix(vm.DiskBus(disk.Bus))
+		disks = append(disks, DiskTemplate{
+			Type:       string(vm.DiskTypeFile),
+			Format:     disk.Format,
+			SourceAttr: "file",
+			Source:     disk.Path,
+			Device:     diskDeviceName(prefix, devices[prefix]),
+			Bus:        disk.Bus,
+			Bootable:   i == 0,
+		})
+		devices[prefix]++
+	}
+
+	networks := make([]NetworkTemplate, 0, len(spec.Networks))
+	for _, network := range spec.Networks {
+		networks = append(networks, NetworkTemplate{
+			Type:       string(vm.NetworkTypeNetwork),
+			Source:     network.Network,
+			SourceAttr: "network",
+			Model:      network.Model,
+		})
+	}
+
+	templateData := DomainTemplate{
+		Name:     spec.Name,
+		UUID:     uuid.New().String(),
+		Memory:   MemoryTemplate{KiB: spec.MemoryBytes / 1024},
+		CPU:      CPUTemplate{Count: spec.CPUs},
+		Disks:    disks,
+		Networks: networks,
+		Firmware: spec.Firmware,
+	}
+
+	b.logger.Debug("Rendering imported domain XML template",
+		logger.String("vm_name", spec.Name),
+		logger.Int("disks", len(disks)),
+		logger.Int("networks", len(networks)))
+
+	domainXML, err := b.templateLoader.RenderTemplate("domain.xml.tmpl", templateData)
+	if err != nil {
+		return "", fmt.Errorf("failed to render domain XML template: %w", err)
+	}
+
+	return domainXML, nil
+}
+
+// sharedFolderTemplates prepares the shared folders of a VM and reports
+// whether any of them needs shared guest memory.
+func sharedFolderTemplates(folders []vm.SharedFolderParams) ([]SharedFolderTemplate, bool, error) {
+	templates := make([]SharedFolderTemplate, 0, len(folders))
+	sharedMemory := false
+	for _, folder := range folders {
+		// The driver is resolved before the domain is built
+		if !folder.Driver.IsValid() {
+			return nil, false, fmt.Errorf("shared folder %s has no driver", folder.Tag)
+		}
+
+		var source strings.Builder
+		if err := xml.EscapeText(&source, []byte(folder.Source)); err != nil {
+			return nil, false, fmt.Errorf("escaping shared folder source: %w", err)
+		}
+
+		templates = append(templates, SharedFolderTemplate{
+			Driver:   string(folder.Driver),
+			Source:   source.String(),
+			Tag:      folder.Tag,
+			ReadOnly: folder.ReadOnly,
+		})
+		sharedMemory = sharedMemory || folder.Driver == vm.SharedFolderDriverVirtioFS
+	}
+	return templates, sharedMemory, nil
+}
+
+// diskDevicePrefix returns the prefix of the device names of disks on a bus.
+func diskDevicePrefix(bus vm.DiskBus) string {
+	switch bus {
+	case vm.DiskBusVirtio:
+		return "vd"
+	case vm.DiskBusIDE:
+		return "hd"
+	default:
+		return "sd"
+	}
+}
+
+// diskDeviceName returns the name of the disk at index on a bus, e.g. vda,
+// vdb, ..., vdz, vdaa.
+func diskDeviceName(prefix string, index int) string {
+	name := ""
+	for i := index + 1; i > 0; i = (i - 1) / 26 {
+		name = string(rune('a'+(i-1)%26)) + name
+	}
+	return prefix + name
+}
+
 // GenerateCloudInitISOPath generates a path for cloud-init ISO.
 func (b *TemplateXMLBuilder) GenerateCloudInitISOPath(vmName string, isoDir string) string {
 	// Create filename
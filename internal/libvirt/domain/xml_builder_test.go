@@ -271,3 +271,201 @@ func TestTemplateXMLBuilder_GenerateCloudInitISOPath(t *testing.T) {
 	expectedPath = filepath.Join(customDir, "test-vm-cloudinit.iso")
 	assert.Equal(t, expectedPath, path)
 }
+
+func TestTemplateXMLBuilder_BuildImportXML(t *testing.T) {
+	// Render the domain template shipped with the server
+	templateLoader, err := xmlutils.NewTemplateLoader(filepath.Join("..", "..", "..", "configs", "templates", "domain"))
+	if err != nil {
+		t.Fatalf("Failed to create template loader: %v", err)
+	}
+
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+
+	builder := NewTemplateXMLBuilder(templateLoader, mockLog)
+
+	xml, err := builder.BuildImportXML(ImportSpec{
+		Name:        "appliance",
+		CPUs:        4,
+		MemoryBytes: 4 * 1024 * 1024 * 1024,
+		Firmware:    "efi",
+		Disks: []ImportDisk{
+			{Path: "/var/lib/libvirt/images/appliance-disk-0.qcow2", Format: "qcow2", Bus: "sata"},
+			{Path: "/var/lib/libvirt/images/appliance-disk-1.qcow2", Format: "qcow2", Bus: "sata"},
+			{Path: "/var/lib/libvirt/images/appliance-disk-2.qcow2", Format: "qcow2", Bus: "virtio"},
+		},
+		Networks: []ImportNetwork{
+			{Network: "default", Model: "e1000"},
+			{Network: "isolated", Model: "vmxnet3"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildImportXML failed: %v", err)
+	}
+
+	assert.Contains(t, xml, "<name>appliance</name>")
+	assert.Contains(t, xml, "<os firmware='efi'>")
+	assert.Contains(t, xml, "<memory unit='KiB'>4194304</memory>")
+	assert.Contains(t, xml, "<vcpu placement='static'>4</vcpu>")
+	assert.Contains(t, xml, "<source file='/var/lib/libvirt/images/appliance-disk-0.qcow2'/>\n      <target dev='sda' bus='sata'/>")
+	assert.Contains(t, xml, "<source file='/var/lib/libvirt/images/appliance-disk-1.qcow2'/>\n      <target dev='sdb' bus='sata'/>")
+	assert.Contains(t, xml, "<source file='/var/lib/libvirt/images/appliance-disk-2.qcow2'/>\n      <target dev='vda' bus='virtio'/>")
+	assert.Contains(t, xml, "<source network='isolated'/>")
+	assert.Contains(t, xml, "<model type='vmxnet3'/>")
+	assert.NotContains(t, xml, "device='cdrom'")
+}
+
+func TestDiskDeviceName(t *testing.T) {
+	assert.Equal(t, "vda", diskDeviceName("vd", 0))
+	assert.Equal(t, "sdz", diskDeviceName("sd", 25))
+	assert.Equal(t, "sdaa", diskDeviceName("sd", 26))
+	assert.Equal(t, "hdab", diskDeviceName("hd", 27))
+}
+
+func TestTemplateXMLBuilder_BuildDomainXML_DiskSources(t *testing.T) {
+	// Use the shipped template, which must render block and network disks
+	templateLoader, err := xmlutils.NewTemplateLoader(filepath.Join("..", "..", "..", "configs", "templates", "domain"))
+	if err != nil {
+		t.Fatalf("Failed to create template loader: %v", err)
+	}
+
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+	builder := NewTemplateXMLBuilder(templateLoader, mockLog)
+
+	params := vm.VMParams{
+		Name:   "test-vm",
+		CPU:    vm.CPUParams{Count: 1},
+		Memory: vm.MemoryParams{SizeBytes: 1 << 30},
+		Disk: vm.DiskParams{
+			Format: "raw",
+			Source: &vm.DiskSource{Type: vm.DiskTypeBlock, Path: "/dev/vg0/test-vm-disk-0"},
+		},
+	}
+
+	xml, err := builder.BuildDomainXML(params)
+	if err != nil {
+		t.Fatalf("BuildDomainXML failed: %v", err)
+	}
+	doc, err := xmlutils.LoadXMLDocumentFromString(xml)
+	if err != nil {
+		t.Fatalf("Domain XML is invalid: %v", err)
+	}
+	disk := xmlutils.FindElement(doc, "/domain/devices/disk[@device='disk']")
+	assert.Equal(t, "block", xmlutils.GetElementAttribute(disk, "type"))
+	assert.Equal(t, "/dev/vg0/test-vm-disk-0", xmlutils.GetElementAttribute(disk.SelectElement("source"), "dev"))
+
+	params.Disk.Source = &vm.DiskSource{
+		Type:     vm.DiskTypeNetwork,
+		Protocol: "rbd",
+		Name:     "rbd/test-vm-disk-0",
+		Hosts:    []vm.DiskHost{{Name: "mon1", Port: 6789}, {Name: "mon2"}},
+		Auth:     &vm.DiskAuth{Username: "libvirt", SecretType: "ceph", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+	}
+	xml, err = builder.BuildDomainXML(params)
+	if err != nil {
+		t.Fatalf("BuildDomainXML failed: %v", err)
+	}
+	doc, err = xmlutils.LoadXMLDocumentFromString(xml)
+	if err != nil {
+		t.Fatalf("Domain XML is invalid: %v", err)
+	}
+	disk = xmlutils.FindElement(doc, "/domain/devices/disk[@device='disk']")
+	assert.Equal(t, "network", xmlutils.GetElementAttribute(disk, "type"))
+	source := disk.SelectElement("source")
+	assert.Equal(t, "rbd", xmlutils.GetElementAttribute(source, "protocol"))
+	assert.Equal(t, "rbd/test-vm-disk-0", xmlutils.GetElementAttribute(source, "name"))
+	hosts := source.SelectElements("host")
+	if assert.Len(t, hosts, 2) {
+		assert.Equal(t, "6789", xmlutils.GetElementAttribute(hosts[0], "port"))
+	}
+	assert.Equal(t, "libvirt", xmlutils.GetElementAttribute(disk.SelectElement("auth"), "username"))
+	assert.Equal(t, "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87", xmlutils.GetElementAttribute(disk.FindElement("auth/secret"), "uuid"))
+	assert.Nil(t, disk.SelectElement("encryption"))
+
+	params.Disk.Source = &vm.DiskSource{
+		Type:       vm.DiskTypeFile,
+		Path:       "/var/lib/libvirt/images/test-vm-disk-0",
+		Encryption: &vm.DiskEncryption{Format: "luks", SecretUUID: "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93"},
+	}
+	xml, err = builder.BuildDomainXML(params)
+	if err != nil {
+		t.Fatalf("BuildDomainXML failed: %v", err)
+	}
+	doc, err = xmlutils.LoadXMLDocumentFromString(xml)
+	if err != nil {
+		t.Fatalf("Domain XML is invalid: %v", err)
+	}
+	disk = xmlutils.FindElement(doc, "/domain/devices/disk[@device='disk']")
+	assert.Equal(t, "luks", xmlutils.GetElementAttribute(disk.SelectElement("encryption"), "format"))
+	secret := disk.FindElement("encryption/secret")
+	assert.Equal(t, "passphrase", xmlutils.GetElementAttribute(secret, "type"))
+	assert.Equal(t, "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93", xmlutils.GetElementAttribute(secret, "uuid"))
+}
+
+func TestTemplateXMLBuilder_BuildDomainXML_SharedFolders(t *testing.T) {
+	// Use the shipped template, which the server loads from the domain directory
+	templateLoader, err := xmlutils.NewTemplateLoader(filepath.Join("..", "..", "..", "configs", "templates", "domain"))
+	if err != nil {
+		t.Fatalf("Failed to create template loader: %v", err)
+	}
+
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+	builder := NewTemplateXMLBuilder(templateLoader, mockLog)
+
+	params := vm.VMParams{
+		Name:   "test-vm",
+		CPU:    vm.CPUParams{Count: 1},
+		Memory: vm.MemoryParams{SizeBytes: 1 << 30},
+		Disk: vm.DiskParams{
+			Format:      "qcow2",
+			SourceImage: "/var/lib/libvirt/images/test-vm.qcow2",
+		},
+		SharedFolders: []vm.SharedFolderParams{
+			{Source: "/srv/build's cache", Tag: "cache", Driver: vm.SharedFolderDriverVirtioFS},
+			{Source: "/srv/docs", Tag: "docs", Driver: vm.SharedFolderDriver9p, ReadOnly: true},
+		},
+	}
+
+	xml, err := builder.BuildDomainXML(params)
+	if err != nil {
+		t.Fatalf("BuildDomainXML failed: %v", err)
+	}
+	doc, err := xmlutils.LoadXMLDocumentFromString(xml)
+	if err != nil {
+		t.Fatalf("Domain XML is invalid: %v", err)
+	}
+
+	// virtio-fs needs shared guest memory
+	backing := xmlutils.FindElement(doc, "/domain/memoryBacking")
+	if assert.NotNil(t, backing) {
+		assert.Equal(t, "memfd", xmlutils.GetElementAttribute(backing.SelectElement("source"), "type"))
+		assert.Equal(t, "shared", xmlutils.GetElementAttribute(backing.SelectElement("access"), "mode"))
+	}
+
+	filesystems := xmlutils.FindElements(doc, "/domain/devices/filesystem")
+	if assert.Len(t, filesystems, 2) {
+		assert.Equal(t, "virtiofs", xmlutils.GetElementAttribute(filesystems[0].SelectElement("driver"), "type"))
+		assert.Equal(t, "/srv/build's cache", xmlutils.GetElementAttribute(filesystems[0].SelectElement("source"), "dir"))
+		assert.Equal(t, "cache", xmlutils.GetElementAttribute(filesystems[0].SelectElement("target"), "dir"))
+		assert.Nil(t, filesystems[0].SelectElement("readonly"))
+
+		assert.Nil(t, filesystems[1].SelectElement("driver"))
+		assert.Equal(t, "docs", xmlutils.GetElementAttribute(filesystems[1].SelectElement("target"), "dir"))
+		assert.NotNil(t, filesystems[1].SelectElement("readonly"))
+	}
+
+	// Without shares the domain keeps private memory and no filesystems
+	params.SharedFolders = nil
+	xml, err = builder.BuildDomainXML(params)
+	if err != nil {
+		t.Fatalf("BuildDomainXML failed: %v", err)
+	}
+	doc, err = xmlutils.LoadXMLDocumentFromString(xml)
+	if err != nil {
+		t.Fatalf("Domain XML is invalid: %v", err)
+	}
+	assert.Nil(t, xmlutils.FindElement(doc, "/domain/memoryBacking"))
+	assert.Empty(t, xmlutils.FindElements(doc, "/domain/devices/filesystem"))
+}
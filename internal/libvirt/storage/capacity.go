@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+)
+
+// newPoolUsage computes the usage ratios of a pool.
+func newPoolUsage(poolName string, capacity, allocation, available, virtualSize uint64, volumes int) *PoolUsage {
+	usage := &PoolUsage{
+		Timestamp:   time.Now().UTC(),
+		Pool:        poolName,
+		Capacity:    capacity,
+		Allocation:  allocation,
+		Available:   available,
+		VirtualSize: virtualSize,
+		Volumes:     volumes,
+	}
+	if capacity > 0 {
+		usage.UsagePercent = float64(allocation) / float64(capacity) * 100
+		usage.OvercommitRatio = float64(virtualSize) / float64(capacity)
+	}
+	return usage
+}
+
+// readPoolUsage reads the physical sizes of a pool and sums the virtual
+// sizes of its volumes.
+func readPoolUsage(libvirtConn *libvirt.Libvirt, pool libvirt.StoragePool) (*PoolUsage, error) {
+	_, capacity, allocation, available, err := libvirtConn.StoragePoolGetInfo(pool)
+	if err != nil {
+		return nil, fmt.Errorf("getting pool info: %w", err)
+	}
+
+	volumes, _, err := libvirtConn.StoragePoolListAllVolumes(pool, -1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing volumes: %w", err)
+	}
+
+	var virtualSize uint64
+	for _, vol := range volumes {
+		_, volCapacity, _, err := libvirtConn.StorageVolGetInfo(vol)
+		if err != nil {
+			// The volume was deleted while the pool was read
+			continue
+		}
+		virtualSize += volCapacity
+	}
+
+	return newPoolUsage(pool.Name, capacity, allocation, available, virtualSize, len(volumes)), nil
+}
+
+// checkOvercommit fails with ErrInsufficientStorage when adding a volume of
+// the given virtual size would take the pool past the overcommit limit. A
+// limit of zero disables the check.
+func checkOvercommit(usage *PoolUsage, addBytes uint64, maxOvercommit float64) error {
+	if maxOvercommit <= 0 || addBytes == 0 || usage.Capacity == 0 {
+		return nil
+	}
+
+	ratio := float64(usage.VirtualSize+addBytes) / float64(usage.Capacity)
+	if ratio > maxOvercommit {
+		return fmt.Errorf("%w: pool %s would be overcommitted %.2fx, the limit is %.2fx",
+			apierrors.ErrInsufficientStorage, usage.Pool, ratio, maxOvercommit)
+	}
+	return nil
+}
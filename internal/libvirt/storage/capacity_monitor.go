@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// DefaultCapacityCheckInterval is how often pools are sampled by default.
+const DefaultCapacityCheckInterval = time.Minute
+
+// defaultCapacityHistorySize keeps a day of samples at the default interval.
+const defaultCapacityHistorySize = 1440
+
+// maxCapacityEvents is the number of threshold events kept.
+const maxCapacityEvents = 1000
+
+// CapacityRecorder receives pool samples and threshold changes, such as a
+// metrics collector.
+type CapacityRecorder interface {
+	RecordStoragePoolUsage(host, pool string, capacity, allocation, virtualSize uint64)
+	RecordStoragePoolAlert(host, pool string, exceeded bool)
+}
+
+// CapacityMonitorConfig configures a LibvirtCapacityMonitor.
+type CapacityMonitorConfig struct {
+	// Hosts are the libvirt hosts whose pools are sampled; none samples the
+	// default host
+	Hosts []string
+	// DefaultHost names the host used by requests that select none
+	DefaultHost string
+	// AlertThreshold is the pool usage in percent that raises an event;
+	// zero disables events
+	AlertThreshold float64
+	// HistorySize is the number of samples kept per pool
+	HistorySize int
+}
+
+// LibvirtCapacityMonitor implements CapacityMonitor. Samples and events are
+// kept in memory.
+type LibvirtCapacityMonitor struct {
+	pools    PoolManager
+	recorder CapacityRecorder
+	logger   logger.Logger
+	history  map[string][]*PoolUsage
+	alerting map[string]bool
+	events   []*PoolCapacityEvent
+	config   CapacityMonitorConfig
+	mu       sync.RWMutex
+}
+
+// NewCapacityMonitor creates a new LibvirtCapacityMonitor. The recorder may
+// be nil.
+func NewCapacityMonitor(pools PoolManager, recorder CapacityRecorder, config CapacityMonitorConfig, logger logger.Logger) *LibvirtCapacityMonitor {
+	if config.HistorySize <= 0 {
+		config.HistorySize = defaultCapacityHistorySize
+	}
+	if len(config.Hosts) == 0 {
+		config.Hosts = []string{config.DefaultHost}
+	}
+
+	return &LibvirtCapacityMonitor{
+		pools:    pools,
+		recorder: recorder,
+		logger:   logger,
+		history:  make(map[string][]*PoolUsage),
+		alerting: make(map[string]bool),
+		config:   config,
+	}
+}
+
+// Start implements CapacityMonitor.Start.
+func (m *LibvirtCapacityMonitor) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCapacityCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		m.Collect(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Collect(ctx)
+			}
+		}
+	}()
+}
+
+// Collect implements CapacityMonitor.Collect. Hosts that cannot be reached
+// keep their previous samples.
+func (m *LibvirtCapacityMonitor) Collect(ctx context.Context) {
+	for _, host := range m.config.Hosts {
+		hostCtx := ctx
+		if host != "" {
+			hostCtx = connection.WithHost(ctx, host)
+		}
+
+		pools, err := m.pools.List(hostCtx)
+		if err != nil {
+			m.logger.Warn("Failed to list storage pools for capacity check",
+				logger.String("host", host),
+				logger.Error(err))
+			continue
+		}
+
+		active := make(map[string]bool, len(pools))
+		for _, pool := range pools {
+			// Inactive pools report no capacity
+			if pool.State != StoragePoolStateRunning {
+				continue
+			}
+			active[pool.Name] = true
+
+			usage, err := m.pools.GetUsage(hostCtx, pool.Name)
+			if err != nil {
+				m.logger.Warn("Failed to get storage pool usage",
+					logger.String("host", host),
+					logger.String("pool", pool.Name),
+					logger.Error(err))
+				continue
+			}
+			usage.Host = host
+			m.record(usage)
+		}
+
+		m.forgetPools(host, active)
+	}
+}
+
+// Sample implements CapacityMonitor.Sample.
+func (m *LibvirtCapacityMonitor) Sample(ctx context.Context, pool string) (*PoolUsage, error) {
+	usage, err := m.pools.GetUsage(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.Host = m.config.DefaultHost
+	if host, ok := connection.HostFromContext(ctx); ok {
+		usage.Host = host
+	}
+	m.record(usage)
+
+	return usage, nil
+}
+
+// record stores a sample and checks it against the alert threshold.
+func (m *LibvirtCapacityMonitor) record(usage *PoolUsage) {
+	key := capacityKey(usage.Host, usage.Pool)
+
+	m.mu.Lock()
+	history := append(m.history[key], usage)
+	if len(history) > m.config.HistorySize {
+		history = history[len(history)-m.config.HistorySize:]
+	}
+	m.history[key] = history
+
+	var event *PoolCapacityEvent
+	if threshold := m.config.AlertThreshold; threshold > 0 {
+		exceeded := usage.UsagePercent >= threshold
+		if exceeded != m.alerting[key] {
+			m.alerting[key] = exceeded
+			event = newPoolCapacityEvent(usage, threshold, exceeded)
+			m.events = append(m.events, event)
+			if len(m.events) > maxCapacityEvents {
+				m.events = m.events[len(m.events)-maxCapacityEvents:]
+			}
+		}
+	}
+	exceeded := m.alerting[key]
+	m.mu.Unlock()
+
+	if m.recorder != nil {
+		m.recorder.RecordStoragePoolUsage(usage.Host, usage.Pool, usage.Capacity, usage.Allocation, usage.VirtualSize)
+		m.recorder.RecordStoragePoolAlert(usage.Host, usage.Pool, exceeded)
+	}
+
+	if event == nil {
+		return
+	}
+	if event.Type == PoolEventThresholdExceeded {
+		m.logger.Warn("Storage pool is above the usage threshold",
+			logger.String("host", usage.Host),
+			logger.String("pool", usage.Pool),
+			logger.Float64("usage_percent", usage.UsagePercent),
+			logger.Float64("threshold", event.Threshold))
+	} else {
+		m.logger.Info("Storage pool is below the usage threshold again",
+			logger.String("host", usage.Host),
+			logger.String("pool", usage.Pool),
+			logger.Float64("usage_percent", usage.UsagePercent),
+			logger.Float64("threshold", event.Threshold))
+	}
+}
+
+// forgetPools drops the samples of pools of a host that were deleted or
+// stopped.
+func (m *LibvirtCapacityMonitor) forgetPools(host string, active map[string]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, history := range m.history {
+		if len(history) == 0 {
+			continue
+		}
+		if usage := history[0]; usage.Host == host && !active[usage.Pool] {
+			delete(m.history, key)
+			delete(m.alerting, key)
+		}
+	}
+}
+
+// Latest implements CapacityMonitor.Latest.
+func (m *LibvirtCapacityMonitor) Latest() []*PoolUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	latest := make([]*PoolUsage, 0, len(m.history))
+	for _, history := range m.history {
+		if len(history) > 0 {
+			latest = append(latest, history[len(history)-1])
+		}
+	}
+	sort.Slice(latest, func(i, j int) bool {
+		if latest[i].Host != latest[j].Host {
+			return latest[i].Host < latest[j].Host
+		}
+		return latest[i].Pool < latest[j].Pool
+	})
+
+	return latest
+}
+
+// History implements CapacityMonitor.History.
+func (m *LibvirtCapacityMonitor) History(host string, pool string) []*PoolUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]*PoolUsage(nil), m.history[capacityKey(host, pool)]...)
+}
+
+// Events implements CapacityMonitor.Events.
+func (m *LibvirtCapacityMonitor) Events(host string, pool string) []*PoolCapacityEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := make([]*PoolCapacityEvent, 0)
+	for i := len(m.events) - 1; i >= 0; i-- {
+		event := m.events[i]
+		if pool != "" && (event.Host != host || event.Pool != pool) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// newPoolCapacityEvent describes a pool crossing the alert threshold.
+func newPoolCapacityEvent(usage *PoolUsage, threshold float64, exceeded bool) *PoolCapacityEvent {
+	event := &PoolCapacityEvent{
+		Timestamp:    usage.Timestamp,
+		Host:         usage.Host,
+		Pool:         usage.Pool,
+		Type:         PoolEventThresholdCleared,
+		UsagePercent: usage.UsagePercent,
+		Threshold:    threshold,
+	}
+	if exceeded {
+		event.Type = PoolEventThresholdExceeded
+		event.Message = fmt.Sprintf("storage pool %s is %.1f%% full, at or above the %.1f%% threshold", usage.Pool, usage.UsagePercent, threshold)
+	} else {
+		event.Message = fmt.Sprintf("storage pool %s is %.1f%% full, below the %.1f%% threshold", usage.Pool, usage.UsagePercent, threshold)
+	}
+	return event
+}
+
+// capacityKey identifies a pool of a host.
+func capacityKey(host string, pool string) string {
+	return host + "/" + pool
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+)
+
+// fakeCapacityRecorder records what a capacity monitor reports.
+type fakeCapacityRecorder struct {
+	usage  map[string]uint64
+	alerts map[string]bool
+}
+
+func newFakeCapacityRecorder() *fakeCapacityRecorder {
+	return &fakeCapacityRecorder{
+		usage:  make(map[string]uint64),
+		alerts: make(map[string]bool),
+	}
+}
+
+func (r *fakeCapacityRecorder) RecordStoragePoolUsage(host, pool string, capacity, allocation, virtualSize uint64) {
+	r.usage[capacityKey(host, pool)] = allocation
+}
+
+func (r *fakeCapacityRecorder) RecordStoragePoolAlert(host, pool string, exceeded bool) {
+	r.alerts[capacityKey(host, pool)] = exceeded
+}
+
+func TestCapacityMonitor_Collect(t *testing.T) {
+	allocation := map[string]uint64{"default": 50, "data": 10}
+	pools := &MockPoolManager{
+		ListFn: func(ctx context.Context) ([]*StoragePoolInfo, error) {
+			return []*StoragePoolInfo{
+				{Name: "default", State: StoragePoolStateRunning},
+				{Name: "data", State: StoragePoolStateRunning},
+				{Name: "stopped", State: StoragePoolStateInactive},
+			}, nil
+		},
+		GetUsageFn: func(ctx context.Context, name string) (*PoolUsage, error) {
+			host, _ := connection.HostFromContext(ctx)
+			assert.Equal(t, "kvm1", host)
+			return newPoolUsage(name, 100, allocation[name], 100-allocation[name], 120, 1), nil
+		},
+	}
+
+	mockLog := new(mockLogger)
+	mockLog.On("Warn", "Storage pool is above the usage threshold", mock.Anything).Return()
+	mockLog.On("Info", "Storage pool is below the usage threshold again", mock.Anything).Return()
+
+	recorder := newFakeCapacityRecorder()
+	monitor := NewCapacityMonitor(pools, recorder, CapacityMonitorConfig{
+		Hosts:          []string{"kvm1"},
+		DefaultHost:    "kvm1",
+		AlertThreshold: 80,
+		HistorySize:    2,
+	}, mockLog)
+
+	monitor.Collect(context.Background())
+	latest := monitor.Latest()
+	require.Len(t, latest, 2)
+	assert.Equal(t, "data", latest[0].Pool)
+	assert.Equal(t, "default", latest[1].Pool)
+	assert.Equal(t, "kvm1", latest[1].Host)
+	assert.Equal(t, 1.2, latest[1].OvercommitRatio)
+	assert.Equal(t, uint64(50), recorder.usage["kvm1/default"])
+	assert.Empty(t, monitor.Events("", ""))
+
+	// Crossing the threshold raises an event once
+	allocation["default"] = 90
+	monitor.Collect(context.Background())
+	monitor.Collect(context.Background())
+	events := monitor.Events("kvm1", "default")
+	require.Len(t, events, 1)
+	assert.Equal(t, PoolEventThresholdExceeded, events[0].Type)
+	assert.Equal(t, 90.0, events[0].UsagePercent)
+	assert.True(t, recorder.alerts["kvm1/default"])
+	assert.False(t, recorder.alerts["kvm1/data"])
+	assert.Empty(t, monitor.Events("kvm1", "data"))
+
+	// History keeps the configured number of samples
+	assert.Len(t, monitor.History("kvm1", "default"), 2)
+
+	// Dropping below the threshold clears it
+	allocation["default"] = 40
+	monitor.Collect(context.Background())
+	events = monitor.Events("", "")
+	require.Len(t, events, 2)
+	assert.Equal(t, PoolEventThresholdCleared, events[0].Type)
+	assert.False(t, recorder.alerts["kvm1/default"])
+}
+
+func TestCapacityMonitor_CollectForgetsPools(t *testing.T) {
+	running := true
+	pools := &MockPoolManager{
+		ListFn: func(ctx context.Context) ([]*StoragePoolInfo, error) {
+			if !running {
+				return []*StoragePoolInfo{}, nil
+			}
+			return []*StoragePoolInfo{{Name: "default", State: StoragePoolStateRunning}}, nil
+		},
+		GetUsageFn: func(ctx context.Context, name string) (*PoolUsage, error) {
+			return newPoolUsage(name, 100, 10, 90, 10, 1), nil
+		},
+	}
+
+	monitor := NewCapacityMonitor(pools, nil, CapacityMonitorConfig{DefaultHost: "local"}, new(mockLogger))
+	monitor.Collect(context.Background())
+	require.Len(t, monitor.Latest(), 1)
+
+	running = false
+	monitor.Collect(context.Background())
+	assert.Empty(t, monitor.Latest())
+	assert.Empty(t, monitor.History("", "default"))
+}
+
+func TestCapacityMonitor_Sample(t *testing.T) {
+	pools := &MockPoolManager{
+		GetUsageFn: func(ctx context.Context, name string) (*PoolUsage, error) {
+			if name == "missing" {
+				return nil, ErrPoolNotFound
+			}
+			return newPoolUsage(name, 100, 10, 90, 10, 1), nil
+		},
+	}
+
+	monitor := NewCapacityMonitor(pools, nil, CapacityMonitorConfig{DefaultHost: "kvm1"}, new(mockLogger))
+
+	usage, err := monitor.Sample(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "kvm1", usage.Host)
+
+	usage, err = monitor.Sample(connection.WithHost(context.Background(), "kvm2"), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "kvm2", usage.Host)
+	assert.Len(t, monitor.History("kvm2", "default"), 1)
+
+	_, err = monitor.Sample(context.Background(), "missing")
+	assert.True(t, errors.Is(err, ErrPoolNotFound))
+}
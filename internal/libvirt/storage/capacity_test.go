@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+)
+
+func TestNewPoolUsage(t *testing.T) {
+	usage := newPoolUsage("default", 100, 25, 75, 150, 3)
+	assert.Equal(t, "default", usage.Pool)
+	assert.Equal(t, 25.0, usage.UsagePercent)
+	assert.Equal(t, 1.5, usage.OvercommitRatio)
+	assert.Equal(t, 3, usage.Volumes)
+	assert.False(t, usage.Timestamp.IsZero())
+
+	// Pools that report no capacity have no ratios
+	usage = newPoolUsage("empty", 0, 0, 0, 10, 1)
+	assert.Zero(t, usage.UsagePercent)
+	assert.Zero(t, usage.OvercommitRatio)
+}
+
+func TestCheckOvercommit(t *testing.T) {
+	usage := newPoolUsage("default", 100, 50, 50, 150, 2)
+
+	tests := []struct {
+		name          string
+		addBytes      uint64
+		maxOvercommit float64
+		wantErr       bool
+	}{
+		{name: "within limit", addBytes: 50, maxOvercommit: 2},
+		{name: "at limit", addBytes: 50, maxOvercommit: 2.0},
+		{name: "beyond limit", addBytes: 51, maxOvercommit: 2, wantErr: true},
+		{name: "no limit", addBytes: 1000, maxOvercommit: 0},
+		{name: "nothing added", addBytes: 0, maxOvercommit: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOvercommit(usage, tt.addBytes, tt.maxOvercommit)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apierrors.ErrInsufficientStorage)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// passphraseBytes is the number of random bytes in a volume passphrase.
+const passphraseBytes = 32
+
+// KeyCipher seals volume passphrases with AES-256-GCM under the master key.
+// Passphrases sealed under previous master keys can still be opened.
+type KeyCipher struct {
+	keys    map[string]cipher.AEAD
+	current string
+}
+
+// NewKeyCipher creates a KeyCipher from base64 encoded 32 byte master keys.
+func NewKeyCipher(masterKey string, previousKeys []string) (*KeyCipher, error) {
+	c := &KeyCipher{keys: make(map[string]cipher.AEAD)}
+
+	id, err := c.addKey(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("master key: %w", err)
+	}
+	c.current = id
+
+	for i, key := range previousKeys {
+		if _, err := c.addKey(key); err != nil {
+			return nil, fmt.Errorf("previous master key %d: %w", i, err)
+		}
+	}
+
+	return c, nil
+}
+
+// addKey decodes a master key and returns its ID.
+func (c *KeyCipher) addKey(encoded string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decoding key: %w", err)
+	}
+	if len(key) != 32 {
+		return "", fmt.Errorf("key is %d bytes, want 32", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("creating cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("creating cipher: %w", err)
+	}
+
+	// The ID names the key in the database without revealing it
+	sum := sha256.Sum256(append([]byte("libgo-master-key:"), key...))
+	id := hex.EncodeToString(sum[:8])
+	c.keys[id] = aead
+	return id, nil
+}
+
+// KeyID returns the ID of the current master key.
+func (c *KeyCipher) KeyID() string {
+	return c.current
+}
+
+// Seal encrypts plaintext under the current master key. The additional data
+// is authenticated but not stored.
+func (c *KeyCipher) Seal(plaintext []byte, additionalData []byte) ([]byte, error) {
+	aead := c.keys[c.current]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts data sealed under the master key with the given ID.
+func (c *KeyCipher) Open(keyID string, sealed []byte, additionalData []byte) ([]byte, error) {
+	aead, ok := c.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not configured", keyID)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed data is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting with master key %s: %w", keyID, err)
+	}
+
+	return plaintext, nil
+}
+
+// NewPassphrase generates a random volume passphrase.
+func NewPassphrase() ([]byte, error) {
+	raw := make([]byte, passphraseBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generating passphrase: %w", err)
+	}
+
+	// qemu-img and libvirt take the passphrase as text
+	passphrase := make([]byte, base64.StdEncoding.EncodedLen(len(raw)))
+	base64.StdEncoding.Encode(passphrase, raw)
+	return passphrase, nil
+}
+
+// secretXML is the libvirt definition of a volume secret.
+type secretXML struct {
+	XMLName     xml.Name `xml:"secret"`
+	Ephemeral   string   `xml:"ephemeral,attr"`
+	Private     string   `xml:"private,attr"`
+	UUID        string   `xml:"uuid"`
+	Description string   `xml:"description"`
+}
+
+// KeyManagerConfig configures a LibvirtKeyManager.
+type KeyManagerConfig struct {
+	// DefaultHost names the host used by requests that select none
+	DefaultHost string
+}
+
+// LibvirtKeyManager implements VolumeKeyManager with libvirt secrets.
+type LibvirtKeyManager struct {
+	connManager connection.Manager
+	store       KeyStore
+	cipher      *KeyCipher
+	logger      logger.Logger
+	config      KeyManagerConfig
+}
+
+// NewLibvirtKeyManager creates a new LibvirtKeyManager.
+func NewLibvirtKeyManager(connManager connection.Manager, store KeyStore, keyCipher *KeyCipher, config KeyManagerConfig, logger logger.Logger) *LibvirtKeyManager {
+	return &LibvirtKeyManager{
+		connManager: connManager,
+		store:       store,
+		cipher:      keyCipher,
+		logger:      logger,
+		config:      config,
+	}
+}
+
+// Create implements VolumeKeyManager.Create.
+func (m *LibvirtKeyManager) Create(ctx context.Context, pool string, volume string) (*VolumeKey, error) {
+	host := m.host(ctx)
+
+	// A key is left behind when its volume was deleted outside LibGo
+	if _, err := m.store.Get(ctx, host, pool, volume); err == nil {
+		if err := m.Delete(ctx, pool, volume); err != nil {
+			return nil, fmt.Errorf("erasing stale key: %w", err)
+		}
+	} else if !errors.Is(err, ErrVolumeKeyNotFound) {
+		return nil, err
+	}
+
+	passphrase, err := NewPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &StoredVolumeKey{
+		VolumeKey: VolumeKey{
+			CreatedAt:  time.Now().UTC(),
+			Host:       host,
+			Pool:       pool,
+			Volume:     volume,
+			SecretUUID: uuid.New().String(),
+		},
+	}
+	if err := m.seal(key, passphrase); err != nil {
+		return nil, err
+	}
+
+	definition, err := xml.Marshal(&secretXML{
+		Ephemeral:   "no",
+		Private:     "yes",
+		UUID:        key.SecretUUID,
+		Description: fmt.Sprintf("LibGo passphrase of volume %s in pool %s", volume, pool),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding secret XML: %w", err)
+	}
+
+	err = m.withConnection(ctx, func(libvirtConn *libvirt.Libvirt) error {
+		secret, err := libvirtConn.SecretDefineXML(string(definition), 0)
+		if err != nil {
+			return fmt.Errorf("defining secret: %w", err)
+		}
+		if err := libvirtConn.SecretSetValue(secret, passphrase, 0); err != nil {
+			m.undefineSecret(libvirtConn, secret)
+			return fmt.Errorf("setting secret value: %w", err)
+		}
+		if err := m.store.Put(ctx, key); err != nil {
+			m.undefineSecret(libvirtConn, secret)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Created volume key",
+		logger.String("host", host),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("secret_uuid", key.SecretUUID))
+
+	return &key.VolumeKey, nil
+}
+
+// Get implements VolumeKeyManager.Get.
+func (m *LibvirtKeyManager) Get(ctx context.Context, pool string, volume string) (*VolumeKey, error) {
+	key, err := m.store.Get(ctx, m.host(ctx), pool, volume)
+	if err != nil {
+		return nil, err
+	}
+	return &key.VolumeKey, nil
+}
+
+// Passphrase implements VolumeKeyManager.Passphrase.
+func (m *LibvirtKeyManager) Passphrase(ctx context.Context, pool string, volume string) ([]byte, error) {
+	key, err := m.store.Get(ctx, m.host(ctx), pool, volume)
+	if err != nil {
+		return nil, err
+	}
+	return m.open(key)
+}
+
+// SetPassphrase implements VolumeKeyManager.SetPassphrase.
+func (m *LibvirtKeyManager) SetPassphrase(ctx context.Context, pool string, volume string, passphrase []byte) (*VolumeKey, error) {
+	key, err := m.store.Get(ctx, m.host(ctx), pool, volume)
+	if err != nil {
+		return nil, err
+	}
+
+	rotatedAt := time.Now().UTC()
+	key.RotatedAt = &rotatedAt
+	if err := m.seal(key, passphrase); err != nil {
+		return nil, err
+	}
+
+	err = m.withConnection(ctx, func(libvirtConn *libvirt.Libvirt) error {
+		secret, err := m.lookupSecret(libvirtConn, key.SecretUUID)
+		if err != nil {
+			return err
+		}
+		if err := libvirtConn.SecretSetValue(secret, passphrase, 0); err != nil {
+			return fmt.Errorf("setting secret value: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.store.Put(ctx, key); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Replaced volume passphrase",
+		logger.String("host", key.Host),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("secret_uuid", key.SecretUUID))
+
+	return &key.VolumeKey, nil
+}
+
+// Delete implements VolumeKeyManager.Delete.
+func (m *LibvirtKeyManager) Delete(ctx context.Context, pool string, volume string) error {
+	key, err := m.store.Get(ctx, m.host(ctx), pool, volume)
+	if err != nil {
+		return err
+	}
+
+	err = m.withConnection(ctx, func(libvirtConn *libvirt.Libvirt) error {
+		secret, err := m.lookupSecret(libvirtConn, key.SecretUUID)
+		if err != nil {
+			// The stored passphrase is the last copy
+			m.logger.Warn("Volume secret is already undefined",
+				logger.String("secret_uuid", key.SecretUUID),
+				logger.Error(err))
+			return nil
+		}
+		if err := libvirtConn.SecretUndefine(secret); err != nil {
+			return fmt.Errorf("undefining secret: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := m.store.Delete(ctx, key.Host, pool, volume); err != nil {
+		return err
+	}
+
+	m.logger.Info("Erased volume key",
+		logger.String("host", key.Host),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("secret_uuid", key.SecretUUID))
+
+	return nil
+}
+
+// Rewrap implements VolumeKeyManager.Rewrap.
+func (m *LibvirtKeyManager) Rewrap(ctx context.Context) (int, error) {
+	keys, err := m.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, key := range keys {
+		if key.MasterKeyID == m.cipher.KeyID() {
+			continue
+		}
+
+		passphrase, err := m.open(key)
+		if err != nil {
+			return rewrapped, fmt.Errorf("volume %s in pool %s: %w", key.Volume, key.Pool, err)
+		}
+		if err := m.seal(key, passphrase); err != nil {
+			return rewrapped, err
+		}
+		if err := m.store.Put(ctx, key); err != nil {
+			return rewrapped, err
+		}
+		rewrapped++
+	}
+
+	if rewrapped > 0 {
+		m.logger.Info("Encrypted volume passphrases under the current master key",
+			logger.Int("count", rewrapped))
+	}
+
+	return rewrapped, nil
+}
+
+// seal seals a passphrase into a key under the current master key.
+func (m *LibvirtKeyManager) seal(key *StoredVolumeKey, passphrase []byte) error {
+	sealed, err := m.cipher.Seal(passphrase, keyAdditionalData(key))
+	if err != nil {
+		return fmt.Errorf("sealing passphrase: %w", err)
+	}
+	key.SealedPassphrase = sealed
+	key.MasterKeyID = m.cipher.KeyID()
+	return nil
+}
+
+// open opens the passphrase of a key.
+func (m *LibvirtKeyManager) open(key *StoredVolumeKey) ([]byte, error) {
+	passphrase, err := m.cipher.Open(key.MasterKeyID, key.SealedPassphrase, keyAdditionalData(key))
+	if err != nil {
+		return nil, fmt.Errorf("opening passphrase: %w", err)
+	}
+	return passphrase, nil
+}
+
+// keyAdditionalData binds a sealed passphrase to its volume and secret, so
+// that it cannot be moved to another database row.
+func keyAdditionalData(key *StoredVolumeKey) []byte {
+	return []byte(key.Host + "\x00" + key.Pool + "\x00" + key.Volume + "\x00" + key.SecretUUID)
+}
+
+// lookupSecret looks up a secret by its UUID.
+func (m *LibvirtKeyManager) lookupSecret(libvirtConn *libvirt.Libvirt, secretUUID string) (libvirt.Secret, error) {
+	parsed, err := uuid.Parse(secretUUID)
+	if err != nil {
+		return libvirt.Secret{}, fmt.Errorf("parsing secret UUID %s: %w", secretUUID, err)
+	}
+
+	secret, err := libvirtConn.SecretLookupByUUID(libvirt.UUID(parsed))
+	if err != nil {
+		return libvirt.Secret{}, fmt.Errorf("looking up secret %s: %w", secretUUID, err)
+	}
+
+	return secret, nil
+}
+
+// undefineSecret removes a secret during error cleanup.
+func (m *LibvirtKeyManager) undefineSecret(libvirtConn *libvirt.Libvirt, secret libvirt.Secret) {
+	if err := libvirtConn.SecretUndefine(secret); err != nil {
+		m.logger.Error("Failed to undefine secret during cleanup", logger.Error(err))
+	}
+}
+
+// withConnection runs an operation on a connection to the host selected by
+// the context.
+func (m *LibvirtKeyManager) withConnection(ctx context.Context, operation func(*libvirt.Libvirt) error) error {
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer func() {
+		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
+			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+		}
+	}()
+
+	return operation(conn.GetLibvirtConnection())
+}
+
+// host returns the host selected by a context.
+func (m *LibvirtKeyManager) host(ctx context.Context) string {
+	if host, ok := connection.HostFromContext(ctx); ok {
+		return host
+	}
+	return m.config.DefaultHost
+}
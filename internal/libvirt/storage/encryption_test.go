@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+const (
+	testMasterKey   = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testPreviousKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+// newTestKeyStore creates a key store backed by an in-memory database.
+func newTestKeyStore(t *testing.T) *GormKeyStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+
+	store, err := NewGormKeyStore(db)
+	require.NoError(t, err)
+	return store
+}
+
+func TestKeyCipher(t *testing.T) {
+	c, err := NewKeyCipher(testMasterKey, nil)
+	require.NoError(t, err)
+	assert.Len(t, c.KeyID(), 16)
+
+	sealed, err := c.Seal([]byte("passphrase"), []byte("kvm1/default/web-disk-0"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "passphrase")
+
+	opened, err := c.Open(c.KeyID(), sealed, []byte("kvm1/default/web-disk-0"))
+	require.NoError(t, err)
+	assert.Equal(t, "passphrase", string(opened))
+
+	// The passphrase is bound to its volume
+	_, err = c.Open(c.KeyID(), sealed, []byte("kvm1/default/db-disk-0"))
+	assert.Error(t, err)
+
+	_, err = c.Open("unknown", sealed, []byte("kvm1/default/web-disk-0"))
+	assert.Error(t, err)
+
+	_, err = NewKeyCipher("c2hvcnQ=", nil)
+	assert.Error(t, err)
+	_, err = NewKeyCipher(testMasterKey, []string{"not base64"})
+	assert.Error(t, err)
+}
+
+func TestNewPassphrase(t *testing.T) {
+	first, err := NewPassphrase()
+	require.NoError(t, err)
+	second, err := NewPassphrase()
+	require.NoError(t, err)
+
+	assert.Len(t, first, 44)
+	assert.NotEqual(t, first, second)
+}
+
+func TestGormKeyStore(t *testing.T) {
+	store := newTestKeyStore(t)
+	ctx := context.Background()
+
+	key := &StoredVolumeKey{
+		VolumeKey: VolumeKey{
+			CreatedAt:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			Host:       "kvm1",
+			Pool:       "default",
+			Volume:     "web-disk-0",
+			SecretUUID: "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93",
+		},
+		MasterKeyID:      "0011223344556677",
+		SealedPassphrase: []byte("sealed"),
+	}
+	require.NoError(t, store.Put(ctx, key))
+
+	got, err := store.Get(ctx, "kvm1", "default", "web-disk-0")
+	require.NoError(t, err)
+	assert.Equal(t, key.SecretUUID, got.SecretUUID)
+	assert.Equal(t, []byte("sealed"), got.SealedPassphrase)
+	assert.Nil(t, got.RotatedAt)
+
+	// Put replaces the key
+	rotatedAt := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	key.RotatedAt = &rotatedAt
+	key.SealedPassphrase = []byte("resealed")
+	require.NoError(t, store.Put(ctx, key))
+
+	keys, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, []byte("resealed"), keys[0].SealedPassphrase)
+	require.NotNil(t, keys[0].RotatedAt)
+	assert.True(t, rotatedAt.Equal(*keys[0].RotatedAt))
+
+	// Keys are per host
+	_, err = store.Get(ctx, "kvm2", "default", "web-disk-0")
+	assert.ErrorIs(t, err, ErrVolumeKeyNotFound)
+
+	require.NoError(t, store.Delete(ctx, "kvm1", "default", "web-disk-0"))
+	assert.ErrorIs(t, store.Delete(ctx, "kvm1", "default", "web-disk-0"), ErrVolumeKeyNotFound)
+}
+
+func TestLibvirtKeyManager_Rewrap(t *testing.T) {
+	store := newTestKeyStore(t)
+	ctx := connection.WithHost(context.Background(), "kvm1")
+
+	mockLog := new(mockLogger)
+	mockLog.On("Info", mock.Anything, mock.Anything).Return()
+
+	// A passphrase stored under the previous master key
+	previous, err := NewKeyCipher(testPreviousKey, nil)
+	require.NoError(t, err)
+	old := NewLibvirtKeyManager(nil, store, previous, KeyManagerConfig{}, mockLog)
+	key := &StoredVolumeKey{
+		VolumeKey: VolumeKey{
+			Host:       "kvm1",
+			Pool:       "default",
+			Volume:     "web-disk-0",
+			SecretUUID: "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93",
+		},
+	}
+	require.NoError(t, old.seal(key, []byte("passphrase")))
+	require.NoError(t, store.Put(ctx, key))
+
+	// The current master key alone cannot open it
+	current, err := NewKeyCipher(testMasterKey, nil)
+	require.NoError(t, err)
+	_, err = NewLibvirtKeyManager(nil, store, current, KeyManagerConfig{}, mockLog).Passphrase(ctx, "default", "web-disk-0")
+	assert.Error(t, err)
+
+	rotated, err := NewKeyCipher(testMasterKey, []string{testPreviousKey})
+	require.NoError(t, err)
+	manager := NewLibvirtKeyManager(nil, store, rotated, KeyManagerConfig{}, mockLog)
+
+	count, err := manager.Rewrap(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	stored, err := store.Get(ctx, "kvm1", "default", "web-disk-0")
+	require.NoError(t, err)
+	assert.Equal(t, current.KeyID(), stored.MasterKeyID)
+
+	// Once rewrapped, the previous master key is no longer needed
+	passphrase, err := NewLibvirtKeyManager(nil, store, current, KeyManagerConfig{}, mockLog).Passphrase(ctx, "default", "web-disk-0")
+	require.NoError(t, err)
+	assert.Equal(t, "passphrase", string(passphrase))
+
+	count, err = manager.Rewrap(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestParseVolumeEncryption(t *testing.T) {
+	doc, err := xmlutils.LoadXMLDocumentFromString(`<volume type='file'>
+  <name>web-disk-0</name>
+  <target>
+    <path>/var/lib/libvirt/images/web-disk-0</path>
+    <format type='qcow2'/>
+    <encryption format='luks'>
+      <secret type='passphrase' uuid='6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93'/>
+    </encryption>
+  </target>
+</volume>`)
+	require.NoError(t, err)
+
+	encryption := parseVolumeEncryption(doc)
+	require.NotNil(t, encryption)
+	assert.Equal(t, "luks", encryption.Format)
+	assert.Equal(t, "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93", encryption.SecretUUID)
+
+	doc, err = xmlutils.LoadXMLDocumentFromString(`<volume><name>data</name><target><format type='raw'/></target></volume>`)
+	require.NoError(t, err)
+	assert.Nil(t, parseVolumeEncryption(doc))
+}
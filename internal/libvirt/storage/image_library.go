@@ -0,0 +1,502 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// imageNamePattern matches valid image names.
+var imageNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ImageLibraryConfig holds image library configuration.
+type ImageLibraryConfig struct {
+	// DefaultPool receives images registered without a pool
+	DefaultPool string
+	// DefaultHost names the host used by requests that select none
+	DefaultHost string
+	// SourceDir holds the image files that can be registered by path;
+	// registering by path is disabled when it is empty
+	SourceDir string
+}
+
+// LibvirtImageLibrary implements ImageLibrary on top of a VolumeManager.
+type LibvirtImageLibrary struct {
+	volumeManager VolumeManager
+	store         ImageStore
+	logger        logger.Logger
+	// registering holds the names of images being registered
+	registering map[string]bool
+	config      ImageLibraryConfig
+	// mu serializes changes to images and their references
+	mu sync.Mutex
+}
+
+// NewImageLibrary creates a new LibvirtImageLibrary.
+func NewImageLibrary(volumeManager VolumeManager, store ImageStore, config ImageLibraryConfig, logger logger.Logger) *LibvirtImageLibrary {
+	return &LibvirtImageLibrary{
+		volumeManager: volumeManager,
+		store:         store,
+		config:        config,
+		logger:        logger,
+		registering:   make(map[string]bool),
+	}
+}
+
+// Register implements ImageLibrary.Register.
+func (l *LibvirtImageLibrary) Register(ctx context.Context, params *RegisterImageParams) (*Image, error) {
+	if !imageNamePattern.MatchString(params.Name) {
+		return nil, fmt.Errorf("%w: invalid image name %q", apierrors.ErrInvalidParameter, params.Name)
+	}
+	if params.SourcePath == "" && params.Volume == "" {
+		return nil, fmt.Errorf("%w: either source_path or volume is required", apierrors.ErrInvalidParameter)
+	}
+
+	var source string
+	if params.SourcePath != "" {
+		var err error
+		if source, err = l.resolveSource(params.SourcePath); err != nil {
+			return nil, err
+		}
+	}
+
+	pool := params.Pool
+	if pool == "" {
+		pool = l.config.DefaultPool
+	}
+	volume := params.Volume
+	if volume == "" {
+		volume = params.Name + filepath.Ext(params.SourcePath)
+	}
+	host := l.hostOf(ctx)
+
+	// Reserve the name, as imports and checksums take a while
+	if err := l.reserve(ctx, params.Name, host, pool, volume); err != nil {
+		return nil, err
+	}
+	defer l.release(params.Name)
+
+	imported := false
+	if source != "" {
+		if err := l.importImage(ctx, params, source, pool, volume); err != nil {
+			return nil, err
+		}
+		imported = true
+	}
+
+	image, err := l.describeImage(ctx, params, host, pool, volume)
+	if err == nil {
+		err = l.store.Create(ctx, image)
+	}
+	if err != nil {
+		if imported {
+			if deleteErr := l.volumeManager.Delete(ctx, pool, volume); deleteErr != nil {
+				l.logger.Warn("Failed to delete volume of unregistered image",
+					logger.String("pool", pool),
+					logger.String("volume", volume),
+					logger.Error(deleteErr))
+			}
+		}
+		return nil, err
+	}
+
+	l.logger.Info("Registered image",
+		logger.String("image", image.Name),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("sha256", image.SHA256),
+		logger.Bool("read_only", image.ReadOnly))
+
+	return image, nil
+}
+
+// reserve checks that neither the name nor the volume of a new image is
+// registered and reserves the name until the image is stored.
+func (l *LibvirtImageLibrary) reserve(ctx context.Context, name string, host string, pool string, volume string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.registering[name] {
+		return fmt.Errorf("%w: image %s is being registered", apierrors.ErrAlreadyExists, name)
+	}
+
+	images, err := l.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if image.Name == name {
+			return fmt.Errorf("%w: image %s", apierrors.ErrAlreadyExists, name)
+		}
+		if image.Host == host && image.Pool == pool && image.Volume == volume {
+			return fmt.Errorf("%w: volume %s is registered as image %s", apierrors.ErrAlreadyExists, volume, image.Name)
+		}
+	}
+
+	l.registering[name] = true
+	return nil
+}
+
+// release releases the name reserved for a new image.
+func (l *LibvirtImageLibrary) release(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.registering, name)
+}
+
+// resolveSource checks that a path given by a client names a file in the
+// source directory.
+func (l *LibvirtImageLibrary) resolveSource(source string) (string, error) {
+	if l.config.SourceDir == "" {
+		return "", fmt.Errorf("%w: registering files on the server is disabled", apierrors.ErrInvalidParameter)
+	}
+	if !filepath.IsAbs(source) {
+		return "", fmt.Errorf("%w: source_path must be an absolute path", apierrors.ErrInvalidParameter)
+	}
+
+	sourceDir, err := filepath.EvalSymlinks(l.config.SourceDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving image source directory: %w", err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return "", fmt.Errorf("%w: source_path %s: %v", apierrors.ErrInvalidParameter, source, err)
+	}
+
+	relative, err := filepath.Rel(sourceDir, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: source_path must be in %s", apierrors.ErrInvalidParameter, l.config.SourceDir)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("%w: source_path %s: %v", apierrors.ErrInvalidParameter, source, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%w: source_path %s is not a file", apierrors.ErrInvalidParameter, source)
+	}
+
+	return resolved, nil
+}
+
+// importImage imports an image file, resolved by resolveSource, into a new
+// volume, verifying its checksum first.
+func (l *LibvirtImageLibrary) importImage(ctx context.Context, params *RegisterImageParams, source string, pool string, volume string) error {
+	if params.SHA256 != "" {
+		checksum, err := fileChecksum(source)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(checksum, params.SHA256) {
+			return fmt.Errorf("%w: %s has checksum %s, expected %s",
+				apierrors.ErrInvalidParameter, params.SourcePath, checksum, params.SHA256)
+		}
+	}
+
+	// Importing replaces existing volumes, which may be in use
+	if _, err := l.volumeManager.GetInfo(ctx, pool, volume); err == nil {
+		return fmt.Errorf("volume %s in pool %s: %w", volume, pool, ErrVolumeExists)
+	}
+
+	if err := l.volumeManager.CreateFromImage(ctx, pool, volume, source, params.Format); err != nil {
+		return fmt.Errorf("importing image: %w", err)
+	}
+
+	return nil
+}
+
+// describeImage builds the image registered for a volume, refusing volumes
+// whose data is registered already.
+func (l *LibvirtImageLibrary) describeImage(ctx context.Context, params *RegisterImageParams, host string, pool string, volume string) (*Image, error) {
+	info, err := l.volumeManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting image volume: %w", err)
+	}
+
+	checksum, err := l.volumeChecksum(ctx, pool, volume)
+	if err != nil {
+		return nil, err
+	}
+	if params.SourcePath == "" && params.SHA256 != "" && !strings.EqualFold(checksum, params.SHA256) {
+		return nil, fmt.Errorf("%w: volume %s has checksum %s, expected %s",
+			apierrors.ErrInvalidParameter, volume, checksum, params.SHA256)
+	}
+
+	images, err := l.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		if image.SHA256 == checksum {
+			return nil, fmt.Errorf("%w: image %s has the same data", apierrors.ErrAlreadyExists, image.Name)
+		}
+	}
+
+	readOnly := true
+	if params.ReadOnly != nil {
+		readOnly = *params.ReadOnly
+	}
+
+	now := time.Now().UTC()
+	return &Image{
+		Name:        params.Name,
+		Description: params.Description,
+		OS:          params.OS,
+		Host:        host,
+		Pool:        pool,
+		Volume:      volume,
+		Path:        info.Path,
+		Format:      info.Format,
+		Capacity:    info.Capacity,
+		SHA256:      checksum,
+		ReadOnly:    readOnly,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// volumeChecksum computes the SHA-256 digest of the data of a volume.
+func (l *LibvirtImageLibrary) volumeChecksum(ctx context.Context, pool string, volume string) (string, error) {
+	hash := sha256.New()
+	if err := l.volumeManager.Download(ctx, pool, volume, hash); err != nil {
+		return "", fmt.Errorf("reading image volume: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fileChecksum computes the SHA-256 digest of a file.
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening image file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("reading image file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Get implements ImageLibrary.Get.
+func (l *LibvirtImageLibrary) Get(ctx context.Context, name string) (*Image, error) {
+	image, err := l.store.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	l.pruneReferences(ctx, image)
+	return image, nil
+}
+
+// List implements ImageLibrary.List.
+func (l *LibvirtImageLibrary) List(ctx context.Context) ([]*Image, error) {
+	images, err := l.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		l.pruneReferences(ctx, image)
+	}
+	return images, nil
+}
+
+// pruneReferences drops the references of an image whose overlay was
+// deleted or no longer has the image as backing store. Overlays on other
+// hosts than the one of the context cannot be checked and are kept.
+func (l *LibvirtImageLibrary) pruneReferences(ctx context.Context, image *Image) {
+	if l.hostOf(ctx) != image.Host {
+		return
+	}
+
+	kept := image.References[:0]
+	for _, ref := range image.References {
+		info, err := l.volumeManager.GetInfo(ctx, ref.Pool, ref.Volume)
+		gone := errors.Is(err, ErrVolumeNotFound) ||
+			(err == nil && (info.BackingStore == nil || info.BackingStore.Path != image.Path))
+		if !gone {
+			kept = append(kept, ref)
+			continue
+		}
+
+		if err := l.store.RemoveReference(ctx, image.Name, ref.Pool, ref.Volume); err != nil {
+			l.logger.Warn("Failed to remove image reference",
+				logger.String("image", image.Name),
+				logger.String("volume", ref.Volume),
+				logger.Error(err))
+			kept = append(kept, ref)
+			continue
+		}
+		l.logger.Info("Removed reference of deleted overlay",
+			logger.String("image", image.Name),
+			logger.String("pool", ref.Pool),
+			logger.String("volume", ref.Volume))
+	}
+	image.References = kept
+}
+
+// Update implements ImageLibrary.Update.
+func (l *LibvirtImageLibrary) Update(ctx context.Context, name string, params *UpdateImageParams) (*Image, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	image, err := l.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.ReadOnly != nil && !*params.ReadOnly && len(image.References) > 0 {
+		return nil, fmt.Errorf("%w: image %s backs %d volumes, which break when it changes",
+			apierrors.ErrImageInUse, name, len(image.References))
+	}
+
+	if params.Description != nil {
+		image.Description = *params.Description
+	}
+	if params.OS != nil {
+		image.OS = *params.OS
+	}
+	if params.ReadOnly != nil {
+		image.ReadOnly = *params.ReadOnly
+	}
+	image.UpdatedAt = time.Now().UTC()
+
+	if err := l.store.Update(ctx, image); err != nil {
+		return nil, err
+	}
+
+	l.logger.Info("Updated image",
+		logger.String("image", name),
+		logger.Bool("read_only", image.ReadOnly))
+
+	return image, nil
+}
+
+// Delete implements ImageLibrary.Delete.
+func (l *LibvirtImageLibrary) Delete(ctx context.Context, name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	image, err := l.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if len(image.References) > 0 {
+		volumes := make([]string, 0, len(image.References))
+		for _, ref := range image.References {
+			volumes = append(volumes, ref.Pool+"/"+ref.Volume)
+		}
+		return fmt.Errorf("%w: image %s backs %s", apierrors.ErrImageInUse, name, strings.Join(volumes, ", "))
+	}
+	if image.ReadOnly {
+		return fmt.Errorf("%w: clear read_only to delete image %s", apierrors.ErrImageReadOnly, name)
+	}
+	if err := l.checkHost(ctx, image); err != nil {
+		return err
+	}
+
+	if err := l.volumeManager.Delete(ctx, image.Pool, image.Volume); err != nil && !errors.Is(err, ErrVolumeNotFound) {
+		return fmt.Errorf("deleting image volume: %w", err)
+	}
+	if err := l.store.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	l.logger.Info("Deleted image",
+		logger.String("image", name),
+		logger.String("pool", image.Pool),
+		logger.String("volume", image.Volume))
+
+	return nil
+}
+
+// CreateOverlay implements ImageLibrary.CreateOverlay.
+func (l *LibvirtImageLibrary) CreateOverlay(ctx context.Context, name string, params *OverlayParams) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	image, err := l.store.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := l.checkHost(ctx, image); err != nil {
+		return err
+	}
+	// Overlays break when the data of their backing image changes
+	if !image.ReadOnly {
+		return fmt.Errorf("%w: image %s is writable; make it read-only to create volumes from it",
+			apierrors.ErrInvalidParameter, name)
+	}
+
+	pool := params.Pool
+	if pool == "" {
+		pool = image.Pool
+	}
+
+	if err := l.volumeManager.CreateWithParams(ctx, pool, &CreateVolumeParams{
+		Name:          params.Volume,
+		Format:        "qcow2",
+		BackingStore:  image.Path,
+		BackingFormat: image.Format,
+		CapacityBytes: params.CapacityBytes,
+		Encrypted:     params.Encrypted,
+	}); err != nil {
+		return fmt.Errorf("creating overlay of image %s: %w", name, err)
+	}
+
+	if err := l.store.AddReference(ctx, name, ImageReference{
+		CreatedAt: time.Now().UTC(),
+		VM:        params.VM,
+		Pool:      pool,
+		Volume:    params.Volume,
+	}); err != nil {
+		if deleteErr := l.volumeManager.Delete(ctx, pool, params.Volume); deleteErr != nil {
+			l.logger.Warn("Failed to delete untracked overlay",
+				logger.String("pool", pool),
+				logger.String("volume", params.Volume),
+				logger.Error(deleteErr))
+		}
+		return err
+	}
+
+	l.logger.Info("Created overlay of image",
+		logger.String("image", name),
+		logger.String("pool", pool),
+		logger.String("volume", params.Volume),
+		logger.String("vm", params.VM))
+
+	return nil
+}
+
+// checkHost checks that an image is on the host of the context.
+func (l *LibvirtImageLibrary) checkHost(ctx context.Context, image *Image) error {
+	if l.hostOf(ctx) != image.Host {
+		return fmt.Errorf("%w: image %s is on host %q", apierrors.ErrInvalidParameter, image.Name, image.Host)
+	}
+	return nil
+}
+
+// hostOf returns the name of the host selected by a context.
+func (l *LibvirtImageLibrary) hostOf(ctx context.Context) string {
+	if host, ok := connection.HostFromContext(ctx); ok {
+		return host
+	}
+	return l.config.DefaultHost
+}
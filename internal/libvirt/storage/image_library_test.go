@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeVolume is a volume of a fakeVolumeManager.
+type fakeVolume struct {
+	info *StorageVolumeInfo
+	data []byte
+}
+
+// fakeVolumeManager keeps volumes in memory. Methods the image library does
+// not use panic through the nil embedded interface.
+type fakeVolumeManager struct {
+	VolumeManager
+	volumes map[string]*fakeVolume
+}
+
+func newFakeVolumeManager() *fakeVolumeManager {
+	return &fakeVolumeManager{volumes: make(map[string]*fakeVolume)}
+}
+
+func (m *fakeVolumeManager) CreateFromImage(_ context.Context, poolName string, volName string, imagePath string, format string) error {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = "qcow2"
+	}
+	m.volumes[poolName+"/"+volName] = &fakeVolume{
+		info: &StorageVolumeInfo{Name: volName, Pool: poolName, Path: "/pools/" + poolName + "/" + volName, Format: format, Capacity: 1 << 30},
+		data: data,
+	}
+	return nil
+}
+
+func (m *fakeVolumeManager) CreateWithParams(_ context.Context, poolName string, params *CreateVolumeParams) error {
+	m.volumes[poolName+"/"+params.Name] = &fakeVolume{
+		info: &StorageVolumeInfo{
+			Name:         params.Name,
+			Pool:         poolName,
+			Path:         "/pools/" + poolName + "/" + params.Name,
+			Format:       params.Format,
+			Capacity:     params.CapacityBytes,
+			BackingStore: &BackingStore{Path: params.BackingStore, Format: params.BackingFormat},
+		},
+	}
+	return nil
+}
+
+func (m *fakeVolumeManager) Delete(_ context.Context, poolName string, volName string) error {
+	if _, ok := m.volumes[poolName+"/"+volName]; !ok {
+		return ErrVolumeNotFound
+	}
+	delete(m.volumes, poolName+"/"+volName)
+	return nil
+}
+
+func (m *fakeVolumeManager) GetInfo(_ context.Context, poolName string, volName string) (*StorageVolumeInfo, error) {
+	vol, ok := m.volumes[poolName+"/"+volName]
+	if !ok {
+		return nil, ErrVolumeNotFound
+	}
+	info := *vol.info
+	return &info, nil
+}
+
+func (m *fakeVolumeManager) Download(_ context.Context, poolName string, volName string, writer io.Writer) error {
+	vol, ok := m.volumes[poolName+"/"+volName]
+	if !ok {
+		return ErrVolumeNotFound
+	}
+	_, err := writer.Write(vol.data)
+	return err
+}
+
+// newTestImageLibrary creates an image library on a fake volume manager and
+// writes an image file to register.
+func newTestImageLibrary(t *testing.T) (*LibvirtImageLibrary, *fakeVolumeManager, string) {
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+	sourceDir := t.TempDir()
+	imagePath := filepath.Join(sourceDir, "ubuntu.qcow2")
+	require.NoError(t, os.WriteFile(imagePath, []byte("ubuntu image"), 0o600))
+
+	volumes := newFakeVolumeManager()
+	library := NewImageLibrary(volumes, newTestImageStore(t), ImageLibraryConfig{
+		DefaultPool: "default",
+		DefaultHost: "local",
+		SourceDir:   sourceDir,
+	}, mockLogger)
+	return library, volumes, imagePath
+}
+
+func TestImageLibrary_Register(t *testing.T) {
+	library, volumes, imagePath := newTestImageLibrary(t)
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("ubuntu image"))
+	checksum := hex.EncodeToString(sum[:])
+
+	image, err := library.Register(ctx, &RegisterImageParams{
+		Name:       "ubuntu",
+		SourcePath: imagePath,
+		SHA256:     checksum,
+		OS:         ImageOS{Family: "linux", Distro: "ubuntu"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "default", image.Pool)
+	assert.Equal(t, "ubuntu.qcow2", image.Volume)
+	assert.Equal(t, "/pools/default/ubuntu.qcow2", image.Path)
+	assert.Equal(t, "local", image.Host)
+	assert.Equal(t, checksum, image.SHA256)
+	assert.True(t, image.ReadOnly, "images are read-only by default")
+	assert.Contains(t, volumes.volumes, "default/ubuntu.qcow2")
+
+	// Names and data are registered once
+	_, err = library.Register(ctx, &RegisterImageParams{Name: "ubuntu", SourcePath: imagePath})
+	assert.ErrorIs(t, err, apierrors.ErrAlreadyExists)
+	_, err = library.Register(ctx, &RegisterImageParams{Name: "ubuntu-copy", SourcePath: imagePath})
+	assert.ErrorIs(t, err, apierrors.ErrAlreadyExists)
+	assert.NotContains(t, volumes.volumes, "default/ubuntu-copy.qcow2", "the imported volume is deleted")
+
+	_, err = library.Register(ctx, &RegisterImageParams{Name: "debian", SourcePath: imagePath, SHA256: "0000"})
+	assert.ErrorIs(t, err, apierrors.ErrInvalidParameter)
+	_, err = library.Register(ctx, &RegisterImageParams{Name: "bad/name", SourcePath: imagePath})
+	assert.ErrorIs(t, err, apierrors.ErrInvalidParameter)
+}
+
+func TestImageLibrary_RegisterSourceDir(t *testing.T) {
+	library, volumes, imagePath := newTestImageLibrary(t)
+	ctx := context.Background()
+
+	outside := filepath.Join(t.TempDir(), "shadow")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o600))
+	link := filepath.Join(filepath.Dir(imagePath), "link.qcow2")
+	require.NoError(t, os.Symlink(outside, link))
+
+	for _, source := range []string{
+		outside,
+		link,
+		filepath.Join(filepath.Dir(imagePath), "..", filepath.Base(filepath.Dir(outside)), "shadow"),
+		"ubuntu.qcow2",
+		filepath.Dir(imagePath),
+	} {
+		_, err := library.Register(ctx, &RegisterImageParams{Name: "escape", SourcePath: source})
+		assert.ErrorIs(t, err, apierrors.ErrInvalidParameter, source)
+	}
+	assert.Empty(t, volumes.volumes)
+
+	// Registering by path is disabled without a source directory
+	library.config.SourceDir = ""
+	_, err := library.Register(ctx, &RegisterImageParams{Name: "ubuntu", SourcePath: imagePath})
+	assert.ErrorIs(t, err, apierrors.ErrInvalidParameter)
+}
+
+func TestImageLibrary_Overlays(t *testing.T) {
+	library, volumes, imagePath := newTestImageLibrary(t)
+	ctx := context.Background()
+
+	_, err := library.Register(ctx, &RegisterImageParams{Name: "ubuntu", SourcePath: imagePath})
+	require.NoError(t, err)
+
+	require.NoError(t, library.CreateOverlay(ctx, "ubuntu", &OverlayParams{Volume: "web-disk-0.qcow2", VM: "web", CapacityBytes: 20 << 30}))
+	overlay := volumes.volumes["default/web-disk-0.qcow2"]
+	require.NotNil(t, overlay)
+	assert.Equal(t, "qcow2", overlay.info.Format)
+	assert.Equal(t, &BackingStore{Path: "/pools/default/ubuntu.qcow2", Format: "qcow2"}, overlay.info.BackingStore)
+
+	image, err := library.Get(ctx, "ubuntu")
+	require.NoError(t, err)
+	require.Len(t, image.References, 1)
+	assert.Equal(t, "web", image.References[0].VM)
+
+	// Referenced images can neither be deleted nor made writable
+	assert.ErrorIs(t, library.Delete(ctx, "ubuntu"), apierrors.ErrImageInUse)
+	writable := false
+	_, err = library.Update(ctx, "ubuntu", &UpdateImageParams{ReadOnly: &writable})
+	assert.ErrorIs(t, err, apierrors.ErrImageInUse)
+
+	// Deleting the overlay drops the reference
+	require.NoError(t, volumes.Delete(ctx, "default", "web-disk-0.qcow2"))
+	image, err = library.Get(ctx, "ubuntu")
+	require.NoError(t, err)
+	assert.Empty(t, image.References)
+
+	assert.ErrorIs(t, library.Delete(ctx, "ubuntu"), apierrors.ErrImageReadOnly)
+
+	image, err = library.Update(ctx, "ubuntu", &UpdateImageParams{ReadOnly: &writable})
+	require.NoError(t, err)
+	assert.False(t, image.ReadOnly)
+
+	// Writable images do not back overlays
+	err = library.CreateOverlay(ctx, "ubuntu", &OverlayParams{Volume: "db-disk-0.qcow2", VM: "db"})
+	assert.ErrorIs(t, err, apierrors.ErrInvalidParameter)
+
+	require.NoError(t, library.Delete(ctx, "ubuntu"))
+	assert.NotContains(t, volumes.volumes, "default/ubuntu.qcow2")
+	_, err = library.Get(ctx, "ubuntu")
+	assert.ErrorIs(t, err, apierrors.ErrImageNotFound)
+}
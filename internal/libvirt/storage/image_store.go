@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"gorm.io/gorm"
+)
+
+// ImageStore persists the images of an image library and the overlays
+// referencing them.
+type ImageStore interface {
+	Create(ctx context.Context, image *Image) error
+	Get(ctx context.Context, name string) (*Image, error)
+	List(ctx context.Context) ([]*Image, error)
+	Update(ctx context.Context, image *Image) error
+	Delete(ctx context.Context, name string) error
+	AddReference(ctx context.Context, name string, ref ImageReference) error
+	RemoveReference(ctx context.Context, name string, pool string, volume string) error
+}
+
+// gormImage is the database model of an image. The image is stored as JSON
+// next to the columns it is looked up by.
+type gormImage struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string `gorm:"primaryKey"`
+	SHA256    string `gorm:"index;not null"`
+	Spec      string `gorm:"type:text;not null"`
+}
+
+// TableName specifies the table name for the gormImage model.
+func (gormImage) TableName() string {
+	return "images"
+}
+
+// gormImageReference is the database model of an overlay backed by an image.
+type gormImageReference struct {
+	CreatedAt time.Time
+	Image     string `gorm:"primaryKey"`
+	Pool      string `gorm:"primaryKey"`
+	Volume    string `gorm:"primaryKey"`
+	VM        string
+}
+
+// TableName specifies the table name for the gormImageReference model.
+func (gormImageReference) TableName() string {
+	return "image_references"
+}
+
+// GormImageStore implements ImageStore using GORM.
+type GormImageStore struct {
+	db *gorm.DB
+}
+
+// NewGormImageStore creates a new GormImageStore.
+func NewGormImageStore(db *gorm.DB) (*GormImageStore, error) {
+	// Auto-migrate the schema
+	if err := db.AutoMigrate(&gormImage{}, &gormImageReference{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate image schema: %w", err)
+	}
+
+	return &GormImageStore{db: db}, nil
+}
+
+// Create implements ImageStore.Create.
+func (s *GormImageStore) Create(ctx context.Context, image *Image) error {
+	model, err := toGormImage(image)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create image: %w", err)
+	}
+
+	return nil
+}
+
+// Get implements ImageStore.Get.
+func (s *GormImageStore) Get(ctx context.Context, name string) (*Image, error) {
+	var model gormImage
+	if err := s.db.WithContext(ctx).First(&model, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", apierrors.ErrImageNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to get image: %w", err)
+	}
+
+	image, err := fromGormImage(&model)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []gormImageReference
+	if err := s.db.WithContext(ctx).Where("image = ?", name).Order("created_at").Find(&refs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list image references: %w", err)
+	}
+	for i := range refs {
+		image.References = append(image.References, fromGormImageReference(&refs[i]))
+	}
+
+	return image, nil
+}
+
+// List implements ImageStore.List.
+func (s *GormImageStore) List(ctx context.Context) ([]*Image, error) {
+	var models []gormImage
+	if err := s.db.WithContext(ctx).Order("name").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	var refs []gormImageReference
+	if err := s.db.WithContext(ctx).Order("created_at").Find(&refs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list image references: %w", err)
+	}
+	refsByImage := make(map[string][]ImageReference)
+	for i := range refs {
+		refsByImage[refs[i].Image] = append(refsByImage[refs[i].Image], fromGormImageReference(&refs[i]))
+	}
+
+	images := make([]*Image, 0, len(models))
+	for i := range models {
+		image, err := fromGormImage(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		image.References = refsByImage[image.Name]
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// Update implements ImageStore.Update.
+func (s *GormImageStore) Update(ctx context.Context, image *Image) error {
+	model, err := toGormImage(image)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Model(&gormImage{}).Where("name = ?", image.Name).
+		Updates(map[string]interface{}{
+			"sha256":     model.SHA256,
+			"spec":       model.Spec,
+			"updated_at": model.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update image: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", apierrors.ErrImageNotFound, image.Name)
+	}
+
+	return nil
+}
+
+// Delete implements ImageStore.Delete.
+func (s *GormImageStore) Delete(ctx context.Context, name string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&gormImage{}, "name = ?", name)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete image: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %s", apierrors.ErrImageNotFound, name)
+		}
+
+		if err := tx.Delete(&gormImageReference{}, "image = ?", name).Error; err != nil {
+			return fmt.Errorf("failed to delete image references: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddReference implements ImageStore.AddReference.
+func (s *GormImageStore) AddReference(ctx context.Context, name string, ref ImageReference) error {
+	model := &gormImageReference{
+		CreatedAt: ref.CreatedAt,
+		Image:     name,
+		Pool:      ref.Pool,
+		Volume:    ref.Volume,
+		VM:        ref.VM,
+	}
+
+	if err := s.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to add image reference: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveReference implements ImageStore.RemoveReference.
+func (s *GormImageStore) RemoveReference(ctx context.Context, name string, pool string, volume string) error {
+	result := s.db.WithContext(ctx).Delete(&gormImageReference{}, "image = ? AND pool = ? AND volume = ?", name, pool, volume)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove image reference: %w", result.Error)
+	}
+
+	return nil
+}
+
+// toGormImage converts an image to its database model. References are
+// stored in their own table.
+func toGormImage(image *Image) (*gormImage, error) {
+	stored := *image
+	stored.References = nil
+
+	spec, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("encoding image: %w", err)
+	}
+
+	return &gormImage{
+		Name:      image.Name,
+		SHA256:    image.SHA256,
+		Spec:      string(spec),
+		CreatedAt: image.CreatedAt,
+		UpdatedAt: image.UpdatedAt,
+	}, nil
+}
+
+// fromGormImage converts a database model to an image.
+func fromGormImage(model *gormImage) (*Image, error) {
+	var image Image
+	if err := json.Unmarshal([]byte(model.Spec), &image); err != nil {
+		return nil, fmt.Errorf("decoding image %s: %w", model.Name, err)
+	}
+
+	return &image, nil
+}
+
+// fromGormImageReference converts a database model to an image reference.
+func fromGormImageReference(model *gormImageReference) ImageReference {
+	return ImageReference{
+		CreatedAt: model.CreatedAt,
+		VM:        model.VM,
+		Pool:      model.Pool,
+		Volume:    model.Volume,
+	}
+}
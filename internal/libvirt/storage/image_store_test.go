@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	apierrors "github.com/threatflux/libgo/internal/errors"
+)
+
+// newTestImageStore creates an image store backed by an in-memory database.
+func newTestImageStore(t *testing.T) *GormImageStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+
+	store, err := NewGormImageStore(db)
+	require.NoError(t, err)
+	return store
+}
+
+func TestGormImageStore(t *testing.T) {
+	store := newTestImageStore(t)
+	ctx := context.Background()
+
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	image := &Image{
+		Name:      "ubuntu-24.04",
+		OS:        ImageOS{Family: "linux", Distro: "ubuntu", Version: "24.04", Architecture: "x86_64"},
+		Pool:      "default",
+		Volume:    "ubuntu-24.04.qcow2",
+		Path:      "/var/lib/libvirt/images/ubuntu-24.04.qcow2",
+		Format:    "qcow2",
+		SHA256:    "abc",
+		Capacity:  10 << 30,
+		ReadOnly:  true,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	require.NoError(t, store.Create(ctx, image))
+
+	got, err := store.Get(ctx, "ubuntu-24.04")
+	require.NoError(t, err)
+	assert.Equal(t, image, got)
+
+	ref := ImageReference{CreatedAt: created, VM: "web", Pool: "default", Volume: "web-disk-0.qcow2"}
+	require.NoError(t, store.AddReference(ctx, "ubuntu-24.04", ref))
+	require.Error(t, store.AddReference(ctx, "ubuntu-24.04", ref), "references are unique")
+
+	image.Description = "Ubuntu cloud image"
+	require.NoError(t, store.Update(ctx, image))
+
+	images, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "Ubuntu cloud image", images[0].Description)
+	assert.Equal(t, []ImageReference{ref}, images[0].References)
+
+	require.NoError(t, store.RemoveReference(ctx, "ubuntu-24.04", "default", "web-disk-0.qcow2"))
+	got, err = store.Get(ctx, "ubuntu-24.04")
+	require.NoError(t, err)
+	assert.Empty(t, got.References)
+
+	require.NoError(t, store.Delete(ctx, "ubuntu-24.04"))
+	_, err = store.Get(ctx, "ubuntu-24.04")
+	assert.ErrorIs(t, err, apierrors.ErrImageNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "ubuntu-24.04"), apierrors.ErrImageNotFound)
+	assert.ErrorIs(t, store.Update(ctx, image), apierrors.ErrImageNotFound)
+}
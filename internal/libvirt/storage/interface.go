@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/digitalocean/go-libvirt"
 )
@@ -44,6 +45,9 @@ type PoolManager interface {
 
 	// GetXML gets the XML configuration of a storage pool.
 	GetXML(ctx context.Context, name string) (string, error)
+
+	// GetUsage gets the fill level and overcommit ratio of a storage pool.
+	GetUsage(ctx context.Context, name string) (*PoolUsage, error)
 }
 
 // VolumeManager defines interface for managing storage volumes.
@@ -51,6 +55,10 @@ type VolumeManager interface {
 	// Create creates a new storage volume.
 	Create(ctx context.Context, poolName string, volName string, capacityBytes uint64, format string) error
 
+	// CreateWithParams creates a new storage volume, as a qcow2 overlay of
+	// params.BackingStore when it is set.
+	CreateWithParams(ctx context.Context, poolName string, params *CreateVolumeParams) error
+
 	// CreateFromImage creates a volume from an existing image.
 	CreateFromImage(ctx context.Context, poolName string, volName string, imagePath string, format string) error
 
@@ -66,6 +74,12 @@ type VolumeManager interface {
 	// Clone clones a storage volume.
 	Clone(ctx context.Context, poolName string, sourceVolName string, destVolName string) error
 
+	// CloneTo clones a storage volume into a pool, converting it when format is set.
+	CloneTo(ctx context.Context, poolName string, sourceVolName string, destPoolName string, destVolName string, format string) error
+
+	// CloneLinked creates a qcow2 volume backed by an existing volume.
+	CloneLinked(ctx context.Context, poolName string, sourceVolName string, destVolName string) error
+
 	// List lists all volumes in a storage pool.
 	List(ctx context.Context, poolName string) ([]*StorageVolumeInfo, error)
 
@@ -75,6 +89,14 @@ type VolumeManager interface {
 	// GetXML gets the XML configuration of a storage volume.
 	GetXML(ctx context.Context, poolName string, volName string) (string, error)
 
+	// GetSource describes how a VM disk attaches a storage volume, which
+	// depends on the type of its pool.
+	GetSource(ctx context.Context, poolName string, volName string) (*VolumeSource, error)
+
+	// LookupByPath finds the pool and name of the volume at a path, such as
+	// the source of a VM disk.
+	LookupByPath(ctx context.Context, path string) (poolName string, volName string, err error)
+
 	// Wipe wipes/zeros a storage volume.
 	Wipe(ctx context.Context, poolName string, volName string) error
 
@@ -83,6 +105,10 @@ type VolumeManager interface {
 
 	// Download downloads data from a storage volume.
 	Download(ctx context.Context, poolName string, volName string, writer io.Writer) error
+
+	// DownloadRange downloads length bytes from offset of a storage volume; a
+	// length of zero reads to the end.
+	DownloadRange(ctx context.Context, poolName string, volName string, offset uint64, length uint64, writer io.Writer) error
 }
 
 // XMLBuilder defines interface for building storage XML.
@@ -90,8 +116,18 @@ type XMLBuilder interface {
 	// BuildStoragePoolXML builds XML for storage pool creation.
 	BuildStoragePoolXML(name string, path string) (string, error)
 
+	// BuildStoragePoolXMLWithParams builds XML for a storage pool of any type.
+	BuildStoragePoolXMLWithParams(params *CreatePoolParams) (string, error)
+
 	// BuildStorageVolumeXML builds XML for storage volume creation.
 	BuildStorageVolumeXML(volName string, capacityBytes uint64, format string) (string, error)
+
+	// BuildOverlayVolumeXML builds XML for a qcow2 volume backed by another image.
+	BuildOverlayVolumeXML(volName string, capacityBytes uint64, backingPath string, backingFormat string) (string, error)
+
+	// BuildEncryptedVolumeXML builds XML for a LUKS encrypted volume whose
+	// passphrase is held by a libvirt secret.
+	BuildEncryptedVolumeXML(params *CreateVolumeParams, secretUUID string) (string, error)
 }
 
 // StoragePoolInfo represents detailed information about a storage pool.
@@ -123,12 +159,43 @@ const (
 )
 
 // StoragePoolSource represents the source configuration of a storage pool.
+// Which fields apply depends on the pool type.
 type StoragePoolSource struct {
-	Host   string `json:"host,omitempty"`
-	Dir    string `json:"dir,omitempty"`
+	// Auth holds the credentials of iSCSI (chap) and RBD (ceph) pools
+	Auth *StoragePoolAuth `json:"auth,omitempty"`
+	// Hosts are the servers of the pool, such as the Ceph monitors of an
+	// RBD pool; Host and Port are shorthand for a single server
+	Hosts []StoragePoolHost `json:"hosts,omitempty"`
+	Host  string            `json:"host,omitempty"`
+	// Dir is the export path of a netfs pool
+	Dir string `json:"dir,omitempty"`
+	// Device is a physical volume of a logical pool or the disk of a disk pool
 	Device string `json:"device,omitempty"`
+	// IQN is the target of an iSCSI pool
+	IQN string `json:"iqn,omitempty"`
+	// Initiator is the initiator IQN an iSCSI pool logs in with
+	Initiator string `json:"initiator,omitempty"`
+	// Name is the volume group of a logical pool or the Ceph pool of an
+	// RBD pool
 	Name   string `json:"name,omitempty"`
 	Format string `json:"format,omitempty"`
+	Port   int    `json:"port,omitempty"`
+}
+
+// StoragePoolHost represents a server of a storage pool.
+type StoragePoolHost struct {
+	Name string `json:"name"`
+	Port int    `json:"port,omitempty"`
+}
+
+// StoragePoolAuth represents the credentials of a storage pool. The secret
+// is a libvirt secret referenced by UUID or usage.
+type StoragePoolAuth struct {
+	// Type is "ceph" for RBD pools and "chap" for iSCSI pools
+	Type        string `json:"type"`
+	Username    string `json:"username"`
+	SecretUUID  string `json:"secret_uuid,omitempty"`
+	SecretUsage string `json:"secret_usage,omitempty"`
 }
 
 // StoragePoolTarget represents the target configuration of a storage pool.
@@ -163,6 +230,45 @@ type StorageVolumeInfo struct {
 	Pool         string                 `json:"pool"`
 	Capacity     uint64                 `json:"capacity"`
 	Allocation   uint64                 `json:"allocation"`
+	// Physical is the size of the volume data, such as the size of a qcow2 file
+	Physical uint64 `json:"physical,omitempty"`
+	// Encryption is set for LUKS encrypted volumes
+	Encryption *VolumeEncryption `json:"encryption,omitempty"`
+	// Labels, Owner and Purpose come from the volume record; volumes
+	// created outside the API have none
+	Labels  map[string]string `json:"labels,omitempty"`
+	Owner   string            `json:"owner,omitempty"`
+	Purpose string            `json:"purpose,omitempty"`
+	// VM is the VM the volume was created as a disk of
+	VM string `json:"vm,omitempty"`
+	// Job is the ID of the job that created the volume
+	Job string `json:"job,omitempty"`
+}
+
+// VolumeEncryption describes the encryption of a volume.
+type VolumeEncryption struct {
+	// Format is the encryption format, "luks"
+	Format string `json:"format"`
+	// SecretUUID is the libvirt secret holding the passphrase
+	SecretUUID string `json:"secret_uuid"`
+}
+
+// VolumeSource describes how a VM disk reaches the data of a volume.
+type VolumeSource struct {
+	// Auth holds the credentials of network volumes
+	Auth  *StoragePoolAuth  `json:"auth,omitempty"`
+	Hosts []StoragePoolHost `json:"hosts,omitempty"`
+	// Type is the disk type: "file", "block" or "network"
+	Type string `json:"type"`
+	// Path is the file or block device of file and block volumes
+	Path string `json:"path,omitempty"`
+	// Protocol and Name address network volumes, such as rbd and pool/image
+	Protocol string `json:"protocol,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// Encryption is set for LUKS encrypted volumes
+	Encryption *VolumeEncryption `json:"encryption,omitempty"`
+	// Thin is set for volumes whose pool allocates space as it is written
+	Thin bool `json:"thin,omitempty"`
 }
 
 // BackingStore represents backing store information for a volume.
@@ -177,7 +283,14 @@ type CreateVolumeParams struct {
 	Name          string                 `json:"name" binding:"required"`
 	Format        string                 `json:"format"`
 	BackingStore  string                 `json:"backing_store,omitempty"`
+	BackingFormat string                 `json:"backing_format,omitempty"`
 	CapacityBytes uint64                 `json:"capacity_bytes" binding:"required"`
+	// Encrypted creates a LUKS encrypted qcow2 or raw volume with its own
+	// passphrase
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Labels and Purpose are stored in the volume record
+	Labels  map[string]string `json:"labels,omitempty"`
+	Purpose string            `json:"purpose,omitempty"`
 }
 
 // UploadVolumeParams represents parameters for uploading to a storage volume.
@@ -187,3 +300,205 @@ type UploadVolumeParams struct {
 	// Length of data to write (0 for entire stream).
 	Length uint64 `json:"length"`
 }
+
+// ImageLibrary manages golden images: base volumes that VM disks are created
+// from as thin qcow2 overlays instead of full copies.
+type ImageLibrary interface {
+	// Register registers a base image. Image files are imported into a pool
+	// first; existing volumes are registered in place.
+	Register(ctx context.Context, params *RegisterImageParams) (*Image, error)
+
+	// Get gets an image and the overlays referencing it.
+	Get(ctx context.Context, name string) (*Image, error)
+
+	// List lists all images.
+	List(ctx context.Context) ([]*Image, error)
+
+	// Update changes the description, OS metadata or read-only flag of an image.
+	Update(ctx context.Context, name string, params *UpdateImageParams) (*Image, error)
+
+	// Delete deletes an image and its volume. Read-only images and images
+	// referenced by overlays are not deleted.
+	Delete(ctx context.Context, name string) error
+
+	// CreateOverlay creates a qcow2 volume backed by an image and records the
+	// VM using it.
+	CreateOverlay(ctx context.Context, name string, params *OverlayParams) error
+}
+
+// ImageOS describes the operating system installed in an image.
+type ImageOS struct {
+	Family       string `json:"family,omitempty"`
+	Distro       string `json:"distro,omitempty"`
+	Version      string `json:"version,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// Image is a registered base image.
+type Image struct {
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	OS          ImageOS   `json:"os"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Host        string    `json:"host,omitempty"`
+	Pool        string    `json:"pool"`
+	Volume      string    `json:"volume"`
+	Path        string    `json:"path"`
+	Format      string    `json:"format"`
+	// SHA256 is the hex encoded digest of the volume data
+	SHA256 string `json:"sha256"`
+	// References are the overlays backed by the image
+	References []ImageReference `json:"references"`
+	Capacity   uint64           `json:"capacity"`
+	// ReadOnly images cannot be deleted; only read-only images back overlays
+	ReadOnly bool `json:"read_only"`
+}
+
+// ImageReference is an overlay volume backed by an image.
+type ImageReference struct {
+	CreatedAt time.Time `json:"created_at"`
+	VM        string    `json:"vm,omitempty"`
+	Pool      string    `json:"pool"`
+	Volume    string    `json:"volume"`
+}
+
+// RegisterImageParams represents parameters for registering an image.
+type RegisterImageParams struct {
+	// ReadOnly defaults to true
+	ReadOnly    *bool   `json:"read_only,omitempty"`
+	OS          ImageOS `json:"os"`
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description,omitempty"`
+	// SourcePath is an image file on the server that is imported into Pool
+	SourcePath string `json:"source_path,omitempty"`
+	// Pool holds the image volume; defaults to the default pool
+	Pool string `json:"pool,omitempty"`
+	// Volume is the volume to register, or the name of the volume an image
+	// file is imported into
+	Volume string `json:"volume,omitempty"`
+	// Format of the volume an image file is imported into; defaults to the
+	// format of the file
+	Format string `json:"format,omitempty"`
+	// SHA256 is the expected digest of the image file or volume
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// UpdateImageParams represents changes to an image. Nil fields are unchanged.
+type UpdateImageParams struct {
+	Description *string  `json:"description,omitempty"`
+	OS          *ImageOS `json:"os,omitempty"`
+	ReadOnly    *bool    `json:"read_only,omitempty"`
+}
+
+// OverlayParams represents parameters for creating an overlay of an image.
+type OverlayParams struct {
+	Pool   string `json:"pool"`
+	Volume string `json:"volume"`
+	// VM is the VM the overlay is a disk of
+	VM string `json:"vm,omitempty"`
+	// CapacityBytes is raised to the capacity of the image when smaller
+	CapacityBytes uint64 `json:"capacity_bytes"`
+	// Encrypted encrypts the data the overlay holds; the image itself stays
+	// unencrypted
+	Encrypted bool `json:"encrypted,omitempty"`
+}
+
+// PoolUsage describes how full a storage pool is at one point in time.
+type PoolUsage struct {
+	Timestamp time.Time `json:"timestamp"`
+	Host      string    `json:"host,omitempty"`
+	Pool      string    `json:"pool"`
+	// Capacity, Allocation and Available are the physical sizes libvirt
+	// reports for the pool
+	Capacity   uint64 `json:"capacity"`
+	Allocation uint64 `json:"allocation"`
+	Available  uint64 `json:"available"`
+	// VirtualSize is the sum of the capacities of all volumes, which thin
+	// provisioned qcow2 volumes only allocate as they are written
+	VirtualSize uint64 `json:"virtual_size"`
+	// UsagePercent is the allocation as a percentage of the capacity
+	UsagePercent float64 `json:"usage_percent"`
+	// OvercommitRatio is the virtual size divided by the capacity
+	OvercommitRatio float64 `json:"overcommit_ratio"`
+	Volumes         int     `json:"volumes"`
+}
+
+// CapacityMonitor samples the usage of the storage pools of every host and
+// raises events when a pool crosses the alert threshold.
+type CapacityMonitor interface {
+	// Collect samples every pool once
+	Collect(ctx context.Context)
+
+	// Sample samples a pool of the host selected by the context now
+	Sample(ctx context.Context, pool string) (*PoolUsage, error)
+
+	// Latest returns the last sample of every pool
+	Latest() []*PoolUsage
+
+	// History returns the samples of a pool, oldest first
+	History(host string, pool string) []*PoolUsage
+
+	// Events returns threshold events, newest first; an empty pool returns
+	// the events of all pools of all hosts
+	Events(host string, pool string) []*PoolCapacityEvent
+
+	// Start samples the pools every interval until the context is canceled
+	Start(ctx context.Context, interval time.Duration)
+}
+
+// Pool capacity event types.
+const (
+	PoolEventThresholdExceeded = "threshold_exceeded"
+	PoolEventThresholdCleared  = "threshold_cleared"
+)
+
+// PoolCapacityEvent records a pool crossing the alert threshold.
+type PoolCapacityEvent struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Host         string    `json:"host,omitempty"`
+	Pool         string    `json:"pool"`
+	Type         string    `json:"type"`
+	Message      string    `json:"message"`
+	UsagePercent float64   `json:"usage_percent"`
+	Threshold    float64   `json:"threshold"`
+}
+
+// VolumeKey records the libvirt secret holding the LUKS passphrase of an
+// encrypted volume.
+type VolumeKey struct {
+	CreatedAt time.Time `json:"created_at"`
+	// RotatedAt is when the passphrase was last replaced
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	Host       string     `json:"host,omitempty"`
+	Pool       string     `json:"pool"`
+	Volume     string     `json:"volume"`
+	SecretUUID string     `json:"secret_uuid"`
+}
+
+// VolumeKeyManager manages the LUKS passphrases of encrypted volumes. Every
+// volume has its own passphrase, held by a libvirt secret on the host of the
+// volume and stored encrypted under the master key. Volumes are those of the
+// host selected by the context.
+type VolumeKeyManager interface {
+	// Create generates the passphrase of a new volume and defines its secret
+	Create(ctx context.Context, pool string, volume string) (*VolumeKey, error)
+
+	// Get gets the key of a volume
+	Get(ctx context.Context, pool string, volume string) (*VolumeKey, error)
+
+	// Passphrase decrypts the passphrase of a volume
+	Passphrase(ctx context.Context, pool string, volume string) ([]byte, error)
+
+	// SetPassphrase replaces the passphrase of a volume in its secret and in
+	// the database
+	SetPassphrase(ctx context.Context, pool string, volume string, passphrase []byte) (*VolumeKey, error)
+
+	// Delete undefines the secret of a volume and erases its passphrase,
+	// which leaves the data of the volume unreadable
+	Delete(ctx context.Context, pool string, volume string) error
+
+	// Rewrap encrypts the passphrases stored under previous master keys
+	// under the current master key and returns how many it encrypted
+	Rewrap(ctx context.Context) (int, error)
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrVolumeKeyNotFound is returned for volumes without a stored key.
+var ErrVolumeKeyNotFound = fmt.Errorf("volume key not found")
+
+// StoredVolumeKey is a volume key with its passphrase sealed under a master
+// key.
+type StoredVolumeKey struct {
+	VolumeKey
+	// MasterKeyID identifies the master key the passphrase is sealed under
+	MasterKeyID string
+	// SealedPassphrase is the nonce followed by the AES-GCM ciphertext
+	SealedPassphrase []byte
+}
+
+// KeyStore persists the sealed passphrases of encrypted volumes.
+type KeyStore interface {
+	// Put creates or replaces the key of a volume
+	Put(ctx context.Context, key *StoredVolumeKey) error
+	Get(ctx context.Context, host string, pool string, volume string) (*StoredVolumeKey, error)
+	List(ctx context.Context) ([]*StoredVolumeKey, error)
+	Delete(ctx context.Context, host string, pool string, volume string) error
+}
+
+// gormVolumeKey is the database model of a volume key.
+type gormVolumeKey struct {
+	CreatedAt        time.Time
+	RotatedAt        *time.Time
+	Host             string `gorm:"primaryKey"`
+	Pool             string `gorm:"primaryKey"`
+	Volume           string `gorm:"primaryKey"`
+	SecretUUID       string `gorm:"not null"`
+	MasterKeyID      string `gorm:"index;not null"`
+	SealedPassphrase []byte `gorm:"not null"`
+}
+
+// TableName specifies the table name for the gormVolumeKey model.
+func (gormVolumeKey) TableName() string {
+	return "volume_keys"
+}
+
+// GormKeyStore implements KeyStore using GORM.
+type GormKeyStore struct {
+	db *gorm.DB
+}
+
+// NewGormKeyStore creates a new GormKeyStore.
+func NewGormKeyStore(db *gorm.DB) (*GormKeyStore, error) {
+	// Auto-migrate the schema
+	if err := db.AutoMigrate(&gormVolumeKey{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate volume key schema: %w", err)
+	}
+
+	return &GormKeyStore{db: db}, nil
+}
+
+// Put implements KeyStore.Put.
+func (s *GormKeyStore) Put(ctx context.Context, key *StoredVolumeKey) error {
+	model := &gormVolumeKey{
+		CreatedAt:        key.CreatedAt,
+		RotatedAt:        key.RotatedAt,
+		Host:             key.Host,
+		Pool:             key.Pool,
+		Volume:           key.Volume,
+		SecretUUID:       key.SecretUUID,
+		MasterKeyID:      key.MasterKeyID,
+		SealedPassphrase: key.SealedPassphrase,
+	}
+
+	if err := s.db.WithContext(ctx).Save(model).Error; err != nil {
+		return fmt.Errorf("failed to store volume key: %w", err)
+	}
+
+	return nil
+}
+
+// Get implements KeyStore.Get.
+func (s *GormKeyStore) Get(ctx context.Context, host string, pool string, volume string) (*StoredVolumeKey, error) {
+	var model gormVolumeKey
+	err := s.db.WithContext(ctx).
+		First(&model, "host = ? AND pool = ? AND volume = ?", host, pool, volume).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("volume %s in pool %s: %w", volume, pool, ErrVolumeKeyNotFound)
+		}
+		return nil, fmt.Errorf("failed to get volume key: %w", err)
+	}
+
+	return fromGormVolumeKey(&model), nil
+}
+
+// List implements KeyStore.List.
+func (s *GormKeyStore) List(ctx context.Context) ([]*StoredVolumeKey, error) {
+	var models []gormVolumeKey
+	if err := s.db.WithContext(ctx).Order("host, pool, volume").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list volume keys: %w", err)
+	}
+
+	keys := make([]*StoredVolumeKey, 0, len(models))
+	for i := range models {
+		keys = append(keys, fromGormVolumeKey(&models[i]))
+	}
+
+	return keys, nil
+}
+
+// Delete implements KeyStore.Delete.
+func (s *GormKeyStore) Delete(ctx context.Context, host string, pool string, volume string) error {
+	result := s.db.WithContext(ctx).
+		Delete(&gormVolumeKey{}, "host = ? AND pool = ? AND volume = ?", host, pool, volume)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete volume key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("volume %s in pool %s: %w", volume, pool, ErrVolumeKeyNotFound)
+	}
+
+	return nil
+}
+
+// fromGormVolumeKey converts a database model to a stored volume key.
+func fromGormVolumeKey(model *gormVolumeKey) *StoredVolumeKey {
+	return &StoredVolumeKey{
+		VolumeKey: VolumeKey{
+			CreatedAt:  model.CreatedAt,
+			RotatedAt:  model.RotatedAt,
+			Host:       model.Host,
+			Pool:       model.Pool,
+			Volume:     model.Volume,
+			SecretUUID: model.SecretUUID,
+		},
+		MasterKeyID:      model.MasterKeyID,
+		SealedPassphrase: model.SealedPassphrase,
+	}
+}
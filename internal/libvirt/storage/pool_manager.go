@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/digitalocean/go-libvirt"
 	"github.com/threatflux/libgo/internal/libvirt/connection"
@@ -307,12 +306,7 @@ func (m *LibvirtPoolManager) validateCreateParams(params *CreatePoolParams) erro
 		params.Type = dirPoolType
 	}
 
-	// For directory type pools, ensure we have a path
-	if params.Type == dirPoolType && params.Path == "" {
-		return fmt.Errorf("path is required for directory type pools")
-	}
-
-	return nil
+	return validatePoolParams(params)
 }
 
 // preparePoolEnvironment prepares the environment for pool creation.
@@ -329,7 +323,7 @@ func (m *LibvirtPoolManager) preparePoolEnvironment(params *CreatePoolParams) er
 // createAndStartPool creates, builds, and starts a storage pool.
 func (m *LibvirtPoolManager) createAndStartPool(libvirtConn *libvirt.Libvirt, params *CreatePoolParams) (libvirt.StoragePool, error) {
 	// Generate pool XML
-	poolXML, err := m.xmlBuilder.BuildStoragePoolXML(params.Name, params.Path)
+	poolXML, err := m.xmlBuilder.BuildStoragePoolXMLWithParams(params)
 	if err != nil {
 		return libvirt.StoragePool{}, fmt.Errorf("failed to build storage pool XML: %w", err)
 	}
@@ -350,8 +344,10 @@ func (m *LibvirtPoolManager) createAndStartPool(libvirtConn *libvirt.Libvirt, pa
 
 // buildAndStartPool builds and starts a defined storage pool.
 func (m *LibvirtPoolManager) buildAndStartPool(libvirtConn *libvirt.Libvirt, pool libvirt.StoragePool, params *CreatePoolParams) error {
-	// Build the pool (for certain types)
-	if params.Type == "logical" || params.Type == "disk" {
+	// Build the pool (for certain types): this creates the volume group of
+	// a logical pool, labels the disk of a disk pool and creates the mount
+	// point of file system pools on the host
+	if m.needsBuild(params) {
 		if err := libvirtConn.StoragePoolBuild(pool, 0); err != nil {
 			m.logger.Warn("Failed to build storage pool",
 				logger.String("name", params.Name),
@@ -380,6 +376,19 @@ func (m *LibvirtPoolManager) buildAndStartPool(libvirtConn *libvirt.Libvirt, poo
 	return nil
 }
 
+// needsBuild reports whether a new pool is built before it is started.
+func (m *LibvirtPoolManager) needsBuild(params *CreatePoolParams) bool {
+	switch params.Type {
+	case logicalPoolType:
+		// Without devices the volume group exists already
+		return params.Source != nil && params.Source.Device != ""
+	case diskPoolType, fsPoolType, netfsPoolType:
+		return true
+	default:
+		return false
+	}
+}
+
 // Start implements PoolManager.Start.
 func (m *LibvirtPoolManager) Start(ctx context.Context, name string) error {
 	// Get libvirt connection
@@ -587,6 +596,30 @@ func (m *LibvirtPoolManager) GetXML(ctx context.Context, name string) (string, e
 	return xml, nil
 }
 
+// GetUsage implements PoolManager.GetUsage.
+func (m *LibvirtPoolManager) GetUsage(ctx context.Context, name string) (*PoolUsage, error) {
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer func() {
+		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
+			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+		}
+	}()
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	// Get the pool
+	pool, err := libvirtConn.StoragePoolLookupByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up pool %s: %w", name, ErrPoolNotFound)
+	}
+
+	return readPoolUsage(libvirtConn, pool)
+}
+
 // getPoolInfo is a helper method to get pool information.
 func (m *LibvirtPoolManager) getPoolInfo(libvirtConn *libvirt.Libvirt, pool *libvirt.StoragePool) (*StoragePoolInfo, error) {
 	// Get pool info
@@ -607,12 +640,9 @@ func (m *LibvirtPoolManager) getPoolInfo(libvirtConn *libvirt.Libvirt, pool *lib
 		return nil, fmt.Errorf("failed to get pool XML: %w", err)
 	}
 
-	// NOTE: Parse XML to get pool type and path.
-	// For now, we'll use basic info
 	poolInfo := &StoragePoolInfo{
 		UUID:       fmt.Sprintf("%x", pool.UUID),
 		Name:       pool.Name,
-		Type:       "dir", // Default, should be parsed from XML
 		State:      mapPoolState(libvirt.StoragePoolState(state)),
 		Autostart:  autostart == 1,
 		Persistent: true, // Assume persistent for now
@@ -621,13 +651,8 @@ func (m *LibvirtPoolManager) getPoolInfo(libvirtConn *libvirt.Libvirt, pool *lib
 		Available:  available,
 	}
 
-	// Extract basic path from XML (simple regex for now)
-	// NOTE: Proper XML parsing.
-	if pathStart := strings.Index(xml, "<path>"); pathStart != -1 {
-		pathEnd := strings.Index(xml[pathStart:], "</path>")
-		if pathEnd != -1 {
-			poolInfo.Path = xml[pathStart+6 : pathStart+pathEnd]
-		}
+	if err := parseStoragePoolXML(xml, poolInfo); err != nil {
+		return nil, err
 	}
 
 	return poolInfo, nil
@@ -13,18 +13,33 @@ import (
 
 // MockXMLBuilder is a mock for storage XML builder
 type MockXMLBuilder struct {
-	BuildStoragePoolXMLFn   func(name string, path string) (string, error)
-	BuildStorageVolumeXMLFn func(volName string, capacityBytes uint64, format string) (string, error)
+	BuildStoragePoolXMLFn           func(name string, path string) (string, error)
+	BuildStoragePoolXMLWithParamsFn func(params *CreatePoolParams) (string, error)
+	BuildStorageVolumeXMLFn         func(volName string, capacityBytes uint64, format string) (string, error)
+	BuildOverlayVolumeXMLFn         func(volName string, capacityBytes uint64, backingPath string, backingFormat string) (string, error)
+	BuildEncryptedVolumeXMLFn       func(params *CreateVolumeParams, secretUUID string) (string, error)
 }
 
 func (m *MockXMLBuilder) BuildStoragePoolXML(name string, path string) (string, error) {
 	return m.BuildStoragePoolXMLFn(name, path)
 }
 
+func (m *MockXMLBuilder) BuildStoragePoolXMLWithParams(params *CreatePoolParams) (string, error) {
+	return m.BuildStoragePoolXMLWithParamsFn(params)
+}
+
 func (m *MockXMLBuilder) BuildStorageVolumeXML(volName string, capacityBytes uint64, format string) (string, error) {
 	return m.BuildStorageVolumeXMLFn(volName, capacityBytes, format)
 }
 
+func (m *MockXMLBuilder) BuildOverlayVolumeXML(volName string, capacityBytes uint64, backingPath string, backingFormat string) (string, error) {
+	return m.BuildOverlayVolumeXMLFn(volName, capacityBytes, backingPath, backingFormat)
+}
+
+func (m *MockXMLBuilder) BuildEncryptedVolumeXML(params *CreateVolumeParams, secretUUID string) (string, error) {
+	return m.BuildEncryptedVolumeXMLFn(params, secretUUID)
+}
+
 // MockLibvirtWithPools is a mock of libvirt with storage pool operations
 type MockLibvirtWithPools struct {
 	libvirt.Libvirt
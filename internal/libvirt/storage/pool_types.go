@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/beevik/etree"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+// Storage pool types beyond directory pools.
+const (
+	fsPoolType      = "fs"
+	netfsPoolType   = "netfs"
+	logicalPoolType = "logical"
+	diskPoolType    = "disk"
+	iscsiPoolType   = "iscsi"
+	rbdPoolType     = "rbd"
+)
+
+// Disk types of the volumes of a pool.
+const (
+	fileDiskType    = "file"
+	blockDiskType   = "block"
+	networkDiskType = "network"
+)
+
+// iscsiTargetPath is where iSCSI pools expose their LUNs by default; these
+// names stay stable across reboots.
+const iscsiTargetPath = "/dev/disk/by-path"
+
+// poolDiskType returns the disk type VM disks use for volumes of a pool type.
+func poolDiskType(poolType string) string {
+	switch poolType {
+	case logicalPoolType, diskPoolType, iscsiPoolType:
+		return blockDiskType
+	case rbdPoolType:
+		return networkDiskType
+	default:
+		return fileDiskType
+	}
+}
+
+// rawOnlyPool reports whether a pool type only holds raw volumes, as block
+// devices and RBD images have no room for a qcow2 header of their own.
+func rawOnlyPool(poolType string) bool {
+	return poolDiskType(poolType) != fileDiskType
+}
+
+// thinPool reports whether a pool type allocates the space of its volumes
+// as it is written, so space the guest discards can be given back. Files
+// are sparse and RBD images thin; logical volumes, partitions and LUNs are
+// allocated up front.
+func thinPool(poolType string) bool {
+	return poolType == rbdPoolType || poolDiskType(poolType) == fileDiskType
+}
+
+// validatePoolParams checks that the source definition of a new pool has
+// what its type needs and fills in defaults.
+func validatePoolParams(params *CreatePoolParams) error {
+	source := params.Source
+	if source == nil {
+		source = &StoragePoolSource{}
+	}
+
+	switch params.Type {
+	case dirPoolType:
+		if params.Path == "" {
+			return fmt.Errorf("%w: path is required for directory type pools", apierrors.ErrInvalidParameter)
+		}
+		return nil
+	case fsPoolType:
+		if source.Device == "" || params.Path == "" {
+			return fmt.Errorf("%w: fs pools need a source device and a path", apierrors.ErrInvalidParameter)
+		}
+	case netfsPoolType:
+		if len(poolHosts(source)) == 0 || source.Dir == "" || params.Path == "" {
+			return fmt.Errorf("%w: netfs pools need a source host, a source dir and a path to mount it at", apierrors.ErrInvalidParameter)
+		}
+		if source.Format == "" {
+			source.Format = "nfs"
+		}
+	case logicalPoolType:
+		if source.Name == "" {
+			return fmt.Errorf("%w: logical pools need the volume group as source name", apierrors.ErrInvalidParameter)
+		}
+		if source.Format == "" {
+			source.Format = "lvm2"
+		}
+	case diskPoolType:
+		if source.Device == "" {
+			return fmt.Errorf("%w: disk pools need a source device", apierrors.ErrInvalidParameter)
+		}
+	case iscsiPoolType:
+		if len(poolHosts(source)) == 0 || source.IQN == "" {
+			return fmt.Errorf("%w: iscsi pools need a source host and target iqn", apierrors.ErrInvalidParameter)
+		}
+		if params.Path == "" {
+			params.Path = iscsiTargetPath
+		}
+	case rbdPoolType:
+		if len(poolHosts(source)) == 0 || source.Name == "" {
+			return fmt.Errorf("%w: rbd pools need the Ceph monitors as source hosts and the Ceph pool as source name", apierrors.ErrInvalidParameter)
+		}
+		if params.Path != "" {
+			return fmt.Errorf("%w: rbd pools have no path", apierrors.ErrInvalidParameter)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported pool type %q", apierrors.ErrInvalidParameter, params.Type)
+	}
+
+	if auth := source.Auth; auth != nil {
+		if auth.Username == "" || (auth.SecretUUID == "" && auth.SecretUsage == "") {
+			return fmt.Errorf("%w: pool auth needs a username and a secret uuid or usage", apierrors.ErrInvalidParameter)
+		}
+		if auth.Type == "" {
+			auth.Type = "chap"
+			if params.Type == rbdPoolType {
+				auth.Type = "ceph"
+			}
+		}
+	}
+
+	params.Source = source
+	return nil
+}
+
+// poolHosts returns the hosts of a pool source, including the Host and Port
+// shorthand.
+func poolHosts(source *StoragePoolSource) []StoragePoolHost {
+	if source == nil {
+		return nil
+	}
+
+	hosts := make([]StoragePoolHost, 0, len(source.Hosts)+1)
+	if source.Host != "" {
+		hosts = append(hosts, StoragePoolHost{Name: source.Host, Port: source.Port})
+	}
+	return append(hosts, source.Hosts...)
+}
+
+// parseStoragePoolXML fills the type, source and target of a pool from its
+// XML description.
+func parseStoragePoolXML(poolXML string, info *StoragePoolInfo) error {
+	doc, err := xmlutils.LoadXMLDocumentFromString(poolXML)
+	if err != nil {
+		return fmt.Errorf("parsing pool XML: %w", err)
+	}
+
+	pool := xmlutils.FindElement(doc, "/pool")
+	if pool == nil {
+		return fmt.Errorf("pool XML has no pool element")
+	}
+	info.Type = xmlutils.GetElementAttribute(pool, "type")
+
+	if path := xmlutils.FindElement(doc, "/pool/target/path"); path != nil {
+		info.Path = xmlutils.GetElementText(path)
+		info.Target = &StoragePoolTarget{Path: info.Path}
+	}
+
+	if source := xmlutils.FindElement(doc, "/pool/source"); source != nil && len(source.ChildElements()) > 0 {
+		info.Source = parsePoolSource(info.Type, source)
+	}
+
+	return nil
+}
+
+// parsePoolSource parses the source element of a pool.
+func parsePoolSource(poolType string, elem *etree.Element) *StoragePoolSource {
+	source := &StoragePoolSource{}
+
+	for _, host := range elem.SelectElements("host") {
+		port, _ := strconv.Atoi(host.SelectAttrValue("port", "")) //nolint:errcheck // Hosts without port use the default
+		source.Hosts = append(source.Hosts, StoragePoolHost{
+			Name: host.SelectAttrValue("name", ""),
+			Port: port,
+		})
+	}
+	// A single server is reported like it is given
+	if len(source.Hosts) == 1 {
+		source.Host = source.Hosts[0].Name
+		source.Port = source.Hosts[0].Port
+		source.Hosts = nil
+	}
+
+	if dir := elem.SelectElement("dir"); dir != nil {
+		source.Dir = dir.SelectAttrValue("path", "")
+	}
+	if device := elem.SelectElement("device"); device != nil {
+		if poolType == iscsiPoolType {
+			source.IQN = device.SelectAttrValue("path", "")
+		} else {
+			source.Device = device.SelectAttrValue("path", "")
+		}
+	}
+	if name := elem.SelectElement("name"); name != nil {
+		source.Name = name.Text()
+	}
+	if format := elem.SelectElement("format"); format != nil {
+		source.Format = format.SelectAttrValue("type", "")
+	}
+	if iqn := elem.FindElement("initiator/iqn"); iqn != nil {
+		source.Initiator = iqn.SelectAttrValue("name", "")
+	}
+	if auth := elem.SelectElement("auth"); auth != nil {
+		source.Auth = &StoragePoolAuth{
+			Type:     auth.SelectAttrValue("type", ""),
+			Username: auth.SelectAttrValue("username", ""),
+		}
+		if secret := auth.SelectElement("secret"); secret != nil {
+			source.Auth.SecretUUID = secret.SelectAttrValue("uuid", "")
+			source.Auth.SecretUsage = secret.SelectAttrValue("usage", "")
+		}
+	}
+
+	return source
+}
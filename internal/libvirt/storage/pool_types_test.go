@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	xmlutils "github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+func TestValidatePoolParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  CreatePoolParams
+		want    CreatePoolParams
+		wantErr bool
+	}{
+		{
+			name:    "dir without path",
+			params:  CreatePoolParams{Name: "p", Type: "dir"},
+			wantErr: true,
+		},
+		{
+			name:   "netfs defaults the format",
+			params: CreatePoolParams{Name: "p", Type: "netfs", Path: "/mnt/p", Source: &StoragePoolSource{Host: "nfs", Dir: "/export"}},
+			want:   CreatePoolParams{Name: "p", Type: "netfs", Path: "/mnt/p", Source: &StoragePoolSource{Host: "nfs", Dir: "/export", Format: "nfs"}},
+		},
+		{
+			name:    "netfs without export",
+			params:  CreatePoolParams{Name: "p", Type: "netfs", Path: "/mnt/p", Source: &StoragePoolSource{Host: "nfs"}},
+			wantErr: true,
+		},
+		{
+			name:   "logical defaults the format",
+			params: CreatePoolParams{Name: "p", Type: "logical", Source: &StoragePoolSource{Name: "vg0"}},
+			want:   CreatePoolParams{Name: "p", Type: "logical", Source: &StoragePoolSource{Name: "vg0", Format: "lvm2"}},
+		},
+		{
+			name: "iscsi defaults the path and auth type",
+			params: CreatePoolParams{Name: "p", Type: "iscsi", Source: &StoragePoolSource{
+				Host: "san", IQN: "iqn.2024-01.com.example:lun",
+				Auth: &StoragePoolAuth{Username: "admin", SecretUsage: "san-chap"},
+			}},
+			want: CreatePoolParams{Name: "p", Type: "iscsi", Path: iscsiTargetPath, Source: &StoragePoolSource{
+				Host: "san", IQN: "iqn.2024-01.com.example:lun",
+				Auth: &StoragePoolAuth{Type: "chap", Username: "admin", SecretUsage: "san-chap"},
+			}},
+		},
+		{
+			name:    "iscsi without iqn",
+			params:  CreatePoolParams{Name: "p", Type: "iscsi", Source: &StoragePoolSource{Host: "san"}},
+			wantErr: true,
+		},
+		{
+			name: "rbd defaults the auth type",
+			params: CreatePoolParams{Name: "p", Type: "rbd", Source: &StoragePoolSource{
+				Hosts: []StoragePoolHost{{Name: "mon1", Port: 6789}, {Name: "mon2"}}, Name: "rbd",
+				Auth: &StoragePoolAuth{Username: "libvirt", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+			}},
+			want: CreatePoolParams{Name: "p", Type: "rbd", Source: &StoragePoolSource{
+				Hosts: []StoragePoolHost{{Name: "mon1", Port: 6789}, {Name: "mon2"}}, Name: "rbd",
+				Auth: &StoragePoolAuth{Type: "ceph", Username: "libvirt", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+			}},
+		},
+		{
+			name:    "rbd with path",
+			params:  CreatePoolParams{Name: "p", Type: "rbd", Path: "/x", Source: &StoragePoolSource{Host: "mon1", Name: "rbd"}},
+			wantErr: true,
+		},
+		{
+			name:    "auth without secret",
+			params:  CreatePoolParams{Name: "p", Type: "rbd", Source: &StoragePoolSource{Host: "mon1", Name: "rbd", Auth: &StoragePoolAuth{Username: "libvirt"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported type",
+			params:  CreatePoolParams{Name: "p", Type: "zfs"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			err := validatePoolParams(&params)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apierrors.ErrInvalidParameter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+		})
+	}
+}
+
+func TestParseStoragePoolXML(t *testing.T) {
+	poolXML := `<pool type='iscsi'>
+  <name>san</name>
+  <source>
+    <host name='san.example.com' port='3260'/>
+    <device path='iqn.2024-01.com.example:lun'/>
+    <initiator>
+      <iqn name='iqn.2024-01.com.example:host'/>
+    </initiator>
+    <auth type='chap' username='admin'>
+      <secret usage='san-chap'/>
+    </auth>
+  </source>
+  <target>
+    <path>/dev/disk/by-path</path>
+  </target>
+</pool>`
+
+	var info StoragePoolInfo
+	require.NoError(t, parseStoragePoolXML(poolXML, &info))
+	assert.Equal(t, "iscsi", info.Type)
+	assert.Equal(t, "/dev/disk/by-path", info.Path)
+	assert.Equal(t, &StoragePoolSource{
+		Host:      "san.example.com",
+		Port:      3260,
+		IQN:       "iqn.2024-01.com.example:lun",
+		Initiator: "iqn.2024-01.com.example:host",
+		Auth:      &StoragePoolAuth{Type: "chap", Username: "admin", SecretUsage: "san-chap"},
+	}, info.Source)
+
+	// Directory pools have an empty source
+	info = StoragePoolInfo{}
+	require.NoError(t, parseStoragePoolXML(`<pool type='dir'><name>default</name><source/><target><path>/var/lib/libvirt/images</path></target></pool>`, &info))
+	assert.Equal(t, "dir", info.Type)
+	assert.Nil(t, info.Source)
+
+	assert.Error(t, parseStoragePoolXML("<pool", &info))
+}
+
+func TestTemplateXMLBuilder_BuildStoragePoolXMLWithParams(t *testing.T) {
+	// Use the shipped template and parse what it renders back
+	templateLoader, err := xmlutils.NewTemplateLoader(filepath.Join("..", "..", "..", "configs", "templates", "storage"))
+	require.NoError(t, err)
+
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+	builder := NewTemplateXMLBuilder(templateLoader, mockLog)
+
+	tests := []CreatePoolParams{
+		{Name: "nfs", Type: "netfs", Path: "/mnt/nfs", Source: &StoragePoolSource{Host: "nfs.example.com", Dir: "/export/vms", Format: "nfs"}},
+		{Name: "lvm", Type: "logical", Path: "/dev/vg0", Source: &StoragePoolSource{Name: "vg0", Format: "lvm2", Device: "/dev/sdb"}},
+		{Name: "san", Type: "iscsi", Path: "/dev/disk/by-path", Source: &StoragePoolSource{
+			Host: "san.example.com", IQN: "iqn.2024-01.com.example:lun",
+			Auth: &StoragePoolAuth{Type: "chap", Username: "admin", SecretUsage: "san-chap"},
+		}},
+		{Name: "ceph", Type: "rbd", Source: &StoragePoolSource{
+			Hosts: []StoragePoolHost{{Name: "mon1", Port: 6789}, {Name: "mon2", Port: 6789}}, Name: "rbd",
+			Auth: &StoragePoolAuth{Type: "ceph", Username: "libvirt", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+		}},
+	}
+
+	for _, params := range tests {
+		t.Run(params.Type, func(t *testing.T) {
+			poolXML, err := builder.BuildStoragePoolXMLWithParams(&params)
+			require.NoError(t, err)
+
+			var info StoragePoolInfo
+			require.NoError(t, parseStoragePoolXML(poolXML, &info), poolXML)
+			assert.Equal(t, params.Type, info.Type)
+			assert.Equal(t, params.Path, info.Path)
+			assert.Equal(t, params.Source, info.Source)
+			assert.NotContains(t, poolXML, "permissions")
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/beevik/etree"
+	"github.com/digitalocean/go-libvirt"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/pkg/logger"
+	"github.com/threatflux/libgo/pkg/utils/xmlutils"
+)
+
+// luksEncryptionFormat is the libvirt encryption format of LUKS volumes.
+const luksEncryptionFormat = "luks"
+
+// backingVolume describes the volume an overlay is backed by.
+type backingVolume struct {
+	format    string
+	capacity  uint64
+	encrypted bool
+}
+
+// createEncrypted creates a LUKS encrypted volume with a new passphrase.
+// Encrypted volumes are never recreated, as the existing volume may hold
+// data.
+func (m *LibvirtVolumeManager) createEncrypted(ctx context.Context, poolName string, params *CreateVolumeParams) error {
+	if m.keys == nil {
+		return fmt.Errorf("%w: volume encryption is not configured", apierrors.ErrInvalidParameter)
+	}
+
+	format := params.Format
+	if format == "" {
+		format = "qcow2"
+	}
+	if format != "qcow2" && format != "raw" {
+		return fmt.Errorf("%w: encrypted volumes must be qcow2 or raw, not %s",
+			apierrors.ErrInvalidParameter, format)
+	}
+	if params.BackingStore != "" && format != "qcow2" {
+		return fmt.Errorf("volumes with a backing store must be qcow2, not %s", format)
+	}
+
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer func() {
+		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
+			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+		}
+	}()
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	pool, err := m.validateAndGetPool(ctx, libvirtConn, poolName)
+	if err != nil {
+		return err
+	}
+
+	// libvirt formats LUKS volumes in file pools only
+	poolType, err := readPoolType(libvirtConn, pool)
+	if err != nil {
+		return err
+	}
+	if poolDiskType(poolType) != fileDiskType {
+		return fmt.Errorf("%w: encrypted volumes cannot be created in %s pool %s",
+			apierrors.ErrInvalidParameter, poolType, poolName)
+	}
+
+	if _, err := libvirtConn.StorageVolLookupByName(*pool, params.Name); err == nil {
+		return fmt.Errorf("volume %s in pool %s: %w", params.Name, poolName, ErrVolumeExists)
+	}
+
+	volume := *params
+	volume.Format = format
+	if params.BackingStore != "" {
+		backing, err := readBackingVolume(libvirtConn, params.BackingStore, params.BackingFormat)
+		if err != nil {
+			return err
+		}
+		if backing.encrypted {
+			return fmt.Errorf("%w: backing store %s is encrypted",
+				apierrors.ErrInvalidParameter, params.BackingStore)
+		}
+		volume.BackingFormat = backing.format
+		volume.CapacityBytes = max(params.CapacityBytes, backing.capacity)
+	}
+
+	if err := m.checkPoolOvercommit(libvirtConn, pool, volume.CapacityBytes); err != nil {
+		return err
+	}
+
+	key, err := m.keys.Create(ctx, poolName, params.Name)
+	if err != nil {
+		return fmt.Errorf("creating volume key: %w", err)
+	}
+
+	if err := m.defineEncryptedVolume(libvirtConn, pool, &volume, key.SecretUUID); err != nil {
+		if deleteErr := m.keys.Delete(ctx, poolName, params.Name); deleteErr != nil {
+			m.logger.Error("Failed to erase key of volume that was not created",
+				logger.String("pool", poolName),
+				logger.String("volume", params.Name),
+				logger.Error(deleteErr))
+		}
+		return err
+	}
+
+	m.logger.Info("Created encrypted storage volume",
+		logger.String("pool", poolName),
+		logger.String("volume", params.Name),
+		logger.Uint64("capacity", volume.CapacityBytes),
+		logger.String("format", format),
+		logger.String("backing_store", volume.BackingStore),
+		logger.String("secret_uuid", key.SecretUUID))
+
+	return nil
+}
+
+// defineEncryptedVolume creates an encrypted volume whose passphrase is held
+// by a secret.
+func (m *LibvirtVolumeManager) defineEncryptedVolume(libvirtConn *libvirt.Libvirt, pool *libvirt.StoragePool, params *CreateVolumeParams, secretUUID string) error {
+	volumeXML, err := m.xmlBuilder.BuildEncryptedVolumeXML(params, secretUUID)
+	if err != nil {
+		return fmt.Errorf("building volume XML: %w", err)
+	}
+
+	if _, err := libvirtConn.StorageVolCreateXML(*pool, volumeXML, 0); err != nil {
+		return fmt.Errorf("creating encrypted volume: %w", err)
+	}
+
+	return nil
+}
+
+// eraseKey erases the passphrase of a deleted volume. Volumes without a key
+// are not encrypted.
+func (m *LibvirtVolumeManager) eraseKey(ctx context.Context, poolName string, volName string) error {
+	if m.keys == nil {
+		return nil
+	}
+
+	if err := m.keys.Delete(ctx, poolName, volName); err != nil {
+		if errors.Is(err, ErrVolumeKeyNotFound) {
+			return nil
+		}
+		return fmt.Errorf("erasing key of volume %s: %w", volName, err)
+	}
+
+	m.logger.Info("Crypto-erased storage volume",
+		logger.String("pool", poolName),
+		logger.String("volume", volName))
+
+	return nil
+}
+
+// readBackingVolume looks up the volume at the backing store path of an
+// overlay, which tells its capacity and, unless given, its format.
+func readBackingVolume(libvirtConn *libvirt.Libvirt, path string, format string) (*backingVolume, error) {
+	vol, err := libvirtConn.StorageVolLookupByPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("backing store %s: %w", path, ErrVolumeNotFound)
+	}
+
+	_, capacity, _, err := libvirtConn.StorageVolGetInfo(vol)
+	if err != nil {
+		return nil, fmt.Errorf("getting backing volume info: %w", err)
+	}
+
+	volXML, err := libvirtConn.StorageVolGetXMLDesc(vol, 0)
+	if err != nil {
+		return nil, fmt.Errorf("getting backing volume XML: %w", err)
+	}
+	doc, err := xmlutils.LoadXMLDocumentFromString(volXML)
+	if err != nil {
+		return nil, fmt.Errorf("parsing backing volume XML: %w", err)
+	}
+
+	if format == "" {
+		format = "raw"
+		if formatElement := xmlutils.FindElement(doc, "/volume/target/format"); formatElement != nil {
+			format = xmlutils.GetElementAttribute(formatElement, "type")
+		}
+	}
+
+	return &backingVolume{
+		format:    format,
+		capacity:  capacity,
+		encrypted: parseVolumeEncryption(doc) != nil,
+	}, nil
+}
+
+// parseVolumeEncryption reads the encryption of a volume from its XML.
+func parseVolumeEncryption(doc *etree.Document) *VolumeEncryption {
+	element := xmlutils.FindElement(doc, "/volume/target/encryption")
+	if element == nil {
+		return nil
+	}
+
+	encryption := &VolumeEncryption{Format: xmlutils.GetElementAttribute(element, "format")}
+	if encryption.Format == "" {
+		encryption.Format = luksEncryptionFormat
+	}
+	if secret := xmlutils.FindElement(doc, "/volume/target/encryption/secret"); secret != nil {
+		encryption.SecretUUID = xmlutils.GetElementAttribute(secret, "uuid")
+	}
+
+	return encryption
+}
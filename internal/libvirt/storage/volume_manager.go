@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/digitalocean/go-libvirt"
+	apierrors "github.com/threatflux/libgo/internal/errors"
 	"github.com/threatflux/libgo/internal/libvirt/connection"
 	"github.com/threatflux/libgo/pkg/logger"
 	executil "github.com/threatflux/libgo/pkg/utils/exec"
@@ -24,21 +25,36 @@ var (
 	ErrPoolNotActive  = fmt.Errorf("storage pool is not active")
 )
 
+// VolumeManagerConfig configures a LibvirtVolumeManager.
+type VolumeManagerConfig struct {
+	// MaxOvercommit rejects volumes that would take the sum of the volume
+	// capacities of a pool past this multiple of the pool capacity; zero
+	// allows any overcommit
+	MaxOvercommit float64
+}
+
 // LibvirtVolumeManager implements VolumeManager for libvirt.
 type LibvirtVolumeManager struct {
 	connManager connection.Manager
 	poolManager PoolManager
 	xmlBuilder  XMLBuilder
-	logger      logger.Logger
+	// keys manages the passphrases of encrypted volumes; nil when no master
+	// key is configured
+	keys   VolumeKeyManager
+	logger logger.Logger
+	config VolumeManagerConfig
 }
 
-// NewLibvirtVolumeManager creates a new LibvirtVolumeManager.
-func NewLibvirtVolumeManager(connManager connection.Manager, poolManager PoolManager, xmlBuilder XMLBuilder, logger logger.Logger) *LibvirtVolumeManager {
+// NewLibvirtVolumeManager creates a new LibvirtVolumeManager. keys may be nil,
+// which disables volume encryption.
+func NewLibvirtVolumeManager(connManager connection.Manager, poolManager PoolManager, xmlBuilder XMLBuilder, keys VolumeKeyManager, config VolumeManagerConfig, logger logger.Logger) *LibvirtVolumeManager {
 	return &LibvirtVolumeManager{
 		connManager: connManager,
 		poolManager: poolManager,
 		xmlBuilder:  xmlBuilder,
+		keys:        keys,
 		logger:      logger,
+		config:      config,
 	}
 }
 
@@ -73,6 +89,13 @@ func (m *LibvirtVolumeManager) Create(ctx context.Context, poolName string, volN
 		return fmt.Errorf("pool %s: %w", poolName, ErrPoolNotActive)
 	}
 
+	if err := m.checkVolumeCreate(libvirtConn, pool, poolName, format); err != nil {
+		return err
+	}
+	if err := m.checkPoolOvercommit(libvirtConn, pool, capacityBytes); err != nil {
+		return err
+	}
+
 	// Check if volume already exists
 	existingVol, err := libvirtConn.StorageVolLookupByName(*pool, volName)
 	if err == nil {
@@ -84,6 +107,9 @@ func (m *LibvirtVolumeManager) Create(ctx context.Context, poolName string, volN
 		if deleteErr := libvirtConn.StorageVolDelete(existingVol, 0); deleteErr != nil {
 			return fmt.Errorf("deleting existing volume %s in pool %s: %w", volName, poolName, deleteErr)
 		}
+		if err := m.eraseKey(ctx, poolName, volName); err != nil {
+			return err
+		}
 	}
 
 	// Generate volume XML
@@ -107,6 +133,76 @@ func (m *LibvirtVolumeManager) Create(ctx context.Context, poolName string, volN
 	return nil
 }
 
+// CreateWithParams implements VolumeManager.CreateWithParams.
+func (m *LibvirtVolumeManager) CreateWithParams(ctx context.Context, poolName string, params *CreateVolumeParams) error {
+	if params.Encrypted {
+		return m.createEncrypted(ctx, poolName, params)
+	}
+	if params.BackingStore == "" {
+		return m.Create(ctx, poolName, params.Name, params.CapacityBytes, params.Format)
+	}
+	if params.Format != "" && params.Format != "qcow2" {
+		return fmt.Errorf("volumes with a backing store must be qcow2, not %s", params.Format)
+	}
+
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer func() {
+		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
+			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+		}
+	}()
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	pool, err := m.validateAndGetPool(ctx, libvirtConn, poolName)
+	if err != nil {
+		return err
+	}
+
+	if err := m.checkVolumeCreate(libvirtConn, pool, poolName, "qcow2"); err != nil {
+		return err
+	}
+
+	// Overlays are never recreated, as the existing volume may hold data
+	if _, err := libvirtConn.StorageVolLookupByName(*pool, params.Name); err == nil {
+		return fmt.Errorf("volume %s in pool %s: %w", params.Name, poolName, ErrVolumeExists)
+	}
+
+	backing, err := readBackingVolume(libvirtConn, params.BackingStore, params.BackingFormat)
+	if err != nil {
+		return err
+	}
+	backingFormat := backing.format
+
+	// An overlay cannot be smaller than its backing store
+	capacity := max(params.CapacityBytes, backing.capacity)
+	if err := m.checkPoolOvercommit(libvirtConn, pool, capacity); err != nil {
+		return err
+	}
+
+	volumeXML, err := m.xmlBuilder.BuildOverlayVolumeXML(params.Name, capacity, params.BackingStore, backingFormat)
+	if err != nil {
+		return fmt.Errorf("building volume XML: %w", err)
+	}
+
+	if _, err := libvirtConn.StorageVolCreateXML(*pool, volumeXML, 0); err != nil {
+		return fmt.Errorf("creating overlay volume: %w", err)
+	}
+
+	m.logger.Info("Created overlay storage volume",
+		logger.String("pool", poolName),
+		logger.String("volume", params.Name),
+		logger.Uint64("capacity", capacity),
+		logger.String("backing_store", params.BackingStore),
+		logger.String("backing_format", backingFormat))
+
+	return nil
+}
+
 // CreateFromImage implements VolumeManager.CreateFromImage.
 func (m *LibvirtVolumeManager) CreateFromImage(ctx context.Context, poolName string, volName string, imagePath string, format string) error {
 	// Get libvirt connection
@@ -128,17 +224,24 @@ func (m *LibvirtVolumeManager) CreateFromImage(ctx context.Context, poolName str
 		return err
 	}
 
-	// Handle existing volume (delete if exists)
-	if handleErr := m.handleExistingVolume(libvirtConn, pool, poolName, volName); handleErr != nil {
-		return handleErr
-	}
-
 	// Validate and get image info
 	imgInfo, finalFormat, err := m.validateImageAndFormat(ctx, imagePath, format)
 	if err != nil {
 		return err
 	}
 
+	if err := m.checkVolumeCreate(libvirtConn, pool, poolName, finalFormat); err != nil {
+		return err
+	}
+	if err := m.checkPoolOvercommit(libvirtConn, pool, imgInfo.VirtualSize); err != nil {
+		return err
+	}
+
+	// Handle existing volume (delete if exists)
+	if handleErr := m.handleExistingVolume(libvirtConn, pool, poolName, volName); handleErr != nil {
+		return handleErr
+	}
+
 	// Create and populate volume
 	return m.createAndPopulateVolume(ctx, libvirtConn, pool, volName, imagePath, finalFormat, imgInfo, poolName)
 }
@@ -322,6 +425,12 @@ func (m *LibvirtVolumeManager) Delete(ctx context.Context, poolName string, volN
 		return err
 	}
 
+	// Without its passphrase the data of an encrypted volume cannot be
+	// recovered from the freed blocks
+	if err := m.eraseKey(ctx, poolName, volName); err != nil {
+		return err
+	}
+
 	m.logger.Info("Deleted storage volume",
 		logger.String("pool", poolName),
 		logger.String("volume", volName))
@@ -356,9 +465,20 @@ func (m *LibvirtVolumeManager) Resize(ctx context.Context, poolName string, volN
 		return fmt.Errorf("volume %s in pool %s: %w", volName, poolName, ErrVolumeNotFound)
 	}
 
-	// Resize the volume
-	// Note: VIR_STORAGE_VOL_RESIZE_BYTES flag (value 1) tells libvirt the new size is in bytes
-	if err := libvirtConn.StorageVolResize(vol, capacityBytes, 1); err != nil {
+	_, currentCapacity, _, err := libvirtConn.StorageVolGetInfo(vol)
+	if err != nil {
+		return fmt.Errorf("getting volume info: %w", err)
+	}
+
+	// Libvirt refuses to shrink volumes unless asked to explicitly
+	var flags libvirt.StorageVolResizeFlags
+	if capacityBytes < currentCapacity {
+		flags = libvirt.StorageVolResizeShrink
+	} else if err := m.checkPoolOvercommit(libvirtConn, pool, capacityBytes-currentCapacity); err != nil {
+		return err
+	}
+
+	if err := libvirtConn.StorageVolResize(vol, capacityBytes, flags); err != nil {
 		return fmt.Errorf("resizing volume: %w", err)
 	}
 
@@ -408,6 +528,11 @@ func (m *LibvirtVolumeManager) GetPath(ctx context.Context, poolName string, vol
 
 // Clone implements VolumeManager.Clone.
 func (m *LibvirtVolumeManager) Clone(ctx context.Context, poolName string, sourceVolName string, destVolName string) error {
+	return m.CloneTo(ctx, poolName, sourceVolName, poolName, destVolName, "")
+}
+
+// CloneTo implements VolumeManager.CloneTo.
+func (m *LibvirtVolumeManager) CloneTo(ctx context.Context, poolName string, sourceVolName string, destPoolName string, destVolName string, format string) error {
 	// Get libvirt connection
 	conn, err := m.connManager.Connect(ctx)
 	if err != nil {
@@ -421,11 +546,18 @@ func (m *LibvirtVolumeManager) Clone(ctx context.Context, poolName string, sourc
 
 	libvirtConn := conn.GetLibvirtConnection()
 
-	// Get the pool
+	// Get the pools
 	pool, err := m.poolManager.Get(ctx, poolName)
 	if err != nil {
 		return fmt.Errorf("getting storage pool: %w", err)
 	}
+	destPool := pool
+	if destPoolName != poolName {
+		destPool, err = m.poolManager.Get(ctx, destPoolName)
+		if err != nil {
+			return fmt.Errorf("getting destination storage pool: %w", err)
+		}
+	}
 
 	// Look up the source volume
 	sourceVol, err := libvirtConn.StorageVolLookupByName(*pool, sourceVolName)
@@ -434,9 +566,17 @@ func (m *LibvirtVolumeManager) Clone(ctx context.Context, poolName string, sourc
 	}
 
 	// Check if destination volume already exists
-	_, err = libvirtConn.StorageVolLookupByName(*pool, destVolName)
+	_, err = libvirtConn.StorageVolLookupByName(*destPool, destVolName)
 	if err == nil {
-		return fmt.Errorf("destination volume %s in pool %s: %w", destVolName, poolName, ErrVolumeExists)
+		return fmt.Errorf("destination volume %s in pool %s: %w", destVolName, destPoolName, ErrVolumeExists)
+	}
+
+	_, sourceCapacity, _, err := libvirtConn.StorageVolGetInfo(sourceVol)
+	if err != nil {
+		return fmt.Errorf("getting source volume info: %w", err)
+	}
+	if err := m.checkPoolOvercommit(libvirtConn, destPool, sourceCapacity); err != nil {
+		return err
 	}
 
 	// Get the source volume XML
@@ -451,6 +591,12 @@ func (m *LibvirtVolumeManager) Clone(ctx context.Context, poolName string, sourc
 		return fmt.Errorf("parsing source volume XML: %w", err)
 	}
 
+	// A copy would need a passphrase of its own
+	if xmlutils.FindElement(doc, "/volume/target/encryption") != nil {
+		return fmt.Errorf("%w: encrypted volume %s cannot be cloned",
+			apierrors.ErrInvalidParameter, sourceVolName)
+	}
+
 	// Set the name in the XML
 	nameElement := xmlutils.FindElement(doc, "/volume/name")
 	if nameElement == nil {
@@ -458,16 +604,120 @@ func (m *LibvirtVolumeManager) Clone(ctx context.Context, poolName string, sourc
 	}
 	nameElement.SetText(destVolName)
 
+	// Libvirt converts the data when the destination format differs
+	if format != "" {
+		if formatElement := xmlutils.FindElement(doc, "/volume/target/format"); formatElement != nil {
+			formatElement.CreateAttr("type", format)
+		}
+	}
+
 	// Generate the new XML
 	newXML := xmlutils.XMLToString(doc)
 
 	// Create the cloned volume
-	_, err = libvirtConn.StorageVolCreateXMLFrom(*pool, newXML, sourceVol, 0)
+	_, err = libvirtConn.StorageVolCreateXMLFrom(*destPool, newXML, sourceVol, 0)
 	if err != nil {
 		return fmt.Errorf("cloning volume: %w", err)
 	}
 
 	m.logger.Info("Cloned storage volume",
+		logger.String("pool", poolName),
+		logger.String("source", sourceVolName),
+		logger.String("destination_pool", destPoolName),
+		logger.String("destination", destVolName))
+
+	return nil
+}
+
+// CloneLinked implements VolumeManager.CloneLinked.
+func (m *LibvirtVolumeManager) CloneLinked(ctx context.Context, poolName string, sourceVolName string, destVolName string) error {
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer func() {
+		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
+			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+		}
+	}()
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	// Get the pool
+	pool, err := m.poolManager.Get(ctx, poolName)
+	if err != nil {
+		return fmt.Errorf("getting storage pool: %w", err)
+	}
+
+	// Look up the source volume
+	sourceVol, err := libvirtConn.StorageVolLookupByName(*pool, sourceVolName)
+	if err != nil {
+		return fmt.Errorf("source volume %s in pool %s: %w", sourceVolName, poolName, ErrVolumeNotFound)
+	}
+
+	// Check if destination volume already exists
+	_, err = libvirtConn.StorageVolLookupByName(*pool, destVolName)
+	if err == nil {
+		return fmt.Errorf("destination volume %s in pool %s: %w", destVolName, poolName, ErrVolumeExists)
+	}
+
+	// The linked volume has the virtual size of its source
+	_, sourceCapacity, _, err := libvirtConn.StorageVolGetInfo(sourceVol)
+	if err != nil {
+		return fmt.Errorf("getting source volume info: %w", err)
+	}
+	if err := m.checkPoolOvercommit(libvirtConn, pool, sourceCapacity); err != nil {
+		return err
+	}
+
+	// Get the source volume XML
+	sourceXML, err := libvirtConn.StorageVolGetXMLDesc(sourceVol, 0)
+	if err != nil {
+		return fmt.Errorf("getting source volume XML: %w", err)
+	}
+
+	sourceDoc, err := xmlutils.LoadXMLDocumentFromString(sourceXML)
+	if err != nil {
+		return fmt.Errorf("parsing source volume XML: %w", err)
+	}
+
+	// Overlays cannot open an encrypted backing volume without its secret
+	if xmlutils.FindElement(sourceDoc, "/volume/target/encryption") != nil {
+		return fmt.Errorf("%w: encrypted volume %s cannot be cloned",
+			apierrors.ErrInvalidParameter, sourceVolName)
+	}
+
+	sourcePath := xmlutils.FindElement(sourceDoc, "/volume/target/path")
+	capacity := xmlutils.FindElement(sourceDoc, "/volume/capacity")
+	if sourcePath == nil || capacity == nil {
+		return fmt.Errorf("source volume XML is missing path or capacity")
+	}
+
+	sourceFormat := "raw"
+	if format := xmlutils.FindElement(sourceDoc, "/volume/target/format"); format != nil {
+		sourceFormat = xmlutils.GetElementAttribute(format, "type")
+	}
+
+	// Build a qcow2 volume backed by the source volume
+	doc := xmlutils.CreateXMLDocument("volume")
+	root := doc.Root()
+	xmlutils.AddElement(root, "name", destVolName)
+	xmlutils.AddElementWithAttributes(root, "capacity", map[string]string{"unit": "bytes"}).
+		SetText(xmlutils.GetElementText(capacity))
+	target := xmlutils.AddElement(root, "target", "")
+	xmlutils.AddElementWithAttributes(target, "format", map[string]string{"type": "qcow2"})
+	backingStore := xmlutils.AddElement(root, "backingStore", "")
+	xmlutils.AddElement(backingStore, "path", xmlutils.GetElementText(sourcePath))
+	xmlutils.AddElementWithAttributes(backingStore, "format", map[string]string{"type": sourceFormat})
+
+	// Create the linked volume
+	_, err = libvirtConn.StorageVolCreateXML(*pool, xmlutils.XMLToString(doc), 0)
+	if err != nil {
+		return fmt.Errorf("creating linked volume: %w", err)
+	}
+
+	m.logger.Info("Created linked clone of storage volume",
 		logger.String("pool", poolName),
 		logger.String("source", sourceVolName),
 		logger.String("destination", destVolName))
@@ -640,6 +890,152 @@ func (m *LibvirtVolumeManager) GetXML(ctx context.Context, poolName string, volN
 	return xml, nil
 }
 
+// GetSource implements VolumeManager.GetSource.
+func (m *LibvirtVolumeManager) GetSource(ctx context.Context, poolName string, volName string) (*VolumeSource, error) {
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer func() {
+		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
+			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+		}
+	}()
+
+	libvirtConn := conn.GetLibvirtConnection()
+
+	// Get the pool
+	pool, err := m.poolManager.Get(ctx, poolName)
+	if err != nil {
+		return nil, fmt.Errorf("getting storage pool: %w", err)
+	}
+
+	// Look up the volume
+	vol, err := libvirtConn.StorageVolLookupByName(*pool, volName)
+	if err != nil {
+		return nil, fmt.Errorf("volume %s in pool %s: %w", volName, poolName, ErrVolumeNotFound)
+	}
+
+	// The pool type decides how the volume is attached
+	poolXML, err := libvirtConn.StoragePoolGetXMLDesc(*pool, 0)
+	if err != nil {
+		return nil, fmt.Errorf("getting pool XML: %w", err)
+	}
+	var poolInfo StoragePoolInfo
+	if err := parseStoragePoolXML(poolXML, &poolInfo); err != nil {
+		return nil, err
+	}
+
+	diskType := poolDiskType(poolInfo.Type)
+	if diskType == networkDiskType {
+		source := &VolumeSource{
+			Type:     diskType,
+			Protocol: poolInfo.Type,
+			Name:     volName,
+			Thin:     thinPool(poolInfo.Type),
+		}
+		if poolInfo.Source != nil {
+			source.Name = poolInfo.Source.Name + "/" + volName
+			source.Hosts = poolHosts(poolInfo.Source)
+			source.Auth = poolInfo.Source.Auth
+		}
+		return source, nil
+	}
+
+	path, err := libvirtConn.StorageVolGetPath(vol)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume path: %w", err)
+	}
+
+	volXML, err := libvirtConn.StorageVolGetXMLDesc(vol, 0)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume XML: %w", err)
+	}
+	doc, err := xmlutils.LoadXMLDocumentFromString(volXML)
+	if err != nil {
+		return nil, fmt.Errorf("parsing volume XML: %w", err)
+	}
+
+	return &VolumeSource{
+		Type:       diskType,
+		Path:       path,
+		Encryption: parseVolumeEncryption(doc),
+		Thin:       thinPool(poolInfo.Type),
+	}, nil
+}
+
+// LookupByPath implements VolumeManager.LookupByPath.
+func (m *LibvirtVolumeManager) LookupByPath(ctx context.Context, path string) (string, string, error) {
+	// Get libvirt connection
+	conn, err := m.connManager.Connect(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to connect to libvirt: %w", err)
+	}
+	defer func() {
+		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
+			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+		}
+	}()
+
+	vol, err := conn.GetLibvirtConnection().StorageVolLookupByPath(path)
+	if err != nil {
+		return "", "", fmt.Errorf("volume at %s: %w", path, ErrVolumeNotFound)
+	}
+
+	return vol.Pool, vol.Name, nil
+}
+
+// checkVolumeCreate checks that volumes of a format can be created in a
+// pool. iSCSI pools only list the LUNs of their target.
+func (m *LibvirtVolumeManager) checkVolumeCreate(libvirtConn *libvirt.Libvirt, pool *libvirt.StoragePool, poolName string, format string) error {
+	if format == "" {
+		format = "qcow2"
+	}
+
+	poolType, err := readPoolType(libvirtConn, pool)
+	if err != nil {
+		return err
+	}
+
+	if poolType == iscsiPoolType {
+		return fmt.Errorf("%w: volumes cannot be created in iscsi pool %s",
+			apierrors.ErrInvalidParameter, poolName)
+	}
+	if format != "raw" && rawOnlyPool(poolType) {
+		return fmt.Errorf("%w: %s pool %s only holds raw volumes, not %s",
+			apierrors.ErrInvalidParameter, poolType, poolName, format)
+	}
+	return nil
+}
+
+// readPoolType reads the type of a pool from its XML.
+func readPoolType(libvirtConn *libvirt.Libvirt, pool *libvirt.StoragePool) (string, error) {
+	poolXML, err := libvirtConn.StoragePoolGetXMLDesc(*pool, 0)
+	if err != nil {
+		return "", fmt.Errorf("getting pool XML: %w", err)
+	}
+	var poolInfo StoragePoolInfo
+	if err := parseStoragePoolXML(poolXML, &poolInfo); err != nil {
+		return "", err
+	}
+	return poolInfo.Type, nil
+}
+
+// checkPoolOvercommit checks that volumes of addBytes more virtual size fit
+// under the overcommit limit of a pool.
+func (m *LibvirtVolumeManager) checkPoolOvercommit(libvirtConn *libvirt.Libvirt, pool *libvirt.StoragePool, addBytes uint64) error {
+	if m.config.MaxOvercommit <= 0 {
+		return nil
+	}
+
+	usage, err := readPoolUsage(libvirtConn, *pool)
+	if err != nil {
+		return err
+	}
+	return checkOvercommit(usage, addBytes, m.config.MaxOvercommit)
+}
+
 // Wipe implements VolumeManager.Wipe.
 func (m *LibvirtVolumeManager) Wipe(ctx context.Context, poolName string, volName string) error {
 	err := m.withVolumeConnection(ctx, poolName, volName, func(libvirtConn *libvirt.Libvirt, vol libvirt.StorageVol) error {
@@ -661,40 +1057,30 @@ func (m *LibvirtVolumeManager) Wipe(ctx context.Context, poolName string, volNam
 	return nil
 }
 
-// Upload implements VolumeManager.Upload.
+// Upload implements VolumeManager.Upload. The data is streamed through the
+// libvirt connection, so it reaches volumes on remote hosts as well.
 func (m *LibvirtVolumeManager) Upload(ctx context.Context, poolName string, volName string, reader io.Reader) error {
-	// Get libvirt connection
-	conn, err := m.connManager.Connect(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to connect to libvirt: %w", err)
-	}
-	defer func() {
-		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
-			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
+	return m.withVolumeConnection(ctx, poolName, volName, func(libvirtConn *libvirt.Libvirt, vol libvirt.StorageVol) error {
+		if err := libvirtConn.StorageVolUpload(vol, reader, 0, 0, 0); err != nil {
+			return fmt.Errorf("uploading volume: %w", err)
 		}
-	}()
-
-	// Stream upload is not supported by digitalocean/go-libvirt
-	// Return an error indicating the feature is not implemented
-	return fmt.Errorf("volume upload is not currently supported")
+		return nil
+	})
 }
 
 // Download implements VolumeManager.Download.
 func (m *LibvirtVolumeManager) Download(ctx context.Context, poolName string, volName string, writer io.Writer) error {
-	// Get libvirt connection
-	conn, err := m.connManager.Connect(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to connect to libvirt: %w", err)
-	}
-	defer func() {
-		if releaseErr := m.connManager.Release(conn); releaseErr != nil {
-			m.logger.Error("Failed to release connection", logger.Error(releaseErr))
-		}
-	}()
+	return m.DownloadRange(ctx, poolName, volName, 0, 0, writer)
+}
 
-	// Stream download is not supported by digitalocean/go-libvirt
-	// Return an error indicating the feature is not implemented
-	return fmt.Errorf("volume download is not currently supported")
+// DownloadRange implements VolumeManager.DownloadRange.
+func (m *LibvirtVolumeManager) DownloadRange(ctx context.Context, poolName string, volName string, offset uint64, length uint64, writer io.Writer) error {
+	return m.withVolumeConnection(ctx, poolName, volName, func(libvirtConn *libvirt.Libvirt, vol libvirt.StorageVol) error {
+		if err := libvirtConn.StorageVolDownload(vol, writer, offset, length, 0); err != nil {
+			return fmt.Errorf("downloading volume: %w", err)
+		}
+		return nil
+	})
 }
 
 // getVolumeInfo is a helper method to get volume information.
@@ -705,6 +1091,13 @@ func (m *LibvirtVolumeManager) getVolumeInfo(libvirtConn *libvirt.Libvirt, vol *
 		return nil, fmt.Errorf("getting volume info: %w", err)
 	}
 
+	// The physical size is the number of bytes a download of the volume
+	// holds; servers that cannot report it leave it unset
+	var physical uint64
+	if _, _, size, err := libvirtConn.StorageVolGetInfoFlags(*vol, uint32(libvirt.StorageVolGetPhysical)); err == nil {
+		physical = size
+	}
+
 	// Get volume path
 	path, err := libvirtConn.StorageVolGetPath(*vol)
 	if err != nil {
@@ -730,15 +1123,31 @@ func (m *LibvirtVolumeManager) getVolumeInfo(libvirtConn *libvirt.Libvirt, vol *
 		}
 	}
 
+	// Overlays name the image they are backed by
+	var backingStore *BackingStore
+	var encryption *VolumeEncryption
+	if doc, err := xmlutils.LoadXMLDocumentFromString(xml); err == nil {
+		encryption = parseVolumeEncryption(doc)
+		if backingPath := xmlutils.FindElement(doc, "/volume/backingStore/path"); backingPath != nil {
+			backingStore = &BackingStore{Path: xmlutils.GetElementText(backingPath), Format: "raw"}
+			if backingFormat := xmlutils.FindElement(doc, "/volume/backingStore/format"); backingFormat != nil {
+				backingStore.Format = xmlutils.GetElementAttribute(backingFormat, "type")
+			}
+		}
+	}
+
 	volumeInfo := &StorageVolumeInfo{
-		Name:       vol.Name,
-		Key:        key,
-		Path:       path,
-		Type:       "file", // Default, should be parsed from XML
-		Capacity:   capacity,
-		Allocation: allocation,
-		Format:     format,
-		Pool:       poolName,
+		BackingStore: backingStore,
+		Name:         vol.Name,
+		Key:          key,
+		Path:         path,
+		Type:         "file", // Default, should be parsed from XML
+		Capacity:     capacity,
+		Allocation:   allocation,
+		Physical:     physical,
+		Format:       format,
+		Pool:         poolName,
+		Encryption:   encryption,
 	}
 
 	return volumeInfo, nil
@@ -25,6 +25,7 @@ type MockPoolManager struct {
 	SetAutostartFn func(ctx context.Context, name string, autostart bool) error
 	IsActiveFn     func(ctx context.Context, name string) (bool, error)
 	GetXMLFn       func(ctx context.Context, name string) (string, error)
+	GetUsageFn     func(ctx context.Context, name string) (*PoolUsage, error)
 }
 
 func (m *MockPoolManager) Get(ctx context.Context, name string) (*libvirt.StoragePool, error) {
@@ -111,6 +112,13 @@ func (m *MockPoolManager) GetXML(ctx context.Context, name string) (string, erro
 	return "", nil
 }
 
+func (m *MockPoolManager) GetUsage(ctx context.Context, name string) (*PoolUsage, error) {
+	if m.GetUsageFn != nil {
+		return m.GetUsageFn(ctx, name)
+	}
+	return nil, nil
+}
+
 // MockLibvirtWithVolumes is a mock of libvirt with storage volume operations
 type MockLibvirtWithVolumes struct {
 	libvirt.Libvirt
@@ -150,7 +158,7 @@ func TestLibvirtVolumeManager_Create(t *testing.T) {
 	}
 
 	// Set up volume manager
-	volumeMgr := NewLibvirtVolumeManager(mockConnMgr, mockPoolManager, mockXMLBuilder, mockLog)
+	volumeMgr := NewLibvirtVolumeManager(mockConnMgr, mockPoolManager, mockXMLBuilder, nil, VolumeManagerConfig{}, mockLog)
 
 	// Mock libvirt implementation
 	mockLibvirt := &MockLibvirtWithVolumes{}
@@ -263,7 +271,7 @@ func TestLibvirtVolumeManager_Delete(t *testing.T) {
 	mockXMLBuilder := &MockXMLBuilder{}
 
 	// Set up volume manager
-	volumeMgr := NewLibvirtVolumeManager(mockConnMgr, mockPoolManager, mockXMLBuilder, mockLog)
+	volumeMgr := NewLibvirtVolumeManager(mockConnMgr, mockPoolManager, mockXMLBuilder, nil, VolumeManagerConfig{}, mockLog)
 
 	// Mock libvirt implementation
 	mockLibvirt := &MockLibvirtWithVolumes{}
@@ -351,7 +359,7 @@ func TestLibvirtVolumeManager_GetPath(t *testing.T) {
 	mockXMLBuilder := &MockXMLBuilder{}
 
 	// Set up volume manager
-	volumeMgr := NewLibvirtVolumeManager(mockConnMgr, mockPoolManager, mockXMLBuilder, mockLog)
+	volumeMgr := NewLibvirtVolumeManager(mockConnMgr, mockPoolManager, mockXMLBuilder, nil, VolumeManagerConfig{}, mockLog)
 
 	// Mock libvirt implementation
 	mockLibvirt := &MockLibvirtWithVolumes{}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrVolumeRecordNotFound is returned for volumes without a stored record.
+var ErrVolumeRecordNotFound = fmt.Errorf("volume record not found")
+
+// VolumeRecord holds what libvirt does not keep about a volume: who created
+// it, for what and its labels.
+type VolumeRecord struct {
+	CreatedAt time.Time
+	Labels    map[string]string
+	Metadata  map[string]interface{}
+	Host      string
+	Pool      string
+	Volume    string
+	// Owner is the ID of the user who created the volume
+	Owner   string
+	Purpose string
+	// VM is the VM the volume was created as a disk of
+	VM string
+	// Job is the ID of the job that created the volume
+	Job string
+}
+
+// RecordStore persists volume records.
+type RecordStore interface {
+	// Put creates or replaces the record of a volume
+	Put(ctx context.Context, record *VolumeRecord) error
+	Get(ctx context.Context, host string, pool string, volume string) (*VolumeRecord, error)
+	// List lists the records of the volumes of a pool
+	List(ctx context.Context, host string, pool string) ([]*VolumeRecord, error)
+	Delete(ctx context.Context, host string, pool string, volume string) error
+}
+
+// VolumeOrigin describes who or what creates volumes during a request.
+type VolumeOrigin struct {
+	// Owner is the ID of the user the volumes belong to
+	Owner string
+	// VM is the VM the volumes are disks of
+	VM string
+	// Job is the ID of the job creating the volumes
+	Job string
+}
+
+// volumeOriginContextKey is the context key of the volume origin.
+type volumeOriginContextKey struct{}
+
+// WithVolumeOrigin returns a context in which volumes are created by an
+// origin. Fields that are not set keep the value of the parent context, so
+// a job started by a user still records the user as the owner.
+func WithVolumeOrigin(ctx context.Context, origin VolumeOrigin) context.Context {
+	parent := VolumeOriginFromContext(ctx)
+	if origin.Owner == "" {
+		origin.Owner = parent.Owner
+	}
+	if origin.VM == "" {
+		origin.VM = parent.VM
+	}
+	if origin.Job == "" {
+		origin.Job = parent.Job
+	}
+	return context.WithValue(ctx, volumeOriginContextKey{}, origin)
+}
+
+// VolumeOriginFromContext returns the origin of the volumes created in a
+// context.
+func VolumeOriginFromContext(ctx context.Context) VolumeOrigin {
+	origin, _ := ctx.Value(volumeOriginContextKey{}).(VolumeOrigin)
+	return origin
+}
+
+// gormVolumeRecord is the database model of a volume record.
+type gormVolumeRecord struct {
+	CreatedAt time.Time
+	Labels    map[string]string      `gorm:"serializer:json"`
+	Metadata  map[string]interface{} `gorm:"serializer:json"`
+	Host      string                 `gorm:"primaryKey"`
+	Pool      string                 `gorm:"primaryKey"`
+	Volume    string                 `gorm:"primaryKey"`
+	Owner     string                 `gorm:"index"`
+	Purpose   string
+	VM        string
+	Job       string
+}
+
+// TableName specifies the table name for the gormVolumeRecord model.
+func (gormVolumeRecord) TableName() string {
+	return "volume_records"
+}
+
+// GormRecordStore implements RecordStore using GORM.
+type GormRecordStore struct {
+	db *gorm.DB
+}
+
+// NewGormRecordStore creates a new GormRecordStore.
+func NewGormRecordStore(db *gorm.DB) (*GormRecordStore, error) {
+	// Auto-migrate the schema
+	if err := db.AutoMigrate(&gormVolumeRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate volume record schema: %w", err)
+	}
+
+	return &GormRecordStore{db: db}, nil
+}
+
+// Put implements RecordStore.Put.
+func (s *GormRecordStore) Put(ctx context.Context, record *VolumeRecord) error {
+	model := &gormVolumeRecord{
+		CreatedAt: record.CreatedAt,
+		Labels:    record.Labels,
+		Metadata:  record.Metadata,
+		Host:      record.Host,
+		Pool:      record.Pool,
+		Volume:    record.Volume,
+		Owner:     record.Owner,
+		Purpose:   record.Purpose,
+		VM:        record.VM,
+		Job:       record.Job,
+	}
+
+	if err := s.db.WithContext(ctx).Save(model).Error; err != nil {
+		return fmt.Errorf("failed to store volume record: %w", err)
+	}
+
+	return nil
+}
+
+// Get implements RecordStore.Get.
+func (s *GormRecordStore) Get(ctx context.Context, host string, pool string, volume string) (*VolumeRecord, error) {
+	var model gormVolumeRecord
+	err := s.db.WithContext(ctx).
+		First(&model, "host = ? AND pool = ? AND volume = ?", host, pool, volume).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("volume %s in pool %s: %w", volume, pool, ErrVolumeRecordNotFound)
+		}
+		return nil, fmt.Errorf("failed to get volume record: %w", err)
+	}
+
+	return fromGormVolumeRecord(&model), nil
+}
+
+// List implements RecordStore.List.
+func (s *GormRecordStore) List(ctx context.Context, host string, pool string) ([]*VolumeRecord, error) {
+	var models []gormVolumeRecord
+	err := s.db.WithContext(ctx).
+		Where("host = ? AND pool = ?", host, pool).
+		Order("volume").
+		Find(&models).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume records: %w", err)
+	}
+
+	records := make([]*VolumeRecord, 0, len(models))
+	for i := range models {
+		records = append(records, fromGormVolumeRecord(&models[i]))
+	}
+
+	return records, nil
+}
+
+// Delete implements RecordStore.Delete.
+func (s *GormRecordStore) Delete(ctx context.Context, host string, pool string, volume string) error {
+	result := s.db.WithContext(ctx).
+		Delete(&gormVolumeRecord{}, "host = ? AND pool = ? AND volume = ?", host, pool, volume)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete volume record: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("volume %s in pool %s: %w", volume, pool, ErrVolumeRecordNotFound)
+	}
+
+	return nil
+}
+
+// fromGormVolumeRecord converts a database model to a volume record.
+func fromGormVolumeRecord(model *gormVolumeRecord) *VolumeRecord {
+	return &VolumeRecord{
+		CreatedAt: model.CreatedAt,
+		Labels:    model.Labels,
+		Metadata:  model.Metadata,
+		Host:      model.Host,
+		Pool:      model.Pool,
+		Volume:    model.Volume,
+		Owner:     model.Owner,
+		Purpose:   model.Purpose,
+		VM:        model.VM,
+		Job:       model.Job,
+	}
+}
+
+// VolumeFilter selects volumes by their record. Empty fields match every
+// volume.
+type VolumeFilter struct {
+	// Labels must all be set on a volume with the same values
+	Labels map[string]string
+	Owner  string
+}
+
+// Matches reports whether a volume is selected by the filter.
+func (f VolumeFilter) Matches(info *StorageVolumeInfo) bool {
+	if f.Owner != "" && info.Owner != f.Owner {
+		return false
+	}
+	for key, value := range f.Labels {
+		if label, ok := info.Labels[key]; !ok || label != value {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterVolumes returns the volumes selected by a filter.
+func FilterVolumes(volumes []*StorageVolumeInfo, filter VolumeFilter) []*StorageVolumeInfo {
+	filtered := make([]*StorageVolumeInfo, 0, len(volumes))
+	for _, volume := range volumes {
+		if filter.Matches(volume) {
+			filtered = append(filtered, volume)
+		}
+	}
+	return filtered
+}
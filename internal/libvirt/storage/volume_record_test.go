@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"go.uber.org/mock/gomock"
+)
+
+// newTestRecordStore creates a record store backed by an in-memory database.
+func newTestRecordStore(t *testing.T) *GormRecordStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+
+	store, err := NewGormRecordStore(db)
+	require.NoError(t, err)
+	return store
+}
+
+func (m *fakeVolumeManager) List(_ context.Context, poolName string) ([]*StorageVolumeInfo, error) {
+	var volumes []*StorageVolumeInfo
+	for _, vol := range m.volumes {
+		if vol.info.Pool == poolName {
+			info := *vol.info
+			volumes = append(volumes, &info)
+		}
+	}
+	return volumes, nil
+}
+
+func TestGormRecordStore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestRecordStore(t)
+
+	record := &VolumeRecord{
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Labels:    map[string]string{"env": "dev"},
+		Metadata:  map[string]interface{}{"ticket": "OPS-1"},
+		Host:      "kvm1",
+		Pool:      "default",
+		Volume:    "data.qcow2",
+		Owner:     "user-1",
+		Purpose:   "scratch",
+	}
+	require.NoError(t, store.Put(ctx, record))
+
+	got, err := store.Get(ctx, "kvm1", "default", "data.qcow2")
+	require.NoError(t, err)
+	assert.Equal(t, record.Labels, got.Labels)
+	assert.Equal(t, record.Metadata, got.Metadata)
+	assert.Equal(t, "user-1", got.Owner)
+	assert.Equal(t, "scratch", got.Purpose)
+
+	// Records are keyed by host as well as pool and volume
+	_, err = store.Get(ctx, "kvm2", "default", "data.qcow2")
+	assert.ErrorIs(t, err, ErrVolumeRecordNotFound)
+
+	record.Labels = map[string]string{"env": "prod"}
+	require.NoError(t, store.Put(ctx, record))
+	records, err := store.List(ctx, "kvm1", "default")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "prod", records[0].Labels["env"])
+
+	require.NoError(t, store.Delete(ctx, "kvm1", "default", "data.qcow2"))
+	assert.ErrorIs(t, store.Delete(ctx, "kvm1", "default", "data.qcow2"), ErrVolumeRecordNotFound)
+}
+
+func TestWithVolumeOrigin(t *testing.T) {
+	ctx := WithVolumeOrigin(context.Background(), VolumeOrigin{Owner: "user-1"})
+	ctx = WithVolumeOrigin(ctx, VolumeOrigin{VM: "web", Job: "job-1"})
+
+	assert.Equal(t, VolumeOrigin{Owner: "user-1", VM: "web", Job: "job-1"}, VolumeOriginFromContext(ctx))
+	assert.Equal(t, VolumeOrigin{}, VolumeOriginFromContext(context.Background()))
+}
+
+func TestFilterVolumes(t *testing.T) {
+	volumes := []*StorageVolumeInfo{
+		{Name: "a", Owner: "user-1", Labels: map[string]string{"env": "dev", "team": "web"}},
+		{Name: "b", Owner: "user-2", Labels: map[string]string{"env": "dev"}},
+		{Name: "c"},
+	}
+
+	names := func(volumes []*StorageVolumeInfo) []string {
+		result := make([]string, 0, len(volumes))
+		for _, volume := range volumes {
+			result = append(result, volume.Name)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, names(FilterVolumes(volumes, VolumeFilter{})))
+	assert.Equal(t, []string{"b"}, names(FilterVolumes(volumes, VolumeFilter{Owner: "user-2"})))
+	assert.Equal(t, []string{"a", "b"}, names(FilterVolumes(volumes, VolumeFilter{Labels: map[string]string{"env": "dev"}})))
+	assert.Equal(t, []string{"a"}, names(FilterVolumes(volumes, VolumeFilter{Labels: map[string]string{"env": "dev", "team": "web"}})))
+	assert.Empty(t, FilterVolumes(volumes, VolumeFilter{Owner: "user-1", Labels: map[string]string{"env": "prod"}}))
+}
+
+func TestRecordingVolumeManager(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	volumes := newFakeVolumeManager()
+	store := newTestRecordStore(t)
+	manager := NewRecordingVolumeManager(volumes, store, VolumeRecorderConfig{DefaultHost: "local"}, mockLogger)
+
+	ctx := WithVolumeOrigin(context.Background(), VolumeOrigin{Owner: "user-1", Job: "job-1"})
+	require.NoError(t, manager.CreateWithParams(ctx, "default", &CreateVolumeParams{
+		Name:          "data.qcow2",
+		Format:        "qcow2",
+		CapacityBytes: 1 << 30,
+		Labels:        map[string]string{"env": "dev"},
+		Purpose:       "scratch",
+	}))
+
+	info, err := manager.GetInfo(context.Background(), "default", "data.qcow2")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", info.Owner)
+	assert.Equal(t, "job-1", info.Job)
+	assert.Equal(t, "scratch", info.Purpose)
+	assert.Equal(t, map[string]string{"env": "dev"}, info.Labels)
+
+	// Records of other hosts are not attached
+	info, err = manager.GetInfo(connection.WithHost(context.Background(), "kvm2"), "default", "data.qcow2")
+	require.NoError(t, err)
+	assert.Empty(t, info.Owner)
+
+	// A volume removed behind the manager's back loses its record on list
+	delete(volumes.volumes, "default/data.qcow2")
+	listed, err := manager.List(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+	_, err = store.Get(context.Background(), "local", "default", "data.qcow2")
+	assert.ErrorIs(t, err, ErrVolumeRecordNotFound)
+}
+
+func TestRecordingVolumeManager_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	volumes := newFakeVolumeManager()
+	store := newTestRecordStore(t)
+	manager := NewRecordingVolumeManager(volumes, store, VolumeRecorderConfig{DefaultHost: "local"}, mockLogger)
+
+	ctx := context.Background()
+	require.NoError(t, manager.CreateWithParams(ctx, "default", &CreateVolumeParams{Name: "data.qcow2", CapacityBytes: 1 << 30}))
+	_, err := store.Get(ctx, "local", "default", "data.qcow2")
+	require.NoError(t, err)
+
+	require.NoError(t, manager.Delete(ctx, "default", "data.qcow2"))
+	_, err = store.Get(ctx, "local", "default", "data.qcow2")
+	assert.ErrorIs(t, err, ErrVolumeRecordNotFound)
+
+	assert.ErrorIs(t, manager.Delete(ctx, "default", "data.qcow2"), ErrVolumeNotFound)
+}
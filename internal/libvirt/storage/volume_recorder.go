@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// VolumeRecorderConfig configures a RecordingVolumeManager.
+type VolumeRecorderConfig struct {
+	// DefaultHost names the host used by requests that select none
+	DefaultHost string
+}
+
+// RecordingVolumeManager is a VolumeManager that keeps a record of the
+// volumes it creates. Records are attached to the volumes it returns and
+// dropped once their volume is gone from libvirt.
+type RecordingVolumeManager struct {
+	VolumeManager
+	store  RecordStore
+	logger logger.Logger
+	config VolumeRecorderConfig
+}
+
+// NewRecordingVolumeManager creates a new RecordingVolumeManager on top of
+// a volume manager.
+func NewRecordingVolumeManager(volumes VolumeManager, store RecordStore, config VolumeRecorderConfig, logger logger.Logger) *RecordingVolumeManager {
+	return &RecordingVolumeManager{
+		VolumeManager: volumes,
+		store:         store,
+		logger:        logger,
+		config:        config,
+	}
+}
+
+// Create implements VolumeManager.Create.
+func (m *RecordingVolumeManager) Create(ctx context.Context, poolName string, volName string, capacityBytes uint64, format string) error {
+	if err := m.VolumeManager.Create(ctx, poolName, volName, capacityBytes, format); err != nil {
+		return err
+	}
+	m.record(ctx, poolName, volName, nil)
+	return nil
+}
+
+// CreateWithParams implements VolumeManager.CreateWithParams.
+func (m *RecordingVolumeManager) CreateWithParams(ctx context.Context, poolName string, params *CreateVolumeParams) error {
+	if err := m.VolumeManager.CreateWithParams(ctx, poolName, params); err != nil {
+		return err
+	}
+	m.record(ctx, poolName, params.Name, params)
+	return nil
+}
+
+// CreateFromImage implements VolumeManager.CreateFromImage.
+func (m *RecordingVolumeManager) CreateFromImage(ctx context.Context, poolName string, volName string, imagePath string, format string) error {
+	if err := m.VolumeManager.CreateFromImage(ctx, poolName, volName, imagePath, format); err != nil {
+		return err
+	}
+	m.record(ctx, poolName, volName, nil)
+	return nil
+}
+
+// Clone implements VolumeManager.Clone.
+func (m *RecordingVolumeManager) Clone(ctx context.Context, poolName string, sourceVolName string, destVolName string) error {
+	if err := m.VolumeManager.Clone(ctx, poolName, sourceVolName, destVolName); err != nil {
+		return err
+	}
+	m.record(ctx, poolName, destVolName, nil)
+	return nil
+}
+
+// CloneTo implements VolumeManager.CloneTo.
+func (m *RecordingVolumeManager) CloneTo(ctx context.Context, poolName string, sourceVolName string, destPoolName string, destVolName string, format string) error {
+	if err := m.VolumeManager.CloneTo(ctx, poolName, sourceVolName, destPoolName, destVolName, format); err != nil {
+		return err
+	}
+	m.record(ctx, destPoolName, destVolName, nil)
+	return nil
+}
+
+// CloneLinked implements VolumeManager.CloneLinked.
+func (m *RecordingVolumeManager) CloneLinked(ctx context.Context, poolName string, sourceVolName string, destVolName string) error {
+	if err := m.VolumeManager.CloneLinked(ctx, poolName, sourceVolName, destVolName); err != nil {
+		return err
+	}
+	m.record(ctx, poolName, destVolName, nil)
+	return nil
+}
+
+// Delete implements VolumeManager.Delete.
+func (m *RecordingVolumeManager) Delete(ctx context.Context, poolName string, volName string) error {
+	if err := m.VolumeManager.Delete(ctx, poolName, volName); err != nil {
+		return err
+	}
+
+	err := m.store.Delete(ctx, m.hostOf(ctx), poolName, volName)
+	if err != nil && !errors.Is(err, ErrVolumeRecordNotFound) {
+		// The record is dropped on the next listing of the pool
+		m.logger.Warn("Failed to delete volume record",
+			logger.String("pool", poolName),
+			logger.String("volume", volName),
+			logger.Error(err))
+	}
+	return nil
+}
+
+// List implements VolumeManager.List. Records of volumes that are gone from
+// the pool, such as volumes deleted with virsh, are deleted.
+func (m *RecordingVolumeManager) List(ctx context.Context, poolName string) ([]*StorageVolumeInfo, error) {
+	volumes, err := m.VolumeManager.List(ctx, poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	host := m.hostOf(ctx)
+	records, err := m.store.List(ctx, host, poolName)
+	if err != nil {
+		// Volumes are still listed, only without their records
+		m.logger.Warn("Failed to list volume records",
+			logger.String("pool", poolName),
+			logger.Error(err))
+		return volumes, nil
+	}
+
+	byVolume := make(map[string]*VolumeRecord, len(records))
+	for _, record := range records {
+		byVolume[record.Volume] = record
+	}
+
+	for _, volume := range volumes {
+		if record, ok := byVolume[volume.Name]; ok {
+			applyVolumeRecord(volume, record)
+			delete(byVolume, volume.Name)
+		}
+	}
+
+	// What is left has no volume anymore
+	for name := range byVolume {
+		if err := m.store.Delete(ctx, host, poolName, name); err != nil && !errors.Is(err, ErrVolumeRecordNotFound) {
+			m.logger.Warn("Failed to delete stale volume record",
+				logger.String("pool", poolName),
+				logger.String("volume", name),
+				logger.Error(err))
+			continue
+		}
+		m.logger.Info("Deleted record of missing volume",
+			logger.String("pool", poolName),
+			logger.String("volume", name))
+	}
+
+	return volumes, nil
+}
+
+// GetInfo implements VolumeManager.GetInfo.
+func (m *RecordingVolumeManager) GetInfo(ctx context.Context, poolName string, volName string) (*StorageVolumeInfo, error) {
+	info, err := m.VolumeManager.GetInfo(ctx, poolName, volName)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := m.store.Get(ctx, m.hostOf(ctx), poolName, volName)
+	switch {
+	case err == nil:
+		applyVolumeRecord(info, record)
+	case !errors.Is(err, ErrVolumeRecordNotFound):
+		m.logger.Warn("Failed to get volume record",
+			logger.String("pool", poolName),
+			logger.String("volume", volName),
+			logger.Error(err))
+	}
+
+	return info, nil
+}
+
+// record stores the record of a volume created in a context. The volume
+// exists at this point, so a failure is logged instead of returned.
+func (m *RecordingVolumeManager) record(ctx context.Context, poolName string, volName string, params *CreateVolumeParams) {
+	origin := VolumeOriginFromContext(ctx)
+	record := &VolumeRecord{
+		CreatedAt: time.Now(),
+		Host:      m.hostOf(ctx),
+		Pool:      poolName,
+		Volume:    volName,
+		Owner:     origin.Owner,
+		VM:        origin.VM,
+		Job:       origin.Job,
+	}
+	if params != nil {
+		record.Labels = params.Labels
+		record.Metadata = params.Metadata
+		record.Purpose = params.Purpose
+	}
+
+	if err := m.store.Put(ctx, record); err != nil {
+		m.logger.Warn("Failed to store volume record",
+			logger.String("pool", poolName),
+			logger.String("volume", volName),
+			logger.Error(err))
+	}
+}
+
+// hostOf returns the host a request targets.
+func (m *RecordingVolumeManager) hostOf(ctx context.Context) string {
+	if host, ok := connection.HostFromContext(ctx); ok {
+		return host
+	}
+	return m.config.DefaultHost
+}
+
+// applyVolumeRecord attaches a record to the information of its volume.
+func applyVolumeRecord(info *StorageVolumeInfo, record *VolumeRecord) {
+	info.Labels = record.Labels
+	info.Owner = record.Owner
+	info.Purpose = record.Purpose
+	info.VM = record.VM
+	info.Job = record.Job
+	if len(record.Metadata) > 0 {
+		info.Metadata = record.Metadata
+	}
+}
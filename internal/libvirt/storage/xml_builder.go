@@ -15,15 +15,22 @@ type TemplateXMLBuilder struct {
 
 // PoolTemplate contains data for storage pool XML template.
 type PoolTemplate struct {
-	Name string
-	Path string
+	Source *StoragePoolSource
+	Hosts  []StoragePoolHost
+	Name   string
+	Type   string
+	Path   string
 }
 
 // VolumeTemplate contains data for storage volume XML template.
 type VolumeTemplate struct {
 	Name          string
 	Format        string
-	CapacityBytes uint64
+	BackingStore  string
+	BackingFormat string
+	// EncryptionSecret is the UUID of the secret of a LUKS encrypted volume
+	EncryptionSecret string
+	CapacityBytes    uint64
 }
 
 // NewTemplateXMLBuilder creates a new TemplateXMLBuilder.
@@ -39,6 +46,7 @@ func (b *TemplateXMLBuilder) BuildStoragePoolXML(name string, path string) (stri
 	// Prepare template data
 	templateData := PoolTemplate{
 		Name: name,
+		Type: dirPoolType,
 		Path: path,
 	}
 
@@ -55,6 +63,36 @@ func (b *TemplateXMLBuilder) BuildStoragePoolXML(name string, path string) (stri
 	return poolXML, nil
 }
 
+// BuildStoragePoolXMLWithParams implements XMLBuilder.BuildStoragePoolXMLWithParams.
+func (b *TemplateXMLBuilder) BuildStoragePoolXMLWithParams(params *CreatePoolParams) (string, error) {
+	poolType := params.Type
+	if poolType == "" {
+		poolType = dirPoolType
+	}
+
+	// Prepare template data
+	templateData := PoolTemplate{
+		Name:   params.Name,
+		Type:   poolType,
+		Path:   params.Path,
+		Source: params.Source,
+		Hosts:  poolHosts(params.Source),
+	}
+
+	// Render the template
+	b.logger.Debug("Rendering storage pool XML template",
+		logger.String("pool_name", params.Name),
+		logger.String("type", poolType),
+		logger.String("path", params.Path))
+
+	poolXML, err := b.templateLoader.RenderTemplate("storage_pool.xml.tmpl", templateData)
+	if err != nil {
+		return "", fmt.Errorf("failed to render storage pool XML template: %w", err)
+	}
+
+	return poolXML, nil
+}
+
 // BuildStorageVolumeXML implements XMLBuilder.BuildStorageVolumeXML.
 func (b *TemplateXMLBuilder) BuildStorageVolumeXML(volName string, capacityBytes uint64, format string) (string, error) {
 	// Default format if not specified
@@ -82,3 +120,62 @@ func (b *TemplateXMLBuilder) BuildStorageVolumeXML(volName string, capacityBytes
 
 	return volumeXML, nil
 }
+
+// BuildOverlayVolumeXML implements XMLBuilder.BuildOverlayVolumeXML.
+func (b *TemplateXMLBuilder) BuildOverlayVolumeXML(volName string, capacityBytes uint64, backingPath string, backingFormat string) (string, error) {
+	// Prepare template data
+	templateData := VolumeTemplate{
+		Name:          volName,
+		CapacityBytes: capacityBytes,
+		Format:        "qcow2",
+		BackingStore:  backingPath,
+		BackingFormat: backingFormat,
+	}
+
+	// Render the template
+	b.logger.Debug("Rendering overlay volume XML template",
+		logger.String("volume_name", volName),
+		logger.Uint64("capacity_bytes", capacityBytes),
+		logger.String("backing_store", backingPath),
+		logger.String("backing_format", backingFormat))
+
+	volumeXML, err := b.templateLoader.RenderTemplate("storage_volume.xml.tmpl", templateData)
+	if err != nil {
+		return "", fmt.Errorf("failed to render overlay volume XML template: %w", err)
+	}
+
+	return volumeXML, nil
+}
+
+// BuildEncryptedVolumeXML implements XMLBuilder.BuildEncryptedVolumeXML.
+func (b *TemplateXMLBuilder) BuildEncryptedVolumeXML(params *CreateVolumeParams, secretUUID string) (string, error) {
+	format := params.Format
+	if format == "" {
+		format = "qcow2"
+	}
+
+	// Prepare template data
+	templateData := VolumeTemplate{
+		Name:             params.Name,
+		CapacityBytes:    params.CapacityBytes,
+		Format:           format,
+		BackingStore:     params.BackingStore,
+		BackingFormat:    params.BackingFormat,
+		EncryptionSecret: secretUUID,
+	}
+
+	// Render the template
+	b.logger.Debug("Rendering encrypted volume XML template",
+		logger.String("volume_name", params.Name),
+		logger.Uint64("capacity_bytes", params.CapacityBytes),
+		logger.String("format", format),
+		logger.String("backing_store", params.BackingStore),
+		logger.String("secret_uuid", secretUUID))
+
+	volumeXML, err := b.templateLoader.RenderTemplate("storage_volume.xml.tmpl", templateData)
+	if err != nil {
+		return "", fmt.Errorf("failed to render encrypted volume XML template: %w", err)
+	}
+
+	return volumeXML, nil
+}
@@ -186,3 +186,75 @@ func TestTemplateXMLBuilder_BuildStorageVolumeXML(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateXMLBuilder_BuildOverlayVolumeXML(t *testing.T) {
+	// Use the shipped template, which must render the backing store
+	templateLoader, err := xmlutils.NewTemplateLoader(filepath.Join("..", "..", "..", "configs", "templates", "storage"))
+	if err != nil {
+		t.Fatalf("Failed to create template loader: %v", err)
+	}
+
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+	builder := NewTemplateXMLBuilder(templateLoader, mockLog)
+
+	xml, err := builder.BuildOverlayVolumeXML("web-disk-0.qcow2", 20<<30, "/var/lib/libvirt/images/ubuntu.qcow2", "qcow2")
+	if err != nil {
+		t.Fatalf("BuildOverlayVolumeXML failed: %v", err)
+	}
+
+	doc, err := xmlutils.LoadXMLDocumentFromString(xml)
+	if err != nil {
+		t.Fatalf("Overlay volume XML is invalid: %v", err)
+	}
+	assert.Equal(t, "qcow2", xmlutils.GetElementAttribute(xmlutils.FindElement(doc, "/volume/target/format"), "type"))
+	assert.Equal(t, "/var/lib/libvirt/images/ubuntu.qcow2", xmlutils.FindElement(doc, "/volume/backingStore/path").Text())
+	assert.Equal(t, "qcow2", xmlutils.GetElementAttribute(xmlutils.FindElement(doc, "/volume/backingStore/format"), "type"))
+
+	// Plain volumes have no backing store
+	xml, err = builder.BuildStorageVolumeXML("data.qcow2", 1<<30, "qcow2")
+	if err != nil {
+		t.Fatalf("BuildStorageVolumeXML failed: %v", err)
+	}
+	assert.NotContains(t, xml, "backingStore")
+}
+
+func TestTemplateXMLBuilder_BuildEncryptedVolumeXML(t *testing.T) {
+	templateLoader, err := xmlutils.NewTemplateLoader(filepath.Join("..", "..", "..", "configs", "templates", "storage"))
+	if err != nil {
+		t.Fatalf("Failed to create template loader: %v", err)
+	}
+
+	mockLog := new(mockLogger)
+	mockLog.On("Debug", mock.Anything, mock.Anything).Return()
+	builder := NewTemplateXMLBuilder(templateLoader, mockLog)
+
+	secretUUID := "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93"
+	xml, err := builder.BuildEncryptedVolumeXML(&CreateVolumeParams{
+		Name:          "web-disk-0",
+		CapacityBytes: 10 << 30,
+		BackingStore:  "/var/lib/libvirt/images/ubuntu.qcow2",
+		BackingFormat: "qcow2",
+	}, secretUUID)
+	if err != nil {
+		t.Fatalf("BuildEncryptedVolumeXML failed: %v", err)
+	}
+
+	doc, err := xmlutils.LoadXMLDocumentFromString(xml)
+	if err != nil {
+		t.Fatalf("Encrypted volume XML is invalid: %v", err)
+	}
+	assert.Equal(t, "qcow2", xmlutils.GetElementAttribute(xmlutils.FindElement(doc, "/volume/target/format"), "type"))
+	assert.Equal(t, "luks", xmlutils.GetElementAttribute(xmlutils.FindElement(doc, "/volume/target/encryption"), "format"))
+	secret := xmlutils.FindElement(doc, "/volume/target/encryption/secret")
+	assert.Equal(t, "passphrase", xmlutils.GetElementAttribute(secret, "type"))
+	assert.Equal(t, secretUUID, xmlutils.GetElementAttribute(secret, "uuid"))
+	assert.Equal(t, "/var/lib/libvirt/images/ubuntu.qcow2", xmlutils.FindElement(doc, "/volume/backingStore/path").Text())
+
+	// Plain volumes are not encrypted
+	xml, err = builder.BuildStorageVolumeXML("data.qcow2", 1<<30, "qcow2")
+	if err != nil {
+		t.Fatalf("BuildStorageVolumeXML failed: %v", err)
+	}
+	assert.NotContains(t, xml, "encryption")
+}
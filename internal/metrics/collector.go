@@ -23,6 +23,13 @@ type Collector interface {
 
 	// RecordLibvirtOperation records a libvirt operation latency
 	RecordLibvirtOperation(operation string, duration time.Duration)
+
+	// RecordStoragePoolUsage records the sizes of a storage pool
+	RecordStoragePoolUsage(host, pool string, capacity, allocation, virtualSize uint64)
+
+	// RecordStoragePoolAlert records whether a storage pool is above the
+	// usage threshold
+	RecordStoragePoolAlert(host, pool string, exceeded bool)
 }
 
 // NewCollector creates a new metrics collector.
@@ -69,3 +76,10 @@ func (n *NoopCollector) RecordLibvirtError(operation string, errorType string) {
 
 // RecordLibvirtOperation is a no-op implementation.
 func (n *NoopCollector) RecordLibvirtOperation(operation string, duration time.Duration) {}
+
+// RecordStoragePoolUsage is a no-op implementation.
+func (n *NoopCollector) RecordStoragePoolUsage(host, pool string, capacity, allocation, virtualSize uint64) {
+}
+
+// RecordStoragePoolAlert is a no-op implementation.
+func (n *NoopCollector) RecordStoragePoolAlert(host, pool string, exceeded bool) {}
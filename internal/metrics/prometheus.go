@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -27,6 +28,17 @@ type PrometheusMetrics struct {
 	libvirtErrors  *prometheus.CounterVec
 	libvirtLatency *prometheus.HistogramVec
 
+	// Storage pool metrics
+	poolCapacity    *prometheus.GaugeVec
+	poolAllocation  *prometheus.GaugeVec
+	poolVirtualSize *prometheus.GaugeVec
+	poolUsage       *prometheus.GaugeVec
+	poolOvercommit  *prometheus.GaugeVec
+	poolAlert       *prometheus.GaugeVec
+	poolAlerts      *prometheus.CounterVec
+	poolAlerting    map[string]bool
+	poolAlertMu     sync.Mutex
+
 	// Dependencies
 	vmManager     interface{}
 	exportManager interface{}
@@ -39,6 +51,7 @@ func NewPrometheusMetrics(vmManager interface{}, exportManager interface{}, logg
 		vmManager:     vmManager,
 		exportManager: exportManager,
 		logger:        logger,
+		poolAlerting:  make(map[string]bool),
 	}
 
 	// Initialize request metrics
@@ -122,6 +135,64 @@ func NewPrometheusMetrics(vmManager interface{}, exportManager interface{}, logg
 		[]string{"operation"},
 	)
 
+	// Initialize storage pool metrics
+	poolLabels := []string{"host", "pool"}
+	m.poolCapacity = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_pool_capacity_bytes",
+			Help: "Physical capacity of storage pools in bytes",
+		},
+		poolLabels,
+	)
+
+	m.poolAllocation = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_pool_allocation_bytes",
+			Help: "Physical allocation of storage pools in bytes",
+		},
+		poolLabels,
+	)
+
+	m.poolVirtualSize = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_pool_virtual_bytes",
+			Help: "Sum of the virtual sizes of the volumes of storage pools in bytes",
+		},
+		poolLabels,
+	)
+
+	m.poolUsage = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_pool_usage_ratio",
+			Help: "Allocation of storage pools as a fraction of their capacity",
+		},
+		poolLabels,
+	)
+
+	m.poolOvercommit = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_pool_overcommit_ratio",
+			Help: "Virtual size of the volumes of storage pools divided by their capacity",
+		},
+		poolLabels,
+	)
+
+	m.poolAlert = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "storage_pool_threshold_exceeded",
+			Help: "Whether storage pools are at or above the disk usage threshold",
+		},
+		poolLabels,
+	)
+
+	m.poolAlerts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "storage_pool_threshold_alerts_total",
+			Help: "Total number of times storage pools crossed the disk usage threshold",
+		},
+		poolLabels,
+	)
+
 	return m
 }
 
@@ -168,3 +239,37 @@ func (m *PrometheusMetrics) RecordLibvirtOperation(operation string, duration ti
 		"operation": operation,
 	}).Observe(duration.Seconds())
 }
+
+// RecordStoragePoolUsage records the sizes of a storage pool.
+func (m *PrometheusMetrics) RecordStoragePoolUsage(host, pool string, capacity, allocation, virtualSize uint64) {
+	labels := prometheus.Labels{"host": host, "pool": pool}
+	m.poolCapacity.With(labels).Set(float64(capacity))
+	m.poolAllocation.With(labels).Set(float64(allocation))
+	m.poolVirtualSize.With(labels).Set(float64(virtualSize))
+
+	if capacity > 0 {
+		m.poolUsage.With(labels).Set(float64(allocation) / float64(capacity))
+		m.poolOvercommit.With(labels).Set(float64(virtualSize) / float64(capacity))
+	}
+}
+
+// RecordStoragePoolAlert records whether a storage pool is above the usage
+// threshold, counting each crossing.
+func (m *PrometheusMetrics) RecordStoragePoolAlert(host, pool string, exceeded bool) {
+	labels := prometheus.Labels{"host": host, "pool": pool}
+	key := host + "/" + pool
+
+	m.poolAlertMu.Lock()
+	defer m.poolAlertMu.Unlock()
+
+	if !exceeded {
+		delete(m.poolAlerting, key)
+		m.poolAlert.With(labels).Set(0)
+		return
+	}
+	if !m.poolAlerting[key] {
+		m.poolAlerting[key] = true
+		m.poolAlerts.With(labels).Inc()
+	}
+	m.poolAlert.With(labels).Set(1)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+)
+
+// HostHeader is the request header that selects a libvirt host.
+const HostHeader = "X-Libvirt-Host"
+
+// HostSelectorGinMiddleware selects the libvirt host for a request from the
+// "host" query parameter or the X-Libvirt-Host header. Requests without a
+// selector use the default host.
+func HostSelectorGinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		host := c.Query("host")
+		if host == "" {
+			host = c.GetHeader(HostHeader)
+		}
+
+		if host != "" {
+			c.Request = c.Request.WithContext(connection.WithHost(c.Request.Context(), host))
+		}
+
+		c.Next()
+	}
+}
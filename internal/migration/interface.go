@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"context"
+	"time"
+)
+
+// Params represents migration parameters.
+type Params struct {
+	// TargetURI is the libvirt URI of the destination host, e.g. qemu+tcp://host-b/system
+	TargetURI string `json:"targetUri" binding:"required"`
+	// Bandwidth limits the migration bandwidth in MiB/s, zero is unlimited
+	Bandwidth uint64 `json:"bandwidth,omitempty"`
+	// Timeout aborts the migration after the given number of seconds, zero waits indefinitely
+	Timeout int `json:"timeout,omitempty"`
+	// Live keeps the VM running while its memory is copied
+	Live bool `json:"live,omitempty"`
+	// Offline migrates only the definition of a stopped VM
+	Offline bool `json:"offline,omitempty"`
+	// Persistent defines the VM on the destination
+	Persistent bool `json:"persistent,omitempty"`
+	// Undefine removes the VM definition from the source after migration
+	Undefine bool `json:"undefine,omitempty"`
+	// Compressed compresses memory pages sent to the destination
+	Compressed bool `json:"compressed,omitempty"`
+	// CopyStorage copies all disks to a destination without shared storage
+	CopyStorage bool `json:"copyStorage,omitempty"`
+	// PostCopy allows switching to post-copy once the memory has been copied once
+	PostCopy bool `json:"postCopy,omitempty"`
+}
+
+// Status represents migration job status.
+type Status string
+
+// Job status constants.
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusPostCopy  Status = "post-copy"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// Job represents a migration job.
+type Job struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+	Params    Params    `json:"params"`
+	ID        string    `json:"id"`
+	VMName    string    `json:"vmName"`
+	// Host is the libvirt host the VM is migrated from
+	Host   string `json:"host,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Status Status `json:"status"`
+	// DataTotal is the amount of memory and disk data to transfer, in bytes
+	DataTotal uint64 `json:"dataTotal"`
+	// DataProcessed is the amount of data transferred so far, in bytes
+	DataProcessed uint64 `json:"dataProcessed"`
+	// DataRemaining is the amount of data left to transfer, in bytes
+	DataRemaining uint64 `json:"dataRemaining"`
+	Progress      int    `json:"progress"`
+}
+
+// Manager defines interface for migration management.
+type Manager interface {
+	// CreateMigrationJob checks the destination and starts migrating a VM
+	CreateMigrationJob(ctx context.Context, vmName string, params Params) (*Job, error)
+
+	// GetJob gets a migration job by ID
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+
+	// ListJobs lists all migration jobs
+	ListJobs(ctx context.Context) ([]*Job, error)
+
+	// CancelJob aborts a running migration
+	CancelJob(ctx context.Context, jobID string) error
+
+	// StartPostCopy switches a running migration to post-copy mode
+	StartPostCopy(ctx context.Context, jobID string) error
+}
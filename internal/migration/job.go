@@ -0,0 +1,106 @@
+package migration
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/jobs"
+)
+
+// jobStore provides thread-safe storage for migration jobs.
+type jobStore struct {
+	*jobs.Store[Job]
+}
+
+// newJobStore creates a new job store.
+func newJobStore() *jobStore {
+	return &jobStore{Store: jobs.NewStore[Job]()}
+}
+
+// createJob creates a new migration job.
+func (s *jobStore) createJob(vmName string, host string, params Params) *Job {
+	id := uuid.New().String()
+	job, _ := s.Add(id, &Job{
+		ID:        id,
+		VMName:    vmName,
+		Host:      host,
+		Status:    StatusPending,
+		Progress:  0,
+		StartTime: time.Now(),
+		Params:    params,
+	}, nil)
+
+	return job
+}
+
+// activeJob returns the ID of an unfinished job for a VM on a host.
+func (s *jobStore) activeJob(vmName string, host string) (string, bool) {
+	return s.Find(func(job *Job) bool {
+		return job.VMName == vmName && job.Host == host && !job.Status.isFinal()
+	})
+}
+
+// updateJobProgress records the transfer progress of a running job.
+func (s *jobStore) updateJobProgress(id string, dataTotal, dataProcessed, dataRemaining uint64) bool {
+	return s.Update(id, func(job *Job) {
+		job.DataTotal = dataTotal
+		job.DataProcessed = dataProcessed
+		job.DataRemaining = dataRemaining
+		// Memory pages dirtied during the copy are sent again, so progress
+		// is based on what remains rather than on what has been processed
+		if dataTotal > 0 && dataRemaining <= dataTotal {
+			job.Progress = int((dataTotal - dataRemaining) * 100 / dataTotal) //nolint:gosec
+		}
+	})
+}
+
+// setPostCopy moves a running job to post-copy. Jobs that left the running
+// state meanwhile, such as migrations that completed, keep their status,
+// which is returned.
+func (s *jobStore) setPostCopy(id string) (Status, bool) {
+	var status Status
+	switched := false
+	s.Update(id, func(job *Job) {
+		if job.Status == StatusRunning {
+			job.Status = StatusPostCopy
+			switched = true
+		}
+		status = job.Status
+	})
+
+	return status, switched
+}
+
+// updateJobStatus updates a job's status. Jobs that already finished keep
+// their final status.
+func (s *jobStore) updateJobStatus(id string, status Status, err error) bool {
+	updated := false
+	s.Update(id, func(job *Job) {
+		if job.Status.isFinal() {
+			return
+		}
+		updated = true
+
+		job.Status = status
+		if err != nil {
+			job.Error = err.Error()
+		}
+		if status == StatusCompleted {
+			job.Progress = 100
+		}
+		if status.isFinal() {
+			job.EndTime = time.Now()
+		}
+	})
+
+	if updated && status.isFinal() {
+		s.Release(id)
+	}
+
+	return updated
+}
+
+// isFinal reports whether a job in this status has finished.
+func (s Status) isFinal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCanceled
+}
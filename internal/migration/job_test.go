@@ -0,0 +1,72 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobStore_Lifecycle(t *testing.T) {
+	store := newJobStore()
+
+	job := store.createJob("test-vm", "", Params{TargetURI: "qemu+tcp://host-b/system", Live: true})
+	assert.Equal(t, StatusPending, job.Status)
+
+	id, active := store.activeJob("test-vm", "")
+	assert.True(t, active)
+	assert.Equal(t, job.ID, id)
+
+	require.True(t, store.updateJobStatus(job.ID, StatusRunning, nil))
+	require.True(t, store.updateJobProgress(job.ID, 1000, 600, 250))
+
+	got, ok := store.Get(job.ID)
+	require.True(t, ok)
+	assert.Equal(t, StatusRunning, got.Status)
+	assert.Equal(t, 75, got.Progress)
+
+	// Re-sent memory pages can leave more remaining than the total
+	require.True(t, store.updateJobProgress(job.ID, 1000, 1200, 1100))
+	got, _ = store.Get(job.ID)
+	assert.Equal(t, 75, got.Progress)
+
+	require.True(t, store.updateJobStatus(job.ID, StatusFailed, errors.New("connection lost")))
+	got, _ = store.Get(job.ID)
+	assert.Equal(t, StatusFailed, got.Status)
+	assert.Equal(t, "connection lost", got.Error)
+	assert.False(t, got.EndTime.IsZero())
+
+	// Finished jobs keep their final status
+	assert.False(t, store.updateJobStatus(job.ID, StatusCompleted, nil))
+
+	_, active = store.activeJob("test-vm", "")
+	assert.False(t, active)
+}
+
+func TestJobStore_SetPostCopy(t *testing.T) {
+	store := newJobStore()
+	job := store.createJob("test-vm", "", Params{Live: true, PostCopy: true})
+
+	// Only running jobs switch to post-copy
+	status, ok := store.setPostCopy(job.ID)
+	assert.False(t, ok)
+	assert.Equal(t, StatusPending, status)
+
+	require.True(t, store.updateJobStatus(job.ID, StatusRunning, nil))
+	status, ok = store.setPostCopy(job.ID)
+	assert.True(t, ok)
+	assert.Equal(t, StatusPostCopy, status)
+
+	// A migration that completed keeps its status
+	require.True(t, store.updateJobStatus(job.ID, StatusCompleted, nil))
+	status, ok = store.setPostCopy(job.ID)
+	assert.False(t, ok)
+	assert.Equal(t, StatusCompleted, status)
+
+	got, _ := store.Get(job.ID)
+	assert.Equal(t, StatusCompleted, got.Status)
+
+	_, ok = store.setPostCopy("missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,214 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// MigrationManager implements Manager.
+type MigrationManager struct {
+	jobStore      *jobStore
+	domainManager domain.Manager
+	volumeManager storage.VolumeManager
+	connect       TargetConnector
+	logger        logger.Logger
+}
+
+// NewMigrationManager creates a new MigrationManager.
+func NewMigrationManager(
+	domainManager domain.Manager,
+	volumeManager storage.VolumeManager,
+	connect TargetConnector,
+	logger logger.Logger,
+) *MigrationManager {
+	return &MigrationManager{
+		jobStore:      newJobStore(),
+		domainManager: domainManager,
+		volumeManager: volumeManager,
+		connect:       connect,
+		logger:        logger,
+	}
+}
+
+// CreateMigrationJob implements Manager.CreateMigrationJob.
+func (m *MigrationManager) CreateMigrationJob(ctx context.Context, vmName string, params Params) (*Job, error) {
+	if err := validateParams(params); err != nil {
+		return nil, err
+	}
+
+	vm, err := m.domainManager.Get(ctx, vmName)
+	if err != nil {
+		return nil, fmt.Errorf("VM not found: %w", err)
+	}
+
+	running := vm.Status == vmmodels.VMStatusRunning
+	if params.Offline && running {
+		return nil, fmt.Errorf("%w: offline migration requires a stopped VM", errors.ErrInvalidParameter)
+	}
+	if !params.Offline && !running {
+		return nil, fmt.Errorf("%w: VM %s is not running, use offline migration", errors.ErrInvalidParameter, vmName)
+	}
+
+	host, _ := connection.HostFromContext(ctx)
+	if jobID, active := m.jobStore.activeJob(vmName, host); active {
+		return nil, fmt.Errorf("%w: job %s", errors.ErrMigrationInProgress, jobID)
+	}
+
+	target, err := m.connect(ctx, params.TargetURI)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrMigrationPreflight, err)
+	}
+	defer m.closeTarget(target)
+
+	if err := m.preflight(ctx, vm, params, target); err != nil {
+		return nil, err
+	}
+
+	job := m.jobStore.createJob(vmName, host, params)
+
+	// The migration outlives the request that started it but keeps its
+	// values, such as the selected libvirt host
+	parent := context.WithoutCancel(ctx)
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if params.Timeout > 0 {
+		jobCtx, cancel = context.WithTimeout(parent, time.Duration(params.Timeout)*time.Second)
+	} else {
+		jobCtx, cancel = context.WithCancel(parent)
+	}
+	m.jobStore.SetCancel(job.ID, cancel)
+
+	go m.processMigrationJob(jobCtx, cancel, job.ID, vmName, params)
+
+	return job, nil
+}
+
+// GetJob implements Manager.GetJob.
+func (m *MigrationManager) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	job, exists := m.jobStore.Get(jobID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrMigrationJobNotFound, jobID)
+	}
+	return job, nil
+}
+
+// ListJobs implements Manager.ListJobs.
+func (m *MigrationManager) ListJobs(ctx context.Context) ([]*Job, error) {
+	return m.jobStore.List(), nil
+}
+
+// CancelJob implements Manager.CancelJob.
+func (m *MigrationManager) CancelJob(ctx context.Context, jobID string) error {
+	job, exists := m.jobStore.Get(jobID)
+	if !exists {
+		return fmt.Errorf("%w: %s", errors.ErrMigrationJobNotFound, jobID)
+	}
+
+	if job.Status.isFinal() {
+		return fmt.Errorf("%w: cannot cancel job in %s state", errors.ErrMigrationInvalidState, job.Status)
+	}
+
+	// Once switched to post-copy the VM runs on the destination and the
+	// migration can no longer be rolled back
+	if job.Status == StatusPostCopy {
+		return fmt.Errorf("%w: cannot cancel job in %s state", errors.ErrMigrationInvalidState, job.Status)
+	}
+
+	m.jobStore.Cancel(jobID)
+
+	m.logger.Info("Migration job canceled",
+		logger.String("job_id", jobID),
+		logger.String("vm", job.VMName))
+
+	return nil
+}
+
+// StartPostCopy implements Manager.StartPostCopy.
+func (m *MigrationManager) StartPostCopy(ctx context.Context, jobID string) error {
+	job, exists := m.jobStore.Get(jobID)
+	if !exists {
+		return fmt.Errorf("%w: %s", errors.ErrMigrationJobNotFound, jobID)
+	}
+
+	if !job.Params.PostCopy {
+		return fmt.Errorf("%w: job was not started with post-copy enabled", errors.ErrMigrationInvalidState)
+	}
+	if job.Status != StatusRunning {
+		return fmt.Errorf("%w: cannot switch job in %s state to post-copy", errors.ErrMigrationInvalidState, job.Status)
+	}
+
+	// The switch must reach the host the migration runs on
+	if job.Host != "" {
+		ctx = connection.WithHost(ctx, job.Host)
+	}
+
+	if err := m.domainManager.StartPostCopy(ctx, job.VMName); err != nil {
+		return fmt.Errorf("failed to switch to post-copy: %w", err)
+	}
+
+	// The migration may have finished while the switch was requested
+	if status, ok := m.jobStore.setPostCopy(jobID); !ok {
+		return fmt.Errorf("%w: job reached %s state before switching to post-copy", errors.ErrMigrationInvalidState, status)
+	}
+	return nil
+}
+
+// processMigrationJob runs a migration in the background.
+func (m *MigrationManager) processMigrationJob(ctx context.Context, cancel context.CancelFunc, jobID string, vmName string, params Params) {
+	defer cancel()
+
+	m.jobStore.updateJobStatus(jobID, StatusRunning, nil)
+
+	m.logger.Info("Starting migration job",
+		logger.String("job_id", jobID),
+		logger.String("vm", vmName),
+		logger.String("target", params.TargetURI))
+
+	err := m.domainManager.Migrate(ctx, vmName, domain.MigrateOptions{
+		TargetURI:   params.TargetURI,
+		Bandwidth:   params.Bandwidth,
+		Live:        params.Live,
+		Offline:     params.Offline,
+		Persistent:  params.Persistent,
+		Undefine:    params.Undefine,
+		Compressed:  params.Compressed,
+		CopyStorage: params.CopyStorage,
+		PostCopy:    params.PostCopy,
+	}, func(progress domain.MigrationProgress) {
+		m.jobStore.updateJobProgress(jobID, progress.DataTotal, progress.DataProcessed, progress.DataRemaining)
+	})
+
+	switch {
+	case err == nil:
+		m.jobStore.updateJobStatus(jobID, StatusCompleted, nil)
+		m.logger.Info("Migration job completed",
+			logger.String("job_id", jobID),
+			logger.String("vm", vmName))
+	case ctx.Err() == context.Canceled:
+		m.jobStore.updateJobStatus(jobID, StatusCanceled, nil)
+	default:
+		m.jobStore.updateJobStatus(jobID, StatusFailed, err)
+		m.logger.Error("Migration job failed",
+			logger.String("job_id", jobID),
+			logger.String("vm", vmName),
+			logger.Error(err))
+	}
+}
+
+// closeTarget releases the connection to a destination host.
+func (m *MigrationManager) closeTarget(target *Target) {
+	if target.Close == nil {
+		return
+	}
+	if err := target.Close(); err != nil {
+		m.logger.Warn("Failed to close target connection", logger.Error(err))
+	}
+}
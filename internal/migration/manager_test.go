@@ -0,0 +1,268 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	customErrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_network "github.com/threatflux/libgo/test/mocks/libvirt/network"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+const testTargetURI = "qemu+tcp://host-b/system"
+
+// migrationTestEnv holds the mocks used by the migration manager tests.
+type migrationTestEnv struct {
+	manager      *MigrationManager
+	source       *mocks_domain.MockManager
+	volumes      *mocks_storage.MockVolumeManager
+	targetDomain *mocks_domain.MockManager
+	targetPools  *mocks_storage.MockPoolManager
+	targetNets   *mocks_network.MockManager
+}
+
+func newMigrationTestEnv(t *testing.T) *migrationTestEnv {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := testutil.NewLogger(ctrl)
+
+	env := &migrationTestEnv{
+		source:       mocks_domain.NewMockManager(ctrl),
+		volumes:      mocks_storage.NewMockVolumeManager(ctrl),
+		targetDomain: mocks_domain.NewMockManager(ctrl),
+		targetPools:  mocks_storage.NewMockPoolManager(ctrl),
+		targetNets:   mocks_network.NewMockManager(ctrl),
+	}
+
+	connect := func(_ context.Context, uri string) (*Target, error) {
+		assert.Equal(t, testTargetURI, uri)
+		return &Target{Domain: env.targetDomain, Pools: env.targetPools, Networks: env.targetNets}, nil
+	}
+	env.manager = NewMigrationManager(env.source, env.volumes, connect, mockLogger)
+
+	return env
+}
+
+func testVM() *vm.VM {
+	return &vm.VM{
+		Name:   "test-vm",
+		Status: vm.VMStatusRunning,
+		Memory: vm.MemoryInfo{SizeBytes: 2 << 30},
+		Disks: []vm.DiskInfo{
+			{Device: "vda", StoragePool: "default", VolumeName: "test-vm-disk-0"},
+		},
+		Networks: []vm.NetInfo{
+			{Type: vm.NetworkTypeNetwork, Source: "default"},
+		},
+	}
+}
+
+// expectPreflight sets up a destination that passes all pre-flight checks.
+func (e *migrationTestEnv) expectPreflight(copyStorage bool) {
+	e.source.EXPECT().GetMigratableCPU(gomock.Any(), "test-vm").Return("<cpu/>", nil)
+	e.targetDomain.EXPECT().CompareCPU(gomock.Any(), "<cpu/>").Return(true, nil)
+	e.targetDomain.EXPECT().GetFreeMemory(gomock.Any()).Return(uint64(8<<30), nil)
+	e.targetNets.EXPECT().Get(gomock.Any(), "default").Return(&libvirt.Network{Name: "default"}, nil)
+	e.targetPools.EXPECT().GetInfo(gomock.Any(), "default").Return(&storage.StoragePoolInfo{Name: "default", Available: 100 << 30}, nil)
+	if copyStorage {
+		e.volumes.EXPECT().GetInfo(gomock.Any(), "default", "test-vm-disk-0").
+			Return(&storage.StorageVolumeInfo{Capacity: 20 << 30}, nil)
+	}
+}
+
+func waitForStatus(t *testing.T, m *MigrationManager, jobID string, status Status) *Job {
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.GetJob(context.Background(), jobID)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 2*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestMigrationManager_CreateMigrationJob(t *testing.T) {
+	env := newMigrationTestEnv(t)
+	env.source.EXPECT().Get(gomock.Any(), "test-vm").Return(testVM(), nil)
+	env.expectPreflight(true)
+	env.source.EXPECT().Migrate(gomock.Any(), "test-vm", domain.MigrateOptions{
+		TargetURI:   testTargetURI,
+		Live:        true,
+		Persistent:  true,
+		CopyStorage: true,
+	}, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, _ domain.MigrateOptions, progress func(domain.MigrationProgress)) error {
+			progress(domain.MigrationProgress{DataTotal: 100, DataProcessed: 50, DataRemaining: 50})
+			return nil
+		})
+
+	job, err := env.manager.CreateMigrationJob(context.Background(), "test-vm", Params{
+		TargetURI:   testTargetURI,
+		Live:        true,
+		Persistent:  true,
+		CopyStorage: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "test-vm", job.VMName)
+
+	job = waitForStatus(t, env.manager, job.ID, StatusCompleted)
+	assert.Equal(t, 100, job.Progress)
+	assert.Equal(t, uint64(100), job.DataTotal)
+}
+
+func TestMigrationManager_PreflightFailures(t *testing.T) {
+	env := newMigrationTestEnv(t)
+	env.source.EXPECT().Get(gomock.Any(), "test-vm").Return(testVM(), nil)
+	env.source.EXPECT().GetMigratableCPU(gomock.Any(), "test-vm").Return("<cpu/>", nil)
+	env.targetDomain.EXPECT().CompareCPU(gomock.Any(), "<cpu/>").Return(false, nil)
+	env.targetDomain.EXPECT().GetFreeMemory(gomock.Any()).Return(uint64(1<<30), nil)
+	env.targetNets.EXPECT().Get(gomock.Any(), "default").Return(nil, errors.New("network not found"))
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", "test-vm-disk-0").
+		Return(&storage.StorageVolumeInfo{Capacity: 20 << 30}, nil)
+	env.targetPools.EXPECT().GetInfo(gomock.Any(), "default").Return(&storage.StoragePoolInfo{Name: "default", Available: 10 << 30}, nil)
+
+	_, err := env.manager.CreateMigrationJob(context.Background(), "test-vm", Params{
+		TargetURI:   testTargetURI,
+		Live:        true,
+		CopyStorage: true,
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, customErrors.ErrMigrationPreflight)
+	assert.Contains(t, err.Error(), "CPU")
+	assert.Contains(t, err.Error(), "free memory")
+	assert.Contains(t, err.Error(), "network default")
+	assert.Contains(t, err.Error(), "storage pool default has")
+
+	jobs, err := env.manager.ListJobs(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestMigrationManager_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+	}{
+		{name: "Missing target", params: Params{Live: true}},
+		{name: "Live and offline", params: Params{TargetURI: testTargetURI, Live: true, Offline: true, Persistent: true}},
+		{name: "Offline without persistent", params: Params{TargetURI: testTargetURI, Offline: true}},
+		{name: "Offline with storage copy", params: Params{TargetURI: testTargetURI, Offline: true, Persistent: true, CopyStorage: true}},
+		{name: "Post-copy without live", params: Params{TargetURI: testTargetURI, PostCopy: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newMigrationTestEnv(t)
+
+			_, err := env.manager.CreateMigrationJob(context.Background(), "test-vm", tt.params)
+			assert.ErrorIs(t, err, customErrors.ErrInvalidParameter)
+		})
+	}
+}
+
+func TestMigrationManager_CancelAndPostCopy(t *testing.T) {
+	env := newMigrationTestEnv(t)
+	env.source.EXPECT().Get(gomock.Any(), "test-vm").Return(testVM(), nil)
+	env.expectPreflight(false)
+	env.source.EXPECT().Migrate(gomock.Any(), "test-vm", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, _ domain.MigrateOptions, _ func(domain.MigrationProgress)) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	env.source.EXPECT().StartPostCopy(gomock.Any(), "test-vm").Return(nil)
+
+	params := Params{TargetURI: testTargetURI, Live: true, PostCopy: true}
+	job, err := env.manager.CreateMigrationJob(context.Background(), "test-vm", params)
+	require.NoError(t, err)
+
+	// Only one migration per VM at a time
+	env.source.EXPECT().Get(gomock.Any(), "test-vm").Return(testVM(), nil)
+	_, err = env.manager.CreateMigrationJob(context.Background(), "test-vm", params)
+	assert.ErrorIs(t, err, customErrors.ErrMigrationInProgress)
+
+	waitForStatus(t, env.manager, job.ID, StatusRunning)
+	require.NoError(t, env.manager.StartPostCopy(context.Background(), job.ID))
+
+	// A migration switched to post-copy cannot be rolled back
+	err = env.manager.CancelJob(context.Background(), job.ID)
+	assert.ErrorIs(t, err, customErrors.ErrMigrationInvalidState)
+
+	// Force the running migration to end and check cancellation of a
+	// finished job
+	env.manager.jobStore.Cancel(job.ID)
+	waitForStatus(t, env.manager, job.ID, StatusCanceled)
+
+	err = env.manager.CancelJob(context.Background(), job.ID)
+	assert.ErrorIs(t, err, customErrors.ErrMigrationInvalidState)
+
+	err = env.manager.CancelJob(context.Background(), "missing")
+	assert.ErrorIs(t, err, customErrors.ErrMigrationJobNotFound)
+}
+
+func TestMigrationManager_PostCopyAfterCompletion(t *testing.T) {
+	env := newMigrationTestEnv(t)
+	env.source.EXPECT().Get(gomock.Any(), "test-vm").Return(testVM(), nil)
+	env.expectPreflight(false)
+
+	release := make(chan struct{})
+	env.source.EXPECT().Migrate(gomock.Any(), "test-vm", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, string, domain.MigrateOptions, func(domain.MigrationProgress)) error {
+			<-release
+			return nil
+		})
+
+	job, err := env.manager.CreateMigrationJob(context.Background(), "test-vm",
+		Params{TargetURI: testTargetURI, Live: true, PostCopy: true})
+	require.NoError(t, err)
+	waitForStatus(t, env.manager, job.ID, StatusRunning)
+
+	// The migration completes while the switch is requested
+	env.source.EXPECT().StartPostCopy(gomock.Any(), "test-vm").DoAndReturn(
+		func(context.Context, string) error {
+			close(release)
+			waitForStatus(t, env.manager, job.ID, StatusCompleted)
+			return nil
+		})
+
+	err = env.manager.StartPostCopy(context.Background(), job.ID)
+	assert.ErrorIs(t, err, customErrors.ErrMigrationInvalidState)
+
+	got, err := env.manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, got.Status)
+}
+
+func TestMigrationManager_CancelJob(t *testing.T) {
+	env := newMigrationTestEnv(t)
+	env.source.EXPECT().Get(gomock.Any(), "test-vm").Return(testVM(), nil)
+	env.expectPreflight(false)
+	env.source.EXPECT().Migrate(gomock.Any(), "test-vm", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, _ domain.MigrateOptions, _ func(domain.MigrationProgress)) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	job, err := env.manager.CreateMigrationJob(context.Background(), "test-vm", Params{TargetURI: testTargetURI, Live: true})
+	require.NoError(t, err)
+
+	waitForStatus(t, env.manager, job.ID, StatusRunning)
+
+	// Post-copy was not requested for this job
+	err = env.manager.StartPostCopy(context.Background(), job.ID)
+	assert.ErrorIs(t, err, customErrors.ErrMigrationInvalidState)
+
+	require.NoError(t, env.manager.CancelJob(context.Background(), job.ID))
+	waitForStatus(t, env.manager, job.ID, StatusCanceled)
+}
@@ -0,0 +1,171 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/errors"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// validateParams checks that the migration options can be combined.
+func validateParams(params Params) error {
+	if params.TargetURI == "" {
+		return fmt.Errorf("%w: target URI is required", errors.ErrInvalidParameter)
+	}
+	if params.Timeout < 0 {
+		return fmt.Errorf("%w: timeout must not be negative", errors.ErrInvalidParameter)
+	}
+	if params.Live && params.Offline {
+		return fmt.Errorf("%w: live and offline migration are mutually exclusive", errors.ErrInvalidParameter)
+	}
+	if params.Offline && !params.Persistent {
+		return fmt.Errorf("%w: offline migration requires a persistent destination", errors.ErrInvalidParameter)
+	}
+	if params.Offline && params.CopyStorage {
+		return fmt.Errorf("%w: storage cannot be copied during offline migration", errors.ErrInvalidParameter)
+	}
+	if params.PostCopy && !params.Live {
+		return fmt.Errorf("%w: post-copy requires live migration", errors.ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// preflight checks that the destination host can run the VM. All problems
+// found are reported together.
+func (m *MigrationManager) preflight(ctx context.Context, vm *vmmodels.VM, params Params, target *Target) error {
+	var problems []string
+
+	if !params.Offline {
+		problems = append(problems, m.checkCPU(ctx, vm.Name, target)...)
+		problems = append(problems, checkMemory(ctx, vm, target)...)
+	}
+	problems = append(problems, checkNetworks(ctx, vm, target)...)
+	problems = append(problems, m.checkPools(ctx, vm, params, target)...)
+
+	if len(problems) > 0 {
+		m.logger.Warn("Migration pre-flight checks failed",
+			logger.String("name", vm.Name),
+			logger.String("target", params.TargetURI),
+			logger.Int("problems", len(problems)))
+		return fmt.Errorf("%w: %s", errors.ErrMigrationPreflight, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// checkCPU checks that the destination CPU provides the features the VM uses.
+func (m *MigrationManager) checkCPU(ctx context.Context, name string, target *Target) []string {
+	cpuXML, err := m.domainManager.GetMigratableCPU(ctx, name)
+	if err != nil {
+		return []string{fmt.Sprintf("getting VM CPU: %v", err)}
+	}
+
+	compatible, err := target.Domain.CompareCPU(ctx, cpuXML)
+	if err != nil {
+		return []string{fmt.Sprintf("comparing CPU with target: %v", err)}
+	}
+	if !compatible {
+		return []string{"target CPU is incompatible with the VM CPU"}
+	}
+
+	return nil
+}
+
+// checkMemory checks that the destination has enough free memory for the VM.
+func checkMemory(ctx context.Context, vm *vmmodels.VM, target *Target) []string {
+	freeMemory, err := target.Domain.GetFreeMemory(ctx)
+	if err != nil {
+		return []string{fmt.Sprintf("getting target free memory: %v", err)}
+	}
+	if freeMemory < vm.Memory.SizeBytes {
+		return []string{fmt.Sprintf("target has %d bytes of free memory, VM needs %d", freeMemory, vm.Memory.SizeBytes)}
+	}
+
+	return nil
+}
+
+// checkNetworks checks that the libvirt networks used by the VM exist on the
+// destination.
+func checkNetworks(ctx context.Context, vm *vmmodels.VM, target *Target) []string {
+	var problems []string
+	checked := make(map[string]bool)
+
+	for _, nic := range vm.Networks {
+		if nic.Type != vmmodels.NetworkTypeNetwork || nic.Source == "" || checked[nic.Source] {
+			continue
+		}
+		checked[nic.Source] = true
+
+		if _, err := target.Networks.Get(ctx, nic.Source); err != nil {
+			problems = append(problems, fmt.Sprintf("network %s not found on target", nic.Source))
+		}
+	}
+
+	return problems
+}
+
+// checkPools checks that the storage pools used by the VM exist on the
+// destination and, when storage is copied, have room for the disks.
+func (m *MigrationManager) checkPools(ctx context.Context, vm *vmmodels.VM, params Params, target *Target) []string {
+	var problems []string
+	required := make(map[string]uint64)
+	var order []string
+
+	for _, disk := range vm.Disks {
+		if disk.StoragePool == "" {
+			continue
+		}
+		if _, seen := required[disk.StoragePool]; !seen {
+			order = append(order, disk.StoragePool)
+			required[disk.StoragePool] = 0
+		}
+
+		if params.CopyStorage {
+			size, err := m.diskSize(ctx, disk)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("getting size of disk %s: %v", disk.Device, err))
+				continue
+			}
+			required[disk.StoragePool] += size
+		}
+	}
+
+	for _, pool := range order {
+		info, err := target.Pools.GetInfo(ctx, pool)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("storage pool %s not found on target", pool))
+			continue
+		}
+
+		if params.CopyStorage && info.Available < required[pool] {
+			problems = append(problems, fmt.Sprintf("storage pool %s has %d bytes available on target, disks need %d",
+				pool, info.Available, required[pool]))
+		}
+	}
+
+	return problems
+}
+
+// diskSize returns the capacity of a disk's volume.
+func (m *MigrationManager) diskSize(ctx context.Context, disk vmmodels.DiskInfo) (uint64, error) {
+	if disk.SizeBytes > 0 {
+		return disk.SizeBytes, nil
+	}
+
+	volName := disk.VolumeName
+	if volName == "" {
+		volName = filepath.Base(disk.Path)
+	}
+
+	info, err := m.volumeManager.GetInfo(ctx, disk.StoragePool, volName)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Capacity, nil
+}
@@ -0,0 +1,56 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/threatflux/libgo/internal/config"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/network"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// defaultTargetTimeout is used when connecting to a destination host
+// without a configured connection timeout.
+const defaultTargetTimeout = 30 * time.Second
+
+// Target gives access to the destination host of a migration.
+type Target struct {
+	Domain   domain.Manager
+	Pools    storage.PoolManager
+	Networks network.Manager
+	// Close releases the connection to the destination host
+	Close func() error
+}
+
+// TargetConnector opens a connection to the host at a libvirt URI.
+type TargetConnector func(ctx context.Context, uri string) (*Target, error)
+
+// NewLibvirtTargetConnector creates a TargetConnector that opens a dedicated
+// libvirt connection to each destination host.
+func NewLibvirtTargetConnector(timeout time.Duration, log logger.Logger) TargetConnector {
+	if timeout <= 0 {
+		timeout = defaultTargetTimeout
+	}
+
+	return func(_ context.Context, uri string) (*Target, error) {
+		connManager, err := connection.NewConnectionManager(config.LibvirtConfig{
+			URI:               uri,
+			ConnectionTimeout: timeout,
+			MaxConnections:    1,
+		}, log)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s: %w", uri, err)
+		}
+
+		return &Target{
+			Domain:   domain.NewDomainManager(connManager, nil, log),
+			Pools:    storage.NewLibvirtPoolManager(connManager, nil, log),
+			Networks: network.NewLibvirtNetworkManager(connManager, nil, log),
+			Close:    connManager.Close,
+		}, nil
+	}
+}
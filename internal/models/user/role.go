@@ -9,23 +9,24 @@ const (
 
 // Permissions.
 const (
-	PermCreate = "create"
-	PermRead   = "read"
-	PermUpdate = "update"
-	PermDelete = "delete"
-	PermStart  = "start"
-	PermStop   = "stop"
-	PermExport = "export"
+	PermCreate  = "create"
+	PermRead    = "read"
+	PermUpdate  = "update"
+	PermDelete  = "delete"
+	PermStart   = "start"
+	PermStop    = "stop"
+	PermExport  = "export"
+	PermConsole = "console"
 )
 
 // RolePermissions maps roles to their permissions.
 var RolePermissions = map[string][]string{
 	RoleAdmin: {
 		PermCreate, PermRead, PermUpdate, PermDelete,
-		PermStart, PermStop, PermExport,
+		PermStart, PermStop, PermExport, PermConsole,
 	},
 	RoleOperator: {
-		PermRead, PermUpdate, PermStart, PermStop, PermExport,
+		PermRead, PermUpdate, PermStart, PermStop, PermExport, PermConsole,
 	},
 	RoleViewer: {
 		PermRead,
@@ -97,6 +98,7 @@ func Permissions() []string {
 		PermStart,
 		PermStop,
 		PermExport,
+		PermConsole,
 	}
 }
 
@@ -113,7 +115,7 @@ func IsValidRole(role string) bool {
 // IsValidPermission checks if a permission is valid.
 func IsValidPermission(permission string) bool {
 	switch permission {
-	case PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport:
+	case PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport, PermConsole:
 		return true
 	default:
 		return false
@@ -15,12 +15,12 @@ func TestGetRolePermissions(t *testing.T) {
 		{
 			name: "Admin permissions",
 			role: RoleAdmin,
-			want: []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport},
+			want: []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport, PermConsole},
 		},
 		{
 			name: "Operator permissions",
 			role: RoleOperator,
-			want: []string{PermRead, PermUpdate, PermStart, PermStop, PermExport},
+			want: []string{PermRead, PermUpdate, PermStart, PermStop, PermExport, PermConsole},
 		},
 		{
 			name: "Viewer permissions",
@@ -114,12 +114,12 @@ func TestGetUserPermissions(t *testing.T) {
 		{
 			name:  "Admin only",
 			roles: []string{RoleAdmin},
-			want:  []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport},
+			want:  []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport, PermConsole},
 		},
 		{
 			name:  "Operator only",
 			roles: []string{RoleOperator},
-			want:  []string{PermRead, PermUpdate, PermStart, PermStop, PermExport},
+			want:  []string{PermRead, PermUpdate, PermStart, PermStop, PermExport, PermConsole},
 		},
 		{
 			name:  "Viewer only",
@@ -129,17 +129,17 @@ func TestGetUserPermissions(t *testing.T) {
 		{
 			name:  "Admin and Operator",
 			roles: []string{RoleAdmin, RoleOperator},
-			want:  []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport},
+			want:  []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport, PermConsole},
 		},
 		{
 			name:  "Admin, Operator, and Viewer",
 			roles: []string{RoleAdmin, RoleOperator, RoleViewer},
-			want:  []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport},
+			want:  []string{PermCreate, PermRead, PermUpdate, PermDelete, PermStart, PermStop, PermExport, PermConsole},
 		},
 		{
 			name:  "Operator and Viewer",
 			roles: []string{RoleOperator, RoleViewer},
-			want:  []string{PermRead, PermUpdate, PermStart, PermStop, PermExport},
+			want:  []string{PermRead, PermUpdate, PermStart, PermStop, PermExport, PermConsole},
 		},
 		{
 			name:  "No roles",
@@ -261,6 +261,7 @@ func TestPermissions(t *testing.T) {
 		PermStart,
 		PermStop,
 		PermExport,
+		PermConsole,
 	}
 
 	if len(perms) != len(expected) {
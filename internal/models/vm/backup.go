@@ -0,0 +1,36 @@
+package vm
+
+// BackupDisk selects a disk for a push-mode backup and the file the
+// hypervisor writes its data to.
+type BackupDisk struct {
+	// Device is the target device of the disk, e.g. "vda"
+	Device string `json:"device"`
+	// TargetPath is the file receiving the backup of the disk
+	TargetPath string `json:"targetPath"`
+	// TargetFormat is the format of the target file, "raw" or "qcow2".
+	// Incremental backups need qcow2 so that unchanged clusters stay
+	// unallocated.
+	TargetFormat string `json:"targetFormat"`
+}
+
+// BackupParams describes a push-mode backup of a running VM.
+type BackupParams struct {
+	// Disks lists the disks to back up; other disks are skipped
+	Disks []BackupDisk `json:"disks"`
+	// Incremental names the checkpoint the backup is incremental from;
+	// the backup is full when empty
+	Incremental string `json:"incremental,omitempty"`
+	// Checkpoint names a checkpoint created when the backup starts. Its
+	// dirty bitmaps track the changes for the next incremental backup.
+	Checkpoint string `json:"checkpoint,omitempty"`
+}
+
+// BackupJobInfo is the state of the backup job of a VM.
+type BackupJobInfo struct {
+	// Error describes why a finished job failed
+	Error         string `json:"error,omitempty"`
+	DataTotal     uint64 `json:"dataTotal"`
+	DataProcessed uint64 `json:"dataProcessed"`
+	// Active is set while the job is running
+	Active bool `json:"active"`
+}
@@ -0,0 +1,57 @@
+package vm
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// CloneMode determines how the disks of a VM are copied.
+type CloneMode string
+
+// Clone mode constants.
+const (
+	// CloneModeFull copies every disk.
+	CloneModeFull CloneMode = "full"
+	// CloneModeLinked creates qcow2 overlays backed by the source disks.
+	CloneModeLinked CloneMode = "linked"
+)
+
+// vmNamePattern matches valid VM names (RFC 1123 hostnames).
+var vmNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// CloneParams contains parameters for cloning a VM.
+type CloneParams struct {
+	// CloudInit overrides the user-data and network-config of the clone.
+	// Meta-data is always regenerated with a new instance-id.
+	CloudInit *CloudInitConfig `json:"cloudInit,omitempty"`
+	// Name is the name of the new VM (required).
+	Name string `json:"name" binding:"required"`
+	// Mode is the disk clone mode, full by default.
+	Mode CloneMode `json:"mode,omitempty"`
+	// Live allows cloning a running VM through a temporary external snapshot.
+	Live bool `json:"live"`
+	// Start starts the clone once it is created.
+	Start bool `json:"start"`
+}
+
+// Validate validates the clone parameters and applies defaults.
+func (p *CloneParams) Validate() error {
+	if !vmNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid VM name: %s", p.Name)
+	}
+
+	switch p.Mode {
+	case "":
+		p.Mode = CloneModeFull
+	case CloneModeFull:
+	case CloneModeLinked:
+		// Linked clones share the source disks, which must not change
+		if p.Live {
+			return fmt.Errorf("linked clones of a running VM are not supported")
+		}
+	default:
+		return fmt.Errorf("invalid clone mode: %s", p.Mode)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package vm
+
+import (
+	"io"
+	"net"
+	"strconv"
+)
+
+// GraphicsType represents the type of a graphics device.
+type GraphicsType string
+
+const (
+	// GraphicsTypeVNC represents a VNC graphics device.
+	GraphicsTypeVNC GraphicsType = "vnc"
+	// GraphicsTypeSPICE represents a SPICE graphics device.
+	GraphicsTypeSPICE GraphicsType = "spice"
+)
+
+// ConsoleStream is an open, bidirectional serial console session.
+type ConsoleStream interface {
+	io.ReadWriteCloser
+
+	// Resize updates the terminal dimensions of the console
+	Resize(cols, rows uint16) error
+}
+
+// ConsoleOptions contains options for opening a serial console.
+type ConsoleOptions struct {
+	// Device is the console device alias, empty for the primary console
+	Device string `json:"device,omitempty"`
+	// Force disconnects any other session attached to the console
+	Force bool `json:"force,omitempty"`
+}
+
+// GraphicsInfo describes a graphics device of a running VM.
+type GraphicsInfo struct {
+	Type    GraphicsType `json:"type"`
+	Listen  string       `json:"listen"`
+	Port    int          `json:"port"`
+	TLSPort int          `json:"tlsPort,omitempty"`
+	// Host is the address of the libvirt host running the VM, empty if it
+	// runs on this machine
+	Host string `json:"host,omitempty"`
+}
+
+// Address returns the host:port of the graphics device as seen from the
+// libvirt host. Wildcard listen addresses are replaced by the address of the
+// libvirt host, or by the loopback interface for local VMs. Loopback listen
+// addresses of VMs on remote hosts are kept: they are on the remote host and
+// must be reached through it, never dialed on this machine.
+func (g GraphicsInfo) Address() string {
+	host := g.Listen
+	switch host {
+	case "", "0.0.0.0", "::":
+		host = g.Host
+		if host == "" {
+			host = "127.0.0.1"
+		}
+	}
+
+	port := g.Port
+	if port <= 0 {
+		port = g.TLSPort
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
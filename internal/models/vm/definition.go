@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Accepted values of the structured update fields.
+var (
+	updateNICModels  = []string{"virtio", "e1000", "e1000e", "rtl8139", "vmxnet3", "pcnet"}
+	updateDiskCaches = []string{"default", "none", "writethrough", "writeback", "directsync", "unsafe"}
+)
+
+// UpdateParams contains changes to the persistent definition of a VM.
+// Fields that are not set are left unchanged.
+type UpdateParams struct {
+	// BootOrder lists the devices the VM boots from, in order, by disk
+	// target (e.g. "vda") or interface MAC address. An empty list removes
+	// the boot order.
+	BootOrder []string `json:"bootOrder,omitempty"`
+	// CPUs is the number of virtual CPUs.
+	CPUs *int `json:"cpus,omitempty"`
+	// MemoryBytes is the memory size in bytes.
+	MemoryBytes *uint64 `json:"memoryBytes,omitempty"`
+	// Description replaces the description; an empty string removes it.
+	Description *string `json:"description,omitempty"`
+	// NICModel is the model of all network interfaces.
+	NICModel *string `json:"nicModel,omitempty"`
+	// DiskCache is the cache mode of all disks; "default" leaves it to the
+	// hypervisor.
+	DiskCache *string `json:"diskCache,omitempty"`
+	// Autostart starts the VM when the host boots.
+	Autostart *bool `json:"autostart,omitempty"`
+}
+
+// Validate validates the update parameters.
+func (p *UpdateParams) Validate() error {
+	if p.BootOrder == nil && p.CPUs == nil && p.MemoryBytes == nil && p.Description == nil &&
+		p.NICModel == nil && p.DiskCache == nil && p.Autostart == nil {
+		return fmt.Errorf("no changes requested")
+	}
+
+	if p.CPUs != nil && (*p.CPUs < 1 || *p.CPUs > 128) {
+		return fmt.Errorf("invalid CPU count: %d", *p.CPUs)
+	}
+	// Minimum 128MB, as for new VMs
+	if p.MemoryBytes != nil && *p.MemoryBytes < 128<<20 {
+		return fmt.Errorf("invalid memory size: %d", *p.MemoryBytes)
+	}
+	if p.NICModel != nil && !slices.Contains(updateNICModels, *p.NICModel) {
+		return fmt.Errorf("invalid NIC model: %s", *p.NICModel)
+	}
+	if p.DiskCache != nil && !slices.Contains(updateDiskCaches, *p.DiskCache) {
+		return fmt.Errorf("invalid disk cache mode: %s", *p.DiskCache)
+	}
+
+	seen := make(map[string]bool, len(p.BootOrder))
+	for _, device := range p.BootOrder {
+		key := strings.ToLower(device)
+		if device == "" || seen[key] {
+			return fmt.Errorf("invalid boot device: %q", device)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// DefinitionUpdate describes a change to the persistent definition of a VM.
+type DefinitionUpdate struct {
+	// Diff is a unified diff of the definition before and after the update
+	Diff string `json:"diff"`
+	// Changed reports whether the definition or the autostart setting changed
+	Changed bool `json:"changed"`
+	// RestartRequired reports whether the running VM only picks up the
+	// change once it is restarted
+	RestartRequired bool `json:"restartRequired"`
+}
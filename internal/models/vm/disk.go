@@ -36,8 +36,9 @@ type DiskType string
 
 // Disk type constants.
 const (
-	DiskTypeFile  DiskType = "file"
-	DiskTypeBlock DiskType = "block"
+	DiskTypeFile    DiskType = "file"
+	DiskTypeBlock   DiskType = "block"
+	DiskTypeNetwork DiskType = "network"
 )
 
 // DiskBus represents the bus type for a disk.
@@ -64,12 +65,61 @@ type DiskParams struct {
 	SourceImage string     `json:"sourceImage,omitempty"`
 	StoragePool string     `json:"storagePool,omitempty"`
 	CacheMode   string     `json:"cacheMode,omitempty" validate:"omitempty,oneof=none writeback writethrough directsync unsafe"`
+	IOMode      string     `json:"ioMode,omitempty" validate:"omitempty,oneof=native threads io_uring"`
+	Discard     string     `json:"discard,omitempty" validate:"omitempty,oneof=unmap ignore"`
 	Format      DiskFormat `json:"format" validate:"required,oneof=qcow2 raw"`
 	Bus         DiskBus    `json:"bus,omitempty" validate:"omitempty,oneof=virtio ide sata scsi"`
 	SizeBytes   uint64     `json:"sizeBytes" validate:"required,min=1073741824"`
 	SizeMB      uint64     `json:"sizeMB,omitempty"`
 	Shareable   bool       `json:"shareable,omitempty"`
 	ReadOnly    bool       `json:"readOnly,omitempty"`
+	// BaseImage names a library image the disk is created from as a thin
+	// qcow2 overlay instead of a full copy
+	BaseImage string `json:"baseImage,omitempty"`
+	// Encrypted creates the disk as a LUKS encrypted volume with its own
+	// passphrase
+	Encrypted bool `json:"encrypted,omitempty"`
+	// IOTune limits the I/O of the disk
+	IOTune *DiskIOTune `json:"ioTune,omitempty"`
+	// Source is where the data of a disk created in a storage pool lives,
+	// which depends on the pool type; it is resolved once the disk exists
+	Source *DiskSource `json:"-"`
+}
+
+// DiskSource describes how a VM reaches the data of a disk.
+type DiskSource struct {
+	// Auth holds the credentials of network disks
+	Auth  *DiskAuth  `json:"auth,omitempty"`
+	Hosts []DiskHost `json:"hosts,omitempty"`
+	Type  DiskType   `json:"type"`
+	// Path is the file or block device of file and block disks
+	Path string `json:"path,omitempty"`
+	// Protocol and Name address network disks, such as rbd and pool/image
+	Protocol string `json:"protocol,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// Encryption is set for LUKS encrypted disks
+	Encryption *DiskEncryption `json:"encryption,omitempty"`
+}
+
+// DiskEncryption references the libvirt secret holding the passphrase of an
+// encrypted disk.
+type DiskEncryption struct {
+	Format     string `json:"format"`
+	SecretUUID string `json:"secretUUID"`
+}
+
+// DiskHost is a server of a network disk.
+type DiskHost struct {
+	Name string `json:"name"`
+	Port int    `json:"port,omitempty"`
+}
+
+// DiskAuth references the libvirt secret a network disk authenticates with.
+type DiskAuth struct {
+	Username    string `json:"username"`
+	SecretType  string `json:"secretType"`
+	SecretUUID  string `json:"secretUUID,omitempty"`
+	SecretUsage string `json:"secretUsage,omitempty"`
 }
 
 // DiskInfo contains information about a VM's disk.
@@ -86,6 +136,12 @@ type DiskInfo struct {
 	ReadOnly    bool       `json:"readOnly,omitempty"`
 	Bootable    bool       `json:"bootable,omitempty"`
 	Shareable   bool       `json:"shareable,omitempty"`
+	Encrypted   bool       `json:"encrypted,omitempty"`
+	CacheMode   string     `json:"cacheMode,omitempty"`
+	IOMode      string     `json:"ioMode,omitempty"`
+	Discard     string     `json:"discard,omitempty"`
+	// IOTune is set for disks with I/O limits
+	IOTune *DiskIOTune `json:"ioTune,omitempty"`
 }
 
 // Validate validates the disk parameters.
@@ -100,6 +156,21 @@ func (p *DiskParams) Validate() error {
 		return fmt.Errorf("disk size must be at least 1 GB (1073741824 bytes)")
 	}
 
+	// Base images are the backing store of a qcow2 disk
+	if p.BaseImage != "" {
+		if p.SourceImage != "" {
+			return fmt.Errorf("source image and base image are mutually exclusive")
+		}
+		if p.Format != DiskFormatQCOW2 {
+			return fmt.Errorf("disks created from a base image must be qcow2")
+		}
+	}
+
+	// Source images are copied as they are
+	if p.Encrypted && p.SourceImage != "" {
+		return fmt.Errorf("disks created from a source image cannot be encrypted")
+	}
+
 	// Check source image if provided
 	if p.SourceImage != "" {
 		ext := filepath.Ext(p.SourceImage)
@@ -128,7 +199,7 @@ func (p *DiskParams) Validate() error {
 		}
 	}
 
-	return nil
+	return p.validateDiskTuning()
 }
 
 // GetBus returns the disk bus, defaulting to virtio if not specified.
@@ -0,0 +1,129 @@
+package vm
+
+import (
+	"fmt"
+)
+
+// Disk I/O mode constants.
+const (
+	DiskIOModeNative  = "native"
+	DiskIOModeThreads = "threads"
+	DiskIOModeIOUring = "io_uring"
+)
+
+// Disk discard constants.
+const (
+	DiskDiscardUnmap  = "unmap"
+	DiskDiscardIgnore = "ignore"
+)
+
+// DiskIOTune limits the I/O of a disk. Zero values are unlimited.
+type DiskIOTune struct {
+	// Sustained limits; total limits exclude read and write limits
+	TotalBytesSec uint64 `json:"totalBytesSec,omitempty"`
+	ReadBytesSec  uint64 `json:"readBytesSec,omitempty"`
+	WriteBytesSec uint64 `json:"writeBytesSec,omitempty"`
+	TotalIOPSSec  uint64 `json:"totalIopsSec,omitempty"`
+	ReadIOPSSec   uint64 `json:"readIopsSec,omitempty"`
+	WriteIOPSSec  uint64 `json:"writeIopsSec,omitempty"`
+	// Burst limits allow short bursts above the sustained limit of the
+	// same kind
+	TotalBytesSecMax uint64 `json:"totalBytesSecMax,omitempty"`
+	ReadBytesSecMax  uint64 `json:"readBytesSecMax,omitempty"`
+	WriteBytesSecMax uint64 `json:"writeBytesSecMax,omitempty"`
+	TotalIOPSSecMax  uint64 `json:"totalIopsSecMax,omitempty"`
+	ReadIOPSSecMax   uint64 `json:"readIopsSecMax,omitempty"`
+	WriteIOPSSecMax  uint64 `json:"writeIopsSecMax,omitempty"`
+	// BurstSeconds is how long a burst may last; QEMU allows one second
+	// when it is not set
+	BurstSeconds uint64 `json:"burstSeconds,omitempty"`
+}
+
+// ioLimit is a sustained limit and its burst limit.
+type ioLimit struct {
+	name  string
+	value uint64
+	max   uint64
+}
+
+// limits returns the sustained and burst limits of an I/O tune.
+func (t *DiskIOTune) limits() []ioLimit {
+	return []ioLimit{
+		{"total bytes", t.TotalBytesSec, t.TotalBytesSecMax},
+		{"read bytes", t.ReadBytesSec, t.ReadBytesSecMax},
+		{"write bytes", t.WriteBytesSec, t.WriteBytesSecMax},
+		{"total IOPS", t.TotalIOPSSec, t.TotalIOPSSecMax},
+		{"read IOPS", t.ReadIOPSSec, t.ReadIOPSSecMax},
+		{"write IOPS", t.WriteIOPSSec, t.WriteIOPSSecMax},
+	}
+}
+
+// IsZero reports whether an I/O tune sets no limits.
+func (t *DiskIOTune) IsZero() bool {
+	return *t == DiskIOTune{}
+}
+
+// Validate validates the I/O limits of a disk.
+func (t *DiskIOTune) Validate() error {
+	// QEMU throttles either the total or reads and writes apart
+	if (t.TotalBytesSec != 0 || t.TotalBytesSecMax != 0) &&
+		(t.ReadBytesSec != 0 || t.WriteBytesSec != 0 || t.ReadBytesSecMax != 0 || t.WriteBytesSecMax != 0) {
+		return fmt.Errorf("total bytes limits cannot be combined with read or write bytes limits")
+	}
+	if (t.TotalIOPSSec != 0 || t.TotalIOPSSecMax != 0) &&
+		(t.ReadIOPSSec != 0 || t.WriteIOPSSec != 0 || t.ReadIOPSSecMax != 0 || t.WriteIOPSSecMax != 0) {
+		return fmt.Errorf("total IOPS limits cannot be combined with read or write IOPS limits")
+	}
+
+	bursts := false
+	for _, limit := range t.limits() {
+		if limit.max == 0 {
+			continue
+		}
+		if limit.value == 0 {
+			return fmt.Errorf("%s burst limit needs a %s limit", limit.name, limit.name)
+		}
+		if limit.max < limit.value {
+			return fmt.Errorf("%s burst limit %d is below the %s limit %d", limit.name, limit.max, limit.name, limit.value)
+		}
+		bursts = true
+	}
+
+	if t.BurstSeconds != 0 && !bursts {
+		return fmt.Errorf("burst length needs a burst limit")
+	}
+
+	return nil
+}
+
+// validateDiskTuning validates the cache, I/O mode, discard and I/O limits
+// of a disk.
+func (p *DiskParams) validateDiskTuning() error {
+	if p.IOMode != "" {
+		switch p.IOMode {
+		case DiskIOModeNative, DiskIOModeThreads, DiskIOModeIOUring:
+			// Valid
+		default:
+			return fmt.Errorf("invalid disk I/O mode: %s", p.IOMode)
+		}
+	}
+
+	// Native AIO needs the host page cache bypassed
+	if p.IOMode == DiskIOModeNative {
+		if cache := p.GetCacheMode(); cache != "none" && cache != "directsync" {
+			return fmt.Errorf("native I/O needs cache mode none or directsync, not %s", cache)
+		}
+	}
+
+	if p.Discard != "" && p.Discard != DiskDiscardUnmap && p.Discard != DiskDiscardIgnore {
+		return fmt.Errorf("invalid disk discard mode: %s", p.Discard)
+	}
+
+	if p.IOTune != nil {
+		if err := p.IOTune.Validate(); err != nil {
+			return fmt.Errorf("invalid disk I/O limits: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package vm
+
+// GuestInfo contains information reported by the QEMU guest agent.
+type GuestInfo struct {
+	Hostname   string           `json:"hostname,omitempty"`
+	Timezone   string           `json:"timezone,omitempty"`
+	Interfaces []GuestInterface `json:"interfaces,omitempty"`
+	OS         GuestOSInfo      `json:"os"`
+}
+
+// GuestOSInfo describes the operating system running in the guest.
+type GuestOSInfo struct {
+	ID            string `json:"id,omitempty"`
+	Name          string `json:"name,omitempty"`
+	PrettyName    string `json:"prettyName,omitempty"`
+	Version       string `json:"version,omitempty"`
+	VersionID     string `json:"versionId,omitempty"`
+	KernelRelease string `json:"kernelRelease,omitempty"`
+	KernelVersion string `json:"kernelVersion,omitempty"`
+	Machine       string `json:"machine,omitempty"`
+}
+
+// GuestInterface is a network interface as seen from inside the guest.
+type GuestInterface struct {
+	Name        string           `json:"name"`
+	MacAddress  string           `json:"macAddress,omitempty"`
+	IPAddresses []GuestIPAddress `json:"ipAddresses,omitempty"`
+}
+
+// GuestIPAddress is an address assigned to a guest interface.
+type GuestIPAddress struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Prefix  int    `json:"prefix"`
+}
+
+// IP address types reported by the guest agent.
+const (
+	GuestIPAddressIPv4 = "ipv4"
+	GuestIPAddressIPv6 = "ipv6"
+)
+
+// GuestFilesystem is a mounted filesystem as seen from inside the guest.
+type GuestFilesystem struct {
+	Mountpoint string `json:"mountpoint"`
+	// Name is the guest device holding the filesystem, such as vda1
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Disks are the targets of the domain disks the filesystem is on
+	Disks []string `json:"disks,omitempty"`
+}
+
+// GuestCommand is a program run inside the guest through the guest agent.
+type GuestCommand struct {
+	// Command is the program path followed by its arguments
+	Command []string `json:"command" binding:"required"`
+	// Timeout is the number of seconds to wait for the program to exit; zero
+	// uses the default of 60 seconds
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// GuestCommandResult is the outcome of a program run inside the guest.
+type GuestCommandResult struct {
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exitCode"`
+	// Signal is the signal that terminated the program, zero if it exited
+	Signal int `json:"signal,omitempty"`
+}
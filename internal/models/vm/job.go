@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"time"
+)
+
+// JobType identifies the operation a VM job performs.
+type JobType string
+
+const (
+	// JobTypeClone creates a copy of a VM
+	JobTypeClone JobType = "clone"
+	// JobTypeShutdown shuts a VM down
+	JobTypeShutdown JobType = "shutdown"
+	// JobTypeRestart shuts a VM down and starts it again
+	JobTypeRestart JobType = "restart"
+	// JobTypeSnapshotDelete merges the overlays of an external snapshot and
+	// deletes it
+	JobTypeSnapshotDelete JobType = "snapshot-delete"
+)
+
+// JobStatus represents the status of a VM job.
+type JobStatus string
+
+const (
+	// JobStatusRunning indicates the job is in progress
+	JobStatusRunning JobStatus = "running"
+	// JobStatusCompleted indicates the job completed successfully
+	JobStatusCompleted JobStatus = "completed"
+	// JobStatusFailed indicates the job failed
+	JobStatusFailed JobStatus = "failed"
+)
+
+// IsFinal returns true if the job has finished.
+func (s JobStatus) IsFinal() bool {
+	return s == JobStatusCompleted || s == JobStatusFailed
+}
+
+// Job represents a long running operation on a VM.
+type Job struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+	// VM is the VM a completed clone created
+	VM *VM `json:"vm,omitempty"`
+	// Power is the outcome of a completed shutdown or restart
+	Power *PowerResult `json:"power,omitempty"`
+	ID    string       `json:"id"`
+	Type  JobType      `json:"type"`
+	// Name is the name of the VM the job operates on
+	Name string `json:"name"`
+	// Target is the name of the VM a clone creates
+	Target string `json:"target,omitempty"`
+	// Snapshot is the name of the snapshot a job deletes
+	Snapshot string    `json:"snapshot,omitempty"`
+	Host     string    `json:"host,omitempty"`
+	Status   JobStatus `json:"status"`
+	Error    string    `json:"error,omitempty"`
+}
@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"time"
+)
+
+// Metrics contains resource usage statistics of a running VM.
+// Counters are cumulative since the VM started; rates are computed from the
+// previous sample and are zero for the first one.
+type Metrics struct {
+	Timestamp time.Time      `json:"timestamp"`
+	CPU       CPUMetrics     `json:"cpu"`
+	Memory    MemoryMetrics  `json:"memory"`
+	Network   NetworkMetrics `json:"network"`
+	Disk      DiskMetrics    `json:"disk"`
+}
+
+// CPUMetrics contains CPU usage statistics.
+type CPUMetrics struct {
+	// TimeNs is the total CPU time consumed, in nanoseconds
+	TimeNs uint64 `json:"timeNs"`
+	// VCPUs is the number of online virtual CPUs
+	VCPUs int `json:"vcpus"`
+	// Utilization is the share of the VCPUs used since the previous sample, 0-100
+	Utilization float64 `json:"utilization"`
+}
+
+// MemoryMetrics contains memory usage statistics.
+type MemoryMetrics struct {
+	// UsedBytes is the memory used by the guest, as reported by the balloon driver
+	UsedBytes uint64 `json:"usedBytes"`
+	// TotalBytes is the memory currently assigned to the guest
+	TotalBytes uint64 `json:"totalBytes"`
+	// RSSBytes is the resident memory of the VM process on the host
+	RSSBytes uint64 `json:"rssBytes"`
+}
+
+// NetworkMetrics contains network statistics summed over all interfaces.
+type NetworkMetrics struct {
+	RxBytes          uint64  `json:"rxBytes"`
+	TxBytes          uint64  `json:"txBytes"`
+	RxPackets        uint64  `json:"rxPackets"`
+	TxPackets        uint64  `json:"txPackets"`
+	RxBytesPerSecond float64 `json:"rxBytesPerSecond"`
+	TxBytesPerSecond float64 `json:"txBytesPerSecond"`
+}
+
+// DiskMetrics contains block device statistics summed over all disks.
+type DiskMetrics struct {
+	ReadBytes           uint64  `json:"readBytes"`
+	WriteBytes          uint64  `json:"writeBytes"`
+	ReadOps             uint64  `json:"readOps"`
+	WriteOps            uint64  `json:"writeOps"`
+	ReadBytesPerSecond  float64 `json:"readBytesPerSecond"`
+	WriteBytesPerSecond float64 `json:"writeBytesPerSecond"`
+}
@@ -12,6 +12,8 @@ type VMParams struct {
 	Memory MemoryParams `json:"memory" validate:"required"`
 	// Network has 3 strings + 1 enum
 	Network NetParams `json:"network"`
+	// SharedFolders lists host directories shared with the VM
+	SharedFolders []SharedFolderParams `json:"sharedFolders,omitempty"`
 	// String fields (16 bytes each) - put at end for optimal alignment
 	Name        string `json:"name" validate:"required,hostname_rfc1123"`
 	Description string `json:"description,omitempty"`
@@ -46,4 +48,6 @@ type CloudInitConfig struct {
 	MetaData      string `json:"metaData,omitempty"`
 	NetworkConfig string `json:"networkConfig,omitempty"`
 	ISODir        string `json:"-"` // Internal use only - not exposed via API
+	ISOPath       string `json:"-"` // Internal use only - the ISO volume on the VM's host
+	InstanceID    string `json:"-"` // Internal use only - defaults to the VM name
 }
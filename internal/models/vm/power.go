@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"time"
+)
+
+// ShutdownMethod identifies how a VM was brought down.
+type ShutdownMethod string
+
+// Shutdown methods, in the order they are tried.
+const (
+	// ShutdownMethodNone means the VM was already stopped
+	ShutdownMethodNone ShutdownMethod = "none"
+	// ShutdownMethodACPI is an ACPI power button press handled by the guest
+	ShutdownMethodACPI ShutdownMethod = "acpi"
+	// ShutdownMethodGuestAgent is a shutdown requested through the guest agent
+	ShutdownMethodGuestAgent ShutdownMethod = "guest-agent"
+	// ShutdownMethodDestroy is an immediate power off
+	ShutdownMethodDestroy ShutdownMethod = "destroy"
+)
+
+// Power operations reported in PowerResult.
+const (
+	PowerOperationShutdown = "shutdown"
+	PowerOperationRestart  = "restart"
+)
+
+// ShutdownOptions controls how a VM is shut down.
+type ShutdownOptions struct {
+	// GracePeriod is how long the guest gets to shut down after the ACPI
+	// request before escalating; zero uses the configured default
+	GracePeriod time.Duration
+	// Force powers the VM off immediately
+	Force bool
+}
+
+// PowerResult reports the outcome of a power operation.
+type PowerResult struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// Name is the name of the VM
+	Name string `json:"name"`
+	// Operation is the power operation performed, e.g. "shutdown" or "restart"
+	Operation string `json:"operation"`
+	// Method is how the VM was shut down, if it was
+	Method ShutdownMethod `json:"method,omitempty"`
+	// State is the status of the VM when the operation finished
+	State VMStatus `json:"state"`
+	// DurationMs is the time the operation took, in milliseconds
+	DurationMs int64 `json:"durationMs"`
+}
+
+// Finish records the end of the operation and the final state of the VM.
+func (r *PowerResult) Finish(state VMStatus) {
+	r.FinishedAt = time.Now()
+	r.State = state
+	r.DurationMs = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+}
@@ -0,0 +1,110 @@
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// SharedFolderDriver represents the transport of a shared host directory.
+type SharedFolderDriver string
+
+// Shared folder driver constants.
+const (
+	SharedFolderDriverVirtioFS SharedFolderDriver = "virtiofs"
+	SharedFolderDriver9p       SharedFolderDriver = "9p"
+)
+
+// Valid shared folder drivers.
+var validSharedFolderDrivers = map[SharedFolderDriver]bool{
+	SharedFolderDriverVirtioFS: true,
+	SharedFolderDriver9p:       true,
+}
+
+// maxSharedFolderTagLength is the longest mount tag virtio-fs accepts.
+const maxSharedFolderTagLength = 36
+
+// IsValid checks if the shared folder driver is valid.
+func (d SharedFolderDriver) IsValid() bool {
+	_, valid := validSharedFolderDrivers[d]
+	return valid
+}
+
+// String returns the string representation of the shared folder driver.
+func (d SharedFolderDriver) String() string {
+	return string(d)
+}
+
+// SharedFolderParams contains parameters for a host directory shared with
+// a VM.
+type SharedFolderParams struct {
+	// Source is the host directory; it must be within one of the shared
+	// folder paths allowed by the configuration
+	Source string `json:"source" validate:"required"`
+	// Tag identifies the share in the guest, e.g. for mount -t virtiofs
+	Tag string `json:"tag" validate:"required,max=36"`
+	// Driver selects virtiofs or 9p; virtiofs is used where the host
+	// supports it and 9p otherwise
+	Driver SharedFolderDriver `json:"driver,omitempty" validate:"omitempty,oneof=virtiofs 9p"`
+	// MountPoint adds a cloud-init mount entry so the share is mounted
+	// there when the guest boots
+	MountPoint string `json:"mountPoint,omitempty"`
+	// ReadOnly exports the directory read-only
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// Validate validates the shared folder parameters.
+func (p *SharedFolderParams) Validate() error {
+	if p.Source == "" || !filepath.IsAbs(p.Source) {
+		return fmt.Errorf("shared folder source must be an absolute path: %q", p.Source)
+	}
+
+	if p.Tag == "" || len(p.Tag) > maxSharedFolderTagLength {
+		return fmt.Errorf("invalid shared folder tag: %q", p.Tag)
+	}
+	for _, r := range p.Tag {
+		if !isTagRune(r) {
+			return fmt.Errorf("invalid shared folder tag: %q", p.Tag)
+		}
+	}
+
+	if p.Driver != "" && !p.Driver.IsValid() {
+		return fmt.Errorf("invalid shared folder driver: %s", p.Driver)
+	}
+
+	// virtio-fs cannot export directories read-only
+	if p.Driver == SharedFolderDriverVirtioFS && p.ReadOnly {
+		return fmt.Errorf("read-only shared folders require the 9p driver")
+	}
+
+	if p.MountPoint != "" && (!filepath.IsAbs(p.MountPoint) || filepath.Clean(p.MountPoint) == "/") {
+		return fmt.Errorf("invalid shared folder mount point: %q", p.MountPoint)
+	}
+
+	return nil
+}
+
+// MountType returns the filesystem type the guest mounts the share with.
+func (p *SharedFolderParams) MountType() string {
+	if p.Driver == SharedFolderDriver9p {
+		return "9p"
+	}
+	return "virtiofs"
+}
+
+// MountOptions returns the options the guest mounts the share with.
+func (p *SharedFolderParams) MountOptions() string {
+	options := "defaults,nofail"
+	if p.Driver == SharedFolderDriver9p {
+		options = "trans=virtio,version=9p2000.L,nofail"
+	}
+	if p.ReadOnly {
+		options += ",ro"
+	}
+	return options
+}
+
+// isTagRune reports whether r may appear in a shared folder tag.
+func isTagRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		r == '-' || r == '_' || r == '.'
+}
@@ -24,15 +24,28 @@ const (
 
 // Snapshot represents a VM snapshot.
 type Snapshot struct {
-	CreatedAt   time.Time     `json:"created_at"`
-	Name        string        `json:"name"`
-	Description string        `json:"description,omitempty"`
-	State       SnapshotState `json:"state"`
-	Parent      string        `json:"parent,omitempty"`
-	IsCurrent   bool          `json:"is_current"`
-	HasMetadata bool          `json:"has_metadata"`
-	HasMemory   bool          `json:"has_memory"`
-	HasDisk     bool          `json:"has_disk"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Disks       []SnapshotDisk `json:"disks,omitempty"`
+	Children    []*Snapshot    `json:"children,omitempty"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	State       SnapshotState  `json:"state"`
+	Parent      string         `json:"parent,omitempty"`
+	IsCurrent   bool           `json:"is_current"`
+	HasMetadata bool           `json:"has_metadata"`
+	HasMemory   bool           `json:"has_memory"`
+	HasDisk     bool           `json:"has_disk"`
+	External    bool           `json:"external"`
+}
+
+// SnapshotDisk describes how a snapshot captured a disk.
+type SnapshotDisk struct {
+	// Device is the target device of the disk, e.g. "vda"
+	Device string `json:"device"`
+	// Snapshot is "internal", "external" or "no"
+	Snapshot string `json:"snapshot"`
+	// File is the overlay that received the writes made after an external snapshot
+	File string `json:"file,omitempty"`
 }
 
 // SnapshotParams represents parameters for creating a snapshot.
@@ -48,6 +61,12 @@ type SnapshotParams struct {
 
 	// Quiesce attempts to quiesce guest filesystems (requires guest agent).
 	Quiesce bool `json:"quiesce"`
+
+	// External creates a disk-only snapshot that puts a qcow2 overlay next to
+	// each disk image instead of storing the snapshot inside the image. It
+	// works for raw disks and does not pause the VM while large disks are
+	// copied.
+	External bool `json:"external"`
 }
 
 // SnapshotListOptions represents options for listing snapshots.
@@ -55,6 +74,52 @@ type SnapshotListOptions struct {
 	// IncludeMetadata includes full metadata for each snapshot.
 	IncludeMetadata bool `json:"include_metadata"`
 
-	// Tree returns snapshots in tree structure.
+	// Tree returns the root snapshots with their descendants as children.
 	Tree bool `json:"tree"`
 }
+
+// BlockCommitParams represents parameters for merging a disk backing chain
+// down into a lower image.
+type BlockCommitParams struct {
+	// Device is the target device of the disk, e.g. "vda"
+	Device string `json:"device" binding:"required"`
+
+	// Top is the image to merge down; empty merges the active image and pivots
+	// the disk to Base once done.
+	Top string `json:"top,omitempty"`
+
+	// Base is the image receiving the data; empty uses the immediate backing
+	// image of Top.
+	Base string `json:"base,omitempty"`
+
+	// Bandwidth limits the job in MiB/s, zero is unlimited.
+	Bandwidth uint64 `json:"bandwidth,omitempty"`
+}
+
+// BlockPullParams represents parameters for pulling backing data up into
+// the active image of a disk.
+type BlockPullParams struct {
+	// Device is the target device of the disk, e.g. "vda"
+	Device string `json:"device" binding:"required"`
+
+	// Base keeps this image and everything below it as the backing chain;
+	// empty flattens the disk into a standalone image.
+	Base string `json:"base,omitempty"`
+
+	// Bandwidth limits the job in MiB/s, zero is unlimited.
+	Bandwidth uint64 `json:"bandwidth,omitempty"`
+}
+
+// BlockJob represents a running block job on a disk.
+type BlockJob struct {
+	// Device is the target device of the disk, e.g. "vda"
+	Device string `json:"device"`
+	// Type is "pull", "copy", "commit", "active-commit" or "backup"
+	Type string `json:"type"`
+	// Current and End measure progress in job-specific units
+	Current uint64 `json:"current"`
+	End     uint64 `json:"end"`
+	// Bandwidth is the job's limit in MiB/s, zero is unlimited
+	Bandwidth uint64 `json:"bandwidth"`
+	Progress  int    `json:"progress"`
+}
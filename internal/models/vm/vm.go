@@ -13,6 +13,8 @@ type VM struct {
 	// Group slices together (8 bytes each)
 	Disks    []DiskInfo `json:"disks"`
 	Networks []NetInfo  `json:"networks"`
+	// Guest agent data, only set for running VMs with a responsive agent
+	Guest *GuestInfo `json:"guest,omitempty"`
 	// Group time.Time (8 bytes)
 	CreatedAt time.Time `json:"createdAt"`
 	// Group structs together
@@ -0,0 +1,508 @@
+package orphan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// DefaultInterval is how often orphans are looked for by default.
+const DefaultInterval = time.Hour
+
+// DefaultGracePeriod is how long orphans are quarantined by default.
+const DefaultGracePeriod = 24 * time.Hour
+
+// cloudInitScope is the scan scope of cloud-init ISO files, which can
+// belong to a VM on any host.
+const cloudInitScope = "cloudinit"
+
+var (
+	// diskVolumeName matches the names vm.GenerateVolumeName gives disks
+	diskVolumeName = regexp.MustCompile(`^(.+)-disk-\d+$`)
+	// cloudInitName matches the names of cloud-init ISOs
+	cloudInitName = regexp.MustCompile(`^(.+)-cloudinit\.iso$`)
+)
+
+// Config holds orphan collector configuration.
+type Config struct {
+	// Hosts are the libvirt hosts whose pools are scanned; none scans the
+	// default host
+	Hosts []string
+	// DefaultHost names the host of images that name none
+	DefaultHost string
+	// CloudInitDir holds the cloud-init ISOs of VMs
+	CloudInitDir string
+	// GracePeriod is how long orphans are quarantined before they are
+	// deleted
+	GracePeriod time.Duration
+}
+
+// Collector implements Manager. The quarantine is kept in memory, so a
+// restart starts the grace period over.
+type Collector struct {
+	domains    domain.Manager
+	pools      storage.PoolManager
+	volumes    storage.VolumeManager
+	images     storage.ImageLibrary
+	logger     logger.Logger
+	quarantine map[string]*Orphan
+	config     Config
+	mu         sync.Mutex
+	// scanMu keeps scans and purges from overlapping
+	scanMu sync.Mutex
+}
+
+// hostUsage is what the domains and images of a host use.
+type hostUsage struct {
+	vms   map[string]bool
+	paths map[string]bool
+}
+
+// NewCollector creates a new Collector. The image library may be nil.
+func NewCollector(
+	domains domain.Manager,
+	pools storage.PoolManager,
+	volumes storage.VolumeManager,
+	images storage.ImageLibrary,
+	config Config,
+	logger logger.Logger,
+) *Collector {
+	if config.GracePeriod <= 0 {
+		config.GracePeriod = DefaultGracePeriod
+	}
+	if len(config.Hosts) == 0 {
+		config.Hosts = []string{config.DefaultHost}
+	}
+
+	return &Collector{
+		domains:    domains,
+		pools:      pools,
+		volumes:    volumes,
+		images:     images,
+		config:     config,
+		logger:     logger,
+		quarantine: make(map[string]*Orphan),
+	}
+}
+
+// Start implements Manager.Start.
+func (c *Collector) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Reconcile(ctx); err != nil {
+					c.logger.Warn("Failed to reconcile orphaned volumes", logger.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Reconcile implements Manager.Reconcile.
+func (c *Collector) Reconcile(ctx context.Context) error {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+
+	if err := c.scan(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var expired []*Orphan
+	c.mu.Lock()
+	for _, o := range c.quarantine {
+		if !now.Before(o.PurgeAt) {
+			expired = append(expired, o)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, o := range expired {
+		if err := c.delete(ctx, o); err != nil {
+			c.logger.Warn("Failed to delete orphan",
+				logger.String("kind", string(o.Kind)),
+				logger.String("path", o.Path),
+				logger.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// List implements Manager.List.
+func (c *Collector) List(_ context.Context) ([]*Orphan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	orphans := make([]*Orphan, 0, len(c.quarantine))
+	for _, o := range c.quarantine {
+		copied := *o
+		orphans = append(orphans, &copied)
+	}
+	sortOrphans(orphans)
+
+	return orphans, nil
+}
+
+// Purge implements Manager.Purge.
+func (c *Collector) Purge(ctx context.Context, id string) (*Orphan, error) {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+
+	// Make sure the orphan was not taken into use since it was found
+	if err := c.scan(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	var orphan *Orphan
+	for _, o := range c.quarantine {
+		if o.ID == id {
+			orphan = o
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if orphan == nil {
+		return nil, fmt.Errorf("%w: %s", apierrors.ErrOrphanNotFound, id)
+	}
+	if err := c.delete(ctx, orphan); err != nil {
+		return nil, err
+	}
+
+	return orphan, nil
+}
+
+// PurgeAll implements Manager.PurgeAll. Orphans that cannot be deleted stay
+// quarantined.
+func (c *Collector) PurgeAll(ctx context.Context) ([]*Orphan, error) {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+
+	if err := c.scan(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	orphans := make([]*Orphan, 0, len(c.quarantine))
+	for _, o := range c.quarantine {
+		orphans = append(orphans, o)
+	}
+	c.mu.Unlock()
+	sortOrphans(orphans)
+
+	purged := make([]*Orphan, 0, len(orphans))
+	var failed int
+	for _, o := range orphans {
+		if err := c.delete(ctx, o); err != nil {
+			c.logger.Warn("Failed to delete orphan",
+				logger.String("kind", string(o.Kind)),
+				logger.String("path", o.Path),
+				logger.Error(err))
+			failed++
+			continue
+		}
+		purged = append(purged, o)
+	}
+
+	if failed > 0 {
+		return purged, fmt.Errorf("deleting %d of %d orphans failed", failed, len(orphans))
+	}
+	return purged, nil
+}
+
+// scan finds the orphans of all hosts and updates the quarantine. Hosts
+// whose domains cannot be listed are skipped, and so are cloud-init ISOs
+// then, as their VM may be defined on that host.
+func (c *Collector) scan(ctx context.Context) error {
+	usage := make(map[string]*hostUsage, len(c.config.Hosts))
+	allVMs := make(map[string]bool)
+	for _, host := range c.config.Hosts {
+		vms, err := c.domains.List(hostContext(ctx, host))
+		if err != nil {
+			c.logger.Warn("Failed to list VMs for orphan check",
+				logger.String("host", host),
+				logger.Error(err))
+			continue
+		}
+
+		hu := &hostUsage{vms: make(map[string]bool), paths: make(map[string]bool)}
+		for _, v := range vms {
+			hu.vms[v.Name] = true
+			allVMs[v.Name] = true
+			for _, disk := range v.Disks {
+				hu.paths[disk.Path] = true
+			}
+		}
+		usage[host] = hu
+	}
+	if len(usage) == 0 {
+		return fmt.Errorf("listing VMs: no host could be reached")
+	}
+
+	// Volumes of library images are never orphans
+	if c.images != nil {
+		images, err := c.images.List(ctx)
+		if err != nil {
+			return fmt.Errorf("listing images: %w", err)
+		}
+		for _, image := range images {
+			host := image.Host
+			if host == "" {
+				host = c.config.DefaultHost
+			}
+			if hu, ok := usage[host]; ok {
+				hu.paths[image.Path] = true
+			}
+		}
+	}
+
+	found := make(map[string]*Orphan)
+	scanned := make(map[string]bool)
+	for host, hu := range usage {
+		orphans, err := c.scanVolumes(ctx, host, hu)
+		if err != nil {
+			c.logger.Warn("Failed to scan volumes for orphans",
+				logger.String("host", host),
+				logger.Error(err))
+			continue
+		}
+		for _, o := range orphans {
+			found[o.key] = o
+		}
+		scanned[volumeScope(host)] = true
+	}
+	if len(usage) == len(c.config.Hosts) {
+		orphans, err := c.scanCloudInit(allVMs)
+		if err != nil {
+			c.logger.Warn("Failed to scan cloud-init ISOs", logger.Error(err))
+		} else {
+			for _, o := range orphans {
+				found[o.key] = o
+			}
+			scanned[cloudInitScope] = true
+		}
+	}
+
+	c.update(found, scanned)
+	return nil
+}
+
+// scanVolumes finds the orphaned volumes in the running pools of a host.
+// Pools whose volumes cannot be listed are skipped.
+func (c *Collector) scanVolumes(ctx context.Context, host string, hu *hostUsage) ([]*Orphan, error) {
+	hostCtx := hostContext(ctx, host)
+
+	pools, err := c.pools.List(hostCtx)
+	if err != nil {
+		return nil, fmt.Errorf("listing storage pools: %w", err)
+	}
+
+	var candidates []*Orphan
+	backing := make(map[string]bool)
+	for _, pool := range pools {
+		if pool.State != storage.StoragePoolStateRunning {
+			continue
+		}
+
+		volumes, err := c.volumes.List(hostCtx, pool.Name)
+		if err != nil {
+			c.logger.Warn("Failed to list volumes for orphan check",
+				logger.String("host", host),
+				logger.String("pool", pool.Name),
+				logger.Error(err))
+			continue
+		}
+
+		for _, vol := range volumes {
+			// Overlays keep their backing volumes in use
+			if vol.BackingStore != nil {
+				backing[vol.BackingStore.Path] = true
+			}
+
+			vmName := ownerName(vol.Name)
+			if vmName == "" || hu.vms[vmName] || hu.paths[vol.Path] {
+				continue
+			}
+			candidates = append(candidates, &Orphan{
+				Kind:      KindVolume,
+				VM:        vmName,
+				Host:      host,
+				Pool:      pool.Name,
+				Volume:    vol.Name,
+				Path:      vol.Path,
+				SizeBytes: vol.Allocation,
+				key:       volumeScope(host) + "/" + pool.Name + "/" + vol.Name,
+				scope:     volumeScope(host),
+			})
+		}
+	}
+
+	orphans := make([]*Orphan, 0, len(candidates))
+	for _, o := range candidates {
+		if !backing[o.Path] {
+			orphans = append(orphans, o)
+		}
+	}
+	return orphans, nil
+}
+
+// scanCloudInit finds the cloud-init ISOs of VMs defined on no host.
+func (c *Collector) scanCloudInit(vms map[string]bool) ([]*Orphan, error) {
+	if c.config.CloudInitDir == "" {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(c.config.CloudInitDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading cloud-init directory: %w", err)
+	}
+
+	var orphans []*Orphan
+	for _, entry := range entries {
+		match := cloudInitName.FindStringSubmatch(entry.Name())
+		if match == nil || !entry.Type().IsRegular() || vms[match[1]] {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// The file was removed while the directory was read
+			continue
+		}
+
+		path := filepath.Join(c.config.CloudInitDir, entry.Name())
+		orphans = append(orphans, &Orphan{
+			Kind:      KindCloudInitISO,
+			VM:        match[1],
+			Path:      path,
+			SizeBytes: uint64(info.Size()),
+			key:       cloudInitScope + "/" + path,
+			scope:     cloudInitScope,
+		})
+	}
+
+	return orphans, nil
+}
+
+// update quarantines new orphans and releases the quarantined orphans of
+// the scanned scopes that were not found again.
+func (c *Collector) update(found map[string]*Orphan, scanned map[string]bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, o := range c.quarantine {
+		if scanned[o.scope] && found[key] == nil {
+			delete(c.quarantine, key)
+			c.logger.Info("Released orphan that is in use again or gone",
+				logger.String("kind", string(o.Kind)),
+				logger.String("path", o.Path))
+		}
+	}
+
+	for key, o := range found {
+		if existing, ok := c.quarantine[key]; ok {
+			existing.SizeBytes = o.SizeBytes
+			continue
+		}
+
+		o.ID = uuid.New().String()
+		o.FoundAt = now
+		o.PurgeAt = now.Add(c.config.GracePeriod)
+		c.quarantine[key] = o
+		c.logger.Warn("Quarantined orphan",
+			logger.String("kind", string(o.Kind)),
+			logger.String("vm", o.VM),
+			logger.String("host", o.Host),
+			logger.String("path", o.Path),
+			logger.Time("purge_at", o.PurgeAt))
+	}
+}
+
+// delete deletes an orphan and removes it from the quarantine.
+func (c *Collector) delete(ctx context.Context, o *Orphan) error {
+	switch o.Kind {
+	case KindVolume:
+		if err := c.volumes.Delete(hostContext(ctx, o.Host), o.Pool, o.Volume); err != nil {
+			return fmt.Errorf("deleting volume %s/%s: %w", o.Pool, o.Volume, err)
+		}
+	case KindCloudInitISO:
+		if err := os.Remove(o.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("deleting cloud-init ISO: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	delete(c.quarantine, o.key)
+	c.mu.Unlock()
+
+	c.logger.Info("Deleted orphan",
+		logger.String("kind", string(o.Kind)),
+		logger.String("vm", o.VM),
+		logger.String("host", o.Host),
+		logger.String("path", o.Path))
+	return nil
+}
+
+// ownerName returns the VM a disk or cloud-init volume is named after, or
+// an empty string for other volumes.
+func ownerName(volume string) string {
+	if match := diskVolumeName.FindStringSubmatch(volume); match != nil {
+		return match[1]
+	}
+	if match := cloudInitName.FindStringSubmatch(volume); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// hostContext selects a host for libvirt requests.
+func hostContext(ctx context.Context, host string) context.Context {
+	if host == "" {
+		return ctx
+	}
+	return connection.WithHost(ctx, host)
+}
+
+// volumeScope is the scan scope of the volumes of a host.
+func volumeScope(host string) string {
+	return "volume/" + host
+}
+
+// sortOrphans sorts orphans by when they were found.
+func sortOrphans(orphans []*Orphan) {
+	sort.Slice(orphans, func(i, j int) bool {
+		if !orphans[i].FoundAt.Equal(orphans[j].FoundAt) {
+			return orphans[i].FoundAt.Before(orphans[j].FoundAt)
+		}
+		return orphans[i].key < orphans[j].key
+	})
+}
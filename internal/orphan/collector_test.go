@@ -0,0 +1,203 @@
+package orphan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+// collectorTestEnv holds the mocks used by the collector tests.
+type collectorTestEnv struct {
+	collector *Collector
+	domains   *mocks_domain.MockManager
+	pools     *mocks_storage.MockPoolManager
+	volumes   *mocks_storage.MockVolumeManager
+	images    *mocks_storage.MockImageLibrary
+	isoDir    string
+}
+
+func newCollectorTestEnv(t *testing.T, gracePeriod time.Duration) *collectorTestEnv {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := testutil.NewLogger(ctrl)
+
+	env := &collectorTestEnv{
+		domains: mocks_domain.NewMockManager(ctrl),
+		pools:   mocks_storage.NewMockPoolManager(ctrl),
+		volumes: mocks_storage.NewMockVolumeManager(ctrl),
+		images:  mocks_storage.NewMockImageLibrary(ctrl),
+		isoDir:  t.TempDir(),
+	}
+	env.collector = NewCollector(env.domains, env.pools, env.volumes, env.images, Config{
+		CloudInitDir: env.isoDir,
+		GracePeriod:  gracePeriod,
+	}, mockLogger)
+
+	env.pools.EXPECT().List(gomock.Any()).Return([]*storage.StoragePoolInfo{
+		{Name: "default", State: storage.StoragePoolStateRunning},
+	}, nil).AnyTimes()
+	env.images.EXPECT().List(gomock.Any()).Return([]*storage.Image{
+		{Name: "ubuntu", Pool: "default", Volume: "golden-disk-0", Path: volumePath("golden-disk-0")},
+	}, nil).AnyTimes()
+
+	return env
+}
+
+func volumePath(name string) string {
+	return "/var/lib/libvirt/images/" + name
+}
+
+// expectScan makes the pool hold the given volumes and only web defined.
+func (env *collectorTestEnv) expectScan(volumes ...*storage.StorageVolumeInfo) {
+	env.domains.EXPECT().List(gomock.Any()).Return([]*vm.VM{
+		{Name: "web", Disks: []vm.DiskInfo{{Path: volumePath("web-disk-0")}, {Path: volumePath("shared-disk-1")}}},
+	}, nil)
+	env.volumes.EXPECT().List(gomock.Any(), "default").Return(volumes, nil)
+}
+
+func newVolume(name string, backing string) *storage.StorageVolumeInfo {
+	info := &storage.StorageVolumeInfo{Name: name, Path: volumePath(name), Pool: "default", Allocation: 1024}
+	if backing != "" {
+		info.BackingStore = &storage.BackingStore{Path: volumePath(backing)}
+	}
+	return info
+}
+
+func (env *collectorTestEnv) writeISO(t *testing.T, vmName string) string {
+	path := filepath.Join(env.isoDir, vmName+"-cloudinit.iso")
+	require.NoError(t, os.WriteFile(path, []byte("iso"), 0o600))
+	return path
+}
+
+func TestCollector_ReconcileQuarantines(t *testing.T) {
+	env := newCollectorTestEnv(t, time.Hour)
+	env.writeISO(t, "web")
+	oldISO := env.writeISO(t, "old")
+
+	env.expectScan(
+		newVolume("web-disk-0", ""),
+		newVolume("web-disk-1", ""),       // named after a defined VM
+		newVolume("shared-disk-1", ""),    // a disk of another VM
+		newVolume("golden-disk-0", ""),    // a library image
+		newVolume("base-disk-0", ""),      // backs an overlay
+		newVolume("clone", "base-disk-0"), // not named after a VM
+		newVolume("gone-disk-0", ""),
+		newVolume("gone-cloudinit.iso", ""),
+	)
+
+	require.NoError(t, env.collector.Reconcile(context.Background()))
+
+	orphans, err := env.collector.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, orphans, 3)
+
+	byPath := make(map[string]*Orphan)
+	for _, o := range orphans {
+		assert.NotEmpty(t, o.ID)
+		assert.Equal(t, time.Hour, o.PurgeAt.Sub(o.FoundAt))
+		byPath[o.Path] = o
+	}
+	assert.Equal(t, KindVolume, byPath[volumePath("gone-disk-0")].Kind)
+	assert.Equal(t, "gone", byPath[volumePath("gone-disk-0")].VM)
+	assert.Equal(t, "gone-cloudinit.iso", byPath[volumePath("gone-cloudinit.iso")].Volume)
+	assert.Equal(t, KindCloudInitISO, byPath[oldISO].Kind)
+	assert.Equal(t, uint64(3), byPath[oldISO].SizeBytes)
+
+	// Nothing is deleted during the grace period
+	assert.FileExists(t, oldISO)
+}
+
+func TestCollector_ReconcileDeletesExpired(t *testing.T) {
+	env := newCollectorTestEnv(t, time.Nanosecond)
+	iso := env.writeISO(t, "gone")
+
+	env.expectScan(newVolume("gone-disk-0", ""))
+	env.volumes.EXPECT().Delete(gomock.Any(), "default", "gone-disk-0").Return(nil)
+
+	require.NoError(t, env.collector.Reconcile(context.Background()))
+
+	assert.NoFileExists(t, iso)
+	orphans, err := env.collector.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+}
+
+func TestCollector_ReconcileReleases(t *testing.T) {
+	env := newCollectorTestEnv(t, time.Hour)
+
+	env.expectScan(newVolume("gone-disk-0", ""))
+	require.NoError(t, env.collector.Reconcile(context.Background()))
+
+	// A VM with the name was defined again
+	env.domains.EXPECT().List(gomock.Any()).Return([]*vm.VM{{Name: "gone"}}, nil)
+	env.volumes.EXPECT().List(gomock.Any(), "default").Return([]*storage.StorageVolumeInfo{newVolume("gone-disk-0", "")}, nil)
+	require.NoError(t, env.collector.Reconcile(context.Background()))
+
+	orphans, err := env.collector.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+}
+
+func TestCollector_ReconcileKeepsQuarantineWhenHostFails(t *testing.T) {
+	env := newCollectorTestEnv(t, time.Hour)
+
+	env.expectScan(newVolume("gone-disk-0", ""))
+	require.NoError(t, env.collector.Reconcile(context.Background()))
+
+	env.domains.EXPECT().List(gomock.Any()).Return(nil, fmt.Errorf("connection refused"))
+	assert.Error(t, env.collector.Reconcile(context.Background()))
+
+	orphans, err := env.collector.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, orphans, 1)
+}
+
+func TestCollector_Purge(t *testing.T) {
+	env := newCollectorTestEnv(t, time.Hour)
+
+	env.expectScan(newVolume("gone-disk-0", ""), newVolume("old-disk-0", ""))
+	require.NoError(t, env.collector.Reconcile(context.Background()))
+
+	orphans, err := env.collector.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, orphans, 2)
+
+	env.expectScan(newVolume("gone-disk-0", ""), newVolume("old-disk-0", ""))
+	env.volumes.EXPECT().Delete(gomock.Any(), "default", orphans[0].Volume).Return(nil)
+	purged, err := env.collector.Purge(context.Background(), orphans[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, orphans[0].ID, purged.ID)
+
+	env.expectScan(newVolume("old-disk-0", ""))
+	_, err = env.collector.Purge(context.Background(), orphans[0].ID)
+	assert.ErrorIs(t, err, errors.ErrOrphanNotFound)
+
+	env.expectScan(newVolume("old-disk-0", ""))
+	env.volumes.EXPECT().Delete(gomock.Any(), "default", "old-disk-0").Return(nil)
+	all, err := env.collector.PurgeAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "old-disk-0", all[0].Volume)
+}
+
+func TestOwnerName(t *testing.T) {
+	assert.Equal(t, "web", ownerName("web-disk-0"))
+	assert.Equal(t, "web-1", ownerName("web-1-disk-12"))
+	assert.Equal(t, "web", ownerName("web-cloudinit.iso"))
+	assert.Empty(t, ownerName("web-disk-"))
+	assert.Empty(t, ownerName("data.qcow2"))
+}
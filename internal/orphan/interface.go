@@ -0,0 +1,63 @@
+package orphan
+
+import (
+	"context"
+	"time"
+)
+
+// Kind identifies what an orphan is.
+type Kind string
+
+const (
+	// KindVolume is a VM disk or cloud-init volume in a storage pool
+	KindVolume Kind = "volume"
+	// KindCloudInitISO is a cloud-init ISO file in the cloud-init directory
+	KindCloudInitISO Kind = "cloudinit-iso"
+)
+
+// Orphan is a VM disk volume or cloud-init ISO whose VM no longer exists.
+// Orphans are quarantined for a grace period before they are deleted.
+type Orphan struct {
+	// FoundAt is when the orphan was first found
+	FoundAt time.Time `json:"foundAt"`
+	// PurgeAt is when the grace period ends and the orphan is deleted
+	PurgeAt time.Time `json:"purgeAt"`
+	ID      string    `json:"id"`
+	Kind    Kind      `json:"kind"`
+	// VM is the VM the orphan was named after
+	VM     string `json:"vm"`
+	Host   string `json:"host,omitempty"`
+	Pool   string `json:"pool,omitempty"`
+	Volume string `json:"volume,omitempty"`
+	Path   string `json:"path"`
+	// SizeBytes is the space the orphan takes up
+	SizeBytes uint64 `json:"sizeBytes"`
+
+	// key identifies the resource across scans
+	key string
+	// scope is the part of a scan that finds the orphan
+	scope string
+}
+
+// Manager finds, quarantines and deletes orphaned VM disk volumes and
+// cloud-init ISOs.
+type Manager interface {
+	// Reconcile cross-references the pool volumes and cloud-init ISOs named
+	// after VMs against the defined domains. New orphans are quarantined,
+	// orphans that are in use again are released and orphans past their
+	// grace period are deleted.
+	Reconcile(ctx context.Context) error
+
+	// List lists the quarantined orphans
+	List(ctx context.Context) ([]*Orphan, error)
+
+	// Purge deletes a quarantined orphan before its grace period ends. The
+	// orphan is checked again first.
+	Purge(ctx context.Context, id string) (*Orphan, error)
+
+	// PurgeAll deletes all quarantined orphans that are still orphaned
+	PurgeAll(ctx context.Context) ([]*Orphan, error)
+
+	// Start reconciles in the background until the context is canceled
+	Start(ctx context.Context, interval time.Duration)
+}
@@ -0,0 +1,121 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+
+	"github.com/threatflux/libgo/internal/compute"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+)
+
+// QuiesceMode selects whether scheduled snapshots quiesce guest filesystems.
+type QuiesceMode string
+
+// Quiesce modes.
+const (
+	// QuiesceNever takes crash-consistent snapshots
+	QuiesceNever QuiesceMode = "never"
+	// QuiescePreferred quiesces when the guest agent responds and falls back to
+	// crash-consistent snapshots otherwise
+	QuiescePreferred QuiesceMode = "preferred"
+	// QuiesceRequired fails the snapshot when the guest cannot be quiesced
+	QuiesceRequired QuiesceMode = "required"
+)
+
+// Run status constants.
+const (
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial"
+	RunStatusFailed  = "failed"
+)
+
+// Retention decides which snapshots of a policy are kept. A snapshot is kept
+// when any rule keeps it; a policy without rules keeps all of its snapshots.
+type Retention struct {
+	// KeepLast keeps the newest N snapshots
+	KeepLast int `json:"keepLast,omitempty"`
+	// KeepDailyDays keeps the newest snapshot of each of the last D days
+	KeepDailyDays int `json:"keepDailyDays,omitempty"`
+}
+
+// Hooks are programs run in the guest through the guest agent around each
+// scheduled snapshot of a running VM.
+type Hooks struct {
+	// Pre runs before the snapshot; the snapshot is skipped when it fails
+	Pre *vmmodels.GuestCommand `json:"pre,omitempty"`
+	// Post runs after the snapshot whenever the pre hook succeeded
+	Post *vmmodels.GuestCommand `json:"post,omitempty"`
+}
+
+// Policy snapshots a set of VMs on a cron schedule and prunes the snapshots
+// its retention no longer keeps.
+type Policy struct {
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// LastRun is when the policy last started running
+	LastRun time.Time `json:"lastRun,omitempty"`
+	// NextRun is when the policy runs next, zero while it is disabled
+	NextRun time.Time `json:"nextRun,omitempty"`
+	// Selector picks compute instances carrying all of these labels
+	Selector map[string]string `json:"selector,omitempty"`
+	// Instances names VMs to snapshot
+	Instances []string  `json:"instances,omitempty"`
+	Hooks     Hooks     `json:"hooks"`
+	Retention Retention `json:"retention"`
+	ID        string    `json:"id"`
+	// Name prefixes the names of the snapshots the policy takes
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description,omitempty"`
+	// Schedule is a five-field cron expression or a descriptor such as @daily,
+	// evaluated in the server's time zone
+	Schedule string `json:"schedule" binding:"required"`
+	// Host is the libvirt host of the VMs named in Instances; instances
+	// matched by the selector run on their own host
+	Host       string      `json:"host,omitempty"`
+	Quiesce    QuiesceMode `json:"quiesce,omitempty"`
+	LastStatus string      `json:"lastStatus,omitempty"`
+	LastError  string      `json:"lastError,omitempty"`
+	// External takes external disk-only snapshots
+	External bool `json:"external,omitempty"`
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Manager defines interface for snapshot policy management.
+type Manager interface {
+	// CreatePolicy validates and stores a new policy
+	CreatePolicy(ctx context.Context, policy Policy) (*Policy, error)
+
+	// GetPolicy gets a policy by ID
+	GetPolicy(ctx context.Context, id string) (*Policy, error)
+
+	// ListPolicies lists all policies
+	ListPolicies(ctx context.Context) ([]*Policy, error)
+
+	// UpdatePolicy replaces the settings of a policy
+	UpdatePolicy(ctx context.Context, id string, policy Policy) (*Policy, error)
+
+	// DeletePolicy deletes a policy; snapshots it took are kept
+	DeletePolicy(ctx context.Context, id string) error
+
+	// RunPolicy starts running a policy now, outside of its schedule
+	RunPolicy(ctx context.Context, id string) error
+
+	// Start runs due policies in the background until the context is canceled
+	Start(ctx context.Context, interval time.Duration)
+}
+
+// Store persists snapshot policies.
+type Store interface {
+	Create(ctx context.Context, policy *Policy) error
+	Get(ctx context.Context, id string) (*Policy, error)
+	List(ctx context.Context) ([]*Policy, error)
+	Update(ctx context.Context, policy *Policy) error
+	Delete(ctx context.Context, id string) error
+}
+
+// InstanceSource resolves label selectors to compute instances and records
+// the outcome of scheduled snapshots as instance events.
+type InstanceSource interface {
+	ListAllInstances(ctx context.Context, opts compute.ComputeInstanceListOptions) ([]*compute.ComputeInstance, error)
+	RecordInstanceEvent(ctx context.Context, event compute.InstanceEvent)
+}
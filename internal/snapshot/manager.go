@@ -0,0 +1,557 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/compute"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/internal/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// DefaultCheckInterval is how often the scheduler looks for due policies.
+const DefaultCheckInterval = 30 * time.Second
+
+// maxPolicyNameLength leaves room in snapshot names for the timestamp suffix.
+const maxPolicyNameLength = 64
+
+// policyNamePattern restricts policy names to characters valid in snapshot names.
+var policyNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Instance event fields of scheduled snapshots.
+const (
+	eventType   = "snapshot"
+	eventAction = "scheduled-snapshot"
+)
+
+// target is a VM a policy snapshots.
+type target struct {
+	name       string
+	host       string
+	instanceID string
+}
+
+// PolicyManager implements Manager.
+type PolicyManager struct {
+	store     Store
+	vmManager vm.Manager
+	instances InstanceSource
+	logger    logger.Logger
+	now       func() time.Time
+	running   map[string]bool
+	mu        sync.Mutex
+}
+
+// NewPolicyManager creates a new PolicyManager.
+func NewPolicyManager(store Store, vmManager vm.Manager, instances InstanceSource, logger logger.Logger) *PolicyManager {
+	return &PolicyManager{
+		store:     store,
+		vmManager: vmManager,
+		instances: instances,
+		logger:    logger,
+		now:       time.Now,
+		running:   make(map[string]bool),
+	}
+}
+
+// CreatePolicy implements Manager.CreatePolicy.
+func (m *PolicyManager) CreatePolicy(ctx context.Context, policy Policy) (*Policy, error) {
+	sched, err := validatePolicy(&policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.checkNameAvailable(ctx, policy.Name, ""); err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	policy.ID = uuid.New().String()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	policy.LastRun = time.Time{}
+	policy.LastStatus = ""
+	policy.LastError = ""
+	policy.NextRun = nextRun(&policy, sched, now)
+
+	if err := m.store.Create(ctx, &policy); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Created snapshot policy",
+		logger.String("id", policy.ID),
+		logger.String("name", policy.Name),
+		logger.String("schedule", policy.Schedule))
+
+	return &policy, nil
+}
+
+// GetPolicy implements Manager.GetPolicy.
+func (m *PolicyManager) GetPolicy(ctx context.Context, id string) (*Policy, error) {
+	return m.store.Get(ctx, id)
+}
+
+// ListPolicies implements Manager.ListPolicies.
+func (m *PolicyManager) ListPolicies(ctx context.Context) ([]*Policy, error) {
+	return m.store.List(ctx)
+}
+
+// UpdatePolicy implements Manager.UpdatePolicy. The run history of the
+// policy is kept and its next run is computed from the new schedule.
+func (m *PolicyManager) UpdatePolicy(ctx context.Context, id string, policy Policy) (*Policy, error) {
+	existing, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sched, err := validatePolicy(&policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.Name != existing.Name {
+		if err := m.checkNameAvailable(ctx, policy.Name, id); err != nil {
+			return nil, err
+		}
+	}
+
+	now := m.now()
+	policy.ID = existing.ID
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = now
+	policy.LastRun = existing.LastRun
+	policy.LastStatus = existing.LastStatus
+	policy.LastError = existing.LastError
+	policy.NextRun = nextRun(&policy, sched, now)
+
+	if err := m.store.Update(ctx, &policy); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Updated snapshot policy",
+		logger.String("id", policy.ID),
+		logger.String("name", policy.Name))
+
+	return &policy, nil
+}
+
+// DeletePolicy implements Manager.DeletePolicy.
+func (m *PolicyManager) DeletePolicy(ctx context.Context, id string) error {
+	if err := m.store.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	m.logger.Info("Deleted snapshot policy", logger.String("id", id))
+	return nil
+}
+
+// RunPolicy implements Manager.RunPolicy. Disabled policies can be run on
+// demand.
+func (m *PolicyManager) RunPolicy(ctx context.Context, id string) error {
+	policy, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !m.markRunning(policy.ID) {
+		return fmt.Errorf("%w: %s", apierrors.ErrSnapshotPolicyRunning, policy.Name)
+	}
+
+	// The run outlives the request that started it
+	go m.execute(context.WithoutCancel(ctx), policy)
+
+	return nil
+}
+
+// Start implements Manager.Start.
+func (m *PolicyManager) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		m.runDuePolicies(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.runDuePolicies(ctx)
+			}
+		}
+	}()
+}
+
+// runDuePolicies starts the enabled policies whose next run has come. A
+// policy that was due while the server was down runs once.
+func (m *PolicyManager) runDuePolicies(ctx context.Context) {
+	policies, err := m.store.List(ctx)
+	if err != nil {
+		m.logger.Error("Failed to list snapshot policies", logger.Error(err))
+		return
+	}
+
+	now := m.now()
+	for _, policy := range policies {
+		if policy.Disabled || policy.NextRun.IsZero() || policy.NextRun.After(now) {
+			continue
+		}
+		if !m.markRunning(policy.ID) {
+			continue
+		}
+		go m.execute(ctx, policy)
+	}
+}
+
+// markRunning marks a policy as running unless it already is.
+func (m *PolicyManager) markRunning(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running[id] {
+		return false
+	}
+	m.running[id] = true
+	return true
+}
+
+// markDone marks a policy as no longer running.
+func (m *PolicyManager) markDone(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.running, id)
+}
+
+// execute snapshots every target of a policy and records the outcome of the run.
+func (m *PolicyManager) execute(ctx context.Context, policy *Policy) {
+	defer m.markDone(policy.ID)
+
+	started := m.now()
+	m.logger.Info("Running snapshot policy",
+		logger.String("id", policy.ID),
+		logger.String("name", policy.Name))
+
+	var failures []string
+	succeeded := 0
+
+	targets, err := m.resolveTargets(ctx, policy)
+	if err != nil {
+		failures = append(failures, err.Error())
+	}
+
+	for _, t := range targets {
+		if err := m.snapshotTarget(ctx, policy, t, started); err != nil {
+			m.logger.Error("Scheduled snapshot failed",
+				logger.String("policy", policy.Name),
+				logger.String("vm", t.name),
+				logger.String("host", t.host),
+				logger.Error(err))
+			failures = append(failures, fmt.Sprintf("%s: %v", t.name, err))
+			continue
+		}
+		succeeded++
+	}
+
+	status := RunStatusSuccess
+	switch {
+	case len(failures) > 0 && succeeded == 0:
+		status = RunStatusFailed
+	case len(failures) > 0:
+		status = RunStatusPartial
+	}
+
+	m.recordRun(ctx, policy.ID, started, status, strings.Join(failures, "; "))
+
+	m.logger.Info("Snapshot policy run finished",
+		logger.String("name", policy.Name),
+		logger.String("status", status),
+		logger.Int("succeeded", succeeded),
+		logger.Int("failed", len(failures)))
+}
+
+// recordRun stores the outcome of a run. The policy is read again so that
+// changes made while it ran are kept.
+func (m *PolicyManager) recordRun(ctx context.Context, id string, started time.Time, status, runErr string) {
+	policy, err := m.store.Get(ctx, id)
+	if err != nil {
+		// The policy was deleted while it ran
+		m.logger.Debug("Not recording snapshot policy run",
+			logger.String("id", id),
+			logger.Error(err))
+		return
+	}
+
+	policy.LastRun = started
+	policy.LastStatus = status
+	policy.LastError = runErr
+
+	sched, err := parseSchedule(policy.Schedule)
+	if err != nil {
+		m.logger.Error("Invalid stored snapshot policy schedule",
+			logger.String("id", id),
+			logger.Error(err))
+		policy.NextRun = time.Time{}
+	} else {
+		policy.NextRun = nextRun(policy, sched, m.now())
+	}
+
+	if err := m.store.Update(ctx, policy); err != nil {
+		m.logger.Error("Failed to record snapshot policy run",
+			logger.String("id", id),
+			logger.Error(err))
+	}
+}
+
+// resolveTargets returns the VMs named by a policy and the KVM instances
+// matched by its selector, without duplicates.
+func (m *PolicyManager) resolveTargets(ctx context.Context, policy *Policy) ([]target, error) {
+	var targets []target
+	seen := make(map[string]bool)
+
+	add := func(t target) {
+		key := t.host + "/" + t.name
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, t)
+		}
+	}
+
+	for _, name := range policy.Instances {
+		add(target{name: name, host: policy.Host})
+	}
+
+	if len(policy.Selector) == 0 {
+		return targets, nil
+	}
+
+	instances, err := m.instances.ListAllInstances(ctx, compute.ComputeInstanceListOptions{
+		Labels:  policy.Selector,
+		Backend: compute.BackendKVM,
+	})
+	if err != nil {
+		return targets, fmt.Errorf("resolving selector: %w", err)
+	}
+
+	for _, instance := range instances {
+		add(target{name: instance.Name, host: instance.Host, instanceID: instance.ID})
+	}
+
+	return targets, nil
+}
+
+// snapshotTarget takes a snapshot of one VM, prunes the snapshots the
+// retention no longer keeps and records the outcome as an instance event.
+func (m *PolicyManager) snapshotTarget(ctx context.Context, policy *Policy, t target, started time.Time) error {
+	if t.host != "" {
+		ctx = connection.WithHost(ctx, t.host)
+	}
+
+	details := map[string]interface{}{
+		"policy":   policy.Name,
+		"policyId": policy.ID,
+	}
+	if t.host != "" {
+		details["host"] = t.host
+	}
+
+	snapshot, err := m.takeSnapshot(ctx, policy, &t, started, details)
+	if err == nil {
+		details["snapshot"] = snapshot.Name
+
+		pruned, pruneErr := m.prune(ctx, policy, t.name)
+		if len(pruned) > 0 {
+			details["pruned"] = pruned
+		}
+		if pruneErr != nil {
+			err = fmt.Errorf("pruning snapshots: %w", pruneErr)
+		}
+	}
+
+	event := compute.InstanceEvent{
+		InstanceID: t.instanceID,
+		Type:       eventType,
+		Action:     eventAction,
+		Status:     RunStatusSuccess,
+		Message:    fmt.Sprintf("Snapshot policy %s snapshotted %s", policy.Name, t.name),
+		Details:    details,
+	}
+	if err != nil {
+		event.Status = RunStatusFailed
+		event.Message = fmt.Sprintf("Snapshot policy %s failed for %s: %v", policy.Name, t.name, err)
+	}
+	m.instances.RecordInstanceEvent(ctx, event)
+
+	return err
+}
+
+// takeSnapshot runs the hooks of a policy around a snapshot of a running VM.
+// Stopped VMs are snapshotted without hooks or quiescing.
+func (m *PolicyManager) takeSnapshot(ctx context.Context, policy *Policy, t *target, started time.Time, details map[string]interface{}) (*vmmodels.Snapshot, error) {
+	machine, err := m.vmManager.Get(ctx, t.name)
+	if err != nil {
+		return nil, err
+	}
+	if t.instanceID == "" {
+		t.instanceID = machine.UUID
+	}
+
+	running := machine.Status == vmmodels.VMStatusRunning
+
+	if running && policy.Hooks.Pre != nil {
+		if err := m.runHook(ctx, t.name, "pre", *policy.Hooks.Pre); err != nil {
+			return nil, err
+		}
+	}
+	if running && policy.Hooks.Post != nil {
+		defer func() {
+			if err := m.runHook(ctx, t.name, "post", *policy.Hooks.Post); err != nil {
+				m.logger.Warn("Post-snapshot hook failed",
+					logger.String("policy", policy.Name),
+					logger.String("vm", t.name),
+					logger.Error(err))
+				details["postHookError"] = err.Error()
+			}
+		}()
+	}
+
+	params := vmmodels.SnapshotParams{
+		Name:        snapshotName(policy.Name, started),
+		Description: fmt.Sprintf("Taken by snapshot policy %s", policy.Name),
+		Quiesce:     running && policy.Quiesce != QuiesceNever,
+		External:    policy.External,
+	}
+
+	snapshot, err := m.vmManager.CreateSnapshot(ctx, t.name, params)
+	if err != nil && params.Quiesce && policy.Quiesce == QuiescePreferred &&
+		errors.Is(err, domain.ErrGuestAgentUnavailable) {
+		m.logger.Debug("Guest cannot be quiesced, taking crash-consistent snapshot",
+			logger.String("vm", t.name),
+			logger.Error(err))
+		details["quiesced"] = false
+		params.Quiesce = false
+		snapshot, err = m.vmManager.CreateSnapshot(ctx, t.name, params)
+	} else if err == nil {
+		details["quiesced"] = params.Quiesce
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// runHook runs a hook command in the guest. A non-zero exit status fails the hook.
+func (m *PolicyManager) runHook(ctx context.Context, vmName, stage string, command vmmodels.GuestCommand) error {
+	result, err := m.vmManager.ExecGuestCommand(ctx, vmName, command)
+	if err != nil {
+		return fmt.Errorf("%s-snapshot hook: %w", stage, err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("%s-snapshot hook exited with status %d: %s",
+			stage, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return nil
+}
+
+// prune deletes the snapshots of a VM that a policy took and its retention
+// no longer keeps, and returns their names.
+func (m *PolicyManager) prune(ctx context.Context, policy *Policy, vmName string) ([]string, error) {
+	snapshots, err := m.vmManager.ListSnapshots(ctx, vmName, vmmodels.SnapshotListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+
+	var pruned []string
+	for _, snapshot := range expiredSnapshots(policy.Retention, policySnapshots(policy.Name, snapshots), m.now()) {
+		if err := m.vmManager.DeleteSnapshot(ctx, vmName, snapshot.Name); err != nil {
+			return pruned, fmt.Errorf("deleting snapshot %s: %w", snapshot.Name, err)
+		}
+		pruned = append(pruned, snapshot.Name)
+	}
+
+	return pruned, nil
+}
+
+// checkNameAvailable fails when another policy has the name.
+func (m *PolicyManager) checkNameAvailable(ctx context.Context, name, exceptID string) error {
+	policies, err := m.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if policy.Name == name && policy.ID != exceptID {
+			return fmt.Errorf("%w: snapshot policy %s", apierrors.ErrAlreadyExists, name)
+		}
+	}
+
+	return nil
+}
+
+// nextRun returns when a policy runs next, or zero when it is disabled.
+func nextRun(policy *Policy, sched *schedule, now time.Time) time.Time {
+	if policy.Disabled {
+		return time.Time{}
+	}
+	return sched.next(now)
+}
+
+// validatePolicy checks a policy, fills in defaults and returns its parsed schedule.
+func validatePolicy(policy *Policy) (*schedule, error) {
+	if len(policy.Name) > maxPolicyNameLength || !policyNamePattern.MatchString(policy.Name) {
+		return nil, fmt.Errorf("%w: policy name must be at most %d letters, digits, '.', '_' or '-'",
+			apierrors.ErrInvalidParameter, maxPolicyNameLength)
+	}
+
+	sched, err := parseSchedule(policy.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", apierrors.ErrInvalidParameter, err)
+	}
+	if sched.next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: schedule %q never runs", apierrors.ErrInvalidParameter, policy.Schedule)
+	}
+
+	if len(policy.Instances) == 0 && len(policy.Selector) == 0 {
+		return nil, fmt.Errorf("%w: policy needs instances or a selector", apierrors.ErrInvalidParameter)
+	}
+	for _, name := range policy.Instances {
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty instance name", apierrors.ErrInvalidParameter)
+		}
+	}
+
+	if policy.Retention.KeepLast < 0 || policy.Retention.KeepDailyDays < 0 {
+		return nil, fmt.Errorf("%w: retention counts must not be negative", apierrors.ErrInvalidParameter)
+	}
+
+	switch policy.Quiesce {
+	case "":
+		policy.Quiesce = QuiescePreferred
+	case QuiesceNever, QuiescePreferred, QuiesceRequired:
+	default:
+		return nil, fmt.Errorf("%w: unknown quiesce mode %q", apierrors.ErrInvalidParameter, policy.Quiesce)
+	}
+
+	for stage, hook := range map[string]*vmmodels.GuestCommand{"pre": policy.Hooks.Pre, "post": policy.Hooks.Post} {
+		if hook != nil && (len(hook.Command) == 0 || hook.Command[0] == "") {
+			return nil, fmt.Errorf("%w: %s hook has no command", apierrors.ErrInvalidParameter, stage)
+		}
+	}
+
+	return sched, nil
+}
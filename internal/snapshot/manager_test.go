@@ -0,0 +1,310 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/compute"
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	mocks_vm "github.com/threatflux/libgo/test/mocks/vm"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+// memoryStore is an in-memory Store.
+type memoryStore struct {
+	policies map[string]Policy
+	mu       sync.Mutex
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{policies: make(map[string]Policy)}
+}
+
+func (s *memoryStore) Create(_ context.Context, policy *Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[policy.ID] = *policy
+	return nil
+}
+
+func (s *memoryStore) Get(_ context.Context, id string) (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	policy, ok := s.policies[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", apierrors.ErrSnapshotPolicyNotFound, id)
+	}
+	return &policy, nil
+}
+
+func (s *memoryStore) List(_ context.Context) ([]*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	policies := make([]*Policy, 0, len(s.policies))
+	for _, policy := range s.policies {
+		policy := policy
+		policies = append(policies, &policy)
+	}
+	return policies, nil
+}
+
+func (s *memoryStore) Update(_ context.Context, policy *Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.policies[policy.ID]; !ok {
+		return fmt.Errorf("%w: %s", apierrors.ErrSnapshotPolicyNotFound, policy.ID)
+	}
+	s.policies[policy.ID] = *policy
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.policies, id)
+	return nil
+}
+
+// fakeInstances is an InstanceSource returning fixed instances.
+type fakeInstances struct {
+	instances []*compute.ComputeInstance
+	events    []compute.InstanceEvent
+	opts      compute.ComputeInstanceListOptions
+}
+
+func (f *fakeInstances) ListAllInstances(_ context.Context, opts compute.ComputeInstanceListOptions) ([]*compute.ComputeInstance, error) {
+	f.opts = opts
+	return f.instances, nil
+}
+
+func (f *fakeInstances) RecordInstanceEvent(_ context.Context, event compute.InstanceEvent) {
+	f.events = append(f.events, event)
+}
+
+// policyTestEnv holds the manager under test and its dependencies.
+type policyTestEnv struct {
+	manager   *PolicyManager
+	store     *memoryStore
+	vms       *mocks_vm.MockManager
+	instances *fakeInstances
+	now       time.Time
+}
+
+func newPolicyTestEnv(t *testing.T) *policyTestEnv {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := testutil.NewLogger(ctrl)
+
+	env := &policyTestEnv{
+		store:     newMemoryStore(),
+		vms:       mocks_vm.NewMockManager(ctrl),
+		instances: &fakeInstances{},
+		now:       time.Date(2024, 5, 15, 2, 0, 0, 0, time.UTC),
+	}
+	env.manager = NewPolicyManager(env.store, env.vms, env.instances, mockLogger)
+	env.manager.now = func() time.Time { return env.now }
+
+	return env
+}
+
+func TestPolicyManager_CreatePolicy(t *testing.T) {
+	env := newPolicyTestEnv(t)
+	ctx := context.Background()
+
+	policy, err := env.manager.CreatePolicy(ctx, Policy{
+		Name:      "nightly",
+		Schedule:  "0 3 * * *",
+		Instances: []string{"web-1"},
+	})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, policy.ID)
+	assert.Equal(t, QuiescePreferred, policy.Quiesce)
+	assert.Equal(t, time.Date(2024, 5, 15, 3, 0, 0, 0, time.UTC), policy.NextRun)
+
+	_, err = env.manager.CreatePolicy(ctx, Policy{
+		Name:      "nightly",
+		Schedule:  "@daily",
+		Instances: []string{"web-2"},
+	})
+	assert.ErrorIs(t, err, apierrors.ErrAlreadyExists)
+}
+
+func TestPolicyManager_CreatePolicyInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+	}{
+		{"bad name", Policy{Name: "my policy", Schedule: "@daily", Instances: []string{"vm"}}},
+		{"bad schedule", Policy{Name: "p", Schedule: "every day", Instances: []string{"vm"}}},
+		{"never runs", Policy{Name: "p", Schedule: "0 0 31 feb *", Instances: []string{"vm"}}},
+		{"no targets", Policy{Name: "p", Schedule: "@daily"}},
+		{"negative retention", Policy{Name: "p", Schedule: "@daily", Instances: []string{"vm"}, Retention: Retention{KeepLast: -1}}},
+		{"bad quiesce", Policy{Name: "p", Schedule: "@daily", Instances: []string{"vm"}, Quiesce: "always"}},
+		{"empty hook", Policy{Name: "p", Schedule: "@daily", Instances: []string{"vm"}, Hooks: Hooks{Pre: &vmmodels.GuestCommand{}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPolicyTestEnv(t)
+			_, err := env.manager.CreatePolicy(context.Background(), tt.policy)
+			assert.ErrorIs(t, err, apierrors.ErrInvalidParameter)
+		})
+	}
+}
+
+func TestPolicyManager_UpdatePolicyKeepsRunHistory(t *testing.T) {
+	env := newPolicyTestEnv(t)
+	ctx := context.Background()
+
+	policy, err := env.manager.CreatePolicy(ctx, Policy{Name: "nightly", Schedule: "@daily", Instances: []string{"web-1"}})
+	require.NoError(t, err)
+
+	policy.LastStatus = RunStatusSuccess
+	require.NoError(t, env.store.Update(ctx, policy))
+
+	updated, err := env.manager.UpdatePolicy(ctx, policy.ID, Policy{
+		Name:      "nightly",
+		Schedule:  "@hourly",
+		Instances: []string{"web-1"},
+		Disabled:  true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, policy.ID, updated.ID)
+	assert.Equal(t, RunStatusSuccess, updated.LastStatus)
+	assert.True(t, updated.NextRun.IsZero())
+}
+
+func TestPolicyManager_Execute(t *testing.T) {
+	env := newPolicyTestEnv(t)
+	ctx := context.Background()
+
+	env.instances.instances = []*compute.ComputeInstance{
+		{ID: "uuid-db-1", Name: "db-1", Host: "host-b"},
+	}
+
+	policy, err := env.manager.CreatePolicy(ctx, Policy{
+		Name:      "nightly",
+		Schedule:  "0 3 * * *",
+		Instances: []string{"db-1"},
+		Host:      "host-b",
+		Selector:  map[string]string{"tier": "db"},
+		Retention: Retention{KeepLast: 2},
+		Hooks: Hooks{
+			Pre:  &vmmodels.GuestCommand{Command: []string{"/usr/local/bin/flush-db"}},
+			Post: &vmmodels.GuestCommand{Command: []string{"/usr/local/bin/resume-db"}},
+		},
+	})
+	require.NoError(t, err)
+
+	onHostB := gomock.Cond(func(x any) bool {
+		host, _ := connection.HostFromContext(x.(context.Context))
+		return host == "host-b"
+	})
+	older := env.now.Add(-48 * time.Hour)
+	old := env.now.Add(-24 * time.Hour)
+
+	gomock.InOrder(
+		env.vms.EXPECT().Get(onHostB, "db-1").
+			Return(&vmmodels.VM{Name: "db-1", UUID: "uuid-db-1", Status: vmmodels.VMStatusRunning}, nil),
+		env.vms.EXPECT().ExecGuestCommand(onHostB, "db-1", *policy.Hooks.Pre).
+			Return(&vmmodels.GuestCommandResult{}, nil),
+		env.vms.EXPECT().CreateSnapshot(onHostB, "db-1", gomock.Any()).
+			Return(nil, fmt.Errorf("quiescing: %w", domain.ErrGuestAgentUnavailable)),
+		env.vms.EXPECT().CreateSnapshot(onHostB, "db-1", vmmodels.SnapshotParams{
+			Name:        "nightly-20240515-020000",
+			Description: "Taken by snapshot policy nightly",
+		}).Return(&vmmodels.Snapshot{Name: "nightly-20240515-020000", CreatedAt: env.now}, nil),
+		env.vms.EXPECT().ExecGuestCommand(onHostB, "db-1", *policy.Hooks.Post).
+			Return(&vmmodels.GuestCommandResult{}, nil),
+		env.vms.EXPECT().ListSnapshots(onHostB, "db-1", gomock.Any()).Return([]*vmmodels.Snapshot{
+			{Name: "nightly-20240515-020000", CreatedAt: env.now},
+			{Name: snapshotName("nightly", old), CreatedAt: old},
+			{Name: snapshotName("nightly", older), CreatedAt: older},
+			{Name: "before-upgrade", CreatedAt: older},
+		}, nil),
+		env.vms.EXPECT().DeleteSnapshot(onHostB, "db-1", snapshotName("nightly", older)).Return(nil),
+	)
+
+	require.True(t, env.manager.markRunning(policy.ID))
+	env.manager.execute(ctx, policy)
+
+	assert.Equal(t, map[string]string{"tier": "db"}, env.instances.opts.Labels)
+	assert.Equal(t, compute.BackendKVM, env.instances.opts.Backend)
+
+	require.Len(t, env.instances.events, 1)
+	event := env.instances.events[0]
+	assert.Equal(t, "uuid-db-1", event.InstanceID)
+	assert.Equal(t, RunStatusSuccess, event.Status)
+	assert.Equal(t, false, event.Details["quiesced"])
+	assert.Equal(t, []string{snapshotName("nightly", older)}, event.Details["pruned"])
+
+	stored, err := env.store.Get(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSuccess, stored.LastStatus)
+	assert.Equal(t, env.now, stored.LastRun)
+	assert.Equal(t, time.Date(2024, 5, 15, 3, 0, 0, 0, time.UTC), stored.NextRun)
+	assert.True(t, env.manager.markRunning(policy.ID), "run should release the policy")
+}
+
+func TestPolicyManager_ExecutePreHookFails(t *testing.T) {
+	env := newPolicyTestEnv(t)
+	ctx := context.Background()
+
+	policy, err := env.manager.CreatePolicy(ctx, Policy{
+		Name:      "nightly",
+		Schedule:  "@daily",
+		Instances: []string{"web-1", "web-2"},
+		Quiesce:   QuiesceNever,
+		Hooks:     Hooks{Pre: &vmmodels.GuestCommand{Command: []string{"/bin/false"}}},
+	})
+	require.NoError(t, err)
+
+	env.vms.EXPECT().Get(gomock.Any(), "web-1").
+		Return(&vmmodels.VM{Name: "web-1", UUID: "uuid-1", Status: vmmodels.VMStatusRunning}, nil)
+	env.vms.EXPECT().ExecGuestCommand(gomock.Any(), "web-1", gomock.Any()).
+		Return(&vmmodels.GuestCommandResult{ExitCode: 1, Stderr: "busy\n"}, nil)
+
+	// Stopped VMs are snapshotted without hooks
+	env.vms.EXPECT().Get(gomock.Any(), "web-2").
+		Return(&vmmodels.VM{Name: "web-2", UUID: "uuid-2", Status: vmmodels.VMStatusStopped}, nil)
+	env.vms.EXPECT().CreateSnapshot(gomock.Any(), "web-2", gomock.Any()).
+		Return(&vmmodels.Snapshot{Name: "nightly-20240515-020000"}, nil)
+	env.vms.EXPECT().ListSnapshots(gomock.Any(), "web-2", gomock.Any()).Return(nil, nil)
+
+	env.manager.execute(ctx, policy)
+
+	require.Len(t, env.instances.events, 2)
+	assert.Equal(t, RunStatusFailed, env.instances.events[0].Status)
+	assert.Contains(t, env.instances.events[0].Message, "busy")
+	assert.Equal(t, RunStatusSuccess, env.instances.events[1].Status)
+
+	stored, err := env.store.Get(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusPartial, stored.LastStatus)
+	assert.Contains(t, stored.LastError, "web-1")
+}
+
+func TestPolicyManager_RunPolicyAlreadyRunning(t *testing.T) {
+	env := newPolicyTestEnv(t)
+	ctx := context.Background()
+
+	policy, err := env.manager.CreatePolicy(ctx, Policy{Name: "nightly", Schedule: "@daily", Instances: []string{"web-1"}})
+	require.NoError(t, err)
+
+	require.True(t, env.manager.markRunning(policy.ID))
+
+	err = env.manager.RunPolicy(ctx, policy.ID)
+	assert.ErrorIs(t, err, apierrors.ErrSnapshotPolicyRunning)
+}
@@ -0,0 +1,83 @@
+package snapshot
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+)
+
+// snapshotTimeFormat is the timestamp suffix of snapshots taken by a policy.
+const snapshotTimeFormat = "20060102-150405"
+
+// snapshotName returns the name of the snapshot a policy takes at a time.
+func snapshotName(policyName string, t time.Time) string {
+	return policyName + "-" + t.UTC().Format(snapshotTimeFormat)
+}
+
+// policySnapshots returns the snapshots taken by a policy, newest first.
+func policySnapshots(policyName string, snapshots []*vmmodels.Snapshot) []*vmmodels.Snapshot {
+	prefix := policyName + "-"
+
+	var result []*vmmodels.Snapshot
+	for _, snapshot := range snapshots {
+		suffix, ok := strings.CutPrefix(snapshot.Name, prefix)
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse(snapshotTimeFormat, suffix); err != nil {
+			continue
+		}
+		result = append(result, snapshot)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result
+}
+
+// expiredSnapshots returns the snapshots a retention no longer keeps. The
+// snapshots must be ordered newest first; days are calendar days in the
+// location of now.
+func expiredSnapshots(retention Retention, snapshots []*vmmodels.Snapshot, now time.Time) []*vmmodels.Snapshot {
+	if retention.KeepLast <= 0 && retention.KeepDailyDays <= 0 {
+		return nil
+	}
+
+	keep := make(map[string]bool)
+
+	for i := 0; i < retention.KeepLast && i < len(snapshots); i++ {
+		keep[snapshots[i].Name] = true
+	}
+
+	if retention.KeepDailyDays > 0 {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		oldest := today.AddDate(0, 0, 1-retention.KeepDailyDays)
+
+		days := make(map[string]bool)
+		for _, snapshot := range snapshots {
+			created := snapshot.CreatedAt.In(now.Location())
+			if created.Before(oldest) {
+				continue
+			}
+
+			day := created.Format(time.DateOnly)
+			if !days[day] {
+				days[day] = true
+				keep[snapshot.Name] = true
+			}
+		}
+	}
+
+	var expired []*vmmodels.Snapshot
+	for _, snapshot := range snapshots {
+		if !keep[snapshot.Name] {
+			expired = append(expired, snapshot)
+		}
+	}
+
+	return expired
+}
@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+)
+
+// testSnapshots returns snapshots of the nightly policy taken at the given
+// times, newest first, plus one snapshot taken by hand.
+func testSnapshots(times ...time.Time) []*vmmodels.Snapshot {
+	snapshots := []*vmmodels.Snapshot{{Name: "before-upgrade", CreatedAt: times[0]}}
+	for _, t := range times {
+		snapshots = append(snapshots, &vmmodels.Snapshot{Name: snapshotName("nightly", t), CreatedAt: t})
+	}
+	return snapshots
+}
+
+func names(snapshots []*vmmodels.Snapshot) []string {
+	var result []string
+	for _, snapshot := range snapshots {
+		result = append(result, snapshot.Name)
+	}
+	return result
+}
+
+func TestSnapshotName(t *testing.T) {
+	at := time.Date(2024, 5, 15, 2, 0, 3, 0, time.FixedZone("CEST", 2*60*60))
+	assert.Equal(t, "nightly-20240515-000003", snapshotName("nightly", at))
+}
+
+func TestPolicySnapshots(t *testing.T) {
+	older := time.Date(2024, 5, 14, 2, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 5, 15, 2, 0, 0, 0, time.UTC)
+
+	snapshots := []*vmmodels.Snapshot{
+		{Name: snapshotName("nightly", older), CreatedAt: older},
+		{Name: "nightly-manual", CreatedAt: newer},
+		{Name: snapshotName("nightly-db", newer), CreatedAt: newer},
+		{Name: snapshotName("nightly", newer), CreatedAt: newer},
+	}
+
+	assert.Equal(t, []string{snapshotName("nightly", newer), snapshotName("nightly", older)},
+		names(policySnapshots("nightly", snapshots)))
+}
+
+func TestExpiredSnapshots(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	snapshots := policySnapshots("nightly", testSnapshots(
+		at(15, 6), at(15, 0), at(14, 12), at(14, 0), at(13, 0), at(10, 0),
+	))
+
+	tests := []struct {
+		name      string
+		retention Retention
+		want      []string
+	}{
+		{
+			name: "no rules keep everything",
+		},
+		{
+			name:      "keep last",
+			retention: Retention{KeepLast: 4},
+			want:      []string{"nightly-20240513-000000", "nightly-20240510-000000"},
+		},
+		{
+			name:      "keep daily",
+			retention: Retention{KeepDailyDays: 2},
+			want: []string{
+				"nightly-20240515-000000", "nightly-20240514-000000",
+				"nightly-20240513-000000", "nightly-20240510-000000",
+			},
+		},
+		{
+			name:      "rules combine",
+			retention: Retention{KeepLast: 1, KeepDailyDays: 3},
+			want: []string{
+				"nightly-20240515-000000", "nightly-20240514-000000", "nightly-20240510-000000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, names(expiredSnapshots(tt.retention, snapshots, now)))
+		})
+	}
+}
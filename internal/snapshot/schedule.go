@@ -0,0 +1,201 @@
+package snapshot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleDescriptors are the cron shorthands accepted in place of five fields.
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// scheduleSearchYears bounds the search for the next run of a schedule.
+const scheduleSearchYears = 5
+
+// schedule is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type schedule struct {
+	minute   uint64
+	hour     uint64
+	monthDay uint64
+	month    uint64
+	weekday  uint64
+	// Restricted day fields match when either matches, as in cron
+	monthDayAny bool
+	weekdayAny  bool
+}
+
+// parseSchedule parses a five-field cron expression (minute, hour, day of
+// month, month, day of week) or a descriptor such as @daily.
+func parseSchedule(expr string) (*schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if descriptor, ok := scheduleDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields, has %d", expr, len(fields))
+	}
+
+	s := &schedule{
+		monthDayAny: fields[2] == "*",
+		weekdayAny:  fields[4] == "*",
+	}
+
+	var err error
+	if s.minute, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.monthDay, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseScheduleField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.weekday, err = parseScheduleField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+
+	// Sunday is both 0 and 7
+	if s.weekday&(1<<7) != 0 {
+		s.weekday |= 1
+	}
+
+	return s, nil
+}
+
+// parseScheduleField parses a comma-separated list of values, ranges and
+// steps such as "*/15", "1-5" or "mon,wed,fri".
+func parseScheduleField(field string, lowest, highest int, names map[string]int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		first, last := lowest, highest
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if first, err = parseScheduleValue(from, names); err != nil {
+				return 0, err
+			}
+			if last, err = parseScheduleValue(to, names); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseScheduleValue(rangePart, names)
+			if err != nil {
+				return 0, err
+			}
+			// A single value with a step runs from the value to the end
+			first = value
+			if !hasStep {
+				last = value
+			}
+		}
+
+		if first < lowest || last > highest || first > last {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, lowest, highest)
+		}
+
+		for value := first; value <= last; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+// parseScheduleValue parses a number or a month or weekday name.
+func parseScheduleValue(value string, names map[string]int) (int, error) {
+	if number, ok := names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+
+	return number, nil
+}
+
+// matchesDay reports whether the schedule runs on the day of t.
+func (s *schedule) matchesDay(t time.Time) bool {
+	monthDay := s.monthDay&(1<<t.Day()) != 0
+	weekday := s.weekday&(1<<int(t.Weekday())) != 0
+
+	switch {
+	case s.monthDayAny && s.weekdayAny:
+		return true
+	case s.monthDayAny:
+		return weekday
+	case s.weekdayAny:
+		return monthDay
+	default:
+		return monthDay || weekday
+	}
+}
+
+// next returns the first time after the given time at which the schedule
+// runs, or the zero time if it does not run within the next years.
+func (s *schedule) next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(scheduleSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"x * * * *",
+		"@sometimes",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := parseSchedule(expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, 5, 15, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 5, 16, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 3 * * sun", time.Date(2024, 5, 19, 3, 30, 0, 0, time.UTC)},
+		{"30 3 * * 7", time.Date(2024, 5, 19, 3, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * mon-fri", time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 9,17 * * *", time.Date(2024, 5, 15, 17, 0, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2024, 5, 15, 10, 25, 0, 0, time.UTC)},
+		// Restricted day of month and day of week match either
+		{"0 0 20 * fri", time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			sched, err := parseSchedule(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sched.next(from))
+		})
+	}
+}
+
+func TestSchedule_NextNever(t *testing.T) {
+	sched, err := parseSchedule("0 0 30 feb *")
+	require.NoError(t, err)
+
+	assert.True(t, sched.next(time.Now()).IsZero())
+}
@@ -0,0 +1,150 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "github.com/threatflux/libgo/internal/errors"
+	"gorm.io/gorm"
+)
+
+// gormPolicy is the database model of a snapshot policy. The policy is
+// stored as JSON next to the columns it is looked up by.
+type gormPolicy struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ID        string `gorm:"primaryKey"`
+	Name      string `gorm:"unique;not null"`
+	Spec      string `gorm:"type:text;not null"`
+}
+
+// TableName specifies the table name for the gormPolicy model.
+func (gormPolicy) TableName() string {
+	return "snapshot_policies"
+}
+
+// GormStore implements Store using GORM.
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a new GormStore.
+func NewGormStore(db *gorm.DB) (*GormStore, error) {
+	// Auto-migrate the schema
+	if err := db.AutoMigrate(&gormPolicy{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate snapshot policy schema: %w", err)
+	}
+
+	return &GormStore{db: db}, nil
+}
+
+// Create implements Store.Create.
+func (s *GormStore) Create(ctx context.Context, policy *Policy) error {
+	model, err := toGormPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create snapshot policy: %w", err)
+	}
+
+	return nil
+}
+
+// Get implements Store.Get.
+func (s *GormStore) Get(ctx context.Context, id string) (*Policy, error) {
+	var model gormPolicy
+	if err := s.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", apierrors.ErrSnapshotPolicyNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get snapshot policy: %w", err)
+	}
+
+	return fromGormPolicy(&model)
+}
+
+// List implements Store.List.
+func (s *GormStore) List(ctx context.Context) ([]*Policy, error) {
+	var models []gormPolicy
+	if err := s.db.WithContext(ctx).Order("name").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list snapshot policies: %w", err)
+	}
+
+	policies := make([]*Policy, 0, len(models))
+	for i := range models {
+		policy, err := fromGormPolicy(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// Update implements Store.Update.
+func (s *GormStore) Update(ctx context.Context, policy *Policy) error {
+	model, err := toGormPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Model(&gormPolicy{}).Where("id = ?", policy.ID).
+		Updates(map[string]interface{}{
+			"name":       model.Name,
+			"spec":       model.Spec,
+			"updated_at": model.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update snapshot policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", apierrors.ErrSnapshotPolicyNotFound, policy.ID)
+	}
+
+	return nil
+}
+
+// Delete implements Store.Delete.
+func (s *GormStore) Delete(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Delete(&gormPolicy{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete snapshot policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", apierrors.ErrSnapshotPolicyNotFound, id)
+	}
+
+	return nil
+}
+
+// toGormPolicy converts a policy to its database model.
+func toGormPolicy(policy *Policy) (*gormPolicy, error) {
+	spec, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("encoding snapshot policy: %w", err)
+	}
+
+	return &gormPolicy{
+		ID:        policy.ID,
+		Name:      policy.Name,
+		Spec:      string(spec),
+		CreatedAt: policy.CreatedAt,
+		UpdatedAt: policy.UpdatedAt,
+	}, nil
+}
+
+// fromGormPolicy converts a database model to a policy.
+func fromGormPolicy(model *gormPolicy) (*Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal([]byte(model.Spec), &policy); err != nil {
+		return nil, fmt.Errorf("decoding snapshot policy %s: %w", model.ID, err)
+	}
+
+	return &policy, nil
+}
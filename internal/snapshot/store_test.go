@@ -0,0 +1,56 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	apierrors "github.com/threatflux/libgo/internal/errors"
+)
+
+func TestGormStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+
+	store, err := NewGormStore(db)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	policy := &Policy{
+		ID:        "policy-1",
+		Name:      "nightly",
+		Schedule:  "@daily",
+		Selector:  map[string]string{"tier": "db"},
+		Retention: Retention{KeepLast: 7},
+		CreatedAt: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, store.Create(ctx, policy))
+
+	got, err := store.Get(ctx, "policy-1")
+	require.NoError(t, err)
+	assert.Equal(t, policy, got)
+
+	policy.LastStatus = RunStatusSuccess
+	require.NoError(t, store.Update(ctx, policy))
+
+	policies, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, RunStatusSuccess, policies[0].LastStatus)
+
+	require.NoError(t, store.Delete(ctx, "policy-1"))
+
+	_, err = store.Get(ctx, "policy-1")
+	assert.ErrorIs(t, err, apierrors.ErrSnapshotPolicyNotFound)
+	assert.ErrorIs(t, store.Update(ctx, policy), apierrors.ErrSnapshotPolicyNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "policy-1"), apierrors.ErrSnapshotPolicyNotFound)
+}
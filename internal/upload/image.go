@@ -0,0 +1,87 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// supportedImageFormats lists the formats uploaded images are converted
+// from.
+var supportedImageFormats = map[string]bool{
+	"qcow2": true,
+	"raw":   true,
+	"vmdk":  true,
+	"vdi":   true,
+	"vhdx":  true,
+	"vpc":   true,
+}
+
+// volumeFormats lists the formats uploads are converted into.
+var volumeFormats = map[string]bool{
+	"qcow2": true,
+	"raw":   true,
+}
+
+// imageInfo is the part of the output of qemu-img info uploads read.
+type imageInfo struct {
+	Format          string `json:"format"`
+	BackingFilename string `json:"backing-filename"`
+	FormatSpecific  struct {
+		Data struct {
+			DataFile string `json:"data-file"`
+			Extents  []struct {
+				Filename string `json:"filename"`
+			} `json:"extents"`
+		} `json:"data"`
+	} `json:"format-specific"`
+	VirtualSize uint64 `json:"virtual-size"`
+}
+
+// probeImage detects the format and virtual size of an uploaded image.
+// Images that refer to other files, such as qcow2 images with a backing
+// file or VMDK descriptors, are rejected: converting them would read files
+// on the server.
+func probeImage(ctx context.Context, path string) (*imageInfo, error) {
+	output, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"info", "--output=json", path,
+	}, exec.CommandOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("reading uploaded image: %w", err)
+	}
+
+	var info imageInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("parsing qemu-img info output: %w", err)
+	}
+
+	if !supportedImageFormats[info.Format] {
+		return nil, fmt.Errorf("%w: unsupported image format %q", errors.ErrInvalidParameter, info.Format)
+	}
+
+	if info.BackingFilename != "" || info.FormatSpecific.Data.DataFile != "" {
+		return nil, fmt.Errorf("%w: uploaded image refers to other files", errors.ErrInvalidParameter)
+	}
+
+	// Monolithic VMDK images keep their only extent in the image itself
+	for _, extent := range info.FormatSpecific.Data.Extents {
+		if extent.Filename != path {
+			return nil, fmt.Errorf("%w: uploaded image refers to other files", errors.ErrInvalidParameter)
+		}
+	}
+
+	return &info, nil
+}
+
+// convertImage writes an image to an existing volume.
+func convertImage(ctx context.Context, source string, sourceFormat string, target string, targetFormat string) error {
+	if _, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"convert", "-n", "-f", sourceFormat, "-O", targetFormat, source, target,
+	}, exec.CommandOptions{}); err != nil {
+		return fmt.Errorf("converting uploaded image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Status represents the status of an upload.
+type Status string
+
+const (
+	// StatusUploading indicates the upload accepts chunks
+	StatusUploading Status = "uploading"
+	// StatusProcessing indicates all data arrived and the image is being
+	// converted into the volume
+	StatusProcessing Status = "processing"
+	// StatusCompleted indicates the volume was created from the upload
+	StatusCompleted Status = "completed"
+	// StatusFailed indicates the upload or the conversion failed
+	StatusFailed Status = "failed"
+	// StatusCanceled indicates the upload was canceled
+	StatusCanceled Status = "canceled"
+)
+
+// Params holds the parameters of an upload.
+type Params struct {
+	// Pool receives the volume; defaults to the default storage pool
+	Pool string `json:"pool,omitempty"`
+	// Volume is the name of the new volume
+	Volume string `json:"volume" binding:"required"`
+	// SHA256 is the hex encoded SHA-256 digest of the complete upload; the
+	// upload fails if the received data does not match it
+	SHA256 string `json:"sha256,omitempty"`
+	// Format of the volume: qcow2 (default) or raw. The format of the
+	// uploaded image is detected and converted as needed.
+	Format string `json:"format,omitempty"`
+	// Length is the size of the complete upload in bytes
+	Length int64 `json:"length" binding:"required"`
+}
+
+// Job represents an upload and the conversion of the uploaded image into a
+// volume.
+type Job struct {
+	StartTime time.Time `json:"startTime"`
+	// UpdateTime is when the last chunk was received
+	UpdateTime time.Time `json:"updateTime"`
+	EndTime    time.Time `json:"endTime,omitempty"`
+	ID         string    `json:"id"`
+	Pool       string    `json:"pool"`
+	Volume     string    `json:"volume"`
+	Host       string    `json:"host,omitempty"`
+	// Owner is the ID of the user who created the upload and owns the
+	// volume
+	Owner string `json:"owner,omitempty"`
+	// Format is the format of the volume
+	Format string `json:"format"`
+	// SourceFormat is the detected format of the uploaded image
+	SourceFormat string `json:"sourceFormat,omitempty"`
+	// SHA256 is the digest the upload is verified against; uploads that
+	// did not name one report the digest of the received data
+	SHA256 string `json:"sha256,omitempty"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Length is the size of the complete upload and Offset the number of
+	// bytes received so far
+	Length   int64 `json:"length"`
+	Offset   int64 `json:"offset"`
+	Progress int   `json:"progress"`
+}
+
+// Manager defines the interface for resumable volume uploads.
+type Manager interface {
+	// Create starts an upload session for a new volume. The owner in the
+	// volume origin of ctx owns the upload and the volume.
+	Create(ctx context.Context, params Params) (*Job, error)
+
+	// WriteChunk appends data at offset, which must be the number of bytes
+	// received so far. Data received before a dropped connection is kept,
+	// so an interrupted chunk is resumed from the new offset. Once all data
+	// arrived its digest is verified and the image is converted into the
+	// volume in the background. The volume belongs to the owner of the
+	// upload, not to whoever sent the last chunk.
+	WriteChunk(ctx context.Context, id string, offset int64, r io.Reader) (*Job, error)
+
+	// GetJob gets an upload by ID
+	GetJob(ctx context.Context, id string) (*Job, error)
+
+	// ListJobs lists all uploads
+	ListJobs(ctx context.Context) ([]*Job, error)
+
+	// CancelJob cancels an upload and discards the received data
+	CancelJob(ctx context.Context, id string) error
+}
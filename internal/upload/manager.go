@@ -0,0 +1,482 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/jobs"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// defaultExpiry is how long uploads wait for their next chunk unless the
+// configuration says otherwise.
+const defaultExpiry = 24 * time.Hour
+
+// Config holds upload manager configuration.
+type Config struct {
+	// ScratchDir receives the uploaded data until it is converted into a
+	// volume
+	ScratchDir string
+	// DefaultPool receives the volume when an upload names no pool
+	DefaultPool string
+	// Expiry is how long an upload may go without receiving a chunk before
+	// it is discarded
+	Expiry time.Duration
+}
+
+// UploadManager implements Manager.
+type UploadManager struct {
+	sessions      *jobs.Store[session]
+	volumeManager storage.VolumeManager
+	logger        logger.Logger
+	config        Config
+}
+
+// session is an upload together with the state needed to resume it.
+type session struct {
+	job Job
+	// digest hashes the data received so far; it is shared by all copies
+	// of the session
+	digest hash.Hash
+	// path is the scratch file receiving the data
+	path   string
+	cancel context.CancelFunc
+	// writing is set while a chunk is being written
+	writing bool
+}
+
+// NewUploadManager creates a new UploadManager.
+func NewUploadManager(volumeManager storage.VolumeManager, config Config, logger logger.Logger) *UploadManager {
+	if config.Expiry <= 0 {
+		config.Expiry = defaultExpiry
+	}
+
+	return &UploadManager{
+		sessions:      jobs.NewStore[session](),
+		volumeManager: volumeManager,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// Create implements Manager.Create.
+func (m *UploadManager) Create(ctx context.Context, params Params) (*Job, error) {
+	m.expireSessions()
+
+	if err := m.validateParams(&params); err != nil {
+		return nil, err
+	}
+
+	// Uploads never replace existing volumes
+	_, err := m.volumeManager.GetInfo(ctx, params.Pool, params.Volume)
+	if err == nil {
+		return nil, fmt.Errorf("%w: volume %s in pool %s", errors.ErrAlreadyExists, params.Volume, params.Pool)
+	}
+	if !errors.Is(err, storage.ErrVolumeNotFound) {
+		return nil, fmt.Errorf("checking volume: %w", err)
+	}
+
+	if err := os.MkdirAll(m.config.ScratchDir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating upload directory: %w", err)
+	}
+
+	host, _ := connection.HostFromContext(ctx)
+	now := time.Now()
+	job := Job{
+		ID:         uuid.New().String(),
+		Pool:       params.Pool,
+		Volume:     params.Volume,
+		Host:       host,
+		Owner:      storage.VolumeOriginFromContext(ctx).Owner,
+		Format:     params.Format,
+		SHA256:     strings.ToLower(params.SHA256),
+		Status:     StatusUploading,
+		Length:     params.Length,
+		StartTime:  now,
+		UpdateTime: now,
+	}
+	s := &session{
+		job:    job,
+		digest: sha256.New(),
+		path:   filepath.Join(m.config.ScratchDir, job.ID+".upload"),
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("creating upload file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(s.path)
+		return nil, fmt.Errorf("creating upload file: %w", err)
+	}
+
+	_, err = m.sessions.Add(job.ID, s, func(id string, other *session) error {
+		if other.job.Pool == job.Pool && other.job.Volume == job.Volume && other.job.Host == job.Host && !other.job.Status.isFinal() {
+			return fmt.Errorf("%w: volume %s is being uploaded by %s", errors.ErrAlreadyExists, job.Volume, id)
+		}
+		return nil
+	})
+	if err != nil {
+		os.Remove(s.path)
+		return nil, err
+	}
+
+	m.logger.Info("Upload created",
+		logger.String("upload_id", job.ID),
+		logger.String("pool", job.Pool),
+		logger.String("volume", job.Volume),
+		logger.Int64("length", job.Length))
+
+	return &job, nil
+}
+
+// WriteChunk implements Manager.WriteChunk.
+func (m *UploadManager) WriteChunk(ctx context.Context, id string, offset int64, r io.Reader) (*Job, error) {
+	chunk, err := m.beginChunk(id, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	written, writeErr := chunk.write(offset, r)
+
+	var job *Job
+	m.sessions.Update(id, func(s *session) {
+		s.writing = false
+
+		// The upload was canceled or expired while the chunk was written
+		if s.job.Status != StatusUploading {
+			err = fmt.Errorf("%w: upload is %s", errors.ErrUploadInvalidState, s.job.Status)
+			return
+		}
+
+		s.job.Offset += written
+		s.job.UpdateTime = time.Now()
+		s.job.Progress = int(s.job.Offset * 100 / s.job.Length)
+		if writeErr != nil {
+			err = writeErr
+			return
+		}
+
+		if s.job.Offset == s.job.Length {
+			if err = m.finishUpload(ctx, s); err != nil {
+				return
+			}
+		}
+
+		copied := s.job
+		job = &copied
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetJob implements Manager.GetJob.
+func (m *UploadManager) GetJob(ctx context.Context, id string) (*Job, error) {
+	m.expireSessions()
+
+	s, exists := m.sessions.Get(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrUploadNotFound, id)
+	}
+
+	return &s.job, nil
+}
+
+// ListJobs implements Manager.ListJobs.
+func (m *UploadManager) ListJobs(ctx context.Context) ([]*Job, error) {
+	m.expireSessions()
+
+	sessions := m.sessions.List()
+
+	result := make([]*Job, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, &s.job)
+	}
+
+	return result, nil
+}
+
+// CancelJob implements Manager.CancelJob.
+func (m *UploadManager) CancelJob(ctx context.Context, id string) error {
+	var (
+		volume string
+		err    error
+	)
+	exists := m.sessions.Update(id, func(s *session) {
+		volume = s.job.Volume
+		if s.job.Status.isFinal() {
+			err = fmt.Errorf("%w: cannot cancel upload in %s state", errors.ErrUploadInvalidState, s.job.Status)
+			return
+		}
+
+		// A running conversion removes the scratch file once it stopped
+		if s.cancel != nil {
+			s.cancel()
+		} else {
+			os.Remove(s.path)
+		}
+		m.finish(s, StatusCanceled, nil)
+	})
+	if !exists {
+		return fmt.Errorf("%w: %s", errors.ErrUploadNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Upload canceled",
+		logger.String("upload_id", id),
+		logger.String("volume", volume))
+
+	return nil
+}
+
+// validateParams validates upload parameters and fills in defaults.
+func (m *UploadManager) validateParams(params *Params) error {
+	if params.Pool == "" {
+		params.Pool = m.config.DefaultPool
+	}
+	if params.Format == "" {
+		params.Format = "qcow2"
+	}
+
+	if params.Volume == "" || strings.ContainsAny(params.Volume, `/\`) || params.Volume == "." || params.Volume == ".." {
+		return fmt.Errorf("%w: invalid volume name %q", errors.ErrInvalidParameter, params.Volume)
+	}
+	if params.Length <= 0 {
+		return fmt.Errorf("%w: upload length must be positive", errors.ErrInvalidParameter)
+	}
+	if !volumeFormats[params.Format] {
+		return fmt.Errorf("%w: unsupported volume format %q", errors.ErrInvalidParameter, params.Format)
+	}
+	if params.SHA256 != "" {
+		if digest, err := hex.DecodeString(params.SHA256); err != nil || len(digest) != sha256.Size {
+			return fmt.Errorf("%w: invalid SHA-256 digest", errors.ErrInvalidParameter)
+		}
+	}
+
+	return nil
+}
+
+// beginChunk marks an upload as receiving a chunk at offset and returns a
+// copy of its session to write the chunk with.
+func (m *UploadManager) beginChunk(id string, offset int64) (*session, error) {
+	var (
+		chunk session
+		err   error
+	)
+	exists := m.sessions.Update(id, func(s *session) {
+		if s.job.Status != StatusUploading {
+			err = fmt.Errorf("%w: upload is %s", errors.ErrUploadInvalidState, s.job.Status)
+			return
+		}
+		if s.writing {
+			err = fmt.Errorf("%w: another chunk is being written", errors.ErrUploadInvalidState)
+			return
+		}
+		if offset != s.job.Offset {
+			err = fmt.Errorf("%w: expected offset %d, got %d", errors.ErrUploadOffsetMismatch, s.job.Offset, offset)
+			return
+		}
+
+		s.writing = true
+		chunk = *s
+	})
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrUploadNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &chunk, nil
+}
+
+// write appends a chunk to the scratch file at offset and returns the
+// number of bytes kept. Chunks that run past the end of the upload are
+// rolled back entirely; the data of chunks cut short by a read error is
+// kept.
+func (s *session) write(offset int64, r io.Reader) (int64, error) {
+	file, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, fmt.Errorf("opening upload file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seeking upload file: %w", err)
+	}
+
+	state, err := s.digest.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return 0, fmt.Errorf("saving digest state: %w", err)
+	}
+
+	remaining := s.job.Length - offset
+	written, copyErr := io.Copy(io.MultiWriter(file, s.digest), io.LimitReader(r, remaining))
+
+	if copyErr == nil && written == remaining {
+		var extra [1]byte
+		if n, _ := r.Read(extra[:]); n > 0 {
+			if err := file.Truncate(offset); err != nil {
+				return 0, fmt.Errorf("truncating upload file: %w", err)
+			}
+			if err := s.digest.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+				return 0, fmt.Errorf("restoring digest state: %w", err)
+			}
+			return 0, fmt.Errorf("%w: chunk exceeds upload length of %d bytes", errors.ErrInvalidParameter, s.job.Length)
+		}
+	}
+
+	if copyErr != nil {
+		return written, fmt.Errorf("receiving chunk: %w", copyErr)
+	}
+
+	return written, nil
+}
+
+// finishUpload verifies the digest of a complete upload and starts
+// converting it into the volume. The caller updates the session in the
+// store.
+func (m *UploadManager) finishUpload(ctx context.Context, s *session) error {
+	digest := hex.EncodeToString(s.digest.Sum(nil))
+	if s.job.SHA256 != "" && s.job.SHA256 != digest {
+		err := fmt.Errorf("%w: expected %s, got %s", errors.ErrUploadChecksumMismatch, s.job.SHA256, digest)
+		os.Remove(s.path)
+		m.finish(s, StatusFailed, err)
+		return err
+	}
+	s.job.SHA256 = digest
+	s.job.Status = StatusProcessing
+	s.job.Progress = 99
+
+	// The conversion outlives the request that completed the upload but
+	// keeps its values, such as the selected libvirt host
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	jobCtx = storage.WithVolumeOrigin(jobCtx, storage.VolumeOrigin{Owner: s.job.Owner, Job: s.job.ID})
+	s.cancel = cancel
+
+	go m.processUpload(jobCtx, cancel, s.job, s.path)
+
+	return nil
+}
+
+// processUpload converts a complete upload into its volume.
+func (m *UploadManager) processUpload(ctx context.Context, cancel context.CancelFunc, job Job, path string) {
+	defer cancel()
+	defer os.Remove(path)
+
+	sourceFormat, err := m.createVolume(ctx, path, job)
+
+	canceled := false
+	m.sessions.Update(job.ID, func(s *session) {
+		s.job.SourceFormat = sourceFormat
+		// Canceled uploads already have their final status
+		if canceled = ctx.Err() != nil; canceled {
+			return
+		}
+		if err != nil {
+			m.finish(s, StatusFailed, err)
+		} else {
+			m.finish(s, StatusCompleted, nil)
+		}
+	})
+	if canceled {
+		return
+	}
+	if err != nil {
+		m.logger.Error("Upload failed",
+			logger.String("upload_id", job.ID),
+			logger.String("volume", job.Volume),
+			logger.Error(err))
+		return
+	}
+
+	m.logger.Info("Upload completed",
+		logger.String("upload_id", job.ID),
+		logger.String("pool", job.Pool),
+		logger.String("volume", job.Volume),
+		logger.String("source_format", sourceFormat))
+}
+
+// createVolume creates the volume of an upload from the uploaded image and
+// returns the detected format of the image.
+func (m *UploadManager) createVolume(ctx context.Context, path string, job Job) (string, error) {
+	info, err := probeImage(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.volumeManager.Create(ctx, job.Pool, job.Volume, info.VirtualSize, job.Format); err != nil {
+		return info.Format, fmt.Errorf("creating volume: %w", err)
+	}
+
+	volumePath, err := m.volumeManager.GetPath(ctx, job.Pool, job.Volume)
+	if err == nil {
+		err = convertImage(ctx, path, info.Format, volumePath, job.Format)
+	}
+	if err != nil {
+		// The volume is removed even if the upload was canceled
+		if deleteErr := m.volumeManager.Delete(context.WithoutCancel(ctx), job.Pool, job.Volume); deleteErr != nil {
+			m.logger.Warn("Failed to delete volume of failed upload",
+				logger.String("volume", job.Volume),
+				logger.Error(deleteErr))
+		}
+		return info.Format, err
+	}
+
+	return info.Format, nil
+}
+
+// expireSessions discards uploads that have not received a chunk within
+// the expiry.
+func (m *UploadManager) expireSessions() {
+	deadline := time.Now().Add(-m.config.Expiry)
+	m.sessions.UpdateAll(func(id string, s *session) {
+		if s.job.Status != StatusUploading || s.writing || s.job.UpdateTime.After(deadline) {
+			return
+		}
+
+		os.Remove(s.path)
+		m.finish(s, StatusFailed, fmt.Errorf("upload expired after %s without data", m.config.Expiry))
+
+		m.logger.Info("Upload expired",
+			logger.String("upload_id", id),
+			logger.String("volume", s.job.Volume))
+	})
+}
+
+// finish records the final status of an upload. The caller updates the
+// session in the store.
+func (m *UploadManager) finish(s *session, status Status, err error) {
+	s.job.Status = status
+	if err != nil {
+		s.job.Error = err.Error()
+	}
+	if status == StatusCompleted {
+		s.job.Progress = 100
+	}
+	s.job.EndTime = time.Now()
+	s.cancel = nil
+}
+
+// isFinal reports whether an upload in this status has finished.
+func (s Status) isFinal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCanceled
+}
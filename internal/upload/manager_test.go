@@ -0,0 +1,259 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+// uploadTestEnv holds the mocks used by the upload manager tests.
+type uploadTestEnv struct {
+	manager *UploadManager
+	volumes *mocks_storage.MockVolumeManager
+	poolDir string
+	// info is what qemu-img info reports for uploaded images
+	info string
+}
+
+func newUploadTestEnv(t *testing.T) *uploadTestEnv {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := testutil.NewLogger(ctrl)
+
+	env := &uploadTestEnv{
+		volumes: mocks_storage.NewMockVolumeManager(ctrl),
+		poolDir: t.TempDir(),
+		info:    `{"format": "raw", "virtual-size": 11}`,
+	}
+	env.manager = NewUploadManager(env.volumes, Config{
+		ScratchDir:  t.TempDir(),
+		DefaultPool: "default",
+	}, mockLogger)
+
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", gomock.Any()).
+		Return(nil, fmt.Errorf("volume: %w", storage.ErrVolumeNotFound)).AnyTimes()
+	env.volumes.EXPECT().GetPath(gomock.Any(), "default", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, volName string) (string, error) {
+			return filepath.Join(env.poolDir, volName), nil
+		}).AnyTimes()
+
+	testutil.StubCommands(t, env.executeCommand)
+
+	return env
+}
+
+// executeCommand stands in for qemu-img.
+func (env *uploadTestEnv) executeCommand(_ context.Context, name string, args []string, _ exec.CommandOptions) ([]byte, error) {
+	if name != "qemu-img" {
+		return nil, fmt.Errorf("unexpected command %s", name)
+	}
+
+	switch args[0] {
+	case "info":
+		return []byte(env.info), nil
+	case "convert":
+		data, err := os.ReadFile(args[len(args)-2])
+		if err != nil {
+			return nil, err
+		}
+		return nil, os.WriteFile(args[len(args)-1], data, 0o600)
+	}
+
+	return nil, fmt.Errorf("unexpected qemu-img command %s", args[0])
+}
+
+// waitForUpload waits until an upload finished.
+func waitForUpload(t *testing.T, manager *UploadManager, id string) *Job {
+	t.Helper()
+
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = manager.GetJob(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status.isFinal()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return job
+}
+
+func digestOf(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUploadManager_ResumedUpload(t *testing.T) {
+	env := newUploadTestEnv(t)
+	const data = "disk data!!"
+
+	ownerCtx := storage.WithVolumeOrigin(context.Background(), storage.VolumeOrigin{Owner: "alice"})
+	job, err := env.manager.Create(ownerCtx, Params{
+		Volume: "image",
+		Length: int64(len(data)),
+		SHA256: strings.ToUpper(digestOf(data)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusUploading, job.Status)
+	assert.Equal(t, "default", job.Pool)
+	assert.Equal(t, "qcow2", job.Format)
+	assert.Equal(t, "alice", job.Owner)
+
+	// The connection drops after 4 bytes, which are kept
+	_, err = env.manager.WriteChunk(context.Background(), job.ID, 0,
+		iotest.TimeoutReader(io.MultiReader(strings.NewReader(data[:4]), strings.NewReader(data[4:]))))
+	require.Error(t, err)
+
+	job, err = env.manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), job.Offset)
+
+	// Chunks must continue at the current offset
+	_, err = env.manager.WriteChunk(context.Background(), job.ID, 0, strings.NewReader(data))
+	assert.ErrorIs(t, err, errors.ErrUploadOffsetMismatch)
+
+	// Chunks past the end are rolled back
+	_, err = env.manager.WriteChunk(context.Background(), job.ID, 4, strings.NewReader(data[4:8]+"too long"))
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	job, err = env.manager.WriteChunk(context.Background(), job.ID, 4, strings.NewReader(data[4:8]))
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), job.Offset)
+
+	// The volume belongs to the owner of the upload, whoever sent the
+	// last chunk
+	uploadID := job.ID
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "image", uint64(11), "qcow2").DoAndReturn(
+		func(ctx context.Context, _ string, _ string, _ uint64, _ string) error {
+			assert.Equal(t, storage.VolumeOrigin{Owner: "alice", Job: uploadID}, storage.VolumeOriginFromContext(ctx))
+			return nil
+		})
+
+	senderCtx := storage.WithVolumeOrigin(context.Background(), storage.VolumeOrigin{Owner: "bob"})
+	job, err = env.manager.WriteChunk(senderCtx, job.ID, 8, strings.NewReader(data[8:]))
+	require.NoError(t, err)
+	assert.Equal(t, StatusProcessing, job.Status)
+
+	job = waitForUpload(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+	assert.Equal(t, "raw", job.SourceFormat)
+	assert.Equal(t, digestOf(data), job.SHA256)
+	assert.Equal(t, 100, job.Progress)
+
+	volume, err := os.ReadFile(filepath.Join(env.poolDir, "image"))
+	require.NoError(t, err)
+	assert.Equal(t, data, string(volume))
+
+	// The scratch file is gone
+	entries, err := os.ReadDir(env.manager.config.ScratchDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestUploadManager_ChecksumMismatch(t *testing.T) {
+	env := newUploadTestEnv(t)
+
+	job, err := env.manager.Create(context.Background(), Params{
+		Volume: "image",
+		Length: 4,
+		SHA256: digestOf("good"),
+	})
+	require.NoError(t, err)
+
+	_, err = env.manager.WriteChunk(context.Background(), job.ID, 0, strings.NewReader("evil"))
+	assert.ErrorIs(t, err, errors.ErrUploadChecksumMismatch)
+
+	job, err = env.manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, job.Status)
+
+	_, err = env.manager.WriteChunk(context.Background(), job.ID, 4, bytes.NewReader(nil))
+	assert.ErrorIs(t, err, errors.ErrUploadInvalidState)
+}
+
+func TestUploadManager_RejectsBackingFile(t *testing.T) {
+	env := newUploadTestEnv(t)
+	env.info = `{"format": "qcow2", "virtual-size": 4, "backing-filename": "/etc/shadow"}`
+
+	job, err := env.manager.Create(context.Background(), Params{Volume: "image", Length: 4})
+	require.NoError(t, err)
+
+	_, err = env.manager.WriteChunk(context.Background(), job.ID, 0, strings.NewReader("qfi\xfb"))
+	require.NoError(t, err)
+
+	job = waitForUpload(t, env.manager, job.ID)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Contains(t, job.Error, "refers to other files")
+}
+
+func TestUploadManager_Create(t *testing.T) {
+	env := newUploadTestEnv(t)
+
+	tests := map[string]Params{
+		"missing length": {Volume: "image"},
+		"path in name":   {Volume: "../image", Length: 1},
+		"bad format":     {Volume: "image", Length: 1, Format: "vmdk"},
+		"bad digest":     {Volume: "image", Length: 1, SHA256: "abc"},
+	}
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := env.manager.Create(context.Background(), params)
+			assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+		})
+	}
+
+	job, err := env.manager.Create(context.Background(), Params{Volume: "image", Length: 1})
+	require.NoError(t, err)
+
+	// One upload per volume at a time
+	_, err = env.manager.Create(context.Background(), Params{Volume: "image", Length: 1})
+	assert.ErrorIs(t, err, errors.ErrAlreadyExists)
+
+	require.NoError(t, env.manager.CancelJob(context.Background(), job.ID))
+	job, err = env.manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status)
+	assert.ErrorIs(t, env.manager.CancelJob(context.Background(), job.ID), errors.ErrUploadInvalidState)
+
+	_, err = env.manager.Create(context.Background(), Params{Volume: "image", Length: 1})
+	require.NoError(t, err)
+
+	_, err = env.manager.GetJob(context.Background(), "missing")
+	assert.ErrorIs(t, err, errors.ErrUploadNotFound)
+}
+
+func TestUploadManager_Expiry(t *testing.T) {
+	env := newUploadTestEnv(t)
+
+	job, err := env.manager.Create(context.Background(), Params{Volume: "image", Length: 10})
+	require.NoError(t, err)
+
+	// Pretend the last chunk arrived a long time ago
+	env.manager.sessions.Update(job.ID, func(s *session) {
+		s.job.UpdateTime = time.Now().Add(-2 * defaultExpiry)
+	})
+
+	jobs, err := env.manager.ListJobs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, StatusFailed, jobs[0].Status)
+	assert.Contains(t, jobs[0].Error, "expired")
+}
@@ -0,0 +1,87 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// BlockCommit starts merging images of a VM disk's backing chain into a
+// lower image. The job runs in the background; active commits pivot the disk
+// to the committed image when done.
+func (m *VMManager) BlockCommit(ctx context.Context, name string, params vm.BlockCommitParams) (*vm.BlockJob, error) {
+	m.logger.Info("Starting block commit",
+		logger.String("name", name),
+		logger.String("device", params.Device),
+		logger.String("top", params.Top),
+		logger.String("base", params.Base))
+
+	job, err := m.domainManager.BlockCommit(ctx, name, params)
+	if err != nil {
+		return nil, fmt.Errorf("starting block commit: %w", err)
+	}
+
+	go m.completeBlockJob(context.WithoutCancel(ctx), name, job)
+
+	return job, nil
+}
+
+// BlockPull starts pulling backing data of a VM disk up into its active
+// image. The job runs in the background.
+func (m *VMManager) BlockPull(ctx context.Context, name string, params vm.BlockPullParams) (*vm.BlockJob, error) {
+	m.logger.Info("Starting block pull",
+		logger.String("name", name),
+		logger.String("device", params.Device),
+		logger.String("base", params.Base))
+
+	job, err := m.domainManager.BlockPull(ctx, name, params)
+	if err != nil {
+		return nil, fmt.Errorf("starting block pull: %w", err)
+	}
+
+	go m.completeBlockJob(context.WithoutCancel(ctx), name, job)
+
+	return job, nil
+}
+
+// ListBlockJobs lists the running block jobs of a VM.
+func (m *VMManager) ListBlockJobs(ctx context.Context, name string) ([]vm.BlockJob, error) {
+	jobs, err := m.domainManager.ListBlockJobs(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("listing block jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// AbortBlockJob cancels the block job of a VM disk.
+func (m *VMManager) AbortBlockJob(ctx context.Context, name string, device string) error {
+	m.logger.Info("Aborting block job",
+		logger.String("name", name),
+		logger.String("device", device))
+
+	if err := m.domainManager.AbortBlockJob(ctx, name, device); err != nil {
+		return fmt.Errorf("aborting block job: %w", err)
+	}
+
+	return nil
+}
+
+// completeBlockJob waits for a block job to finish and logs its outcome.
+func (m *VMManager) completeBlockJob(ctx context.Context, name string, job *vm.BlockJob) {
+	if err := m.domainManager.CompleteBlockJob(ctx, name, job.Device); err != nil {
+		m.logger.Error("Block job failed",
+			logger.String("name", name),
+			logger.String("device", job.Device),
+			logger.String("type", job.Type),
+			logger.Error(err))
+		return
+	}
+
+	m.logger.Info("Block job completed",
+		logger.String("name", name),
+		logger.String("device", job.Device),
+		logger.String("type", job.Type))
+}
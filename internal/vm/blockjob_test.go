@@ -0,0 +1,69 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/models/vm"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVMManager_BlockCommit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	manager := NewVMManager(mockDomainManager, nil, nil, nil, nil, nil, nil, Config{}, mockLogger)
+
+	params := vm.BlockCommitParams{Device: "vda"}
+	job := &vm.BlockJob{Device: "vda", Type: "active-commit"}
+
+	// The job is completed in the background after the request returns
+	completed := make(chan context.Context, 1)
+	mockDomainManager.EXPECT().BlockCommit(gomock.Any(), "test-vm", params).Return(job, nil)
+	mockDomainManager.EXPECT().CompleteBlockJob(gomock.Any(), "test-vm", "vda").
+		DoAndReturn(func(ctx context.Context, _, _ string) error {
+			completed <- ctx
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := manager.BlockCommit(ctx, "test-vm", params)
+	cancel()
+	require.NoError(t, err)
+	assert.Equal(t, job, result)
+
+	select {
+	case completeCtx := <-completed:
+		assert.NoError(t, completeCtx.Err())
+	case <-time.After(time.Second):
+		t.Fatal("block job was not completed")
+	}
+}
+
+func TestVMManager_BlockPullError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	manager := NewVMManager(mockDomainManager, nil, nil, nil, nil, nil, nil, Config{}, mockLogger)
+
+	params := vm.BlockPullParams{Device: "vdc"}
+	mockDomainManager.EXPECT().BlockPull(gomock.Any(), "test-vm", params).Return(nil, domain.ErrDiskNotFound)
+
+	_, err := manager.BlockPull(context.Background(), "test-vm", params)
+	assert.True(t, errors.Is(err, domain.ErrDiskNotFound))
+}
@@ -0,0 +1,255 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// clonedVolume is a volume created for a clone, deleted again on failure.
+type clonedVolume struct {
+	pool string
+	name string
+}
+
+// Clone implements Manager.Clone.
+func (m *VMManager) Clone(ctx context.Context, sourceName string, params vm.CloneParams) (*vm.VM, error) {
+	source, err := m.checkClone(ctx, sourceName, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.clone(ctx, sourceName, source, params)
+}
+
+// StartClone implements Manager.StartClone.
+func (m *VMManager) StartClone(ctx context.Context, sourceName string, params vm.CloneParams) (*vm.Job, error) {
+	source, err := m.checkClone(ctx, sourceName, params)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := m.jobs.create(ctx, &vm.Job{Type: vm.JobTypeClone, Name: sourceName, Target: params.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	// The clone outlives the request that started it
+	go func() {
+		cloned, err := m.clone(context.WithoutCancel(ctx), sourceName, source, params)
+		m.jobs.finish(job.ID, err, func(job *vm.Job) { job.VM = cloned })
+
+		if err != nil {
+			m.logger.Error("VM clone failed",
+				logger.String("job_id", job.ID),
+				logger.String("source", sourceName),
+				logger.String("name", params.Name),
+				logger.Error(err))
+		}
+	}()
+
+	return job, nil
+}
+
+// checkClone validates a clone request and returns the source VM.
+func (m *VMManager) checkClone(ctx context.Context, sourceName string, params vm.CloneParams) (*vm.VM, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("validating parameters: %w", err)
+	}
+
+	source, err := m.domainManager.Get(ctx, sourceName)
+	if err != nil {
+		return nil, fmt.Errorf("getting source VM: %w", err)
+	}
+
+	if _, err := m.domainManager.Get(ctx, params.Name); err == nil {
+		return nil, fmt.Errorf("cloning VM %s to %s: %w", sourceName, params.Name, ErrVMAlreadyExists)
+	}
+
+	// Disks of a running VM change while they are copied
+	if source.Status != vm.VMStatusStopped && !params.Live {
+		return nil, fmt.Errorf("source VM %s is %s, stop it or request a live full clone: %w",
+			sourceName, source.Status, ErrVMInvalidState)
+	}
+
+	return source, nil
+}
+
+// clone copies a source VM that passed checkClone.
+func (m *VMManager) clone(ctx context.Context, sourceName string, source *vm.VM, params vm.CloneParams) (*vm.VM, error) {
+	live := source.Status != vm.VMStatusStopped
+
+	m.logger.Info("Cloning VM",
+		logger.String("source", sourceName),
+		logger.String("name", params.Name),
+		logger.String("mode", string(params.Mode)),
+		logger.Bool("live", live))
+
+	// Redirect writes of the running source to temporary overlays so that
+	// its disk images stay consistent while they are copied
+	var overlays []domain.DiskOverlay
+	var err error
+	if live {
+		overlays, err = m.domainManager.CreateDiskOverlays(ctx, sourceName)
+		if err != nil {
+			return nil, fmt.Errorf("creating temporary snapshot: %w", err)
+		}
+		defer m.commitDiskOverlays(ctx, sourceName, overlays)
+	}
+
+	spec := domain.CloneSpec{
+		Name:  params.Name,
+		UUID:  uuid.NewString(),
+		Disks: make(map[string]domain.CloneDisk, len(source.Disks)),
+	}
+
+	volumes, err := m.cloneDisks(storage.WithVolumeOrigin(ctx, storage.VolumeOrigin{VM: params.Name}), source, params, spec.Disks)
+	if err != nil {
+		m.deleteClonedVolumes(ctx, volumes)
+		return nil, err
+	}
+
+	// The persistent definition of the source refers to the overlays
+	// until they are merged
+	for _, overlay := range overlays {
+		if cloneDisk, ok := spec.Disks[overlay.BasePath]; ok {
+			spec.Disks[overlay.OverlayPath] = cloneDisk
+		}
+	}
+
+	spec.CloudInitISO, err = m.cloneCloudInit(ctx, sourceName, params, spec.UUID)
+	if err != nil {
+		m.deleteClonedVolumes(ctx, volumes)
+		return nil, err
+	}
+
+	result, err := m.domainManager.DefineClone(ctx, sourceName, spec)
+	if err != nil {
+		m.deleteClonedVolumes(ctx, volumes)
+		if spec.CloudInitISO != "" {
+			m.deleteCloudInitVolume(ctx, params.Name)
+		}
+		return nil, fmt.Errorf("defining clone: %w", err)
+	}
+
+	m.logger.Info("VM cloned",
+		logger.String("source", sourceName),
+		logger.String("name", params.Name))
+
+	if !params.Start {
+		return result, nil
+	}
+
+	// The clone is usable even if it fails to start, so report its state
+	if err := m.domainManager.Start(ctx, params.Name); err != nil {
+		m.logger.Warn("Failed to start cloned VM",
+			logger.String("name", params.Name),
+			logger.Error(err))
+		return result, nil
+	}
+
+	return m.domainManager.Get(ctx, params.Name)
+}
+
+// cloneDisks copies the writable disks of the source VM and records the
+// copies in disks, keyed by domain.DiskSourceKey.
+func (m *VMManager) cloneDisks(ctx context.Context, source *vm.VM, params vm.CloneParams, disks map[string]domain.CloneDisk) ([]clonedVolume, error) {
+	volumes := make([]clonedVolume, 0, len(source.Disks))
+
+	for i, disk := range source.Disks {
+		// Read-only and shared disks are attached to the clone as they are
+		key := domain.DiskSourceKey(disk)
+		if key == "" || disk.ReadOnly || disk.Shareable {
+			continue
+		}
+
+		poolName, sourceVolume := m.diskVolume(ctx, disk)
+		volumeName := vm.GenerateVolumeName(params.Name, i)
+		format := string(disk.Format)
+
+		m.logger.Debug("Cloning VM disk",
+			logger.String("pool", poolName),
+			logger.String("source", sourceVolume),
+			logger.String("volume", volumeName))
+
+		var err error
+		if params.Mode == vm.CloneModeLinked {
+			err = m.storageManager.CloneLinked(ctx, poolName, sourceVolume, volumeName)
+			format = string(vm.DiskFormatQCOW2)
+		} else {
+			err = m.storageManager.Clone(ctx, poolName, sourceVolume, volumeName)
+		}
+		if err != nil {
+			return volumes, fmt.Errorf("cloning disk %s: %w", disk.Device, err)
+		}
+		volumes = append(volumes, clonedVolume{pool: poolName, name: volumeName})
+
+		// The copy is attached the way its pool attaches volumes, such as
+		// an rbd image for RBD pools
+		volumeSource, err := m.storageManager.GetSource(ctx, poolName, volumeName)
+		if err != nil {
+			return volumes, fmt.Errorf("getting source of cloned disk %s: %w", disk.Device, err)
+		}
+
+		disks[key] = domain.CloneDisk{Source: *diskSourceFromVolume(volumeSource), Format: format}
+	}
+
+	return volumes, nil
+}
+
+// cloneCloudInit creates a cloud-init ISO with a new instance-id for the
+// clone, so that cloud-init treats it as a new instance. It returns an empty
+// path if the source VM does not use cloud-init.
+func (m *VMManager) cloneCloudInit(ctx context.Context, sourceName string, params vm.CloneParams, instanceID string) (string, error) {
+	sourceXML, err := m.domainManager.GetXML(ctx, sourceName)
+	if err != nil {
+		return "", fmt.Errorf("getting source VM XML: %w", err)
+	}
+
+	if !strings.Contains(sourceXML, "-cloudinit.iso") {
+		return "", nil
+	}
+
+	cloneParams := vm.VMParams{Name: params.Name}
+	if params.CloudInit != nil {
+		cloneParams.CloudInit = *params.CloudInit
+	}
+	// Meta-data always identifies the clone
+	cloneParams.CloudInit.MetaData = ""
+	cloneParams.CloudInit.InstanceID = instanceID
+
+	isoPath, err := m.setupCloudInit(ctx, cloneParams)
+	if err != nil {
+		return "", fmt.Errorf("setting up cloud-init: %w", err)
+	}
+
+	return isoPath, nil
+}
+
+// commitDiskOverlays merges the temporary overlays of a live clone back into
+// the source disks, even if the clone request was cancelled.
+func (m *VMManager) commitDiskOverlays(ctx context.Context, sourceName string, overlays []domain.DiskOverlay) {
+	if err := m.domainManager.CommitDiskOverlays(context.WithoutCancel(ctx), sourceName, overlays); err != nil {
+		m.logger.Error("Failed to merge temporary snapshot of cloned VM",
+			logger.String("source", sourceName),
+			logger.Error(err))
+	}
+}
+
+// deleteClonedVolumes deletes the volumes of a failed clone.
+func (m *VMManager) deleteClonedVolumes(ctx context.Context, volumes []clonedVolume) {
+	for _, volume := range volumes {
+		if err := m.storageManager.Delete(ctx, volume.pool, volume.name); err != nil {
+			m.logger.Warn("Failed to delete cloned disk volume",
+				logger.String("pool", volume.pool),
+				logger.String("volume", volume.name),
+				logger.Error(err))
+		}
+	}
+}
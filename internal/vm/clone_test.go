@@ -0,0 +1,408 @@
+package vm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/internal/vm/cloudinit"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	mocks_cloudinit "github.com/threatflux/libgo/test/mocks/vm/cloudinit"
+	"go.uber.org/mock/gomock"
+)
+
+const goldenDiskPath = "/var/lib/libvirt/images/golden-disk-0"
+
+func goldenVM(status vm.VMStatus) *vm.VM {
+	return &vm.VM{
+		Name:   "golden",
+		Status: status,
+		Disks: []vm.DiskInfo{
+			{Path: goldenDiskPath, Format: vm.DiskFormatQCOW2, Device: "vda"},
+		},
+	}
+}
+
+// expectGoldenDiskClone expects the disk of the golden VM to be found in
+// the default pool and its copy to be attached as a file.
+func expectGoldenDiskClone(mockStorageManager *mocks_storage.MockVolumeManager) {
+	mockStorageManager.EXPECT().LookupByPath(gomock.Any(), goldenDiskPath).
+		Return("default", "golden-disk-0", nil).AnyTimes()
+	mockStorageManager.EXPECT().GetSource(gomock.Any(), "default", "web-1-disk-0").
+		Return(&storage.VolumeSource{Type: "file", Path: "/var/lib/libvirt/images/web-1-disk-0"}, nil)
+}
+
+func TestVMManager_Clone_Linked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockStorageManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockCloudInitManager := mocks_cloudinit.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	cloudInitDir := t.TempDir()
+	manager := NewVMManager(
+		mockDomainManager,
+		mockStorageManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		mockCloudInitManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{StoragePoolName: "default", CloudInitDir: cloudInitDir},
+		mockLogger,
+	)
+
+	// Set up expectations
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(goldenVM(vm.VMStatusStopped), nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-1").Return(nil, domain.ErrDomainNotFound)
+
+	mockStorageManager.EXPECT().CloneLinked(gomock.Any(), "default", "golden-disk-0", "web-1-disk-0").Return(nil)
+	expectGoldenDiskClone(mockStorageManager)
+
+	// Source uses cloud-init, so the clone gets a new ISO
+	mockDomainManager.EXPECT().GetXML(gomock.Any(), "golden").
+		Return("<domain><source file='/tmp/golden-cloudinit.iso'/></domain>", nil)
+	mockCloudInitManager.EXPECT().GenerateUserData(gomock.Any()).Return("#cloud-config", nil)
+	mockCloudInitManager.EXPECT().GenerateNetworkConfig(gomock.Any()).Return("version: 2", nil)
+
+	var instanceID string
+	mockCloudInitManager.EXPECT().GenerateMetaData(gomock.Any()).
+		DoAndReturn(func(params vm.VMParams) (string, error) {
+			instanceID = params.CloudInit.InstanceID
+			return "instance-id: " + instanceID, nil
+		})
+	mockCloudInitManager.EXPECT().
+		GenerateISO(gomock.Any(), gomock.Any(), filepath.Join(cloudInitDir, "web-1-cloudinit.iso")).
+		DoAndReturn(func(ctx context.Context, config cloudinit.CloudInitConfig, outputPath string) error {
+			assert.Equal(t, "instance-id: "+instanceID, config.MetaData)
+			return writeTestISO(ctx, config, outputPath)
+		})
+	expectCloudInitUpload(mockStorageManager, "web-1")
+
+	mockDomainManager.EXPECT().DefineClone(gomock.Any(), "golden", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, spec domain.CloneSpec) (*vm.VM, error) {
+			assert.Equal(t, "web-1", spec.Name)
+			assert.Equal(t, instanceID, spec.UUID)
+			assert.Equal(t, "/var/lib/libvirt/images/web-1-cloudinit.iso", spec.CloudInitISO)
+			assert.Equal(t, domain.CloneDisk{
+				Source: vm.DiskSource{Type: vm.DiskTypeFile, Path: "/var/lib/libvirt/images/web-1-disk-0"},
+				Format: "qcow2",
+			}, spec.Disks[goldenDiskPath])
+			return &vm.VM{Name: "web-1", UUID: spec.UUID, Status: vm.VMStatusStopped}, nil
+		})
+
+	// Test Clone
+	clone, err := manager.Clone(context.Background(), "golden", vm.CloneParams{
+		Name: "web-1",
+		Mode: vm.CloneModeLinked,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "web-1", clone.Name)
+	assert.NotEmpty(t, instanceID)
+}
+
+func TestVMManager_Clone_Live(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockStorageManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		mockStorageManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{StoragePoolName: "default"},
+		mockLogger,
+	)
+
+	overlays := []domain.DiskOverlay{
+		{Device: "vda", BasePath: goldenDiskPath, OverlayPath: goldenDiskPath + ".overlay-1"},
+	}
+
+	// Set up expectations
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(goldenVM(vm.VMStatusRunning), nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-1").Return(nil, domain.ErrDomainNotFound)
+
+	gomock.InOrder(
+		mockDomainManager.EXPECT().CreateDiskOverlays(gomock.Any(), "golden").Return(overlays, nil),
+		mockStorageManager.EXPECT().Clone(gomock.Any(), "default", "golden-disk-0", "web-1-disk-0").Return(nil),
+		mockDomainManager.EXPECT().DefineClone(gomock.Any(), "golden", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, spec domain.CloneSpec) (*vm.VM, error) {
+				// Disks are mapped from both the base image and the overlay
+				assert.Equal(t, spec.Disks[goldenDiskPath], spec.Disks[goldenDiskPath+".overlay-1"])
+				assert.Empty(t, spec.CloudInitISO)
+				return &vm.VM{Name: "web-1", Status: vm.VMStatusStopped}, nil
+			}),
+		mockDomainManager.EXPECT().CommitDiskOverlays(gomock.Any(), "golden", overlays).Return(nil),
+	)
+	expectGoldenDiskClone(mockStorageManager)
+	mockDomainManager.EXPECT().GetXML(gomock.Any(), "golden").Return("<domain/>", nil)
+
+	// Test Clone
+	_, err := manager.Clone(context.Background(), "golden", vm.CloneParams{Name: "web-1", Live: true})
+	require.NoError(t, err)
+}
+
+func TestVMManager_Clone_NetworkDisk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockStorageManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		mockStorageManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{StoragePoolName: "default"},
+		mockLogger,
+	)
+
+	// The disk is an image of an RBD pool, found by its name
+	source := &vm.VM{
+		Name:   "golden",
+		Status: vm.VMStatusStopped,
+		Disks: []vm.DiskInfo{
+			{Path: "rbd/golden-disk-0", Format: vm.DiskFormatRAW, Device: "vda"},
+		},
+	}
+	cloneSource := &storage.VolumeSource{
+		Type:     "network",
+		Protocol: "rbd",
+		Name:     "rbd/web-1-disk-0",
+		Hosts:    []storage.StoragePoolHost{{Name: "mon1", Port: 6789}},
+		Auth:     &storage.StoragePoolAuth{Username: "libvirt", Type: "ceph", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+	}
+
+	// Set up expectations
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(source, nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-1").Return(nil, domain.ErrDomainNotFound)
+	mockStorageManager.EXPECT().LookupByPath(gomock.Any(), "rbd/golden-disk-0").Return("ceph", "golden-disk-0", nil)
+	mockStorageManager.EXPECT().Clone(gomock.Any(), "ceph", "golden-disk-0", "web-1-disk-0").Return(nil)
+	mockStorageManager.EXPECT().GetSource(gomock.Any(), "ceph", "web-1-disk-0").Return(cloneSource, nil)
+	mockDomainManager.EXPECT().GetXML(gomock.Any(), "golden").Return("<domain/>", nil)
+	mockDomainManager.EXPECT().DefineClone(gomock.Any(), "golden", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, spec domain.CloneSpec) (*vm.VM, error) {
+			assert.Equal(t, domain.CloneDisk{
+				Source: vm.DiskSource{
+					Type:     vm.DiskTypeNetwork,
+					Protocol: "rbd",
+					Name:     "rbd/web-1-disk-0",
+					Hosts:    []vm.DiskHost{{Name: "mon1", Port: 6789}},
+					Auth:     &vm.DiskAuth{Username: "libvirt", SecretType: "ceph", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+				},
+				Format: "raw",
+			}, spec.Disks["rbd/golden-disk-0"])
+			return &vm.VM{Name: "web-1", Status: vm.VMStatusStopped}, nil
+		})
+
+	// Test Clone
+	_, err := manager.Clone(context.Background(), "golden", vm.CloneParams{Name: "web-1"})
+	require.NoError(t, err)
+}
+
+func TestVMManager_Clone_RunningSourceRequiresLive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	// Set up expectations
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(goldenVM(vm.VMStatusRunning), nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-1").Return(nil, domain.ErrDomainNotFound)
+
+	// Test Clone
+	_, err := manager.Clone(context.Background(), "golden", vm.CloneParams{Name: "web-1"})
+	assert.ErrorIs(t, err, ErrVMInvalidState)
+}
+
+func TestVMManager_StartClone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockStorageManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		mockStorageManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{StoragePoolName: "default"},
+		mockLogger,
+	)
+
+	// The clone waits for the gate, so the job is still running when it
+	// is first polled
+	gate := make(chan struct{})
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(goldenVM(vm.VMStatusStopped), nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-1").Return(nil, domain.ErrDomainNotFound)
+	mockStorageManager.EXPECT().Clone(gomock.Any(), "default", "golden-disk-0", "web-1-disk-0").
+		DoAndReturn(func(context.Context, string, string, string) error {
+			<-gate
+			return nil
+		})
+	expectGoldenDiskClone(mockStorageManager)
+	mockDomainManager.EXPECT().GetXML(gomock.Any(), "golden").Return("<domain/>", nil)
+	mockDomainManager.EXPECT().DefineClone(gomock.Any(), "golden", gomock.Any()).
+		Return(&vm.VM{Name: "web-1", Status: vm.VMStatusStopped}, nil)
+
+	// The request context ends before the clone does
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := manager.StartClone(ctx, "golden", vm.CloneParams{Name: "web-1"})
+	cancel()
+	require.NoError(t, err)
+	assert.Equal(t, vm.JobTypeClone, job.Type)
+	assert.Equal(t, "golden", job.Name)
+	assert.Equal(t, "web-1", job.Target)
+	assert.Equal(t, vm.JobStatusRunning, job.Status)
+
+	// A second clone to the same name is refused while the first runs
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(goldenVM(vm.VMStatusStopped), nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-1").Return(nil, domain.ErrDomainNotFound)
+	_, err = manager.StartClone(context.Background(), "golden", vm.CloneParams{Name: "web-1"})
+	assert.ErrorIs(t, err, ErrVMAlreadyExists)
+
+	close(gate)
+	require.Eventually(t, func() bool {
+		job, err := manager.GetJob(context.Background(), job.ID)
+		return err == nil && job.Status.IsFinal()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	finished, err := manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, vm.JobStatusCompleted, finished.Status)
+	require.NotNil(t, finished.VM)
+	assert.Equal(t, "web-1", finished.VM.Name)
+	assert.False(t, finished.EndTime.IsZero())
+
+	jobs, err := manager.ListJobs(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	_, err = manager.GetJob(context.Background(), "missing")
+	assert.ErrorIs(t, err, errors.ErrVMJobNotFound)
+}
+
+func TestVMManager_StartClone_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockStorageManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		mockStorageManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{StoragePoolName: "default"},
+		mockLogger,
+	)
+
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(goldenVM(vm.VMStatusStopped), nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-1").Return(nil, domain.ErrDomainNotFound)
+	mockStorageManager.EXPECT().LookupByPath(gomock.Any(), goldenDiskPath).Return("default", "golden-disk-0", nil)
+	mockStorageManager.EXPECT().Clone(gomock.Any(), "default", "golden-disk-0", "web-1-disk-0").
+		Return(errors.ErrInsufficientStorage)
+
+	job, err := manager.StartClone(context.Background(), "golden", vm.CloneParams{Name: "web-1"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		job, err := manager.GetJob(context.Background(), job.ID)
+		return err == nil && job.Status.IsFinal()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	failed, err := manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, vm.JobStatusFailed, failed.Status)
+	assert.Contains(t, failed.Error, "cloning disk vda")
+	assert.Nil(t, failed.VM)
+
+	// Requests failing the checks do not create jobs
+	mockDomainManager.EXPECT().Get(gomock.Any(), "golden").Return(goldenVM(vm.VMStatusRunning), nil)
+	mockDomainManager.EXPECT().Get(gomock.Any(), "web-2").Return(nil, domain.ErrDomainNotFound)
+	_, err = manager.StartClone(context.Background(), "golden", vm.CloneParams{Name: "web-2"})
+	assert.ErrorIs(t, err, ErrVMInvalidState)
+
+	jobs, err := manager.ListJobs(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+}
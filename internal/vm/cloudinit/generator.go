@@ -47,7 +47,8 @@ func (g *CloudInitGenerator) GenerateUserData(params vm.VMParams) (string, error
 
 	// Build template data
 	data := map[string]interface{}{
-		"VM": params,
+		"VM":     params,
+		"Mounts": sharedFolderMounts(params.SharedFolders),
 	}
 
 	// Execute template
@@ -72,8 +73,11 @@ func (g *CloudInitGenerator) GenerateMetaData(params vm.VMParams) (string, error
 		return params.CloudInit.MetaData, nil
 	}
 
-	// Generate a random UUID if not provided
+	// Use the VM name unless a specific instance ID is requested
 	instanceID := params.Name
+	if params.CloudInit.InstanceID != "" {
+		instanceID = params.CloudInit.InstanceID
+	}
 
 	// Build template data
 	data := map[string]interface{}{
@@ -119,6 +123,17 @@ func (g *CloudInitGenerator) GenerateNetworkConfig(params vm.VMParams) (string,
 	return result.String(), nil
 }
 
+// sharedFolderMounts returns the shared folders the guest mounts on boot.
+func sharedFolderMounts(folders []vm.SharedFolderParams) []vm.SharedFolderParams {
+	var mounts []vm.SharedFolderParams
+	for _, folder := range folders {
+		if folder.MountPoint != "" {
+			mounts = append(mounts, folder)
+		}
+	}
+	return mounts
+}
+
 // loadTemplates loads cloud-init templates.
 func (g *CloudInitGenerator) loadTemplates() error {
 	// List of template files to load
@@ -189,6 +204,12 @@ packages:
   - cloud-init
 package_update: true
 package_upgrade: true
+{{- if .Mounts }}
+mounts:
+{{- range .Mounts }}
+  - [{{printf "%q" .Tag}}, {{printf "%q" .MountPoint}}, {{.MountType}}, "{{.MountOptions}}", "0", "0"]
+{{- end }}
+{{- end }}
 runcmd:
   - systemctl enable qemu-guest-agent
   - systemctl start qemu-guest-agent
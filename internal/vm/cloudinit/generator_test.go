@@ -184,3 +184,38 @@ func TestCloudInitGenerator_CreateDefaultTemplate(t *testing.T) {
 	_, err := generator.createDefaultTemplate("invalid.tmpl")
 	assert.Error(t, err)
 }
+
+func TestCloudInitGenerator_SharedFolderMounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// The default template is used as the directory has none
+	generator, err := NewCloudInitGenerator(t.TempDir(), mockLogger)
+	require.NoError(t, err)
+
+	testParams := vm.VMParams{
+		Name: "build-01",
+		SharedFolders: []vm.SharedFolderParams{
+			{Source: "/srv/cache", Tag: "cache", Driver: vm.SharedFolderDriverVirtioFS, MountPoint: "/var/cache/artifacts"},
+			{Source: "/srv/docs", Tag: "docs", Driver: vm.SharedFolderDriver9p, MountPoint: "/mnt/docs", ReadOnly: true},
+			{Source: "/srv/tools", Tag: "tools", Driver: vm.SharedFolderDriverVirtioFS},
+		},
+	}
+
+	userData, err := generator.GenerateUserData(testParams)
+	require.NoError(t, err)
+	assert.Contains(t, userData, "mounts:\n"+
+		`  - ["cache", "/var/cache/artifacts", virtiofs, "defaults,nofail", "0", "0"]`+"\n"+
+		`  - ["docs", "/mnt/docs", 9p, "trans=virtio,version=9p2000.L,nofail,ro", "0", "0"]`+"\n")
+	assert.NotContains(t, userData, "tools")
+
+	// No mounts without mount points
+	testParams.SharedFolders = testParams.SharedFolders[2:]
+	userData, err = generator.GenerateUserData(testParams)
+	require.NoError(t, err)
+	assert.NotContains(t, userData, "mounts:")
+}
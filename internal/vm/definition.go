@@ -0,0 +1,54 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// GetDefinition implements Manager.GetDefinition.
+func (m *VMManager) GetDefinition(ctx context.Context, name string) (string, error) {
+	definition, err := m.domainManager.GetDefinition(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("getting VM definition: %w", err)
+	}
+
+	return definition, nil
+}
+
+// UpdateDefinition implements Manager.UpdateDefinition.
+func (m *VMManager) UpdateDefinition(ctx context.Context, name string, definition string) (*vm.DefinitionUpdate, error) {
+	update, err := m.domainManager.UpdateDefinition(ctx, name, definition)
+	if err != nil {
+		return nil, fmt.Errorf("updating VM definition: %w", err)
+	}
+
+	m.logger.Info("VM definition replaced",
+		logger.String("name", name),
+		logger.Bool("changed", update.Changed),
+		logger.Bool("restart_required", update.RestartRequired))
+
+	return update, nil
+}
+
+// Update implements Manager.Update.
+func (m *VMManager) Update(ctx context.Context, name string, params vm.UpdateParams) (*vm.DefinitionUpdate, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidParameter, err)
+	}
+
+	update, err := m.domainManager.Update(ctx, name, params)
+	if err != nil {
+		return nil, fmt.Errorf("updating VM: %w", err)
+	}
+
+	m.logger.Info("VM updated",
+		logger.String("name", name),
+		logger.Bool("changed", update.Changed),
+		logger.Bool("restart_required", update.RestartRequired))
+
+	return update, nil
+}
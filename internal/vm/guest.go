@@ -0,0 +1,91 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// GetGuestInfo implements Manager.GetGuestInfo.
+func (m *VMManager) GetGuestInfo(ctx context.Context, name string) (*vm.GuestInfo, error) {
+	info, err := m.domainManager.GetGuestInfo(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("getting guest info: %w", err)
+	}
+
+	return info, nil
+}
+
+// SetGuestPassword implements Manager.SetGuestPassword.
+func (m *VMManager) SetGuestPassword(ctx context.Context, name string, username string, password string) error {
+	if err := m.domainManager.SetUserPassword(ctx, name, username, password); err != nil {
+		return fmt.Errorf("setting guest password: %w", err)
+	}
+
+	m.logger.Info("Guest password set",
+		logger.String("name", name),
+		logger.String("user", username))
+	return nil
+}
+
+// ExecGuestCommand implements Manager.ExecGuestCommand.
+func (m *VMManager) ExecGuestCommand(ctx context.Context, name string, command vm.GuestCommand) (*vm.GuestCommandResult, error) {
+	result, err := m.domainManager.GuestExec(ctx, name, command)
+	if err != nil {
+		return nil, fmt.Errorf("running guest command: %w", err)
+	}
+
+	return result, nil
+}
+
+// addGuestInfo adds guest agent data to a VM and fills in interface
+// addresses by MAC address. VMs without a responsive agent are left as is.
+func (m *VMManager) addGuestInfo(ctx context.Context, result *vm.VM) {
+	info, err := m.domainManager.GetGuestInfo(ctx, result.Name)
+	if err != nil {
+		m.logger.Debug("Guest agent data not available",
+			logger.String("name", result.Name),
+			logger.Error(err))
+		return
+	}
+
+	result.Guest = info
+
+	for i := range result.Networks {
+		network := &result.Networks[i]
+		for _, iface := range info.Interfaces {
+			if !strings.EqualFold(iface.MacAddress, network.MacAddress) {
+				continue
+			}
+
+			for _, addr := range iface.IPAddresses {
+				switch {
+				case addr.Type == vm.GuestIPAddressIPv4 && network.IPAddress == "":
+					network.IPAddress = addr.Address
+				case addr.Type == vm.GuestIPAddressIPv6 && network.IPAddressV6 == "" && !isLinkLocalIPv6(addr.Address):
+					network.IPAddressV6 = addr.Address
+				}
+			}
+		}
+	}
+}
+
+// syncGuestTime sets the guest clock from the host clock. Guests without an
+// agent keep their clock and catch up through NTP.
+func (m *VMManager) syncGuestTime(ctx context.Context, name string) {
+	if err := m.domainManager.SyncTime(ctx, name); err != nil {
+		m.logger.Debug("Failed to synchronize guest time",
+			logger.String("name", name),
+			logger.Error(err))
+	}
+}
+
+// isLinkLocalIPv6 checks if an address is an IPv6 link-local address.
+func isLinkLocalIPv6(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLinkLocalUnicast()
+}
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/vm"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVMManager_GetDetails_WithGuestInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	// Set up expectations
+	domainVM := &vm.VM{
+		Name:   "test-vm",
+		Status: vm.VMStatusRunning,
+		Networks: []vm.NetInfo{
+			{Type: vm.NetworkTypeBridge, Source: "br0", MacAddress: "52:54:00:AA:BB:CC"},
+		},
+	}
+	guestInfo := &vm.GuestInfo{
+		Hostname: "test-host",
+		OS:       vm.GuestOSInfo{ID: "ubuntu"},
+		Interfaces: []vm.GuestInterface{
+			{
+				Name:       "eth0",
+				MacAddress: "52:54:00:aa:bb:cc",
+				IPAddresses: []vm.GuestIPAddress{
+					{Address: "fe80::5054:ff:feaa:bbcc", Type: vm.GuestIPAddressIPv6, Prefix: 64},
+					{Address: "192.168.1.20", Type: vm.GuestIPAddressIPv4, Prefix: 24},
+					{Address: "2001:db8::20", Type: vm.GuestIPAddressIPv6, Prefix: 64},
+				},
+			},
+		},
+	}
+
+	mockDomainManager.EXPECT().
+		Get(gomock.Any(), "test-vm").
+		Return(domainVM, nil)
+	mockDomainManager.EXPECT().
+		GetGuestInfo(gomock.Any(), "test-vm").
+		Return(guestInfo, nil)
+
+	// Test Get
+	result, err := manager.GetDetails(context.Background(), "test-vm")
+	require.NoError(t, err)
+	assert.Equal(t, guestInfo, result.Guest)
+	require.Len(t, result.Networks, 1)
+	assert.Equal(t, "192.168.1.20", result.Networks[0].IPAddress)
+	assert.Equal(t, "2001:db8::20", result.Networks[0].IPAddressV6)
+}
+
+func TestVMManager_GetDetails_StoppedSkipsGuestAgent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	// No guest agent call is expected for a stopped VM
+	mockDomainManager.EXPECT().
+		Get(gomock.Any(), "test-vm").
+		Return(&vm.VM{Name: "test-vm", Status: vm.VMStatusStopped}, nil)
+
+	result, err := manager.GetDetails(context.Background(), "test-vm")
+	require.NoError(t, err)
+	assert.Nil(t, result.Guest)
+}
+
+func TestVMManager_Resume_SyncsGuestTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	// A failed time sync does not fail the resume
+	gomock.InOrder(
+		mockDomainManager.EXPECT().Resume(gomock.Any(), "test-vm").Return(nil),
+		mockDomainManager.EXPECT().SyncTime(gomock.Any(), "test-vm").Return(errors.New("agent not connected")),
+	)
+
+	err := manager.Resume(context.Background(), "test-vm")
+	require.NoError(t, err)
+}
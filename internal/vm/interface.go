@@ -2,6 +2,7 @@ package vm
 
 import (
 	"context"
+	"net"
 
 	"github.com/threatflux/libgo/internal/models/vm"
 )
@@ -14,6 +15,10 @@ type Manager interface {
 	// Get gets a VM by name
 	Get(ctx context.Context, name string) (*vm.VM, error)
 
+	// GetDetails gets a VM by name, including the data reported by the
+	// guest agent of a running VM
+	GetDetails(ctx context.Context, name string) (*vm.VM, error)
+
 	// List lists all VMs
 	List(ctx context.Context) ([]*vm.VM, error)
 
@@ -23,11 +28,73 @@ type Manager interface {
 	// Start starts a VM
 	Start(ctx context.Context, name string) error
 
-	// Stop stops a VM
+	// Stop shuts down a VM with the default shutdown options
 	Stop(ctx context.Context, name string) error
 
-	// Restart restarts a VM
-	Restart(ctx context.Context, name string) error
+	// Shutdown shuts down a VM, escalating to powering it off if the guest
+	// does not shut down within the grace period
+	Shutdown(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.PowerResult, error)
+
+	// ForceStop forces a VM to stop
+	ForceStop(ctx context.Context, name string) error
+
+	// Restart shuts down a VM and starts it again
+	Restart(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.PowerResult, error)
+
+	// StartShutdown checks that a VM exists and shuts it down in the background
+	StartShutdown(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.Job, error)
+
+	// StartRestart checks that a VM exists and restarts it in the background
+	StartRestart(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.Job, error)
+
+	// Reboot reboots a running VM from within the guest
+	Reboot(ctx context.Context, name string) error
+
+	// Reset resets a running VM without involving the guest
+	Reset(ctx context.Context, name string) error
+
+	// Pause pauses a running VM
+	Pause(ctx context.Context, name string) error
+
+	// Resume resumes a paused VM
+	Resume(ctx context.Context, name string) error
+
+	// SendKeys sends a key combination to a VM
+	SendKeys(ctx context.Context, name string, keys []string) error
+
+	// GetDefinition gets the persistent XML definition of a VM
+	GetDefinition(ctx context.Context, name string) (string, error)
+
+	// UpdateDefinition replaces the persistent XML definition of a VM
+	UpdateDefinition(ctx context.Context, name string, definition string) (*vm.DefinitionUpdate, error)
+
+	// Update applies structured changes to the persistent definition of a VM
+	Update(ctx context.Context, name string, params vm.UpdateParams) (*vm.DefinitionUpdate, error)
+
+	// Clone creates a copy of a VM with a new identity
+	Clone(ctx context.Context, sourceName string, params vm.CloneParams) (*vm.VM, error)
+
+	// StartClone checks a clone request and clones the VM in the background
+	StartClone(ctx context.Context, sourceName string, params vm.CloneParams) (*vm.Job, error)
+
+	// GetJob gets a VM job by ID
+	GetJob(ctx context.Context, id string) (*vm.Job, error)
+
+	// ListJobs lists all VM jobs
+	ListJobs(ctx context.Context) ([]*vm.Job, error)
+
+	// GetMetrics gets resource usage metrics of a running VM
+	GetMetrics(ctx context.Context, name string) (*vm.Metrics, error)
+
+	// Guest agent operations
+	// GetGuestInfo gets information reported by the guest agent of a running VM
+	GetGuestInfo(ctx context.Context, name string) (*vm.GuestInfo, error)
+
+	// SetGuestPassword sets the password of a user account in a running VM
+	SetGuestPassword(ctx context.Context, name string, username string, password string) error
+
+	// ExecGuestCommand runs a program in a running VM through the guest agent
+	ExecGuestCommand(ctx context.Context, name string, command vm.GuestCommand) (*vm.GuestCommandResult, error)
 
 	// Snapshot operations
 	// CreateSnapshot creates a new snapshot of a VM
@@ -42,6 +109,36 @@ type Manager interface {
 	// DeleteSnapshot deletes a snapshot
 	DeleteSnapshot(ctx context.Context, vmName string, snapshotName string) error
 
+	// StartDeleteSnapshot deletes an internal snapshot at once, returning a
+	// nil job, and an external snapshot in the background
+	StartDeleteSnapshot(ctx context.Context, vmName string, snapshotName string) (*vm.Job, error)
+
 	// RevertSnapshot reverts a VM to a snapshot
 	RevertSnapshot(ctx context.Context, vmName string, snapshotName string) error
+
+	// SetDiskIOTune replaces the I/O limits of a disk, applying them at once to a running VM
+	SetDiskIOTune(ctx context.Context, name string, device string, tune vm.DiskIOTune) (*vm.DiskInfo, error)
+
+	// Block job operations
+	// BlockCommit starts merging images of a disk's backing chain into a lower image
+	BlockCommit(ctx context.Context, name string, params vm.BlockCommitParams) (*vm.BlockJob, error)
+
+	// BlockPull starts pulling backing data of a disk up into its active image
+	BlockPull(ctx context.Context, name string, params vm.BlockPullParams) (*vm.BlockJob, error)
+
+	// ListBlockJobs lists the running block jobs of a VM
+	ListBlockJobs(ctx context.Context, name string) ([]vm.BlockJob, error)
+
+	// AbortBlockJob cancels the block job of a disk
+	AbortBlockJob(ctx context.Context, name string, device string) error
+
+	// Console operations
+	// OpenConsole opens a serial console session on a running VM
+	OpenConsole(ctx context.Context, name string, opts vm.ConsoleOptions) (vm.ConsoleStream, error)
+
+	// GetGraphics gets the graphics devices of a running VM
+	GetGraphics(ctx context.Context, name string) ([]vm.GraphicsInfo, error)
+
+	// OpenGraphics connects to the graphics device of a type of a running VM
+	OpenGraphics(ctx context.Context, name string, graphicsType vm.GraphicsType) (net.Conn, error)
 }
@@ -0,0 +1,38 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// SetDiskIOTune implements Manager.SetDiskIOTune.
+func (m *VMManager) SetDiskIOTune(ctx context.Context, name string, device string, tune vm.DiskIOTune) (*vm.DiskInfo, error) {
+	if err := tune.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidParameter, err)
+	}
+
+	if err := m.domainManager.SetDiskIOTune(ctx, name, device, tune); err != nil {
+		return nil, fmt.Errorf("setting disk I/O limits: %w", err)
+	}
+
+	// Report the limits as libvirt applied them
+	updated, err := m.domainManager.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("getting VM: %w", err)
+	}
+	for i := range updated.Disks {
+		if updated.Disks[i].Device == device {
+			m.logger.Info("VM disk I/O limits set",
+				logger.String("name", name),
+				logger.String("device", device))
+			return &updated.Disks[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s on %s", domain.ErrDiskNotFound, device, name)
+}
@@ -0,0 +1,95 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/jobs"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/models/vm"
+)
+
+// jobStore provides thread-safe storage for VM jobs.
+type jobStore struct {
+	*jobs.Store[vm.Job]
+}
+
+// newJobStore creates a new job store.
+func newJobStore() *jobStore {
+	return &jobStore{Store: jobs.NewStore[vm.Job]()}
+}
+
+// create records a job as running on the host selected in ctx. It fails if
+// a running job on that host conflicts with it.
+func (s *jobStore) create(ctx context.Context, job *vm.Job) (*vm.Job, error) {
+	job.ID = uuid.New().String()
+	job.Host, _ = connection.HostFromContext(ctx)
+	job.Status = vm.JobStatusRunning
+	job.StartTime = time.Now()
+
+	return s.Add(job.ID, job, func(id string, running *vm.Job) error {
+		if running.Host != job.Host || running.Status.IsFinal() {
+			return nil
+		}
+		if job.Target != "" && running.Target == job.Target {
+			return fmt.Errorf("%w: VM %s is being created by job %s", ErrVMAlreadyExists, job.Target, id)
+		}
+		if running.Name == job.Name && jobGroup(running.Type) != "" && jobGroup(running.Type) == jobGroup(job.Type) {
+			return fmt.Errorf("%w: VM %s is busy with %s job %s", ErrVMInvalidState, job.Name, running.Type, id)
+		}
+		return nil
+	})
+}
+
+// jobGroup returns the group of job types of which only one job may run on
+// a VM at a time, or an empty string if jobs of the type may run together.
+func jobGroup(jobType vm.JobType) string {
+	switch jobType {
+	case vm.JobTypeShutdown, vm.JobTypeRestart:
+		return "power"
+	case vm.JobTypeSnapshotDelete:
+		return "snapshot"
+	default:
+		return ""
+	}
+}
+
+// get gets a copy of a job by ID.
+func (s *jobStore) get(id string) (*vm.Job, error) {
+	job, exists := s.Get(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrVMJobNotFound, id)
+	}
+
+	return job, nil
+}
+
+// finish records the outcome of a job. record, if set, stores the result
+// of a successful job.
+func (s *jobStore) finish(id string, err error, record func(job *vm.Job)) {
+	s.Update(id, func(job *vm.Job) {
+		if err != nil {
+			job.Status = vm.JobStatusFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = vm.JobStatusCompleted
+			if record != nil {
+				record(job)
+			}
+		}
+		job.EndTime = time.Now()
+	})
+}
+
+// GetJob implements Manager.GetJob.
+func (m *VMManager) GetJob(ctx context.Context, id string) (*vm.Job, error) {
+	return m.jobs.get(id)
+}
+
+// ListJobs implements Manager.ListJobs.
+func (m *VMManager) ListJobs(ctx context.Context) ([]*vm.Job, error) {
+	return m.jobs.List(), nil
+}
@@ -3,9 +3,13 @@ package vm
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/threatflux/libgo/internal/libvirt/connection"
 	"github.com/threatflux/libgo/internal/libvirt/domain"
 	"github.com/threatflux/libgo/internal/libvirt/network"
 	"github.com/threatflux/libgo/internal/libvirt/storage"
@@ -23,9 +27,18 @@ type VMManager struct {
 	networkManager   network.Manager
 	templateManager  template.Manager
 	cloudInitManager cloudinit.Manager
+	imageLibrary     storage.ImageLibrary
+	hosts            connection.HostLocator
 	logger           logger.Logger
+	// Background jobs, such as clones
+	jobs *jobStore
+	// Previous metrics samples used to compute rates
+	lastMetrics map[metricsKey]vm.Metrics
+	// metricsPruned is when stale samples were last dropped
+	metricsPruned time.Time
 	// Group struct (potentially smaller than interfaces)
-	config Config
+	config      Config
+	metricsLock sync.Mutex
 }
 
 // Config holds VM manager configuration.
@@ -34,6 +47,11 @@ type Config struct {
 	NetworkName     string
 	WorkDir         string
 	CloudInitDir    string
+	// SharedFolderPaths lists the host directories, and their
+	// subdirectories, that may be shared with VMs
+	SharedFolderPaths []string
+	// ShutdownTimeout is the default grace period for graceful shutdowns
+	ShutdownTimeout time.Duration
 }
 
 // NewVMManager creates a new VMManager.
@@ -43,6 +61,8 @@ func NewVMManager(
 	networkManager network.Manager,
 	templateManager template.Manager,
 	cloudInitManager cloudinit.Manager,
+	imageLibrary storage.ImageLibrary,
+	hosts connection.HostLocator,
 	config Config,
 	logger logger.Logger,
 ) *VMManager {
@@ -52,8 +72,12 @@ func NewVMManager(
 		networkManager:   networkManager,
 		templateManager:  templateManager,
 		cloudInitManager: cloudInitManager,
+		imageLibrary:     imageLibrary,
+		hosts:            hosts,
 		config:           config,
 		logger:           logger,
+		jobs:             newJobStore(),
+		lastMetrics:      make(map[metricsKey]vm.Metrics),
 	}
 }
 
@@ -71,29 +95,45 @@ func (m *VMManager) Create(ctx context.Context, params vm.VMParams) (*vm.VM, err
 	params.CloudInit.ISODir = m.config.CloudInitDir
 
 	// Validate parameters
-	if err := m.validateParams(params); err != nil {
+	if err := m.validateParams(ctx, params); err != nil {
 		return nil, fmt.Errorf("validating parameters: %w", err)
 	}
 
 	// Set default values if not provided
 	params = m.setDefaultParams(params)
 
+	// Pick the shared folder drivers before they end up in cloud-init
+	if err := m.resolveSharedFolderDrivers(ctx, params.SharedFolders); err != nil {
+		return nil, err
+	}
+
 	// Ensure cloud-init directory exists
 	if err := os.MkdirAll(m.config.CloudInitDir, 0755); err != nil {
 		return nil, fmt.Errorf("creating cloud-init directory: %w", err)
 	}
 
+	// Volumes created from here on are recorded as disks of the VM
+	ctx = storage.WithVolumeOrigin(ctx, storage.VolumeOrigin{VM: params.Name})
+
 	// Create VM disk
 	if err := m.createVMDisk(ctx, params); err != nil {
 		return nil, fmt.Errorf("creating VM disk: %w", err)
 	}
 
+	// Attach the disk the way its pool type needs
+	if err := m.resolveDiskSource(ctx, &params); err != nil {
+		_ = m.cleanupDisk(ctx, params) //nolint:errcheck // Cleanup errors are logged but don't affect the primary error
+		return nil, fmt.Errorf("resolving VM disk source: %w", err)
+	}
+
 	// Generate and create cloud-init ISO
-	if err := m.setupCloudInit(ctx, params); err != nil {
+	isoPath, err := m.setupCloudInit(ctx, params)
+	if err != nil {
 		// Attempt to clean up disk on failure
 		_ = m.cleanupDisk(ctx, params) //nolint:errcheck // Cleanup errors are logged but don't affect the primary error
 		return nil, fmt.Errorf("setting up cloud-init: %w", err)
 	}
+	params.CloudInit.ISOPath = isoPath
 
 	// Create domain
 	vm, err := m.domainManager.Create(ctx, params)
@@ -112,6 +152,22 @@ func (m *VMManager) Get(ctx context.Context, name string) (*vm.VM, error) {
 	return m.domainManager.Get(ctx, name)
 }
 
+// GetDetails implements Manager.GetDetails. Querying the guest agent can
+// take seconds, so only callers presenting the VM to users ask for it.
+func (m *VMManager) GetDetails(ctx context.Context, name string) (*vm.VM, error) {
+	result, err := m.domainManager.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Guest agent data is only available while the VM is running
+	if result.Status == vm.VMStatusRunning {
+		m.addGuestInfo(ctx, result)
+	}
+
+	return result, nil
+}
+
 // List implements Manager.List.
 func (m *VMManager) List(ctx context.Context) ([]*vm.VM, error) {
 	return m.domainManager.List(ctx)
@@ -132,12 +188,7 @@ func (m *VMManager) Delete(ctx context.Context, name string) error {
 
 	// Delete VM disks
 	for _, disk := range vmInfo.Disks {
-		// Extract volume name and pool from path
-		volumeName := filepath.Base(disk.Path)
-		poolName := disk.StoragePool
-		if poolName == "" {
-			poolName = m.config.StoragePoolName
-		}
+		poolName, volumeName := m.diskVolume(ctx, disk)
 
 		m.logger.Debug("Deleting disk volume",
 			logger.String("vm", name),
@@ -155,8 +206,9 @@ func (m *VMManager) Delete(ctx context.Context, name string) error {
 	}
 
 	// Delete cloud-init ISO if it exists
-	cloudInitVolName := fmt.Sprintf("%s-cloudinit.iso", name)
-	_ = m.storageManager.Delete(ctx, m.config.StoragePoolName, cloudInitVolName) //nolint:errcheck // Cloud-init ISO deletion failure is not critical
+	_ = m.storageManager.Delete(ctx, m.config.StoragePoolName, cloudInitVolumeName(name)) //nolint:errcheck // Cloud-init ISO deletion failure is not critical
+
+	m.forgetMetrics(newMetricsKey(ctx, name))
 
 	m.logger.Info("VM deleted", logger.String("name", name))
 	return nil
@@ -174,40 +226,64 @@ func (m *VMManager) Start(ctx context.Context, name string) error {
 
 // Stop implements Manager.Stop.
 func (m *VMManager) Stop(ctx context.Context, name string) error {
-	if err := m.domainManager.Stop(ctx, name); err != nil {
-		return fmt.Errorf("stopping VM: %w", err)
+	_, err := m.Shutdown(ctx, name, vm.ShutdownOptions{})
+	return err
+}
+
+// ForceStop implements Manager.ForceStop.
+func (m *VMManager) ForceStop(ctx context.Context, name string) error {
+	if err := m.domainManager.ForceStop(ctx, name); err != nil {
+		return fmt.Errorf("force stopping VM: %w", err)
 	}
 
-	m.logger.Info("VM stopped", logger.String("name", name))
+	m.logger.Info("VM force stopped", logger.String("name", name))
 	return nil
 }
 
-// Restart implements Manager.Restart.
-func (m *VMManager) Restart(ctx context.Context, name string) error {
-	// Get VM to check its status
-	vm, err := m.domainManager.Get(ctx, name)
-	if err != nil {
-		return fmt.Errorf("getting VM info: %w", err)
+// Reboot implements Manager.Reboot.
+func (m *VMManager) Reboot(ctx context.Context, name string) error {
+	if err := m.domainManager.Reboot(ctx, name); err != nil {
+		return fmt.Errorf("rebooting VM: %w", err)
 	}
 
-	// If VM is running, stop it first
-	if vm.Status == "running" {
-		if err := m.domainManager.Stop(ctx, name); err != nil {
-			return fmt.Errorf("stopping VM for restart: %w", err)
-		}
+	m.logger.Info("VM rebooted", logger.String("name", name))
+	return nil
+}
+
+// Pause implements Manager.Pause.
+func (m *VMManager) Pause(ctx context.Context, name string) error {
+	if err := m.domainManager.Pause(ctx, name); err != nil {
+		return fmt.Errorf("pausing VM: %w", err)
 	}
 
-	// Start the VM
-	if err := m.domainManager.Start(ctx, name); err != nil {
-		return fmt.Errorf("starting VM for restart: %w", err)
+	m.logger.Info("VM paused", logger.String("name", name))
+	return nil
+}
+
+// Resume implements Manager.Resume.
+func (m *VMManager) Resume(ctx context.Context, name string) error {
+	if err := m.domainManager.Resume(ctx, name); err != nil {
+		return fmt.Errorf("resuming VM: %w", err)
+	}
+
+	// The guest clock stood still while paused
+	m.syncGuestTime(ctx, name)
+
+	m.logger.Info("VM resumed", logger.String("name", name))
+	return nil
+}
+
+// SendKeys implements Manager.SendKeys.
+func (m *VMManager) SendKeys(ctx context.Context, name string, keys []string) error {
+	if err := m.domainManager.SendKeys(ctx, name, keys); err != nil {
+		return fmt.Errorf("sending keys to VM: %w", err)
 	}
 
-	m.logger.Info("VM restarted", logger.String("name", name))
 	return nil
 }
 
 // validateParams validates VM creation parameters.
-func (m *VMManager) validateParams(params vm.VMParams) error {
+func (m *VMManager) validateParams(ctx context.Context, params vm.VMParams) error {
 	// Check VM name
 	if params.Name == "" {
 		return fmt.Errorf("VM name is required")
@@ -240,6 +316,11 @@ func (m *VMManager) validateParams(params vm.VMParams) error {
 		}
 	}
 
+	// Validate shared folders
+	if err := m.validateSharedFolders(ctx, params.SharedFolders); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -286,6 +367,31 @@ func (m *VMManager) createVMDisk(ctx context.Context, params vm.VMParams) error
 		logger.String("format", string(params.Disk.Format)),
 		logger.Uint64("size", params.Disk.SizeBytes))
 
+	// Disks from a base image only store the blocks they change
+	if params.Disk.BaseImage != "" {
+		if m.imageLibrary == nil {
+			return fmt.Errorf("image library is not configured")
+		}
+		return m.imageLibrary.CreateOverlay(ctx, params.Disk.BaseImage, &storage.OverlayParams{
+			Pool:          poolName,
+			Volume:        volumeName,
+			VM:            params.Name,
+			CapacityBytes: params.Disk.SizeBytes,
+			Encrypted:     params.Disk.Encrypted,
+		})
+	}
+
+	// Source images are copied as they are, so DiskParams.Validate rejects
+	// encrypting them
+	if params.Disk.Encrypted {
+		return m.storageManager.CreateWithParams(ctx, poolName, &storage.CreateVolumeParams{
+			Name:          volumeName,
+			Format:        string(params.Disk.Format),
+			CapacityBytes: params.Disk.SizeBytes,
+			Encrypted:     true,
+		})
+	}
+
 	// If source image is provided, create from image
 	if params.Disk.SourceImage != "" {
 		return m.storageManager.CreateFromImage(
@@ -307,8 +413,80 @@ func (m *VMManager) createVMDisk(ctx context.Context, params vm.VMParams) error
 	)
 }
 
-// setupCloudInit generates cloud-init data and creates the ISO.
-func (m *VMManager) setupCloudInit(ctx context.Context, params vm.VMParams) error {
+// resolveDiskSource sets the source of a disk created in a storage pool.
+// Source images are attached as files and need no resolution.
+func (m *VMManager) resolveDiskSource(ctx context.Context, params *vm.VMParams) error {
+	if params.Disk.SourceImage != "" {
+		return nil
+	}
+
+	source, err := m.storageManager.GetSource(ctx, params.Disk.StoragePool, vm.GenerateVolumeName(params.Name, 0))
+	if err != nil {
+		return err
+	}
+	params.Disk.Source = diskSourceFromVolume(source)
+
+	// Discarded blocks go back to pools that allocate space as it is
+	// written
+	if params.Disk.Discard == "" && source.Thin {
+		params.Disk.Discard = vm.DiskDiscardUnmap
+	}
+
+	return nil
+}
+
+// diskSourceFromVolume converts how a volume is attached into the source of
+// a VM disk.
+func diskSourceFromVolume(source *storage.VolumeSource) *vm.DiskSource {
+	diskSource := &vm.DiskSource{
+		Type:     vm.DiskType(source.Type),
+		Path:     source.Path,
+		Protocol: source.Protocol,
+		Name:     source.Name,
+	}
+	for _, host := range source.Hosts {
+		diskSource.Hosts = append(diskSource.Hosts, vm.DiskHost{Name: host.Name, Port: host.Port})
+	}
+	if source.Auth != nil {
+		diskSource.Auth = &vm.DiskAuth{
+			Username:    source.Auth.Username,
+			SecretType:  source.Auth.Type,
+			SecretUUID:  source.Auth.SecretUUID,
+			SecretUsage: source.Auth.SecretUsage,
+		}
+	}
+	if source.Encryption != nil {
+		diskSource.Encryption = &vm.DiskEncryption{
+			Format:     source.Encryption.Format,
+			SecretUUID: source.Encryption.SecretUUID,
+		}
+	}
+	return diskSource
+}
+
+// diskVolume returns the pool and volume of a VM disk. Disks that do not
+// name their pool, such as block and network disks, are looked up by path
+// and otherwise assumed to be in the default pool.
+func (m *VMManager) diskVolume(ctx context.Context, disk vm.DiskInfo) (string, string) {
+	// Volume disks name both
+	if disk.StoragePool != "" && disk.VolumeName != "" {
+		return disk.StoragePool, disk.VolumeName
+	}
+	if disk.StoragePool != "" {
+		return disk.StoragePool, filepath.Base(disk.Path)
+	}
+
+	if poolName, volumeName, err := m.storageManager.LookupByPath(ctx, disk.Path); err == nil {
+		return poolName, volumeName
+	}
+
+	return m.config.StoragePoolName, filepath.Base(disk.Path)
+}
+
+// setupCloudInit generates cloud-init data and stores the ISO as a volume of
+// the default storage pool, so it is created on the host running the VM. It
+// returns the path of the ISO volume.
+func (m *VMManager) setupCloudInit(ctx context.Context, params vm.VMParams) (string, error) {
 	// Generate cloud-init data if not provided
 	var config vm.CloudInitConfig
 
@@ -316,7 +494,7 @@ func (m *VMManager) setupCloudInit(ctx context.Context, params vm.VMParams) erro
 	if params.CloudInit.UserData == "" {
 		userData, err := m.cloudInitManager.GenerateUserData(params)
 		if err != nil {
-			return fmt.Errorf("generating user-data: %w", err)
+			return "", fmt.Errorf("generating user-data: %w", err)
 		}
 		config.UserData = userData
 	} else {
@@ -327,7 +505,7 @@ func (m *VMManager) setupCloudInit(ctx context.Context, params vm.VMParams) erro
 	if params.CloudInit.MetaData == "" {
 		metaData, err := m.cloudInitManager.GenerateMetaData(params)
 		if err != nil {
-			return fmt.Errorf("generating meta-data: %w", err)
+			return "", fmt.Errorf("generating meta-data: %w", err)
 		}
 		config.MetaData = metaData
 	} else {
@@ -338,15 +516,16 @@ func (m *VMManager) setupCloudInit(ctx context.Context, params vm.VMParams) erro
 	if params.CloudInit.NetworkConfig == "" {
 		networkConfig, err := m.cloudInitManager.GenerateNetworkConfig(params)
 		if err != nil {
-			return fmt.Errorf("generating network-config: %w", err)
+			return "", fmt.Errorf("generating network-config: %w", err)
 		}
 		config.NetworkConfig = networkConfig
 	} else {
 		config.NetworkConfig = params.CloudInit.NetworkConfig
 	}
 
-	// Create cloud-init ISO - ensure path matches what domain XML builder expects
-	isoPath := filepath.Join(m.config.CloudInitDir, fmt.Sprintf("%s-cloudinit.iso", params.Name))
+	// The ISO is built in the local cloud-init directory and uploaded
+	// through libvirt from there
+	isoPath := filepath.Join(m.config.CloudInitDir, cloudInitVolumeName(params.Name))
 
 	m.logger.Debug("Creating cloud-init ISO",
 		logger.String("vm", params.Name),
@@ -360,10 +539,59 @@ func (m *VMManager) setupCloudInit(ctx context.Context, params vm.VMParams) erro
 	}
 
 	if err := m.cloudInitManager.GenerateISO(ctx, cloudInitConfig, isoPath); err != nil {
-		return fmt.Errorf("generating cloud-init ISO: %w", err)
+		return "", fmt.Errorf("generating cloud-init ISO: %w", err)
 	}
+	defer os.Remove(isoPath)
 
-	return nil
+	return m.uploadCloudInitISO(ctx, params.Name, isoPath)
+}
+
+// uploadCloudInitISO stores a generated cloud-init ISO as a volume of the
+// default storage pool and returns the path of the volume.
+func (m *VMManager) uploadCloudInitISO(ctx context.Context, vmName string, isoPath string) (string, error) {
+	file, err := os.Open(isoPath)
+	if err != nil {
+		return "", fmt.Errorf("opening cloud-init ISO: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("reading cloud-init ISO: %w", err)
+	}
+
+	poolName := m.config.StoragePoolName
+	volName := cloudInitVolumeName(vmName)
+	if err := m.storageManager.Create(ctx, poolName, volName, uint64(info.Size()), "raw"); err != nil {
+		return "", fmt.Errorf("creating cloud-init volume: %w", err)
+	}
+
+	path, err := m.storageManager.GetPath(ctx, poolName, volName)
+	if err == nil {
+		err = m.storageManager.Upload(ctx, poolName, volName, file)
+	}
+	if err != nil {
+		m.deleteCloudInitVolume(ctx, vmName)
+		return "", fmt.Errorf("uploading cloud-init ISO: %w", err)
+	}
+
+	return path, nil
+}
+
+// deleteCloudInitVolume deletes the cloud-init ISO volume of a VM.
+func (m *VMManager) deleteCloudInitVolume(ctx context.Context, vmName string) {
+	volName := cloudInitVolumeName(vmName)
+	if err := m.storageManager.Delete(ctx, m.config.StoragePoolName, volName); err != nil {
+		m.logger.Warn("Failed to delete cloud-init ISO",
+			logger.String("vm", vmName),
+			logger.String("volume", volName),
+			logger.Error(err))
+	}
+}
+
+// cloudInitVolumeName returns the name of the cloud-init ISO volume of a VM.
+func cloudInitVolumeName(vmName string) string {
+	return fmt.Sprintf("%s-cloudinit.iso", vmName)
 }
 
 // cleanupDisk cleans up VM disk on failure.
@@ -388,14 +616,8 @@ func (m *VMManager) cleanupResources(ctx context.Context, params vm.VMParams) er
 			logger.Error(err))
 	}
 
-	// Cleanup cloud-init ISO - make sure this matches the path used for creation
-	isoPath := filepath.Join(m.config.CloudInitDir, fmt.Sprintf("%s-cloudinit.iso", params.Name))
-	if err := os.Remove(isoPath); err != nil && !os.IsNotExist(err) {
-		m.logger.Warn("Failed to clean up cloud-init ISO",
-			logger.String("vm", params.Name),
-			logger.String("path", isoPath),
-			logger.Error(err))
-	}
+	// Cleanup cloud-init ISO
+	m.deleteCloudInitVolume(ctx, params.Name)
 
 	return nil
 }
@@ -470,6 +692,40 @@ func (m *VMManager) DeleteSnapshot(ctx context.Context, vmName string, snapshotN
 	return nil
 }
 
+// StartDeleteSnapshot deletes an internal snapshot at once and returns a nil
+// job. External snapshots are deleted in the background, since merging their
+// overlays copies the data written since.
+func (m *VMManager) StartDeleteSnapshot(ctx context.Context, vmName string, snapshotName string) (*vm.Job, error) {
+	snapshot, err := m.GetSnapshot(ctx, vmName, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !snapshot.External {
+		return nil, m.DeleteSnapshot(ctx, vmName, snapshotName)
+	}
+
+	job, err := m.jobs.create(ctx, &vm.Job{Type: vm.JobTypeSnapshotDelete, Name: vmName, Snapshot: snapshotName})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := m.DeleteSnapshot(context.WithoutCancel(ctx), vmName, snapshotName)
+		m.jobs.finish(job.ID, err, nil)
+
+		if err != nil {
+			m.logger.Error("VM snapshot deletion failed",
+				logger.String("job_id", job.ID),
+				logger.String("vm", vmName),
+				logger.String("snapshot", snapshotName),
+				logger.Error(err))
+		}
+	}()
+
+	return job, nil
+}
+
 // RevertSnapshot reverts a VM to a snapshot.
 func (m *VMManager) RevertSnapshot(ctx context.Context, vmName string, snapshotName string) error {
 	m.logger.Info("Reverting VM to snapshot",
@@ -487,3 +743,39 @@ func (m *VMManager) RevertSnapshot(ctx context.Context, vmName string, snapshotN
 
 	return nil
 }
+
+// OpenConsole opens a serial console session on a running VM.
+func (m *VMManager) OpenConsole(ctx context.Context, name string, opts vm.ConsoleOptions) (vm.ConsoleStream, error) {
+	m.logger.Debug("Opening VM console",
+		logger.String("name", name),
+		logger.String("device", opts.Device))
+
+	// Delegate to domain manager
+	console, err := m.domainManager.OpenConsole(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("opening console: %w", err)
+	}
+
+	return console, nil
+}
+
+// GetGraphics gets the graphics devices of a running VM.
+func (m *VMManager) GetGraphics(ctx context.Context, name string) ([]vm.GraphicsInfo, error) {
+	// Delegate to domain manager
+	graphics, err := m.domainManager.GetGraphics(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("getting graphics devices: %w", err)
+	}
+
+	return graphics, nil
+}
+
+// OpenGraphics connects to the graphics device of a type of a running VM.
+func (m *VMManager) OpenGraphics(ctx context.Context, name string, graphicsType vm.GraphicsType) (net.Conn, error) {
+	conn, err := m.domainManager.OpenGraphics(ctx, name, graphicsType)
+	if err != nil {
+		return nil, fmt.Errorf("opening graphics device: %w", err)
+	}
+
+	return conn, nil
+}
@@ -3,11 +3,16 @@ package vm
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
 	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/internal/vm/cloudinit"
 	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
 	mocks_network "github.com/threatflux/libgo/test/mocks/libvirt/network"
 	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
@@ -17,6 +22,21 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
+// writeTestISO stands in for cloudinit.Manager.GenerateISO.
+func writeTestISO(_ context.Context, _ cloudinit.CloudInitConfig, outputPath string) error {
+	return os.WriteFile(outputPath, []byte("iso"), 0o600)
+}
+
+// expectCloudInitUpload expects the cloud-init ISO of a VM to be uploaded as
+// a volume of the default pool.
+func expectCloudInitUpload(mockStorageManager *mocks_storage.MockVolumeManager, vmName string) {
+	volName := vmName + "-cloudinit.iso"
+	mockStorageManager.EXPECT().Create(gomock.Any(), "default", volName, uint64(3), "raw").Return(nil)
+	mockStorageManager.EXPECT().GetPath(gomock.Any(), "default", volName).
+		Return("/var/lib/libvirt/images/"+volName, nil)
+	mockStorageManager.EXPECT().Upload(gomock.Any(), "default", volName, gomock.Any()).Return(nil)
+}
+
 func TestVMManager_Create(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -34,11 +54,12 @@ func TestVMManager_Create(t *testing.T) {
 	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
 
 	// Create VM manager
+	cloudInitDir := t.TempDir()
 	config := Config{
 		StoragePoolName: "default",
 		NetworkName:     "default",
 		WorkDir:         "/tmp",
-		CloudInitDir:    "/tmp",
+		CloudInitDir:    cloudInitDir,
 	}
 
 	manager := NewVMManager(
@@ -47,6 +68,8 @@ func TestVMManager_Create(t *testing.T) {
 		mockNetworkManager,
 		mockTemplateManager,
 		mockCloudInitManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
 		config,
 		mockLogger,
 	)
@@ -77,6 +100,10 @@ func TestVMManager_Create(t *testing.T) {
 		Create(gomock.Any(), "default", "test-vm-disk-0", uint64(20*1024*1024*1024), "qcow2").
 		Return(nil)
 
+	mockStorageManager.EXPECT().
+		GetSource(gomock.Any(), "default", "test-vm-disk-0").
+		Return(&storage.VolumeSource{Type: "file", Path: "/var/lib/libvirt/images/test-vm-disk-0"}, nil)
+
 	// Set up expectations for cloud-init generation
 	mockCloudInitManager.EXPECT().
 		GenerateUserData(gomock.Any()).
@@ -91,8 +118,9 @@ func TestVMManager_Create(t *testing.T) {
 		Return("version: 2\nethernets:\n  ens3:\n    dhcp4: true", nil)
 
 	mockCloudInitManager.EXPECT().
-		GenerateISO(gomock.Any(), gomock.Any(), "/tmp/test-vm-cloudinit.iso").
-		Return(nil)
+		GenerateISO(gomock.Any(), gomock.Any(), filepath.Join(cloudInitDir, "test-vm-cloudinit.iso")).
+		DoAndReturn(writeTestISO)
+	expectCloudInitUpload(mockStorageManager, "test-vm")
 
 	// Set up expectation for domain creation
 	expectedVM := &vm.VM{
@@ -102,7 +130,10 @@ func TestVMManager_Create(t *testing.T) {
 
 	mockDomainManager.EXPECT().
 		Create(gomock.Any(), gomock.Any()).
-		Return(expectedVM, nil)
+		DoAndReturn(func(_ context.Context, params vm.VMParams) (*vm.VM, error) {
+			assert.Equal(t, "/var/lib/libvirt/images/test-vm-cloudinit.iso", params.CloudInit.ISOPath)
+			return expectedVM, nil
+		})
 
 	// Test VM creation
 	createdVM, err := manager.Create(context.Background(), vmParams)
@@ -127,11 +158,12 @@ func TestVMManager_Create_WithTemplate(t *testing.T) {
 	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
 
 	// Create VM manager
+	cloudInitDir := t.TempDir()
 	config := Config{
 		StoragePoolName: "default",
 		NetworkName:     "default",
 		WorkDir:         "/tmp",
-		CloudInitDir:    "/tmp",
+		CloudInitDir:    cloudInitDir,
 	}
 
 	manager := NewVMManager(
@@ -140,6 +172,8 @@ func TestVMManager_Create_WithTemplate(t *testing.T) {
 		mockNetworkManager,
 		mockTemplateManager,
 		mockCloudInitManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
 		config,
 		mockLogger,
 	)
@@ -169,6 +203,10 @@ func TestVMManager_Create_WithTemplate(t *testing.T) {
 		Create(gomock.Any(), "default", "test-vm-disk-0", uint64(10*1024*1024*1024), "qcow2").
 		Return(nil)
 
+	mockStorageManager.EXPECT().
+		GetSource(gomock.Any(), "default", "test-vm-disk-0").
+		Return(&storage.VolumeSource{Type: "file", Path: "/var/lib/libvirt/images/test-vm-disk-0"}, nil)
+
 	// Set up expectations for cloud-init generation
 	mockCloudInitManager.EXPECT().
 		GenerateUserData(gomock.Any()).
@@ -183,8 +221,9 @@ func TestVMManager_Create_WithTemplate(t *testing.T) {
 		Return("version: 2\nethernets:\n  ens3:\n    dhcp4: true", nil)
 
 	mockCloudInitManager.EXPECT().
-		GenerateISO(gomock.Any(), gomock.Any(), "/tmp/test-vm-cloudinit.iso").
-		Return(nil)
+		GenerateISO(gomock.Any(), gomock.Any(), filepath.Join(cloudInitDir, "test-vm-cloudinit.iso")).
+		DoAndReturn(writeTestISO)
+	expectCloudInitUpload(mockStorageManager, "test-vm")
 
 	// Set up expectation for domain creation
 	expectedVM := &vm.VM{
@@ -220,11 +259,12 @@ func TestVMManager_Create_Error(t *testing.T) {
 	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
 
 	// Create VM manager
+	cloudInitDir := t.TempDir()
 	config := Config{
 		StoragePoolName: "default",
 		NetworkName:     "default",
 		WorkDir:         "/tmp",
-		CloudInitDir:    "/tmp",
+		CloudInitDir:    cloudInitDir,
 	}
 
 	manager := NewVMManager(
@@ -233,6 +273,8 @@ func TestVMManager_Create_Error(t *testing.T) {
 		mockNetworkManager,
 		mockTemplateManager,
 		mockCloudInitManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
 		config,
 		mockLogger,
 	)
@@ -272,6 +314,10 @@ func TestVMManager_Create_Error(t *testing.T) {
 		Create(gomock.Any(), "default", "test-vm-disk-0", uint64(20*1024*1024*1024), "qcow2").
 		Return(nil)
 
+	mockStorageManager.EXPECT().
+		GetSource(gomock.Any(), "default", "test-vm-disk-0").
+		Return(&storage.VolumeSource{Type: "file", Path: "/var/lib/libvirt/images/test-vm-disk-0"}, nil)
+
 	mockCloudInitManager.EXPECT().
 		GenerateUserData(gomock.Any()).
 		Return("", errors.New("user-data generation failed"))
@@ -289,6 +335,10 @@ func TestVMManager_Create_Error(t *testing.T) {
 		Create(gomock.Any(), "default", "test-vm-disk-0", uint64(20*1024*1024*1024), "qcow2").
 		Return(nil)
 
+	mockStorageManager.EXPECT().
+		GetSource(gomock.Any(), "default", "test-vm-disk-0").
+		Return(&storage.VolumeSource{Type: "file", Path: "/var/lib/libvirt/images/test-vm-disk-0"}, nil)
+
 	mockCloudInitManager.EXPECT().
 		GenerateUserData(gomock.Any()).
 		Return("#cloud-config\nhostname: test-vm", nil)
@@ -302,8 +352,9 @@ func TestVMManager_Create_Error(t *testing.T) {
 		Return("version: 2\nethernets:\n  ens3:\n    dhcp4: true", nil)
 
 	mockCloudInitManager.EXPECT().
-		GenerateISO(gomock.Any(), gomock.Any(), "/tmp/test-vm-cloudinit.iso").
-		Return(nil)
+		GenerateISO(gomock.Any(), gomock.Any(), filepath.Join(cloudInitDir, "test-vm-cloudinit.iso")).
+		DoAndReturn(writeTestISO)
+	expectCloudInitUpload(mockStorageManager, "test-vm")
 
 	mockDomainManager.EXPECT().
 		Create(gomock.Any(), gomock.Any()).
@@ -312,12 +363,110 @@ func TestVMManager_Create_Error(t *testing.T) {
 	mockStorageManager.EXPECT().
 		Delete(gomock.Any(), "default", "test-vm-disk-0").
 		Return(nil)
+	mockStorageManager.EXPECT().
+		Delete(gomock.Any(), "default", "test-vm-cloudinit.iso").
+		Return(nil)
 
 	_, err = manager.Create(context.Background(), vmParams)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "creating domain")
 }
 
+func TestVMManager_CreateVMDisk_BaseImage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorageManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockImageLibrary := mocks_storage.NewMockImageLibrary(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	manager := NewVMManager(
+		nil, // Not used in this test
+		mockStorageManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		mockImageLibrary,
+		nil, // Not used in this test
+		Config{StoragePoolName: "default"},
+		mockLogger,
+	)
+
+	// The disk is an overlay of the image rather than a new volume
+	mockImageLibrary.EXPECT().
+		CreateOverlay(gomock.Any(), "ubuntu-24.04", &storage.OverlayParams{
+			Pool:          "default",
+			Volume:        "test-vm-disk-0",
+			VM:            "test-vm",
+			CapacityBytes: 20 * 1024 * 1024 * 1024,
+		}).
+		Return(nil)
+
+	err := manager.createVMDisk(context.Background(), vm.VMParams{
+		Name: "test-vm",
+		Disk: vm.DiskParams{
+			SizeBytes:   20 * 1024 * 1024 * 1024, // 20 GB
+			Format:      "qcow2",
+			StoragePool: "default",
+			BaseImage:   "ubuntu-24.04",
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestVMManager_ResolveDiskSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorageManager := mocks_storage.NewMockVolumeManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	manager := NewVMManager(
+		nil, // Not used in this test
+		mockStorageManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{StoragePoolName: "default"},
+		mockLogger,
+	)
+
+	// Volumes of RBD pools are attached as network disks
+	mockStorageManager.EXPECT().
+		GetSource(gomock.Any(), "ceph", "test-vm-disk-0").
+		Return(&storage.VolumeSource{
+			Type:     "network",
+			Protocol: "rbd",
+			Name:     "rbd/test-vm-disk-0",
+			Hosts:    []storage.StoragePoolHost{{Name: "mon1", Port: 6789}},
+			Auth:     &storage.StoragePoolAuth{Type: "ceph", Username: "libvirt", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+			Thin:     true,
+		}, nil)
+
+	params := vm.VMParams{
+		Name: "test-vm",
+		Disk: vm.DiskParams{Format: "raw", StoragePool: "ceph"},
+	}
+	require.NoError(t, manager.resolveDiskSource(context.Background(), &params))
+	assert.Equal(t, &vm.DiskSource{
+		Type:     vm.DiskTypeNetwork,
+		Protocol: "rbd",
+		Name:     "rbd/test-vm-disk-0",
+		Hosts:    []vm.DiskHost{{Name: "mon1", Port: 6789}},
+		Auth:     &vm.DiskAuth{Username: "libvirt", SecretType: "ceph", SecretUUID: "2ec115d7-3a88-3ceb-bc12-0ac909a6fd87"},
+	}, params.Disk.Source)
+	// RBD images are thin, so discards are passed on
+	assert.Equal(t, vm.DiskDiscardUnmap, params.Disk.Discard)
+
+	// Disks from a source image are attached as they are
+	params = vm.VMParams{Name: "test-vm", Disk: vm.DiskParams{SourceImage: "/images/test.qcow2"}}
+	require.NoError(t, manager.resolveDiskSource(context.Background(), &params))
+	assert.Nil(t, params.Disk.Source)
+}
+
 func TestVMManager_Get(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -333,6 +482,8 @@ func TestVMManager_Get(t *testing.T) {
 		nil, // Not used in this test
 		nil, // Not used in this test
 		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
 		Config{},
 		mockLogger,
 	)
@@ -347,6 +498,8 @@ func TestVMManager_Get(t *testing.T) {
 		Get(gomock.Any(), "test-vm").
 		Return(expectedVM, nil)
 
+	// Get does not query the guest agent, so no GetGuestInfo call is expected
+
 	// Test Get
 	vm, err := manager.Get(context.Background(), "test-vm")
 	require.NoError(t, err)
@@ -377,6 +530,8 @@ func TestVMManager_Delete(t *testing.T) {
 		nil, // Not used in this test
 		nil, // Not used in this test
 		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
 		config,
 		mockLogger,
 	)
@@ -432,6 +587,8 @@ func TestVMManager_Start(t *testing.T) {
 		nil, // Not used in this test
 		nil, // Not used in this test
 		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
 		Config{},
 		mockLogger,
 	)
@@ -464,15 +621,25 @@ func TestVMManager_Stop(t *testing.T) {
 		nil, // Not used in this test
 		nil, // Not used in this test
 		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
 		Config{},
 		mockLogger,
 	)
 
-	// Set up expectation
+	// Set up expectations
+	mockDomainManager.EXPECT().
+		Get(gomock.Any(), "test-vm").
+		Return(&vm.VM{Name: "test-vm", Status: vm.VMStatusRunning}, nil)
+
 	mockDomainManager.EXPECT().
 		Stop(gomock.Any(), "test-vm").
 		Return(nil)
 
+	mockDomainManager.EXPECT().
+		WaitForShutoff(gomock.Any(), "test-vm", defaultShutdownTimeout).
+		Return(true, nil)
+
 	// Test Stop
 	err := manager.Stop(context.Background(), "test-vm")
 	require.NoError(t, err)
@@ -496,6 +663,8 @@ func TestVMManager_Restart(t *testing.T) {
 		nil, // Not used in this test
 		nil, // Not used in this test
 		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
 		Config{},
 		mockLogger,
 	)
@@ -510,17 +679,23 @@ func TestVMManager_Restart(t *testing.T) {
 		Get(gomock.Any(), "test-vm").
 		Return(runningVM, nil)
 
-	mockDomainManager.EXPECT().
-		Stop(gomock.Any(), "test-vm").
-		Return(nil)
-
-	mockDomainManager.EXPECT().
-		Start(gomock.Any(), "test-vm").
-		Return(nil)
+	gomock.InOrder(
+		mockDomainManager.EXPECT().
+			Stop(gomock.Any(), "test-vm").
+			Return(nil),
+		mockDomainManager.EXPECT().
+			WaitForShutoff(gomock.Any(), "test-vm", defaultShutdownTimeout).
+			Return(true, nil),
+		mockDomainManager.EXPECT().
+			Start(gomock.Any(), "test-vm").
+			Return(nil),
+	)
 
 	// Test Restart (running VM)
-	err := manager.Restart(context.Background(), "test-vm")
+	result, err := manager.Restart(context.Background(), "test-vm", vm.ShutdownOptions{})
 	require.NoError(t, err)
+	assert.Equal(t, vm.ShutdownMethodACPI, result.Method)
+	assert.Equal(t, vm.VMStatusRunning, result.State)
 
 	// Test 2: VM is stopped
 	stoppedVM := &vm.VM{
@@ -537,6 +712,81 @@ func TestVMManager_Restart(t *testing.T) {
 		Return(nil)
 
 	// Test Restart (stopped VM)
-	err = manager.Restart(context.Background(), "test-vm")
+	result, err = manager.Restart(context.Background(), "test-vm", vm.ShutdownOptions{})
 	require.NoError(t, err)
+	assert.Equal(t, vm.ShutdownMethodNone, result.Method)
+}
+
+func TestVMManager_StartDeleteSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	t.Run("Internal snapshot is deleted at once", func(t *testing.T) {
+		mockDomainManager.EXPECT().GetSnapshot(gomock.Any(), "test-vm", "snap1").
+			Return(&vm.Snapshot{Name: "snap1"}, nil)
+		mockDomainManager.EXPECT().DeleteSnapshot(gomock.Any(), "test-vm", "snap1").Return(nil)
+
+		job, err := manager.StartDeleteSnapshot(context.Background(), "test-vm", "snap1")
+		require.NoError(t, err)
+		assert.Nil(t, job)
+	})
+
+	t.Run("External snapshot is merged in the background", func(t *testing.T) {
+		gate := make(chan struct{})
+		mockDomainManager.EXPECT().GetSnapshot(gomock.Any(), "test-vm", "snap2").
+			Return(&vm.Snapshot{Name: "snap2", External: true}, nil).Times(2)
+		mockDomainManager.EXPECT().DeleteSnapshot(gomock.Any(), "test-vm", "snap2").
+			DoAndReturn(func(ctx context.Context, _ string, _ string) error {
+				<-gate
+				// The merge outlives the request
+				return ctx.Err()
+			})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		job, err := manager.StartDeleteSnapshot(ctx, "test-vm", "snap2")
+		cancel()
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, vm.JobTypeSnapshotDelete, job.Type)
+		assert.Equal(t, "snap2", job.Snapshot)
+
+		// Only one merge per VM at a time
+		_, err = manager.StartDeleteSnapshot(context.Background(), "test-vm", "snap2")
+		assert.ErrorIs(t, err, ErrVMInvalidState)
+
+		close(gate)
+		require.Eventually(t, func() bool {
+			job, err := manager.GetJob(context.Background(), job.ID)
+			return err == nil && job.Status == vm.JobStatusCompleted
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Snapshot not found", func(t *testing.T) {
+		mockDomainManager.EXPECT().GetSnapshot(gomock.Any(), "test-vm", "missing").
+			Return(nil, errors.New("failed to get snapshot: not found"))
+
+		_, err := manager.StartDeleteSnapshot(context.Background(), "test-vm", "missing")
+		assert.Error(t, err)
+	})
 }
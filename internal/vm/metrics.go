@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/models/vm"
+)
+
+// metricsRetention is how long the last metrics sample of a VM is kept once
+// no collection sees the VM any more, e.g. after it was deleted outside the
+// API or nobody watches it.
+const metricsRetention = 10 * time.Minute
+
+// metricsKey identifies the metrics samples of a VM. VMs on different hosts
+// may have the same name.
+type metricsKey struct {
+	host string
+	name string
+}
+
+// newMetricsKey returns the key of the samples of a VM on the host a
+// request selects.
+func newMetricsKey(ctx context.Context, name string) metricsKey {
+	host, _ := connection.HostFromContext(ctx)
+	return metricsKey{host: host, name: name}
+}
+
+// GetMetrics implements Manager.GetMetrics.
+func (m *VMManager) GetMetrics(ctx context.Context, name string) (*vm.Metrics, error) {
+	key := newMetricsKey(ctx, name)
+
+	metrics, err := m.domainManager.GetStats(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrDomainNotFound) {
+			m.forgetMetrics(key)
+		}
+		return nil, fmt.Errorf("getting VM stats: %w", err)
+	}
+
+	m.metricsLock.Lock()
+	previous, hasPrevious := m.lastMetrics[key]
+	m.lastMetrics[key] = *metrics
+	m.pruneMetrics(metrics.Timestamp)
+	m.metricsLock.Unlock()
+
+	if hasPrevious {
+		applyRates(&previous, metrics)
+	}
+
+	return metrics, nil
+}
+
+// forgetMetrics drops the previous metrics sample of a VM.
+func (m *VMManager) forgetMetrics(key metricsKey) {
+	m.metricsLock.Lock()
+	delete(m.lastMetrics, key)
+	m.metricsLock.Unlock()
+}
+
+// pruneMetrics drops samples of VMs that were not collected within the
+// retention period. The caller must hold metricsLock.
+func (m *VMManager) pruneMetrics(now time.Time) {
+	if now.Sub(m.metricsPruned) < metricsRetention {
+		return
+	}
+	m.metricsPruned = now
+
+	for key, sample := range m.lastMetrics {
+		if now.Sub(sample.Timestamp) > metricsRetention {
+			delete(m.lastMetrics, key)
+		}
+	}
+}
+
+// applyRates fills in CPU utilization and throughput rates from the delta
+// between two samples. Counters that went backwards, e.g. after the VM was
+// restarted, yield a zero rate.
+func applyRates(previous, current *vm.Metrics) {
+	elapsed := current.Timestamp.Sub(previous.Timestamp)
+	if elapsed <= 0 {
+		return
+	}
+	seconds := elapsed.Seconds()
+
+	if current.CPU.VCPUs > 0 {
+		cpuDelta := counterDelta(previous.CPU.TimeNs, current.CPU.TimeNs)
+		utilization := float64(cpuDelta) / float64(elapsed.Nanoseconds()) / float64(current.CPU.VCPUs) * 100
+		current.CPU.Utilization = min(utilization, 100)
+	}
+
+	current.Network.RxBytesPerSecond = float64(counterDelta(previous.Network.RxBytes, current.Network.RxBytes)) / seconds
+	current.Network.TxBytesPerSecond = float64(counterDelta(previous.Network.TxBytes, current.Network.TxBytes)) / seconds
+	current.Disk.ReadBytesPerSecond = float64(counterDelta(previous.Disk.ReadBytes, current.Disk.ReadBytes)) / seconds
+	current.Disk.WriteBytesPerSecond = float64(counterDelta(previous.Disk.WriteBytes, current.Disk.WriteBytes)) / seconds
+}
+
+// counterDelta returns the increase of a cumulative counter.
+func counterDelta(previous, current uint64) uint64 {
+	if current < previous {
+		return 0
+	}
+	return current - previous
+}
@@ -0,0 +1,163 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/models/vm"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVMManager_GetMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	start := time.Now()
+	first := &vm.Metrics{Timestamp: start}
+	first.CPU.TimeNs = 1_000_000_000
+	first.CPU.VCPUs = 2
+	first.Network.RxBytes = 1000
+	first.Disk.WriteBytes = 4096
+
+	second := &vm.Metrics{Timestamp: start.Add(2 * time.Second)}
+	second.CPU.TimeNs = 3_000_000_000
+	second.CPU.VCPUs = 2
+	second.Network.RxBytes = 5000
+	second.Disk.WriteBytes = 4096 + 8192
+
+	gomock.InOrder(
+		mockDomainManager.EXPECT().GetStats(gomock.Any(), "test-vm").Return(first, nil),
+		mockDomainManager.EXPECT().GetStats(gomock.Any(), "test-vm").Return(second, nil),
+	)
+
+	// First sample has no rates
+	metrics, err := manager.GetMetrics(context.Background(), "test-vm")
+	require.NoError(t, err)
+	assert.Zero(t, metrics.CPU.Utilization)
+	assert.Zero(t, metrics.Network.RxBytesPerSecond)
+
+	// Second sample: 2s of CPU time over 2s on 2 VCPUs is 50%
+	metrics, err = manager.GetMetrics(context.Background(), "test-vm")
+	require.NoError(t, err)
+	assert.InDelta(t, 50.0, metrics.CPU.Utilization, 0.001)
+	assert.InDelta(t, 2000.0, metrics.Network.RxBytesPerSecond, 0.001)
+	assert.InDelta(t, 4096.0, metrics.Disk.WriteBytesPerSecond, 0.001)
+}
+
+func TestApplyRates_CounterReset(t *testing.T) {
+	start := time.Now()
+	previous := &vm.Metrics{Timestamp: start}
+	previous.CPU.TimeNs = 5_000_000_000
+	previous.Network.TxBytes = 10_000
+
+	// VM was restarted, counters start again from zero
+	current := &vm.Metrics{Timestamp: start.Add(5 * time.Second)}
+	current.CPU.TimeNs = 1_000_000
+	current.CPU.VCPUs = 1
+	current.Network.TxBytes = 100
+
+	applyRates(previous, current)
+
+	assert.Zero(t, current.CPU.Utilization)
+	assert.Zero(t, current.Network.TxBytesPerSecond)
+}
+
+func TestVMManager_GetMetrics_Pruning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	manager := NewVMManager(mockDomainManager, nil, nil, nil, nil, nil, nil, Config{}, mocks_logger.NewMockLogger(ctrl))
+
+	start := time.Now()
+
+	t.Run("Deleted VM", func(t *testing.T) {
+		mockDomainManager.EXPECT().GetStats(gomock.Any(), "deleted-vm").Return(&vm.Metrics{Timestamp: start}, nil)
+		mockDomainManager.EXPECT().GetStats(gomock.Any(), "deleted-vm").
+			Return(nil, fmt.Errorf("looking up domain: %w", domain.ErrDomainNotFound))
+
+		_, err := manager.GetMetrics(context.Background(), "deleted-vm")
+		require.NoError(t, err)
+		assert.Contains(t, manager.lastMetrics, metricsKey{name: "deleted-vm"})
+
+		_, err = manager.GetMetrics(context.Background(), "deleted-vm")
+		assert.ErrorIs(t, err, domain.ErrDomainNotFound)
+		assert.NotContains(t, manager.lastMetrics, metricsKey{name: "deleted-vm"})
+	})
+
+	t.Run("VM no longer collected", func(t *testing.T) {
+		mockDomainManager.EXPECT().GetStats(gomock.Any(), "idle-vm").Return(&vm.Metrics{Timestamp: start}, nil)
+		mockDomainManager.EXPECT().GetStats(gomock.Any(), "busy-vm").
+			Return(&vm.Metrics{Timestamp: start.Add(metricsRetention + time.Minute)}, nil)
+
+		_, err := manager.GetMetrics(context.Background(), "idle-vm")
+		require.NoError(t, err)
+		_, err = manager.GetMetrics(context.Background(), "busy-vm")
+		require.NoError(t, err)
+
+		assert.NotContains(t, manager.lastMetrics, metricsKey{name: "idle-vm"})
+		assert.Contains(t, manager.lastMetrics, metricsKey{name: "busy-vm"})
+	})
+}
+
+func TestVMManager_GetMetrics_Hosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	manager := NewVMManager(mockDomainManager, nil, nil, nil, nil, nil, nil, Config{}, mocks_logger.NewMockLogger(ctrl))
+
+	start := time.Now()
+	sample := func(offset time.Duration, cpuTimeNs uint64) *vm.Metrics {
+		metrics := &vm.Metrics{Timestamp: start.Add(offset)}
+		metrics.CPU.TimeNs = cpuTimeNs
+		metrics.CPU.VCPUs = 1
+		return metrics
+	}
+
+	hv1 := connection.WithHost(context.Background(), "hv1")
+	hv2 := connection.WithHost(context.Background(), "hv2")
+
+	// VMs named web run on both hosts with different CPU counters
+	gomock.InOrder(
+		mockDomainManager.EXPECT().GetStats(hv1, "web").Return(sample(0, 1_000_000_000), nil),
+		mockDomainManager.EXPECT().GetStats(hv2, "web").Return(sample(time.Second, 50_000_000_000), nil),
+		mockDomainManager.EXPECT().GetStats(hv1, "web").Return(sample(2*time.Second, 1_500_000_000), nil),
+	)
+
+	_, err := manager.GetMetrics(hv1, "web")
+	require.NoError(t, err)
+	_, err = manager.GetMetrics(hv2, "web")
+	require.NoError(t, err)
+
+	// The rate compares with the previous sample of the same host
+	metrics, err := manager.GetMetrics(hv1, "web")
+	require.NoError(t, err)
+	assert.InDelta(t, 25.0, metrics.CPU.Utilization, 0.001)
+	assert.Contains(t, manager.lastMetrics, metricsKey{host: "hv2", name: "web"})
+}
@@ -0,0 +1,200 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// defaultShutdownTimeout is the grace period used when none is configured.
+const defaultShutdownTimeout = 60 * time.Second
+
+// guestAgentShutdownTimeout is how long a guest agent shutdown may take
+// after the ACPI grace period has passed.
+const guestAgentShutdownTimeout = 15 * time.Second
+
+// Shutdown implements Manager.Shutdown.
+func (m *VMManager) Shutdown(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.PowerResult, error) {
+	result := &vm.PowerResult{
+		Name:      name,
+		Operation: vm.PowerOperationShutdown,
+		StartedAt: time.Now(),
+	}
+
+	if err := m.shutdown(ctx, name, opts, result); err != nil {
+		return nil, fmt.Errorf("stopping VM: %w", err)
+	}
+	result.Finish(vm.VMStatusStopped)
+
+	m.logger.Info("VM stopped",
+		logger.String("name", name),
+		logger.String("method", string(result.Method)),
+		logger.Int64("durationMs", result.DurationMs))
+	return result, nil
+}
+
+// Restart implements Manager.Restart.
+func (m *VMManager) Restart(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.PowerResult, error) {
+	result := &vm.PowerResult{
+		Name:      name,
+		Operation: vm.PowerOperationRestart,
+		StartedAt: time.Now(),
+	}
+
+	// Wait for the guest to be fully down, starting a domain that is still
+	// shutting down is a no-op
+	if err := m.shutdown(ctx, name, opts, result); err != nil {
+		return nil, fmt.Errorf("stopping VM for restart: %w", err)
+	}
+
+	if err := m.domainManager.Start(ctx, name); err != nil {
+		return nil, fmt.Errorf("starting VM for restart: %w", err)
+	}
+	result.Finish(vm.VMStatusRunning)
+
+	m.logger.Info("VM restarted",
+		logger.String("name", name),
+		logger.String("method", string(result.Method)),
+		logger.Int64("durationMs", result.DurationMs))
+	return result, nil
+}
+
+// StartShutdown implements Manager.StartShutdown.
+func (m *VMManager) StartShutdown(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.Job, error) {
+	return m.startPowerJob(ctx, vm.JobTypeShutdown, name, opts, m.Shutdown)
+}
+
+// StartRestart implements Manager.StartRestart.
+func (m *VMManager) StartRestart(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.Job, error) {
+	return m.startPowerJob(ctx, vm.JobTypeRestart, name, opts, m.Restart)
+}
+
+// startPowerJob runs a power operation on an existing VM in the background,
+// since waiting for the guest may take longer than a request is allowed to.
+func (m *VMManager) startPowerJob(
+	ctx context.Context,
+	jobType vm.JobType,
+	name string,
+	opts vm.ShutdownOptions,
+	run func(context.Context, string, vm.ShutdownOptions) (*vm.PowerResult, error),
+) (*vm.Job, error) {
+	if _, err := m.domainManager.Get(ctx, name); err != nil {
+		return nil, fmt.Errorf("getting VM info: %w", err)
+	}
+
+	job, err := m.jobs.create(ctx, &vm.Job{Type: jobType, Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		result, err := run(context.WithoutCancel(ctx), name, opts)
+		m.jobs.finish(job.ID, err, func(job *vm.Job) { job.Power = result })
+
+		if err != nil {
+			m.logger.Error("VM power operation failed",
+				logger.String("job_id", job.ID),
+				logger.String("name", name),
+				logger.String("operation", string(jobType)),
+				logger.Error(err))
+		}
+	}()
+
+	return job, nil
+}
+
+// Reset implements Manager.Reset.
+func (m *VMManager) Reset(ctx context.Context, name string) error {
+	if err := m.domainManager.Reset(ctx, name); err != nil {
+		return fmt.Errorf("resetting VM: %w", err)
+	}
+
+	m.logger.Info("VM reset", logger.String("name", name))
+	return nil
+}
+
+// shutdown brings a VM down, escalating from an ACPI request to a guest agent
+// shutdown to powering it off, and records the method that succeeded.
+func (m *VMManager) shutdown(ctx context.Context, name string, opts vm.ShutdownOptions, result *vm.PowerResult) error {
+	current, err := m.domainManager.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("getting VM info: %w", err)
+	}
+
+	if current.Status == vm.VMStatusStopped {
+		result.Method = vm.ShutdownMethodNone
+		return nil
+	}
+
+	if !opts.Force {
+		gracePeriod := opts.GracePeriod
+		if gracePeriod <= 0 {
+			gracePeriod = m.shutdownTimeout()
+		}
+
+		stopped, err := m.requestShutdown(ctx, name, vm.ShutdownMethodACPI, m.domainManager.Stop, gracePeriod)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			result.Method = vm.ShutdownMethodACPI
+			return nil
+		}
+
+		// Guests without ACPI support often still run an agent
+		stopped, err = m.requestShutdown(ctx, name, vm.ShutdownMethodGuestAgent, m.domainManager.ShutdownGuest, guestAgentShutdownTimeout)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			result.Method = vm.ShutdownMethodGuestAgent
+			return nil
+		}
+
+		m.logger.Warn("VM did not shut down gracefully, powering it off",
+			logger.String("name", name),
+			logger.Duration("gracePeriod", gracePeriod))
+	}
+
+	if err := m.domainManager.ForceStop(ctx, name); err != nil {
+		return fmt.Errorf("powering off VM: %w", err)
+	}
+	result.Method = vm.ShutdownMethodDestroy
+	return nil
+}
+
+// requestShutdown sends a shutdown request and waits for the VM to shut off.
+// A rejected request is not an error, the next method is tried instead.
+func (m *VMManager) requestShutdown(
+	ctx context.Context,
+	name string,
+	method vm.ShutdownMethod,
+	request func(context.Context, string) error,
+	timeout time.Duration,
+) (bool, error) {
+	if err := request(ctx, name); err != nil {
+		m.logger.Debug("Shutdown request failed",
+			logger.String("name", name),
+			logger.String("method", string(method)),
+			logger.Error(err))
+		return false, nil
+	}
+
+	stopped, err := m.domainManager.WaitForShutoff(ctx, name, timeout)
+	if err != nil {
+		return false, fmt.Errorf("waiting for VM to shut down: %w", err)
+	}
+
+	return stopped, nil
+}
+
+// shutdownTimeout returns the configured grace period for shutdowns.
+func (m *VMManager) shutdownTimeout() time.Duration {
+	if m.config.ShutdownTimeout > 0 {
+		return m.config.ShutdownTimeout
+	}
+	return defaultShutdownTimeout
+}
@@ -0,0 +1,222 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/models/vm"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVMManager_Shutdown(t *testing.T) {
+	runningVM := &vm.VM{Name: "test-vm", Status: vm.VMStatusRunning}
+
+	tests := []struct {
+		name       string
+		opts       vm.ShutdownOptions
+		setup      func(m *mocks_domain.MockManager)
+		wantMethod vm.ShutdownMethod
+		wantErr    bool
+	}{
+		{
+			name: "Already stopped",
+			setup: func(m *mocks_domain.MockManager) {
+				m.EXPECT().Get(gomock.Any(), "test-vm").Return(&vm.VM{Name: "test-vm", Status: vm.VMStatusStopped}, nil)
+			},
+			wantMethod: vm.ShutdownMethodNone,
+		},
+		{
+			name: "Guest agent after ACPI grace period",
+			opts: vm.ShutdownOptions{GracePeriod: 5 * time.Second},
+			setup: func(m *mocks_domain.MockManager) {
+				m.EXPECT().Get(gomock.Any(), "test-vm").Return(runningVM, nil)
+				gomock.InOrder(
+					m.EXPECT().Stop(gomock.Any(), "test-vm").Return(nil),
+					m.EXPECT().WaitForShutoff(gomock.Any(), "test-vm", 5*time.Second).Return(false, nil),
+					m.EXPECT().ShutdownGuest(gomock.Any(), "test-vm").Return(nil),
+					m.EXPECT().WaitForShutoff(gomock.Any(), "test-vm", guestAgentShutdownTimeout).Return(true, nil),
+				)
+			},
+			wantMethod: vm.ShutdownMethodGuestAgent,
+		},
+		{
+			name: "Destroy when graceful methods fail",
+			setup: func(m *mocks_domain.MockManager) {
+				m.EXPECT().Get(gomock.Any(), "test-vm").Return(runningVM, nil)
+				gomock.InOrder(
+					m.EXPECT().Stop(gomock.Any(), "test-vm").Return(nil),
+					m.EXPECT().WaitForShutoff(gomock.Any(), "test-vm", defaultShutdownTimeout).Return(false, nil),
+					m.EXPECT().ShutdownGuest(gomock.Any(), "test-vm").Return(domain.ErrGuestAgentUnavailable),
+					m.EXPECT().ForceStop(gomock.Any(), "test-vm").Return(nil),
+				)
+			},
+			wantMethod: vm.ShutdownMethodDestroy,
+		},
+		{
+			name: "Force",
+			opts: vm.ShutdownOptions{Force: true},
+			setup: func(m *mocks_domain.MockManager) {
+				m.EXPECT().Get(gomock.Any(), "test-vm").Return(runningVM, nil)
+				m.EXPECT().ForceStop(gomock.Any(), "test-vm").Return(nil)
+			},
+			wantMethod: vm.ShutdownMethodDestroy,
+		},
+		{
+			name: "Cancelled while waiting",
+			setup: func(m *mocks_domain.MockManager) {
+				m.EXPECT().Get(gomock.Any(), "test-vm").Return(runningVM, nil)
+				m.EXPECT().Stop(gomock.Any(), "test-vm").Return(nil)
+				m.EXPECT().WaitForShutoff(gomock.Any(), "test-vm", defaultShutdownTimeout).
+					Return(false, context.Canceled)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mocks
+			mockDomainManager := mocks_domain.NewMockManager(ctrl)
+			mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+			// Setup expected logging calls
+			mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+			// Create VM manager
+			manager := NewVMManager(
+				mockDomainManager,
+				nil, // Not used in this test
+				nil, // Not used in this test
+				nil, // Not used in this test
+				nil, // Not used in this test
+				nil, // Not used in this test
+				nil, // Not used in this test
+				Config{},
+				mockLogger,
+			)
+
+			tt.setup(mockDomainManager)
+
+			result, err := manager.Shutdown(context.Background(), "test-vm", tt.opts)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, context.Canceled))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMethod, result.Method)
+			assert.Equal(t, vm.VMStatusStopped, result.State)
+			assert.Equal(t, vm.PowerOperationShutdown, result.Operation)
+			assert.False(t, result.FinishedAt.Before(result.StartedAt))
+		})
+	}
+}
+
+func TestVMManager_StartRestart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Setup expected logging calls
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	// The guest shuts down once the gate is closed
+	gate := make(chan struct{})
+	runningVM := &vm.VM{Name: "test-vm", Status: vm.VMStatusRunning}
+	mockDomainManager.EXPECT().Get(gomock.Any(), "test-vm").Return(runningVM, nil).Times(3)
+	gomock.InOrder(
+		mockDomainManager.EXPECT().Stop(gomock.Any(), "test-vm").Return(nil),
+		mockDomainManager.EXPECT().WaitForShutoff(gomock.Any(), "test-vm", defaultShutdownTimeout).
+			DoAndReturn(func(context.Context, string, time.Duration) (bool, error) {
+				<-gate
+				return true, nil
+			}),
+		mockDomainManager.EXPECT().Start(gomock.Any(), "test-vm").Return(nil),
+	)
+
+	// The request context ends before the restart does
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := manager.StartRestart(ctx, "test-vm", vm.ShutdownOptions{})
+	cancel()
+	require.NoError(t, err)
+	assert.Equal(t, vm.JobTypeRestart, job.Type)
+	assert.Equal(t, vm.JobStatusRunning, job.Status)
+
+	// A second power operation on the VM is refused while the first runs
+	_, err = manager.StartShutdown(context.Background(), "test-vm", vm.ShutdownOptions{Force: true})
+	assert.ErrorIs(t, err, ErrVMInvalidState)
+
+	close(gate)
+	require.Eventually(t, func() bool {
+		job, err := manager.GetJob(context.Background(), job.ID)
+		return err == nil && job.Status.IsFinal()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	finished, err := manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, vm.JobStatusCompleted, finished.Status)
+	require.NotNil(t, finished.Power)
+	assert.Equal(t, vm.ShutdownMethodACPI, finished.Power.Method)
+	assert.Equal(t, vm.VMStatusRunning, finished.Power.State)
+}
+
+func TestVMManager_StartShutdown_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+
+	// Create VM manager
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{},
+		mockLogger,
+	)
+
+	mockDomainManager.EXPECT().Get(gomock.Any(), "missing").Return(nil, domain.ErrDomainNotFound)
+
+	_, err := manager.StartShutdown(context.Background(), "missing", vm.ShutdownOptions{})
+	assert.ErrorIs(t, err, domain.ErrDomainNotFound)
+
+	jobs, err := manager.ListJobs(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
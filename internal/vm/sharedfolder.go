@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// validateSharedFolders validates the shared folders of a new VM. Sources
+// must be within one of the configured shared folder paths. Symlinks are
+// resolved first so they cannot point outside of them, and the sources are
+// replaced with the resolved paths. The sources are looked up on this
+// machine, so VMs on remote hosts cannot have shared folders.
+func (m *VMManager) validateSharedFolders(ctx context.Context, folders []vm.SharedFolderParams) error {
+	if len(folders) == 0 {
+		return nil
+	}
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return fmt.Errorf("sharing folders: %w", err)
+	}
+
+	tags := make(map[string]bool, len(folders))
+	for i := range folders {
+		folder := &folders[i]
+		if err := folder.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", errors.ErrInvalidParameter, err)
+		}
+
+		if tags[folder.Tag] {
+			return fmt.Errorf("%w: duplicate shared folder tag: %s", errors.ErrInvalidParameter, folder.Tag)
+		}
+		tags[folder.Tag] = true
+
+		source, err := filepath.EvalSymlinks(folder.Source)
+		if err != nil {
+			return fmt.Errorf("%w: shared folder source %s: %v", errors.ErrInvalidParameter, folder.Source, err)
+		}
+		if !m.sharedFolderAllowed(source) {
+			return fmt.Errorf("%w: shared folder source %s is not within an allowed path", errors.ErrForbidden, folder.Source)
+		}
+		folder.Source = source
+	}
+
+	return nil
+}
+
+// sharedFolderAllowed reports whether a resolved path is within one of the
+// configured shared folder paths.
+func (m *VMManager) sharedFolderAllowed(path string) bool {
+	for _, allowed := range m.config.SharedFolderPaths {
+		root, err := filepath.EvalSymlinks(allowed)
+		if err != nil {
+			continue
+		}
+		if path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSharedFolderDrivers picks the driver of shared folders that do not
+// name one: virtio-fs where the hypervisor supports it and 9p otherwise.
+// Read-only folders always use 9p, as virtio-fs cannot export them
+// read-only.
+func (m *VMManager) resolveSharedFolderDrivers(ctx context.Context, folders []vm.SharedFolderParams) error {
+	var virtioFS *bool
+	for i := range folders {
+		folder := &folders[i]
+		if folder.Driver != "" {
+			continue
+		}
+		if folder.ReadOnly {
+			folder.Driver = vm.SharedFolderDriver9p
+			continue
+		}
+
+		if virtioFS == nil {
+			supported, err := m.domainManager.VirtioFSSupported(ctx)
+			if err != nil {
+				return fmt.Errorf("checking virtio-fs support: %w", err)
+			}
+			if !supported {
+				m.logger.Info("virtio-fs not supported, sharing folders over 9p",
+					logger.Int("shared_folders", len(folders)))
+			}
+			virtioFS = &supported
+		}
+
+		folder.Driver = vm.SharedFolderDriver9p
+		if *virtioFS {
+			folder.Driver = vm.SharedFolderDriverVirtioFS
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/models/vm"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+	"github.com/threatflux/libgo/test/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func newSharedFolderTestManager(t *testing.T, allowed ...string) (*VMManager, *mocks_domain.MockManager) {
+	ctrl := gomock.NewController(t)
+
+	mockDomainManager := mocks_domain.NewMockManager(ctrl)
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	manager := NewVMManager(
+		mockDomainManager,
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		nil, // Not used in this test
+		Config{SharedFolderPaths: allowed},
+		mockLogger,
+	)
+	return manager, mockDomainManager
+}
+
+func TestVMManager_ValidateSharedFolders(t *testing.T) {
+	base := t.TempDir()
+	allowed := filepath.Join(base, "allowed")
+	outside := filepath.Join(base, "outside")
+	sibling := filepath.Join(base, "allowed-other")
+	cache := filepath.Join(allowed, "cache")
+	for _, dir := range []string{allowed, outside, sibling, cache} {
+		require.NoError(t, os.Mkdir(dir, 0o755))
+	}
+	require.NoError(t, os.Symlink(outside, filepath.Join(allowed, "escape")))
+	require.NoError(t, os.Symlink(cache, filepath.Join(outside, "link")))
+
+	manager, _ := newSharedFolderTestManager(t, allowed)
+
+	// Symlinks into an allowed path are resolved
+	folders := []vm.SharedFolderParams{
+		{Source: cache, Tag: "cache"},
+		{Source: filepath.Join(outside, "link"), Tag: "linked", MountPoint: "/mnt/cache"},
+	}
+	require.NoError(t, manager.validateSharedFolders(context.Background(), folders))
+	resolved, err := filepath.EvalSymlinks(cache)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, folders[1].Source)
+
+	tests := []struct {
+		name    string
+		folders []vm.SharedFolderParams
+		wantErr error
+	}{
+		{
+			name:    "Outside allowed paths",
+			folders: []vm.SharedFolderParams{{Source: outside, Tag: "data"}},
+			wantErr: errors.ErrForbidden,
+		},
+		{
+			name:    "Symlink out of allowed path",
+			folders: []vm.SharedFolderParams{{Source: filepath.Join(allowed, "escape"), Tag: "data"}},
+			wantErr: errors.ErrForbidden,
+		},
+		{
+			name:    "Sibling with an allowed prefix",
+			folders: []vm.SharedFolderParams{{Source: sibling, Tag: "data"}},
+			wantErr: errors.ErrForbidden,
+		},
+		{
+			name:    "Missing source",
+			folders: []vm.SharedFolderParams{{Source: filepath.Join(allowed, "missing"), Tag: "data"}},
+			wantErr: errors.ErrInvalidParameter,
+		},
+		{
+			name:    "Relative source",
+			folders: []vm.SharedFolderParams{{Source: "cache", Tag: "data"}},
+			wantErr: errors.ErrInvalidParameter,
+		},
+		{
+			name:    "Invalid tag",
+			folders: []vm.SharedFolderParams{{Source: cache, Tag: "my cache"}},
+			wantErr: errors.ErrInvalidParameter,
+		},
+		{
+			name: "Duplicate tag",
+			folders: []vm.SharedFolderParams{
+				{Source: cache, Tag: "cache"},
+				{Source: allowed, Tag: "cache"},
+			},
+			wantErr: errors.ErrInvalidParameter,
+		},
+		{
+			name:    "Read-only virtio-fs",
+			folders: []vm.SharedFolderParams{{Source: cache, Tag: "cache", Driver: vm.SharedFolderDriverVirtioFS, ReadOnly: true}},
+			wantErr: errors.ErrInvalidParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manager.validateSharedFolders(context.Background(), tt.folders)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	// Nothing is shared unless paths are allowed
+	manager, _ = newSharedFolderTestManager(t)
+	err = manager.validateSharedFolders(context.Background(), []vm.SharedFolderParams{{Source: cache, Tag: "cache"}})
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+
+	// Sources are looked up on this machine, not on remote hosts
+	manager, _ = newSharedFolderTestManager(t, allowed)
+	manager.hosts = testutil.RemoteHost{}
+	err = manager.validateSharedFolders(context.Background(), []vm.SharedFolderParams{{Source: cache, Tag: "cache"}})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+	require.NoError(t, manager.validateSharedFolders(context.Background(), nil))
+}
+
+func TestVMManager_ResolveSharedFolderDrivers(t *testing.T) {
+	tests := []struct {
+		name      string
+		virtioFS  bool
+		wantFirst vm.SharedFolderDriver
+	}{
+		{name: "virtio-fs supported", virtioFS: true, wantFirst: vm.SharedFolderDriverVirtioFS},
+		{name: "Fall back to 9p", virtioFS: false, wantFirst: vm.SharedFolderDriver9p},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, mockDomainManager := newSharedFolderTestManager(t)
+			mockDomainManager.EXPECT().VirtioFSSupported(gomock.Any()).Return(tt.virtioFS, nil).Times(1)
+
+			folders := []vm.SharedFolderParams{
+				{Source: "/srv/cache", Tag: "cache"},
+				{Source: "/srv/tools", Tag: "tools"},
+				{Source: "/srv/docs", Tag: "docs", ReadOnly: true},
+				{Source: "/srv/logs", Tag: "logs", Driver: vm.SharedFolderDriver9p},
+			}
+			require.NoError(t, manager.resolveSharedFolderDrivers(context.Background(), folders))
+
+			assert.Equal(t, tt.wantFirst, folders[0].Driver)
+			assert.Equal(t, tt.wantFirst, folders[1].Driver)
+			assert.Equal(t, vm.SharedFolderDriver9p, folders[2].Driver)
+			assert.Equal(t, vm.SharedFolderDriver9p, folders[3].Driver)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package vmimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// supportedImageFormats lists the disk image formats imports convert.
+var supportedImageFormats = map[string]bool{
+	"qcow2": true,
+	"raw":   true,
+	"vmdk":  true,
+	"vdi":   true,
+	"vhdx":  true,
+	"vpc":   true,
+}
+
+// imageInfo is the part of the output of qemu-img info imports read.
+type imageInfo struct {
+	Format          string `json:"format"`
+	BackingFilename string `json:"backing-filename"`
+	FormatSpecific  struct {
+		Data struct {
+			DataFile string `json:"data-file"`
+			Extents  []struct {
+				Filename string `json:"filename"`
+			} `json:"extents"`
+		} `json:"data"`
+	} `json:"format-specific"`
+	VirtualSize uint64 `json:"virtual-size"`
+}
+
+// probeImage reads the format and virtual size of a disk image. Images that
+// refer to other files, such as qcow2 images with a backing file or VMDK
+// descriptors with extents in other directories, are rejected: converting
+// them would read files on the server the source does not contain.
+func probeImage(ctx context.Context, path string) (*imageInfo, error) {
+	output, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"info", "--output=json", path,
+	}, exec.CommandOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("reading disk image %s: %w", filepath.Base(path), err)
+	}
+
+	var info imageInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("parsing qemu-img info output: %w", err)
+	}
+
+	if !supportedImageFormats[info.Format] {
+		return nil, fmt.Errorf("%w: unsupported disk image format %q", errors.ErrInvalidParameter, info.Format)
+	}
+
+	if info.BackingFilename != "" || info.FormatSpecific.Data.DataFile != "" {
+		return nil, fmt.Errorf("%w: disk image %s refers to other files", errors.ErrInvalidParameter, filepath.Base(path))
+	}
+
+	// Split VMDK images keep their extents next to the descriptor
+	for _, extent := range info.FormatSpecific.Data.Extents {
+		extentPath := extent.Filename
+		if !filepath.IsAbs(extentPath) {
+			extentPath = filepath.Join(filepath.Dir(path), extentPath)
+		}
+		if filepath.Dir(filepath.Clean(extentPath)) != filepath.Dir(filepath.Clean(path)) {
+			return nil, fmt.Errorf("%w: disk image %s refers to files in other directories", errors.ErrInvalidParameter, filepath.Base(path))
+		}
+	}
+
+	return &info, nil
+}
+
+// convertImage writes a disk image to an existing qcow2 volume.
+func convertImage(ctx context.Context, source string, format string, target string) error {
+	if _, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"convert", "-n", "-f", format, "-O", "qcow2", source, target,
+	}, exec.CommandOptions{}); err != nil {
+		return fmt.Errorf("converting disk image %s: %w", filepath.Base(source), err)
+	}
+	return nil
+}
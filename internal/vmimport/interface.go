@@ -0,0 +1,132 @@
+package vmimport
+
+import (
+	"context"
+	"io"
+	"time"
+
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+)
+
+// Format is the format of an import source.
+type Format string
+
+const (
+	// FormatOVA is a tar archive holding an OVF descriptor and its disks
+	FormatOVA Format = "ova"
+	// FormatOVF is an OVF descriptor with its disks in the same directory
+	FormatOVF Format = "ovf"
+	// FormatDisk is a single disk image in a format qemu-img reads, such as
+	// vmdk, vdi, qcow2 or raw
+	FormatDisk Format = "disk"
+)
+
+// Status represents the status of an import job.
+type Status string
+
+const (
+	// StatusPending indicates the import has not started yet
+	StatusPending Status = "pending"
+	// StatusRunning indicates the import is in progress
+	StatusRunning Status = "running"
+	// StatusCompleted indicates the import completed successfully
+	StatusCompleted Status = "completed"
+	// StatusFailed indicates the import failed
+	StatusFailed Status = "failed"
+	// StatusCanceled indicates the import was canceled
+	StatusCanceled Status = "canceled"
+)
+
+// Params holds the parameters of an import. Values that are not set are
+// taken from the OVF descriptor or mapped from the source hardware.
+type Params struct {
+	// Networks maps OVF network names to libvirt networks
+	Networks map[string]string `json:"networks,omitempty"`
+	// Name of the new VM; defaults to the name in the OVF descriptor or
+	// the file name of the source
+	Name string `json:"name,omitempty"`
+	// Pool receives the converted disks as qcow2 volumes
+	Pool string `json:"pool,omitempty"`
+	// Network receives the interfaces whose OVF network is not mapped
+	Network string `json:"network,omitempty"`
+	// DiskBus overrides the bus of all disks: virtio, sata or scsi
+	DiskBus string `json:"diskBus,omitempty"`
+	// NICModel overrides the model of all interfaces, e.g. virtio or e1000
+	NICModel string `json:"nicModel,omitempty"`
+	// Firmware overrides the firmware: bios or efi
+	Firmware    string `json:"firmware,omitempty"`
+	MemoryBytes uint64 `json:"memoryBytes,omitempty"`
+	CPUs        int    `json:"cpus,omitempty"`
+	// Start starts the VM once it is defined
+	Start bool `json:"start,omitempty"`
+	// RemoveSource deletes the source once the import finished, e.g. an
+	// uploaded file
+	RemoveSource bool `json:"-"`
+}
+
+// Appliance is the virtual hardware of an import source.
+type Appliance struct {
+	Disks       []ApplianceDisk `json:"disks"`
+	NICs        []ApplianceNIC  `json:"nics"`
+	Name        string          `json:"name"`
+	Firmware    string          `json:"firmware,omitempty"`
+	MemoryBytes uint64          `json:"memoryBytes"`
+	CPUs        int             `json:"cpus"`
+}
+
+// ApplianceDisk is a disk of an import source.
+type ApplianceDisk struct {
+	// File is the name of the disk image, which sits next to the OVF
+	// descriptor; empty for blank disks
+	File string `json:"file,omitempty"`
+	// Bus is the libvirt disk bus the disk is attached to
+	Bus string `json:"bus"`
+	// Capacity is the virtual size of the disk in bytes
+	Capacity uint64 `json:"capacity"`
+}
+
+// ApplianceNIC is a network interface of an import source.
+type ApplianceNIC struct {
+	// Network is the name of the network in the OVF descriptor
+	Network string `json:"network,omitempty"`
+	// Model is the libvirt interface model
+	Model string `json:"model"`
+}
+
+// Job represents an import job.
+type Job struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+	ID        string    `json:"id"`
+	VMName    string    `json:"vmName"`
+	Host      string    `json:"host,omitempty"`
+	// Source is the file name of the import source
+	Source   string `json:"source"`
+	Format   Format `json:"format"`
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Progress int    `json:"progress"`
+}
+
+// Manager defines the interface for importing VMs.
+type Manager interface {
+	// Import converts the disks of a source into volumes and defines a VM
+	// using them, returning once the VM is defined
+	Import(ctx context.Context, source string, params Params) (*vmmodels.VM, error)
+
+	// StartImport starts importing a source on the server in the background
+	StartImport(ctx context.Context, source string, params Params) (*Job, error)
+
+	// StartUploadImport stores an uploaded source and starts importing it
+	// in the background
+	StartUploadImport(ctx context.Context, filename string, r io.Reader, params Params) (*Job, error)
+
+	// GetJob gets an import job by ID
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+
+	// ListJobs lists all import jobs
+	ListJobs(ctx context.Context) ([]*Job, error)
+
+	// CancelJob cancels a running import job
+	CancelJob(ctx context.Context, jobID string) error
+}
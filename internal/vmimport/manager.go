@@ -0,0 +1,589 @@
+package vmimport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// Share of the progress of an OVA import spent extracting the archive; the
+// rest is spent converting disks.
+const extractProgressShare = 20
+
+// invalidNameChars matches the characters VM names cannot contain.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Config holds import manager configuration.
+type Config struct {
+	// SourceDir holds the files that can be imported by path; paths
+	// outside it are rejected
+	SourceDir string
+	// ScratchDir receives uploaded sources and extracted OVA archives
+	ScratchDir string
+	// DefaultPool receives the converted disks when an import names no pool
+	DefaultPool string
+	// DefaultNetwork receives the interfaces whose network is not mapped
+	DefaultNetwork string
+}
+
+// ImportManager implements Manager.
+type ImportManager struct {
+	jobStore      *jobStore
+	domainManager domain.Manager
+	volumeManager storage.VolumeManager
+	hosts         connection.HostLocator
+	logger        logger.Logger
+	config        Config
+}
+
+// importPlan is an import source together with the VM it becomes.
+type importPlan struct {
+	appliance *Appliance
+	// source is the path of the OVA archive, OVF descriptor or disk image
+	source string
+	// diskDir holds the disk files of the appliance; for OVA archives it
+	// is the directory the archive is extracted to
+	diskDir string
+	format  Format
+	params  Params
+}
+
+// NewImportManager creates a new ImportManager. Imports convert disk images
+// with qemu-img on this machine, so VMs cannot be imported to remote hosts.
+func NewImportManager(
+	domainManager domain.Manager,
+	volumeManager storage.VolumeManager,
+	hosts connection.HostLocator,
+	config Config,
+	logger logger.Logger,
+) *ImportManager {
+	return &ImportManager{
+		jobStore:      newJobStore(),
+		domainManager: domainManager,
+		volumeManager: volumeManager,
+		hosts:         hosts,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// Import implements Manager.Import.
+func (m *ImportManager) Import(ctx context.Context, source string, params Params) (*vmmodels.VM, error) {
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("importing VM: %w", err)
+	}
+
+	source, err := m.resolveSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if params.RemoveSource {
+		defer os.Remove(source)
+	}
+
+	plan, err := m.prepare(ctx, source, filepath.Base(source), params)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.runImport(ctx, "", plan)
+}
+
+// StartImport implements Manager.StartImport.
+func (m *ImportManager) StartImport(ctx context.Context, source string, params Params) (*Job, error) {
+	source, err := m.resolveSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.startJob(ctx, source, filepath.Base(source), params)
+}
+
+// StartUploadImport implements Manager.StartUploadImport.
+func (m *ImportManager) StartUploadImport(ctx context.Context, filename string, r io.Reader, params Params) (*Job, error) {
+	// Refuse remote hosts before storing the upload
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("importing VM: %w", err)
+	}
+
+	// The extension of the file name tells the format of the source
+	filename = filepath.Base(filename)
+	if filename == "." || filename == string(filepath.Separator) {
+		filename = "upload"
+	}
+
+	uploadDir := filepath.Join(m.config.ScratchDir, "uploads")
+	if err := os.MkdirAll(uploadDir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating upload directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(uploadDir, "*-"+filename)
+	if err != nil {
+		return nil, fmt.Errorf("creating upload file: %w", err)
+	}
+	source := file.Name()
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(source)
+		return nil, fmt.Errorf("storing upload: %w", err)
+	}
+
+	params.RemoveSource = true
+	job, err := m.startJob(ctx, source, filename, params)
+	if err != nil {
+		os.Remove(source)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetJob implements Manager.GetJob.
+func (m *ImportManager) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	job, exists := m.jobStore.Get(jobID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrImportJobNotFound, jobID)
+	}
+	return job, nil
+}
+
+// ListJobs implements Manager.ListJobs.
+func (m *ImportManager) ListJobs(ctx context.Context) ([]*Job, error) {
+	return m.jobStore.List(), nil
+}
+
+// CancelJob implements Manager.CancelJob.
+func (m *ImportManager) CancelJob(ctx context.Context, jobID string) error {
+	job, exists := m.jobStore.Get(jobID)
+	if !exists {
+		return fmt.Errorf("%w: %s", errors.ErrImportJobNotFound, jobID)
+	}
+
+	if job.Status.isFinal() {
+		return fmt.Errorf("%w: cannot cancel job in %s state", errors.ErrImportInvalidState, job.Status)
+	}
+
+	m.jobStore.Cancel(jobID)
+
+	m.logger.Info("Import job canceled",
+		logger.String("job_id", jobID),
+		logger.String("vm", job.VMName))
+
+	return nil
+}
+
+// startJob inspects a source and starts importing it in the background.
+// sourceName is the name of the source reported by the job.
+func (m *ImportManager) startJob(ctx context.Context, source string, sourceName string, params Params) (*Job, error) {
+	// qemu-img reads the source and writes the volumes on this machine
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("importing VM: %w", err)
+	}
+
+	plan, err := m.prepare(ctx, source, sourceName, params)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := connection.HostFromContext(ctx)
+	vmName := plan.params.Name
+	if jobID, active := m.jobStore.activeJob(vmName, host); active {
+		return nil, fmt.Errorf("%w: VM %s is being imported by job %s", errors.ErrAlreadyExists, vmName, jobID)
+	}
+
+	job := m.jobStore.createJob(vmName, host, sourceName, plan.format)
+
+	// The import outlives the request that started it but keeps its
+	// values, such as the selected libvirt host
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	jobCtx = storage.WithVolumeOrigin(jobCtx, storage.VolumeOrigin{VM: vmName, Job: job.ID})
+	m.jobStore.SetCancel(job.ID, cancel)
+
+	go m.processImportJob(jobCtx, cancel, job.ID, plan)
+
+	return job, nil
+}
+
+// processImportJob runs an import in the background.
+func (m *ImportManager) processImportJob(ctx context.Context, cancel context.CancelFunc, jobID string, plan *importPlan) {
+	defer cancel()
+	if plan.params.RemoveSource {
+		defer os.Remove(plan.source)
+	}
+
+	m.jobStore.updateJobStatus(jobID, StatusRunning, nil)
+
+	m.logger.Info("Starting import job",
+		logger.String("job_id", jobID),
+		logger.String("vm", plan.params.Name),
+		logger.String("format", string(plan.format)))
+
+	_, err := m.runImport(ctx, jobID, plan)
+
+	switch {
+	case err == nil:
+		m.jobStore.updateJobStatus(jobID, StatusCompleted, nil)
+		m.logger.Info("Import job completed",
+			logger.String("job_id", jobID),
+			logger.String("vm", plan.params.Name))
+	case ctx.Err() == context.Canceled:
+		m.jobStore.updateJobStatus(jobID, StatusCanceled, nil)
+	default:
+		m.jobStore.updateJobStatus(jobID, StatusFailed, err)
+		m.logger.Error("Import job failed",
+			logger.String("job_id", jobID),
+			logger.String("vm", plan.params.Name),
+			logger.Error(err))
+	}
+}
+
+// resolveSource checks that a path given by a client names a file in the
+// source directory.
+func (m *ImportManager) resolveSource(source string) (string, error) {
+	if m.config.SourceDir == "" {
+		return "", fmt.Errorf("%w: importing files on the server is disabled", errors.ErrInvalidParameter)
+	}
+	if !filepath.IsAbs(source) {
+		return "", fmt.Errorf("%w: source must be an absolute path", errors.ErrInvalidParameter)
+	}
+
+	sourceDir, err := filepath.EvalSymlinks(m.config.SourceDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving source directory: %w", err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return "", fmt.Errorf("%w: source %s: %v", errors.ErrInvalidParameter, source, err)
+	}
+
+	relative, err := filepath.Rel(sourceDir, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: source must be in %s", errors.ErrInvalidParameter, m.config.SourceDir)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("%w: source %s: %v", errors.ErrInvalidParameter, source, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%w: source %s is not a file", errors.ErrInvalidParameter, source)
+	}
+
+	return resolved, nil
+}
+
+// prepare reads the virtual hardware of a source and applies the import
+// parameters to it. VMs are named after the appliance, or else after
+// sourceName.
+func (m *ImportManager) prepare(ctx context.Context, source string, sourceName string, params Params) (*importPlan, error) {
+	if err := validateParams(params); err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{
+		source:  source,
+		diskDir: filepath.Dir(source),
+		format:  sourceFormat(source),
+	}
+
+	var err error
+	switch plan.format {
+	case FormatOVA:
+		var descriptor []byte
+		if descriptor, err = readOVADescriptor(source); err == nil {
+			plan.appliance, err = parseOVF(descriptor)
+		}
+	case FormatOVF:
+		var descriptor []byte
+		if descriptor, err = os.ReadFile(source); err != nil {
+			return nil, fmt.Errorf("reading OVF descriptor: %w", err)
+		}
+		plan.appliance, err = parseOVF(descriptor)
+	default:
+		plan.appliance, err = diskAppliance(ctx, source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	applyParams(plan.appliance, params)
+
+	if params.Name == "" {
+		params.Name = vmName(plan.appliance.Name)
+		if params.Name == "" {
+			params.Name = vmName(strings.TrimSuffix(sourceName, filepath.Ext(sourceName)))
+		}
+		if params.Name == "" {
+			return nil, fmt.Errorf("%w: name is required", errors.ErrInvalidParameter)
+		}
+	}
+
+	if _, err := m.domainManager.Get(ctx, params.Name); err == nil {
+		return nil, fmt.Errorf("%w: VM %s", errors.ErrAlreadyExists, params.Name)
+	}
+
+	if params.Pool == "" {
+		params.Pool = m.config.DefaultPool
+	}
+	if params.Network == "" {
+		params.Network = m.config.DefaultNetwork
+	}
+	plan.params = params
+
+	return plan, nil
+}
+
+// runImport converts the disks of a source into volumes and defines a VM
+// using them. jobID is empty for imports that are not tracked as jobs.
+func (m *ImportManager) runImport(ctx context.Context, jobID string, plan *importPlan) (*vmmodels.VM, error) {
+	params := plan.params
+	appliance := plan.appliance
+
+	progressStart := 0
+	if plan.format == FormatOVA {
+		workDir := filepath.Join(m.config.ScratchDir, uuid.New().String())
+		if err := os.MkdirAll(workDir, 0o750); err != nil {
+			return nil, fmt.Errorf("creating scratch directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+
+		if _, err := extractOVA(ctx, plan.source, workDir); err != nil {
+			return nil, err
+		}
+		plan.diskDir = workDir
+
+		progressStart = extractProgressShare
+		m.updateProgress(jobID, progressStart)
+	}
+
+	spec := domain.ImportSpec{
+		Name:        params.Name,
+		CPUs:        appliance.CPUs,
+		MemoryBytes: appliance.MemoryBytes,
+		Firmware:    appliance.Firmware,
+		Disks:       make([]domain.ImportDisk, 0, len(appliance.Disks)),
+		Networks:    make([]domain.ImportNetwork, 0, len(appliance.NICs)),
+	}
+
+	var created []string
+	defer func() {
+		// Volumes of an import that did not define a VM are not used
+		for _, volName := range created {
+			if err := m.volumeManager.Delete(context.WithoutCancel(ctx), params.Pool, volName); err != nil {
+				m.logger.Warn("Failed to delete imported volume",
+					logger.String("volume", volName),
+					logger.Error(err))
+			}
+		}
+	}()
+
+	for i, disk := range appliance.Disks {
+		volName := vmmodels.GenerateVolumeName(params.Name, i)
+		path, err := m.importDisk(ctx, plan.diskDir, disk, params.Pool, volName)
+		if err != nil {
+			return nil, fmt.Errorf("importing disk %d: %w", i, err)
+		}
+		created = append(created, volName)
+
+		spec.Disks = append(spec.Disks, domain.ImportDisk{Path: path, Format: "qcow2", Bus: disk.Bus})
+		m.updateProgress(jobID, progressStart+(i+1)*(100-progressStart)/len(appliance.Disks))
+	}
+
+	for _, nic := range appliance.NICs {
+		network, ok := params.Networks[nic.Network]
+		if !ok {
+			network = params.Network
+		}
+		spec.Networks = append(spec.Networks, domain.ImportNetwork{Network: network, Model: nic.Model})
+	}
+
+	vm, err := m.domainManager.DefineImported(ctx, spec)
+	if err != nil {
+		return nil, fmt.Errorf("defining imported VM: %w", err)
+	}
+	created = nil
+
+	m.logger.Info("Imported VM",
+		logger.String("vm", params.Name),
+		logger.String("format", string(plan.format)),
+		logger.Int("disks", len(spec.Disks)),
+		logger.Int("networks", len(spec.Networks)))
+
+	if params.Start {
+		if err := m.domainManager.Start(ctx, params.Name); err != nil {
+			return nil, fmt.Errorf("starting imported VM: %w", err)
+		}
+	}
+
+	return vm, nil
+}
+
+// importDisk writes a disk of an appliance to a new qcow2 volume and returns
+// its path. Blank disks get an empty volume. The volume is deleted again
+// when writing fails.
+func (m *ImportManager) importDisk(ctx context.Context, diskDir string, disk ApplianceDisk, pool string, volName string) (path string, err error) {
+	var info *imageInfo
+	capacity := disk.Capacity
+	if disk.File != "" {
+		info, err = probeImage(ctx, filepath.Join(diskDir, disk.File))
+		if err != nil {
+			return "", err
+		}
+		capacity = max(capacity, info.VirtualSize)
+	}
+	if capacity == 0 {
+		return "", fmt.Errorf("%w: disk has no capacity", errors.ErrInvalidParameter)
+	}
+
+	if err := m.volumeManager.Create(ctx, pool, volName, capacity, "qcow2"); err != nil {
+		return "", fmt.Errorf("creating volume %s: %w", volName, err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if deleteErr := m.volumeManager.Delete(context.WithoutCancel(ctx), pool, volName); deleteErr != nil {
+			m.logger.Warn("Failed to delete imported volume",
+				logger.String("volume", volName),
+				logger.Error(deleteErr))
+		}
+	}()
+
+	path, err = m.volumeManager.GetPath(ctx, pool, volName)
+	if err != nil {
+		return "", fmt.Errorf("getting volume path: %w", err)
+	}
+
+	if info != nil {
+		if err = convertImage(ctx, filepath.Join(diskDir, disk.File), info.Format, path); err != nil {
+			return "", err
+		}
+	}
+
+	return path, nil
+}
+
+// updateProgress records the progress of an import tracked as a job.
+func (m *ImportManager) updateProgress(jobID string, progress int) {
+	if jobID != "" {
+		m.jobStore.updateJobProgress(jobID, progress)
+	}
+}
+
+// diskAppliance describes the VM of a single disk image. Images in the
+// formats of other hypervisors get devices their guests support without
+// extra drivers; qcow2 and raw images usually come from KVM guests and get
+// virtio devices.
+func diskAppliance(ctx context.Context, source string) (*Appliance, error) {
+	info, err := probeImage(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := string(vmmodels.DiskBusSATA)
+	model := defaultNICModel
+	if info.Format == "qcow2" || info.Format == "raw" {
+		bus = string(vmmodels.DiskBusVirtio)
+		model = "virtio"
+	}
+
+	return &Appliance{
+		CPUs:        defaultCPUs,
+		MemoryBytes: defaultMemoryBytes,
+		Disks:       []ApplianceDisk{{File: filepath.Base(source), Bus: bus, Capacity: info.VirtualSize}},
+		NICs:        []ApplianceNIC{{Model: model}},
+	}, nil
+}
+
+// applyParams overrides the hardware of an appliance with the values set in
+// the import parameters.
+func applyParams(appliance *Appliance, params Params) {
+	if params.CPUs > 0 {
+		appliance.CPUs = params.CPUs
+	}
+	if params.MemoryBytes > 0 {
+		appliance.MemoryBytes = params.MemoryBytes
+	}
+	switch params.Firmware {
+	case "efi":
+		appliance.Firmware = "efi"
+	case "bios":
+		appliance.Firmware = ""
+	}
+	for i := range appliance.Disks {
+		if params.DiskBus != "" {
+			appliance.Disks[i].Bus = params.DiskBus
+		}
+	}
+	for i := range appliance.NICs {
+		if params.NICModel != "" {
+			appliance.NICs[i].Model = params.NICModel
+		}
+	}
+}
+
+// validateParams checks the import parameters.
+func validateParams(params Params) error {
+	switch vmmodels.DiskBus(params.DiskBus) {
+	case "", vmmodels.DiskBusVirtio, vmmodels.DiskBusSATA, vmmodels.DiskBusSCSI:
+	default:
+		return fmt.Errorf("%w: diskBus must be virtio, sata or scsi", errors.ErrInvalidParameter)
+	}
+
+	switch params.Firmware {
+	case "", "bios", "efi":
+	default:
+		return fmt.Errorf("%w: firmware must be bios or efi", errors.ErrInvalidParameter)
+	}
+
+	if params.CPUs < 0 || params.CPUs > 128 {
+		return fmt.Errorf("%w: cpus must be between 1 and 128", errors.ErrInvalidParameter)
+	}
+
+	if params.Name != "" && vmName(params.Name) != params.Name {
+		return fmt.Errorf("%w: invalid VM name %q", errors.ErrInvalidParameter, params.Name)
+	}
+
+	return nil
+}
+
+// sourceFormat tells the format of a source from its file extension. Files
+// that are neither OVA archives nor OVF descriptors are read as disk images.
+func sourceFormat(source string) Format {
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".ova":
+		return FormatOVA
+	case ".ovf":
+		return FormatOVF
+	default:
+		return FormatDisk
+	}
+}
+
+// vmName turns the name of an appliance into a VM name, e.g. "Vendor
+// Appliance 1.2" into "vendor-appliance-1-2".
+func vmName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
@@ -0,0 +1,282 @@
+package vmimport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+// importTestEnv holds the mocks used by the import manager tests.
+type importTestEnv struct {
+	manager   *ImportManager
+	domain    *mocks_domain.MockManager
+	volumes   *mocks_storage.MockVolumeManager
+	sourceDir string
+	poolDir   string
+	// images maps disk image paths to the info qemu-img reports for them
+	images map[string]string
+}
+
+func newImportTestEnv(t *testing.T) *importTestEnv {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := testutil.NewLogger(ctrl)
+
+	env := &importTestEnv{
+		domain:    mocks_domain.NewMockManager(ctrl),
+		volumes:   mocks_storage.NewMockVolumeManager(ctrl),
+		sourceDir: t.TempDir(),
+		poolDir:   t.TempDir(),
+		images:    make(map[string]string),
+	}
+	env.manager = NewImportManager(env.domain, env.volumes, nil, Config{
+		SourceDir:      env.sourceDir,
+		ScratchDir:     t.TempDir(),
+		DefaultPool:    "default",
+		DefaultNetwork: "default",
+	}, mockLogger)
+
+	env.domain.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, domain.ErrDomainNotFound).AnyTimes()
+	env.volumes.EXPECT().GetPath(gomock.Any(), "default", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, volName string) (string, error) {
+			return filepath.Join(env.poolDir, volName), nil
+		}).AnyTimes()
+
+	testutil.StubCommands(t, env.executeCommand)
+
+	return env
+}
+
+// executeCommand stands in for qemu-img.
+func (env *importTestEnv) executeCommand(_ context.Context, name string, args []string, _ exec.CommandOptions) ([]byte, error) {
+	if name != "qemu-img" {
+		return nil, fmt.Errorf("unexpected command %s", name)
+	}
+
+	switch args[0] {
+	case "info":
+		info, ok := env.images[filepath.Base(args[len(args)-1])]
+		if !ok {
+			return nil, fmt.Errorf("could not open %s", args[len(args)-1])
+		}
+		return []byte(info), nil
+	case "convert":
+		data, err := os.ReadFile(args[len(args)-2])
+		if err != nil {
+			return nil, err
+		}
+		return nil, os.WriteFile(args[len(args)-1], data, 0o600)
+	}
+
+	return nil, fmt.Errorf("unexpected qemu-img command %s", args[0])
+}
+
+// waitForJob waits until an import job finished.
+func waitForJob(t *testing.T, manager *ImportManager, jobID string) *Job {
+	t.Helper()
+
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = manager.GetJob(context.Background(), jobID)
+		require.NoError(t, err)
+		return job.Status.isFinal()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return job
+}
+
+func TestImportManager_StartImportOVA(t *testing.T) {
+	env := newImportTestEnv(t)
+	env.images["appliance-disk1.vmdk"] = `{"format": "vmdk", "virtual-size": 17179869184, "format-specific": {"type": "vmdk", "data": {"extents": [{"filename": "appliance-disk1.vmdk"}]}}}`
+
+	ovaPath := filepath.Join(env.sourceDir, "appliance.ova")
+	writeOVA(t, ovaPath,
+		ovaEntry{name: "appliance.ovf", data: testOVF},
+		ovaEntry{name: "appliance-disk1.vmdk", data: "disk data"},
+	)
+
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "vendor-appliance-4-2-disk-0", uint64(16<<30), "qcow2").Return(nil)
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "vendor-appliance-4-2-disk-1", uint64(2<<30), "qcow2").Return(nil)
+	env.domain.EXPECT().DefineImported(gomock.Any(), domain.ImportSpec{
+		Name:        "vendor-appliance-4-2",
+		CPUs:        4,
+		MemoryBytes: 8 << 30,
+		Firmware:    "efi",
+		Disks: []domain.ImportDisk{
+			{Path: filepath.Join(env.poolDir, "vendor-appliance-4-2-disk-0"), Format: "qcow2", Bus: "sata"},
+			{Path: filepath.Join(env.poolDir, "vendor-appliance-4-2-disk-1"), Format: "qcow2", Bus: "sata"},
+		},
+		Networks: []domain.ImportNetwork{
+			{Network: "lan", Model: "vmxnet3"},
+			{Network: "default", Model: "pcnet"},
+		},
+	}).Return(&vm.VM{Name: "vendor-appliance-4-2"}, nil)
+
+	job, err := env.manager.StartImport(context.Background(), ovaPath, Params{
+		Networks: map[string]string{"VM Network": "lan"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "vendor-appliance-4-2", job.VMName)
+	assert.Equal(t, FormatOVA, job.Format)
+	assert.Equal(t, "appliance.ova", job.Source)
+
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+	assert.Equal(t, 100, job.Progress)
+
+	// The converted disk holds the data of the appliance disk
+	data, err := os.ReadFile(filepath.Join(env.poolDir, "vendor-appliance-4-2-disk-0"))
+	require.NoError(t, err)
+	assert.Equal(t, "disk data", string(data))
+
+	// The source stays in place
+	assert.FileExists(t, ovaPath)
+}
+
+func TestImportManager_ImportDiskImage(t *testing.T) {
+	env := newImportTestEnv(t)
+	env.images["router.qcow2"] = `{"format": "qcow2", "virtual-size": 2147483648}`
+
+	source := filepath.Join(env.sourceDir, "router.qcow2")
+	require.NoError(t, os.WriteFile(source, []byte("qcow2 data"), 0o600))
+
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "edge-disk-0", uint64(2<<30), "qcow2").Return(nil)
+	env.domain.EXPECT().DefineImported(gomock.Any(), domain.ImportSpec{
+		Name:        "edge",
+		CPUs:        2,
+		MemoryBytes: defaultMemoryBytes,
+		Disks: []domain.ImportDisk{
+			{Path: filepath.Join(env.poolDir, "edge-disk-0"), Format: "qcow2", Bus: "virtio"},
+		},
+		Networks: []domain.ImportNetwork{{Network: "default", Model: "virtio"}},
+	}).Return(&vm.VM{Name: "edge"}, nil)
+	env.domain.EXPECT().Start(gomock.Any(), "edge").Return(nil)
+
+	imported, err := env.manager.Import(context.Background(), source, Params{Name: "edge", CPUs: 2, Start: true})
+	require.NoError(t, err)
+	assert.Equal(t, "edge", imported.Name)
+}
+
+func TestImportManager_StartUploadImportFailure(t *testing.T) {
+	env := newImportTestEnv(t)
+	env.images["vendor-disk.vmdk"] = `{"format": "vmdk", "virtual-size": 1073741824}`
+
+	// Volumes of a failed import are deleted again
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "vendor-disk-disk-0", uint64(1<<30), "qcow2").Return(nil)
+	env.volumes.EXPECT().Delete(gomock.Any(), "default", "vendor-disk-disk-0").Return(nil)
+	env.domain.EXPECT().DefineImported(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("unsupported configuration"))
+
+	// Uploads are stored under a generated name
+	testutil.StubCommands(t, func(ctx context.Context, name string, args []string, opts exec.CommandOptions) ([]byte, error) {
+		if args[0] == "info" {
+			return []byte(env.images["vendor-disk.vmdk"]), nil
+		}
+		return env.executeCommand(ctx, name, args, opts)
+	})
+
+	job, err := env.manager.StartUploadImport(context.Background(), "vendor-disk.vmdk", strings.NewReader("vmdk data"), Params{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatDisk, job.Format)
+	assert.Equal(t, "vendor-disk.vmdk", job.Source)
+
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Contains(t, job.Error, "unsupported configuration")
+
+	// Uploads are removed once the import finished
+	entries, err := os.ReadDir(filepath.Join(env.manager.config.ScratchDir, "uploads"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestImportManager_RejectsSources(t *testing.T) {
+	env := newImportTestEnv(t)
+	ctx := context.Background()
+
+	outside := filepath.Join(t.TempDir(), "disk.qcow2")
+	require.NoError(t, os.WriteFile(outside, []byte("data"), 0o600))
+
+	_, err := env.manager.StartImport(ctx, outside, Params{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartImport(ctx, filepath.Join(env.sourceDir, "..", filepath.Base(filepath.Dir(outside)), "disk.qcow2"), Params{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartImport(ctx, "disk.qcow2", Params{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	// Images referring to files outside the source are rejected
+	env.images["linked.qcow2"] = `{"format": "qcow2", "virtual-size": 1073741824, "backing-filename": "/etc/shadow"}`
+	linked := filepath.Join(env.sourceDir, "linked.qcow2")
+	require.NoError(t, os.WriteFile(linked, []byte("data"), 0o600))
+
+	_, err = env.manager.StartImport(ctx, linked, Params{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	env.images["extents.vmdk"] = `{"format": "vmdk", "virtual-size": 1073741824, "format-specific": {"type": "vmdk", "data": {"extents": [{"filename": "../../etc/shadow"}]}}}`
+	extents := filepath.Join(env.sourceDir, "extents.vmdk")
+	require.NoError(t, os.WriteFile(extents, []byte("data"), 0o600))
+
+	_, err = env.manager.StartImport(ctx, extents, Params{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	// Invalid parameters
+	env.images["disk.qcow2"] = `{"format": "qcow2", "virtual-size": 1073741824}`
+	disk := filepath.Join(env.sourceDir, "disk.qcow2")
+	require.NoError(t, os.WriteFile(disk, []byte("data"), 0o600))
+
+	_, err = env.manager.StartImport(ctx, disk, Params{DiskBus: "floppy"})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+	_, err = env.manager.StartImport(ctx, disk, Params{Name: "Not A Name"})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+}
+
+func TestImportManager_RemoteHost(t *testing.T) {
+	env := newImportTestEnv(t)
+	env.manager.hosts = testutil.RemoteHost{}
+	ctx := context.Background()
+
+	env.images["disk.qcow2"] = `{"format": "qcow2", "virtual-size": 1073741824}`
+	disk := filepath.Join(env.sourceDir, "disk.qcow2")
+	require.NoError(t, os.WriteFile(disk, []byte("data"), 0o600))
+
+	_, err := env.manager.StartImport(ctx, disk, Params{})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	_, err = env.manager.Import(ctx, disk, Params{})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	// Uploads are not stored for remote hosts
+	_, err = env.manager.StartUploadImport(ctx, "disk.qcow2", strings.NewReader("data"), Params{})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+	_, err = os.Stat(filepath.Join(env.manager.config.ScratchDir, "uploads"))
+	assert.True(t, os.IsNotExist(err))
+
+	jobs, err := env.manager.ListJobs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestVMName(t *testing.T) {
+	assert.Equal(t, "vendor-appliance-4-2", vmName("Vendor Appliance 4.2"))
+	assert.Equal(t, "web-01", vmName("--web_01--"))
+	assert.Empty(t, vmName("..."))
+}
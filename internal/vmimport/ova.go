@@ -0,0 +1,203 @@
+package vmimport
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/errors"
+)
+
+// manifestLine matches the entries of an OVF manifest, e.g.
+// "SHA256(disk1.vmdk)= 0a1b...".
+var manifestLine = regexp.MustCompile(`^(SHA1|SHA256|SHA512)\((.+)\)\s*=\s*([0-9a-fA-F]+)$`)
+
+// readOVADescriptor reads the OVF descriptor of an OVA archive without
+// extracting the disks. The descriptor is normally the first entry.
+func readOVADescriptor(ovaPath string) ([]byte, error) {
+	file, err := os.Open(ovaPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening OVA archive: %w", err)
+	}
+	defer file.Close()
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: OVA archive has no OVF descriptor", errors.ErrInvalidParameter)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading OVA archive: %v", errors.ErrInvalidParameter, err)
+		}
+
+		if header.Typeflag == tar.TypeReg && strings.EqualFold(path.Ext(header.Name), ".ovf") {
+			return io.ReadAll(reader)
+		}
+	}
+}
+
+// extractOVA extracts the files of an OVA archive into dir and verifies them
+// against the manifest of the archive, if it has one. It returns the path of
+// the OVF descriptor.
+func extractOVA(ctx context.Context, ovaPath string, dir string) (string, error) {
+	file, err := os.Open(ovaPath)
+	if err != nil {
+		return "", fmt.Errorf("opening OVA archive: %w", err)
+	}
+	defer file.Close()
+
+	var descriptor, manifest string
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: reading OVA archive: %v", errors.ErrInvalidParameter, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// OVA archives are flat; entries in directories are not referenced
+		// by the descriptor
+		name := header.Name
+		if name != path.Base(name) || name == "." || name == ".." {
+			continue
+		}
+
+		if err := extractFile(ctx, reader, filepath.Join(dir, name)); err != nil {
+			return "", fmt.Errorf("extracting %s: %w", name, err)
+		}
+
+		switch strings.ToLower(path.Ext(name)) {
+		case ".ovf":
+			if descriptor == "" {
+				descriptor = filepath.Join(dir, name)
+			}
+		case ".mf":
+			manifest = filepath.Join(dir, name)
+		}
+	}
+
+	if descriptor == "" {
+		return "", fmt.Errorf("%w: OVA archive has no OVF descriptor", errors.ErrInvalidParameter)
+	}
+
+	if manifest != "" {
+		if err := verifyManifest(ctx, manifest, dir); err != nil {
+			return "", err
+		}
+	}
+
+	return descriptor, nil
+}
+
+// extractFile copies a file out of an archive.
+func extractFile(ctx context.Context, r io.Reader, target string) error {
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, &contextReader{ctx: ctx, r: r}); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// verifyManifest checks the files listed in an OVF manifest against their
+// checksums.
+func verifyManifest(ctx context.Context, manifestPath string, dir string) error {
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return fmt.Errorf("opening manifest: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		match := manifestLine.FindStringSubmatch(line)
+		if match == nil {
+			return fmt.Errorf("%w: invalid manifest entry %q", errors.ErrInvalidParameter, line)
+		}
+
+		name := match[2]
+		if name != path.Base(name) {
+			return fmt.Errorf("%w: invalid manifest entry %q", errors.ErrInvalidParameter, line)
+		}
+
+		sum, err := fileChecksum(ctx, filepath.Join(dir, name), match[1])
+		if err != nil {
+			return fmt.Errorf("checking %s: %w", name, err)
+		}
+		if !strings.EqualFold(sum, match[3]) {
+			return fmt.Errorf("%w: checksum mismatch for %s", errors.ErrInvalidParameter, name)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+	return nil
+}
+
+// fileChecksum computes the hex checksum of a file.
+func fileChecksum(ctx context.Context, filePath string, algorithm string) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case "SHA1":
+		h = sha1.New() //nolint:gosec
+	case "SHA256":
+		h = sha256.New()
+	default:
+		h = sha512.New()
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, &contextReader{ctx: ctx, r: file}); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contextReader stops reading once its context is done, so that canceled
+// imports do not finish copying large disks.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,101 @@
+package vmimport
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threatflux/libgo/internal/errors"
+)
+
+// ovaEntry is a file of a test OVA archive.
+type ovaEntry struct {
+	name string
+	data string
+}
+
+// writeOVA writes an OVA archive holding entries to path.
+func writeOVA(t *testing.T, path string, entries ...ovaEntry) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	writer := tar.NewWriter(file)
+	for _, entry := range entries {
+		require.NoError(t, writer.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Mode:     0o644,
+			Size:     int64(len(entry.data)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := writer.Write([]byte(entry.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+}
+
+// sha256Line returns the manifest entry of a file.
+func sha256Line(name string, data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return fmt.Sprintf("SHA256(%s)= %s\n", name, hex.EncodeToString(sum[:]))
+}
+
+func TestExtractOVA(t *testing.T) {
+	ovaPath := filepath.Join(t.TempDir(), "appliance.ova")
+	writeOVA(t, ovaPath,
+		ovaEntry{name: "appliance.ovf", data: testOVF},
+		ovaEntry{name: "appliance.mf", data: sha256Line("appliance.ovf", testOVF) + sha256Line("appliance-disk1.vmdk", "disk data")},
+		ovaEntry{name: "appliance-disk1.vmdk", data: "disk data"},
+		ovaEntry{name: "../escape.txt", data: "outside"},
+	)
+
+	descriptor, err := readOVADescriptor(ovaPath)
+	require.NoError(t, err)
+	assert.Equal(t, testOVF, string(descriptor))
+
+	dir := t.TempDir()
+	ovfPath, err := extractOVA(context.Background(), ovaPath, dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "appliance.ovf"), ovfPath)
+
+	data, err := os.ReadFile(filepath.Join(dir, "appliance-disk1.vmdk"))
+	require.NoError(t, err)
+	assert.Equal(t, "disk data", string(data))
+
+	// Entries outside the archive root are skipped
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "escape.txt"))
+}
+
+func TestExtractOVA_ChecksumMismatch(t *testing.T) {
+	ovaPath := filepath.Join(t.TempDir(), "appliance.ova")
+	writeOVA(t, ovaPath,
+		ovaEntry{name: "appliance.ovf", data: testOVF},
+		ovaEntry{name: "appliance.mf", data: sha256Line("appliance-disk1.vmdk", "original data")},
+		ovaEntry{name: "appliance-disk1.vmdk", data: "tampered data"},
+	)
+
+	_, err := extractOVA(context.Background(), ovaPath, t.TempDir())
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestExtractOVA_NoDescriptor(t *testing.T) {
+	ovaPath := filepath.Join(t.TempDir(), "appliance.ova")
+	writeOVA(t, ovaPath, ovaEntry{name: "disk.vmdk", data: "disk data"})
+
+	_, err := readOVADescriptor(ovaPath)
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = extractOVA(context.Background(), ovaPath, t.TempDir())
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+}
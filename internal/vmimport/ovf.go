@@ -0,0 +1,306 @@
+package vmimport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/errors"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+)
+
+// CIM resource types of the virtual hardware items in OVF descriptors.
+const (
+	resourceCPU            = 3
+	resourceMemory         = 4
+	resourceIDEController  = 5
+	resourceSCSIController = 6
+	resourceEthernet       = 10
+	resourceDisk           = 17
+	resourceSATAController = 20
+)
+
+// Default hardware of imports whose source does not describe it.
+const (
+	defaultCPUs        = 1
+	defaultMemoryBytes = 1024 * 1024 * 1024
+	defaultNICModel    = "e1000"
+)
+
+// ovfEnvelope is the part of an OVF descriptor imports read. Elements and
+// attributes are matched by local name, whatever their namespace.
+type ovfEnvelope struct {
+	Files         []ovfFile        `xml:"References>File"`
+	Disks         []ovfDisk        `xml:"DiskSection>Disk"`
+	VirtualSystem ovfVirtualSystem `xml:"VirtualSystem"`
+}
+
+type ovfFile struct {
+	ID          string `xml:"id,attr"`
+	Href        string `xml:"href,attr"`
+	Compression string `xml:"compression,attr"`
+}
+
+type ovfDisk struct {
+	DiskID                  string `xml:"diskId,attr"`
+	FileRef                 string `xml:"fileRef,attr"`
+	Capacity                string `xml:"capacity,attr"`
+	CapacityAllocationUnits string `xml:"capacityAllocationUnits,attr"`
+}
+
+type ovfVirtualSystem struct {
+	ID       string      `xml:"id,attr"`
+	Name     string      `xml:"Name"`
+	Hardware ovfHardware `xml:"VirtualHardwareSection"`
+}
+
+type ovfHardware struct {
+	Items             []ovfItem   `xml:"Item"`
+	StorageItems      []ovfItem   `xml:"StorageItem"`
+	EthernetPortItems []ovfItem   `xml:"EthernetPortItem"`
+	Configs           []ovfConfig `xml:"Config"`
+}
+
+// ovfItem is a virtual hardware item; OVF 1.x uses rasd elements and OVF 2.x
+// adds sasd and epasd elements with the same names.
+type ovfItem struct {
+	InstanceID      string   `xml:"InstanceID"`
+	ResourceSubType string   `xml:"ResourceSubType"`
+	AllocationUnits string   `xml:"AllocationUnits"`
+	VirtualQuantity string   `xml:"VirtualQuantity"`
+	Parent          string   `xml:"Parent"`
+	HostResource    []string `xml:"HostResource"`
+	Connection      []string `xml:"Connection"`
+	ResourceType    int      `xml:"ResourceType"`
+}
+
+// ovfConfig is a VMware extension holding settings such as the firmware.
+type ovfConfig struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// parseOVF reads the virtual hardware of an OVF descriptor.
+func parseOVF(data []byte) (*Appliance, error) {
+	var envelope ovfEnvelope
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: parsing OVF descriptor: %v", errors.ErrInvalidParameter, err)
+	}
+
+	system := envelope.VirtualSystem
+	appliance := &Appliance{
+		Name:        system.Name,
+		CPUs:        defaultCPUs,
+		MemoryBytes: defaultMemoryBytes,
+		Disks:       []ApplianceDisk{},
+		NICs:        []ApplianceNIC{},
+	}
+	if appliance.Name == "" {
+		appliance.Name = system.ID
+	}
+
+	for _, config := range system.Hardware.Configs {
+		if config.Key == "firmware" && config.Value == "efi" {
+			appliance.Firmware = "efi"
+		}
+	}
+
+	items := make([]ovfItem, 0, len(system.Hardware.Items)+len(system.Hardware.StorageItems)+len(system.Hardware.EthernetPortItems))
+	items = append(items, system.Hardware.Items...)
+	items = append(items, system.Hardware.StorageItems...)
+	items = append(items, system.Hardware.EthernetPortItems...)
+
+	controllers := make(map[string]ovfItem)
+	for _, item := range items {
+		switch item.ResourceType {
+		case resourceIDEController, resourceSCSIController, resourceSATAController:
+			controllers[item.InstanceID] = item
+		}
+	}
+
+	files := make(map[string]ovfFile, len(envelope.Files))
+	for _, file := range envelope.Files {
+		files[file.ID] = file
+	}
+	disks := make(map[string]ovfDisk, len(envelope.Disks))
+	for _, disk := range envelope.Disks {
+		disks[disk.DiskID] = disk
+	}
+
+	for _, item := range items {
+		switch item.ResourceType {
+		case resourceCPU:
+			count, err := strconv.Atoi(strings.TrimSpace(item.VirtualQuantity))
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("%w: invalid CPU count %q", errors.ErrInvalidParameter, item.VirtualQuantity)
+			}
+			appliance.CPUs = count
+		case resourceMemory:
+			// Memory is given in MiB unless the units say otherwise
+			units := item.AllocationUnits
+			if units == "" {
+				units = "byte * 2^20"
+			}
+			size, err := parseCapacity(item.VirtualQuantity, units)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid memory size: %v", errors.ErrInvalidParameter, err)
+			}
+			appliance.MemoryBytes = size
+		case resourceDisk:
+			disk, err := applianceDisk(item, disks, files)
+			if err != nil {
+				return nil, err
+			}
+			disk.Bus = diskBus(controllers[item.Parent])
+			appliance.Disks = append(appliance.Disks, disk)
+		case resourceEthernet:
+			nic := ApplianceNIC{Model: nicModel(item.ResourceSubType)}
+			if len(item.Connection) > 0 {
+				nic.Network = strings.TrimSpace(item.Connection[0])
+			}
+			appliance.NICs = append(appliance.NICs, nic)
+		}
+	}
+
+	if len(appliance.Disks) == 0 {
+		return nil, fmt.Errorf("%w: OVF descriptor has no disks", errors.ErrInvalidParameter)
+	}
+
+	return appliance, nil
+}
+
+// applianceDisk resolves the disk a disk item refers to. Host resources name
+// either a disk of the disk section, as ovf:/disk/{id}, or a file, as
+// ovf:/file/{id}.
+func applianceDisk(item ovfItem, disks map[string]ovfDisk, files map[string]ovfFile) (ApplianceDisk, error) {
+	if len(item.HostResource) == 0 {
+		return ApplianceDisk{}, fmt.Errorf("%w: disk item %s has no host resource", errors.ErrInvalidParameter, item.InstanceID)
+	}
+
+	resource := strings.TrimPrefix(strings.TrimSpace(item.HostResource[0]), "ovf:")
+	var disk ApplianceDisk
+	var fileRef string
+	switch {
+	case strings.HasPrefix(resource, "/disk/"):
+		ovfDisk, ok := disks[strings.TrimPrefix(resource, "/disk/")]
+		if !ok {
+			return ApplianceDisk{}, fmt.Errorf("%w: disk %s is not in the disk section", errors.ErrInvalidParameter, resource)
+		}
+
+		capacity, err := parseCapacity(ovfDisk.Capacity, ovfDisk.CapacityAllocationUnits)
+		if err != nil {
+			return ApplianceDisk{}, fmt.Errorf("%w: invalid capacity of disk %s: %v", errors.ErrInvalidParameter, ovfDisk.DiskID, err)
+		}
+		disk.Capacity = capacity
+		fileRef = ovfDisk.FileRef
+	case strings.HasPrefix(resource, "/file/"):
+		fileRef = strings.TrimPrefix(resource, "/file/")
+	default:
+		return ApplianceDisk{}, fmt.Errorf("%w: unsupported disk host resource %s", errors.ErrInvalidParameter, resource)
+	}
+
+	// Disks without a file are blank
+	if fileRef == "" {
+		return disk, nil
+	}
+
+	file, ok := files[fileRef]
+	if !ok {
+		return ApplianceDisk{}, fmt.Errorf("%w: file %s is not in the references", errors.ErrInvalidParameter, fileRef)
+	}
+	if file.Compression != "" && file.Compression != "identity" {
+		return ApplianceDisk{}, fmt.Errorf("%w: %s compressed disk files are not supported", errors.ErrInvalidParameter, file.Compression)
+	}
+
+	// Disk files must sit next to the descriptor
+	if file.Href == "" || file.Href != path.Base(file.Href) || file.Href == "." || file.Href == ".." {
+		return ApplianceDisk{}, fmt.Errorf("%w: unsupported disk file reference %q", errors.ErrInvalidParameter, file.Href)
+	}
+	disk.File = file.Href
+
+	return disk, nil
+}
+
+// diskBus maps the controller of a disk to a libvirt disk bus. IDE disks
+// move to SATA, which q35 machines provide instead, and disks of virtio
+// controllers stay on virtio. Other controllers are mapped to SATA, which
+// guests support without extra drivers far more often than the SCSI
+// controller models of other hypervisors.
+func diskBus(controller ovfItem) string {
+	if strings.Contains(strings.ToLower(controller.ResourceSubType), "virtio") {
+		return string(vmmodels.DiskBusVirtio)
+	}
+	return string(vmmodels.DiskBusSATA)
+}
+
+// nicModel maps the adapter type of a network interface to a libvirt
+// interface model, falling back to e1000, which nearly all guests support.
+func nicModel(subType string) string {
+	switch strings.ToLower(strings.TrimSpace(subType)) {
+	case "e1000e":
+		return "e1000e"
+	case "vmxnet3":
+		return "vmxnet3"
+	case "pcnet32":
+		return "pcnet"
+	case "virtio", "virtio-net":
+		return "virtio"
+	case "rtl8139":
+		return "rtl8139"
+	default:
+		return defaultNICModel
+	}
+}
+
+// parseCapacity converts a quantity in OVF allocation units, such as
+// "byte * 2^20" or "MegaBytes", to bytes.
+func parseCapacity(quantity string, units string) (uint64, error) {
+	value, err := strconv.ParseUint(strings.TrimSpace(quantity), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", quantity)
+	}
+
+	multiplier, err := allocationUnits(units)
+	if err != nil {
+		return 0, err
+	}
+
+	if multiplier != 0 && value > math.MaxUint64/multiplier {
+		return 0, fmt.Errorf("quantity %s %s is too large", quantity, units)
+	}
+	return value * multiplier, nil
+}
+
+// allocationUnits returns the number of bytes of an OVF allocation unit.
+func allocationUnits(units string) (uint64, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(units, " ", ""))
+	switch normalized {
+	case "", "byte", "bytes":
+		return 1, nil
+	case "kilobytes", "kb":
+		return 1 << 10, nil
+	case "megabytes", "mb":
+		return 1 << 20, nil
+	case "gigabytes", "gb":
+		return 1 << 30, nil
+	case "terabytes", "tb":
+		return 1 << 40, nil
+	}
+
+	var base, exponent uint64
+	if _, err := fmt.Sscanf(normalized, "byte*%d^%d", &base, &exponent); err != nil || (base != 2 && base != 10) {
+		return 0, fmt.Errorf("unsupported allocation units %q", units)
+	}
+
+	multiplier := uint64(1)
+	for range exponent {
+		if multiplier > math.MaxUint64/base {
+			return 0, fmt.Errorf("unsupported allocation units %q", units)
+		}
+		multiplier *= base
+	}
+	return multiplier, nil
+}
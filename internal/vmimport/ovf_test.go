@@ -0,0 +1,175 @@
+package vmimport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/threatflux/libgo/internal/errors"
+)
+
+// testOVF is an OVF descriptor as exported by VMware, with a second blank
+// disk on a SCSI controller.
+const testOVF = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1"
+          xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"
+          xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData"
+          xmlns:vmw="http://www.vmware.com/schema/ovf"
+          xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData">
+  <References>
+    <File ovf:href="appliance-disk1.vmdk" ovf:id="file1" ovf:size="1048576"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:capacity="16" ovf:capacityAllocationUnits="byte * 2^30" ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+    <Disk ovf:capacity="2048" ovf:capacityAllocationUnits="byte * 2^20" ovf:diskId="vmdisk2"/>
+  </DiskSection>
+  <NetworkSection>
+    <Info>The list of logical networks</Info>
+    <Network ovf:name="VM Network"/>
+    <Network ovf:name="Management"/>
+  </NetworkSection>
+  <VirtualSystem ovf:id="vendor-appliance">
+    <Info>A virtual machine</Info>
+    <Name>Vendor Appliance 4.2</Name>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>4</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>8192</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>lsilogic</rasd:ResourceSubType>
+        <rasd:ResourceType>6</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>1</rasd:AddressOnParent>
+        <rasd:HostResource>ovf:/disk/vmdisk2</rasd:HostResource>
+        <rasd:InstanceID>5</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:Connection>VM Network</rasd:Connection>
+        <rasd:InstanceID>6</rasd:InstanceID>
+        <rasd:ResourceSubType>VmxNet3</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:Connection>Management</rasd:Connection>
+        <rasd:InstanceID>7</rasd:InstanceID>
+        <rasd:ResourceSubType>PCNet32</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+      <vmw:Config ovf:required="false" vmw:key="firmware" vmw:value="efi"/>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+func TestParseOVF(t *testing.T) {
+	appliance, err := parseOVF([]byte(testOVF))
+	require.NoError(t, err)
+
+	assert.Equal(t, &Appliance{
+		Name:        "Vendor Appliance 4.2",
+		CPUs:        4,
+		MemoryBytes: 8 << 30,
+		Firmware:    "efi",
+		Disks: []ApplianceDisk{
+			{File: "appliance-disk1.vmdk", Bus: "sata", Capacity: 16 << 30},
+			{Bus: "sata", Capacity: 2 << 30},
+		},
+		NICs: []ApplianceNIC{
+			{Network: "VM Network", Model: "vmxnet3"},
+			{Network: "Management", Model: "pcnet"},
+		},
+	}, appliance)
+}
+
+func TestParseOVF_VirtIODisksAndOVF2Items(t *testing.T) {
+	descriptor := `<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/2" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/2">
+  <References><File ovf:href="disk.qcow2" ovf:id="f1"/></References>
+  <VirtualSystem ovf:id="router">
+    <VirtualHardwareSection>
+      <Item><InstanceID>1</InstanceID><ResourceType>20</ResourceType><ResourceSubType>virtio-blk</ResourceSubType></Item>
+      <StorageItem><InstanceID>2</InstanceID><HostResource>ovf:/file/f1</HostResource><Parent>1</Parent><ResourceType>17</ResourceType></StorageItem>
+      <EthernetPortItem><InstanceID>3</InstanceID><ResourceSubType>virtio</ResourceSubType><ResourceType>10</ResourceType></EthernetPortItem>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+	appliance, err := parseOVF([]byte(descriptor))
+	require.NoError(t, err)
+
+	assert.Equal(t, "router", appliance.Name)
+	assert.Equal(t, defaultCPUs, appliance.CPUs)
+	assert.Equal(t, uint64(defaultMemoryBytes), appliance.MemoryBytes)
+	assert.Equal(t, []ApplianceDisk{{File: "disk.qcow2", Bus: "virtio"}}, appliance.Disks)
+	assert.Equal(t, []ApplianceNIC{{Model: "virtio"}}, appliance.NICs)
+}
+
+func TestParseOVF_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not xml":  "not xml",
+		"no disks": `<Envelope><VirtualSystem ovf:id="empty"/></Envelope>`,
+		"path traversal": `<Envelope><References><File href="../../etc/shadow" id="f1"/></References>
+  <VirtualSystem><VirtualHardwareSection>
+    <Item><HostResource>ovf:/file/f1</HostResource><ResourceType>17</ResourceType></Item>
+  </VirtualHardwareSection></VirtualSystem></Envelope>`,
+		"compressed": `<Envelope><References><File href="disk.vmdk.gz" id="f1" compression="gzip"/></References>
+  <VirtualSystem><VirtualHardwareSection>
+    <Item><HostResource>ovf:/file/f1</HostResource><ResourceType>17</ResourceType></Item>
+  </VirtualHardwareSection></VirtualSystem></Envelope>`,
+	}
+
+	for name, descriptor := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseOVF([]byte(descriptor))
+			assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+		})
+	}
+}
+
+func TestParseCapacity(t *testing.T) {
+	tests := []struct {
+		quantity string
+		units    string
+		expected uint64
+	}{
+		{"512", "", 512},
+		{"4", "byte * 2^30", 4 << 30},
+		{"1024", "MegaBytes", 1 << 30},
+		{"3", "byte * 10^3", 3000},
+		{" 2 ", "GB", 2 << 30},
+	}
+	for _, test := range tests {
+		size, err := parseCapacity(test.quantity, test.units)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, size)
+	}
+
+	_, err := parseCapacity("${disk.size}", "byte")
+	assert.Error(t, err)
+	_, err = parseCapacity("1", "byte * 3^4")
+	assert.Error(t, err)
+	_, err = parseCapacity("1", "byte * 2^70")
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// downloadFormats maps the formats volumes can be converted to for download
+// to the extension of the downloaded file.
+var downloadFormats = map[string]string{
+	"qcow2": ".qcow2",
+	"raw":   ".img",
+	"vmdk":  ".vmdk",
+	"vdi":   ".vdi",
+	"vhdx":  ".vhdx",
+	"vpc":   ".vhd",
+}
+
+// OpenDownload implements Manager.OpenDownload.
+func (m *VolumeManager) OpenDownload(ctx context.Context, pool string, volume string, opts DownloadOptions) (Download, error) {
+	info, err := m.storageManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume: %w", err)
+	}
+
+	if opts.Format == "" || opts.Format == info.Format {
+		return m.openVolumeDownload(pool, info)
+	}
+
+	extension, supported := downloadFormats[opts.Format]
+	if !supported {
+		return nil, fmt.Errorf("%w: unsupported download format %q", errors.ErrInvalidParameter, opts.Format)
+	}
+
+	// qemu-img would need the passphrase, and the converted image would
+	// hold the data unencrypted
+	if info.Encryption != nil {
+		return nil, fmt.Errorf("%w: encrypted volume %s can only be downloaded as stored",
+			errors.ErrInvalidParameter, volume)
+	}
+
+	// qemu-img reads the volume path on this machine
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("converting volume %s: %w", volume, err)
+	}
+
+	// qemu-img cannot read images the guest is writing to
+	if err := m.checkNotRunning(ctx, info); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(m.config.ScratchDir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating download directory: %w", err)
+	}
+
+	path := filepath.Join(m.config.ScratchDir, uuid.New().String()+extension)
+	if _, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"convert", "-f", info.Format, "-O", opts.Format, info.Path, path,
+	}, exec.CommandOptions{}); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("converting volume: %w", err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("reading converted volume: %w", err)
+	}
+
+	m.logger.Info("Converted volume for download",
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("format", opts.Format),
+		logger.Int64("size", stat.Size()))
+
+	return &fileDownload{
+		info: DownloadInfo{
+			Filename: strings.TrimSuffix(volume, filepath.Ext(volume)) + extension,
+			Format:   opts.Format,
+			Size:     stat.Size(),
+		},
+		path: path,
+	}, nil
+}
+
+// openVolumeDownload prepares the download of a volume as stored.
+func (m *VolumeManager) openVolumeDownload(pool string, info *storage.StorageVolumeInfo) (Download, error) {
+	size := info.Physical
+	if size == 0 && info.Format == "raw" {
+		size = info.Capacity
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("size of volume %s is unknown", info.Name)
+	}
+
+	return &volumeDownload{
+		storageManager: m.storageManager,
+		pool:           pool,
+		volume:         info.Name,
+		info: DownloadInfo{
+			Filename: info.Name,
+			Format:   info.Format,
+			Size:     int64(size),
+		},
+	}, nil
+}
+
+// volumeDownload streams a volume as stored from libvirt.
+type volumeDownload struct {
+	storageManager storage.VolumeManager
+	pool           string
+	volume         string
+	info           DownloadInfo
+}
+
+// Info implements Download.Info.
+func (d *volumeDownload) Info() DownloadInfo {
+	return d.info
+}
+
+// WriteRange implements Download.WriteRange.
+func (d *volumeDownload) WriteRange(ctx context.Context, w io.Writer, offset int64, length int64) error {
+	// Libvirt reads to the end of the volume for a length of zero
+	if length <= 0 {
+		return nil
+	}
+	return d.storageManager.DownloadRange(ctx, d.pool, d.volume, uint64(offset), uint64(length), w)
+}
+
+// Close implements Download.Close.
+func (d *volumeDownload) Close() error {
+	return nil
+}
+
+// fileDownload sends a volume converted into a scratch file.
+type fileDownload struct {
+	path string
+	info DownloadInfo
+}
+
+// Info implements Download.Info.
+func (d *fileDownload) Info() DownloadInfo {
+	return d.info
+}
+
+// WriteRange implements Download.WriteRange.
+func (d *fileDownload) WriteRange(ctx context.Context, w io.Writer, offset int64, length int64) error {
+	file, err := os.Open(d.path)
+	if err != nil {
+		return fmt.Errorf("opening converted volume: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(w, io.NewSectionReader(file, offset, length)); err != nil {
+		return fmt.Errorf("sending converted volume: %w", err)
+	}
+	return nil
+}
+
+// Close implements Download.Close.
+func (d *fileDownload) Close() error {
+	if err := os.Remove(d.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing converted volume: %w", err)
+	}
+	return nil
+}
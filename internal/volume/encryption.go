@@ -0,0 +1,180 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// RotateKey implements Manager.RotateKey.
+func (m *VolumeManager) RotateKey(ctx context.Context, pool string, volume string) (*KeyRotation, error) {
+	if m.keys == nil {
+		return nil, fmt.Errorf("%w: volume encryption is not configured", errors.ErrInvalidParameter)
+	}
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("rotating key of volume %s: %w", volume, err)
+	}
+
+	info, err := m.storageManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume: %w", err)
+	}
+	if info.Encryption == nil {
+		return nil, fmt.Errorf("%w: volume %s is not encrypted", errors.ErrInvalidParameter, volume)
+	}
+
+	// qemu-img cannot open images the guest is writing to
+	if err := m.checkNotRunning(ctx, info); err != nil {
+		return nil, err
+	}
+
+	release, err := m.startRotation(ctx, pool, volume)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	oldPassphrase, err := m.keys.Passphrase(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("reading volume passphrase: %w", err)
+	}
+	newPassphrase, err := storage.NewPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	// The new passphrase gets a keyslot of its own before the secret is
+	// switched over, so the secret opens the volume at every step
+	if err := m.amendKeyslot(ctx, info, oldPassphrase, newPassphrase, true); err != nil {
+		return nil, fmt.Errorf("adding new passphrase: %w", err)
+	}
+
+	key, err := m.keys.SetPassphrase(ctx, pool, volume, newPassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("storing new passphrase: %w", err)
+	}
+
+	if err := m.amendKeyslot(ctx, info, newPassphrase, oldPassphrase, false); err != nil {
+		m.logger.Warn("Old passphrase still opens volume",
+			logger.String("pool", pool),
+			logger.String("volume", volume),
+			logger.Error(err))
+		return nil, fmt.Errorf("removing old passphrase: %w", err)
+	}
+
+	m.logger.Info("Rotated volume key",
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("secret_uuid", key.SecretUUID))
+
+	result := &KeyRotation{
+		Pool:       pool,
+		Volume:     volume,
+		SecretUUID: key.SecretUUID,
+	}
+	if key.RotatedAt != nil {
+		result.RotatedAt = *key.RotatedAt
+	}
+
+	return result, nil
+}
+
+// startRotation marks the key of a volume as being rotated, refusing
+// volumes that a job or another rotation uses. The returned function clears
+// the mark.
+func (m *VolumeManager) startRotation(ctx context.Context, pool string, volume string) (func(), error) {
+	host, _ := connection.HostFromContext(ctx)
+	name := host + "/" + pool + "/" + volume
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, busy := m.activeJob(host, pool, volume); busy {
+		return nil, fmt.Errorf("%w: volume %s is used by job %s", errors.ErrVolumeInUse, volume, id)
+	}
+	if m.rotating[name] {
+		return nil, fmt.Errorf("%w: key of volume %s is being rotated", errors.ErrVolumeInUse, volume)
+	}
+	m.rotating[name] = true
+
+	return func() {
+		m.mu.Lock()
+		delete(m.rotating, name)
+		m.mu.Unlock()
+	}, nil
+}
+
+// amendKeyslot opens a LUKS volume with one passphrase and adds a keyslot
+// for another, or removes the keyslots of another.
+func (m *VolumeManager) amendKeyslot(ctx context.Context, info *storage.StorageVolumeInfo, openWith []byte, slot []byte, add bool) error {
+	if err := os.MkdirAll(m.config.ScratchDir, 0o750); err != nil {
+		return fmt.Errorf("creating scratch directory: %w", err)
+	}
+
+	keyFile, err := writeSecretFile(m.config.ScratchDir, openWith)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(keyFile)
+
+	slotFile, err := writeSecretFile(m.config.ScratchDir, slot)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(slotFile)
+
+	// qcow2 volumes hold LUKS under the encrypt. options
+	driver, prefix := "luks", ""
+	if info.Format == "qcow2" {
+		driver, prefix = "qcow2", "encrypt."
+	}
+	state, slotOption := "active", "new-secret"
+	if !add {
+		state, slotOption = "inactive", "old-secret"
+	}
+
+	if _, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"amend",
+		"--object", "secret,id=key,file=" + escapeOption(keyFile),
+		"--object", "secret,id=slot,file=" + escapeOption(slotFile),
+		"--image-opts", fmt.Sprintf("driver=%s,file.filename=%s,%skey-secret=key", driver, escapeOption(info.Path), prefix),
+		"-o", fmt.Sprintf("%sstate=%s,%s%s=slot", prefix, state, prefix, slotOption),
+	}, exec.CommandOptions{}); err != nil {
+		return fmt.Errorf("amending keyslots: %w", err)
+	}
+
+	return nil
+}
+
+// writeSecretFile writes a passphrase to a file only the server can read,
+// which keeps it off the qemu-img command line.
+func writeSecretFile(dir string, secret []byte) (string, error) {
+	file, err := os.CreateTemp(dir, "secret-*")
+	if err != nil {
+		return "", fmt.Errorf("creating secret file: %w", err)
+	}
+
+	if _, err := file.Write(secret); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("writing secret file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("writing secret file: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
+// escapeOption escapes the commas of a qemu option value.
+func escapeOption(value string) string {
+	return strings.ReplaceAll(value, ",", ",,")
+}
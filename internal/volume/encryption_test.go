@@ -0,0 +1,104 @@
+package volume
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+func TestVolumeManager_RotateKey(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	encryption := &storage.VolumeEncryption{Format: "luks", SecretUUID: "6b3c0f8e-2d4a-4c1b-9e7f-5a8d2c1b0e93"}
+	env.expectVolume("web-disk-0", storage.StorageVolumeInfo{Format: "qcow2", Encryption: encryption})
+	env.expectVolume("plain", storage.StorageVolumeInfo{Format: "qcow2"})
+	env.expectVMs("web-disk-0", vm.VMStatusShutdown)
+
+	_, err := env.manager.RotateKey(context.Background(), "default", "plain")
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	// The passphrase is added to a keyslot, stored and the old one removed
+	var amends [][]string
+	var secrets []string
+	testutil.StubCommands(t, func(_ context.Context, name string, args []string, _ exec.CommandOptions) ([]byte, error) {
+		assert.Equal(t, "qemu-img", name)
+		amends = append(amends, args)
+		for _, arg := range args {
+			if file, ok := strings.CutPrefix(arg, "secret,id=key,file="); ok {
+				data, err := os.ReadFile(file)
+				require.NoError(t, err)
+				secrets = append(secrets, string(data))
+			}
+		}
+		return nil, nil
+	})
+
+	rotatedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	var newPassphrase []byte
+	env.keys.EXPECT().Passphrase(gomock.Any(), "default", "web-disk-0").Return([]byte("old"), nil)
+	env.keys.EXPECT().SetPassphrase(gomock.Any(), "default", "web-disk-0", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ string, passphrase []byte) (*storage.VolumeKey, error) {
+			newPassphrase = passphrase
+			return &storage.VolumeKey{SecretUUID: encryption.SecretUUID, RotatedAt: &rotatedAt}, nil
+		})
+
+	rotation, err := env.manager.RotateKey(context.Background(), "default", "web-disk-0")
+	require.NoError(t, err)
+	assert.Equal(t, &KeyRotation{
+		RotatedAt:  rotatedAt,
+		Pool:       "default",
+		Volume:     "web-disk-0",
+		SecretUUID: encryption.SecretUUID,
+	}, rotation)
+
+	require.Len(t, amends, 2)
+	assert.Contains(t, amends[0], "driver=qcow2,file.filename=/var/lib/libvirt/images/web-disk-0,encrypt.key-secret=key")
+	assert.Contains(t, amends[0], "encrypt.state=active,encrypt.new-secret=slot")
+	assert.Contains(t, amends[1], "encrypt.state=inactive,encrypt.old-secret=slot")
+	assert.Equal(t, []string{"old", string(newPassphrase)}, secrets)
+
+	// Secret files are removed
+	entries, err := os.ReadDir(env.manager.config.ScratchDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestVolumeManager_RotateKeyRunningVM(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("web-disk-0", storage.StorageVolumeInfo{
+		Format:     "raw",
+		Encryption: &storage.VolumeEncryption{Format: "luks"},
+	})
+	env.expectVMs("web-disk-0", vm.VMStatusRunning)
+
+	_, err := env.manager.RotateKey(context.Background(), "default", "web-disk-0")
+	assert.ErrorIs(t, err, errors.ErrVolumeInUse)
+}
+
+func TestVolumeManager_RotateKeyNotConfigured(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.manager.keys = nil
+
+	_, err := env.manager.RotateKey(context.Background(), "default", "web-disk-0")
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+}
+
+func TestVolumeManager_RotateKeyRemoteHost(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.manager.hosts = testutil.RemoteHost{}
+
+	_, err := env.manager.RotateKey(context.Background(), "default", "web-disk-0")
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+}
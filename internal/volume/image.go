@@ -0,0 +1,519 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/pkg/logger"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// convertFormats lists the formats a volume can be converted into.
+var convertFormats = map[string]bool{
+	"qcow2": true,
+	"raw":   true,
+	"vmdk":  true,
+	"vdi":   true,
+	"vhdx":  true,
+}
+
+// checkFormats lists the formats qemu-img checks.
+var checkFormats = map[string]bool{
+	"qcow2": true,
+	"qed":   true,
+	"vmdk":  true,
+	"vdi":   true,
+	"vhdx":  true,
+}
+
+// repairModes lists the errors a check can repair.
+var repairModes = map[string]bool{
+	"leaks": true,
+	"all":   true,
+}
+
+// qemuImgProgress matches a progress report of qemu-img -p, such as
+// "(42.50/100%)".
+var qemuImgProgress = regexp.MustCompile(`\((\d+(?:\.\d+)?)/100%\)`)
+
+// maxProgressBuffer bounds the output kept while waiting for the end of a
+// progress report.
+const maxProgressBuffer = 64
+
+// qemuImageInfo is an image in the output of qemu-img info.
+type qemuImageInfo struct {
+	Filename        string `json:"filename"`
+	Format          string `json:"format"`
+	BackingFilename string `json:"backing-filename"`
+	BackingFormat   string `json:"backing-filename-format"`
+	FormatSpecific  struct {
+		Data struct {
+			Compat  string `json:"compat"`
+			Corrupt bool   `json:"corrupt"`
+		} `json:"data"`
+	} `json:"format-specific"`
+	VirtualSize uint64 `json:"virtual-size"`
+	ActualSize  uint64 `json:"actual-size"`
+	ClusterSize uint64 `json:"cluster-size"`
+	DirtyFlag   bool   `json:"dirty-flag"`
+	Encrypted   bool   `json:"encrypted"`
+}
+
+// qemuCheckResult is the output of qemu-img check.
+type qemuCheckResult struct {
+	CheckErrors        int    `json:"check-errors"`
+	Corruptions        int    `json:"corruptions"`
+	Leaks              int    `json:"leaks"`
+	CorruptionsFixed   int    `json:"corruptions-fixed"`
+	LeaksFixed         int    `json:"leaks-fixed"`
+	TotalClusters      uint64 `json:"total-clusters"`
+	AllocatedClusters  uint64 `json:"allocated-clusters"`
+	FragmentedClusters uint64 `json:"fragmented-clusters"`
+	ImageEndOffset     uint64 `json:"image-end-offset"`
+}
+
+// ImageInfo implements Manager.ImageInfo.
+func (m *VolumeManager) ImageInfo(ctx context.Context, pool string, volume string) (*ImageInfo, error) {
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("inspecting volume %s: %w", volume, err)
+	}
+
+	info, err := m.storageManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume: %w", err)
+	}
+
+	// Reading the headers is safe while a guest writes to the volume
+	output, err := exec.ExecuteCommand(ctx, "qemu-img", []string{
+		"info", "--output=json", "--backing-chain", "--force-share", info.Path,
+	}, exec.CommandOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("reading volume image: %w", err)
+	}
+
+	var chain []qemuImageInfo
+	if err := json.Unmarshal(output, &chain); err != nil {
+		return nil, fmt.Errorf("parsing qemu-img info output: %w", err)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("qemu-img info reported no image")
+	}
+
+	image := chain[0].toImageInfo()
+	for _, backing := range chain[1:] {
+		image.BackingChain = append(image.BackingChain, backing.toImageInfo())
+	}
+
+	return &image, nil
+}
+
+// StartCheck implements Manager.StartCheck.
+func (m *VolumeManager) StartCheck(ctx context.Context, pool string, volume string, params CheckParams) (*Job, error) {
+	if params.Repair != "" && !repairModes[params.Repair] {
+		return nil, fmt.Errorf("%w: unsupported repair mode %q", errors.ErrInvalidParameter, params.Repair)
+	}
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("checking volume %s: %w", volume, err)
+	}
+
+	info, err := m.imageVolume(ctx, pool, volume)
+	if err != nil {
+		return nil, err
+	}
+	if !checkFormats[info.Format] {
+		return nil, fmt.Errorf("%w: %s volumes cannot be checked", errors.ErrInvalidParameter, info.Format)
+	}
+
+	job, err := m.createJob(ctx, JobTypeCheck, pool, volume, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// An interrupted repair leaves the image in an unknown state
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	if params.Repair == "" {
+		m.setCancel(job.ID, cancel)
+	}
+	go m.runCheck(jobCtx, cancel, job.ID, info, params)
+
+	m.logger.Info("Started volume check",
+		logger.String("job_id", job.ID),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("repair", params.Repair))
+
+	return job, nil
+}
+
+// StartConvert implements Manager.StartConvert.
+func (m *VolumeManager) StartConvert(ctx context.Context, pool string, volume string, params ConvertParams) (*Job, error) {
+	if params.Pool == "" {
+		params.Pool = pool
+	}
+	if err := validateVolumeName(params.Name); err != nil {
+		return nil, err
+	}
+	if !convertFormats[params.Format] {
+		return nil, fmt.Errorf("%w: unsupported volume format %q", errors.ErrInvalidParameter, params.Format)
+	}
+	if params.Compress && params.Format != "qcow2" {
+		return nil, fmt.Errorf("%w: only qcow2 volumes can be compressed", errors.ErrInvalidParameter)
+	}
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("converting volume %s: %w", volume, err)
+	}
+
+	source, err := m.imageVolume(ctx, pool, volume)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conversions never replace existing volumes
+	_, err = m.storageManager.GetInfo(ctx, params.Pool, params.Name)
+	if err == nil {
+		return nil, fmt.Errorf("%w: volume %s in pool %s", errors.ErrAlreadyExists, params.Name, params.Pool)
+	}
+	if !errors.Is(err, storage.ErrVolumeNotFound) {
+		return nil, fmt.Errorf("checking target volume: %w", err)
+	}
+
+	job, err := m.createJob(ctx, JobTypeConvert, pool, volume, params.Pool, params.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	jobCtx = storage.WithVolumeOrigin(jobCtx, storage.VolumeOrigin{Job: job.ID})
+	m.setCancel(job.ID, cancel)
+	go m.runConvert(jobCtx, cancel, job.ID, source, params)
+
+	m.logger.Info("Started volume conversion",
+		logger.String("job_id", job.ID),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("target_pool", params.Pool),
+		logger.String("target_volume", params.Name),
+		logger.String("format", params.Format))
+
+	return job, nil
+}
+
+// StartRebase implements Manager.StartRebase.
+func (m *VolumeManager) StartRebase(ctx context.Context, pool string, volume string, params RebaseParams) (*Job, error) {
+	if params.BackingPool == "" {
+		params.BackingPool = pool
+	}
+	if err := connection.RequireLocalHost(ctx, m.hosts); err != nil {
+		return nil, fmt.Errorf("rebasing volume %s: %w", volume, err)
+	}
+
+	info, err := m.imageVolume(ctx, pool, volume)
+	if err != nil {
+		return nil, err
+	}
+	if info.Format != "qcow2" {
+		return nil, fmt.Errorf("%w: only qcow2 volumes have backing files", errors.ErrInvalidParameter)
+	}
+
+	var backing *storage.StorageVolumeInfo
+	backingPool := ""
+	if params.BackingVolume == "" {
+		if info.BackingStore == nil || info.BackingStore.Path == "" {
+			return nil, fmt.Errorf("%w: volume %s has no backing file", errors.ErrInvalidParameter, volume)
+		}
+	} else {
+		if params.BackingPool == pool && params.BackingVolume == volume {
+			return nil, fmt.Errorf("%w: volume %s cannot back itself", errors.ErrInvalidParameter, volume)
+		}
+		backing, err = m.storageManager.GetInfo(ctx, params.BackingPool, params.BackingVolume)
+		if err != nil {
+			return nil, fmt.Errorf("getting backing volume: %w", err)
+		}
+		backingPool = params.BackingPool
+	}
+
+	// The new backing volume must not change while data is copied from it
+	job, err := m.createJob(ctx, JobTypeRebase, pool, volume, backingPool, params.BackingVolume)
+	if err != nil {
+		return nil, err
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.setCancel(job.ID, cancel)
+	go m.runRebase(jobCtx, cancel, job.ID, info, backing)
+
+	m.logger.Info("Started volume rebase",
+		logger.String("job_id", job.ID),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("backing_pool", params.BackingPool),
+		logger.String("backing_volume", params.BackingVolume))
+
+	return job, nil
+}
+
+// imageVolume gets a volume qemu-img may open for writing.
+func (m *VolumeManager) imageVolume(ctx context.Context, pool string, volume string) (*storage.StorageVolumeInfo, error) {
+	info, err := m.storageManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume: %w", err)
+	}
+
+	// qemu-img would need the passphrase
+	if info.Encryption != nil {
+		return nil, fmt.Errorf("%w: encrypted volume %s cannot be processed with qemu-img",
+			errors.ErrInvalidParameter, volume)
+	}
+
+	// qemu-img cannot open images the guest is writing to
+	if err := m.checkNotRunning(ctx, info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// runCheck checks a volume and records what was found. qemu-img does not
+// report the progress of a check.
+func (m *VolumeManager) runCheck(ctx context.Context, cancel context.CancelFunc, id string, info *storage.StorageVolumeInfo, params CheckParams) {
+	defer cancel()
+
+	args := []string{"check", "--output=json", "-f", info.Format}
+	if params.Repair != "" {
+		args = append(args, "-r", params.Repair)
+	}
+	args = append(args, info.Path)
+
+	// qemu-img exits with an error when it finds errors, which the result
+	// reports
+	output, err := exec.ExecuteCommand(ctx, "qemu-img", args, exec.CommandOptions{})
+	var result qemuCheckResult
+	if ctx.Err() == nil && json.Unmarshal(output, &result) == nil {
+		err = nil
+		m.jobs.Update(id, func(state *jobState) {
+			state.job.Check = result.toCheckResult()
+		})
+	}
+
+	if m.finishImageJob(id, err) {
+		return
+	}
+	if err != nil {
+		m.logger.Error("Volume check failed",
+			logger.String("job_id", id),
+			logger.String("volume", info.Name),
+			logger.Error(err))
+		return
+	}
+
+	m.logger.Info("Volume check completed",
+		logger.String("job_id", id),
+		logger.String("volume", info.Name),
+		logger.Int("corruptions", result.Corruptions),
+		logger.Int("leaks", result.Leaks))
+}
+
+// runConvert converts a volume into a new volume, which is deleted again if
+// the conversion fails or is canceled.
+func (m *VolumeManager) runConvert(ctx context.Context, cancel context.CancelFunc, id string, source *storage.StorageVolumeInfo, params ConvertParams) {
+	defer cancel()
+
+	created, err := m.convert(ctx, id, source, params)
+
+	canceled := m.finishImageJob(id, err)
+	if created && (err != nil || canceled) {
+		if deleteErr := m.storageManager.Delete(context.WithoutCancel(ctx), params.Pool, params.Name); deleteErr != nil {
+			m.logger.Warn("Failed to delete volume of stopped conversion",
+				logger.String("job_id", id),
+				logger.String("volume", params.Name),
+				logger.Error(deleteErr))
+		}
+	}
+
+	switch {
+	case canceled:
+		// Canceled conversions already have their final status
+	case err != nil:
+		m.logger.Error("Volume conversion failed",
+			logger.String("job_id", id),
+			logger.String("volume", source.Name),
+			logger.Error(err))
+	default:
+		m.logger.Info("Volume conversion completed",
+			logger.String("job_id", id),
+			logger.String("target_pool", params.Pool),
+			logger.String("target_volume", params.Name))
+	}
+}
+
+// convert creates the volume of a conversion and writes the converted
+// image to it. It reports whether the volume was created.
+func (m *VolumeManager) convert(ctx context.Context, id string, source *storage.StorageVolumeInfo, params ConvertParams) (bool, error) {
+	// Libvirt has no vhdx volumes, so qemu-img writes the image over a raw
+	// volume
+	volumeFormat := params.Format
+	if volumeFormat == "vhdx" {
+		volumeFormat = "raw"
+	}
+	if err := m.storageManager.Create(ctx, params.Pool, params.Name, source.Capacity, volumeFormat); err != nil {
+		return false, fmt.Errorf("creating volume: %w", err)
+	}
+
+	target, err := m.storageManager.GetPath(ctx, params.Pool, params.Name)
+	if err != nil {
+		return true, fmt.Errorf("getting volume path: %w", err)
+	}
+
+	args := []string{"convert", "-p", "-f", source.Format, "-O", params.Format}
+	if params.Compress {
+		args = append(args, "-c")
+	}
+	args = append(args, source.Path, target)
+
+	if err := m.runQemuImg(ctx, id, args); err != nil {
+		return true, fmt.Errorf("converting volume: %w", err)
+	}
+	return true, nil
+}
+
+// runRebase moves a volume onto a backing volume, or merges its backing
+// chain into it when backing is nil. qemu-img rewrites the header last, so
+// a canceled rebase leaves the volume on its old backing file.
+func (m *VolumeManager) runRebase(ctx context.Context, cancel context.CancelFunc, id string, info *storage.StorageVolumeInfo, backing *storage.StorageVolumeInfo) {
+	defer cancel()
+
+	args := []string{"rebase", "-p", "-f", info.Format}
+	if backing != nil {
+		args = append(args, "-b", backing.Path, "-F", backing.Format)
+	} else {
+		args = append(args, "-b", "")
+	}
+	args = append(args, info.Path)
+
+	err := m.runQemuImg(ctx, id, args)
+	if err != nil {
+		err = fmt.Errorf("rebasing volume: %w", err)
+	}
+
+	if m.finishImageJob(id, err) {
+		return
+	}
+	if err != nil {
+		m.logger.Error("Volume rebase failed",
+			logger.String("job_id", id),
+			logger.String("volume", info.Name),
+			logger.Error(err))
+		return
+	}
+
+	m.logger.Info("Volume rebase completed",
+		logger.String("job_id", id),
+		logger.String("volume", info.Name),
+		logger.Bool("flattened", backing == nil))
+}
+
+// runQemuImg runs qemu-img with -p, recording the progress it reports.
+func (m *VolumeManager) runQemuImg(ctx context.Context, id string, args []string) error {
+	progress := &progressWriter{report: func(percent int) {
+		m.updateProgress(id, percent)
+	}}
+
+	_, err := exec.ExecuteCommand(ctx, "qemu-img", args, exec.CommandOptions{Stdout: progress})
+	return err
+}
+
+// setCancel makes a running job cancelable.
+func (m *VolumeManager) setCancel(id string, cancel context.CancelFunc) {
+	m.jobs.Update(id, func(state *jobState) {
+		state.cancel = cancel
+	})
+}
+
+// finishImageJob records the result of a qemu-img job and reports whether
+// the job was canceled, in which case it already has its final status.
+func (m *VolumeManager) finishImageJob(id string, err error) bool {
+	canceled := false
+	m.jobs.Update(id, func(state *jobState) {
+		canceled = state.canceled
+		state.canceled = false
+		state.cancel = nil
+		if canceled {
+			return
+		}
+
+		if err != nil {
+			m.finish(state, StatusFailed, err)
+		} else {
+			m.finish(state, StatusCompleted, nil)
+		}
+	})
+
+	return canceled
+}
+
+// progressWriter parses the progress reports qemu-img -p writes.
+type progressWriter struct {
+	report func(percent int)
+	buf    []byte
+}
+
+// Write implements io.Writer.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	if matches := qemuImgProgress.FindAllSubmatch(w.buf, -1); len(matches) > 0 {
+		if percent, err := strconv.ParseFloat(string(matches[len(matches)-1][1]), 64); err == nil {
+			w.report(int(percent))
+		}
+	}
+
+	// Reports end with a carriage return; a partial one is kept for the
+	// next write
+	if i := bytes.LastIndexAny(w.buf, "\r\n"); i >= 0 {
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxProgressBuffer {
+		w.buf = w.buf[len(w.buf)-maxProgressBuffer:]
+	}
+
+	return len(p), nil
+}
+
+// toImageInfo converts an image reported by qemu-img info.
+func (i *qemuImageInfo) toImageInfo() ImageInfo {
+	return ImageInfo{
+		Filename:        i.Filename,
+		Format:          i.Format,
+		BackingFilename: i.BackingFilename,
+		BackingFormat:   i.BackingFormat,
+		Compat:          i.FormatSpecific.Data.Compat,
+		VirtualSize:     i.VirtualSize,
+		ActualSize:      i.ActualSize,
+		ClusterSize:     i.ClusterSize,
+		DirtyFlag:       i.DirtyFlag,
+		Corrupt:         i.FormatSpecific.Data.Corrupt,
+		Encrypted:       i.Encrypted,
+	}
+}
+
+// toCheckResult converts the output of qemu-img check.
+func (r *qemuCheckResult) toCheckResult() *CheckResult {
+	return &CheckResult{
+		CheckErrors:        r.CheckErrors,
+		Corruptions:        r.Corruptions,
+		Leaks:              r.Leaks,
+		CorruptionsFixed:   r.CorruptionsFixed,
+		LeaksFixed:         r.LeaksFixed,
+		TotalClusters:      r.TotalClusters,
+		AllocatedClusters:  r.AllocatedClusters,
+		FragmentedClusters: r.FragmentedClusters,
+		ImageEndOffset:     r.ImageEndOffset,
+	}
+}
@@ -0,0 +1,343 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+func TestVolumeManager_ImageInfo(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{Format: "qcow2"})
+
+	testutil.StubCommands(t, func(_ context.Context, name string, args []string, _ exec.CommandOptions) ([]byte, error) {
+		assert.Equal(t, "qemu-img", name)
+		assert.Equal(t, []string{"info", "--output=json", "--backing-chain", "--force-share", "/var/lib/libvirt/images/disk.qcow2"}, args)
+		return []byte(`[
+			{
+				"filename": "/var/lib/libvirt/images/disk.qcow2",
+				"format": "qcow2",
+				"virtual-size": 10737418240,
+				"actual-size": 200704,
+				"cluster-size": 65536,
+				"dirty-flag": true,
+				"backing-filename": "/var/lib/libvirt/images/base.qcow2",
+				"backing-filename-format": "qcow2",
+				"format-specific": {"type": "qcow2", "data": {"compat": "1.1", "corrupt": false}}
+			},
+			{
+				"filename": "/var/lib/libvirt/images/base.qcow2",
+				"format": "qcow2",
+				"virtual-size": 10737418240,
+				"actual-size": 1073741824,
+				"cluster-size": 65536,
+				"dirty-flag": false,
+				"format-specific": {"type": "qcow2", "data": {"compat": "0.10", "corrupt": true}}
+			}
+		]`), nil
+	})
+
+	image, err := env.manager.ImageInfo(context.Background(), "default", "disk.qcow2")
+	require.NoError(t, err)
+	assert.Equal(t, "qcow2", image.Format)
+	assert.Equal(t, uint64(10<<30), image.VirtualSize)
+	assert.Equal(t, "1.1", image.Compat)
+	assert.True(t, image.DirtyFlag)
+	assert.Equal(t, "/var/lib/libvirt/images/base.qcow2", image.BackingFilename)
+	require.Len(t, image.BackingChain, 1)
+	assert.Equal(t, "0.10", image.BackingChain[0].Compat)
+	assert.True(t, image.BackingChain[0].Corrupt)
+	assert.Empty(t, image.BackingChain[0].BackingChain)
+}
+
+func TestVolumeManager_Check(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{Format: "qcow2"})
+	env.expectVolume("disk.raw", storage.StorageVolumeInfo{Format: "raw"})
+	env.expectVMs("other-data", vm.VMStatusRunning)
+
+	_, err := env.manager.StartCheck(context.Background(), "default", "disk.qcow2", CheckParams{Repair: "everything"})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartCheck(context.Background(), "default", "disk.raw", CheckParams{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	// Found errors are reported by the result rather than failing the job
+	testutil.StubCommands(t, func(_ context.Context, _ string, args []string, _ exec.CommandOptions) ([]byte, error) {
+		assert.Equal(t, []string{"check", "--output=json", "-f", "qcow2", "/var/lib/libvirt/images/disk.qcow2"}, args)
+		return []byte(`{"check-errors": 0, "leaks": 3, "total-clusters": 163840, "allocated-clusters": 40}`),
+			fmt.Errorf("command failed: exit status 3")
+	})
+
+	job, err := env.manager.StartCheck(context.Background(), "default", "disk.qcow2", CheckParams{})
+	require.NoError(t, err)
+	assert.Equal(t, JobTypeCheck, job.Type)
+
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+	require.NotNil(t, job.Check)
+	assert.Equal(t, 3, job.Check.Leaks)
+	assert.Equal(t, uint64(163840), job.Check.TotalClusters)
+}
+
+func TestVolumeManager_RepairCannotBeCanceled(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{Format: "qcow2"})
+	env.expectVMs("other-data", vm.VMStatusRunning)
+
+	release := make(chan struct{})
+	testutil.StubCommands(t, func(_ context.Context, _ string, args []string, _ exec.CommandOptions) ([]byte, error) {
+		assert.Equal(t, []string{"-r", "leaks"}, args[4:6])
+		<-release
+		return []byte(`{"leaks-fixed": 3}`), nil
+	})
+
+	job, err := env.manager.StartCheck(context.Background(), "default", "disk.qcow2", CheckParams{Repair: "leaks"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, env.manager.CancelJob(context.Background(), job.ID), errors.ErrVolumeJobInvalidState)
+
+	close(release)
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+	assert.Equal(t, 3, job.Check.LeaksFixed)
+}
+
+func TestVolumeManager_Convert(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{Format: "qcow2", Capacity: 10 << 30})
+	env.expectVolume("existing", storage.StorageVolumeInfo{})
+	env.expectVMs("other-data", vm.VMStatusRunning)
+
+	_, err := env.manager.StartConvert(context.Background(), "default", "disk.qcow2", ConvertParams{Name: "disk.iso", Format: "iso"})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartConvert(context.Background(), "default", "disk.qcow2", ConvertParams{Name: "disk.vmdk", Format: "vmdk", Compress: true})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartConvert(context.Background(), "default", "disk.qcow2", ConvertParams{Name: "existing", Format: "raw"})
+	assert.ErrorIs(t, err, errors.ErrAlreadyExists)
+
+	// vhdx images are written over a raw volume
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", "disk.vhdx").
+		Return(nil, fmt.Errorf("volume: %w", storage.ErrVolumeNotFound))
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "disk.vhdx", uint64(10<<30), "raw").Return(nil)
+	env.volumes.EXPECT().GetPath(gomock.Any(), "default", "disk.vhdx").Return("/var/lib/libvirt/images/disk.vhdx", nil)
+
+	reported := make(chan struct{})
+	release := make(chan struct{})
+	testutil.StubCommands(t, func(_ context.Context, _ string, args []string, opts exec.CommandOptions) ([]byte, error) {
+		assert.Equal(t, []string{
+			"convert", "-p", "-f", "qcow2", "-O", "vhdx",
+			"/var/lib/libvirt/images/disk.qcow2", "/var/lib/libvirt/images/disk.vhdx",
+		}, args)
+		_, _ = opts.Stdout.Write([]byte("    (10.00/100%)\r    (42."))
+		_, _ = opts.Stdout.Write([]byte("50/100%)\r"))
+		close(reported)
+		<-release
+		return nil, nil
+	})
+
+	job, err := env.manager.StartConvert(context.Background(), "default", "disk.qcow2", ConvertParams{Name: "disk.vhdx", Format: "vhdx"})
+	require.NoError(t, err)
+	assert.Equal(t, JobTypeConvert, job.Type)
+	assert.Equal(t, "disk.vhdx", job.TargetVolume)
+
+	<-reported
+	job, err = env.manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 42, job.Progress)
+
+	close(release)
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+	assert.Equal(t, 100, job.Progress)
+}
+
+func TestVolumeManager_ConvertFailure(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{Format: "qcow2", Capacity: 1 << 30})
+	env.expectVMs("other-data", vm.VMStatusRunning)
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", "disk.raw").
+		Return(nil, fmt.Errorf("volume: %w", storage.ErrVolumeNotFound))
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "disk.raw", uint64(1<<30), "raw").Return(nil)
+	env.volumes.EXPECT().GetPath(gomock.Any(), "default", "disk.raw").Return("/var/lib/libvirt/images/disk.raw", nil)
+	deleted := make(chan struct{})
+	env.volumes.EXPECT().Delete(gomock.Any(), "default", "disk.raw").DoAndReturn(
+		func(_ context.Context, _, _ string) error {
+			close(deleted)
+			return nil
+		})
+
+	testutil.StubCommands(t, func(_ context.Context, _ string, _ []string, _ exec.CommandOptions) ([]byte, error) {
+		return nil, fmt.Errorf("command failed: exit status 1: No space left on device")
+	})
+
+	job, err := env.manager.StartConvert(context.Background(), "default", "disk.qcow2", ConvertParams{Name: "disk.raw", Format: "raw"})
+	require.NoError(t, err)
+
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Contains(t, job.Error, "No space left on device")
+
+	select {
+	case <-deleted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("volume of failed conversion was not deleted")
+	}
+}
+
+func TestVolumeManager_CancelConvert(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{Format: "qcow2", Capacity: 1 << 30})
+	env.expectVMs("other-data", vm.VMStatusRunning)
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", "copy.qcow2").
+		Return(nil, fmt.Errorf("volume: %w", storage.ErrVolumeNotFound))
+	env.volumes.EXPECT().Create(gomock.Any(), "default", "copy.qcow2", uint64(1<<30), "qcow2").Return(nil)
+	env.volumes.EXPECT().GetPath(gomock.Any(), "default", "copy.qcow2").Return("/var/lib/libvirt/images/copy.qcow2", nil)
+
+	started := make(chan struct{})
+	testutil.StubCommands(t, func(ctx context.Context, _ string, args []string, _ exec.CommandOptions) ([]byte, error) {
+		assert.Contains(t, args, "-c")
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	deleted := make(chan struct{})
+	env.volumes.EXPECT().Delete(gomock.Any(), "default", "copy.qcow2").DoAndReturn(
+		func(_ context.Context, _, _ string) error {
+			close(deleted)
+			return nil
+		})
+
+	job, err := env.manager.StartConvert(context.Background(), "default", "disk.qcow2",
+		ConvertParams{Name: "copy.qcow2", Format: "qcow2", Compress: true})
+	require.NoError(t, err)
+
+	<-started
+	require.NoError(t, env.manager.CancelJob(context.Background(), job.ID))
+
+	select {
+	case <-deleted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("volume of canceled conversion was not deleted")
+	}
+
+	job, err = env.manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status)
+}
+
+func TestVolumeManager_Rebase(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{
+		Format:       "qcow2",
+		BackingStore: &storage.BackingStore{Path: "/var/lib/libvirt/images/base.qcow2", Format: "qcow2"},
+	})
+	env.expectVolume("base.raw", storage.StorageVolumeInfo{Format: "raw"})
+	env.expectVolume("standalone.qcow2", storage.StorageVolumeInfo{Format: "qcow2"})
+	env.expectVolume("disk.raw", storage.StorageVolumeInfo{Format: "raw"})
+	env.expectVMs("other-data", vm.VMStatusRunning)
+
+	_, err := env.manager.StartRebase(context.Background(), "default", "disk.raw", RebaseParams{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartRebase(context.Background(), "default", "standalone.qcow2", RebaseParams{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartRebase(context.Background(), "default", "disk.qcow2", RebaseParams{BackingVolume: "disk.qcow2"})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	var calls [][]string
+	testutil.StubCommands(t, func(_ context.Context, _ string, args []string, _ exec.CommandOptions) ([]byte, error) {
+		calls = append(calls, args)
+		return nil, nil
+	})
+
+	job, err := env.manager.StartRebase(context.Background(), "default", "disk.qcow2", RebaseParams{BackingVolume: "base.raw"})
+	require.NoError(t, err)
+	assert.Equal(t, "base.raw", job.TargetVolume)
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+
+	// Without a backing volume the chain is merged into the volume
+	job, err = env.manager.StartRebase(context.Background(), "default", "disk.qcow2", RebaseParams{})
+	require.NoError(t, err)
+	assert.Empty(t, job.TargetVolume)
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+
+	assert.Equal(t, [][]string{
+		{"rebase", "-p", "-f", "qcow2", "-b", "/var/lib/libvirt/images/base.raw", "-F", "raw", "/var/lib/libvirt/images/disk.qcow2"},
+		{"rebase", "-p", "-f", "qcow2", "-b", "", "/var/lib/libvirt/images/disk.qcow2"},
+	}, calls)
+}
+
+func TestVolumeManager_ImageJobsOnRunningVolume(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("live.qcow2", storage.StorageVolumeInfo{Format: "qcow2"})
+	env.expectVolume("secret.qcow2", storage.StorageVolumeInfo{Format: "qcow2", Encryption: &storage.VolumeEncryption{Format: "luks"}})
+	env.expectVMs("live.qcow2", vm.VMStatusRunning)
+
+	_, err := env.manager.StartCheck(context.Background(), "default", "live.qcow2", CheckParams{})
+	assert.ErrorIs(t, err, errors.ErrVolumeInUse)
+
+	_, err = env.manager.StartConvert(context.Background(), "default", "live.qcow2", ConvertParams{Name: "copy", Format: "raw"})
+	assert.ErrorIs(t, err, errors.ErrVolumeInUse)
+
+	_, err = env.manager.StartCheck(context.Background(), "default", "secret.qcow2", CheckParams{})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+}
+
+func TestVolumeManager_ImageJobsOnRemoteHost(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.manager.hosts = testutil.RemoteHost{}
+	ctx := context.Background()
+
+	// qemu-img would open the path on this machine instead of the host
+	testutil.StubCommands(t, func(context.Context, string, []string, exec.CommandOptions) ([]byte, error) {
+		t.Fatal("qemu-img must not run for remote hosts")
+		return nil, nil
+	})
+
+	_, err := env.manager.ImageInfo(ctx, "default", "disk.qcow2")
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	_, err = env.manager.StartCheck(ctx, "default", "disk.qcow2", CheckParams{})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	_, err = env.manager.StartConvert(ctx, "default", "disk.qcow2", ConvertParams{Name: "copy", Format: "raw"})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	_, err = env.manager.StartRebase(ctx, "default", "disk.qcow2", RebaseParams{})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	jobs, err := env.manager.ListJobs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestProgressWriter(t *testing.T) {
+	var reported []int
+	w := &progressWriter{report: func(percent int) { reported = append(reported, percent) }}
+
+	for _, chunk := range []string{"    (0.00/100%)\r", "    (33.", "33/100%)\r", "noise without progress", "    (100.00/100%)\r\n"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+
+	assert.Equal(t, []int{0, 33, 100}, reported)
+	assert.LessOrEqual(t, len(w.buf), maxProgressBuffer)
+}
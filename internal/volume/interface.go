@@ -0,0 +1,262 @@
+package volume
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// JobType identifies the operation a volume job performs.
+type JobType string
+
+const (
+	// JobTypeClone copies a volume into a new volume
+	JobTypeClone JobType = "clone"
+	// JobTypeWipe overwrites the data of a volume with zeros
+	JobTypeWipe JobType = "wipe"
+	// JobTypeCheck checks the image of a volume for errors
+	JobTypeCheck JobType = "check"
+	// JobTypeConvert converts a volume into a new volume of another format
+	JobTypeConvert JobType = "convert"
+	// JobTypeRebase moves a volume onto another backing volume or merges
+	// its backing chain into it
+	JobTypeRebase JobType = "rebase"
+)
+
+// Status represents the status of a volume job.
+type Status string
+
+const (
+	// StatusRunning indicates the job is in progress
+	StatusRunning Status = "running"
+	// StatusCompleted indicates the job completed successfully
+	StatusCompleted Status = "completed"
+	// StatusFailed indicates the job failed
+	StatusFailed Status = "failed"
+	// StatusCanceled indicates the job was canceled
+	StatusCanceled Status = "canceled"
+)
+
+// Job represents a long running operation on a volume.
+type Job struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+	// Check is the result of a completed check
+	Check  *CheckResult `json:"check,omitempty"`
+	ID     string       `json:"id"`
+	Type   JobType      `json:"type"`
+	Pool   string       `json:"pool"`
+	Volume string       `json:"volume"`
+	Host   string       `json:"host,omitempty"`
+	// Owner is the ID of the user who started the job
+	Owner string `json:"owner,omitempty"`
+	// TargetPool and TargetVolume name the volume a clone or conversion
+	// creates, or the new backing volume of a rebase
+	TargetPool   string `json:"targetPool,omitempty"`
+	TargetVolume string `json:"targetVolume,omitempty"`
+	Status       Status `json:"status"`
+	Error        string `json:"error,omitempty"`
+	Progress     int    `json:"progress"`
+}
+
+// CloneParams holds the parameters of a volume clone.
+type CloneParams struct {
+	// Name is the name of the new volume
+	Name string `json:"name" binding:"required"`
+	// Pool receives the new volume; defaults to the pool of the source
+	Pool string `json:"pool,omitempty"`
+	// Format of the new volume; defaults to the format of the source
+	Format string `json:"format,omitempty"`
+}
+
+// CheckParams holds the parameters of a volume check.
+type CheckParams struct {
+	// Repair fixes "leaks" or "all" errors found; the check only reports
+	// them when empty
+	Repair string `json:"repair,omitempty"`
+}
+
+// CheckResult is what qemu-img check found in the image of a volume.
+type CheckResult struct {
+	// CheckErrors counts the parts of the image that could not be checked
+	CheckErrors int `json:"checkErrors"`
+	Corruptions int `json:"corruptions"`
+	// Leaks counts clusters that are allocated but not used
+	Leaks            int `json:"leaks"`
+	CorruptionsFixed int `json:"corruptionsFixed"`
+	LeaksFixed       int `json:"leaksFixed"`
+	// Cluster statistics are reported for qcow2 images
+	TotalClusters      uint64 `json:"totalClusters,omitempty"`
+	AllocatedClusters  uint64 `json:"allocatedClusters,omitempty"`
+	FragmentedClusters uint64 `json:"fragmentedClusters,omitempty"`
+	ImageEndOffset     uint64 `json:"imageEndOffset,omitempty"`
+}
+
+// ConvertParams holds the parameters of a volume conversion.
+type ConvertParams struct {
+	// Name is the name of the new volume
+	Name string `json:"name" binding:"required"`
+	// Format of the new volume: qcow2, raw, vmdk, vdi or vhdx
+	Format string `json:"format" binding:"required"`
+	// Pool receives the new volume; defaults to the pool of the source
+	Pool string `json:"pool,omitempty"`
+	// Compress compresses the data of qcow2 volumes
+	Compress bool `json:"compress,omitempty"`
+}
+
+// RebaseParams holds the parameters of a volume rebase.
+type RebaseParams struct {
+	// BackingPool holds the new backing volume; defaults to the pool of
+	// the volume
+	BackingPool string `json:"backingPool,omitempty"`
+	// BackingVolume is the new backing volume. When empty, the backing
+	// chain is merged into the volume, which no longer has a backing file.
+	BackingVolume string `json:"backingVolume,omitempty"`
+}
+
+// ImageInfo describes the image of a volume as qemu-img reports it.
+type ImageInfo struct {
+	// BackingChain lists the backing images, nearest first
+	BackingChain    []ImageInfo `json:"backingChain,omitempty"`
+	Filename        string      `json:"filename"`
+	Format          string      `json:"format"`
+	BackingFilename string      `json:"backingFilename,omitempty"`
+	BackingFormat   string      `json:"backingFormat,omitempty"`
+	// Compat is the version of qcow2 images, "0.10" or "1.1"
+	Compat      string `json:"compat,omitempty"`
+	VirtualSize uint64 `json:"virtualSize"`
+	ActualSize  uint64 `json:"actualSize"`
+	ClusterSize uint64 `json:"clusterSize,omitempty"`
+	// DirtyFlag is set for images that were not closed cleanly, such as
+	// qcow2 images with lazy refcounts
+	DirtyFlag bool `json:"dirtyFlag"`
+	// Corrupt is set for qcow2 images that qemu found inconsistent
+	Corrupt   bool `json:"corrupt"`
+	Encrypted bool `json:"encrypted"`
+}
+
+// ResizeParams holds the parameters of a volume resize.
+type ResizeParams struct {
+	// CapacityBytes is the new capacity of the volume
+	CapacityBytes uint64 `json:"capacityBytes" binding:"required"`
+	// Force allows shrinking the volume, which discards the data past the
+	// new capacity
+	Force bool `json:"force,omitempty"`
+	// GrowFilesystem grows the guest filesystems on the volume through the
+	// guest agent when the volume is a disk of a running VM
+	GrowFilesystem bool `json:"growFilesystem,omitempty"`
+}
+
+// ResizeResult describes a completed resize.
+type ResizeResult struct {
+	Pool             string `json:"pool"`
+	Volume           string `json:"volume"`
+	PreviousCapacity uint64 `json:"previousCapacity"`
+	Capacity         uint64 `json:"capacity"`
+	// VM is the VM the volume is a disk of, if any
+	VM string `json:"vm,omitempty"`
+	// Live is set when the disk of a running VM was resized and the guest
+	// notified of the new size
+	Live bool `json:"live"`
+	// Filesystems are the guest filesystems on the disk of a running VM.
+	// Unless the resize grew them, they can be grown by repeating the
+	// request with growFilesystem set.
+	Filesystems []Filesystem `json:"filesystems,omitempty"`
+	// FilesystemError tells why the guest filesystems could not be listed
+	FilesystemError string `json:"filesystemError,omitempty"`
+}
+
+// Filesystem is a guest filesystem on a resized disk.
+type Filesystem struct {
+	Mountpoint string `json:"mountpoint"`
+	// Device is the guest device holding the filesystem
+	Device string `json:"device"`
+	Type   string `json:"type"`
+	Grown  bool   `json:"grown"`
+	Error  string `json:"error,omitempty"`
+}
+
+// KeyRotation describes a completed key rotation.
+type KeyRotation struct {
+	RotatedAt time.Time `json:"rotatedAt"`
+	Pool      string    `json:"pool"`
+	Volume    string    `json:"volume"`
+	// SecretUUID is the libvirt secret holding the new passphrase
+	SecretUUID string `json:"secretUUID"`
+}
+
+// DownloadOptions holds the options of a volume download.
+type DownloadOptions struct {
+	// Format converts the volume to qcow2, raw, vmdk, vdi, vhdx or vpc
+	// before it is sent; the volume is sent as stored when empty
+	Format string
+}
+
+// DownloadInfo describes the data of a download.
+type DownloadInfo struct {
+	// Filename is the suggested name of the downloaded file
+	Filename string
+	Format   string
+	// Size is the number of bytes in the download
+	Size int64
+}
+
+// Download is an opened volume download.
+type Download interface {
+	// Info describes the data of the download
+	Info() DownloadInfo
+
+	// WriteRange writes length bytes starting at offset to w
+	WriteRange(ctx context.Context, w io.Writer, offset int64, length int64) error
+
+	// Close releases the download, removing converted data
+	Close() error
+}
+
+// Manager defines the interface for volume downloads, resizes, clones,
+// wipes, key rotations and image operations.
+type Manager interface {
+	// OpenDownload prepares the download of a volume, converting it when
+	// the options ask for another format
+	OpenDownload(ctx context.Context, pool string, volume string, opts DownloadOptions) (Download, error)
+
+	// Resize changes the capacity of a volume. Disks of running VMs are
+	// resized live.
+	Resize(ctx context.Context, pool string, volume string, params ResizeParams) (*ResizeResult, error)
+
+	// StartClone starts copying a volume into a new volume
+	StartClone(ctx context.Context, pool string, volume string, params CloneParams) (*Job, error)
+
+	// StartWipe starts overwriting the data of a volume with zeros
+	StartWipe(ctx context.Context, pool string, volume string) (*Job, error)
+
+	// GetJob gets a volume job by ID
+	GetJob(ctx context.Context, id string) (*Job, error)
+
+	// ListJobs lists all volume jobs
+	ListJobs(ctx context.Context) ([]*Job, error)
+
+	// CancelJob cancels a running job. The new volume of a clone is
+	// deleted once libvirt finished copying. Wipes and repairs cannot be
+	// canceled.
+	CancelJob(ctx context.Context, id string) error
+
+	// RotateKey replaces the LUKS passphrase of an encrypted volume with a
+	// new one. The volume must not be a disk of a running VM.
+	RotateKey(ctx context.Context, pool string, volume string) (*KeyRotation, error)
+
+	// ImageInfo describes the image of a volume and its backing chain
+	ImageInfo(ctx context.Context, pool string, volume string) (*ImageInfo, error)
+
+	// StartCheck starts checking the image of a volume for errors,
+	// repairing them when asked
+	StartCheck(ctx context.Context, pool string, volume string, params CheckParams) (*Job, error)
+
+	// StartConvert starts converting a volume into a new volume of another
+	// format
+	StartConvert(ctx context.Context, pool string, volume string, params ConvertParams) (*Job, error)
+
+	// StartRebase starts moving a qcow2 volume onto another backing volume,
+	// or merging its backing chain into it
+	StartRebase(ctx context.Context, pool string, volume string, params RebaseParams) (*Job, error)
+}
@@ -0,0 +1,429 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/jobs"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/domain"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// cloneProgressInterval is how often the size of a volume being cloned is
+// checked to report progress.
+var cloneProgressInterval = 2 * time.Second
+
+// cloneFormats lists the formats a volume can be cloned into.
+var cloneFormats = map[string]bool{
+	"qcow2": true,
+	"raw":   true,
+}
+
+// Config holds volume manager configuration.
+type Config struct {
+	// ScratchDir receives volumes converted for download
+	ScratchDir string
+}
+
+// VolumeManager implements Manager.
+type VolumeManager struct {
+	jobs *jobs.Store[jobState]
+	// rotating holds the volumes whose key is being rotated; mu guards it
+	rotating       map[string]bool
+	storageManager storage.VolumeManager
+	domainManager  domain.Manager
+	// keys is nil when volume encryption is not configured
+	keys   storage.VolumeKeyManager
+	hosts  connection.HostLocator
+	logger logger.Logger
+	config Config
+	mu     sync.Mutex
+}
+
+// jobState is a job together with the state needed to cancel it.
+type jobState struct {
+	job Job
+	// cancel stops the qemu-img process of an image job; nil for jobs
+	// that cannot be stopped
+	cancel context.CancelFunc
+	// canceled is set when a job was canceled while it still runs, such
+	// as a clone libvirt copies
+	canceled bool
+}
+
+// attachment is a VM using a volume as a disk.
+type attachment struct {
+	vm      string
+	device  string
+	running bool
+}
+
+// NewVolumeManager creates a new VolumeManager. keys may be nil, which
+// disables key rotation. Operations that run qemu-img on volume paths are
+// limited to the local host hosts reports.
+func NewVolumeManager(storageManager storage.VolumeManager, domainManager domain.Manager, keys storage.VolumeKeyManager, hosts connection.HostLocator, config Config, logger logger.Logger) *VolumeManager {
+	return &VolumeManager{
+		jobs:           jobs.NewStore[jobState](),
+		rotating:       make(map[string]bool),
+		storageManager: storageManager,
+		domainManager:  domainManager,
+		keys:           keys,
+		hosts:          hosts,
+		config:         config,
+		logger:         logger,
+	}
+}
+
+// StartClone implements Manager.StartClone.
+func (m *VolumeManager) StartClone(ctx context.Context, pool string, volume string, params CloneParams) (*Job, error) {
+	if params.Pool == "" {
+		params.Pool = pool
+	}
+	if err := validateVolumeName(params.Name); err != nil {
+		return nil, err
+	}
+	if params.Format != "" && !cloneFormats[params.Format] {
+		return nil, fmt.Errorf("%w: unsupported volume format %q", errors.ErrInvalidParameter, params.Format)
+	}
+
+	source, err := m.storageManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting source volume: %w", err)
+	}
+
+	// Clones never replace existing volumes
+	_, err = m.storageManager.GetInfo(ctx, params.Pool, params.Name)
+	if err == nil {
+		return nil, fmt.Errorf("%w: volume %s in pool %s", errors.ErrAlreadyExists, params.Name, params.Pool)
+	}
+	if !errors.Is(err, storage.ErrVolumeNotFound) {
+		return nil, fmt.Errorf("checking target volume: %w", err)
+	}
+
+	// A copy of a disk the guest is writing to would be inconsistent
+	if err := m.checkNotRunning(ctx, source); err != nil {
+		return nil, err
+	}
+
+	job, err := m.createJob(ctx, JobTypeClone, pool, volume, params.Pool, params.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The clone outlives the request but keeps its values, such as the
+	// selected libvirt host
+	jobCtx := storage.WithVolumeOrigin(context.WithoutCancel(ctx), storage.VolumeOrigin{Job: job.ID})
+	go m.runClone(jobCtx, job.ID, pool, volume, source.Allocation, params)
+
+	m.logger.Info("Started volume clone",
+		logger.String("job_id", job.ID),
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.String("target_pool", params.Pool),
+		logger.String("target_volume", params.Name))
+
+	return job, nil
+}
+
+// StartWipe implements Manager.StartWipe.
+func (m *VolumeManager) StartWipe(ctx context.Context, pool string, volume string) (*Job, error) {
+	info, err := m.storageManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume: %w", err)
+	}
+
+	if err := m.checkNotRunning(ctx, info); err != nil {
+		return nil, err
+	}
+
+	job, err := m.createJob(ctx, JobTypeWipe, pool, volume, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	go m.runWipe(context.WithoutCancel(ctx), job.ID, pool, volume)
+
+	m.logger.Info("Started volume wipe",
+		logger.String("job_id", job.ID),
+		logger.String("pool", pool),
+		logger.String("volume", volume))
+
+	return job, nil
+}
+
+// GetJob implements Manager.GetJob.
+func (m *VolumeManager) GetJob(ctx context.Context, id string) (*Job, error) {
+	state, exists := m.jobs.Get(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errors.ErrVolumeJobNotFound, id)
+	}
+
+	return &state.job, nil
+}
+
+// ListJobs implements Manager.ListJobs.
+func (m *VolumeManager) ListJobs(ctx context.Context) ([]*Job, error) {
+	states := m.jobs.List()
+
+	result := make([]*Job, 0, len(states))
+	for _, state := range states {
+		result = append(result, &state.job)
+	}
+
+	return result, nil
+}
+
+// CancelJob implements Manager.CancelJob.
+func (m *VolumeManager) CancelJob(ctx context.Context, id string) error {
+	var (
+		job Job
+		err error
+	)
+	exists := m.jobs.Update(id, func(state *jobState) {
+		job = state.job
+		if state.job.Status.isFinal() {
+			err = fmt.Errorf("%w: cannot cancel job in %s state", errors.ErrVolumeJobInvalidState, state.job.Status)
+			return
+		}
+		if state.job.Type != JobTypeClone && state.cancel == nil {
+			err = fmt.Errorf("%w: %s jobs cannot be canceled", errors.ErrVolumeJobInvalidState, state.job.Type)
+			return
+		}
+
+		// Libvirt cannot interrupt a clone, so the new volume is deleted
+		// once the copy finished. qemu-img is stopped.
+		state.canceled = true
+		if state.cancel != nil {
+			state.cancel()
+		}
+		m.finish(state, StatusCanceled, nil)
+	})
+	if !exists {
+		return fmt.Errorf("%w: %s", errors.ErrVolumeJobNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Volume job canceled",
+		logger.String("job_id", id),
+		logger.String("type", string(job.Type)),
+		logger.String("target_volume", job.TargetVolume))
+
+	return nil
+}
+
+// createJob records a running job, refusing to start one on a volume that
+// another job uses.
+func (m *VolumeManager) createJob(ctx context.Context, jobType JobType, pool, volume, targetPool, targetVolume string) (*Job, error) {
+	host, _ := connection.HostFromContext(ctx)
+
+	job := Job{
+		ID:           uuid.New().String(),
+		Type:         jobType,
+		Pool:         pool,
+		Volume:       volume,
+		Host:         host,
+		Owner:        storage.VolumeOriginFromContext(ctx).Owner,
+		TargetPool:   targetPool,
+		TargetVolume: targetVolume,
+		Status:       StatusRunning,
+		StartTime:    time.Now(),
+	}
+
+	state, err := m.jobs.Add(job.ID, &jobState{job: job}, func(id string, other *jobState) error {
+		if other.uses(host, pool, volume) {
+			return fmt.Errorf("%w: volume %s is used by job %s", errors.ErrVolumeInUse, volume, id)
+		}
+		if targetVolume != "" && other.uses(host, targetPool, targetVolume) {
+			return fmt.Errorf("%w: volume %s is used by job %s", errors.ErrVolumeInUse, targetVolume, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &state.job, nil
+}
+
+// activeJob returns the ID of a running job reading or writing a volume.
+func (m *VolumeManager) activeJob(host, pool, volume string) (string, bool) {
+	return m.jobs.Find(func(state *jobState) bool {
+		return state.uses(host, pool, volume)
+	})
+}
+
+// uses reports whether a job reads or writes a volume. Canceled jobs count
+// until they stopped, such as clones until libvirt finished copying.
+func (s *jobState) uses(host, pool, volume string) bool {
+	job := s.job
+	if job.Host != host || (job.Status.isFinal() && !s.canceled) {
+		return false
+	}
+	return (job.Pool == pool && job.Volume == volume) || (job.TargetPool == pool && job.TargetVolume == volume)
+}
+
+// runClone copies a volume, reporting progress from the allocation of the
+// new volume.
+func (m *VolumeManager) runClone(ctx context.Context, id, pool, volume string, sourceAllocation uint64, params CloneParams) {
+	done := make(chan error, 1)
+	go func() {
+		done <- m.storageManager.CloneTo(ctx, pool, volume, params.Pool, params.Name, params.Format)
+	}()
+
+	ticker := time.NewTicker(cloneProgressInterval)
+	defer ticker.Stop()
+
+	var err error
+	for running := true; running; {
+		select {
+		case err = <-done:
+			running = false
+		case <-ticker.C:
+			target, infoErr := m.storageManager.GetInfo(ctx, params.Pool, params.Name)
+			if infoErr == nil && sourceAllocation > 0 {
+				m.updateProgress(id, int(target.Allocation*100/sourceAllocation))
+			}
+		}
+	}
+
+	canceled := false
+	m.jobs.Update(id, func(state *jobState) {
+		canceled = state.canceled
+		state.canceled = false
+		if !canceled {
+			if err != nil {
+				m.finish(state, StatusFailed, err)
+			} else {
+				m.finish(state, StatusCompleted, nil)
+			}
+		}
+	})
+
+	switch {
+	case canceled && err == nil:
+		if deleteErr := m.storageManager.Delete(ctx, params.Pool, params.Name); deleteErr != nil {
+			m.logger.Warn("Failed to delete volume of canceled clone",
+				logger.String("job_id", id),
+				logger.String("volume", params.Name),
+				logger.Error(deleteErr))
+		}
+	case err != nil:
+		m.logger.Error("Volume clone failed",
+			logger.String("job_id", id),
+			logger.String("volume", volume),
+			logger.Error(err))
+	case !canceled:
+		m.logger.Info("Volume clone completed",
+			logger.String("job_id", id),
+			logger.String("target_pool", params.Pool),
+			logger.String("target_volume", params.Name))
+	}
+}
+
+// runWipe wipes a volume. Libvirt does not report the progress of a wipe.
+func (m *VolumeManager) runWipe(ctx context.Context, id, pool, volume string) {
+	err := m.storageManager.Wipe(ctx, pool, volume)
+
+	m.jobs.Update(id, func(state *jobState) {
+		if err != nil {
+			m.finish(state, StatusFailed, err)
+		} else {
+			m.finish(state, StatusCompleted, nil)
+		}
+	})
+
+	if err != nil {
+		m.logger.Error("Volume wipe failed",
+			logger.String("job_id", id),
+			logger.String("volume", volume),
+			logger.Error(err))
+		return
+	}
+
+	m.logger.Info("Volume wipe completed",
+		logger.String("job_id", id),
+		logger.String("pool", pool),
+		logger.String("volume", volume))
+}
+
+// updateProgress records the progress of a running job.
+func (m *VolumeManager) updateProgress(id string, progress int) {
+	m.jobs.Update(id, func(state *jobState) {
+		if !state.job.Status.isFinal() {
+			state.job.Progress = min(max(progress, 0), 99)
+		}
+	})
+}
+
+// finish records the final status of a job. The caller updates the job in
+// the store.
+func (m *VolumeManager) finish(state *jobState, status Status, err error) {
+	state.job.Status = status
+	if err != nil {
+		state.job.Error = err.Error()
+	}
+	if status == StatusCompleted {
+		state.job.Progress = 100
+	}
+	state.job.EndTime = time.Now()
+}
+
+// findAttachment finds the VM using a volume as a disk, returning nil if
+// no VM uses it.
+func (m *VolumeManager) findAttachment(ctx context.Context, path string) (*attachment, error) {
+	vms, err := m.domainManager.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing VMs: %w", err)
+	}
+
+	for _, v := range vms {
+		for _, disk := range v.Disks {
+			if disk.Path != path {
+				continue
+			}
+			return &attachment{
+				vm:      v.Name,
+				device:  disk.Device,
+				running: v.Status == vm.VMStatusRunning || v.Status == vm.VMStatusPaused,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkNotRunning fails if a volume is a disk of a running VM.
+func (m *VolumeManager) checkNotRunning(ctx context.Context, info *storage.StorageVolumeInfo) error {
+	att, err := m.findAttachment(ctx, info.Path)
+	if err != nil {
+		return err
+	}
+	if att != nil && att.running {
+		return fmt.Errorf("%w: volume %s is a disk of running VM %s", errors.ErrVolumeInUse, info.Name, att.vm)
+	}
+
+	return nil
+}
+
+// validateVolumeName rejects names that are not plain file names.
+func validateVolumeName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("%w: invalid volume name %q", errors.ErrInvalidParameter, name)
+	}
+	return nil
+}
+
+// isFinal reports whether a job in this status has finished.
+func (s Status) isFinal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCanceled
+}
@@ -0,0 +1,333 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/libvirt/storage"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/utils/exec"
+	mocks_domain "github.com/threatflux/libgo/test/mocks/libvirt/domain"
+	mocks_storage "github.com/threatflux/libgo/test/mocks/libvirt/storage"
+	"github.com/threatflux/libgo/test/testutil"
+)
+
+// volumeTestEnv holds the mocks used by the volume manager tests.
+type volumeTestEnv struct {
+	manager *VolumeManager
+	volumes *mocks_storage.MockVolumeManager
+	domains *mocks_domain.MockManager
+	keys    *mocks_storage.MockVolumeKeyManager
+}
+
+func newVolumeTestEnv(t *testing.T) *volumeTestEnv {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := testutil.NewLogger(ctrl)
+
+	env := &volumeTestEnv{
+		volumes: mocks_storage.NewMockVolumeManager(ctrl),
+		domains: mocks_domain.NewMockManager(ctrl),
+		keys:    mocks_storage.NewMockVolumeKeyManager(ctrl),
+	}
+	env.manager = NewVolumeManager(env.volumes, env.domains, env.keys, nil, Config{ScratchDir: t.TempDir()}, mockLogger)
+
+	return env
+}
+
+// expectVolume makes a volume exist.
+func (env *volumeTestEnv) expectVolume(name string, info storage.StorageVolumeInfo) {
+	info.Name = name
+	info.Pool = "default"
+	info.Path = "/var/lib/libvirt/images/" + name
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", name).Return(&info, nil).AnyTimes()
+}
+
+// expectVMs makes the VMs list return a VM using a volume as disk vdb.
+func (env *volumeTestEnv) expectVMs(volume string, status vm.VMStatus) {
+	env.domains.EXPECT().List(gomock.Any()).Return([]*vm.VM{
+		{Name: "other", Status: vm.VMStatusRunning, Disks: []vm.DiskInfo{{Path: "/var/lib/libvirt/images/os", Device: "vda"}}},
+		{Name: "web", Status: status, Disks: []vm.DiskInfo{
+			{Path: "/var/lib/libvirt/images/web-os", Device: "vda"},
+			{Path: "/var/lib/libvirt/images/" + volume, Device: "vdb"},
+		}},
+	}, nil).AnyTimes()
+}
+
+// waitForJob waits until a job finished.
+func waitForJob(t *testing.T, manager *VolumeManager, id string) *Job {
+	t.Helper()
+
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = manager.GetJob(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status.isFinal()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return job
+}
+
+func TestVolumeManager_Resize(t *testing.T) {
+	t.Run("Shrinking needs force", func(t *testing.T) {
+		env := newVolumeTestEnv(t)
+		env.expectVolume("data", storage.StorageVolumeInfo{Capacity: 10 << 30})
+
+		_, err := env.manager.Resize(context.Background(), "default", "data", ResizeParams{CapacityBytes: 5 << 30})
+		assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+		env.expectVMs("other-data", vm.VMStatusRunning)
+		env.volumes.EXPECT().Resize(gomock.Any(), "default", "data", uint64(5<<30)).Return(nil)
+
+		result, err := env.manager.Resize(context.Background(), "default", "data", ResizeParams{CapacityBytes: 5 << 30, Force: true})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10<<30), result.PreviousCapacity)
+		assert.False(t, result.Live)
+		assert.Empty(t, result.VM)
+	})
+
+	t.Run("Disk of stopped VM", func(t *testing.T) {
+		env := newVolumeTestEnv(t)
+		env.expectVolume("data", storage.StorageVolumeInfo{Capacity: 10 << 30})
+		env.expectVMs("data", vm.VMStatusStopped)
+		env.volumes.EXPECT().Resize(gomock.Any(), "default", "data", uint64(20<<30)).Return(nil)
+
+		result, err := env.manager.Resize(context.Background(), "default", "data", ResizeParams{CapacityBytes: 20 << 30, GrowFilesystem: true})
+		require.NoError(t, err)
+		assert.Equal(t, "web", result.VM)
+		assert.False(t, result.Live)
+		assert.Empty(t, result.Filesystems)
+	})
+
+	t.Run("Disk of running VM", func(t *testing.T) {
+		env := newVolumeTestEnv(t)
+		env.expectVolume("data", storage.StorageVolumeInfo{Capacity: 10 << 30})
+		env.expectVMs("data", vm.VMStatusRunning)
+		env.domains.EXPECT().ResizeDisk(gomock.Any(), "web", "vdb", uint64(20<<30)).Return(nil)
+		env.domains.EXPECT().GetFilesystems(gomock.Any(), "web").Return([]vm.GuestFilesystem{
+			{Mountpoint: "/", Name: "vda1", Type: "ext4", Disks: []string{"vda"}},
+			{Mountpoint: "/srv", Name: "vdb1", Type: "xfs", Disks: []string{"vdb"}},
+			{Mountpoint: "/var/lib/pgsql", Name: "dm-0", Type: "ext4", Disks: []string{"vdb"}},
+		}, nil)
+		env.domains.EXPECT().GuestExec(gomock.Any(), "web", gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, command vm.GuestCommand) (*vm.GuestCommandResult, error) {
+				// Guest names are passed as arguments, not in the script
+				assert.Equal(t, []string{"vdb1", "xfs", "/srv"}, command.Command[4:])
+				return &vm.GuestCommandResult{}, nil
+			})
+
+		result, err := env.manager.Resize(context.Background(), "default", "data", ResizeParams{CapacityBytes: 20 << 30, GrowFilesystem: true})
+		require.NoError(t, err)
+		assert.Equal(t, "web", result.VM)
+		assert.True(t, result.Live)
+		require.Len(t, result.Filesystems, 2)
+		assert.Equal(t, Filesystem{Mountpoint: "/srv", Device: "vdb1", Type: "xfs", Grown: true}, result.Filesystems[0])
+		assert.False(t, result.Filesystems[1].Grown)
+		assert.Contains(t, result.Filesystems[1].Error, "device-mapper")
+
+		// Running VMs keep their disk size
+		_, err = env.manager.Resize(context.Background(), "default", "data", ResizeParams{CapacityBytes: 5 << 30, Force: true})
+		assert.ErrorIs(t, err, errors.ErrVolumeInUse)
+	})
+
+	t.Run("Filesystems are offered without the guest agent", func(t *testing.T) {
+		env := newVolumeTestEnv(t)
+		env.expectVolume("data", storage.StorageVolumeInfo{Capacity: 10 << 30})
+		env.expectVMs("data", vm.VMStatusRunning)
+		env.domains.EXPECT().ResizeDisk(gomock.Any(), "web", "vdb", uint64(20<<30)).Return(nil)
+		env.domains.EXPECT().GetFilesystems(gomock.Any(), "web").Return(nil, fmt.Errorf("guest agent is not responding"))
+
+		result, err := env.manager.Resize(context.Background(), "default", "data", ResizeParams{CapacityBytes: 20 << 30})
+		require.NoError(t, err)
+		assert.True(t, result.Live)
+		assert.Contains(t, result.FilesystemError, "guest agent")
+	})
+}
+
+func TestVolumeManager_Clone(t *testing.T) {
+	original := cloneProgressInterval
+	cloneProgressInterval = 5 * time.Millisecond
+	t.Cleanup(func() { cloneProgressInterval = original })
+
+	env := newVolumeTestEnv(t)
+	env.expectVolume("base", storage.StorageVolumeInfo{Format: "qcow2", Allocation: 100})
+	env.expectVolume("existing", storage.StorageVolumeInfo{})
+	env.expectVMs("base", vm.VMStatusStopped)
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", "../copy").Times(0)
+
+	_, err := env.manager.StartClone(context.Background(), "default", "base", CloneParams{Name: "../copy"})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	_, err = env.manager.StartClone(context.Background(), "default", "base", CloneParams{Name: "existing"})
+	assert.ErrorIs(t, err, errors.ErrAlreadyExists)
+
+	// The clone reports progress from the allocation of the new volume
+	release := make(chan struct{})
+	var copied uint64
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", "copy").DoAndReturn(
+		func(_ context.Context, _ string, _ string) (*storage.StorageVolumeInfo, error) {
+			if copied == 0 {
+				return nil, fmt.Errorf("volume: %w", storage.ErrVolumeNotFound)
+			}
+			return &storage.StorageVolumeInfo{Allocation: copied}, nil
+		}).AnyTimes()
+	env.volumes.EXPECT().CloneTo(gomock.Any(), "default", "base", "default", "copy", "raw").DoAndReturn(
+		func(_ context.Context, _, _, _, _, _ string) error {
+			<-release
+			return nil
+		})
+
+	job, err := env.manager.StartClone(context.Background(), "default", "base", CloneParams{Name: "copy", Format: "raw"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, job.Status)
+	assert.Equal(t, "copy", job.TargetVolume)
+
+	env.manager.mu.Lock()
+	copied = 40
+	env.manager.mu.Unlock()
+
+	require.Eventually(t, func() bool {
+		job, err := env.manager.GetJob(context.Background(), job.ID)
+		require.NoError(t, err)
+		return job.Progress == 40
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Neither volume can be used by another job meanwhile
+	_, err = env.manager.StartWipe(context.Background(), "default", "base")
+	assert.ErrorIs(t, err, errors.ErrVolumeInUse)
+
+	close(release)
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusCompleted, job.Status, job.Error)
+	assert.Equal(t, 100, job.Progress)
+	assert.ErrorIs(t, env.manager.CancelJob(context.Background(), job.ID), errors.ErrVolumeJobInvalidState)
+}
+
+func TestVolumeManager_CancelClone(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("base", storage.StorageVolumeInfo{})
+	env.expectVMs("base", vm.VMStatusStopped)
+	env.volumes.EXPECT().GetInfo(gomock.Any(), "default", "copy").
+		Return(nil, fmt.Errorf("volume: %w", storage.ErrVolumeNotFound)).AnyTimes()
+
+	release := make(chan struct{})
+	env.volumes.EXPECT().CloneTo(gomock.Any(), "default", "base", "default", "copy", "").DoAndReturn(
+		func(_ context.Context, _, _, _, _, _ string) error {
+			<-release
+			return nil
+		})
+	deleted := make(chan struct{})
+	env.volumes.EXPECT().Delete(gomock.Any(), "default", "copy").DoAndReturn(
+		func(_ context.Context, _, _ string) error {
+			close(deleted)
+			return nil
+		})
+
+	job, err := env.manager.StartClone(context.Background(), "default", "base", CloneParams{Name: "copy"})
+	require.NoError(t, err)
+
+	require.NoError(t, env.manager.CancelJob(context.Background(), job.ID))
+	job, err = env.manager.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status)
+
+	// The target stays reserved until the copy finished and was deleted
+	_, err = env.manager.StartClone(context.Background(), "default", "base", CloneParams{Name: "copy"})
+	assert.ErrorIs(t, err, errors.ErrVolumeInUse)
+
+	close(release)
+	select {
+	case <-deleted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("clone of canceled job was not deleted")
+	}
+}
+
+func TestVolumeManager_Wipe(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("data", storage.StorageVolumeInfo{})
+	env.expectVolume("live", storage.StorageVolumeInfo{})
+	env.expectVMs("live", vm.VMStatusRunning)
+
+	_, err := env.manager.StartWipe(context.Background(), "default", "live")
+	assert.ErrorIs(t, err, errors.ErrVolumeInUse)
+
+	release := make(chan struct{})
+	env.volumes.EXPECT().Wipe(gomock.Any(), "default", "data").DoAndReturn(
+		func(_ context.Context, _, _ string) error {
+			<-release
+			return fmt.Errorf("wiping volume: I/O error")
+		})
+
+	job, err := env.manager.StartWipe(context.Background(), "default", "data")
+	require.NoError(t, err)
+	assert.Equal(t, JobTypeWipe, job.Type)
+
+	assert.ErrorIs(t, env.manager.CancelJob(context.Background(), job.ID), errors.ErrVolumeJobInvalidState)
+
+	close(release)
+	job = waitForJob(t, env.manager, job.ID)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Contains(t, job.Error, "I/O error")
+
+	_, err = env.manager.GetJob(context.Background(), "missing")
+	assert.ErrorIs(t, err, errors.ErrVolumeJobNotFound)
+}
+
+func TestVolumeManager_OpenDownload(t *testing.T) {
+	env := newVolumeTestEnv(t)
+	env.expectVolume("disk.qcow2", storage.StorageVolumeInfo{Format: "qcow2", Capacity: 10 << 30, Physical: 4096})
+	env.expectVMs("other-data", vm.VMStatusRunning)
+
+	// Volumes are sent as stored by default
+	download, err := env.manager.OpenDownload(context.Background(), "default", "disk.qcow2", DownloadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, DownloadInfo{Filename: "disk.qcow2", Format: "qcow2", Size: 4096}, download.Info())
+
+	env.volumes.EXPECT().DownloadRange(gomock.Any(), "default", "disk.qcow2", uint64(512), uint64(1024), gomock.Any()).Return(nil)
+	require.NoError(t, download.WriteRange(context.Background(), &bytes.Buffer{}, 512, 1024))
+	require.NoError(t, download.Close())
+
+	_, err = env.manager.OpenDownload(context.Background(), "default", "disk.qcow2", DownloadOptions{Format: "iso"})
+	assert.ErrorIs(t, err, errors.ErrInvalidParameter)
+
+	// Other formats are converted into a scratch file
+	testutil.StubCommands(t, func(_ context.Context, name string, args []string, _ exec.CommandOptions) ([]byte, error) {
+		assert.Equal(t, "qemu-img", name)
+		assert.Equal(t, []string{"convert", "-f", "qcow2", "-O", "vmdk", "/var/lib/libvirt/images/disk.qcow2"}, args[:len(args)-1])
+		return nil, os.WriteFile(args[len(args)-1], []byte("converted vmdk"), 0o600)
+	})
+
+	download, err = env.manager.OpenDownload(context.Background(), "default", "disk.qcow2", DownloadOptions{Format: "vmdk"})
+	require.NoError(t, err)
+	assert.Equal(t, DownloadInfo{Filename: "disk.vmdk", Format: "vmdk", Size: 14}, download.Info())
+
+	var data bytes.Buffer
+	require.NoError(t, download.WriteRange(context.Background(), &data, 10, 4))
+	assert.Equal(t, "vmdk", data.String())
+
+	require.NoError(t, download.Close())
+	entries, err := os.ReadDir(env.manager.config.ScratchDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Remote volumes are only sent as stored
+	env.manager.hosts = testutil.RemoteHost{}
+	_, err = env.manager.OpenDownload(context.Background(), "default", "disk.qcow2", DownloadOptions{Format: "vmdk"})
+	assert.ErrorIs(t, err, connection.ErrRemoteHost)
+
+	download, err = env.manager.OpenDownload(context.Background(), "default", "disk.qcow2", DownloadOptions{})
+	require.NoError(t, err)
+	require.NoError(t, download.Close())
+}
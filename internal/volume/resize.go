@@ -0,0 +1,170 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/threatflux/libgo/internal/errors"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// growFilesystemScript grows a guest filesystem: its partition is grown to
+// the end of the disk with growpart, then the filesystem to the end of the
+// partition. The device, filesystem type and mountpoint are passed as
+// arguments so guest-supplied names are never interpreted by the shell.
+const growFilesystemScript = `set -e
+PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin
+device=$1 type=$2 mountpoint=$3
+if [ -f "/sys/class/block/$device/partition" ]; then
+	disk=$(basename "$(dirname "$(readlink -f "/sys/class/block/$device")")")
+	# growpart exits with 1 when the partition already fills the disk
+	growpart "/dev/$disk" "$(cat "/sys/class/block/$device/partition")" || [ $? -eq 1 ]
+fi
+case $type in
+ext2|ext3|ext4) resize2fs "/dev/$device" ;;
+xfs) xfs_growfs "$mountpoint" ;;
+btrfs) btrfs filesystem resize max "$mountpoint" ;;
+esac
+`
+
+// growableFilesystems lists the filesystem types grown after a resize.
+var growableFilesystems = map[string]bool{
+	"ext2":  true,
+	"ext3":  true,
+	"ext4":  true,
+	"xfs":   true,
+	"btrfs": true,
+}
+
+// Resize implements Manager.Resize.
+func (m *VolumeManager) Resize(ctx context.Context, pool string, volume string, params ResizeParams) (*ResizeResult, error) {
+	if params.CapacityBytes == 0 {
+		return nil, fmt.Errorf("%w: capacity must be positive", errors.ErrInvalidParameter)
+	}
+
+	info, err := m.storageManager.GetInfo(ctx, pool, volume)
+	if err != nil {
+		return nil, fmt.Errorf("getting volume: %w", err)
+	}
+
+	shrink := params.CapacityBytes < info.Capacity
+	if shrink && !params.Force {
+		return nil, fmt.Errorf("%w: shrinking volume %s from %d to %d bytes discards data; set force to shrink it",
+			errors.ErrInvalidParameter, volume, info.Capacity, params.CapacityBytes)
+	}
+
+	host, _ := connection.HostFromContext(ctx)
+	if id, busy := m.activeJob(host, pool, volume); busy {
+		return nil, fmt.Errorf("%w: volume %s is used by job %s", errors.ErrVolumeInUse, volume, id)
+	}
+
+	att, err := m.findAttachment(ctx, info.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResizeResult{
+		Pool:             pool,
+		Volume:           volume,
+		PreviousCapacity: info.Capacity,
+		Capacity:         params.CapacityBytes,
+	}
+	if att != nil {
+		result.VM = att.vm
+	}
+
+	switch {
+	case att != nil && att.running:
+		// Guests do not expect their disks to shrink
+		if shrink {
+			return nil, fmt.Errorf("%w: cannot shrink volume %s, a disk of running VM %s", errors.ErrVolumeInUse, volume, att.vm)
+		}
+		if params.CapacityBytes > info.Capacity {
+			if err := m.domainManager.ResizeDisk(ctx, att.vm, att.device, params.CapacityBytes); err != nil {
+				return nil, fmt.Errorf("resizing disk %s of %s: %w", att.device, att.vm, err)
+			}
+			result.Live = true
+		}
+		m.resizeFilesystems(ctx, att, params.GrowFilesystem, result)
+	case params.CapacityBytes != info.Capacity:
+		if err := m.storageManager.Resize(ctx, pool, volume, params.CapacityBytes); err != nil {
+			return nil, fmt.Errorf("resizing volume: %w", err)
+		}
+	}
+
+	m.logger.Info("Resized volume",
+		logger.String("pool", pool),
+		logger.String("volume", volume),
+		logger.Uint64("previous_capacity", info.Capacity),
+		logger.Uint64("capacity", params.CapacityBytes),
+		logger.String("vm", result.VM),
+		logger.Bool("live", result.Live))
+
+	return result, nil
+}
+
+// resizeFilesystems lists the guest filesystems on a resized disk of a
+// running VM and grows them if asked to.
+func (m *VolumeManager) resizeFilesystems(ctx context.Context, att *attachment, grow bool, result *ResizeResult) {
+	filesystems, err := m.domainManager.GetFilesystems(ctx, att.vm)
+	if err != nil {
+		m.logger.Warn("Failed to list guest filesystems",
+			logger.String("vm", att.vm),
+			logger.Error(err))
+		result.FilesystemError = err.Error()
+		return
+	}
+
+	for _, fs := range filesystems {
+		if !slices.Contains(fs.Disks, att.device) {
+			continue
+		}
+
+		entry := Filesystem{
+			Mountpoint: fs.Mountpoint,
+			Device:     fs.Name,
+			Type:       fs.Type,
+		}
+		if grow {
+			if err := m.growFilesystem(ctx, att.vm, fs); err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Grown = true
+			}
+		}
+		result.Filesystems = append(result.Filesystems, entry)
+	}
+}
+
+// growFilesystem grows a guest filesystem and its partition to the end of
+// the disk.
+func (m *VolumeManager) growFilesystem(ctx context.Context, vmName string, fs vm.GuestFilesystem) error {
+	if !growableFilesystems[fs.Type] {
+		return fmt.Errorf("%s filesystems cannot be grown", fs.Type)
+	}
+	// Logical volumes and encrypted devices need to be grown first
+	if strings.HasPrefix(fs.Name, "dm-") {
+		return fmt.Errorf("filesystems on device-mapper devices cannot be grown")
+	}
+
+	result, err := m.domainManager.GuestExec(ctx, vmName, vm.GuestCommand{
+		Command: []string{"/bin/sh", "-c", growFilesystemScript, "grow-filesystem", fs.Name, fs.Type, fs.Mountpoint},
+	})
+	if err != nil {
+		return fmt.Errorf("growing filesystem: %w", err)
+	}
+	if result.ExitCode != 0 || result.Signal != 0 {
+		return fmt.Errorf("growing filesystem failed with exit code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	m.logger.Info("Grew guest filesystem",
+		logger.String("vm", vmName),
+		logger.String("device", fs.Name),
+		logger.String("mountpoint", fs.Mountpoint))
+
+	return nil
+}
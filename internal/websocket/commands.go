@@ -0,0 +1,188 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/threatflux/libgo/internal/models/user"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// Time allowed for a command to complete.
+const commandTimeout = 2 * time.Minute
+
+// Command actions.
+const (
+	CommandStart     = "start"
+	CommandStop      = "stop"
+	CommandForceStop = "force-stop"
+	CommandRestart   = "restart"
+	CommandReboot    = "reboot"
+	CommandReset     = "reset"
+	CommandPause     = "pause"
+	CommandResume    = "resume"
+	CommandSnapshot  = "snapshot"
+	CommandSendKeys  = "send-keys"
+)
+
+// commandPermissions maps command actions to the permissions they require.
+// The REST power and snapshot routes require the same permissions.
+var commandPermissions = map[string][]string{
+	CommandStart:     {user.PermStart},
+	CommandStop:      {user.PermStop},
+	CommandForceStop: {user.PermStop},
+	CommandRestart:   {user.PermStop, user.PermStart},
+	CommandReboot:    {user.PermStop, user.PermStart},
+	CommandReset:     {user.PermStop, user.PermStart},
+	CommandPause:     {user.PermStop},
+	CommandResume:    {user.PermStart},
+	CommandSnapshot:  {user.PermUpdate},
+	CommandSendKeys:  {user.PermConsole},
+}
+
+// VMCommander is the interface for executing VM commands.
+type VMCommander interface {
+	Start(ctx context.Context, name string) error
+	Stop(ctx context.Context, name string) error
+	ForceStop(ctx context.Context, name string) error
+	Restart(ctx context.Context, name string, opts vmmodels.ShutdownOptions) (*vmmodels.PowerResult, error)
+	Reboot(ctx context.Context, name string) error
+	Reset(ctx context.Context, name string) error
+	Pause(ctx context.Context, name string) error
+	Resume(ctx context.Context, name string) error
+	CreateSnapshot(ctx context.Context, vmName string, params vmmodels.SnapshotParams) (*vmmodels.Snapshot, error)
+	SendKeys(ctx context.Context, name string, keys []string) error
+}
+
+// authorizeCommand checks that the client may execute the given action.
+func (h *Handler) authorizeCommand(client *Client, action string) error {
+	permissions, ok := commandPermissions[action]
+	if !ok {
+		return fmt.Errorf("unknown command '%s'", action)
+	}
+
+	if !h.authEnabled {
+		return nil
+	}
+
+	if !client.TokenExpiry.IsZero() && time.Now().After(client.TokenExpiry) {
+		return fmt.Errorf("authentication token expired")
+	}
+
+	for _, permission := range permissions {
+		if !user.UserHasPermission(client.Roles, permission) {
+			return fmt.Errorf("insufficient permissions for command '%s'", action)
+		}
+	}
+
+	return nil
+}
+
+// executeCommand runs a VM command and returns a human-readable result.
+func (h *Handler) executeCommand(ctx context.Context, vmName, action string, params map[string]interface{}) (string, error) {
+	if h.commander == nil {
+		return "", fmt.Errorf("commands are not supported")
+	}
+
+	var err error
+	switch action {
+	case CommandStart:
+		err = h.commander.Start(ctx, vmName)
+	case CommandStop:
+		err = h.commander.Stop(ctx, vmName)
+	case CommandForceStop:
+		err = h.commander.ForceStop(ctx, vmName)
+	case CommandRestart:
+		force, _ := params["force"].(bool)
+		_, err = h.commander.Restart(ctx, vmName, vmmodels.ShutdownOptions{Force: force})
+	case CommandReboot:
+		err = h.commander.Reboot(ctx, vmName)
+	case CommandReset:
+		err = h.commander.Reset(ctx, vmName)
+	case CommandPause:
+		err = h.commander.Pause(ctx, vmName)
+	case CommandResume:
+		err = h.commander.Resume(ctx, vmName)
+	case CommandSnapshot:
+		return h.executeSnapshot(ctx, vmName, params)
+	case CommandSendKeys:
+		return h.executeSendKeys(ctx, vmName, params)
+	default:
+		return "", fmt.Errorf("unknown command '%s'", action)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Command '%s' completed", action), nil
+}
+
+// executeSnapshot creates a snapshot from command parameters.
+func (h *Handler) executeSnapshot(ctx context.Context, vmName string, params map[string]interface{}) (string, error) {
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("snapshot name is required")
+	}
+
+	snapshotParams := vmmodels.SnapshotParams{Name: name}
+	if description, ok := params["description"].(string); ok {
+		snapshotParams.Description = description
+	}
+	if includeMemory, ok := params["includeMemory"].(bool); ok {
+		snapshotParams.IncludeMemory = includeMemory
+	}
+	if quiesce, ok := params["quiesce"].(bool); ok {
+		snapshotParams.Quiesce = quiesce
+	}
+
+	snapshot, err := h.commander.CreateSnapshot(ctx, vmName, snapshotParams)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Snapshot '%s' created", snapshot.Name), nil
+}
+
+// executeSendKeys sends a key combination from command parameters.
+func (h *Handler) executeSendKeys(ctx context.Context, vmName string, params map[string]interface{}) (string, error) {
+	rawKeys, ok := params["keys"].([]interface{})
+	if !ok || len(rawKeys) == 0 {
+		return "", fmt.Errorf("keys are required")
+	}
+
+	keys := make([]string, 0, len(rawKeys))
+	for _, rawKey := range rawKeys {
+		key, ok := rawKey.(string)
+		if !ok {
+			return "", fmt.Errorf("keys must be strings")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := h.commander.SendKeys(ctx, vmName, keys); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Sent %d keys", len(keys)), nil
+}
+
+// pushStatus sends the VM's current state to its clients after a command.
+func (h *Handler) pushStatus(ctx context.Context, client *Client) {
+	if h.monitor != nil {
+		h.monitor.RefreshVM(ctx, client.key())
+		return
+	}
+
+	vmInfo, err := h.vmManager.Get(ctx, client.VMName)
+	if err != nil {
+		h.logger.Warn("Failed to get VM status after command",
+			logger.String("vmName", client.VMName),
+			logger.Error(err))
+		return
+	}
+
+	h.SendVMStatus(client.key(), vmInfo.Status, time.Now(), 0)
+}
@@ -0,0 +1,289 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/models/user"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+)
+
+// fakeCommander records the commands it runs.
+type fakeCommander struct {
+	err      error
+	gate     chan struct{}
+	calls    []string
+	keys     []string
+	snapshot vmmodels.SnapshotParams
+	force    bool
+	mu       sync.Mutex
+}
+
+func (c *fakeCommander) record(call string) error {
+	if c.gate != nil {
+		<-c.gate
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, call)
+	return c.err
+}
+
+func (c *fakeCommander) recorded() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+func (c *fakeCommander) Start(ctx context.Context, name string) error {
+	return c.record("start " + name)
+}
+
+func (c *fakeCommander) Stop(ctx context.Context, name string) error {
+	return c.record("stop " + name)
+}
+
+func (c *fakeCommander) ForceStop(ctx context.Context, name string) error {
+	return c.record("force-stop " + name)
+}
+
+func (c *fakeCommander) Restart(ctx context.Context, name string, opts vmmodels.ShutdownOptions) (*vmmodels.PowerResult, error) {
+	c.force = opts.Force
+	return &vmmodels.PowerResult{}, c.record("restart " + name)
+}
+
+func (c *fakeCommander) Reboot(ctx context.Context, name string) error {
+	return c.record("reboot " + name)
+}
+
+func (c *fakeCommander) Reset(ctx context.Context, name string) error {
+	return c.record("reset " + name)
+}
+
+func (c *fakeCommander) Pause(ctx context.Context, name string) error {
+	return c.record("pause " + name)
+}
+
+func (c *fakeCommander) Resume(ctx context.Context, name string) error {
+	return c.record("resume " + name)
+}
+
+func (c *fakeCommander) CreateSnapshot(ctx context.Context, vmName string, params vmmodels.SnapshotParams) (*vmmodels.Snapshot, error) {
+	c.snapshot = params
+	return &vmmodels.Snapshot{Name: params.Name}, c.record("snapshot " + vmName)
+}
+
+func (c *fakeCommander) SendKeys(ctx context.Context, name string, keys []string) error {
+	c.keys = keys
+	return c.record("send-keys " + name)
+}
+
+func TestAuthorizeCommand(t *testing.T) {
+	handler := NewHandler(newFakeVMManager(), &fakeCommander{}, nopLogger{})
+	handler.authEnabled = true
+
+	tests := []struct {
+		name    string
+		client  *Client
+		action  string
+		wantErr string
+	}{
+		{
+			name:   "Operator starts a VM",
+			client: &Client{Roles: []string{user.RoleOperator}},
+			action: CommandStart,
+		},
+		{
+			name:   "Operator restarts a VM",
+			client: &Client{Roles: []string{user.RoleOperator}},
+			action: CommandRestart,
+		},
+		{
+			name:   "Admin sends keys",
+			client: &Client{Roles: []string{user.RoleAdmin}},
+			action: CommandSendKeys,
+		},
+		{
+			name:    "Viewer cannot stop a VM",
+			client:  &Client{Roles: []string{user.RoleViewer}},
+			action:  CommandStop,
+			wantErr: "insufficient permissions",
+		},
+		{
+			name:    "Viewer cannot take snapshots",
+			client:  &Client{Roles: []string{user.RoleViewer}},
+			action:  CommandSnapshot,
+			wantErr: "insufficient permissions",
+		},
+		{
+			name:    "No roles",
+			client:  &Client{},
+			action:  CommandResume,
+			wantErr: "insufficient permissions",
+		},
+		{
+			name:    "Expired token",
+			client:  &Client{Roles: []string{user.RoleAdmin}, TokenExpiry: time.Now().Add(-time.Minute)},
+			action:  CommandStart,
+			wantErr: "token expired",
+		},
+		{
+			name:    "Unknown command",
+			client:  &Client{Roles: []string{user.RoleAdmin}},
+			action:  "destroy",
+			wantErr: "unknown command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handler.authorizeCommand(tt.client, tt.action)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("Authentication disabled", func(t *testing.T) {
+		handler := NewHandler(newFakeVMManager(), &fakeCommander{}, nopLogger{})
+
+		assert.NoError(t, handler.authorizeCommand(&Client{}, CommandStop))
+		assert.Error(t, handler.authorizeCommand(&Client{}, "destroy"))
+	})
+}
+
+func TestExecuteCommand(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Power commands", func(t *testing.T) {
+		commander := &fakeCommander{}
+		handler := NewHandler(newFakeVMManager(), commander, nopLogger{})
+
+		actions := []string{CommandStart, CommandStop, CommandForceStop, CommandReboot,
+			CommandReset, CommandPause, CommandResume}
+		for _, action := range actions {
+			result, err := handler.executeCommand(ctx, "test-vm", action, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Command '"+action+"' completed", result)
+		}
+
+		_, err := handler.executeCommand(ctx, "test-vm", CommandRestart, map[string]interface{}{"force": true})
+		require.NoError(t, err)
+		assert.True(t, commander.force)
+
+		assert.Equal(t, []string{"start test-vm", "stop test-vm", "force-stop test-vm", "reboot test-vm",
+			"reset test-vm", "pause test-vm", "resume test-vm", "restart test-vm"}, commander.recorded())
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		commander := &fakeCommander{}
+		handler := NewHandler(newFakeVMManager(), commander, nopLogger{})
+
+		result, err := handler.executeCommand(ctx, "test-vm", CommandSnapshot, map[string]interface{}{
+			"name":          "before-upgrade",
+			"description":   "Before the upgrade",
+			"includeMemory": true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Snapshot 'before-upgrade' created", result)
+		assert.Equal(t, vmmodels.SnapshotParams{
+			Name:          "before-upgrade",
+			Description:   "Before the upgrade",
+			IncludeMemory: true,
+		}, commander.snapshot)
+
+		_, err = handler.executeCommand(ctx, "test-vm", CommandSnapshot, map[string]interface{}{})
+		assert.ErrorContains(t, err, "snapshot name is required")
+	})
+
+	t.Run("Send keys", func(t *testing.T) {
+		commander := &fakeCommander{}
+		handler := NewHandler(newFakeVMManager(), commander, nopLogger{})
+
+		result, err := handler.executeCommand(ctx, "test-vm", CommandSendKeys, map[string]interface{}{
+			"keys": []interface{}{"KEY_LEFTCTRL", "KEY_LEFTALT", "KEY_DELETE"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Sent 3 keys", result)
+		assert.Equal(t, []string{"KEY_LEFTCTRL", "KEY_LEFTALT", "KEY_DELETE"}, commander.keys)
+
+		_, err = handler.executeCommand(ctx, "test-vm", CommandSendKeys, map[string]interface{}{
+			"keys": []interface{}{"KEY_A", 1},
+		})
+		assert.ErrorContains(t, err, "keys must be strings")
+
+		_, err = handler.executeCommand(ctx, "test-vm", CommandSendKeys, nil)
+		assert.ErrorContains(t, err, "keys are required")
+	})
+
+	t.Run("Failures", func(t *testing.T) {
+		commander := &fakeCommander{err: errors.New("domain is not running")}
+		handler := NewHandler(newFakeVMManager(), commander, nopLogger{})
+
+		_, err := handler.executeCommand(ctx, "test-vm", CommandStop, nil)
+		assert.ErrorContains(t, err, "domain is not running")
+
+		_, err = handler.executeCommand(ctx, "test-vm", "destroy", nil)
+		assert.ErrorContains(t, err, "unknown command")
+
+		handler = NewHandler(newFakeVMManager(), nil, nopLogger{})
+		_, err = handler.executeCommand(ctx, "test-vm", CommandStart, nil)
+		assert.ErrorContains(t, err, "not supported")
+	})
+}
+
+func TestCommandsRunInBackground(t *testing.T) {
+	commander := &fakeCommander{gate: make(chan struct{})}
+	vmManager := newFakeVMManager()
+	handler, server := newWebSocketTestServer(t, vmManager)
+	handler.commander = commander
+
+	conn := dialWebSocket(t, server, "/ws/vms/test-vm/console")
+	readMessages(t, conn, MessageTypeConnection)
+
+	sendMessage(t, conn, MessageTypeCommand, map[string]interface{}{
+		"action":    CommandStop,
+		"requestId": "req-1",
+	})
+
+	// The connection keeps serving messages while the command runs
+	sendMessage(t, conn, MessageTypeHeartbeat, nil)
+	readMessages(t, conn, MessageTypeHeartbeat)
+	assert.Empty(t, commander.recorded())
+
+	close(commander.gate)
+	messages := readMessageTypes(t, conn, MessageTypeResponse, MessageTypeStatus)
+	assert.Equal(t, "req-1", messages[MessageTypeResponse].Data["requestId"])
+	assert.Equal(t, true, messages[MessageTypeResponse].Data["success"])
+	assert.Equal(t, []string{"stop test-vm"}, commander.recorded())
+
+	// The resulting state is pushed to the client
+	assert.Equal(t, string(vmmodels.VMStatusRunning), messages[MessageTypeStatus].Data["status"])
+}
+
+func TestCommandOutlivingClient(t *testing.T) {
+	commander := &fakeCommander{gate: make(chan struct{})}
+	handler, server := newWebSocketTestServer(t, newFakeVMManager())
+	handler.commander = commander
+
+	conn := dialWebSocket(t, server, "/ws/vms/test-vm")
+	readMessages(t, conn, MessageTypeConnection)
+
+	sendMessage(t, conn, MessageTypeCommand, map[string]interface{}{"action": CommandStart})
+	require.NoError(t, conn.Close())
+
+	// Replies after the client left are dropped
+	time.Sleep(50 * time.Millisecond)
+	close(commander.gate)
+	assert.Eventually(t, func() bool {
+		return len(commander.recorded()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
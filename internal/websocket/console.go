@@ -0,0 +1,190 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+const (
+	// Size of the buffer used to read console output.
+	consoleReadBufferSize = 4096
+
+	// Time allowed to open a console stream.
+	consoleOpenTimeout = 30 * time.Second
+)
+
+// consoleSession is a console stream shared by all console clients of a VM.
+type consoleSession struct {
+	stream vmmodels.ConsoleStream
+	err    error
+	// ready is closed once the stream is open or failed to open
+	ready   chan struct{}
+	clients int
+}
+
+// attachConsole attaches a client to the console session of its VM, opening
+// the session if this is the first console client. The stream is opened
+// without holding consolesLock, so consoles of other VMs are not blocked
+// while it opens.
+func (h *Handler) attachConsole(client *Client) error {
+	key := client.key()
+
+	h.consolesLock.Lock()
+	if session, exists := h.consoles[key]; exists {
+		session.clients++
+		h.consolesLock.Unlock()
+
+		<-session.ready
+		return session.err
+	}
+
+	session := &consoleSession{
+		ready:   make(chan struct{}),
+		clients: 1,
+	}
+	h.consoles[key] = session
+	h.consolesLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(hostContext(context.Background(), client.Host), consoleOpenTimeout)
+	defer cancel()
+
+	stream, err := h.vmManager.OpenConsole(ctx, client.VMName, vmmodels.ConsoleOptions{})
+
+	h.consolesLock.Lock()
+	defer h.consolesLock.Unlock()
+	defer close(session.ready)
+
+	if err != nil {
+		session.err = err
+		if h.consoles[key] == session {
+			delete(h.consoles, key)
+		}
+		return err
+	}
+
+	// All clients left while the stream was opening
+	if h.consoles[key] != session {
+		h.closeConsoleStream(key, stream)
+		session.err = io.ErrClosedPipe
+		return session.err
+	}
+
+	session.stream = stream
+	go h.pumpConsoleOutput(key, session)
+
+	h.logger.Info("Console session opened",
+		logger.String("vmName", client.VMName),
+		logger.String("host", client.Host),
+		logger.String("userID", client.UserID))
+
+	return nil
+}
+
+// detachConsole detaches a client from the console session of its VM,
+// closing the session when the last console client leaves.
+func (h *Handler) detachConsole(client *Client) {
+	h.consolesLock.Lock()
+	defer h.consolesLock.Unlock()
+
+	key := client.key()
+	session, exists := h.consoles[key]
+	if !exists {
+		return
+	}
+
+	session.clients--
+	if session.clients > 0 {
+		return
+	}
+
+	delete(h.consoles, key)
+
+	// A session that is still opening is closed by attachConsole
+	if session.stream == nil {
+		return
+	}
+	h.closeConsoleStream(key, session.stream)
+
+	h.logger.Info("Console session closed",
+		logger.String("vmName", client.VMName),
+		logger.String("host", client.Host))
+}
+
+// closeConsoleStream closes the console stream of a VM.
+func (h *Handler) closeConsoleStream(key string, stream vmmodels.ConsoleStream) {
+	if err := stream.Close(); err != nil {
+		h.logger.Debug("Failed to close console stream",
+			logger.String("vmName", key),
+			logger.Error(err))
+	}
+}
+
+// getConsole returns the console stream of a VM, if a session is open.
+func (h *Handler) getConsole(key string) (vmmodels.ConsoleStream, bool) {
+	h.consolesLock.Lock()
+	defer h.consolesLock.Unlock()
+
+	session, exists := h.consoles[key]
+	if !exists || session.stream == nil {
+		return nil, false
+	}
+	return session.stream, true
+}
+
+// pumpConsoleOutput forwards console output to the console clients of a VM.
+func (h *Handler) pumpConsoleOutput(key string, session *consoleSession) {
+	buf := make([]byte, consoleReadBufferSize)
+	var pending []byte
+
+	for {
+		n, err := session.stream.Read(buf)
+		if n > 0 {
+			// Hold back incomplete UTF-8 sequences until the next read
+			pending = append(pending, buf[:n]...)
+			valid := validUTF8Prefix(pending)
+			if valid > 0 {
+				h.SendVMConsoleOutput(key, string(pending[:valid]), false)
+				pending = append(pending[:0], pending[valid:]...)
+			}
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+				h.logger.Warn("Console stream ended with error",
+					logger.String("vmName", key),
+					logger.Error(err))
+			}
+			break
+		}
+	}
+
+	// Drop the session if it is still registered, so the next client reopens it
+	h.consolesLock.Lock()
+	if current, exists := h.consoles[key]; exists && current == session {
+		delete(h.consoles, key)
+	}
+	h.consolesLock.Unlock()
+
+	h.SendVMConsoleOutput(key, string(pending), true)
+}
+
+// validUTF8Prefix returns the length of data without a trailing incomplete
+// UTF-8 sequence. Invalid bytes elsewhere are left for the encoder to replace.
+func validUTF8Prefix(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			return i
+		}
+		break
+	}
+	return len(data)
+}
@@ -0,0 +1,430 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+// nopLogger discards log output, including from goroutines that outlive a test.
+type nopLogger struct{}
+
+func (l nopLogger) Debug(msg string, fields ...logger.Field)        {}
+func (l nopLogger) Info(msg string, fields ...logger.Field)         {}
+func (l nopLogger) Warn(msg string, fields ...logger.Field)         {}
+func (l nopLogger) Error(msg string, fields ...logger.Field)        {}
+func (l nopLogger) Fatal(msg string, fields ...logger.Field)        {}
+func (l nopLogger) WithFields(fields ...logger.Field) logger.Logger { return l }
+func (l nopLogger) WithError(err error) logger.Logger               { return l }
+func (l nopLogger) Sync() error                                     { return nil }
+
+// fakeConsole is a console stream whose output is written by the test.
+type fakeConsole struct {
+	reader  *io.PipeReader
+	output  *io.PipeWriter
+	input   bytes.Buffer
+	resizes [][2]uint16
+	closed  bool
+	mu      sync.Mutex
+}
+
+func newFakeConsole() *fakeConsole {
+	reader, writer := io.Pipe()
+	return &fakeConsole{reader: reader, output: writer}
+}
+
+func (c *fakeConsole) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c *fakeConsole) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.input.Write(p)
+}
+
+func (c *fakeConsole) Resize(cols, rows uint16) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resizes = append(c.resizes, [2]uint16{cols, rows})
+	return nil
+}
+
+func (c *fakeConsole) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return c.reader.Close()
+}
+
+func (c *fakeConsole) state() (string, [][2]uint16, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.input.String(), append([][2]uint16(nil), c.resizes...), c.closed
+}
+
+// fakeVMManager opens fake consoles and reports configured graphics devices.
+type fakeVMManager struct {
+	consoles map[string]*fakeConsole
+	// gates block OpenConsole for a VM until closed
+	gates    map[string]chan struct{}
+	graphics []vmmodels.GraphicsInfo
+	opens    int
+	mu       sync.Mutex
+}
+
+func newFakeVMManager() *fakeVMManager {
+	return &fakeVMManager{
+		consoles: make(map[string]*fakeConsole),
+		gates:    make(map[string]chan struct{}),
+	}
+}
+
+func (m *fakeVMManager) Get(ctx context.Context, name string) (*vmmodels.VM, error) {
+	return &vmmodels.VM{Name: name, Status: vmmodels.VMStatusRunning}, nil
+}
+
+func (m *fakeVMManager) GetMetrics(ctx context.Context, name string) (*vmmodels.Metrics, error) {
+	return &vmmodels.Metrics{}, nil
+}
+
+func (m *fakeVMManager) OpenConsole(ctx context.Context, name string, opts vmmodels.ConsoleOptions) (vmmodels.ConsoleStream, error) {
+	m.mu.Lock()
+	gate := m.gates[name]
+	m.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.opens++
+	if host, ok := connection.HostFromContext(ctx); ok {
+		name += "@" + host
+	}
+	console, ok := m.consoles[name]
+	if !ok {
+		return nil, fmt.Errorf("VM %s has no console", name)
+	}
+	return console, nil
+}
+
+func (m *fakeVMManager) OpenGraphics(ctx context.Context, name string, graphicsType vmmodels.GraphicsType) (net.Conn, error) {
+	for _, g := range m.graphics {
+		if g.Type == graphicsType {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "tcp", g.Address())
+		}
+	}
+	return nil, fmt.Errorf("VM %s has no %s graphics device", name, graphicsType)
+}
+
+func (m *fakeVMManager) openCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.opens
+}
+
+// newWebSocketTestServer serves the console and graphics endpoints of a handler.
+func newWebSocketTestServer(t *testing.T, vmManager VMManager) (*Handler, *httptest.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHandler(vmManager, nil, nopLogger{})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "user-1")
+		c.Next()
+	})
+	router.GET("/ws/vms/:name", handler.HandleVM)
+	router.GET("/ws/vms/:name/console", handler.HandleVMConsole)
+	router.GET("/ws/vms/:name/vnc", handler.HandleVMVNC)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return handler, server
+}
+
+// dialWebSocket connects to a path of the test server.
+func dialWebSocket(t *testing.T, server *httptest.Server, path string, subprotocols ...string) *websocket.Conn {
+	t.Helper()
+
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+path, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// readMessages reads messages until one of the given type arrives. The write
+// pump joins queued messages with newlines.
+func readMessages(t *testing.T, conn *websocket.Conn, msgType MessageType) *Message {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			var msg Message
+			require.NoError(t, json.Unmarshal(line, &msg))
+			if msg.Type == msgType {
+				return &msg
+			}
+		}
+	}
+}
+
+// readMessageTypes reads messages until one of each given type arrived.
+func readMessageTypes(t *testing.T, conn *websocket.Conn, msgTypes ...MessageType) map[MessageType]*Message {
+	t.Helper()
+
+	messages := make(map[MessageType]*Message)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for len(messages) < len(msgTypes) {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			var msg Message
+			require.NoError(t, json.Unmarshal(line, &msg))
+			for _, msgType := range msgTypes {
+				if msg.Type == msgType && messages[msgType] == nil {
+					messages[msgType] = &msg
+				}
+			}
+		}
+	}
+	return messages
+}
+
+func sendMessage(t *testing.T, conn *websocket.Conn, msgType MessageType, data map[string]interface{}) {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(NewMessage(msgType, data)))
+}
+
+func TestConsoleProxy(t *testing.T) {
+	vmManager := newFakeVMManager()
+	console := newFakeConsole()
+	vmManager.consoles["test-vm"] = console
+	_, server := newWebSocketTestServer(t, vmManager)
+
+	conn := dialWebSocket(t, server, "/ws/vms/test-vm/console")
+	readMessages(t, conn, MessageTypeConnection)
+
+	t.Run("Output", func(t *testing.T) {
+		_, err := console.output.Write([]byte("login: "))
+		require.NoError(t, err)
+
+		msg := readMessages(t, conn, MessageTypeConsole)
+		assert.Equal(t, "login: ", msg.Data["content"])
+		assert.Equal(t, false, msg.Data["eof"])
+	})
+
+	t.Run("Input and resize", func(t *testing.T) {
+		sendMessage(t, conn, MessageTypeConsoleIn, map[string]interface{}{"content": "root\n"})
+		sendMessage(t, conn, MessageTypeResize, map[string]interface{}{"cols": 120, "rows": 40})
+
+		assert.Eventually(t, func() bool {
+			input, resizes, _ := console.state()
+			return input == "root\n" && len(resizes) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, resizes, _ := console.state()
+		assert.Equal(t, [2]uint16{120, 40}, resizes[0])
+	})
+
+	t.Run("Invalid resize", func(t *testing.T) {
+		sendMessage(t, conn, MessageTypeResize, map[string]interface{}{"cols": 0, "rows": 40})
+
+		msg := readMessages(t, conn, MessageTypeError)
+		assert.Equal(t, "INVALID_CONSOLE_RESIZE", msg.Data["code"])
+	})
+
+	t.Run("End of stream", func(t *testing.T) {
+		require.NoError(t, console.output.Close())
+
+		msg := readMessages(t, conn, MessageTypeConsole)
+		assert.Equal(t, true, msg.Data["eof"])
+	})
+}
+
+func TestConsoleSessionSharing(t *testing.T) {
+	vmManager := newFakeVMManager()
+	console := newFakeConsole()
+	vmManager.consoles["test-vm"] = console
+	_, server := newWebSocketTestServer(t, vmManager)
+
+	first := dialWebSocket(t, server, "/ws/vms/test-vm/console")
+	readMessages(t, first, MessageTypeConnection)
+	second := dialWebSocket(t, server, "/ws/vms/test-vm/console")
+	readMessages(t, second, MessageTypeConnection)
+
+	assert.Equal(t, 1, vmManager.openCount())
+
+	// The stream stays open until the last client leaves
+	require.NoError(t, first.Close())
+	time.Sleep(50 * time.Millisecond)
+	_, _, closed := console.state()
+	assert.False(t, closed)
+
+	require.NoError(t, second.Close())
+	assert.Eventually(t, func() bool {
+		_, _, closed := console.state()
+		return closed
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestConsoleHostSelection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	vmManager := newFakeVMManager()
+	local := newFakeConsole()
+	remote := newFakeConsole()
+	vmManager.consoles["test-vm"] = local
+	vmManager.consoles["test-vm@hv2"] = remote
+
+	router := gin.New()
+	handler := SetupRoutesWithoutAuth(router, "/ws", vmManager, nil, nopLogger{})
+	t.Cleanup(handler.monitor.Stop)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	localConn := dialWebSocket(t, server, "/ws/vms/test-vm/console")
+	readMessages(t, localConn, MessageTypeConnection)
+	remoteConn := dialWebSocket(t, server, "/ws/vms/test-vm/console?host=hv2")
+	readMessages(t, remoteConn, MessageTypeConnection)
+
+	// Same-named VMs on different hosts have separate sessions
+	assert.Equal(t, 2, vmManager.openCount())
+
+	_, err := remote.output.Write([]byte("remote"))
+	require.NoError(t, err)
+	assert.Equal(t, "remote", readMessages(t, remoteConn, MessageTypeConsole).Data["content"])
+
+	_, err = local.output.Write([]byte("local"))
+	require.NoError(t, err)
+	assert.Equal(t, "local", readMessages(t, localConn, MessageTypeConsole).Data["content"])
+}
+
+func TestConsoleUnavailable(t *testing.T) {
+	_, server := newWebSocketTestServer(t, newFakeVMManager())
+
+	conn := dialWebSocket(t, server, "/ws/vms/test-vm/console")
+
+	msg := readMessages(t, conn, MessageTypeError)
+	assert.Equal(t, "CONSOLE_UNAVAILABLE", msg.Data["code"])
+}
+
+func TestAttachConsole(t *testing.T) {
+	t.Run("Opening a console does not block other VMs", func(t *testing.T) {
+		vmManager := newFakeVMManager()
+		vmManager.consoles["slow-vm"] = newFakeConsole()
+		vmManager.consoles["fast-vm"] = newFakeConsole()
+		gate := make(chan struct{})
+		vmManager.gates["slow-vm"] = gate
+		handler := NewHandler(vmManager, nil, nopLogger{})
+
+		slow := make(chan error, 1)
+		go func() { slow <- handler.attachConsole(&Client{VMName: "slow-vm"}) }()
+
+		fast := make(chan error, 1)
+		go func() { fast <- handler.attachConsole(&Client{VMName: "fast-vm"}) }()
+
+		select {
+		case err := <-fast:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("attaching to fast-vm waited for slow-vm")
+		}
+
+		close(gate)
+		require.NoError(t, <-slow)
+	})
+
+	t.Run("Clients wait for the console being opened", func(t *testing.T) {
+		vmManager := newFakeVMManager()
+		vmManager.consoles["test-vm"] = newFakeConsole()
+		gate := make(chan struct{})
+		vmManager.gates["test-vm"] = gate
+		handler := NewHandler(vmManager, nil, nopLogger{})
+
+		results := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() { results <- handler.attachConsole(&Client{VMName: "test-vm"}) }()
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(gate)
+		require.NoError(t, <-results)
+		require.NoError(t, <-results)
+
+		assert.Equal(t, 1, vmManager.openCount())
+		_, ok := handler.getConsole("test-vm")
+		assert.True(t, ok)
+	})
+
+	t.Run("Stream opened after all clients left is closed", func(t *testing.T) {
+		vmManager := newFakeVMManager()
+		console := newFakeConsole()
+		vmManager.consoles["test-vm"] = console
+		gate := make(chan struct{})
+		vmManager.gates["test-vm"] = gate
+		handler := NewHandler(vmManager, nil, nopLogger{})
+
+		client := &Client{VMName: "test-vm"}
+		result := make(chan error, 1)
+		go func() { result <- handler.attachConsole(client) }()
+
+		assert.Eventually(t, func() bool {
+			handler.consolesLock.Lock()
+			defer handler.consolesLock.Unlock()
+			return handler.consoles["test-vm"] != nil
+		}, 5*time.Second, 10*time.Millisecond)
+		handler.detachConsole(client)
+		close(gate)
+
+		assert.Error(t, <-result)
+		_, _, closed := console.state()
+		assert.True(t, closed)
+		_, ok := handler.getConsole("test-vm")
+		assert.False(t, ok)
+	})
+
+	t.Run("Failed open", func(t *testing.T) {
+		handler := NewHandler(newFakeVMManager(), nil, nopLogger{})
+
+		err := handler.attachConsole(&Client{VMName: "test-vm"})
+		assert.Error(t, err)
+		_, ok := handler.getConsole("test-vm")
+		assert.False(t, ok)
+	})
+}
+
+func TestValidUTF8Prefix(t *testing.T) {
+	euro := []byte("€")
+
+	assert.Equal(t, 3, validUTF8Prefix([]byte("abc")))
+	assert.Equal(t, 1, validUTF8Prefix(append([]byte("a"), euro[:2]...)))
+	assert.Equal(t, 4, validUTF8Prefix(append([]byte("a"), euro...)))
+	assert.Equal(t, 0, validUTF8Prefix(nil))
+}
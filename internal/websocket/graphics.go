@@ -0,0 +1,216 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+	"github.com/threatflux/libgo/pkg/logger"
+)
+
+const (
+	// Size of the buffer used to relay graphics traffic.
+	graphicsBufferSize = 32 * 1024
+
+	// Time allowed to connect to a graphics device.
+	graphicsDialTimeout = 10 * time.Second
+)
+
+// graphicsUpgrader upgrades graphics connections. noVNC and spice-html5
+// negotiate the websockify "binary" subprotocol.
+var graphicsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  graphicsBufferSize,
+	WriteBufferSize: graphicsBufferSize,
+	Subprotocols:    []string{"binary"},
+	CheckOrigin: func(r *http.Request) bool {
+		// Allow all origins in development
+		// Note: In production, this should be more restrictive to verify origin
+		return true
+	},
+}
+
+// HandleVMVNC handles WebSocket connections proxied to the VM's VNC server.
+func (h *Handler) HandleVMVNC(c *gin.Context) {
+	h.handleGraphics(c, vmmodels.GraphicsTypeVNC)
+}
+
+// HandleVMSPICE handles WebSocket connections proxied to the VM's SPICE server.
+func (h *Handler) HandleVMSPICE(c *gin.Context) {
+	h.handleGraphics(c, vmmodels.GraphicsTypeSPICE)
+}
+
+// handleGraphics proxies a WebSocket connection to a graphics device of a VM.
+// The graphics server itself only listens on the hypervisor's loopback
+// interface; this proxy is the only way to reach it remotely. The VM manager
+// dials devices from the libvirt host of the VM.
+func (h *Handler) handleGraphics(c *gin.Context, graphicsType vmmodels.GraphicsType) {
+	vmName := c.Param("name")
+	if vmName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "VM name is required"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		userIDStr = "unknown"
+	}
+
+	// Resolve and connect to the graphics device before upgrading, so
+	// failures can still be reported as plain HTTP errors
+	target, err := h.dialGraphics(c.Request.Context(), vmName, graphicsType)
+	if err != nil {
+		h.logger.Error("Failed to connect to VM graphics",
+			logger.String("vmName", vmName),
+			logger.String("userID", userIDStr),
+			logger.String("type", string(graphicsType)),
+			logger.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to connect to %s console", graphicsType)})
+		return
+	}
+
+	conn, err := graphicsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		target.Close()
+		h.logger.Error("Failed to upgrade connection to WebSocket",
+			logger.String("vmName", vmName),
+			logger.String("userID", userIDStr),
+			logger.Error(err))
+		return
+	}
+
+	h.logger.Info("Graphics proxy connection established",
+		logger.String("vmName", vmName),
+		logger.String("userID", userIDStr),
+		logger.String("type", string(graphicsType)))
+
+	go h.proxyGraphics(conn, target, vmName, userIDStr)
+}
+
+// dialGraphics connects to the graphics device of the given type.
+func (h *Handler) dialGraphics(ctx context.Context, vmName string, graphicsType vmmodels.GraphicsType) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, graphicsDialTimeout)
+	defer cancel()
+
+	return h.vmManager.OpenGraphics(ctx, vmName, graphicsType)
+}
+
+// proxyGraphics relays binary frames between the WebSocket and the graphics
+// server until either side closes.
+func (h *Handler) proxyGraphics(conn *websocket.Conn, target net.Conn, vmName, userID string) {
+	var closeOnce sync.Once
+	closeBoth := func() {
+		closeOnce.Do(func() {
+			target.Close()
+			conn.Close()
+		})
+	}
+	defer closeBoth()
+
+	done := make(chan struct{})
+	var writeLock sync.Mutex
+
+	// Graphics server to WebSocket
+	go func() {
+		defer close(done)
+		defer closeBoth()
+
+		buf := make([]byte, graphicsBufferSize)
+		for {
+			n, err := target.Read(buf)
+			if n > 0 {
+				writeLock.Lock()
+				writeErr := conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if writeErr == nil {
+					writeErr = conn.WriteMessage(websocket.BinaryMessage, buf[:n])
+				}
+				writeLock.Unlock()
+				if writeErr != nil {
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+					h.logger.Debug("Graphics server read error",
+						logger.String("vmName", vmName),
+						logger.Error(err))
+				}
+				return
+			}
+		}
+	}()
+
+	// Keep the WebSocket alive while the VM is idle
+	go func() {
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				writeLock.Lock()
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+				writeLock.Unlock()
+				if err != nil {
+					closeBoth()
+					return
+				}
+			}
+		}
+	}()
+
+	// WebSocket to graphics server
+	if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
+		h.logger.Debug("Failed to set read deadline", logger.Error(err))
+	}
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.logger.Debug("Graphics proxy read error",
+					logger.String("vmName", vmName),
+					logger.Error(err))
+			}
+			break
+		}
+
+		// Any data counts as activity
+		if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
+			h.logger.Debug("Failed to set read deadline", logger.Error(err))
+		}
+
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		if _, err := target.Write(data); err != nil {
+			break
+		}
+	}
+
+	closeBoth()
+	<-done
+
+	h.logger.Info("Graphics proxy connection closed",
+		logger.String("vmName", vmName),
+		logger.String("userID", userID))
+}
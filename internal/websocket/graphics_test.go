@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vmmodels "github.com/threatflux/libgo/internal/models/vm"
+)
+
+// startGraphicsServer starts a fake VNC server that greets clients and
+// echoes everything it receives.
+func startGraphicsServer(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if _, err := conn.Write([]byte("RFB 003.008\n")); err != nil {
+					return
+				}
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestGraphicsProxy(t *testing.T) {
+	vmManager := newFakeVMManager()
+	vmManager.graphics = []vmmodels.GraphicsInfo{
+		{Type: vmmodels.GraphicsTypeVNC, Listen: "127.0.0.1", Port: startGraphicsServer(t)},
+	}
+	_, server := newWebSocketTestServer(t, vmManager)
+
+	conn := dialWebSocket(t, server, "/ws/vms/test-vm/vnc", "binary")
+	assert.Equal(t, "binary", conn.Subprotocol())
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	messageType, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+	assert.Equal(t, "RFB 003.008\n", string(data))
+
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("RFB 003.008\n")))
+	messageType, data, err = conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+	assert.Equal(t, "RFB 003.008\n", string(data))
+
+	// Text frames are not relayed
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ignored")))
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte{1}))
+	_, data, err = conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, data)
+}
+
+func TestGraphicsProxyErrors(t *testing.T) {
+	t.Run("No graphics device of the type", func(t *testing.T) {
+		vmManager := newFakeVMManager()
+		vmManager.graphics = []vmmodels.GraphicsInfo{
+			{Type: vmmodels.GraphicsTypeSPICE, Listen: "127.0.0.1", Port: 5900},
+		}
+		_, server := newWebSocketTestServer(t, vmManager)
+
+		resp, err := http.Get(server.URL + "/ws/vms/test-vm/vnc")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+
+	t.Run("Graphics server not listening", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		require.NoError(t, listener.Close())
+
+		vmManager := newFakeVMManager()
+		vmManager.graphics = []vmmodels.GraphicsInfo{
+			{Type: vmmodels.GraphicsTypeVNC, Listen: "127.0.0.1", Port: port},
+		}
+		_, server := newWebSocketTestServer(t, vmManager)
+
+		dialer := websocket.Dialer{Subprotocols: []string{"binary"}}
+		_, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/vms/test-vm/vnc", nil)
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+}
+
+func TestGraphicsInfoAddress(t *testing.T) {
+	local := vmmodels.GraphicsInfo{Listen: "0.0.0.0", Port: 5900}
+	assert.Equal(t, "127.0.0.1:5900", local.Address())
+
+	remote := vmmodels.GraphicsInfo{Listen: "0.0.0.0", Port: 5900, Host: "hv2.example.com"}
+	assert.Equal(t, "hv2.example.com:5900", remote.Address())
+
+	bound := vmmodels.GraphicsInfo{Listen: "10.0.0.5", TLSPort: 5901, Host: "hv2.example.com"}
+	assert.Equal(t, "10.0.0.5:5901", bound.Address())
+
+	// Loopback addresses of remote hosts stay on that host
+	loopback := vmmodels.GraphicsInfo{Listen: "127.0.0.1", Port: 5900, Host: "hv2.example.com"}
+	assert.Equal(t, "127.0.0.1:5900", loopback.Address())
+}
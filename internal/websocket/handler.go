@@ -1,14 +1,18 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
 	vmmodels "github.com/threatflux/libgo/internal/models/vm"
 	"github.com/threatflux/libgo/pkg/logger"
 )
@@ -39,18 +43,27 @@ var upgrader = websocket.Upgrader{
 
 // Handler represents a WebSocket handler.
 type Handler struct {
-	hub    *Hub
-	logger logger.Logger
+	hub          *Hub
+	vmManager    VMManager
+	commander    VMCommander
+	monitor      *VMMonitor
+	logger       logger.Logger
+	consoles     map[string]*consoleSession
+	consolesLock sync.Mutex
+	authEnabled  bool
 }
 
 // NewHandler creates a new WebSocket handler.
-func NewHandler(logger logger.Logger) *Handler {
+func NewHandler(vmManager VMManager, commander VMCommander, logger logger.Logger) *Handler {
 	hub := NewHub()
 	go hub.Run()
 
 	return &Handler{
-		hub:    hub,
-		logger: logger,
+		hub:       hub,
+		vmManager: vmManager,
+		commander: commander,
+		logger:    logger,
+		consoles:  make(map[string]*consoleSession),
 	}
 }
 
@@ -85,6 +98,9 @@ func (h *Handler) handleConnection(c *gin.Context, isConsole bool) {
 		userIDStr = "unknown"
 	}
 
+	// The host selector middleware picks the libvirt host of the VM
+	host, _ := connection.HostFromContext(c.Request.Context())
+
 	// Upgrade HTTP connection to WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -99,16 +115,39 @@ func (h *Handler) handleConnection(c *gin.Context, isConsole bool) {
 	client := &Client{
 		Conn:       conn,
 		Send:       make(chan *Message, 256),
+		done:       make(chan struct{}),
 		UserID:     userIDStr,
 		VMName:     vmName,
+		Host:       host,
 		IsConsole:  isConsole,
 		CreatedAt:  time.Now(),
 		LastActive: time.Now(),
 	}
 
+	// Keep the authorization of the token used to connect for commands
+	if roles, ok := c.Get("userRoles"); ok {
+		client.Roles, _ = roles.([]string)
+	}
+	if expiry, ok := c.Get("tokenExpiry"); ok {
+		client.TokenExpiry, _ = expiry.(time.Time)
+	}
+
 	// Register client with hub
 	h.hub.register <- client
 
+	// Attach console clients to the VM's serial console
+	if isConsole {
+		if err := h.attachConsole(client); err != nil {
+			h.logger.Error("Failed to open VM console",
+				logger.String("vmName", vmName),
+				logger.String("userID", userIDStr),
+				logger.Error(err))
+			client.Send <- ErrorMessage("CONSOLE_UNAVAILABLE", fmt.Sprintf("Failed to open console: %v", err))
+		} else {
+			client.consoleAttached = true
+		}
+	}
+
 	// Start goroutines for reading and writing
 	go h.readPump(client)
 	go h.writePump(client)
@@ -130,8 +169,17 @@ func (h *Handler) handleConnection(c *gin.Context, isConsole bool) {
 // readPump pumps messages from the WebSocket connection to the hub.
 func (h *Handler) readPump(client *Client) {
 	defer func() {
-		h.hub.unregister <- client
+		if client.consoleAttached {
+			h.detachConsole(client)
+		}
 		client.Conn.Close()
+
+		// Commands still running reply on the send channel, which is
+		// closed when the client is unregistered
+		close(client.done)
+		client.commands.Wait()
+
+		h.hub.unregister <- client
 		h.logger.Info("WebSocket connection closed",
 			logger.String("vmName", client.VMName),
 			logger.String("userID", client.UserID))
@@ -179,13 +227,25 @@ func (h *Handler) readPump(client *Client) {
 		// Handle message based on type
 		switch msg.Type {
 		case MessageTypeCommand:
-			h.handleCommand(client, &msg)
+			// Commands may run for minutes, so they do not hold up console
+			// input and heartbeats
+			client.commands.Add(1)
+			go func() {
+				defer client.commands.Done()
+				h.handleCommand(client, &msg)
+			}()
 		case MessageTypeConsoleIn:
 			if client.IsConsole {
 				h.handleConsoleInput(client, &msg)
 			} else {
 				client.Send <- ErrorMessage("INVALID_MESSAGE_TYPE", "Console input not allowed on this connection")
 			}
+		case MessageTypeResize:
+			if client.IsConsole {
+				h.handleConsoleResize(client, &msg)
+			} else {
+				client.Send <- ErrorMessage("INVALID_MESSAGE_TYPE", "Console resize not allowed on this connection")
+			}
 		case MessageTypeHeartbeat:
 			// Just acknowledge heartbeat
 			client.Send <- NewMessage(MessageTypeHeartbeat, map[string]interface{}{
@@ -319,7 +379,7 @@ func (h *Handler) handleCommand(client *Client, msg *Message) {
 	// Extract command information
 	action, ok := msg.Data["action"].(string)
 	if !ok {
-		client.Send <- ErrorMessage("INVALID_COMMAND", "Missing or invalid action")
+		h.reply(client, ErrorMessage("INVALID_COMMAND", "Missing or invalid action"))
 		return
 	}
 
@@ -337,9 +397,47 @@ func (h *Handler) handleCommand(client *Client, msg *Message) {
 		logger.String("action", action),
 		logger.String("requestId", requestID))
 
-	// Note: Command handling implementation needed for VM operations
-	// For now, just acknowledge the command
-	client.Send <- ResponseMessage(requestID, true, fmt.Sprintf("Command '%s' acknowledged", action))
+	if err := h.authorizeCommand(client, action); err != nil {
+		h.logger.Warn("VM command rejected",
+			logger.String("vmName", client.VMName),
+			logger.String("userID", client.UserID),
+			logger.String("action", action),
+			logger.Error(err))
+		h.reply(client, ResponseMessage(requestID, false, err.Error()))
+		return
+	}
+
+	params, ok := msg.Data["params"].(map[string]interface{})
+	if !ok {
+		params = map[string]interface{}{}
+	}
+
+	ctx, cancel := context.WithTimeout(hostContext(context.Background(), client.Host), commandTimeout)
+	defer cancel()
+
+	result, err := h.executeCommand(ctx, client.VMName, action, params)
+	if err != nil {
+		h.logger.Error("VM command failed",
+			logger.String("vmName", client.VMName),
+			logger.String("userID", client.UserID),
+			logger.String("action", action),
+			logger.Error(err))
+		h.reply(client, ResponseMessage(requestID, false, fmt.Sprintf("Command '%s' failed: %v", action, err)))
+		return
+	}
+
+	h.reply(client, ResponseMessage(requestID, true, result))
+
+	// Push the resulting state to every client watching the VM
+	h.pushStatus(ctx, client)
+}
+
+// reply queues the response to a command unless the client disconnected.
+func (h *Handler) reply(client *Client, msg *Message) {
+	select {
+	case client.Send <- msg:
+	case <-client.done:
+	}
 }
 
 // handleConsoleInput handles VM console input.
@@ -355,37 +453,71 @@ func (h *Handler) handleConsoleInput(client *Client, msg *Message) {
 		logger.String("userID", client.UserID),
 		logger.Int("contentLength", len(content)))
 
-	// Note: Console input handling implementation needed for VM console access
-	// For now, just echo the input back
-	client.Send <- ConsoleMessage(content, false)
+	console, ok := h.getConsole(client.key())
+	if !ok {
+		client.Send <- ErrorMessage("CONSOLE_UNAVAILABLE", "Console is not connected")
+		return
+	}
+
+	if _, err := io.WriteString(console, content); err != nil {
+		h.logger.Error("Failed to write console input",
+			logger.String("vmName", client.VMName),
+			logger.String("userID", client.UserID),
+			logger.Error(err))
+		client.Send <- ErrorMessage("CONSOLE_WRITE_FAILED", "Failed to write console input")
+	}
+}
+
+// handleConsoleResize handles VM console resize requests.
+func (h *Handler) handleConsoleResize(client *Client, msg *Message) {
+	cols, colsOK := msg.Data["cols"].(float64)
+	rows, rowsOK := msg.Data["rows"].(float64)
+	if !colsOK || !rowsOK || cols < 1 || rows < 1 || cols > math.MaxUint16 || rows > math.MaxUint16 {
+		client.Send <- ErrorMessage("INVALID_CONSOLE_RESIZE", "Missing or invalid cols/rows")
+		return
+	}
+
+	console, ok := h.getConsole(client.key())
+	if !ok {
+		client.Send <- ErrorMessage("CONSOLE_UNAVAILABLE", "Console is not connected")
+		return
+	}
+
+	if err := console.Resize(uint16(cols), uint16(rows)); err != nil {
+		h.logger.Warn("Failed to resize console",
+			logger.String("vmName", client.VMName),
+			logger.String("userID", client.UserID),
+			logger.Error(err))
+		client.Send <- ErrorMessage("CONSOLE_RESIZE_FAILED", "Failed to resize console")
+	}
 }
 
 // SendVMStatus sends a VM status update to all clients connected to the VM.
-func (h *Handler) SendVMStatus(vmName string, status vmmodels.VMStatus, lastChange time.Time, uptime int64) {
+// The VM is identified as in Hub.SendToVM.
+func (h *Handler) SendVMStatus(key string, status vmmodels.VMStatus, lastChange time.Time, uptime int64) {
 	msg := StatusMessage(status, lastChange, uptime)
-	h.hub.SendToVM(vmName, msg)
+	h.hub.SendToVM(key, msg)
 }
 
 // SendVMMetrics sends VM metrics to all clients connected to the VM.
-func (h *Handler) SendVMMetrics(vmName string, cpu float64, memory, memoryTotal, rxBytes, txBytes, readBytes, writeBytes uint64) {
-	msg := MetricsMessage(cpu, memory, memoryTotal, rxBytes, txBytes, readBytes, writeBytes)
-	h.hub.SendToVM(vmName, msg)
+func (h *Handler) SendVMMetrics(key string, metrics *vmmodels.Metrics) {
+	msg := MetricsMessage(metrics)
+	h.hub.SendToVM(key, msg)
 }
 
 // SendVMConsoleOutput sends VM console output to console clients.
-func (h *Handler) SendVMConsoleOutput(vmName string, content string, eof bool) {
+func (h *Handler) SendVMConsoleOutput(key string, content string, eof bool) {
 	msg := ConsoleMessage(content, eof)
 
 	// Only send to console clients
-	clients := h.hub.vmClients[vmName]
-	for _, client := range clients {
-		if client.IsConsole {
-			select {
-			case client.Send <- msg:
-			default:
-				close(client.Send)
-				delete(h.hub.clients, client)
-			}
-		}
+	h.hub.sendToVM(key, msg, true)
+}
+
+// hostContext returns ctx scoped to a selected libvirt host. Work that
+// outlives the request of a client runs on the client's host this way.
+func hostContext(ctx context.Context, host string) context.Context {
+	if host == "" {
+		return ctx
 	}
+	return connection.WithHost(ctx, host)
 }
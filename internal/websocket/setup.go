@@ -2,7 +2,11 @@ package websocket
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/threatflux/libgo/internal/auth/jwt"
+	"github.com/threatflux/libgo/internal/libvirt/connection"
+	"github.com/threatflux/libgo/internal/middleware"
 	"github.com/threatflux/libgo/internal/middleware/auth"
+	"github.com/threatflux/libgo/internal/models/user"
 	"github.com/threatflux/libgo/pkg/logger"
 )
 
@@ -11,33 +15,49 @@ func SetupRoutes(
 	router *gin.Engine,
 	basePath string,
 	vmManager VMManager,
+	commander VMCommander,
 	logger logger.Logger,
 	authMiddleware *auth.JWTMiddleware,
 	roleMiddleware *auth.RoleMiddleware,
 ) *Handler {
 	// Create WebSocket handler
-	handler := NewHandler(logger)
+	handler := NewHandler(vmManager, commander, logger)
 
 	// Create VM monitor
 	monitor := NewVMMonitor(handler, vmManager, logger)
 	monitor.Start()
+	handler.monitor = monitor
+	handler.authEnabled = true
 
-	// Setup WebSocket routes
-	ws := router.Group(basePath)
+	// Setup WebSocket routes; like the REST API, they select the libvirt
+	// host of the VM with the "host" query parameter or X-Libvirt-Host header
+	ws := router.Group(basePath, middleware.HostSelectorGinMiddleware())
 	{
 		// VM monitoring endpoint
 		ws.GET("/vms/:name", authMiddleware.Authenticate(), roleMiddleware.RequirePermission("read"), func(c *gin.Context) {
-			vmName := c.Param("name")
-			monitor.RegisterVM(vmName)
+			host, _ := connection.HostFromContext(c.Request.Context())
+			monitor.RegisterVM(host, c.Param("name"))
+			setAuthenticatedClient(c)
 			handler.HandleVM(c)
 		})
 
 		// VM console endpoint
 		ws.GET("/vms/:name/console", authMiddleware.Authenticate(), roleMiddleware.RequirePermission("console"), func(c *gin.Context) {
-			vmName := c.Param("name")
-			monitor.RegisterVM(vmName)
+			host, _ := connection.HostFromContext(c.Request.Context())
+			monitor.RegisterVM(host, c.Param("name"))
+			setAuthenticatedClient(c)
 			handler.HandleVMConsole(c)
 		})
+
+		// VM graphics console endpoints (noVNC / spice-html5)
+		ws.GET("/vms/:name/vnc", authMiddleware.Authenticate(), roleMiddleware.RequirePermission("console"), func(c *gin.Context) {
+			setAuthenticatedClient(c)
+			handler.HandleVMVNC(c)
+		})
+		ws.GET("/vms/:name/spice", authMiddleware.Authenticate(), roleMiddleware.RequirePermission("console"), func(c *gin.Context) {
+			setAuthenticatedClient(c)
+			handler.HandleVMSPICE(c)
+		})
 	}
 
 	return handler
@@ -48,22 +68,24 @@ func SetupRoutesWithoutAuth(
 	router *gin.Engine,
 	basePath string,
 	vmManager VMManager,
+	commander VMCommander,
 	logger logger.Logger,
 ) *Handler {
 	// Create WebSocket handler
-	handler := NewHandler(logger)
+	handler := NewHandler(vmManager, commander, logger)
 
 	// Create VM monitor
 	monitor := NewVMMonitor(handler, vmManager, logger)
 	monitor.Start()
+	handler.monitor = monitor
 
 	// Setup WebSocket routes without authentication
-	ws := router.Group(basePath)
+	ws := router.Group(basePath, middleware.HostSelectorGinMiddleware())
 	{
 		// VM monitoring endpoint
 		ws.GET("/vms/:name", func(c *gin.Context) {
-			vmName := c.Param("name")
-			monitor.RegisterVM(vmName)
+			host, _ := connection.HostFromContext(c.Request.Context())
+			monitor.RegisterVM(host, c.Param("name"))
 			// Set a default userID when auth is disabled
 			c.Set("userID", "anonymous")
 			handler.HandleVM(c)
@@ -71,13 +93,43 @@ func SetupRoutesWithoutAuth(
 
 		// VM console endpoint
 		ws.GET("/vms/:name/console", func(c *gin.Context) {
-			vmName := c.Param("name")
-			monitor.RegisterVM(vmName)
+			host, _ := connection.HostFromContext(c.Request.Context())
+			monitor.RegisterVM(host, c.Param("name"))
 			// Set a default userID when auth is disabled
 			c.Set("userID", "anonymous")
 			handler.HandleVMConsole(c)
 		})
+
+		// VM graphics console endpoints (noVNC / spice-html5)
+		ws.GET("/vms/:name/vnc", func(c *gin.Context) {
+			c.Set("userID", "anonymous")
+			handler.HandleVMVNC(c)
+		})
+		ws.GET("/vms/:name/spice", func(c *gin.Context) {
+			c.Set("userID", "anonymous")
+			handler.HandleVMSPICE(c)
+		})
 	}
 
 	return handler
 }
+
+// setAuthenticatedClient exposes the user and token set by the auth
+// middleware as the WebSocket client's identity and authorization.
+func setAuthenticatedClient(c *gin.Context) {
+	if u, ok := c.Get(auth.UserContextKey); ok {
+		if authUser, ok := u.(*user.User); ok {
+			c.Set("userID", authUser.ID)
+			c.Set("userRoles", authUser.Roles)
+		}
+	}
+
+	if claims, ok := c.Get("claims"); ok {
+		if jwtClaims, ok := claims.(*jwt.Claims); ok {
+			c.Set("userRoles", jwtClaims.Roles)
+			if jwtClaims.ExpiresAt != nil {
+				c.Set("tokenExpiry", jwtClaims.ExpiresAt.Time)
+			}
+		}
+	}
+}
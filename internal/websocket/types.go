@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -18,6 +19,7 @@ const (
 	MessageTypeResponse   MessageType = "response"
 	MessageTypeConsole    MessageType = "console"
 	MessageTypeConsoleIn  MessageType = "console_input"
+	MessageTypeResize     MessageType = "console_resize"
 	MessageTypeError      MessageType = "error"
 	MessageTypeHeartbeat  MessageType = "heartbeat"
 	MessageTypeConnection MessageType = "connection"
@@ -49,24 +51,32 @@ func StatusMessage(status vmmodels.VMStatus, lastChange time.Time, uptime int64)
 }
 
 // MetricsMessage creates a new metrics message.
-func MetricsMessage(cpu float64, memory uint64, memoryTotal uint64,
-	rxBytes, txBytes, readBytes, writeBytes uint64) *Message {
+// Byte counters are cumulative; the per-second fields are rates since the
+// previous sample.
+func MetricsMessage(metrics *vmmodels.Metrics) *Message {
 	return NewMessage(MessageTypeMetrics, map[string]interface{}{
 		"cpu": map[string]interface{}{
-			"utilization": cpu,
+			"utilization": metrics.CPU.Utilization,
+			"vcpus":       metrics.CPU.VCPUs,
 		},
 		"memory": map[string]interface{}{
-			"used":  memory,
-			"total": memoryTotal,
+			"used":  metrics.Memory.UsedBytes,
+			"total": metrics.Memory.TotalBytes,
+			"rss":   metrics.Memory.RSSBytes,
 		},
 		"network": map[string]interface{}{
-			"rxBytes": rxBytes,
-			"txBytes": txBytes,
+			"rxBytes":          metrics.Network.RxBytes,
+			"txBytes":          metrics.Network.TxBytes,
+			"rxBytesPerSecond": metrics.Network.RxBytesPerSecond,
+			"txBytesPerSecond": metrics.Network.TxBytesPerSecond,
 		},
 		"disk": map[string]interface{}{
-			"readBytes":  readBytes,
-			"writeBytes": writeBytes,
+			"readBytes":           metrics.Disk.ReadBytes,
+			"writeBytes":          metrics.Disk.WriteBytes,
+			"readBytesPerSecond":  metrics.Disk.ReadBytesPerSecond,
+			"writeBytesPerSecond": metrics.Disk.WriteBytesPerSecond,
 		},
+		"sampledAt": metrics.Timestamp.Format(time.RFC3339Nano),
 	})
 }
 
@@ -97,13 +107,39 @@ func ResponseMessage(requestID string, success bool, message string) *Message {
 
 // Client represents a WebSocket client connection.
 type Client struct {
-	Conn       *websocket.Conn
-	Send       chan *Message
-	CreatedAt  time.Time
-	LastActive time.Time
-	UserID     string
-	VMName     string
-	IsConsole  bool
+	Conn        *websocket.Conn
+	Send        chan *Message
+	CreatedAt   time.Time
+	LastActive  time.Time
+	TokenExpiry time.Time
+	UserID      string
+	VMName      string
+	// Host is the libvirt host selected for the VM, empty for the default
+	Host      string
+	Roles     []string
+	IsConsole bool
+
+	// done is closed when the client disconnects
+	done chan struct{}
+	// commands tracks the commands still running for the client
+	commands sync.WaitGroup
+
+	// consoleAttached is set once the client shares the VM console session
+	consoleAttached bool
+}
+
+// key identifies the client's VM across libvirt hosts.
+func (c *Client) key() string {
+	return vmKey(c.Host, c.VMName)
+}
+
+// vmKey identifies a VM by name and libvirt host. VM names are only unique
+// per host, so clients of same-named VMs on different hosts are kept apart.
+func vmKey(host, vmName string) string {
+	if host == "" {
+		return vmName
+	}
+	return vmName + "@" + host
 }
 
 // Hub maintains the set of active clients and broadcasts messages.
@@ -122,6 +158,9 @@ type Hub struct {
 
 	// VM name to clients mapping for targeted messages
 	vmClients map[string][]*Client
+
+	// mu guards clients and vmClients, which are also read by senders
+	mu sync.Mutex
 }
 
 // NewHub creates a new hub instance.
@@ -151,13 +190,19 @@ func (h *Hub) Run() {
 
 // handleClientRegistration registers a new client.
 func (h *Hub) handleClientRegistration(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	h.clients[client] = true
 	// Add to VM specific clients
-	h.vmClients[client.VMName] = append(h.vmClients[client.VMName], client)
+	h.vmClients[client.key()] = append(h.vmClients[client.key()], client)
 }
 
 // handleClientUnregistration unregisters a client.
 func (h *Hub) handleClientUnregistration(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	if _, ok := h.clients[client]; !ok {
 		return
 	}
@@ -171,41 +216,62 @@ func (h *Hub) handleClientUnregistration(client *Client) {
 
 // removeClientFromVM removes a client from VM-specific client list.
 func (h *Hub) removeClientFromVM(client *Client) {
-	clients := h.vmClients[client.VMName]
+	key := client.key()
+	clients := h.vmClients[key]
 	for i, c := range clients {
 		if c == client {
-			h.vmClients[client.VMName] = append(clients[:i], clients[i+1:]...)
+			h.vmClients[key] = append(clients[:i], clients[i+1:]...)
 			break
 		}
 	}
 
 	// Clean up empty VM entries
-	if len(h.vmClients[client.VMName]) == 0 {
-		delete(h.vmClients, client.VMName)
+	if len(h.vmClients[key]) == 0 {
+		delete(h.vmClients, key)
 	}
 }
 
 // handleBroadcastMessage broadcasts a message to all clients.
 func (h *Hub) handleBroadcastMessage(message *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	for client := range h.clients {
-		select {
-		case client.Send <- message:
-		default:
-			close(client.Send)
-			delete(h.clients, client)
-		}
+		h.send(client, message)
 	}
 }
 
-// SendToVM sends a message to all clients connected to a specific VM.
-func (h *Hub) SendToVM(vmName string, message *Message) {
-	clients := h.vmClients[vmName]
+// SendToVM sends a message to all clients connected to a specific VM. The VM
+// is identified by its name, suffixed with "@host" for VMs on a selected
+// libvirt host.
+func (h *Hub) SendToVM(key string, message *Message) {
+	h.sendToVM(key, message, false)
+}
+
+// sendToVM sends a message to the clients of a VM, optionally only to its
+// console clients.
+func (h *Hub) sendToVM(key string, message *Message, consoleOnly bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Dropping clients modifies the VM's client list
+	clients := append([]*Client(nil), h.vmClients[key]...)
 	for _, client := range clients {
-		select {
-		case client.Send <- message:
-		default:
-			close(client.Send)
-			delete(h.clients, client)
+		if consoleOnly && !client.IsConsole {
+			continue
 		}
+		h.send(client, message)
+	}
+}
+
+// send queues a message for a client, dropping clients that do not keep up.
+// The caller must hold mu.
+func (h *Hub) send(client *Client, message *Message) {
+	select {
+	case client.Send <- message:
+	default:
+		close(client.Send)
+		delete(h.clients, client)
+		h.removeClientFromVM(client)
 	}
 }
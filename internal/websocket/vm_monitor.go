@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"context"
+	"net"
 	"sync"
 	"time"
 
@@ -29,6 +30,7 @@ type monitoredVM struct {
 	stateChanged  time.Time
 	startTime     time.Time
 	name          string
+	host          string
 	lastStatus    vmmodels.VMStatus
 	clientCount   int
 	isMonitoring  bool
@@ -37,26 +39,9 @@ type monitoredVM struct {
 // VMManager is the interface for VM operations.
 type VMManager interface {
 	Get(ctx context.Context, name string) (*vmmodels.VM, error)
-	GetMetrics(ctx context.Context, name string) (*VMMetrics, error)
-}
-
-// VMMetrics contains VM metrics data.
-type VMMetrics struct {
-	CPU struct {
-		Utilization float64
-	}
-	Memory struct {
-		Used  uint64
-		Total uint64
-	}
-	Network struct {
-		RxBytes uint64
-		TxBytes uint64
-	}
-	Disk struct {
-		ReadBytes  uint64
-		WriteBytes uint64
-	}
+	GetMetrics(ctx context.Context, name string) (*vmmodels.Metrics, error)
+	OpenConsole(ctx context.Context, name string, opts vmmodels.ConsoleOptions) (vmmodels.ConsoleStream, error)
+	OpenGraphics(ctx context.Context, name string, graphicsType vmmodels.GraphicsType) (net.Conn, error)
 }
 
 // NewVMMonitor creates a new VM monitor.
@@ -92,60 +77,70 @@ func (m *VMMonitor) Stop() {
 	}
 }
 
-// RegisterVM starts monitoring a VM when clients connect.
-func (m *VMMonitor) RegisterVM(vmName string) {
+// RegisterVM starts monitoring a VM on a libvirt host when clients connect.
+// An empty host selects the default host.
+func (m *VMMonitor) RegisterVM(host, vmName string) {
 	m.monitoredVMsLock.Lock()
-	defer m.monitoredVMsLock.Unlock()
 
 	// Check if VM is already monitored
-	vm, exists := m.monitoredVMs[vmName]
+	key := vmKey(host, vmName)
+	vm, exists := m.monitoredVMs[key]
 	if exists {
 		vm.clientCount++
 		m.logger.Debug("Incremented client count for monitored VM",
 			logger.String("vmName", vmName),
 			logger.Int("clientCount", vm.clientCount))
+		m.monitoredVMsLock.Unlock()
 		return
 	}
 
 	// Start monitoring new VM
 	vm = &monitoredVM{
 		name:         vmName,
+		host:         host,
 		lastChecked:  time.Now(),
 		stateChanged: time.Now(),
 		clientCount:  1,
 	}
-	m.monitoredVMs[vmName] = vm
+	m.monitoredVMs[key] = vm
+	m.monitoredVMsLock.Unlock()
 
-	// Get initial VM state
-	ctx := context.Background()
+	// Get initial VM state without holding the lock, remote hosts may be
+	// slow to answer
+	ctx := hostContext(context.Background(), host)
 	vmInfo, err := m.vmManager.Get(ctx, vmName)
 	if err != nil {
 		m.logger.Error("Failed to get VM info for monitoring",
 			logger.String("vmName", vmName),
+			logger.String("host", host),
 			logger.Error(err))
 		return
 	}
 
+	m.monitoredVMsLock.Lock()
 	vm.lastStatus = vmInfo.Status
 	if vmInfo.Status == vmmodels.VMStatusRunning {
 		vm.startTime = time.Now()
 	}
+	m.monitoredVMsLock.Unlock()
 
 	// Start monitoring goroutine
-	m.startMonitoring(vmName)
+	m.startMonitoring(key)
 
 	m.logger.Info("Started monitoring VM",
 		logger.String("vmName", vmName),
-		logger.String("status", string(vm.lastStatus)))
+		logger.String("host", host),
+		logger.String("status", string(vmInfo.Status)))
 }
 
 // UnregisterVM stops monitoring a VM when all clients disconnect.
-func (m *VMMonitor) UnregisterVM(vmName string) {
+func (m *VMMonitor) UnregisterVM(host, vmName string) {
 	m.monitoredVMsLock.Lock()
 	defer m.monitoredVMsLock.Unlock()
 
 	// Check if VM is monitored
-	vm, exists := m.monitoredVMs[vmName]
+	key := vmKey(host, vmName)
+	vm, exists := m.monitoredVMs[key]
 	if !exists {
 		return
 	}
@@ -168,23 +163,23 @@ func (m *VMMonitor) UnregisterVM(vmName string) {
 	}
 
 	// Remove VM from monitored list
-	delete(m.monitoredVMs, vmName)
+	delete(m.monitoredVMs, key)
 
 	m.logger.Info("Stopped monitoring VM",
 		logger.String("vmName", vmName))
 }
 
 // startMonitoring starts the monitoring goroutine for a VM.
-func (m *VMMonitor) startMonitoring(vmName string) {
+func (m *VMMonitor) startMonitoring(key string) {
 	m.monitoredVMsLock.Lock()
-	vm := m.monitoredVMs[vmName]
-	if vm.isMonitoring {
+	vm, exists := m.monitoredVMs[key]
+	if !exists || vm.isMonitoring {
 		m.monitoredVMsLock.Unlock()
 		return
 	}
 
 	// Create cancellable context
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(hostContext(context.Background(), vm.host))
 	vm.cancelContext = cancel
 	vm.isMonitoring = true
 	m.monitoredVMsLock.Unlock()
@@ -199,14 +194,26 @@ func (m *VMMonitor) startMonitoring(vmName string) {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				m.collectAndBroadcastMetrics(ctx, vmName)
+				m.collectAndBroadcastMetrics(ctx, key)
 			}
 		}
 	}()
 }
 
-// collectAndBroadcastMetrics collects VM metrics and broadcasts them.
-func (m *VMMonitor) collectAndBroadcastMetrics(ctx context.Context, vmName string) {
+// collectAndBroadcastMetrics collects VM metrics and broadcasts them. The
+// VM is identified by its key; ctx selects its libvirt host.
+func (m *VMMonitor) collectAndBroadcastMetrics(ctx context.Context, key string) {
+	m.monitoredVMsLock.RLock()
+	vm, exists := m.monitoredVMs[key]
+	var vmName string
+	if exists {
+		vmName = vm.name
+	}
+	m.monitoredVMsLock.RUnlock()
+	if !exists {
+		return
+	}
+
 	// Get VM status
 	vmInfo, err := m.vmManager.Get(ctx, vmName)
 	if err != nil {
@@ -218,7 +225,7 @@ func (m *VMMonitor) collectAndBroadcastMetrics(ctx context.Context, vmName strin
 
 	// Update monitored VM state
 	m.monitoredVMsLock.Lock()
-	vm, exists := m.monitoredVMs[vmName]
+	vm, exists = m.monitoredVMs[key]
 	if !exists {
 		m.monitoredVMsLock.Unlock()
 		return
@@ -250,7 +257,7 @@ func (m *VMMonitor) collectAndBroadcastMetrics(ctx context.Context, vmName strin
 		uptime = int64(time.Since(vm.startTime).Seconds())
 	}
 
-	m.handler.SendVMStatus(vmName, vmInfo.Status, vm.stateChanged, uptime)
+	m.handler.SendVMStatus(key, vmInfo.Status, vm.stateChanged, uptime)
 
 	// Get and send metrics if VM is running
 	if vmInfo.Status == vmmodels.VMStatusRunning {
@@ -262,19 +269,16 @@ func (m *VMMonitor) collectAndBroadcastMetrics(ctx context.Context, vmName strin
 			return
 		}
 
-		m.handler.SendVMMetrics(
-			vmName,
-			metrics.CPU.Utilization,
-			metrics.Memory.Used,
-			metrics.Memory.Total,
-			metrics.Network.RxBytes,
-			metrics.Network.TxBytes,
-			metrics.Disk.ReadBytes,
-			metrics.Disk.WriteBytes,
-		)
+		m.handler.SendVMMetrics(key, metrics)
 	}
 }
 
+// RefreshVM immediately collects and broadcasts the state of a monitored VM.
+// The VM is identified as in Hub.SendToVM; ctx selects its libvirt host.
+func (m *VMMonitor) RefreshVM(ctx context.Context, key string) {
+	m.collectAndBroadcastMetrics(ctx, key)
+}
+
 // cleanupRoutine periodically cleans up stale VM monitoring.
 func (m *VMMonitor) cleanupRoutine() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -297,7 +301,7 @@ func (m *VMMonitor) cleanupStaleMonitoring() {
 
 	staleTime := time.Now().Add(-15 * time.Minute)
 
-	for name, vm := range m.monitoredVMs {
+	for key, vm := range m.monitoredVMs {
 		if vm.lastChecked.Before(staleTime) {
 			// Stop monitoring goroutine
 			if vm.isMonitoring && vm.cancelContext != nil {
@@ -305,10 +309,11 @@ func (m *VMMonitor) cleanupStaleMonitoring() {
 			}
 
 			// Remove from monitored VMs
-			delete(m.monitoredVMs, name)
+			delete(m.monitoredVMs, key)
 
 			m.logger.Info("Cleaned up stale VM monitoring",
-				logger.String("vmName", name),
+				logger.String("vmName", vm.name),
+				logger.String("host", vm.host),
 				logger.Time("lastChecked", vm.lastChecked))
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"time"
@@ -36,6 +37,9 @@ var ExecuteCommand ExecuteCommandFunc = executeCommandImpl
 
 // CommandOptions holds options for command execution.
 type CommandOptions struct {
+	// Stdout, when set, also receives the standard output while the
+	// command runs, such as progress reports
+	Stdout         io.Writer
 	Directory      string
 	Environment    []string
 	StdinData      []byte
@@ -71,11 +75,16 @@ func executeCommandImpl(ctx context.Context, name string, args []string, opts Co
 	// Create buffers for output
 	var stdout, stderr bytes.Buffer
 
+	var output io.Writer = &stdout
+	if opts.Stdout != nil {
+		output = io.MultiWriter(&stdout, opts.Stdout)
+	}
+
 	if opts.CombinedOutput {
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stdout
+		cmd.Stdout = output
+		cmd.Stderr = output
 	} else {
-		cmd.Stdout = &stdout
+		cmd.Stdout = output
 		cmd.Stderr = &stderr
 	}
 
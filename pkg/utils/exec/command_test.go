@@ -237,6 +237,27 @@ func TestExecuteCommandWithCombinedOutput(t *testing.T) {
 	}
 }
 
+func TestExecuteCommandWithStdout(t *testing.T) {
+	var stream strings.Builder
+	opts := CommandOptions{
+		Stdout: &stream,
+	}
+
+	ctx := context.Background()
+	output, err := ExecuteCommand(ctx, getEchoCmdName(), getEchoArgs("streamed"), opts)
+	if err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+
+	// The writer receives the output as well as the caller
+	if !strings.Contains(stream.String(), "streamed") {
+		t.Errorf("Expected streamed output to contain 'streamed', got: '%s'", stream.String())
+	}
+	if !strings.Contains(string(output), "streamed") {
+		t.Errorf("Expected output to contain 'streamed', got: '%s'", string(output))
+	}
+}
+
 // Helper functions to handle differences between operating systems
 func getEchoCmdName() string {
 	if runtime.GOOS == "windows" {
@@ -11,8 +11,11 @@ package mocks_domain
 
 import (
 	context "context"
+	net "net"
 	reflect "reflect"
+	time "time"
 
+	domain "github.com/threatflux/libgo/internal/libvirt/domain"
 	vm "github.com/threatflux/libgo/internal/models/vm"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +44,122 @@ func (m *MockManager) EXPECT() *MockManagerMockRecorder {
 	return m.recorder
 }
 
+// AbortBackup mocks base method.
+func (m *MockManager) AbortBackup(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortBackup", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortBackup indicates an expected call of AbortBackup.
+func (mr *MockManagerMockRecorder) AbortBackup(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortBackup", reflect.TypeOf((*MockManager)(nil).AbortBackup), ctx, name)
+}
+
+// AbortBlockJob mocks base method.
+func (m *MockManager) AbortBlockJob(ctx context.Context, name, device string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortBlockJob", ctx, name, device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortBlockJob indicates an expected call of AbortBlockJob.
+func (mr *MockManagerMockRecorder) AbortBlockJob(ctx, name, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortBlockJob", reflect.TypeOf((*MockManager)(nil).AbortBlockJob), ctx, name, device)
+}
+
+// BeginBackup mocks base method.
+func (m *MockManager) BeginBackup(ctx context.Context, name string, params vm.BackupParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginBackup", ctx, name, params)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginBackup indicates an expected call of BeginBackup.
+func (mr *MockManagerMockRecorder) BeginBackup(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginBackup", reflect.TypeOf((*MockManager)(nil).BeginBackup), ctx, name, params)
+}
+
+// BlockCommit mocks base method.
+func (m *MockManager) BlockCommit(ctx context.Context, name string, params vm.BlockCommitParams) (*vm.BlockJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockCommit", ctx, name, params)
+	ret0, _ := ret[0].(*vm.BlockJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockCommit indicates an expected call of BlockCommit.
+func (mr *MockManagerMockRecorder) BlockCommit(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockCommit", reflect.TypeOf((*MockManager)(nil).BlockCommit), ctx, name, params)
+}
+
+// BlockPull mocks base method.
+func (m *MockManager) BlockPull(ctx context.Context, name string, params vm.BlockPullParams) (*vm.BlockJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockPull", ctx, name, params)
+	ret0, _ := ret[0].(*vm.BlockJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockPull indicates an expected call of BlockPull.
+func (mr *MockManagerMockRecorder) BlockPull(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockPull", reflect.TypeOf((*MockManager)(nil).BlockPull), ctx, name, params)
+}
+
+// CommitDiskOverlays mocks base method.
+func (m *MockManager) CommitDiskOverlays(ctx context.Context, name string, overlays []domain.DiskOverlay) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitDiskOverlays", ctx, name, overlays)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitDiskOverlays indicates an expected call of CommitDiskOverlays.
+func (mr *MockManagerMockRecorder) CommitDiskOverlays(ctx, name, overlays any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitDiskOverlays", reflect.TypeOf((*MockManager)(nil).CommitDiskOverlays), ctx, name, overlays)
+}
+
+// CompareCPU mocks base method.
+func (m *MockManager) CompareCPU(ctx context.Context, cpuXML string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareCPU", ctx, cpuXML)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareCPU indicates an expected call of CompareCPU.
+func (mr *MockManagerMockRecorder) CompareCPU(ctx, cpuXML any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareCPU", reflect.TypeOf((*MockManager)(nil).CompareCPU), ctx, cpuXML)
+}
+
+// CompleteBlockJob mocks base method.
+func (m *MockManager) CompleteBlockJob(ctx context.Context, name, device string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteBlockJob", ctx, name, device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteBlockJob indicates an expected call of CompleteBlockJob.
+func (mr *MockManagerMockRecorder) CompleteBlockJob(ctx, name, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteBlockJob", reflect.TypeOf((*MockManager)(nil).CompleteBlockJob), ctx, name, device)
+}
+
 // Create mocks base method.
 func (m *MockManager) Create(ctx context.Context, params vm.VMParams) (*vm.VM, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +175,21 @@ func (mr *MockManagerMockRecorder) Create(ctx, params any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockManager)(nil).Create), ctx, params)
 }
 
+// CreateDiskOverlays mocks base method.
+func (m *MockManager) CreateDiskOverlays(ctx context.Context, name string) ([]domain.DiskOverlay, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDiskOverlays", ctx, name)
+	ret0, _ := ret[0].([]domain.DiskOverlay)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDiskOverlays indicates an expected call of CreateDiskOverlays.
+func (mr *MockManagerMockRecorder) CreateDiskOverlays(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDiskOverlays", reflect.TypeOf((*MockManager)(nil).CreateDiskOverlays), ctx, name)
+}
+
 // CreateSnapshot mocks base method.
 func (m *MockManager) CreateSnapshot(ctx context.Context, vmName string, params vm.SnapshotParams) (*vm.Snapshot, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +205,51 @@ func (mr *MockManagerMockRecorder) CreateSnapshot(ctx, vmName, params any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSnapshot", reflect.TypeOf((*MockManager)(nil).CreateSnapshot), ctx, vmName, params)
 }
 
+// DefineClone mocks base method.
+func (m *MockManager) DefineClone(ctx context.Context, sourceName string, spec domain.CloneSpec) (*vm.VM, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DefineClone", ctx, sourceName, spec)
+	ret0, _ := ret[0].(*vm.VM)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DefineClone indicates an expected call of DefineClone.
+func (mr *MockManagerMockRecorder) DefineClone(ctx, sourceName, spec any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefineClone", reflect.TypeOf((*MockManager)(nil).DefineClone), ctx, sourceName, spec)
+}
+
+// DefineFromDefinition mocks base method.
+func (m *MockManager) DefineFromDefinition(ctx context.Context, sourceXML string, spec domain.CloneSpec) (*vm.VM, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DefineFromDefinition", ctx, sourceXML, spec)
+	ret0, _ := ret[0].(*vm.VM)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DefineFromDefinition indicates an expected call of DefineFromDefinition.
+func (mr *MockManagerMockRecorder) DefineFromDefinition(ctx, sourceXML, spec any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefineFromDefinition", reflect.TypeOf((*MockManager)(nil).DefineFromDefinition), ctx, sourceXML, spec)
+}
+
+// DefineImported mocks base method.
+func (m *MockManager) DefineImported(ctx context.Context, spec domain.ImportSpec) (*vm.VM, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DefineImported", ctx, spec)
+	ret0, _ := ret[0].(*vm.VM)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DefineImported indicates an expected call of DefineImported.
+func (mr *MockManagerMockRecorder) DefineImported(ctx, spec any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefineImported", reflect.TypeOf((*MockManager)(nil).DefineImported), ctx, spec)
+}
+
 // Delete mocks base method.
 func (m *MockManager) Delete(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -85,6 +264,20 @@ func (mr *MockManagerMockRecorder) Delete(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockManager)(nil).Delete), ctx, name)
 }
 
+// DeleteCheckpoint mocks base method.
+func (m *MockManager) DeleteCheckpoint(ctx context.Context, name, checkpoint string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCheckpoint", ctx, name, checkpoint)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCheckpoint indicates an expected call of DeleteCheckpoint.
+func (mr *MockManagerMockRecorder) DeleteCheckpoint(ctx, name, checkpoint any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCheckpoint", reflect.TypeOf((*MockManager)(nil).DeleteCheckpoint), ctx, name, checkpoint)
+}
+
 // DeleteSnapshot mocks base method.
 func (m *MockManager) DeleteSnapshot(ctx context.Context, vmName, snapshotName string) error {
 	m.ctrl.T.Helper()
@@ -113,6 +306,21 @@ func (mr *MockManagerMockRecorder) ForceStop(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceStop", reflect.TypeOf((*MockManager)(nil).ForceStop), ctx, name)
 }
 
+// FreezeFilesystems mocks base method.
+func (m *MockManager) FreezeFilesystems(ctx context.Context, name string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeFilesystems", ctx, name)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreezeFilesystems indicates an expected call of FreezeFilesystems.
+func (mr *MockManagerMockRecorder) FreezeFilesystems(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeFilesystems", reflect.TypeOf((*MockManager)(nil).FreezeFilesystems), ctx, name)
+}
+
 // Get mocks base method.
 func (m *MockManager) Get(ctx context.Context, name string) (*vm.VM, error) {
 	m.ctrl.T.Helper()
@@ -128,6 +336,111 @@ func (mr *MockManagerMockRecorder) Get(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockManager)(nil).Get), ctx, name)
 }
 
+// GetBackupJob mocks base method.
+func (m *MockManager) GetBackupJob(ctx context.Context, name string) (*vm.BackupJobInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBackupJob", ctx, name)
+	ret0, _ := ret[0].(*vm.BackupJobInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBackupJob indicates an expected call of GetBackupJob.
+func (mr *MockManagerMockRecorder) GetBackupJob(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBackupJob", reflect.TypeOf((*MockManager)(nil).GetBackupJob), ctx, name)
+}
+
+// GetDefinition mocks base method.
+func (m *MockManager) GetDefinition(ctx context.Context, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefinition", ctx, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDefinition indicates an expected call of GetDefinition.
+func (mr *MockManagerMockRecorder) GetDefinition(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefinition", reflect.TypeOf((*MockManager)(nil).GetDefinition), ctx, name)
+}
+
+// GetFilesystems mocks base method.
+func (m *MockManager) GetFilesystems(ctx context.Context, name string) ([]vm.GuestFilesystem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFilesystems", ctx, name)
+	ret0, _ := ret[0].([]vm.GuestFilesystem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFilesystems indicates an expected call of GetFilesystems.
+func (mr *MockManagerMockRecorder) GetFilesystems(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilesystems", reflect.TypeOf((*MockManager)(nil).GetFilesystems), ctx, name)
+}
+
+// GetFreeMemory mocks base method.
+func (m *MockManager) GetFreeMemory(ctx context.Context) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFreeMemory", ctx)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFreeMemory indicates an expected call of GetFreeMemory.
+func (mr *MockManagerMockRecorder) GetFreeMemory(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFreeMemory", reflect.TypeOf((*MockManager)(nil).GetFreeMemory), ctx)
+}
+
+// GetGraphics mocks base method.
+func (m *MockManager) GetGraphics(ctx context.Context, name string) ([]vm.GraphicsInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGraphics", ctx, name)
+	ret0, _ := ret[0].([]vm.GraphicsInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGraphics indicates an expected call of GetGraphics.
+func (mr *MockManagerMockRecorder) GetGraphics(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGraphics", reflect.TypeOf((*MockManager)(nil).GetGraphics), ctx, name)
+}
+
+// GetGuestInfo mocks base method.
+func (m *MockManager) GetGuestInfo(ctx context.Context, name string) (*vm.GuestInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGuestInfo", ctx, name)
+	ret0, _ := ret[0].(*vm.GuestInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGuestInfo indicates an expected call of GetGuestInfo.
+func (mr *MockManagerMockRecorder) GetGuestInfo(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGuestInfo", reflect.TypeOf((*MockManager)(nil).GetGuestInfo), ctx, name)
+}
+
+// GetMigratableCPU mocks base method.
+func (m *MockManager) GetMigratableCPU(ctx context.Context, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMigratableCPU", ctx, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMigratableCPU indicates an expected call of GetMigratableCPU.
+func (mr *MockManagerMockRecorder) GetMigratableCPU(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMigratableCPU", reflect.TypeOf((*MockManager)(nil).GetMigratableCPU), ctx, name)
+}
+
 // GetSnapshot mocks base method.
 func (m *MockManager) GetSnapshot(ctx context.Context, vmName, snapshotName string) (*vm.Snapshot, error) {
 	m.ctrl.T.Helper()
@@ -143,6 +456,21 @@ func (mr *MockManagerMockRecorder) GetSnapshot(ctx, vmName, snapshotName any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshot", reflect.TypeOf((*MockManager)(nil).GetSnapshot), ctx, vmName, snapshotName)
 }
 
+// GetStats mocks base method.
+func (m *MockManager) GetStats(ctx context.Context, name string) (*vm.Metrics, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", ctx, name)
+	ret0, _ := ret[0].(*vm.Metrics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockManagerMockRecorder) GetStats(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockManager)(nil).GetStats), ctx, name)
+}
+
 // GetXML mocks base method.
 func (m *MockManager) GetXML(ctx context.Context, name string) (string, error) {
 	m.ctrl.T.Helper()
@@ -158,6 +486,21 @@ func (mr *MockManagerMockRecorder) GetXML(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetXML", reflect.TypeOf((*MockManager)(nil).GetXML), ctx, name)
 }
 
+// GuestExec mocks base method.
+func (m *MockManager) GuestExec(ctx context.Context, name string, command vm.GuestCommand) (*vm.GuestCommandResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GuestExec", ctx, name, command)
+	ret0, _ := ret[0].(*vm.GuestCommandResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GuestExec indicates an expected call of GuestExec.
+func (mr *MockManagerMockRecorder) GuestExec(ctx, name, command any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GuestExec", reflect.TypeOf((*MockManager)(nil).GuestExec), ctx, name, command)
+}
+
 // List mocks base method.
 func (m *MockManager) List(ctx context.Context) ([]*vm.VM, error) {
 	m.ctrl.T.Helper()
@@ -173,6 +516,36 @@ func (mr *MockManagerMockRecorder) List(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockManager)(nil).List), ctx)
 }
 
+// ListBlockJobs mocks base method.
+func (m *MockManager) ListBlockJobs(ctx context.Context, name string) ([]vm.BlockJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlockJobs", ctx, name)
+	ret0, _ := ret[0].([]vm.BlockJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlockJobs indicates an expected call of ListBlockJobs.
+func (mr *MockManagerMockRecorder) ListBlockJobs(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlockJobs", reflect.TypeOf((*MockManager)(nil).ListBlockJobs), ctx, name)
+}
+
+// ListCheckpoints mocks base method.
+func (m *MockManager) ListCheckpoints(ctx context.Context, name string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCheckpoints", ctx, name)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCheckpoints indicates an expected call of ListCheckpoints.
+func (mr *MockManagerMockRecorder) ListCheckpoints(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCheckpoints", reflect.TypeOf((*MockManager)(nil).ListCheckpoints), ctx, name)
+}
+
 // ListSnapshots mocks base method.
 func (m *MockManager) ListSnapshots(ctx context.Context, vmName string, opts vm.SnapshotListOptions) ([]*vm.Snapshot, error) {
 	m.ctrl.T.Helper()
@@ -188,6 +561,120 @@ func (mr *MockManagerMockRecorder) ListSnapshots(ctx, vmName, opts any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshots", reflect.TypeOf((*MockManager)(nil).ListSnapshots), ctx, vmName, opts)
 }
 
+// Migrate mocks base method.
+func (m *MockManager) Migrate(ctx context.Context, name string, opts domain.MigrateOptions, progress func(domain.MigrationProgress)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Migrate", ctx, name, opts, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Migrate indicates an expected call of Migrate.
+func (mr *MockManagerMockRecorder) Migrate(ctx, name, opts, progress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Migrate", reflect.TypeOf((*MockManager)(nil).Migrate), ctx, name, opts, progress)
+}
+
+// OpenConsole mocks base method.
+func (m *MockManager) OpenConsole(ctx context.Context, name string, opts vm.ConsoleOptions) (vm.ConsoleStream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenConsole", ctx, name, opts)
+	ret0, _ := ret[0].(vm.ConsoleStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenConsole indicates an expected call of OpenConsole.
+func (mr *MockManagerMockRecorder) OpenConsole(ctx, name, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenConsole", reflect.TypeOf((*MockManager)(nil).OpenConsole), ctx, name, opts)
+}
+
+// OpenGraphics mocks base method.
+func (m *MockManager) OpenGraphics(ctx context.Context, name string, graphicsType vm.GraphicsType) (net.Conn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenGraphics", ctx, name, graphicsType)
+	ret0, _ := ret[0].(net.Conn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenGraphics indicates an expected call of OpenGraphics.
+func (mr *MockManagerMockRecorder) OpenGraphics(ctx, name, graphicsType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenGraphics", reflect.TypeOf((*MockManager)(nil).OpenGraphics), ctx, name, graphicsType)
+}
+
+// Pause mocks base method.
+func (m *MockManager) Pause(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pause", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Pause indicates an expected call of Pause.
+func (mr *MockManagerMockRecorder) Pause(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockManager)(nil).Pause), ctx, name)
+}
+
+// Reboot mocks base method.
+func (m *MockManager) Reboot(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reboot", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reboot indicates an expected call of Reboot.
+func (mr *MockManagerMockRecorder) Reboot(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reboot", reflect.TypeOf((*MockManager)(nil).Reboot), ctx, name)
+}
+
+// Reset mocks base method.
+func (m *MockManager) Reset(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockManagerMockRecorder) Reset(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockManager)(nil).Reset), ctx, name)
+}
+
+// ResizeDisk mocks base method.
+func (m *MockManager) ResizeDisk(ctx context.Context, name, device string, capacityBytes uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeDisk", ctx, name, device, capacityBytes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeDisk indicates an expected call of ResizeDisk.
+func (mr *MockManagerMockRecorder) ResizeDisk(ctx, name, device, capacityBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeDisk", reflect.TypeOf((*MockManager)(nil).ResizeDisk), ctx, name, device, capacityBytes)
+}
+
+// Resume mocks base method.
+func (m *MockManager) Resume(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockManagerMockRecorder) Resume(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockManager)(nil).Resume), ctx, name)
+}
+
 // RevertSnapshot mocks base method.
 func (m *MockManager) RevertSnapshot(ctx context.Context, vmName, snapshotName string) error {
 	m.ctrl.T.Helper()
@@ -202,6 +689,62 @@ func (mr *MockManagerMockRecorder) RevertSnapshot(ctx, vmName, snapshotName any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertSnapshot", reflect.TypeOf((*MockManager)(nil).RevertSnapshot), ctx, vmName, snapshotName)
 }
 
+// SendKeys mocks base method.
+func (m *MockManager) SendKeys(ctx context.Context, name string, keys []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendKeys", ctx, name, keys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendKeys indicates an expected call of SendKeys.
+func (mr *MockManagerMockRecorder) SendKeys(ctx, name, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendKeys", reflect.TypeOf((*MockManager)(nil).SendKeys), ctx, name, keys)
+}
+
+// SetDiskIOTune mocks base method.
+func (m *MockManager) SetDiskIOTune(ctx context.Context, name, device string, tune vm.DiskIOTune) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDiskIOTune", ctx, name, device, tune)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDiskIOTune indicates an expected call of SetDiskIOTune.
+func (mr *MockManagerMockRecorder) SetDiskIOTune(ctx, name, device, tune any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDiskIOTune", reflect.TypeOf((*MockManager)(nil).SetDiskIOTune), ctx, name, device, tune)
+}
+
+// SetUserPassword mocks base method.
+func (m *MockManager) SetUserPassword(ctx context.Context, name, username, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPassword", ctx, name, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPassword indicates an expected call of SetUserPassword.
+func (mr *MockManagerMockRecorder) SetUserPassword(ctx, name, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPassword", reflect.TypeOf((*MockManager)(nil).SetUserPassword), ctx, name, username, password)
+}
+
+// ShutdownGuest mocks base method.
+func (m *MockManager) ShutdownGuest(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShutdownGuest", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ShutdownGuest indicates an expected call of ShutdownGuest.
+func (mr *MockManagerMockRecorder) ShutdownGuest(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShutdownGuest", reflect.TypeOf((*MockManager)(nil).ShutdownGuest), ctx, name)
+}
+
 // Start mocks base method.
 func (m *MockManager) Start(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -216,6 +759,20 @@ func (mr *MockManagerMockRecorder) Start(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockManager)(nil).Start), ctx, name)
 }
 
+// StartPostCopy mocks base method.
+func (m *MockManager) StartPostCopy(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartPostCopy", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartPostCopy indicates an expected call of StartPostCopy.
+func (mr *MockManagerMockRecorder) StartPostCopy(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartPostCopy", reflect.TypeOf((*MockManager)(nil).StartPostCopy), ctx, name)
+}
+
 // Stop mocks base method.
 func (m *MockManager) Stop(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -230,6 +787,95 @@ func (mr *MockManagerMockRecorder) Stop(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockManager)(nil).Stop), ctx, name)
 }
 
+// SyncTime mocks base method.
+func (m *MockManager) SyncTime(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncTime", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncTime indicates an expected call of SyncTime.
+func (mr *MockManagerMockRecorder) SyncTime(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncTime", reflect.TypeOf((*MockManager)(nil).SyncTime), ctx, name)
+}
+
+// ThawFilesystems mocks base method.
+func (m *MockManager) ThawFilesystems(ctx context.Context, name string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ThawFilesystems", ctx, name)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ThawFilesystems indicates an expected call of ThawFilesystems.
+func (mr *MockManagerMockRecorder) ThawFilesystems(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ThawFilesystems", reflect.TypeOf((*MockManager)(nil).ThawFilesystems), ctx, name)
+}
+
+// Update mocks base method.
+func (m *MockManager) Update(ctx context.Context, name string, params vm.UpdateParams) (*vm.DefinitionUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, name, params)
+	ret0, _ := ret[0].(*vm.DefinitionUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockManagerMockRecorder) Update(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockManager)(nil).Update), ctx, name, params)
+}
+
+// UpdateDefinition mocks base method.
+func (m *MockManager) UpdateDefinition(ctx context.Context, name, definition string) (*vm.DefinitionUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDefinition", ctx, name, definition)
+	ret0, _ := ret[0].(*vm.DefinitionUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDefinition indicates an expected call of UpdateDefinition.
+func (mr *MockManagerMockRecorder) UpdateDefinition(ctx, name, definition any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDefinition", reflect.TypeOf((*MockManager)(nil).UpdateDefinition), ctx, name, definition)
+}
+
+// VirtioFSSupported mocks base method.
+func (m *MockManager) VirtioFSSupported(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VirtioFSSupported", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VirtioFSSupported indicates an expected call of VirtioFSSupported.
+func (mr *MockManagerMockRecorder) VirtioFSSupported(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VirtioFSSupported", reflect.TypeOf((*MockManager)(nil).VirtioFSSupported), ctx)
+}
+
+// WaitForShutoff mocks base method.
+func (m *MockManager) WaitForShutoff(ctx context.Context, name string, timeout time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForShutoff", ctx, name, timeout)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitForShutoff indicates an expected call of WaitForShutoff.
+func (mr *MockManagerMockRecorder) WaitForShutoff(ctx, name, timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForShutoff", reflect.TypeOf((*MockManager)(nil).WaitForShutoff), ctx, name, timeout)
+}
+
 // MockXMLBuilder is a mock of XMLBuilder interface.
 type MockXMLBuilder struct {
 	isgomock struct{}
@@ -268,3 +914,18 @@ func (mr *MockXMLBuilderMockRecorder) BuildDomainXML(params any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildDomainXML", reflect.TypeOf((*MockXMLBuilder)(nil).BuildDomainXML), params)
 }
+
+// BuildImportXML mocks base method.
+func (m *MockXMLBuilder) BuildImportXML(spec domain.ImportSpec) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildImportXML", spec)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildImportXML indicates an expected call of BuildImportXML.
+func (mr *MockXMLBuilderMockRecorder) BuildImportXML(spec any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildImportXML", reflect.TypeOf((*MockXMLBuilder)(nil).BuildImportXML), spec)
+}
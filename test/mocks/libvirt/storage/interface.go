@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -source=internal/libvirt/storage/interface.go -destination=./test/mocks/libvirt/storage/interface.go -package=mocks_storage
+//	mockgen -source=internal/libvirt/storage/interface.go -destination=test/mocks/libvirt/storage/interface.go -package=mocks_storage
 //
 
 // Package mocks_storage is a generated GoMock package.
@@ -13,6 +13,7 @@ import (
 	context "context"
 	io "io"
 	reflect "reflect"
+	time "time"
 
 	libvirt "github.com/digitalocean/go-libvirt"
 	storage "github.com/threatflux/libgo/internal/libvirt/storage"
@@ -116,6 +117,21 @@ func (mr *MockPoolManagerMockRecorder) GetInfo(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInfo", reflect.TypeOf((*MockPoolManager)(nil).GetInfo), ctx, name)
 }
 
+// GetUsage mocks base method.
+func (m *MockPoolManager) GetUsage(ctx context.Context, name string) (*storage.PoolUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx, name)
+	ret0, _ := ret[0].(*storage.PoolUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockPoolManagerMockRecorder) GetUsage(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockPoolManager)(nil).GetUsage), ctx, name)
+}
+
 // GetXML mocks base method.
 func (m *MockPoolManager) GetXML(ctx context.Context, name string) (string, error) {
 	m.ctrl.T.Helper()
@@ -255,6 +271,34 @@ func (mr *MockVolumeManagerMockRecorder) Clone(ctx, poolName, sourceVolName, des
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockVolumeManager)(nil).Clone), ctx, poolName, sourceVolName, destVolName)
 }
 
+// CloneLinked mocks base method.
+func (m *MockVolumeManager) CloneLinked(ctx context.Context, poolName, sourceVolName, destVolName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneLinked", ctx, poolName, sourceVolName, destVolName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloneLinked indicates an expected call of CloneLinked.
+func (mr *MockVolumeManagerMockRecorder) CloneLinked(ctx, poolName, sourceVolName, destVolName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneLinked", reflect.TypeOf((*MockVolumeManager)(nil).CloneLinked), ctx, poolName, sourceVolName, destVolName)
+}
+
+// CloneTo mocks base method.
+func (m *MockVolumeManager) CloneTo(ctx context.Context, poolName, sourceVolName, destPoolName, destVolName, format string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneTo", ctx, poolName, sourceVolName, destPoolName, destVolName, format)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloneTo indicates an expected call of CloneTo.
+func (mr *MockVolumeManagerMockRecorder) CloneTo(ctx, poolName, sourceVolName, destPoolName, destVolName, format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneTo", reflect.TypeOf((*MockVolumeManager)(nil).CloneTo), ctx, poolName, sourceVolName, destPoolName, destVolName, format)
+}
+
 // Create mocks base method.
 func (m *MockVolumeManager) Create(ctx context.Context, poolName, volName string, capacityBytes uint64, format string) error {
 	m.ctrl.T.Helper()
@@ -283,6 +327,20 @@ func (mr *MockVolumeManagerMockRecorder) CreateFromImage(ctx, poolName, volName,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFromImage", reflect.TypeOf((*MockVolumeManager)(nil).CreateFromImage), ctx, poolName, volName, imagePath, format)
 }
 
+// CreateWithParams mocks base method.
+func (m *MockVolumeManager) CreateWithParams(ctx context.Context, poolName string, params *storage.CreateVolumeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithParams", ctx, poolName, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWithParams indicates an expected call of CreateWithParams.
+func (mr *MockVolumeManagerMockRecorder) CreateWithParams(ctx, poolName, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithParams", reflect.TypeOf((*MockVolumeManager)(nil).CreateWithParams), ctx, poolName, params)
+}
+
 // Delete mocks base method.
 func (m *MockVolumeManager) Delete(ctx context.Context, poolName, volName string) error {
 	m.ctrl.T.Helper()
@@ -311,6 +369,20 @@ func (mr *MockVolumeManagerMockRecorder) Download(ctx, poolName, volName, writer
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockVolumeManager)(nil).Download), ctx, poolName, volName, writer)
 }
 
+// DownloadRange mocks base method.
+func (m *MockVolumeManager) DownloadRange(ctx context.Context, poolName, volName string, offset, length uint64, writer io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadRange", ctx, poolName, volName, offset, length, writer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadRange indicates an expected call of DownloadRange.
+func (mr *MockVolumeManagerMockRecorder) DownloadRange(ctx, poolName, volName, offset, length, writer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadRange", reflect.TypeOf((*MockVolumeManager)(nil).DownloadRange), ctx, poolName, volName, offset, length, writer)
+}
+
 // GetInfo mocks base method.
 func (m *MockVolumeManager) GetInfo(ctx context.Context, poolName, volName string) (*storage.StorageVolumeInfo, error) {
 	m.ctrl.T.Helper()
@@ -341,6 +413,21 @@ func (mr *MockVolumeManagerMockRecorder) GetPath(ctx, poolName, volName any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPath", reflect.TypeOf((*MockVolumeManager)(nil).GetPath), ctx, poolName, volName)
 }
 
+// GetSource mocks base method.
+func (m *MockVolumeManager) GetSource(ctx context.Context, poolName, volName string) (*storage.VolumeSource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSource", ctx, poolName, volName)
+	ret0, _ := ret[0].(*storage.VolumeSource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSource indicates an expected call of GetSource.
+func (mr *MockVolumeManagerMockRecorder) GetSource(ctx, poolName, volName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSource", reflect.TypeOf((*MockVolumeManager)(nil).GetSource), ctx, poolName, volName)
+}
+
 // GetXML mocks base method.
 func (m *MockVolumeManager) GetXML(ctx context.Context, poolName, volName string) (string, error) {
 	m.ctrl.T.Helper()
@@ -371,6 +458,22 @@ func (mr *MockVolumeManagerMockRecorder) List(ctx, poolName any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockVolumeManager)(nil).List), ctx, poolName)
 }
 
+// LookupByPath mocks base method.
+func (m *MockVolumeManager) LookupByPath(ctx context.Context, path string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookupByPath", ctx, path)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LookupByPath indicates an expected call of LookupByPath.
+func (mr *MockVolumeManagerMockRecorder) LookupByPath(ctx, path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupByPath", reflect.TypeOf((*MockVolumeManager)(nil).LookupByPath), ctx, path)
+}
+
 // Resize mocks base method.
 func (m *MockVolumeManager) Resize(ctx context.Context, poolName, volName string, capacityBytes uint64) error {
 	m.ctrl.T.Helper()
@@ -437,6 +540,36 @@ func (m *MockXMLBuilder) EXPECT() *MockXMLBuilderMockRecorder {
 	return m.recorder
 }
 
+// BuildEncryptedVolumeXML mocks base method.
+func (m *MockXMLBuilder) BuildEncryptedVolumeXML(params *storage.CreateVolumeParams, secretUUID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildEncryptedVolumeXML", params, secretUUID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildEncryptedVolumeXML indicates an expected call of BuildEncryptedVolumeXML.
+func (mr *MockXMLBuilderMockRecorder) BuildEncryptedVolumeXML(params, secretUUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildEncryptedVolumeXML", reflect.TypeOf((*MockXMLBuilder)(nil).BuildEncryptedVolumeXML), params, secretUUID)
+}
+
+// BuildOverlayVolumeXML mocks base method.
+func (m *MockXMLBuilder) BuildOverlayVolumeXML(volName string, capacityBytes uint64, backingPath, backingFormat string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildOverlayVolumeXML", volName, capacityBytes, backingPath, backingFormat)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildOverlayVolumeXML indicates an expected call of BuildOverlayVolumeXML.
+func (mr *MockXMLBuilderMockRecorder) BuildOverlayVolumeXML(volName, capacityBytes, backingPath, backingFormat any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildOverlayVolumeXML", reflect.TypeOf((*MockXMLBuilder)(nil).BuildOverlayVolumeXML), volName, capacityBytes, backingPath, backingFormat)
+}
+
 // BuildStoragePoolXML mocks base method.
 func (m *MockXMLBuilder) BuildStoragePoolXML(name, path string) (string, error) {
 	m.ctrl.T.Helper()
@@ -452,6 +585,21 @@ func (mr *MockXMLBuilderMockRecorder) BuildStoragePoolXML(name, path any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildStoragePoolXML", reflect.TypeOf((*MockXMLBuilder)(nil).BuildStoragePoolXML), name, path)
 }
 
+// BuildStoragePoolXMLWithParams mocks base method.
+func (m *MockXMLBuilder) BuildStoragePoolXMLWithParams(params *storage.CreatePoolParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildStoragePoolXMLWithParams", params)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildStoragePoolXMLWithParams indicates an expected call of BuildStoragePoolXMLWithParams.
+func (mr *MockXMLBuilderMockRecorder) BuildStoragePoolXMLWithParams(params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildStoragePoolXMLWithParams", reflect.TypeOf((*MockXMLBuilder)(nil).BuildStoragePoolXMLWithParams), params)
+}
+
 // BuildStorageVolumeXML mocks base method.
 func (m *MockXMLBuilder) BuildStorageVolumeXML(volName string, capacityBytes uint64, format string) (string, error) {
 	m.ctrl.T.Helper()
@@ -466,3 +614,333 @@ func (mr *MockXMLBuilderMockRecorder) BuildStorageVolumeXML(volName, capacityByt
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildStorageVolumeXML", reflect.TypeOf((*MockXMLBuilder)(nil).BuildStorageVolumeXML), volName, capacityBytes, format)
 }
+
+// MockImageLibrary is a mock of ImageLibrary interface.
+type MockImageLibrary struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockImageLibraryMockRecorder
+}
+
+// MockImageLibraryMockRecorder is the mock recorder for MockImageLibrary.
+type MockImageLibraryMockRecorder struct {
+	mock *MockImageLibrary
+}
+
+// NewMockImageLibrary creates a new mock instance.
+func NewMockImageLibrary(ctrl *gomock.Controller) *MockImageLibrary {
+	mock := &MockImageLibrary{ctrl: ctrl}
+	mock.recorder = &MockImageLibraryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImageLibrary) EXPECT() *MockImageLibraryMockRecorder {
+	return m.recorder
+}
+
+// CreateOverlay mocks base method.
+func (m *MockImageLibrary) CreateOverlay(ctx context.Context, name string, params *storage.OverlayParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOverlay", ctx, name, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOverlay indicates an expected call of CreateOverlay.
+func (mr *MockImageLibraryMockRecorder) CreateOverlay(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOverlay", reflect.TypeOf((*MockImageLibrary)(nil).CreateOverlay), ctx, name, params)
+}
+
+// Delete mocks base method.
+func (m *MockImageLibrary) Delete(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockImageLibraryMockRecorder) Delete(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockImageLibrary)(nil).Delete), ctx, name)
+}
+
+// Get mocks base method.
+func (m *MockImageLibrary) Get(ctx context.Context, name string) (*storage.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, name)
+	ret0, _ := ret[0].(*storage.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockImageLibraryMockRecorder) Get(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockImageLibrary)(nil).Get), ctx, name)
+}
+
+// List mocks base method.
+func (m *MockImageLibrary) List(ctx context.Context) ([]*storage.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]*storage.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockImageLibraryMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockImageLibrary)(nil).List), ctx)
+}
+
+// Register mocks base method.
+func (m *MockImageLibrary) Register(ctx context.Context, params *storage.RegisterImageParams) (*storage.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, params)
+	ret0, _ := ret[0].(*storage.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockImageLibraryMockRecorder) Register(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockImageLibrary)(nil).Register), ctx, params)
+}
+
+// Update mocks base method.
+func (m *MockImageLibrary) Update(ctx context.Context, name string, params *storage.UpdateImageParams) (*storage.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, name, params)
+	ret0, _ := ret[0].(*storage.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockImageLibraryMockRecorder) Update(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockImageLibrary)(nil).Update), ctx, name, params)
+}
+
+// MockCapacityMonitor is a mock of CapacityMonitor interface.
+type MockCapacityMonitor struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockCapacityMonitorMockRecorder
+}
+
+// MockCapacityMonitorMockRecorder is the mock recorder for MockCapacityMonitor.
+type MockCapacityMonitorMockRecorder struct {
+	mock *MockCapacityMonitor
+}
+
+// NewMockCapacityMonitor creates a new mock instance.
+func NewMockCapacityMonitor(ctrl *gomock.Controller) *MockCapacityMonitor {
+	mock := &MockCapacityMonitor{ctrl: ctrl}
+	mock.recorder = &MockCapacityMonitorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCapacityMonitor) EXPECT() *MockCapacityMonitorMockRecorder {
+	return m.recorder
+}
+
+// Collect mocks base method.
+func (m *MockCapacityMonitor) Collect(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Collect", ctx)
+}
+
+// Collect indicates an expected call of Collect.
+func (mr *MockCapacityMonitorMockRecorder) Collect(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Collect", reflect.TypeOf((*MockCapacityMonitor)(nil).Collect), ctx)
+}
+
+// Events mocks base method.
+func (m *MockCapacityMonitor) Events(host, pool string) []*storage.PoolCapacityEvent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events", host, pool)
+	ret0, _ := ret[0].([]*storage.PoolCapacityEvent)
+	return ret0
+}
+
+// Events indicates an expected call of Events.
+func (mr *MockCapacityMonitorMockRecorder) Events(host, pool any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockCapacityMonitor)(nil).Events), host, pool)
+}
+
+// History mocks base method.
+func (m *MockCapacityMonitor) History(host, pool string) []*storage.PoolUsage {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", host, pool)
+	ret0, _ := ret[0].([]*storage.PoolUsage)
+	return ret0
+}
+
+// History indicates an expected call of History.
+func (mr *MockCapacityMonitorMockRecorder) History(host, pool any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockCapacityMonitor)(nil).History), host, pool)
+}
+
+// Latest mocks base method.
+func (m *MockCapacityMonitor) Latest() []*storage.PoolUsage {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Latest")
+	ret0, _ := ret[0].([]*storage.PoolUsage)
+	return ret0
+}
+
+// Latest indicates an expected call of Latest.
+func (mr *MockCapacityMonitorMockRecorder) Latest() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Latest", reflect.TypeOf((*MockCapacityMonitor)(nil).Latest))
+}
+
+// Sample mocks base method.
+func (m *MockCapacityMonitor) Sample(ctx context.Context, pool string) (*storage.PoolUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sample", ctx, pool)
+	ret0, _ := ret[0].(*storage.PoolUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sample indicates an expected call of Sample.
+func (mr *MockCapacityMonitorMockRecorder) Sample(ctx, pool any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sample", reflect.TypeOf((*MockCapacityMonitor)(nil).Sample), ctx, pool)
+}
+
+// Start mocks base method.
+func (m *MockCapacityMonitor) Start(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start", ctx, interval)
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockCapacityMonitorMockRecorder) Start(ctx, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockCapacityMonitor)(nil).Start), ctx, interval)
+}
+
+// MockVolumeKeyManager is a mock of VolumeKeyManager interface.
+type MockVolumeKeyManager struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockVolumeKeyManagerMockRecorder
+}
+
+// MockVolumeKeyManagerMockRecorder is the mock recorder for MockVolumeKeyManager.
+type MockVolumeKeyManagerMockRecorder struct {
+	mock *MockVolumeKeyManager
+}
+
+// NewMockVolumeKeyManager creates a new mock instance.
+func NewMockVolumeKeyManager(ctrl *gomock.Controller) *MockVolumeKeyManager {
+	mock := &MockVolumeKeyManager{ctrl: ctrl}
+	mock.recorder = &MockVolumeKeyManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVolumeKeyManager) EXPECT() *MockVolumeKeyManagerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockVolumeKeyManager) Create(ctx context.Context, pool, volume string) (*storage.VolumeKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, pool, volume)
+	ret0, _ := ret[0].(*storage.VolumeKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockVolumeKeyManagerMockRecorder) Create(ctx, pool, volume any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockVolumeKeyManager)(nil).Create), ctx, pool, volume)
+}
+
+// Delete mocks base method.
+func (m *MockVolumeKeyManager) Delete(ctx context.Context, pool, volume string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, pool, volume)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockVolumeKeyManagerMockRecorder) Delete(ctx, pool, volume any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockVolumeKeyManager)(nil).Delete), ctx, pool, volume)
+}
+
+// Get mocks base method.
+func (m *MockVolumeKeyManager) Get(ctx context.Context, pool, volume string) (*storage.VolumeKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, pool, volume)
+	ret0, _ := ret[0].(*storage.VolumeKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockVolumeKeyManagerMockRecorder) Get(ctx, pool, volume any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockVolumeKeyManager)(nil).Get), ctx, pool, volume)
+}
+
+// Passphrase mocks base method.
+func (m *MockVolumeKeyManager) Passphrase(ctx context.Context, pool, volume string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Passphrase", ctx, pool, volume)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Passphrase indicates an expected call of Passphrase.
+func (mr *MockVolumeKeyManagerMockRecorder) Passphrase(ctx, pool, volume any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Passphrase", reflect.TypeOf((*MockVolumeKeyManager)(nil).Passphrase), ctx, pool, volume)
+}
+
+// Rewrap mocks base method.
+func (m *MockVolumeKeyManager) Rewrap(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rewrap", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rewrap indicates an expected call of Rewrap.
+func (mr *MockVolumeKeyManagerMockRecorder) Rewrap(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rewrap", reflect.TypeOf((*MockVolumeKeyManager)(nil).Rewrap), ctx)
+}
+
+// SetPassphrase mocks base method.
+func (m *MockVolumeKeyManager) SetPassphrase(ctx context.Context, pool, volume string, passphrase []byte) (*storage.VolumeKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPassphrase", ctx, pool, volume, passphrase)
+	ret0, _ := ret[0].(*storage.VolumeKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPassphrase indicates an expected call of SetPassphrase.
+func (mr *MockVolumeKeyManagerMockRecorder) SetPassphrase(ctx, pool, volume, passphrase any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPassphrase", reflect.TypeOf((*MockVolumeKeyManager)(nil).SetPassphrase), ctx, pool, volume, passphrase)
+}
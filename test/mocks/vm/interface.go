@@ -11,6 +11,7 @@ package mocks_vm
 
 import (
 	context "context"
+	net "net"
 	reflect "reflect"
 
 	vm "github.com/threatflux/libgo/internal/models/vm"
@@ -41,6 +42,65 @@ func (m *MockManager) EXPECT() *MockManagerMockRecorder {
 	return m.recorder
 }
 
+// AbortBlockJob mocks base method.
+func (m *MockManager) AbortBlockJob(ctx context.Context, name, device string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortBlockJob", ctx, name, device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortBlockJob indicates an expected call of AbortBlockJob.
+func (mr *MockManagerMockRecorder) AbortBlockJob(ctx, name, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortBlockJob", reflect.TypeOf((*MockManager)(nil).AbortBlockJob), ctx, name, device)
+}
+
+// BlockCommit mocks base method.
+func (m *MockManager) BlockCommit(ctx context.Context, name string, params vm.BlockCommitParams) (*vm.BlockJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockCommit", ctx, name, params)
+	ret0, _ := ret[0].(*vm.BlockJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockCommit indicates an expected call of BlockCommit.
+func (mr *MockManagerMockRecorder) BlockCommit(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockCommit", reflect.TypeOf((*MockManager)(nil).BlockCommit), ctx, name, params)
+}
+
+// BlockPull mocks base method.
+func (m *MockManager) BlockPull(ctx context.Context, name string, params vm.BlockPullParams) (*vm.BlockJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockPull", ctx, name, params)
+	ret0, _ := ret[0].(*vm.BlockJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockPull indicates an expected call of BlockPull.
+func (mr *MockManagerMockRecorder) BlockPull(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockPull", reflect.TypeOf((*MockManager)(nil).BlockPull), ctx, name, params)
+}
+
+// Clone mocks base method.
+func (m *MockManager) Clone(ctx context.Context, sourceName string, params vm.CloneParams) (*vm.VM, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clone", ctx, sourceName, params)
+	ret0, _ := ret[0].(*vm.VM)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Clone indicates an expected call of Clone.
+func (mr *MockManagerMockRecorder) Clone(ctx, sourceName, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockManager)(nil).Clone), ctx, sourceName, params)
+}
+
 // Create mocks base method.
 func (m *MockManager) Create(ctx context.Context, params vm.VMParams) (*vm.VM, error) {
 	m.ctrl.T.Helper()
@@ -99,6 +159,35 @@ func (mr *MockManagerMockRecorder) DeleteSnapshot(ctx, vmName, snapshotName any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshot", reflect.TypeOf((*MockManager)(nil).DeleteSnapshot), ctx, vmName, snapshotName)
 }
 
+// ExecGuestCommand mocks base method.
+func (m *MockManager) ExecGuestCommand(ctx context.Context, name string, command vm.GuestCommand) (*vm.GuestCommandResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecGuestCommand", ctx, name, command)
+	ret0, _ := ret[0].(*vm.GuestCommandResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecGuestCommand indicates an expected call of ExecGuestCommand.
+func (mr *MockManagerMockRecorder) ExecGuestCommand(ctx, name, command any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecGuestCommand", reflect.TypeOf((*MockManager)(nil).ExecGuestCommand), ctx, name, command)
+}
+
+// ForceStop mocks base method.
+func (m *MockManager) ForceStop(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceStop", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceStop indicates an expected call of ForceStop.
+func (mr *MockManagerMockRecorder) ForceStop(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceStop", reflect.TypeOf((*MockManager)(nil).ForceStop), ctx, name)
+}
+
 // Get mocks base method.
 func (m *MockManager) Get(ctx context.Context, name string) (*vm.VM, error) {
 	m.ctrl.T.Helper()
@@ -114,6 +203,96 @@ func (mr *MockManagerMockRecorder) Get(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockManager)(nil).Get), ctx, name)
 }
 
+// GetDefinition mocks base method.
+func (m *MockManager) GetDefinition(ctx context.Context, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefinition", ctx, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDefinition indicates an expected call of GetDefinition.
+func (mr *MockManagerMockRecorder) GetDefinition(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefinition", reflect.TypeOf((*MockManager)(nil).GetDefinition), ctx, name)
+}
+
+// GetDetails mocks base method.
+func (m *MockManager) GetDetails(ctx context.Context, name string) (*vm.VM, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDetails", ctx, name)
+	ret0, _ := ret[0].(*vm.VM)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDetails indicates an expected call of GetDetails.
+func (mr *MockManagerMockRecorder) GetDetails(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDetails", reflect.TypeOf((*MockManager)(nil).GetDetails), ctx, name)
+}
+
+// GetGraphics mocks base method.
+func (m *MockManager) GetGraphics(ctx context.Context, name string) ([]vm.GraphicsInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGraphics", ctx, name)
+	ret0, _ := ret[0].([]vm.GraphicsInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGraphics indicates an expected call of GetGraphics.
+func (mr *MockManagerMockRecorder) GetGraphics(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGraphics", reflect.TypeOf((*MockManager)(nil).GetGraphics), ctx, name)
+}
+
+// GetGuestInfo mocks base method.
+func (m *MockManager) GetGuestInfo(ctx context.Context, name string) (*vm.GuestInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGuestInfo", ctx, name)
+	ret0, _ := ret[0].(*vm.GuestInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGuestInfo indicates an expected call of GetGuestInfo.
+func (mr *MockManagerMockRecorder) GetGuestInfo(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGuestInfo", reflect.TypeOf((*MockManager)(nil).GetGuestInfo), ctx, name)
+}
+
+// GetJob mocks base method.
+func (m *MockManager) GetJob(ctx context.Context, id string) (*vm.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(*vm.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockManagerMockRecorder) GetJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockManager)(nil).GetJob), ctx, id)
+}
+
+// GetMetrics mocks base method.
+func (m *MockManager) GetMetrics(ctx context.Context, name string) (*vm.Metrics, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetrics", ctx, name)
+	ret0, _ := ret[0].(*vm.Metrics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetrics indicates an expected call of GetMetrics.
+func (mr *MockManagerMockRecorder) GetMetrics(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetrics", reflect.TypeOf((*MockManager)(nil).GetMetrics), ctx, name)
+}
+
 // GetSnapshot mocks base method.
 func (m *MockManager) GetSnapshot(ctx context.Context, vmName, snapshotName string) (*vm.Snapshot, error) {
 	m.ctrl.T.Helper()
@@ -144,6 +323,36 @@ func (mr *MockManagerMockRecorder) List(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockManager)(nil).List), ctx)
 }
 
+// ListBlockJobs mocks base method.
+func (m *MockManager) ListBlockJobs(ctx context.Context, name string) ([]vm.BlockJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlockJobs", ctx, name)
+	ret0, _ := ret[0].([]vm.BlockJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlockJobs indicates an expected call of ListBlockJobs.
+func (mr *MockManagerMockRecorder) ListBlockJobs(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlockJobs", reflect.TypeOf((*MockManager)(nil).ListBlockJobs), ctx, name)
+}
+
+// ListJobs mocks base method.
+func (m *MockManager) ListJobs(ctx context.Context) ([]*vm.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJobs", ctx)
+	ret0, _ := ret[0].([]*vm.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJobs indicates an expected call of ListJobs.
+func (mr *MockManagerMockRecorder) ListJobs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobs", reflect.TypeOf((*MockManager)(nil).ListJobs), ctx)
+}
+
 // ListSnapshots mocks base method.
 func (m *MockManager) ListSnapshots(ctx context.Context, vmName string, opts vm.SnapshotListOptions) ([]*vm.Snapshot, error) {
 	m.ctrl.T.Helper()
@@ -159,18 +368,105 @@ func (mr *MockManagerMockRecorder) ListSnapshots(ctx, vmName, opts any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshots", reflect.TypeOf((*MockManager)(nil).ListSnapshots), ctx, vmName, opts)
 }
 
-// Restart mocks base method.
-func (m *MockManager) Restart(ctx context.Context, name string) error {
+// OpenConsole mocks base method.
+func (m *MockManager) OpenConsole(ctx context.Context, name string, opts vm.ConsoleOptions) (vm.ConsoleStream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenConsole", ctx, name, opts)
+	ret0, _ := ret[0].(vm.ConsoleStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenConsole indicates an expected call of OpenConsole.
+func (mr *MockManagerMockRecorder) OpenConsole(ctx, name, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenConsole", reflect.TypeOf((*MockManager)(nil).OpenConsole), ctx, name, opts)
+}
+
+// OpenGraphics mocks base method.
+func (m *MockManager) OpenGraphics(ctx context.Context, name string, graphicsType vm.GraphicsType) (net.Conn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenGraphics", ctx, name, graphicsType)
+	ret0, _ := ret[0].(net.Conn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenGraphics indicates an expected call of OpenGraphics.
+func (mr *MockManagerMockRecorder) OpenGraphics(ctx, name, graphicsType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenGraphics", reflect.TypeOf((*MockManager)(nil).OpenGraphics), ctx, name, graphicsType)
+}
+
+// Pause mocks base method.
+func (m *MockManager) Pause(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Restart", ctx, name)
+	ret := m.ctrl.Call(m, "Pause", ctx, name)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// Pause indicates an expected call of Pause.
+func (mr *MockManagerMockRecorder) Pause(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockManager)(nil).Pause), ctx, name)
+}
+
+// Reboot mocks base method.
+func (m *MockManager) Reboot(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reboot", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reboot indicates an expected call of Reboot.
+func (mr *MockManagerMockRecorder) Reboot(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reboot", reflect.TypeOf((*MockManager)(nil).Reboot), ctx, name)
+}
+
+// Reset mocks base method.
+func (m *MockManager) Reset(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockManagerMockRecorder) Reset(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockManager)(nil).Reset), ctx, name)
+}
+
+// Restart mocks base method.
+func (m *MockManager) Restart(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.PowerResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restart", ctx, name, opts)
+	ret0, _ := ret[0].(*vm.PowerResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Restart indicates an expected call of Restart.
-func (mr *MockManagerMockRecorder) Restart(ctx, name any) *gomock.Call {
+func (mr *MockManagerMockRecorder) Restart(ctx, name, opts any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restart", reflect.TypeOf((*MockManager)(nil).Restart), ctx, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restart", reflect.TypeOf((*MockManager)(nil).Restart), ctx, name, opts)
+}
+
+// Resume mocks base method.
+func (m *MockManager) Resume(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockManagerMockRecorder) Resume(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockManager)(nil).Resume), ctx, name)
 }
 
 // RevertSnapshot mocks base method.
@@ -187,6 +483,64 @@ func (mr *MockManagerMockRecorder) RevertSnapshot(ctx, vmName, snapshotName any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertSnapshot", reflect.TypeOf((*MockManager)(nil).RevertSnapshot), ctx, vmName, snapshotName)
 }
 
+// SendKeys mocks base method.
+func (m *MockManager) SendKeys(ctx context.Context, name string, keys []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendKeys", ctx, name, keys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendKeys indicates an expected call of SendKeys.
+func (mr *MockManagerMockRecorder) SendKeys(ctx, name, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendKeys", reflect.TypeOf((*MockManager)(nil).SendKeys), ctx, name, keys)
+}
+
+// SetDiskIOTune mocks base method.
+func (m *MockManager) SetDiskIOTune(ctx context.Context, name, device string, tune vm.DiskIOTune) (*vm.DiskInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDiskIOTune", ctx, name, device, tune)
+	ret0, _ := ret[0].(*vm.DiskInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetDiskIOTune indicates an expected call of SetDiskIOTune.
+func (mr *MockManagerMockRecorder) SetDiskIOTune(ctx, name, device, tune any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDiskIOTune", reflect.TypeOf((*MockManager)(nil).SetDiskIOTune), ctx, name, device, tune)
+}
+
+// SetGuestPassword mocks base method.
+func (m *MockManager) SetGuestPassword(ctx context.Context, name, username, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetGuestPassword", ctx, name, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGuestPassword indicates an expected call of SetGuestPassword.
+func (mr *MockManagerMockRecorder) SetGuestPassword(ctx, name, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGuestPassword", reflect.TypeOf((*MockManager)(nil).SetGuestPassword), ctx, name, username, password)
+}
+
+// Shutdown mocks base method.
+func (m *MockManager) Shutdown(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.PowerResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shutdown", ctx, name, opts)
+	ret0, _ := ret[0].(*vm.PowerResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Shutdown indicates an expected call of Shutdown.
+func (mr *MockManagerMockRecorder) Shutdown(ctx, name, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockManager)(nil).Shutdown), ctx, name, opts)
+}
+
 // Start mocks base method.
 func (m *MockManager) Start(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -201,6 +555,66 @@ func (mr *MockManagerMockRecorder) Start(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockManager)(nil).Start), ctx, name)
 }
 
+// StartClone mocks base method.
+func (m *MockManager) StartClone(ctx context.Context, sourceName string, params vm.CloneParams) (*vm.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartClone", ctx, sourceName, params)
+	ret0, _ := ret[0].(*vm.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartClone indicates an expected call of StartClone.
+func (mr *MockManagerMockRecorder) StartClone(ctx, sourceName, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartClone", reflect.TypeOf((*MockManager)(nil).StartClone), ctx, sourceName, params)
+}
+
+// StartDeleteSnapshot mocks base method.
+func (m *MockManager) StartDeleteSnapshot(ctx context.Context, vmName, snapshotName string) (*vm.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartDeleteSnapshot", ctx, vmName, snapshotName)
+	ret0, _ := ret[0].(*vm.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartDeleteSnapshot indicates an expected call of StartDeleteSnapshot.
+func (mr *MockManagerMockRecorder) StartDeleteSnapshot(ctx, vmName, snapshotName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartDeleteSnapshot", reflect.TypeOf((*MockManager)(nil).StartDeleteSnapshot), ctx, vmName, snapshotName)
+}
+
+// StartRestart mocks base method.
+func (m *MockManager) StartRestart(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartRestart", ctx, name, opts)
+	ret0, _ := ret[0].(*vm.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartRestart indicates an expected call of StartRestart.
+func (mr *MockManagerMockRecorder) StartRestart(ctx, name, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartRestart", reflect.TypeOf((*MockManager)(nil).StartRestart), ctx, name, opts)
+}
+
+// StartShutdown mocks base method.
+func (m *MockManager) StartShutdown(ctx context.Context, name string, opts vm.ShutdownOptions) (*vm.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartShutdown", ctx, name, opts)
+	ret0, _ := ret[0].(*vm.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartShutdown indicates an expected call of StartShutdown.
+func (mr *MockManagerMockRecorder) StartShutdown(ctx, name, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartShutdown", reflect.TypeOf((*MockManager)(nil).StartShutdown), ctx, name, opts)
+}
+
 // Stop mocks base method.
 func (m *MockManager) Stop(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -214,3 +628,33 @@ func (mr *MockManagerMockRecorder) Stop(ctx, name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockManager)(nil).Stop), ctx, name)
 }
+
+// Update mocks base method.
+func (m *MockManager) Update(ctx context.Context, name string, params vm.UpdateParams) (*vm.DefinitionUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, name, params)
+	ret0, _ := ret[0].(*vm.DefinitionUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockManagerMockRecorder) Update(ctx, name, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockManager)(nil).Update), ctx, name, params)
+}
+
+// UpdateDefinition mocks base method.
+func (m *MockManager) UpdateDefinition(ctx context.Context, name, definition string) (*vm.DefinitionUpdate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDefinition", ctx, name, definition)
+	ret0, _ := ret[0].(*vm.DefinitionUpdate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDefinition indicates an expected call of UpdateDefinition.
+func (mr *MockManagerMockRecorder) UpdateDefinition(ctx, name, definition any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDefinition", reflect.TypeOf((*MockManager)(nil).UpdateDefinition), ctx, name, definition)
+}
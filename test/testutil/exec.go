@@ -0,0 +1,18 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/threatflux/libgo/pkg/utils/exec"
+)
+
+// StubCommands runs the commands a test executes, such as qemu-img, through
+// run instead of starting them. The previous command execution is restored
+// when the test finished.
+func StubCommands(t testing.TB, run exec.ExecuteCommandFunc) {
+	t.Helper()
+
+	original := exec.ExecuteCommand
+	t.Cleanup(func() { exec.ExecuteCommand = original })
+	exec.ExecuteCommand = run
+}
@@ -0,0 +1,17 @@
+// Package testutil holds fixtures shared by the unit tests of several
+// packages.
+package testutil
+
+import "context"
+
+// RemoteHostAddress is the address RemoteHost reports.
+const RemoteHostAddress = "hv2.example.com"
+
+// RemoteHost is a connection.HostLocator that selects a libvirt host on
+// another machine for every operation.
+type RemoteHost struct{}
+
+// HostAddress implements connection.HostLocator.
+func (RemoteHost) HostAddress(context.Context) string {
+	return RemoteHostAddress
+}
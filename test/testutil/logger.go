@@ -0,0 +1,19 @@
+package testutil
+
+import (
+	"go.uber.org/mock/gomock"
+
+	mocks_logger "github.com/threatflux/libgo/test/mocks/logger"
+)
+
+// NewLogger creates a mock logger that accepts any number of messages on
+// every level, for tests that do not check what is logged.
+func NewLogger(ctrl *gomock.Controller) *mocks_logger.MockLogger {
+	mockLogger := mocks_logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	return mockLogger
+}